@@ -4,72 +4,468 @@ package main
 
 import (
 	"context"
-	"log"
+	"crypto/tls"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/cassiascheffer/uplift/internal/abuse"
+	"github.com/cassiascheffer/uplift/internal/admin"
+	"github.com/cassiascheffer/uplift/internal/analytics"
+	"github.com/cassiascheffer/uplift/internal/api"
+	"github.com/cassiascheffer/uplift/internal/apikeys"
+	"github.com/cassiascheffer/uplift/internal/branding"
+	"github.com/cassiascheffer/uplift/internal/certs"
+	"github.com/cassiascheffer/uplift/internal/cors"
+	"github.com/cassiascheffer/uplift/internal/dictation"
+	"github.com/cassiascheffer/uplift/internal/export"
+	"github.com/cassiascheffer/uplift/internal/features"
+	"github.com/cassiascheffer/uplift/internal/gifs"
+	"github.com/cassiascheffer/uplift/internal/invites"
+	"github.com/cassiascheffer/uplift/internal/joinqr"
+	"github.com/cassiascheffer/uplift/internal/leader"
+	"github.com/cassiascheffer/uplift/internal/logging"
+	"github.com/cassiascheffer/uplift/internal/moderation"
+	"github.com/cassiascheffer/uplift/internal/notifications"
+	"github.com/cassiascheffer/uplift/internal/push"
+	"github.com/cassiascheffer/uplift/internal/ratelimit"
+	"github.com/cassiascheffer/uplift/internal/redis"
+	"github.com/cassiascheffer/uplift/internal/secrets"
 	"github.com/cassiascheffer/uplift/internal/session"
+	"github.com/cassiascheffer/uplift/internal/starters"
+	"github.com/cassiascheffer/uplift/internal/systemd"
+	"github.com/cassiascheffer/uplift/internal/wall"
 	"github.com/cassiascheffer/uplift/internal/websocket"
 )
 
+// fatal logs msg with its attributes and exits, for problems the server can't start or run with
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func main() {
+	dbPath := flag.String("db", "", "SQLite file to keep sessions in across restarts, e.g. uplift.db")
+	flag.Parse()
+
+	// Structured logs first, so everything after goes through them
+	if err := logging.Configure(os.Stderr, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT")); err != nil {
+		fatal("Invalid logging config", "err", err)
+	}
+
+	// Terminate TLS directly when given certificate files or domains to obtain certificates for
+	tlsConfig, certManager := tlsSetup()
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
+		if tlsConfig != nil {
+			port = "443"
+		}
 	}
 
 	// Create context that will be cancelled on SIGINT/SIGTERM
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	// Create session manager
+	// Create session manager, keeping sessions in memory, in SQLite (--db) or in Redis (SESSION_STORE)
+	sessionStore, leases := sessionStorage(ctx, *dbPath)
 	sessionManager := session.NewManager()
+	if sessionStore != nil {
+		sessionManager = session.NewManagerWithStore(sessionStore)
+		go sessionStore.Run(ctx)
+	}
 
-	// Start session cleanup routine in background with cancellable context
-	go sessionManager.StartCleanupRoutine(ctx)
+	// Configure the length and alphabet of session codes
+	if format, changed := codeFormat(); changed {
+		sessionManager.SetCodeFormat(format)
+	}
+
+	// Start session cleanup routine in background with cancellable context.
+	// It runs under a lease so that with sessions in a shared store only one
+	// node cleans up; with in-memory sessions each node holds its own lease.
+	go leader.NewElector(leases, "session-cleanup", nodeID(), 30*time.Second).Run(ctx, sessionManager.StartCleanupRoutine)
 
 	// Create WebSocket hub
 	hub := websocket.NewHub(nil)
 
+	// Create usage analytics collector
+	collector := analytics.NewCollector()
+
+	// Track circles per host for the facilitator dashboard
+	hostHistory := analytics.NewHostHistory()
+
+	// Load feature flags (FEATURE_FLAGS overrides the defaults)
+	flags, err := features.NewFlags(os.Getenv("FEATURE_FLAGS"))
+	if err != nil {
+		fatal("Invalid FEATURE_FLAGS", "err", err)
+	}
+
 	// Create message handler
 	messageHandler := websocket.NewMessageHandler(hub, sessionManager)
+	if sessionStore != nil {
+		sessionStore.SetLoadHandler(messageHandler.AdoptSession)
+	}
+
+	// Without a persistent store, optionally save sessions to a file and pick them back up on restart
+	snapshotPath, snapshotInterval := snapshotConfig(sessionStore != nil)
+	if snapshotPath != "" {
+		restored, err := sessionManager.RestoreSnapshots(snapshotPath)
+		if err != nil {
+			slog.Error("Failed to restore sessions", "path", snapshotPath, "err", err)
+		}
+		for _, sess := range restored {
+			messageHandler.AdoptSession(sess)
+		}
+		slog.Info("Session snapshots enabled", "path", snapshotPath, "interval", snapshotInterval, "restored", len(restored))
+		go sessionManager.RunSnapshots(ctx, snapshotPath, snapshotInterval)
+	}
+	messageHandler.SetAnalytics(collector)
+	messageHandler.SetFeatures(flags)
+	messageHandler.SetHostHistory(hostHistory)
+	dispatcher, webPush := notificationDispatcher(ctx)
+	if dispatcher != nil {
+		messageHandler.SetNotifications(dispatcher)
+	}
+	if searcher := gifSearcher(); searcher != nil {
+		messageHandler.SetGIFs(searcher)
+	}
+	messageHandler.SetModeration(moderationFilter())
+
+	// Require proof-of-work before session creation if configured
+	if value := os.Getenv("SESSION_CHALLENGE_DIFFICULTY"); value != "" {
+		difficulty, err := strconv.Atoi(value)
+		if err != nil {
+			fatal("Invalid SESSION_CHALLENGE_DIFFICULTY", "err", err)
+		}
+		if difficulty > 0 {
+			challenger, err := abuse.NewChallenger(difficulty)
+			if err != nil {
+				fatal("Failed to create session challenger", "err", err)
+			}
+			messageHandler.SetChallenger(challenger)
+			slog.Info("Session creation challenge enabled", "difficulty", difficulty)
+		}
+	}
 
 	// Set the message handler on the hub
 	hub.SetMessageHandler(messageHandler.HandleMessage)
 
 	// Set the disconnect handler on the hub
+	hub.SetConnectHandler(messageHandler.HandleClientConnect)
 	hub.SetDisconnectHandler(messageHandler.HandleClientDisconnect)
 
+	// Let admins attach read-only observers to live sessions
+	hub.SetObserverHandler(messageHandler.AttachObserver)
+
+	// Configure what happens when a slow client's send buffer fills
+	if value := os.Getenv("SEND_QUEUE_POLICY"); value != "" {
+		policy, err := websocket.ParseDropPolicy(value)
+		if err != nil {
+			fatal("Invalid SEND_QUEUE_POLICY", "err", err)
+		}
+		hub.SetDropPolicy(policy)
+	}
+
+	// Configure inactivity timeouts (hosts may be given longer)
+	hub.SetHostChecker(messageHandler.IsHost)
+	if err := hub.SetInactivity(inactivityConfig()); err != nil {
+		fatal("Invalid inactivity config", "err", err)
+	}
+
+	// Name this instance so sticky load balancers can keep a session's clients together
+	if instanceID := os.Getenv("INSTANCE_ID"); instanceID != "" {
+		hub.SetInstanceID(instanceID)
+		slog.Info("Load balancer affinity enabled", "instanceID", instanceID)
+	}
+
+	// Keep recent unprocessable messages for diagnosis (DEAD_LETTER_SIZE=0 disables)
+	deadLetterSize := websocket.DefaultDeadLetterSize
+	if value := os.Getenv("DEAD_LETTER_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			fatal("Invalid DEAD_LETTER_SIZE", "value", value)
+		}
+		deadLetterSize = size
+	}
+	hub.SetDeadLetters(websocket.NewDeadLetters(deadLetterSize))
+
+	// Believe X-Forwarded-For only from these proxies when telling clients apart by address
+	if value := os.Getenv("TRUSTED_PROXIES"); value != "" {
+		proxies, err := abuse.ParseTrustedProxies(value)
+		if err != nil {
+			fatal("Invalid TRUSTED_PROXIES", "err", err)
+		}
+		abuse.SetTrustedProxies(proxies)
+		slog.Info("Trusting forwarded client addresses", "proxyRanges", len(proxies))
+	}
+
+	// Cap how many connections one address may hold open (WS_CONNECTIONS_PER_IP=0 disables)
+	connectionsPerIP := 200
+	if value := os.Getenv("WS_CONNECTIONS_PER_IP"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			fatal("Invalid WS_CONNECTIONS_PER_IP", "value", value)
+		}
+		connectionsPerIP = limit
+	}
+
+	// Stop one client flooding the server with connections, messages or new sessions
+	hub.SetRateLimits(websocket.RateLimits{
+		Upgrades:    rateLimiter("WS_CONNECT_RATE_LIMIT", "30/m"),
+		Messages:    rateLimiter("WS_MESSAGE_RATE_LIMIT", "20/s"),
+		Creates:     rateLimiter("WS_CREATE_RATE_LIMIT", "10/m"),
+		Sessions:    rateLimiter("SESSION_RATE_LIMIT", "30/h"),
+		Connections: connectionsPerIP,
+	})
+
+	// Inject faults for soak testing (only in binaries built with -tags chaos)
+	if value := os.Getenv("CHAOS"); value != "" {
+		config, err := websocket.ParseChaosConfig(value)
+		if err != nil {
+			fatal("Invalid CHAOS", "err", err)
+		}
+		if err := hub.SetChaos(config); err != nil {
+			fatal("Invalid CHAOS", "err", err)
+		}
+		slog.Warn("CHAOS MODE ENABLED", "config", fmt.Sprintf("%+v", config))
+	}
+
+	// Load per-tenant branding for white-labelled deployments (BRANDING_FILE)
+	brands, err := branding.Load(os.Getenv("BRANDING_FILE"))
+	if err != nil {
+		fatal("Invalid BRANDING_FILE", "err", err)
+	}
+	messageHandler.SetBranding(brands)
+
+	// Sentence starters offered while writing, managed per tenant via the admin API (persisted to STARTERS_FILE if set)
+	sentenceStarters, err := starters.NewStore(os.Getenv("STARTERS_FILE"))
+	if err != nil {
+		fatal("Failed to load sentence starters", "err", err)
+	}
+	messageHandler.SetStarters(sentenceStarters)
+
+	// Frontend build currently deployed; clients reporting another build are asked to refresh
+	messageHandler.SetAssetVersion(os.Getenv("ASSET_VERSION"))
+
+	// Public gratitude walls for notes authors agreed to share (kept in memory)
+	walls := wall.NewStore()
+	messageHandler.SetWalls(walls)
+
+	// Sign invite links hosts share
+	messageHandler.SetInvites(inviteSigner())
+
+	// Keepsake downloads of completed circles' notes (kept in memory for EXPORT_RETENTION)
+	var exportRetention time.Duration
+	if value := os.Getenv("EXPORT_RETENTION"); value != "" {
+		exportRetention, err = time.ParseDuration(value)
+		if err != nil || exportRetention <= 0 {
+			fatal("Invalid EXPORT_RETENTION", "value", value)
+		}
+	}
+	exports := export.NewStore(exportRetention)
+	messageHandler.SetExports(exports)
+
+	// Relay broadcasts between replicas so a session's clients may connect to any of them
+	switch backplane := os.Getenv("HUB_BACKPLANE"); backplane {
+	case "", "none":
+	case "redis":
+		client, prefix := redisClient("HUB_BACKPLANE=redis")
+		relay := websocket.NewBackplane(client, prefix, nodeID())
+		hub.SetBackplane(relay)
+		go relay.Run(ctx, hub)
+		slog.Info("Hub backplane enabled", "redisPrefix", prefix)
+	default:
+		fatal("Invalid HUB_BACKPLANE", "value", backplane)
+	}
+
 	// Start hub in background
 	go hub.Run()
 
+	// Load IP ban list (persisted to BAN_LIST_FILE if set)
+	bans, err := abuse.NewBanList(os.Getenv("BAN_LIST_FILE"))
+	if err != nil {
+		fatal("Failed to load ban list", "err", err)
+	}
+
 	// Create WebSocket handler
 	wsHandler := websocket.NewHandler(hub)
+	if err := wsHandler.SetCompression(compressionConfig()); err != nil {
+		fatal("Invalid WebSocket compression config", "err", err)
+	}
+	wsHandler.SetBranding(brands)
+
+	// Load the admin token from env, a file or Vault, re-reading it so rotations apply
+	adminToken, err := secrets.Load("ADMIN_TOKEN")
+	if err != nil {
+		fatal("Failed to load ADMIN_TOKEN", "err", err)
+	}
+	go adminToken.Watch(ctx, secretsRefreshInterval())
+
+	// Create admin API handler
+	adminAPI := admin.NewHandler(adminToken.Value(), collector, bans)
+	adminAPI.SetTokenSource(adminToken.Value)
+	adminAPI.SetMetrics(func() interface{} { return hub.Metrics() })
+	adminAPI.SetDeadLetters(func() interface{} { return hub.DeadLetters().Snapshot() })
+	adminAPI.SetStarters(sentenceStarters)
+	adminAPI.SetFeatures(flags)
+	adminAPI.SetObserver(wsHandler.ServeObserver)
+	adminAPI.SetSessions(sessionManager)
+	adminAPI.SetMerger(messageHandler.MergeSessions)
+	adminAPI.SetRollbacker(messageHandler.RollbackSession)
+	adminAPI.SetCompleter(messageHandler.ForceCompleteSession)
+	adminAPI.SetCloser(messageHandler.CloseSession)
+	adminAPI.SetKicker(messageHandler.KickParticipant)
+	adminAPI.SetConnected(hub.ConnectedUserIDs)
+
+	// Let integrations call the admin API with scoped keys (persisted to API_KEYS_FILE if set)
+	keys, err := apikeys.NewStore(os.Getenv("API_KEYS_FILE"))
+	if err != nil {
+		fatal("Failed to load API keys", "err", err)
+	}
+	adminAPI.SetAPIKeys(keys)
+	adminAPI.SetKeyRateLimit(rateLimiter("API_KEY_RATE_LIMIT", "600/m"))
+	if dispatcher != nil {
+		adminAPI.SetNotifications(dispatcher)
+	}
+	// Limit each source IP across the HTTP API
+	ipLimiter := rateLimiter("API_RATE_LIMIT", "120/m")
+	var adminHandler http.Handler = ipLimiter.Middleware(ratelimit.ClientIP, adminAPI)
+	var hostHistoryHandler http.Handler = ipLimiter.Middleware(ratelimit.ClientIP, hostHistory)
+
+	// Session API for clients that don't speak the WebSocket protocol
+	sessionAPI := api.NewHandler(sessionManager, messageHandler.CreateSession)
+	sessionAPI.SetBranding(brands)
+	sessionAPI.SetAPIKeys(keys)
+	sessionAPI.SetChallenge(messageHandler.Challenge)
+	sessionAPI.SetDraining(messageHandler.Draining)
+	var sessionAPIHandler http.Handler = bans.Middleware(ipLimiter.Middleware(ratelimit.ClientIP, sessionAPI))
+
+	// Voice dictation, with its own tighter limit since every call costs a transcription
+	var dictationHandler http.Handler
+	if provider := dictationProvider(); provider != nil {
+		dictationLimiter := rateLimiter("DICTATION_RATE_LIMIT", "20/m")
+		dictationHandler = dictationLimiter.Middleware(ratelimit.ClientIP, dictation.NewHandler(provider, messageHandler.CanDictate))
+	}
+
+	// Restrict browser origins if configured; the same allowlist applies to
+	// WebSocket upgrades and cross-origin API calls
+	if origins := cors.ParseList(os.Getenv("CORS_ALLOWED_ORIGINS")); len(origins) > 0 {
+		policy := cors.NewPolicy(origins)
+		if methods := cors.ParseList(os.Getenv("CORS_ALLOWED_METHODS")); len(methods) > 0 {
+			policy.AllowedMethods = methods
+		}
+		if headers := cors.ParseList(os.Getenv("CORS_ALLOWED_HEADERS")); len(headers) > 0 {
+			policy.AllowedHeaders = headers
+		}
+		policy.AllowCredentials = os.Getenv("CORS_ALLOW_CREDENTIALS") == "true"
+
+		wsHandler.SetCheckOrigin(policy.CheckOrigin)
+		adminHandler = policy.Middleware(adminHandler)
+		hostHistoryHandler = policy.Middleware(hostHistoryHandler)
+		sessionAPIHandler = policy.Middleware(sessionAPIHandler)
+		if dictationHandler != nil {
+			dictationHandler = policy.Middleware(dictationHandler)
+		}
+		slog.Info("CORS enabled", "origins", origins)
+	}
+
+	// QR codes of join links for hosts presenting on a shared screen
+	joinCodes := joinqr.NewHandler(sessionManager)
+	joinCodes.SetBaseURL(os.Getenv("PUBLIC_URL"))
+	joinCodes.SetInstanceID(os.Getenv("INSTANCE_ID"))
 
 	// Register routes
-	http.Handle("/ws", wsHandler)
-	http.Handle("/", http.FileServer(http.Dir("./static")))
+	serverRoutes := routes{
+		ws:          bans.Middleware(wsHandler),
+		admin:       adminHandler,
+		hostHistory: hostHistoryHandler,
+		sessionAPI:  sessionAPIHandler,
+		walls:       ipLimiter.Middleware(ratelimit.ClientIP, walls),
+		exports:     ipLimiter.Middleware(ratelimit.ClientIP, exports),
+		joinCodes:   ipLimiter.Middleware(ratelimit.ClientIP, joinCodes),
+		dictation:   dictationHandler,
+		static:      http.FileServer(http.Dir("./static")),
+	}
+	if webPush != nil {
+		serverRoutes.vapidKey = webPush
+	}
 
 	// Create HTTP server
 	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: nil, // Use DefaultServeMux
+		Addr:      ":" + port,
+		Handler:   newServeMux(serverRoutes),
+		TLSConfig: tlsConfig,
+	}
+
+	// How long shutdown may wait for circles that are reading aloud
+	drainTimeout, restartEstimate := drainConfig()
+
+	// Listen before reporting readiness so systemd only sees READY once we accept connections
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		fatal("Failed to listen", "err", err)
 	}
 
 	// Start server in background
 	go func() {
-		log.Printf("Starting uplift server on port %s", port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+		var err error
+		if tlsConfig != nil {
+			slog.Info("Starting uplift server with TLS", "port", port)
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			slog.Info("Starting uplift server", "port", port)
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Server failed", "err", err)
 		}
 	}()
 
+	// Redirect plain HTTP to HTTPS, answering ACME challenges on the way
+	var redirectServer *http.Server
+	if tlsConfig != nil {
+		redirectServer = httpRedirectServer(port, certManager)
+	}
+
+	// Tell systemd we're ready, and ping its watchdog while the hub loop responds
+	if err := systemd.Notify("READY=1"); err != nil {
+		slog.Warn("Failed to notify systemd", "err", err)
+	}
+	if interval := systemd.WatchdogInterval(); interval > 0 {
+		go systemd.RunWatchdog(ctx, interval, func() bool {
+			return hub.Alive(interval / 4)
+		})
+		slog.Info("Systemd watchdog enabled", "interval", interval)
+	}
+
 	// Wait for interrupt signal
 	<-ctx.Done()
-	log.Printf("Shutdown signal received, starting graceful shutdown...")
+	slog.Info("Shutdown signal received, starting graceful shutdown")
+	if err := systemd.Notify("STOPPING=1"); err != nil {
+		slog.Warn("Failed to notify systemd", "err", err)
+	}
+
+	// Refuse new sessions and let circles reading aloud finish before going away
+	if drainTimeout > 0 {
+		messageHandler.Drain(time.Now().Add(drainTimeout), restartEstimate)
+		drainCtx, drainCancel := context.WithTimeout(context.Background(), drainTimeout)
+		if messageHandler.WaitForReading(drainCtx) {
+			slog.Info("Drain complete, no circles reading")
+		} else {
+			slog.Warn("Drain deadline reached with circles still reading")
+		}
+		drainCancel()
+	}
 
 	// Create shutdown context with timeout
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -77,8 +473,552 @@ func main() {
 
 	// Attempt graceful shutdown
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+		slog.Error("Server shutdown error", "err", err)
 	} else {
-		log.Printf("Server shutdown complete")
+		slog.Info("Server shutdown complete")
+	}
+	if redirectServer != nil {
+		redirectServer.Shutdown(shutdownCtx)
+	}
+
+	// Save the latest state so circles carry on after the restart
+	if sessionStore != nil {
+		sessionStore.Flush(shutdownCtx)
+	}
+	if snapshotPath != "" {
+		if count, err := sessionManager.SaveSnapshots(snapshotPath); err != nil {
+			slog.Error("Failed to save sessions", "path", snapshotPath, "err", err)
+		} else {
+			slog.Info("Sessions saved", "path", snapshotPath, "sessions", count)
+		}
+	}
+}
+
+// compressionConfig reads the WebSocket compression policy from the environment
+func compressionConfig() websocket.CompressionConfig {
+	config := websocket.DefaultCompressionConfig()
+
+	if value := os.Getenv("WS_COMPRESSION"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			fatal("Invalid WS_COMPRESSION", "err", err)
+		}
+		config.Enabled = enabled
+	}
+	if value := os.Getenv("WS_COMPRESSION_LEVEL"); value != "" {
+		level, err := strconv.Atoi(value)
+		if err != nil {
+			fatal("Invalid WS_COMPRESSION_LEVEL", "err", err)
+		}
+		config.Level = level
+	}
+	if value := os.Getenv("WS_COMPRESSION_MIN_SIZE"); value != "" {
+		minSize, err := strconv.Atoi(value)
+		if err != nil {
+			fatal("Invalid WS_COMPRESSION_MIN_SIZE", "err", err)
+		}
+		config.MinSize = minSize
+	}
+
+	return config
+}
+
+// inactivityConfig reads the inactivity timeouts from the environment
+func inactivityConfig() websocket.InactivityConfig {
+	config := websocket.DefaultInactivityConfig()
+
+	if value := os.Getenv("INACTIVITY_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			fatal("Invalid INACTIVITY_TIMEOUT", "err", err)
+		}
+		config.Timeout = timeout
+		config.HostTimeout = timeout
+	}
+	if value := os.Getenv("HOST_INACTIVITY_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			fatal("Invalid HOST_INACTIVITY_TIMEOUT", "err", err)
+		}
+		config.HostTimeout = timeout
+	}
+	if value := os.Getenv("INACTIVITY_WARNING"); value != "" {
+		warning, err := time.ParseDuration(value)
+		if err != nil {
+			fatal("Invalid INACTIVITY_WARNING", "err", err)
+		}
+		config.Warning = warning
+	} else if shortest := min(config.Timeout, config.HostTimeout); config.Warning >= shortest {
+		// Keep the default warning inside short timeouts
+		config.Warning = shortest / 2
+	}
+
+	return config
+}
+
+// codeFormat reads the length (SESSION_CODE_LENGTH) and alphabet (SESSION_CODE_ALPHABET)
+// of session codes, reporting whether either was set
+func codeFormat() (session.CodeFormat, bool) {
+	lengthValue, alphabet := os.Getenv("SESSION_CODE_LENGTH"), os.Getenv("SESSION_CODE_ALPHABET")
+	if lengthValue == "" && alphabet == "" {
+		return session.CodeFormat{}, false
+	}
+
+	length := session.DefaultCodeLength
+	if lengthValue != "" {
+		parsed, err := strconv.Atoi(lengthValue)
+		if err != nil {
+			fatal("Invalid SESSION_CODE_LENGTH", "value", lengthValue)
+		}
+		length = parsed
+	}
+
+	format, err := session.NewCodeFormat(length, alphabet)
+	if err != nil {
+		fatal("Invalid session code format", "err", err)
+	}
+	return format, true
+}
+
+// rateLimiter reads a rate limit such as "120/m" from the environment, or uses fallback
+// "off" disables the limit
+func rateLimiter(name, fallback string) *ratelimit.Limiter {
+	value := os.Getenv(name)
+	if value == "" {
+		value = fallback
+	}
+
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		fatal("Invalid "+name, "err", err)
+	}
+	return ratelimit.NewLimiter(limit)
+}
+
+// drainConfig reads how long shutdown waits for reading circles to finish (DRAIN_TIMEOUT,
+// 0 skips draining) and how long clients are told to expect the restart to take (RESTART_ESTIMATE)
+func drainConfig() (time.Duration, time.Duration) {
+	timeout, estimate := 60*time.Second, 30*time.Second
+	if value := os.Getenv("DRAIN_TIMEOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			fatal("Invalid DRAIN_TIMEOUT", "value", value)
+		}
+		timeout = parsed
+	}
+	if value := os.Getenv("RESTART_ESTIMATE"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			fatal("Invalid RESTART_ESTIMATE", "value", value)
+		}
+		estimate = parsed
+	}
+	return timeout, estimate
+}
+
+// snapshotConfig reads where sessions are saved between restarts without a persistent
+// store (SNAPSHOT_FILE, "" for nowhere) and how often (SNAPSHOT_INTERVAL)
+func snapshotConfig(persistent bool) (string, time.Duration) {
+	path := os.Getenv("SNAPSHOT_FILE")
+	if path == "" {
+		return "", 0
+	}
+	if persistent {
+		fatal("SNAPSHOT_FILE can't be used with a persistent session store (--db or SESSION_STORE)")
+	}
+
+	interval := 10 * time.Second
+	if value := os.Getenv("SNAPSHOT_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			fatal("Invalid SNAPSHOT_INTERVAL", "value", value)
+		}
+		interval = parsed
+	}
+	return path, interval
+}
+
+// tlsSetup configures TLS from the environment: a certificate and key from TLS_CERT_FILE
+// and TLS_KEY_FILE, or certificates for TLS_DOMAINS obtained through ACME
+// Returns a nil config when TLS is left to a proxy, and the manager when ACME is used
+func tlsSetup() (*tls.Config, *certs.Manager) {
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	domains := strings.FieldsFunc(os.Getenv("TLS_DOMAINS"), func(r rune) bool { return r == ',' || r == ' ' })
+
+	switch {
+	case certFile != "" || keyFile != "":
+		if certFile == "" || keyFile == "" {
+			fatal("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+		}
+		if len(domains) > 0 {
+			fatal("TLS_DOMAINS can't be combined with TLS_CERT_FILE")
+		}
+		cert, err := certs.NewFileCertificate(certFile, keyFile)
+		if err != nil {
+			fatal("Invalid TLS certificate", "err", err)
+		}
+		slog.Info("TLS enabled", "certFile", certFile)
+		return &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: cert.GetCertificate}, nil
+	case len(domains) > 0:
+		cacheDir := os.Getenv("ACME_CACHE_DIR")
+		if cacheDir == "" {
+			cacheDir = "certs"
+		}
+		manager, err := certs.NewManager(domains, cacheDir, os.Getenv("ACME_DIRECTORY_URL"), os.Getenv("ACME_EMAIL"))
+		if err != nil {
+			fatal("Invalid ACME config", "err", err)
+		}
+		slog.Info("TLS enabled with ACME", "domains", domains, "cacheDir", cacheDir)
+		return &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: manager.GetCertificate}, manager
+	default:
+		return nil, nil
+	}
+}
+
+// httpRedirectServer listens on HTTP_REDIRECT_PORT (80 by default, "off" to disable) and
+// redirects requests to HTTPS on httpsPort, serving ACME challenges when manager is set
+func httpRedirectServer(httpsPort string, manager *certs.Manager) *http.Server {
+	port := os.Getenv("HTTP_REDIRECT_PORT")
+	if port == "" {
+		port = "80"
+	}
+	if port == "off" {
+		if manager != nil {
+			fatal("HTTP_REDIRECT_PORT can't be off with TLS_DOMAINS: ACME validates domains over HTTP")
+		}
+		return nil
+	}
+
+	handler := certs.RedirectHTTPS(httpsPort)
+	if manager != nil {
+		handler = manager.HTTPHandler(handler)
+	}
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		fatal("Failed to listen for HTTP redirects", "err", err)
+	}
+	go func() {
+		slog.Info("Redirecting HTTP to HTTPS", "port", port)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fatal("HTTP redirect server failed", "err", err)
+		}
+	}()
+	return server
+}
+
+// secretsRefreshInterval reads how often secrets are re-read to pick up rotations
+func secretsRefreshInterval() time.Duration {
+	value := os.Getenv("SECRETS_REFRESH_INTERVAL")
+	if value == "" {
+		return 5 * time.Minute
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		fatal("Invalid SECRETS_REFRESH_INTERVAL", "value", value)
+	}
+	return interval
+}
+
+// notificationDispatcher configures notification channels and routing from the environment
+// Returns a nil dispatcher if no channel is configured, and a nil WebPush unless VAPID keys are set
+func notificationDispatcher(ctx context.Context) (*notifications.Dispatcher, *push.WebPush) {
+	rules, err := notifications.ParseRules(os.Getenv("NOTIFICATION_RULES"))
+	if err != nil {
+		fatal("Invalid NOTIFICATION_RULES", "err", err)
+	}
+	dispatcher := notifications.NewDispatcher(rules)
+	configured := false
+
+	sender, webPush := pushSender(ctx)
+	if sender != nil {
+		dispatcher.Register(notifications.ChannelPush, notifications.NewPushNotifier(sender))
+		configured = true
+	}
+
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		password, err := secrets.Lookup("SMTP_PASSWORD")
+		if err != nil {
+			fatal("Failed to load SMTP_PASSWORD", "err", err)
+		}
+		to := cors.ParseList(os.Getenv("NOTIFY_EMAIL_TO"))
+		email, err := notifications.NewEmail(addr, os.Getenv("SMTP_USERNAME"), password, os.Getenv("SMTP_FROM"), to)
+		if err != nil {
+			fatal("Invalid email config", "err", err)
+		}
+		dispatcher.Register(notifications.ChannelEmail, email)
+		configured = true
+	}
+
+	slackURL, err := secrets.Lookup("SLACK_WEBHOOK_URL")
+	if err != nil {
+		fatal("Failed to load SLACK_WEBHOOK_URL", "err", err)
+	}
+	if slackURL != "" {
+		dispatcher.Register(notifications.ChannelSlack, notifications.NewSlack(slackURL))
+		configured = true
+	}
+
+	teamsURL, err := secrets.Lookup("TEAMS_WEBHOOK_URL")
+	if err != nil {
+		fatal("Failed to load TEAMS_WEBHOOK_URL", "err", err)
+	}
+	if teamsURL != "" {
+		dispatcher.Register(notifications.ChannelTeams, notifications.NewTeams(teamsURL))
+		configured = true
+	}
+
+	if !configured {
+		return nil, nil
+	}
+	return dispatcher, webPush
+}
+
+// pushSender configures push providers from the environment
+// Returns a nil sender if no provider is configured, and a nil WebPush unless VAPID keys are set
+func pushSender(ctx context.Context) (*push.Sender, *push.WebPush) {
+	sender := push.NewSender()
+	configured := false
+
+	if projectID := os.Getenv("FCM_PROJECT_ID"); projectID != "" {
+		accessToken, err := secrets.Load("FCM_ACCESS_TOKEN")
+		if err != nil {
+			fatal("Failed to load FCM_ACCESS_TOKEN", "err", err)
+		}
+		go accessToken.Watch(ctx, secretsRefreshInterval())
+		sender.Register("fcm", push.NewFCM(projectID, accessToken.Value))
+		configured = true
+	}
+
+	if keyID := os.Getenv("APNS_KEY_ID"); keyID != "" {
+		key, err := secrets.Lookup("APNS_KEY")
+		if err != nil {
+			fatal("Failed to load APNS_KEY", "err", err)
+		}
+		apns, err := push.NewAPNs(key, keyID, os.Getenv("APNS_TEAM_ID"), os.Getenv("APNS_TOPIC"), os.Getenv("APNS_SANDBOX") == "true")
+		if err != nil {
+			fatal("Invalid APNs config", "err", err)
+		}
+		sender.Register("apns", apns)
+		configured = true
+	}
+
+	var webPush *push.WebPush
+	if subject := os.Getenv("VAPID_SUBJECT"); subject != "" {
+		key, err := secrets.Lookup("VAPID_PRIVATE_KEY")
+		if err != nil {
+			fatal("Failed to load VAPID_PRIVATE_KEY", "err", err)
+		}
+		webPush, err = push.NewWebPush(key, subject)
+		if err != nil {
+			fatal("Invalid Web Push config", "err", err)
+		}
+		sender.Register("webpush", webPush)
+		configured = true
+	}
+
+	if !configured {
+		return nil, nil
+	}
+	return sender, webPush
+}
+
+// inviteSigner signs invite links with INVITE_SECRET, or with a random key when it's
+// unset, in which case invites only work on this instance until it restarts
+func inviteSigner() *invites.Signer {
+	secret, err := secrets.Lookup("INVITE_SECRET")
+	if err != nil {
+		fatal("Failed to load INVITE_SECRET", "err", err)
+	}
+	if secret == "" {
+		slog.Warn("INVITE_SECRET not set; invite links won't survive a restart or work on other instances")
+	}
+
+	signer, err := invites.NewSigner(secret)
+	if err != nil {
+		fatal("Failed to create invite signer", "err", err)
+	}
+	return signer
+}
+
+// gifSearcher configures the GIF search proxy from the environment
+// Returns nil if no provider is configured
+func gifSearcher() *gifs.Searcher {
+	name := os.Getenv("GIF_PROVIDER")
+	if name == "" {
+		return nil
+	}
+
+	apiKey, err := secrets.Lookup("GIF_API_KEY")
+	if err != nil {
+		fatal("Failed to load GIF_API_KEY", "err", err)
+	}
+	if apiKey == "" {
+		fatal("GIF_API_KEY is required when GIF_PROVIDER is set")
+	}
+
+	rating := gifs.RatingG
+	if value := os.Getenv("GIF_RATING"); value != "" {
+		rating, err = gifs.ParseRating(value)
+		if err != nil {
+			fatal("Invalid GIF_RATING", "err", err)
+		}
+	}
+
+	var provider gifs.Provider
+	switch name {
+	case "giphy":
+		provider = gifs.NewGiphy(apiKey)
+	case "tenor":
+		provider = gifs.NewTenor(apiKey)
+	default:
+		fatal("Invalid GIF_PROVIDER", "value", name)
+	}
+
+	slog.Info("GIF search enabled", "provider", name, "rating", rating)
+	return gifs.NewSearcher(provider, rating)
+}
+
+// moderationFilter configures content moderation from the environment: a blocklist
+// from MODERATION_BLOCKLIST and MODERATION_BLOCKLIST_FILE, then a moderation webhook
+// Returns nil if neither is configured
+func moderationFilter() moderation.Filter {
+	var filters []moderation.Filter
+
+	terms := strings.Split(os.Getenv("MODERATION_BLOCKLIST"), ",")
+	if path := os.Getenv("MODERATION_BLOCKLIST_FILE"); path != "" {
+		fromFile, err := moderation.LoadTerms(path)
+		if err != nil {
+			fatal("Invalid MODERATION_BLOCKLIST_FILE", "err", err)
+		}
+		terms = append(terms, fromFile...)
+	}
+	blocklist := moderation.NewBlocklist(terms)
+	if blocklist.Len() > 0 {
+		filters = append(filters, blocklist)
+		slog.Info("Moderation blocklist enabled", "terms", blocklist.Len())
+	}
+
+	if url := os.Getenv("MODERATION_WEBHOOK_URL"); url != "" {
+		token, err := secrets.Lookup("MODERATION_WEBHOOK_TOKEN")
+		if err != nil {
+			fatal("Failed to load MODERATION_WEBHOOK_TOKEN", "err", err)
+		}
+		failOpen := os.Getenv("MODERATION_FAIL_OPEN") == "true"
+		filters = append(filters, moderation.NewWebhook(url, token, failOpen))
+		slog.Info("Moderation webhook enabled", "failOpen", failOpen)
+	}
+
+	return moderation.Chain(filters...)
+}
+
+// dictationProvider configures speech-to-text for voice dictation from the environment
+// Returns nil if no provider is configured
+func dictationProvider() dictation.Provider {
+	name := os.Getenv("DICTATION_PROVIDER")
+	if name == "" {
+		return nil
+	}
+
+	apiKey, err := secrets.Lookup("DICTATION_API_KEY")
+	if err != nil {
+		fatal("Failed to load DICTATION_API_KEY", "err", err)
+	}
+	if apiKey == "" {
+		fatal("DICTATION_API_KEY is required when DICTATION_PROVIDER is set")
+	}
+
+	var provider dictation.Provider
+	switch name {
+	case "whisper":
+		provider = dictation.NewWhisper(apiKey, os.Getenv("DICTATION_ENDPOINT"))
+	case "deepgram":
+		provider = dictation.NewDeepgram(apiKey)
+	default:
+		fatal("Invalid DICTATION_PROVIDER", "value", name)
+	}
+
+	slog.Info("Voice dictation enabled", "provider", name)
+	return provider
+}
+
+// sessionStorage configures where sessions are kept from --db or the environment, returning
+// the persistent store (nil for memory) and the leases background jobs are elected with
+func sessionStorage(ctx context.Context, dbPath string) (*session.SnapshotStore, leader.Lease) {
+	if dbPath != "" {
+		return sqliteStore(ctx, dbPath), leader.NewMemoryLease()
+	}
+
+	switch store := os.Getenv("SESSION_STORE"); store {
+	case "", "memory":
+		return nil, leader.NewMemoryLease()
+	case "redis":
+		client, prefix := redisClient("SESSION_STORE=redis")
+		slog.Info("Sessions stored in Redis", "prefix", prefix)
+		return session.NewRedisStore(client, prefix), leader.NewRedisLease(client, prefix)
+	default:
+		fatal("Invalid SESSION_STORE", "value", store)
+		return nil, nil
+	}
+}
+
+// sqliteStore opens (creating if needed) the SQLite file at path and keeps sessions in it
+func sqliteStore(ctx context.Context, path string) *session.SnapshotStore {
+	if os.Getenv("SESSION_STORE") != "" {
+		fatal("--db and SESSION_STORE can't be used together")
+	}
+
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		fatal("Failed to open database", "path", path, "err", err)
+	}
+	// One connection serialises writes, so saves never fail with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, "PRAGMA journal_mode=WAL"); err != nil {
+		fatal("Failed to open database", "path", path, "err", err)
+	}
+
+	store, err := session.NewSQLStore(ctx, db)
+	if err != nil {
+		fatal("Failed to open database", "path", path, "err", err)
+	}
+	slog.Info("Sessions stored in SQLite", "path", path)
+	return store
+}
+
+// redisClient connects to REDIS_URL, which the setting named by requiredBy needs,
+// and returns it with the key prefix from REDIS_PREFIX
+func redisClient(requiredBy string) (*redis.Client, string) {
+	redisURL, err := secrets.Lookup("REDIS_URL")
+	if err != nil {
+		fatal("Failed to load REDIS_URL", "err", err)
+	}
+	if redisURL == "" {
+		fatal("REDIS_URL is required", "requiredBy", requiredBy)
+	}
+	client, err := redis.New(redisURL)
+	if err != nil {
+		fatal("Invalid REDIS_URL", "err", err)
+	}
+
+	prefix := os.Getenv("REDIS_PREFIX")
+	if prefix == "" {
+		prefix = "uplift:"
+	}
+	return client, prefix
+}
+
+// nodeID identifies this replica in leader election: INSTANCE_ID if set,
+// otherwise the hostname and process ID
+func nodeID() string {
+	if id := os.Getenv("INSTANCE_ID"); id != "" {
+		return id
 	}
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
 }
@@ -0,0 +1,49 @@
+// ABOUTME: The server's routing table, kept apart from main so tests can exercise the real mux
+// ABOUTME: Optional features leave their handler nil and their routes unregistered
+package main
+
+import (
+	"net/http"
+
+	"github.com/cassiascheffer/uplift/internal/api"
+	"github.com/cassiascheffer/uplift/internal/export"
+	"github.com/cassiascheffer/uplift/internal/joinqr"
+	"github.com/cassiascheffer/uplift/internal/wall"
+)
+
+// routes are the handlers requests are sent to, already wrapped in their middleware
+type routes struct {
+	ws          http.Handler
+	admin       http.Handler
+	hostHistory http.Handler
+	sessionAPI  http.Handler
+	walls       http.Handler
+	exports     http.Handler
+	joinCodes   http.Handler
+	dictation   http.Handler // nil = dictation disabled
+	vapidKey    http.Handler // nil = web push disabled
+	static      http.Handler
+}
+
+// newServeMux registers every route the server answers
+func newServeMux(r routes) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/ws", r.ws)
+	mux.Handle("/admin/", r.admin)
+	mux.Handle("/api/host/history", r.hostHistory)
+	for _, pattern := range api.Patterns {
+		mux.Handle(pattern, r.sessionAPI)
+	}
+	mux.Handle(wall.PathPrefix, r.walls)
+	mux.Handle(export.PathPrefix, r.exports)
+	mux.Handle(joinqr.Pattern, r.joinCodes)
+	if r.dictation != nil {
+		mux.Handle("/api/dictation", r.dictation)
+	}
+	if r.vapidKey != nil {
+		// Browsers need the VAPID public key to subscribe
+		mux.Handle("/push/vapid-public-key", r.vapidKey)
+	}
+	mux.Handle("/", r.static)
+	return mux
+}
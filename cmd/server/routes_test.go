@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/abuse"
+	"github.com/cassiascheffer/uplift/internal/api"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestSessionAPIRoutes(t *testing.T) {
+	sessionAPI := api.NewHandler(session.NewManager(), func(options []byte, tenant, remoteIP string, keyed bool) (*session.Session, *session.Participant, error) {
+		return nil, nil, abuse.ErrChallengeRequired
+	})
+	sessionAPI.SetChallenge(func() map[string]interface{} {
+		return map[string]interface{}{"required": true, "challenge": "abc", "difficulty": 8}
+	})
+
+	notFound := http.NotFoundHandler()
+	mux := newServeMux(routes{
+		ws:          notFound,
+		admin:       notFound,
+		hostHistory: notFound,
+		sessionAPI:  sessionAPI,
+		walls:       notFound,
+		exports:     notFound,
+		joinCodes:   notFound,
+		static:      http.FileServer(http.Dir(t.TempDir())),
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/challenge", nil))
+	var challenge map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &challenge)
+	if rec.Code != http.StatusOK || challenge["challenge"] != "abc" {
+		t.Errorf("Expected /api/challenge to reach the session API, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sessions", strings.NewReader(`{"userName": "Sam"}`)))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected an unsolved create to reach the session API and be refused, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/NOPE00", nil))
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Header().Get("Content-Type"), "json") {
+		t.Errorf("Expected the session API's JSON 404 for an unknown code, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
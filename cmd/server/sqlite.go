@@ -0,0 +1,8 @@
+// ABOUTME: Links the pure-Go SQLite driver so --db can keep sessions in a local file
+// ABOUTME: modernc.org/sqlite needs no cgo, so the binary still cross-compiles and runs anywhere
+package main
+
+import _ "modernc.org/sqlite"
+
+// sqliteDriver is the database/sql driver --db opens
+const sqliteDriver = "sqlite"
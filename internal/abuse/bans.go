@@ -0,0 +1,197 @@
+// ABOUTME: IP ban list for rejecting abusive sources before they reach the app
+// ABOUTME: Supports single IPs and CIDR ranges with optional JSON file persistence
+package abuse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Ban is a banned IP address or CIDR range
+type Ban struct {
+	Prefix    string    `json:"prefix"` // Normalised CIDR, e.g. 203.0.113.7/32
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BanList holds banned IP ranges
+type BanList struct {
+	bans map[netip.Prefix]Ban
+	path string // JSON file bans are persisted to (empty = memory only)
+	mu   sync.RWMutex
+}
+
+// NewBanList creates a ban list persisted to path, loading any existing bans
+// An empty path keeps bans in memory only
+func NewBanList(path string) (*BanList, error) {
+	b := &BanList{
+		bans: make(map[netip.Prefix]Ban),
+		path: path,
+	}
+
+	if path == "" {
+		return b, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading ban list: %w", err)
+	}
+
+	var bans []Ban
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return nil, fmt.Errorf("parsing ban list: %w", err)
+	}
+	for _, ban := range bans {
+		prefix, err := ParsePrefix(ban.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("parsing ban list: %w", err)
+		}
+		b.bans[prefix] = ban
+	}
+
+	slog.Info("Ban list loaded", "path", path, "bans", len(b.bans))
+	return b, nil
+}
+
+// ParsePrefix parses an IP address or CIDR range into a normalised prefix
+func ParsePrefix(value string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address or CIDR range: %s", value)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Add bans an IP address or CIDR range
+func (b *BanList) Add(value, reason string) (Ban, error) {
+	prefix, err := ParsePrefix(value)
+	if err != nil {
+		return Ban{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ban := Ban{
+		Prefix:    prefix.String(),
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	b.bans[prefix] = ban
+
+	if err := b.saveUnlocked(); err != nil {
+		return Ban{}, err
+	}
+
+	slog.Info("Ban added", "prefix", ban.Prefix, "reason", reason)
+	return ban, nil
+}
+
+// Remove lifts a ban on an IP address or CIDR range
+func (b *BanList) Remove(value string) error {
+	prefix, err := ParsePrefix(value)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.bans[prefix]; !exists {
+		return errors.New("ban not found")
+	}
+	delete(b.bans, prefix)
+
+	if err := b.saveUnlocked(); err != nil {
+		return err
+	}
+
+	slog.Info("Ban removed", "prefix", prefix)
+	return nil
+}
+
+// List returns all bans ordered by prefix
+func (b *BanList) List() []Ban {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	bans := make([]Ban, 0, len(b.bans))
+	for _, ban := range b.bans {
+		bans = append(bans, ban)
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Prefix < bans[j].Prefix
+	})
+	return bans
+}
+
+// IsBanned reports whether an IP address falls within any banned range
+func (b *BanList) IsBanned(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for prefix := range b.bans {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware rejects requests from banned sources before they reach next
+func (b *BanList) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if addr, ok := ClientIP(r); ok && b.IsBanned(addr) {
+			slog.Warn("Rejected banned source", "remoteIP", addr, "path", r.URL.Path)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// saveUnlocked writes the ban list to disk if persistence is configured
+// Internal helper that assumes caller already holds the write lock
+func (b *BanList) saveUnlocked() error {
+	if b.path == "" {
+		return nil
+	}
+
+	bans := make([]Ban, 0, len(b.bans))
+	for _, ban := range b.bans {
+		bans = append(bans, ban)
+	}
+	data, err := json.MarshalIndent(bans, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding ban list: %w", err)
+	}
+
+	// Write to a temp file and rename so a crash never leaves a partial list
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing ban list: %w", err)
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return fmt.Errorf("writing ban list: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package abuse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"testing"
+)
+
+func TestBanListMatching(t *testing.T) {
+	bans, err := NewBanList("")
+	if err != nil {
+		t.Fatalf("Failed to create ban list: %v", err)
+	}
+
+	if _, err := bans.Add("203.0.113.7", "spam"); err != nil {
+		t.Fatalf("Failed to ban IP: %v", err)
+	}
+	if _, err := bans.Add("198.51.100.0/24", ""); err != nil {
+		t.Fatalf("Failed to ban range: %v", err)
+	}
+	if _, err := bans.Add("2001:db8::/32", ""); err != nil {
+		t.Fatalf("Failed to ban IPv6 range: %v", err)
+	}
+
+	tests := []struct {
+		ip     string
+		banned bool
+	}{
+		{"203.0.113.7", true},
+		{"203.0.113.8", false},
+		{"198.51.100.42", true},
+		{"::ffff:198.51.100.42", true},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+	}
+
+	for _, tt := range tests {
+		if got := bans.IsBanned(netip.MustParseAddr(tt.ip)); got != tt.banned {
+			t.Errorf("IsBanned(%s) = %v, expected %v", tt.ip, got, tt.banned)
+		}
+	}
+
+	if _, err := bans.Add("not-an-ip", ""); err == nil {
+		t.Error("Expected error banning invalid address")
+	}
+}
+
+func TestBanListRemove(t *testing.T) {
+	bans, _ := NewBanList("")
+	bans.Add("198.51.100.0/24", "")
+
+	// Host bits are masked, so any address in the range names the same ban
+	if err := bans.Remove("198.51.100.9/24"); err != nil {
+		t.Fatalf("Failed to remove ban: %v", err)
+	}
+
+	if bans.IsBanned(netip.MustParseAddr("198.51.100.42")) {
+		t.Error("Expected range to be unbanned")
+	}
+
+	if err := bans.Remove("198.51.100.0/24"); err == nil {
+		t.Error("Expected error removing missing ban")
+	}
+}
+
+func TestBanListPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.json")
+
+	bans, err := NewBanList(path)
+	if err != nil {
+		t.Fatalf("Failed to create ban list: %v", err)
+	}
+	bans.Add("203.0.113.7", "spam")
+
+	reloaded, err := NewBanList(path)
+	if err != nil {
+		t.Fatalf("Failed to reload ban list: %v", err)
+	}
+
+	list := reloaded.List()
+	if len(list) != 1 || list[0].Prefix != "203.0.113.7/32" || list[0].Reason != "spam" {
+		t.Errorf("Expected persisted ban, got %+v", list)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	bans, _ := NewBanList("")
+	bans.Add("203.0.113.7", "")
+
+	handler := bans.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected banned source to get 403, got %d", rec.Code)
+	}
+
+	req.RemoteAddr = "192.0.2.1:51234"
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected allowed source to get 200, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,142 @@
+// ABOUTME: Proof-of-work challenges that make bulk session creation expensive for bots
+// ABOUTME: Challenges are HMAC-signed and stateless; solved challenges are remembered until expiry
+package abuse
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChallengeTTL is how long an issued challenge remains solvable
+const ChallengeTTL = 5 * time.Minute
+
+// MaxChallengeDifficulty caps the required leading zero bits
+const MaxChallengeDifficulty = 32
+
+var (
+	ErrChallengeRequired = errors.New("challenge required")
+	ErrChallengeInvalid  = errors.New("invalid challenge")
+	ErrChallengeExpired  = errors.New("challenge expired")
+	ErrChallengeReused   = errors.New("challenge already used")
+	ErrChallengeUnsolved = errors.New("challenge solution incorrect")
+)
+
+// Challenger issues and verifies proof-of-work challenges
+// A solution is any string s where sha256(challenge + ":" + s) starts with
+// at least Difficulty zero bits
+type Challenger struct {
+	difficulty int
+	secret     []byte
+	used       map[string]time.Time // Solved challenges and when they expire
+	mu         sync.Mutex
+
+	// now returns the current time (replaced in tests)
+	now func() time.Time
+}
+
+// NewChallenger creates a challenger requiring difficulty leading zero bits
+func NewChallenger(difficulty int) (*Challenger, error) {
+	if difficulty < 1 || difficulty > MaxChallengeDifficulty {
+		return nil, errors.New("challenge difficulty must be between 1 and 32")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	return &Challenger{
+		difficulty: difficulty,
+		secret:     secret,
+		used:       make(map[string]time.Time),
+		now:        time.Now,
+	}, nil
+}
+
+// Difficulty returns the number of leading zero bits a solution must produce
+func (c *Challenger) Difficulty() int {
+	return c.difficulty
+}
+
+// Issue creates a new signed challenge
+// Format: <expiry unix seconds>.<random hex>.<hmac hex>
+func (c *Challenger) Issue() string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	payload := strconv.FormatInt(c.now().Add(ChallengeTTL).Unix(), 10) + "." + hex.EncodeToString(nonce)
+	return payload + "." + c.sign(payload)
+}
+
+// Verify checks a solution and marks the challenge as used
+func (c *Challenger) Verify(challenge, solution string) error {
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 3 {
+		return ErrChallengeInvalid
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(c.sign(payload))) {
+		return ErrChallengeInvalid
+	}
+
+	expiry, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return ErrChallengeInvalid
+	}
+	expiresAt := time.Unix(expiry, 0)
+	now := c.now()
+	if now.After(expiresAt) {
+		return ErrChallengeExpired
+	}
+
+	if LeadingZeroBits(challenge, solution) < c.difficulty {
+		return ErrChallengeUnsolved
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Forget solved challenges that can no longer be replayed
+	for used, usedExpiry := range c.used {
+		if now.After(usedExpiry) {
+			delete(c.used, used)
+		}
+	}
+
+	if _, exists := c.used[challenge]; exists {
+		return ErrChallengeReused
+	}
+	c.used[challenge] = expiresAt
+
+	return nil
+}
+
+// sign returns the hex HMAC of a challenge payload
+func (c *Challenger) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LeadingZeroBits returns the number of leading zero bits in sha256(challenge + ":" + solution)
+func LeadingZeroBits(challenge, solution string) int {
+	sum := sha256.Sum256([]byte(challenge + ":" + solution))
+
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
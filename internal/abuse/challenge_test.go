@@ -0,0 +1,82 @@
+package abuse
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// solve brute-forces a solution for a challenge
+func solve(challenge string, difficulty int) string {
+	for i := 0; ; i++ {
+		solution := strconv.Itoa(i)
+		if LeadingZeroBits(challenge, solution) >= difficulty {
+			return solution
+		}
+	}
+}
+
+func TestChallengeVerify(t *testing.T) {
+	challenger, err := NewChallenger(8)
+	if err != nil {
+		t.Fatalf("Failed to create challenger: %v", err)
+	}
+
+	challenge := challenger.Issue()
+	solution := solve(challenge, 8)
+
+	if err := challenger.Verify(challenge, solution); err != nil {
+		t.Fatalf("Expected valid solution to verify, got %v", err)
+	}
+
+	if err := challenger.Verify(challenge, solution); !errors.Is(err, ErrChallengeReused) {
+		t.Errorf("Expected ErrChallengeReused on replay, got %v", err)
+	}
+}
+
+func TestChallengeRejections(t *testing.T) {
+	challenger, err := NewChallenger(8)
+	if err != nil {
+		t.Fatalf("Failed to create challenger: %v", err)
+	}
+
+	challenge := challenger.Issue()
+
+	// Find a solution that does not meet the difficulty
+	wrong := ""
+	for i := 0; ; i++ {
+		wrong = strconv.Itoa(i)
+		if LeadingZeroBits(challenge, wrong) < 8 {
+			break
+		}
+	}
+	if err := challenger.Verify(challenge, wrong); !errors.Is(err, ErrChallengeUnsolved) {
+		t.Errorf("Expected ErrChallengeUnsolved, got %v", err)
+	}
+
+	// Tampered or foreign challenges fail the signature check
+	other, _ := NewChallenger(8)
+	foreign := other.Issue()
+	if err := challenger.Verify(foreign, solve(foreign, 8)); !errors.Is(err, ErrChallengeInvalid) {
+		t.Errorf("Expected ErrChallengeInvalid for foreign challenge, got %v", err)
+	}
+	if err := challenger.Verify("garbage", "0"); !errors.Is(err, ErrChallengeInvalid) {
+		t.Errorf("Expected ErrChallengeInvalid for malformed challenge, got %v", err)
+	}
+
+	// Expired challenges are rejected even when solved
+	solution := solve(challenge, 8)
+	challenger.now = func() time.Time { return time.Now().Add(ChallengeTTL + time.Minute) }
+	if err := challenger.Verify(challenge, solution); !errors.Is(err, ErrChallengeExpired) {
+		t.Errorf("Expected ErrChallengeExpired, got %v", err)
+	}
+}
+
+func TestNewChallengerDifficulty(t *testing.T) {
+	for _, difficulty := range []int{0, -1, MaxChallengeDifficulty + 1} {
+		if _, err := NewChallenger(difficulty); err == nil {
+			t.Errorf("Expected error for difficulty %d", difficulty)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// ABOUTME: Works out a request's source IP, looking past reverse proxies the deployment trusts
+// ABOUTME: X-Forwarded-For is only believed when the connection comes from a trusted proxy
+package abuse
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+)
+
+// trustedProxies are the proxies whose X-Forwarded-For is believed (nil = none)
+var trustedProxies atomic.Pointer[[]netip.Prefix]
+
+// ParseTrustedProxies reads a comma-separated list of proxy IP addresses and CIDR ranges
+func ParseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		prefix, err := ParsePrefix(field)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// SetTrustedProxies sets the proxies whose X-Forwarded-For header ClientIP believes
+// Safe to call from any goroutine
+func SetTrustedProxies(prefixes []netip.Prefix) {
+	trustedProxies.Store(&prefixes)
+}
+
+// isTrustedProxy reports whether addr is one of the trusted proxies
+func isTrustedProxy(addr netip.Addr) bool {
+	prefixes := trustedProxies.Load()
+	if prefixes == nil {
+		return false
+	}
+	for _, prefix := range *prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the request's source IP address
+// When the connection comes from a trusted proxy, X-Forwarded-For is read from the right,
+// skipping further trusted proxies, to the first address a proxy vouches for; entries
+// further left were supplied by the client and could say anything
+func ClientIP(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+
+	if !isTrustedProxy(addr) {
+		return addr, true
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !isTrustedProxy(addr) {
+			break
+		}
+	}
+	return addr, true
+}
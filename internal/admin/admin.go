@@ -0,0 +1,418 @@
+// ABOUTME: Authenticated HTTP API for operators running an uplift deployment
+// ABOUTME: Serves usage statistics, session operations, notification status and ban management under /admin/api behind a bearer token or API key
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cassiascheffer/uplift/internal/abuse"
+	"github.com/cassiascheffer/uplift/internal/analytics"
+	"github.com/cassiascheffer/uplift/internal/apikeys"
+	"github.com/cassiascheffer/uplift/internal/features"
+	"github.com/cassiascheffer/uplift/internal/notifications"
+	"github.com/cassiascheffer/uplift/internal/ratelimit"
+	"github.com/cassiascheffer/uplift/internal/session"
+	"github.com/cassiascheffer/uplift/internal/starters"
+)
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 366
+)
+
+// Handler serves the admin API
+type Handler struct {
+	token         func() string
+	analytics     *analytics.Collector
+	bans          *abuse.BanList
+	metrics       func() interface{}
+	deadLetters   func() interface{}
+	features      *features.Flags
+	notifications *notifications.Dispatcher
+	observer      func(w http.ResponseWriter, r *http.Request, sessionCode string)
+	sessions      *session.Manager
+	merge         func(targetCode, sourceCode string) error
+	rollback      func(code string, phase session.Phase) (session.RollbackResult, error)
+	complete      func(code string) error
+	closeSession  func(code string) error
+	kick          func(code, participantID string) error
+	connected     func(sessionID string) []string
+	starters      *starters.Store
+	keys          *apikeys.Store
+	keyLimits     *ratelimit.Limiter
+	mux           *http.ServeMux
+}
+
+// NewHandler creates an admin API handler authenticated by the given bearer token
+// An empty token disables the API entirely
+func NewHandler(token string, collector *analytics.Collector, bans *abuse.BanList) *Handler {
+	h := &Handler{
+		token:     func() string { return token },
+		analytics: collector,
+		bans:      bans,
+		mux:       http.NewServeMux(),
+	}
+
+	h.route("GET /admin/api/stats", apikeys.ScopeStatsRead, h.handleStats)
+	h.route("GET /admin/api/metrics", apikeys.ScopeStatsRead, h.handleMetrics)
+	h.route("GET /admin/api/features", apikeys.ScopeFeaturesRead, h.handleListFeatures)
+	h.route("PUT /admin/api/features/{name}", apikeys.ScopeFeaturesWrite, h.handleSetFeature)
+	h.route("GET /admin/api/notifications", apikeys.ScopeNotificationsRead, h.handleListNotifications)
+	h.route("GET /admin/api/sessions", apikeys.ScopeSessionsRead, h.handleListSessions)
+	h.route("GET /admin/api/sessions/{code}", apikeys.ScopeSessionsRead, h.handleInspectSession)
+	h.route("DELETE /admin/api/sessions/{code}", apikeys.ScopeSessionsWrite, h.handleCloseSession)
+	h.route("POST /admin/api/sessions/{code}/complete", apikeys.ScopeSessionsWrite, h.handleCompleteSession)
+	h.route("DELETE /admin/api/sessions/{code}/participants/{id}", apikeys.ScopeSessionsWrite, h.handleKickParticipant)
+	h.route("GET /admin/api/sessions/{code}/observe", apikeys.ScopeSessionsRead, h.handleObserveSession)
+	h.route("GET /admin/api/sessions/{code}/timeline", apikeys.ScopeSessionsRead, h.handleSessionTimeline)
+	h.route("POST /admin/api/sessions/{code}/merge", apikeys.ScopeSessionsWrite, h.handleMergeSession)
+	h.route("POST /admin/api/sessions/{code}/rollback", apikeys.ScopeSessionsWrite, h.handleRollbackSession)
+	h.route("GET /admin/api/dead-letters", apikeys.ScopeDiagnosticsRead, h.handleDeadLetters)
+	h.route("GET /admin/api/starters", apikeys.ScopeContentRead, h.handleListStarters)
+	h.route("PUT /admin/api/starters/{tenant}", apikeys.ScopeContentWrite, h.handleSetStarters)
+	h.route("DELETE /admin/api/starters/{tenant}", apikeys.ScopeContentWrite, h.handleResetStarters)
+	h.route("GET /admin/api/bans", apikeys.ScopeBansRead, h.handleListBans)
+	h.route("POST /admin/api/bans", apikeys.ScopeBansWrite, h.handleAddBan)
+	h.route("DELETE /admin/api/bans", apikeys.ScopeBansWrite, h.handleRemoveBan)
+
+	// Keys are managed with the admin token only, never with another key
+	h.route("GET /admin/api/keys", "", h.handleListKeys)
+	h.route("POST /admin/api/keys", "", h.handleCreateKey)
+	h.route("DELETE /admin/api/keys/{id}", "", h.handleRevokeKey)
+
+	return h
+}
+
+// SetTokenSource replaces the bearer token with one looked up per request,
+// so a rotated token takes effect without a restart
+func (h *Handler) SetTokenSource(source func() string) {
+	h.token = source
+}
+
+// SetMetrics sets the source of runtime metrics served at /admin/api/metrics
+func (h *Handler) SetMetrics(source func() interface{}) {
+	h.metrics = source
+}
+
+// SetDeadLetters sets the source of unprocessable messages served at /admin/api/dead-letters
+func (h *Handler) SetDeadLetters(source func() interface{}) {
+	h.deadLetters = source
+}
+
+// SetFeatures sets the feature flags that can be toggled at /admin/api/features
+func (h *Handler) SetFeatures(flags *features.Flags) {
+	h.features = flags
+}
+
+// SetNotifications sets the dispatcher whose delivery status is served at /admin/api/notifications
+func (h *Handler) SetNotifications(dispatcher *notifications.Dispatcher) {
+	h.notifications = dispatcher
+}
+
+// SetStarters sets the store of sentence starters managed at /admin/api/starters
+func (h *Handler) SetStarters(store *starters.Store) {
+	h.starters = store
+}
+
+// SetObserver sets the function that attaches a read-only WebSocket connection to a live session
+func (h *Handler) SetObserver(observer func(w http.ResponseWriter, r *http.Request, sessionCode string)) {
+	h.observer = observer
+}
+
+// apiKeyContextKey carries the API key a request authenticated with
+// Requests made with the admin token carry none
+type apiKeyContextKey struct{}
+
+// ServeHTTP authenticates the request and routes it to the admin endpoints
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.token() == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	key, ok := h.authenticate(r)
+	if !ok {
+		slog.Warn("Admin API unauthorized request", "path", r.URL.Path, "remoteAddr", r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="uplift-admin"`)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if key != nil {
+		result := h.keyLimits.Allow(key.ID)
+		ratelimit.WriteHeaders(w, result)
+		if !result.Allowed {
+			slog.Warn("Admin API key rate limited", "keyID", key.ID, "path", r.URL.Path)
+			ratelimit.WriteLimited(w, result)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key))
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// authenticate checks the request carries the admin bearer token or an active API key
+// Returns the key for API key requests and nil for the admin token
+func (h *Handler) authenticate(r *http.Request) (*apikeys.Key, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, false
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.token())) == 1 {
+		return nil, true
+	}
+	if key, ok := h.keys.Authenticate(token); ok {
+		return &key, true
+	}
+	return nil, false
+}
+
+// route registers an endpoint that API keys may call only if granted scope
+// An empty scope restricts the endpoint to the admin token
+func (h *Handler) route(pattern string, scope apikeys.Scope, handler http.HandlerFunc) {
+	h.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		key, _ := r.Context().Value(apiKeyContextKey{}).(*apikeys.Key)
+		if key != nil && (scope == "" || !key.Allows(scope)) {
+			slog.Warn("Admin API key lacks scope", "keyID", key.ID, "path", r.URL.Path, "scope", scope)
+			writeError(w, http.StatusForbidden, "api key not permitted")
+			return
+		}
+		handler(w, r)
+	})
+}
+
+// handleStats returns time-bucketed usage statistics
+// Query parameters: period=daily|weekly (default daily), days=1-366 (default 30)
+func (h *Handler) handleStats(w http.ResponseWriter, r *http.Request) {
+	period := analytics.Period(r.URL.Query().Get("period"))
+	if period == "" {
+		period = analytics.PeriodDaily
+	}
+	if period != analytics.PeriodDaily && period != analytics.PeriodWeekly {
+		writeError(w, http.StatusBadRequest, "period must be daily or weekly")
+		return
+	}
+
+	days := defaultStatsDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxStatsDays {
+			writeError(w, http.StatusBadRequest, "days must be between 1 and 366")
+			return
+		}
+		days = parsed
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"period":  period,
+		"days":    days,
+		"buckets": h.analytics.Stats(period, days),
+	})
+}
+
+// handleMetrics returns a snapshot of runtime metrics such as send-queue occupancy
+func (h *Handler) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if h.metrics == nil {
+		writeError(w, http.StatusNotFound, "metrics not available")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.metrics())
+}
+
+// handleDeadLetters returns recent inbound messages that couldn't be decoded or had unknown types
+func (h *Handler) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if h.deadLetters == nil {
+		writeError(w, http.StatusNotFound, "dead letters not available")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.deadLetters())
+}
+
+// handleListFeatures returns every feature flag and whether it's enabled
+func (h *Handler) handleListFeatures(w http.ResponseWriter, r *http.Request) {
+	if h.features == nil {
+		writeError(w, http.StatusNotFound, "feature flags not available")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"features": h.features.List(),
+	})
+}
+
+// handleSetFeature turns a feature on or off
+// Body: {"enabled": true}
+func (h *Handler) handleSetFeature(w http.ResponseWriter, r *http.Request) {
+	if h.features == nil {
+		writeError(w, http.StatusNotFound, "feature flags not available")
+		return
+	}
+
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+		writeError(w, http.StatusBadRequest, "enabled required")
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := h.features.Set(name, *body.Enabled); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, features.Flag{Name: name, Enabled: *body.Enabled})
+}
+
+// handleListStarters returns the built-in sentence starters and every configured set
+func (h *Handler) handleListStarters(w http.ResponseWriter, r *http.Request) {
+	if h.starters == nil {
+		writeError(w, http.StatusNotFound, "sentence starters not available")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"defaults": starters.Defaults,
+		"sets":     h.starters.List(),
+	})
+}
+
+// handleSetStarters replaces a tenant's sentence starters ("default" for the deployment-wide set)
+// Body: {"starters": ["I appreciated when you…"]}
+func (h *Handler) handleSetStarters(w http.ResponseWriter, r *http.Request) {
+	if h.starters == nil {
+		writeError(w, http.StatusNotFound, "sentence starters not available")
+		return
+	}
+
+	var body struct {
+		Starters []string `json:"starters"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	tenant := r.PathValue("tenant")
+	set, err := h.starters.Set(tenant, body.Starters)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, starters.TenantSet{Tenant: tenant, Starters: set})
+}
+
+// handleResetStarters removes a tenant's own sentence starters so it uses the deployment-wide set
+func (h *Handler) handleResetStarters(w http.ResponseWriter, r *http.Request) {
+	if h.starters == nil {
+		writeError(w, http.StatusNotFound, "sentence starters not available")
+		return
+	}
+
+	if err := h.starters.Reset(r.PathValue("tenant")); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListNotifications returns delivery counts and recent deliveries
+// Query parameters: status=pending|delivered|failed (default all)
+func (h *Handler) handleListNotifications(w http.ResponseWriter, r *http.Request) {
+	if h.notifications == nil {
+		writeError(w, http.StatusNotFound, "notifications not configured")
+		return
+	}
+
+	status := notifications.DeliveryStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", notifications.StatusPending, notifications.StatusDelivered, notifications.StatusFailed:
+	default:
+		writeError(w, http.StatusBadRequest, "status must be pending, delivered or failed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"stats":      h.notifications.Stats(),
+		"deliveries": h.notifications.Deliveries(status),
+	})
+}
+
+// handleObserveSession upgrades to a WebSocket that silently watches a live session
+// The observer isn't a participant, isn't counted and can't act
+func (h *Handler) handleObserveSession(w http.ResponseWriter, r *http.Request) {
+	if h.observer == nil {
+		writeError(w, http.StatusNotFound, "session observation not available")
+		return
+	}
+
+	code := r.PathValue("code")
+	slog.Info("Admin observing session", "sessionCode", code, "remoteAddr", r.RemoteAddr)
+	h.observer(w, r, code)
+}
+
+// handleListBans returns all banned IP addresses and ranges
+func (h *Handler) handleListBans(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"bans": h.bans.List(),
+	})
+}
+
+// handleAddBan bans an IP address or CIDR range
+// Body: {"prefix": "203.0.113.0/24", "reason": "..."}
+func (h *Handler) handleAddBan(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Prefix string `json:"prefix"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ban, err := h.bans.Add(body.Prefix, body.Reason)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, ban)
+}
+
+// handleRemoveBan lifts a ban given as ?prefix=203.0.113.0/24
+func (h *Handler) handleRemoveBan(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		writeError(w, http.StatusBadRequest, "prefix required")
+		return
+	}
+
+	if err := h.bans.Remove(prefix); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("Admin API response encoding error", "err", err)
+	}
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": message,
+	})
+}
@@ -0,0 +1,646 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/abuse"
+	"github.com/cassiascheffer/uplift/internal/analytics"
+	"github.com/cassiascheffer/uplift/internal/apikeys"
+	"github.com/cassiascheffer/uplift/internal/features"
+	"github.com/cassiascheffer/uplift/internal/notifications"
+	"github.com/cassiascheffer/uplift/internal/ratelimit"
+	"github.com/cassiascheffer/uplift/internal/session"
+	"github.com/cassiascheffer/uplift/internal/starters"
+)
+
+// newTestHandler creates an admin handler with in-memory dependencies
+func newTestHandler(t *testing.T, token string) (*Handler, *analytics.Collector, *abuse.BanList) {
+	t.Helper()
+
+	collector := analytics.NewCollector()
+	bans, err := abuse.NewBanList("")
+	if err != nil {
+		t.Fatalf("Failed to create ban list: %v", err)
+	}
+	return NewHandler(token, collector, bans), collector, bans
+}
+
+// doRequest sends an authenticated request to the handler
+func doRequest(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStatsRequiresToken(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"valid token", "Bearer secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/api/stats", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAdminDisabledWithoutToken(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/api/stats", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 when admin API is disabled, got %d", rec.Code)
+	}
+}
+
+func TestStats(t *testing.T) {
+	handler, collector, _ := newTestHandler(t, "secret")
+	collector.RecordSessionCreated()
+
+	rec := doRequest(handler, http.MethodGet, "/admin/api/stats?period=weekly&days=7", "")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var body struct {
+		Period  string             `json:"period"`
+		Buckets []analytics.Bucket `json:"buckets"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if body.Period != "weekly" {
+		t.Errorf("Expected weekly period, got %s", body.Period)
+	}
+
+	created := 0
+	for _, bucket := range body.Buckets {
+		created += bucket.SessionsCreated
+	}
+	if created != 1 {
+		t.Errorf("Expected 1 session created, got %d", created)
+	}
+
+	// Invalid parameters
+	for _, query := range []string{"?period=hourly", "?days=0", "?days=abc"} {
+		rec := doRequest(handler, http.MethodGet, "/admin/api/stats"+query, "")
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestBanManagement(t *testing.T) {
+	handler, _, bans := newTestHandler(t, "secret")
+
+	rec := doRequest(handler, http.MethodPost, "/admin/api/bans", `{"prefix": "198.51.100.0/24", "reason": "spam"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", rec.Code)
+	}
+
+	if len(bans.List()) != 1 {
+		t.Errorf("Expected 1 ban, got %d", len(bans.List()))
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/bans", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "198.51.100.0/24") {
+		t.Errorf("Expected ban in list, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(handler, http.MethodPost, "/admin/api/bans", `{"prefix": "nope"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid prefix, got %d", rec.Code)
+	}
+
+	rec = doRequest(handler, http.MethodDelete, "/admin/api/bans?prefix=198.51.100.0/24", "")
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", rec.Code)
+	}
+
+	rec = doRequest(handler, http.MethodDelete, "/admin/api/bans?prefix=198.51.100.0/24", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 removing missing ban, got %d", rec.Code)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+
+	rec := doRequest(handler, http.MethodGet, "/admin/api/metrics", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without a metrics source, got %d", rec.Code)
+	}
+
+	handler.SetMetrics(func() interface{} {
+		return map[string]int{"processQueueDepth": 3}
+	})
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/metrics", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"processQueueDepth":3`) {
+		t.Errorf("Expected metrics in body, got %s", rec.Body.String())
+	}
+}
+
+func TestFeatureToggles(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+	flags, _ := features.NewFlags("")
+	handler.SetFeatures(flags)
+
+	rec := doRequest(handler, http.MethodGet, "/admin/api/features", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"name":"breakouts"`) {
+		t.Errorf("Expected feature list, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(handler, http.MethodPut, "/admin/api/features/reactions", `{"enabled": true}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if !flags.Enabled(features.Reactions) {
+		t.Error("Expected reactions to be enabled")
+	}
+
+	rec = doRequest(handler, http.MethodPut, "/admin/api/features/teleport", `{"enabled": true}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown feature, got %d", rec.Code)
+	}
+
+	rec = doRequest(handler, http.MethodPut, "/admin/api/features/reactions", `{}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without enabled, got %d", rec.Code)
+	}
+}
+
+func TestTokenRotation(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+
+	token := "secret"
+	handler.SetTokenSource(func() string { return token })
+
+	if rec := doRequest(handler, http.MethodGet, "/admin/api/bans", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with current token, got %d", rec.Code)
+	}
+
+	token = "rotated"
+	if rec := doRequest(handler, http.MethodGet, "/admin/api/bans", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 with old token after rotation, got %d", rec.Code)
+	}
+}
+
+// notifierFunc adapts a function to notifications.Notifier
+type notifierFunc func(address string) error
+
+func (f notifierFunc) Notify(ctx context.Context, address string, n notifications.Notification) error {
+	return f(address)
+}
+
+func TestNotificationStatus(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+
+	rec := doRequest(handler, http.MethodGet, "/admin/api/notifications", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without notifications, got %d", rec.Code)
+	}
+
+	dispatcher := notifications.NewDispatcher(notifications.DefaultRules())
+	dispatcher.Register(notifications.ChannelPush, notifierFunc(func(address string) error { return nil }))
+	handler.SetNotifications(dispatcher)
+
+	dispatcher.Dispatch(notifications.Notification{Event: notifications.EventYourTurn}, []notifications.Contact{
+		{Channel: notifications.ChannelPush, Address: "fcm:device-1", Label: "participant-1"},
+	})
+	for deadline := time.Now().Add(time.Second); dispatcher.Stats().Delivered == 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/notifications?status=delivered", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var body struct {
+		Stats      notifications.Stats      `json:"stats"`
+		Deliveries []notifications.Delivery `json:"deliveries"`
+	}
+	json.NewDecoder(rec.Body).Decode(&body)
+	if body.Stats.Delivered != 1 || len(body.Deliveries) != 1 || body.Deliveries[0].Recipient != "participant-1" {
+		t.Errorf("Unexpected notification status: %+v", body)
+	}
+	if strings.Contains(rec.Body.String(), "device-1") {
+		t.Error("Expected device tokens to be kept out of the response")
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/notifications?status=lost", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown status, got %d", rec.Code)
+	}
+}
+
+func TestObserveSession(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+
+	rec := doRequest(handler, http.MethodGet, "/admin/api/sessions/ABC123/observe", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without an observer, got %d", rec.Code)
+	}
+
+	var observed string
+	handler.SetObserver(func(w http.ResponseWriter, r *http.Request, sessionCode string) {
+		observed = sessionCode
+	})
+
+	doRequest(handler, http.MethodGet, "/admin/api/sessions/ABC123/observe", "")
+	if observed != "ABC123" {
+		t.Errorf("Expected to observe ABC123, got %q", observed)
+	}
+
+	// Observation requires the admin token like every other endpoint
+	observed = ""
+	req := httptest.NewRequest(http.MethodGet, "/admin/api/sessions/ABC123/observe", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || observed != "" {
+		t.Errorf("Expected unauthenticated observation to be refused, got %d", rec.Code)
+	}
+}
+
+func TestSessionTimeline(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+
+	rec := doRequest(handler, http.MethodGet, "/admin/api/sessions/ABC123/timeline", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without sessions, got %d", rec.Code)
+	}
+
+	manager := session.NewManager()
+	handler.SetSessions(manager)
+	sess := manager.CreateSession("Host")
+	sess.AddParticipant("Alice")
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/sessions/"+sess.Code+"/timeline", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var body struct {
+		Events []session.TimelineEvent `json:"events"`
+	}
+	json.NewDecoder(rec.Body).Decode(&body)
+	if len(body.Events) != 2 || body.Events[1].Type != session.EventJoined {
+		t.Errorf("Unexpected timeline: %+v", body.Events)
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/sessions/"+sess.Code+"/timeline?replay=true&speed=1000", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("Expected NDJSON replay, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if lines := strings.Count(rec.Body.String(), "\n"); lines != 2 {
+		t.Errorf("Expected one line per event, got %d", lines)
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/sessions/"+sess.Code+"/timeline?replay=true&speed=0", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid speed, got %d", rec.Code)
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/sessions/NOPE00/timeline", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown session, got %d", rec.Code)
+	}
+}
+
+func TestListAndInspectSessions(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+
+	rec := doRequest(handler, http.MethodGet, "/admin/api/sessions", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without sessions, got %d", rec.Code)
+	}
+
+	manager := session.NewManager()
+	handler.SetSessions(manager)
+	joining := manager.CreateSession("Host")
+	alice, _ := joining.AddParticipant("Alice")
+	writing := manager.CreateSession("Other Host")
+	writing.AddParticipant("Bob")
+	writing.TransitionToWriting()
+	handler.SetConnected(func(sessionID string) []string {
+		if sessionID == joining.ID {
+			return []string{alice.ID}
+		}
+		return nil
+	})
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/sessions", "")
+	var list struct {
+		Count    int `json:"count"`
+		Sessions []struct {
+			Code         string `json:"sessionCode"`
+			Phase        string `json:"phase"`
+			Participants int    `json:"participants"`
+			Connected    int    `json:"connected"`
+		} `json:"sessions"`
+	}
+	json.NewDecoder(rec.Body).Decode(&list)
+	if rec.Code != http.StatusOK || list.Count != 2 {
+		t.Fatalf("Expected both sessions listed, got %d: %+v", rec.Code, list)
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/sessions?phase=JOINING", "")
+	list.Sessions = nil
+	json.NewDecoder(rec.Body).Decode(&list)
+	if len(list.Sessions) != 1 || list.Sessions[0].Code != joining.Code || list.Sessions[0].Participants != 2 || list.Sessions[0].Connected != 1 {
+		t.Errorf("Expected the joining session with 2 participants, 1 connected, got %+v", list.Sessions)
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/sessions/"+joining.Code, "")
+	var detail struct {
+		Session      session.Inspection `json:"session"`
+		Participants []struct {
+			ID        string `json:"id"`
+			Name      string `json:"name"`
+			Connected bool   `json:"connected"`
+		} `json:"participants"`
+	}
+	json.NewDecoder(rec.Body).Decode(&detail)
+	if rec.Code != http.StatusOK || detail.Session.Code != joining.Code || len(detail.Participants) != 2 {
+		t.Fatalf("Expected session details, got %d: %+v", rec.Code, detail)
+	}
+	if detail.Participants[1].Name != "Alice" || !detail.Participants[1].Connected || detail.Participants[0].Connected {
+		t.Errorf("Expected only Alice connected, got %+v", detail.Participants)
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/sessions/NOPE00", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown session, got %d", rec.Code)
+	}
+}
+
+func TestSessionOperationEndpoints(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+	manager := session.NewManager()
+	handler.SetSessions(manager)
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+
+	rec := doRequest(handler, http.MethodPost, "/admin/api/sessions/"+sess.Code+"/complete", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without a completer, got %d", rec.Code)
+	}
+
+	var calls []string
+	handler.SetCompleter(func(code string) error {
+		calls = append(calls, "complete "+code)
+		return errors.New("session is already complete")
+	})
+	handler.SetCloser(func(code string) error {
+		calls = append(calls, "close "+code)
+		return nil
+	})
+	handler.SetKicker(func(code, participantID string) error {
+		calls = append(calls, "kick "+code+" "+participantID)
+		return nil
+	})
+
+	rec = doRequest(handler, http.MethodPost, "/admin/api/sessions/"+sess.Code+"/complete", "")
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a refused completion, got %d", rec.Code)
+	}
+	rec = doRequest(handler, http.MethodDelete, "/admin/api/sessions/"+strings.ToLower(sess.Code)+"/participants/"+alice.ID, "")
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 for a kick, got %d", rec.Code)
+	}
+	rec = doRequest(handler, http.MethodDelete, "/admin/api/sessions/"+sess.Code+"/participants/nobody", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown participant, got %d", rec.Code)
+	}
+	rec = doRequest(handler, http.MethodDelete, "/admin/api/sessions/"+sess.Code, "")
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 for a close, got %d", rec.Code)
+	}
+	rec = doRequest(handler, http.MethodDelete, "/admin/api/sessions/NOPE00", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown session, got %d", rec.Code)
+	}
+
+	want := []string{"complete " + sess.Code, "kick " + sess.Code + " " + alice.ID, "close " + sess.Code}
+	if strings.Join(calls, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected calls %v, got %v", want, calls)
+	}
+}
+
+func TestMergeSessionEndpoint(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+
+	var target, source string
+	handler.SetMerger(func(targetCode, sourceCode string) error {
+		target, source = targetCode, sourceCode
+		return nil
+	})
+
+	rec := doRequest(handler, http.MethodPost, "/admin/api/sessions/ABC123/merge", `{"from": "XYZ789"}`)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", rec.Code)
+	}
+	if target != "ABC123" || source != "XYZ789" {
+		t.Errorf("Expected merge of XYZ789 into ABC123, got %s into %s", source, target)
+	}
+
+	rec = doRequest(handler, http.MethodPost, "/admin/api/sessions/ABC123/merge", `{}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without from, got %d", rec.Code)
+	}
+}
+
+func TestDeadLettersEndpoint(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+
+	rec := doRequest(handler, http.MethodGet, "/admin/api/dead-letters", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without dead letters, got %d", rec.Code)
+	}
+
+	handler.SetDeadLetters(func() interface{} {
+		return map[string]interface{}{"total": 1}
+	})
+	rec = doRequest(handler, http.MethodGet, "/admin/api/dead-letters", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"total":1`) {
+		t.Errorf("Expected dead letters, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRollbackSessionEndpoint(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+
+	var code string
+	var phase session.Phase
+	handler.SetRollbacker(func(c string, p session.Phase) (session.RollbackResult, error) {
+		code, phase = c, p
+		if p == session.PhaseReading {
+			return session.RollbackResult{}, errors.New("every note has already been read")
+		}
+		return session.RollbackResult{From: session.PhaseReading, To: p}, nil
+	})
+
+	rec := doRequest(handler, http.MethodPost, "/admin/api/sessions/ABC123/rollback", `{"phase": "WRITING"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if code != "ABC123" || phase != session.PhaseWriting {
+		t.Errorf("Expected ABC123 rolled back to WRITING, got %s to %s", code, phase)
+	}
+
+	rec = doRequest(handler, http.MethodPost, "/admin/api/sessions/ABC123/rollback", `{"phase": "READING"}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a refused rollback, got %d", rec.Code)
+	}
+
+	rec = doRequest(handler, http.MethodPost, "/admin/api/sessions/ABC123/rollback", `{}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without phase, got %d", rec.Code)
+	}
+}
+
+func TestStartersEndpoints(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+	store, _ := starters.NewStore("")
+	handler.SetStarters(store)
+
+	rec := doRequest(handler, http.MethodPut, "/admin/api/starters/acme", `{"starters": ["  Thanks for  ", ""]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := store.For("acme"); len(got) != 1 || got[0] != "Thanks for" {
+		t.Errorf("Expected the trimmed starter for acme, got %v", got)
+	}
+
+	rec = doRequest(handler, http.MethodPut, "/admin/api/starters/acme", `{"starters": []}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty set, got %d", rec.Code)
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/admin/api/starters", "")
+	var listed struct {
+		Defaults []string             `json:"defaults"`
+		Sets     []starters.TenantSet `json:"sets"`
+	}
+	json.NewDecoder(rec.Body).Decode(&listed)
+	if len(listed.Defaults) == 0 || len(listed.Sets) != 1 || listed.Sets[0].Tenant != "acme" {
+		t.Errorf("Unexpected starters listing: %+v", listed)
+	}
+
+	rec = doRequest(handler, http.MethodDelete, "/admin/api/starters/acme", "")
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", rec.Code)
+	}
+	if got := store.For("acme"); len(got) != len(starters.Defaults) {
+		t.Errorf("Expected acme to fall back to the defaults, got %v", got)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+	keys, _ := apikeys.NewStore("")
+	handler.SetAPIKeys(keys)
+
+	rec := doRequest(handler, http.MethodPost, "/admin/api/keys", `{"name": "reporting", "scopes": ["stats:read"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Key    apikeys.Key `json:"key"`
+		Secret string      `json:"secret"`
+	}
+	json.NewDecoder(rec.Body).Decode(&created)
+
+	withKey := func(method, target string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(`{}`))
+		req.Header.Set("Authorization", "Bearer "+created.Secret)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := withKey(http.MethodGet, "/admin/api/stats"); code != http.StatusOK {
+		t.Errorf("Expected scoped key to read stats, got %d", code)
+	}
+	if code := withKey(http.MethodGet, "/admin/api/bans"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 outside the key's scopes, got %d", code)
+	}
+	if code := withKey(http.MethodPost, "/admin/api/keys"); code != http.StatusForbidden {
+		t.Errorf("Expected keys not to manage keys, got %d", code)
+	}
+
+	if rec := doRequest(handler, http.MethodPost, "/admin/api/keys", `{"name": "bad", "scopes": ["root"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown scope, got %d", rec.Code)
+	}
+
+	if rec := doRequest(handler, http.MethodDelete, "/admin/api/keys/"+created.Key.ID, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 revoking, got %d", rec.Code)
+	}
+	if code := withKey(http.MethodGet, "/admin/api/stats"); code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked key to be rejected, got %d", code)
+	}
+}
+
+func TestAPIKeyRateLimit(t *testing.T) {
+	handler, _, _ := newTestHandler(t, "secret")
+	keys, _ := apikeys.NewStore("")
+	handler.SetAPIKeys(keys)
+	handler.SetKeyRateLimit(ratelimit.NewLimiter(ratelimit.Limit{Rate: 1, Burst: 1}))
+	_, secret, _ := keys.Create("reporting", []apikeys.Scope{apikeys.ScopeStatsRead})
+
+	request := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/api/stats", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(secret); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Errorf("Expected first call allowed with quota headers, got %d %v", rec.Code, rec.Header())
+	}
+	if rec := request(secret); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After, got %d", rec.Code)
+	}
+
+	// The admin token isn't subject to key limits
+	if rec := request("secret"); rec.Code != http.StatusOK {
+		t.Errorf("Expected admin token to be unaffected, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,85 @@
+// ABOUTME: Admin endpoints for issuing, listing and revoking API keys
+// ABOUTME: Integrations authenticate with these keys instead of the operator's admin token
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/cassiascheffer/uplift/internal/apikeys"
+	"github.com/cassiascheffer/uplift/internal/ratelimit"
+)
+
+// SetAPIKeys enables API key authentication and the key management endpoints
+func (h *Handler) SetAPIKeys(store *apikeys.Store) {
+	h.keys = store
+}
+
+// SetKeyRateLimit limits how often each API key may call the API
+func (h *Handler) SetKeyRateLimit(limiter *ratelimit.Limiter) {
+	h.keyLimits = limiter
+}
+
+// handleListKeys returns every issued key without its secret
+func (h *Handler) handleListKeys(w http.ResponseWriter, r *http.Request) {
+	if h.keys == nil {
+		writeError(w, http.StatusNotFound, "api keys not available")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys":   h.keys.List(),
+		"scopes": apikeys.Scopes,
+	})
+}
+
+// handleCreateKey issues a key; the response is the only time the secret is shown
+// Body: {"name": "reporting", "scopes": ["stats:read"]}
+func (h *Handler) handleCreateKey(w http.ResponseWriter, r *http.Request) {
+	if h.keys == nil {
+		writeError(w, http.StatusNotFound, "api keys not available")
+		return
+	}
+
+	var body struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	scopes, err := apikeys.ParseScopes(body.Scopes)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, secret, err := h.keys.Create(body.Name, scopes)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	slog.Info("Admin created API key", "keyID", key.ID, "remoteAddr", r.RemoteAddr)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"key":    key,
+		"secret": secret,
+	})
+}
+
+// handleRevokeKey permanently disables a key
+func (h *Handler) handleRevokeKey(w http.ResponseWriter, r *http.Request) {
+	if h.keys == nil {
+		writeError(w, http.StatusNotFound, "api keys not available")
+		return
+	}
+
+	if err := h.keys.Revoke(r.PathValue("id")); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,339 @@
+// ABOUTME: Admin endpoints for listing, inspecting and operating on live sessions
+// ABOUTME: Serves session timelines, replays them as paced NDJSON, merges, rolls back, completes and closes sessions
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// maxReplayGap caps the pause between replayed events so long idle stretches don't stall a replay
+const maxReplayGap = 5 * time.Second
+
+// SetSessions sets the session manager used by the session inspection endpoints
+func (h *Handler) SetSessions(manager *session.Manager) {
+	h.sessions = manager
+}
+
+// SetMerger sets the function that merges one joining session into another by code
+func (h *Handler) SetMerger(merge func(targetCode, sourceCode string) error) {
+	h.merge = merge
+}
+
+// SetRollbacker sets the function that moves a live session back to an earlier phase by code
+func (h *Handler) SetRollbacker(rollback func(code string, phase session.Phase) (session.RollbackResult, error)) {
+	h.rollback = rollback
+}
+
+// SetCompleter sets the function that ends a live session early by code
+func (h *Handler) SetCompleter(complete func(code string) error) {
+	h.complete = complete
+}
+
+// SetCloser sets the function that closes and removes a live session by code
+func (h *Handler) SetCloser(closeSession func(code string) error) {
+	h.closeSession = closeSession
+}
+
+// SetKicker sets the function that removes a participant from a live session by code
+func (h *Handler) SetKicker(kick func(code, participantID string) error) {
+	h.kick = kick
+}
+
+// SetConnected sets the function that lists which participants in a session are connected
+func (h *Handler) SetConnected(connected func(sessionID string) []string) {
+	h.connected = connected
+}
+
+// sessionListing is one session in the session list
+type sessionListing struct {
+	ID           string        `json:"id"`
+	Code         string        `json:"sessionCode"`
+	Title        string        `json:"title"`
+	Phase        session.Phase `json:"phase"`
+	Tenant       string        `json:"tenant,omitempty"`
+	Participants int           `json:"participants"`
+	Connected    int           `json:"connected"`
+	Notes        int           `json:"notes"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	CompletedAt  *time.Time    `json:"completedAt,omitempty"`
+}
+
+// participantView is a participant with whether they're connected right now
+type participantView struct {
+	session.Participant
+	Connected bool `json:"connected"`
+}
+
+// handleListSessions lists live sessions, newest first
+// Query parameters: phase=JOINING|WRITING|READING|COMPLETE|BREAKOUT narrows the list
+func (h *Handler) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	if h.sessions == nil {
+		writeError(w, http.StatusNotFound, "sessions not available")
+		return
+	}
+
+	phase := session.Phase(r.URL.Query().Get("phase"))
+	listings := []sessionListing{}
+	for _, sess := range h.sessions.GetAllSessions() {
+		inspection := sess.Inspect()
+		if phase != "" && inspection.Phase != phase {
+			continue
+		}
+		listings = append(listings, sessionListing{
+			ID:           inspection.ID,
+			Code:         inspection.Code,
+			Title:        inspection.Title,
+			Phase:        inspection.Phase,
+			Tenant:       inspection.Tenant,
+			Participants: len(inspection.Participants),
+			Connected:    len(h.connectedIDs(inspection.ID)),
+			Notes:        inspection.Notes,
+			CreatedAt:    inspection.CreatedAt,
+			CompletedAt:  inspection.CompletedAt,
+		})
+	}
+	sort.Slice(listings, func(i, j int) bool {
+		return listings[i].CreatedAt.After(listings[j].CreatedAt)
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"count":    len(listings),
+		"sessions": listings,
+	})
+}
+
+// handleInspectSession returns one session's state, participants and note counts
+// Note content is never included
+func (h *Handler) handleInspectSession(w http.ResponseWriter, r *http.Request) {
+	if h.sessions == nil {
+		writeError(w, http.StatusNotFound, "sessions not available")
+		return
+	}
+
+	sess, err := h.sessions.GetSessionByCode(r.PathValue("code"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	inspection := sess.Inspect()
+
+	connected := make(map[string]bool)
+	for _, id := range h.connectedIDs(inspection.ID) {
+		connected[id] = true
+	}
+	participants := make([]participantView, len(inspection.Participants))
+	for i, participant := range inspection.Participants {
+		participants[i] = participantView{Participant: participant, Connected: connected[participant.ID]}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"session":      inspection,
+		"participants": participants,
+	})
+}
+
+// handleCompleteSession ends a session early, delivering the notes written so far
+func (h *Handler) handleCompleteSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.liveSession(w, r, h.complete != nil)
+	if !ok {
+		return
+	}
+	code := sess.Code
+
+	if err := h.complete(code); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	slog.Info("Admin completed session", "sessionCode", code, "remoteAddr", r.RemoteAddr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCloseSession tells a session's participants it has closed and removes it
+func (h *Handler) handleCloseSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.liveSession(w, r, h.closeSession != nil)
+	if !ok {
+		return
+	}
+	code := sess.Code
+
+	if err := h.closeSession(code); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	slog.Info("Admin closed session", "sessionCode", code, "remoteAddr", r.RemoteAddr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleKickParticipant removes a participant from a session; they can't rejoin in place
+func (h *Handler) handleKickParticipant(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.liveSession(w, r, h.kick != nil)
+	if !ok {
+		return
+	}
+	code := sess.Code
+
+	participantID := r.PathValue("id")
+	if !sess.HasParticipant(participantID) {
+		writeError(w, http.StatusNotFound, "participant not found")
+		return
+	}
+
+	if err := h.kick(code, participantID); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	slog.Info("Admin removed participant", "sessionCode", code, "userID", participantID, "remoteAddr", r.RemoteAddr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// liveSession returns the session the request's code names when the operation is
+// available, otherwise writing a 404
+func (h *Handler) liveSession(w http.ResponseWriter, r *http.Request, available bool) (*session.Session, bool) {
+	if h.sessions == nil || !available {
+		writeError(w, http.StatusNotFound, "session operations not available")
+		return nil, false
+	}
+
+	sess, err := h.sessions.GetSessionByCode(r.PathValue("code"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return nil, false
+	}
+	return sess, true
+}
+
+// connectedIDs lists a session's connected participants, or none without a source
+func (h *Handler) connectedIDs(sessionID string) []string {
+	if h.connected == nil {
+		return nil
+	}
+	return h.connected(sessionID)
+}
+
+// handleMergeSession merges another joining session into this one
+// Body: {"from": "ABC123"}
+func (h *Handler) handleMergeSession(w http.ResponseWriter, r *http.Request) {
+	if h.merge == nil {
+		writeError(w, http.StatusNotFound, "session merging not available")
+		return
+	}
+
+	var body struct {
+		From string `json:"from"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.From == "" {
+		writeError(w, http.StatusBadRequest, "from required")
+		return
+	}
+
+	code := r.PathValue("code")
+	if err := h.merge(code, body.From); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	slog.Info("Admin merged sessions", "sessionCode", code, "from", body.From, "remoteAddr", r.RemoteAddr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRollbackSession repairs a stuck session by moving it back to an earlier phase
+// Body: {"phase": "WRITING"}
+func (h *Handler) handleRollbackSession(w http.ResponseWriter, r *http.Request) {
+	if h.rollback == nil {
+		writeError(w, http.StatusNotFound, "session rollback not available")
+		return
+	}
+
+	var body struct {
+		Phase session.Phase `json:"phase"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Phase == "" {
+		writeError(w, http.StatusBadRequest, "phase required")
+		return
+	}
+
+	code := r.PathValue("code")
+	result, err := h.rollback(code, body.Phase)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	slog.Info("Admin rolled back session", "sessionCode", code, "from", result.From, "to", result.To, "remoteAddr", r.RemoteAddr)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleSessionTimeline returns a session's timeline of joins, transitions, draws and reads
+// Query parameters: replay=true streams events as NDJSON with their original pacing,
+// sped up by speed (default 1, max 1000)
+func (h *Handler) handleSessionTimeline(w http.ResponseWriter, r *http.Request) {
+	if h.sessions == nil {
+		writeError(w, http.StatusNotFound, "sessions not available")
+		return
+	}
+
+	sess, err := h.sessions.GetSessionByCode(r.PathValue("code"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	timeline := sess.Timeline()
+
+	if r.URL.Query().Get("replay") != "true" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"sessionCode": sess.Code,
+			"phase":       sess.GetPhase(),
+			"events":      timeline,
+		})
+		return
+	}
+
+	speed := 1.0
+	if raw := r.URL.Query().Get("speed"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed <= 0 || parsed > 1000 {
+			writeError(w, http.StatusBadRequest, "speed must be between 0 and 1000")
+			return
+		}
+		speed = parsed
+	}
+
+	replayTimeline(w, r, timeline, speed)
+}
+
+// replayTimeline writes one event per line, pausing between events for their
+// original gap divided by speed; stops early if the client goes away
+func replayTimeline(w http.ResponseWriter, r *http.Request, timeline []session.TimelineEvent, speed float64) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	for i, event := range timeline {
+		if i > 0 {
+			gap := time.Duration(float64(event.At.Sub(timeline[i-1].At)) / speed)
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(min(gap, maxReplayGap)):
+			}
+		}
+
+		if err := encoder.Encode(event); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
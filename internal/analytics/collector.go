@@ -0,0 +1,190 @@
+// ABOUTME: In-memory usage analytics collected from session lifecycle events
+// ABOUTME: Aggregates daily counts that can be rolled up into daily or weekly buckets
+package analytics
+
+import (
+	"sync"
+	"time"
+)
+
+// retention is how long daily counts are kept
+const retention = 400 * 24 * time.Hour
+
+// Period selects the bucket size for usage statistics
+type Period string
+
+const (
+	PeriodDaily  Period = "daily"
+	PeriodWeekly Period = "weekly"
+)
+
+// dayCounts holds the raw counts recorded for one UTC day
+type dayCounts struct {
+	created      int
+	completed    int
+	abandoned    int
+	participants int // Total participants across completed sessions
+	ratings      int
+	ratingTotal  int
+}
+
+// Bucket is the usage summary for one day or week
+type Bucket struct {
+	Start             time.Time `json:"start"`
+	SessionsCreated   int       `json:"sessionsCreated"`
+	SessionsCompleted int       `json:"sessionsCompleted"`
+	SessionsAbandoned int       `json:"sessionsAbandoned"`
+	AbandonmentRate   float64   `json:"abandonmentRate"` // Abandoned / (completed + abandoned)
+	AvgParticipants   float64   `json:"avgParticipants"` // Across completed sessions
+	Ratings           int       `json:"ratings"`
+	AvgRating         float64   `json:"avgRating"`
+}
+
+// Collector records session lifecycle events for usage statistics
+// No session, participant, or note identifiers are stored
+type Collector struct {
+	days map[time.Time]*dayCounts // UTC midnight -> counts
+	now  func() time.Time
+	mu   sync.Mutex
+}
+
+// NewCollector creates a new analytics collector
+func NewCollector() *Collector {
+	return &Collector{
+		days: make(map[time.Time]*dayCounts),
+		now:  time.Now,
+	}
+}
+
+// RecordSessionCreated counts a newly created session
+func (c *Collector) RecordSessionCreated() {
+	c.record(func(d *dayCounts) {
+		d.created++
+	})
+}
+
+// RecordSessionCompleted counts a session that finished reading
+func (c *Collector) RecordSessionCompleted(participants int) {
+	c.record(func(d *dayCounts) {
+		d.completed++
+		d.participants += participants
+	})
+}
+
+// RecordSessionAbandoned counts a session that ended before completing
+func (c *Collector) RecordSessionAbandoned() {
+	c.record(func(d *dayCounts) {
+		d.abandoned++
+	})
+}
+
+// RecordRating counts an anonymous 1-5 completion rating
+func (c *Collector) RecordRating(score int) {
+	c.record(func(d *dayCounts) {
+		d.ratings++
+		d.ratingTotal += score
+	})
+}
+
+// record applies an update to today's counts and drops expired days
+// Recording on a nil Collector is a no-op so analytics stays optional
+func (c *Collector) record(update func(*dayCounts)) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now().UTC()
+	today := startOfDay(now)
+	counts, exists := c.days[today]
+	if !exists {
+		counts = &dayCounts{}
+		c.days[today] = counts
+
+		// Expire old days whenever a new day starts
+		cutoff := now.Add(-retention)
+		for day := range c.days {
+			if day.Before(cutoff) {
+				delete(c.days, day)
+			}
+		}
+	}
+	update(counts)
+}
+
+// Stats returns buckets of the given period covering the last `days` days, oldest first
+// Buckets with no activity are included so the series can be charted directly
+func (c *Collector) Stats(period Period, days int) []Bucket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now().UTC()
+	end := startOfDay(now)
+	start := end.AddDate(0, 0, -(days - 1))
+
+	bucketStart := startOfDay
+	step := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	if period == PeriodWeekly {
+		bucketStart = startOfWeek
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	}
+
+	totals := make(map[time.Time]*dayCounts)
+	buckets := []time.Time{}
+	for b := bucketStart(start); !b.After(end); b = step(b) {
+		totals[b] = &dayCounts{}
+		buckets = append(buckets, b)
+	}
+
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		counts, exists := c.days[day]
+		if !exists {
+			continue
+		}
+		total := totals[bucketStart(day)]
+		total.created += counts.created
+		total.completed += counts.completed
+		total.abandoned += counts.abandoned
+		total.participants += counts.participants
+		total.ratings += counts.ratings
+		total.ratingTotal += counts.ratingTotal
+	}
+
+	result := make([]Bucket, 0, len(buckets))
+	for _, b := range buckets {
+		total := totals[b]
+		bucket := Bucket{
+			Start:             b,
+			SessionsCreated:   total.created,
+			SessionsCompleted: total.completed,
+			SessionsAbandoned: total.abandoned,
+			Ratings:           total.ratings,
+		}
+		if ended := total.completed + total.abandoned; ended > 0 {
+			bucket.AbandonmentRate = float64(total.abandoned) / float64(ended)
+		}
+		if total.completed > 0 {
+			bucket.AvgParticipants = float64(total.participants) / float64(total.completed)
+		}
+		if total.ratings > 0 {
+			bucket.AvgRating = float64(total.ratingTotal) / float64(total.ratings)
+		}
+		result = append(result, bucket)
+	}
+	return result
+}
+
+// startOfDay returns UTC midnight of the given time's day
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// startOfWeek returns UTC midnight of the Monday starting the given time's week
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	return day.AddDate(0, 0, -offset)
+}
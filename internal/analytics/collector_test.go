@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyStats(t *testing.T) {
+	collector := NewCollector()
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC) // Wednesday
+	collector.now = func() time.Time { return now }
+
+	collector.RecordSessionCreated()
+	collector.RecordSessionCreated()
+	collector.RecordSessionCompleted(4)
+	collector.RecordSessionAbandoned()
+	collector.RecordRating(5)
+	collector.RecordRating(3)
+
+	// Activity on an earlier day
+	now = now.AddDate(0, 0, -2)
+	collector.RecordSessionCreated()
+	collector.RecordSessionCompleted(6)
+	now = now.AddDate(0, 0, 2)
+
+	buckets := collector.Stats(PeriodDaily, 3)
+	if len(buckets) != 3 {
+		t.Fatalf("Expected 3 daily buckets, got %d", len(buckets))
+	}
+
+	first := buckets[0]
+	if first.SessionsCreated != 1 || first.SessionsCompleted != 1 || first.AvgParticipants != 6 {
+		t.Errorf("Unexpected first bucket: %+v", first)
+	}
+
+	if empty := buckets[1]; empty.SessionsCreated != 0 || empty.AbandonmentRate != 0 {
+		t.Errorf("Expected empty middle bucket, got %+v", empty)
+	}
+
+	today := buckets[2]
+	if today.SessionsCreated != 2 {
+		t.Errorf("Expected 2 sessions created today, got %d", today.SessionsCreated)
+	}
+	if today.AbandonmentRate != 0.5 {
+		t.Errorf("Expected abandonment rate 0.5, got %v", today.AbandonmentRate)
+	}
+	if today.AvgParticipants != 4 {
+		t.Errorf("Expected 4 average participants, got %v", today.AvgParticipants)
+	}
+	if today.Ratings != 2 || today.AvgRating != 4 {
+		t.Errorf("Expected 2 ratings averaging 4, got %d averaging %v", today.Ratings, today.AvgRating)
+	}
+}
+
+func TestWeeklyStats(t *testing.T) {
+	collector := NewCollector()
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC) // Wednesday
+	collector.now = func() time.Time { return now }
+
+	collector.RecordSessionCreated()
+
+	// Previous week's Sunday
+	now = time.Date(2025, 3, 9, 10, 0, 0, 0, time.UTC)
+	collector.RecordSessionCreated()
+	collector.RecordSessionCreated()
+	now = time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC)
+
+	buckets := collector.Stats(PeriodWeekly, 14)
+	if len(buckets) != 3 {
+		t.Fatalf("Expected 3 weekly buckets, got %d", len(buckets))
+	}
+
+	for _, bucket := range buckets {
+		if bucket.Start.Weekday() != time.Monday {
+			t.Errorf("Expected weekly buckets to start on Monday, got %s", bucket.Start.Weekday())
+		}
+	}
+
+	if buckets[1].SessionsCreated != 2 {
+		t.Errorf("Expected 2 sessions in previous week, got %d", buckets[1].SessionsCreated)
+	}
+	if buckets[2].SessionsCreated != 1 {
+		t.Errorf("Expected 1 session this week, got %d", buckets[2].SessionsCreated)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	collector := NewCollector()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	collector.now = func() time.Time { return now }
+
+	collector.RecordSessionCreated()
+
+	now = now.Add(retention + 24*time.Hour)
+	collector.RecordSessionCreated()
+
+	if len(collector.days) != 1 {
+		t.Errorf("Expected expired days to be dropped, got %d days", len(collector.days))
+	}
+}
@@ -0,0 +1,176 @@
+// ABOUTME: Per-facilitator history of circles run, for a host-facing dashboard
+// ABOUTME: Keyed by a hash of the host's key and served to whoever presents that key
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxCirclesPerHost bounds how many circles are kept for one host, oldest dropped first
+	maxCirclesPerHost = 1000
+
+	// defaultHistoryWeeks and maxHistoryWeeks bound the weekly trend series
+	defaultHistoryWeeks = 12
+	maxHistoryWeeks     = 52
+)
+
+// Circle summarises one session a host ran once it has ended
+type Circle struct {
+	EndedAt      time.Time
+	Completed    bool // False if everyone left before every note was read
+	Participants int  // Participants present at the end
+	Contributors int  // Participants who wrote at least one note
+	Notes        int
+}
+
+// HostWeek is one week of a host's activity
+type HostWeek struct {
+	Start     time.Time `json:"start"`
+	Circles   int       `json:"circles"`
+	Completed int       `json:"completed"`
+	Notes     int       `json:"notes"`
+}
+
+// HostSummary is a host's aggregate history
+type HostSummary struct {
+	CirclesRun        int        `json:"circlesRun"`
+	CirclesCompleted  int        `json:"circlesCompleted"`
+	CompletionRate    float64    `json:"completionRate"`    // Completed / run
+	AvgParticipants   float64    `json:"avgParticipants"`   // Across completed circles
+	ParticipationRate float64    `json:"participationRate"` // Share of participants who wrote a note, across completed circles
+	AvgNotes          float64    `json:"avgNotes"`          // Across completed circles
+	Weeks             []HostWeek `json:"weeks"`             // Oldest first, including empty weeks
+}
+
+// HostHistory records circles per host
+// Only hashes of host keys are stored, and no session or participant identifiers
+// History is held in memory and starts empty on restart
+type HostHistory struct {
+	hosts map[string][]Circle // sha256(host key) -> circles, oldest first
+	now   func() time.Time
+	mu    sync.Mutex
+}
+
+// NewHostHistory creates an empty host history
+func NewHostHistory() *HostHistory {
+	return &HostHistory{
+		hosts: make(map[string][]Circle),
+		now:   time.Now,
+	}
+}
+
+// Record adds an ended circle to a host's history
+// Recording on a nil HostHistory or without a host key is a no-op
+func (h *HostHistory) Record(hostKey string, circle Circle) {
+	if h == nil || hostKey == "" {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id := hashHostKey(hostKey)
+	cutoff := h.now().Add(-retention)
+	kept := h.hosts[id][:0]
+	for _, c := range h.hosts[id] {
+		if c.EndedAt.After(cutoff) {
+			kept = append(kept, c)
+		}
+	}
+	kept = append(kept, circle)
+	if len(kept) > maxCirclesPerHost {
+		kept = kept[len(kept)-maxCirclesPerHost:]
+	}
+	h.hosts[id] = kept
+}
+
+// Summary returns a host's aggregate history with a weekly series covering the last `weeks` weeks
+func (h *HostHistory) Summary(hostKey string, weeks int) HostSummary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	end := startOfWeek(h.now())
+	start := end.AddDate(0, 0, -7*(weeks-1))
+
+	summary := HostSummary{Weeks: make([]HostWeek, 0, weeks)}
+	index := make(map[time.Time]int)
+	for w := start; !w.After(end); w = w.AddDate(0, 0, 7) {
+		index[w] = len(summary.Weeks)
+		summary.Weeks = append(summary.Weeks, HostWeek{Start: w})
+	}
+
+	var participants, contributors, notes int
+	for _, c := range h.hosts[hashHostKey(hostKey)] {
+		summary.CirclesRun++
+		if c.Completed {
+			summary.CirclesCompleted++
+			participants += c.Participants
+			contributors += c.Contributors
+			notes += c.Notes
+		}
+
+		if i, ok := index[startOfWeek(c.EndedAt)]; ok {
+			week := &summary.Weeks[i]
+			week.Circles++
+			week.Notes += c.Notes
+			if c.Completed {
+				week.Completed++
+			}
+		}
+	}
+
+	if summary.CirclesRun > 0 {
+		summary.CompletionRate = float64(summary.CirclesCompleted) / float64(summary.CirclesRun)
+	}
+	if summary.CirclesCompleted > 0 {
+		summary.AvgParticipants = float64(participants) / float64(summary.CirclesCompleted)
+		summary.AvgNotes = float64(notes) / float64(summary.CirclesCompleted)
+	}
+	if participants > 0 {
+		summary.ParticipationRate = float64(contributors) / float64(participants)
+	}
+	return summary
+}
+
+// ServeHTTP returns the summary for the host key given as a bearer token
+// ?weeks=N sets the length of the weekly series (default 12, max 52)
+func (h *HostHistory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hostKey, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || hostKey == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	weeks := defaultHistoryWeeks
+	if value := r.URL.Query().Get("weeks"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxHistoryWeeks {
+			http.Error(w, "weeks must be between 1 and 52", http.StatusBadRequest)
+			return
+		}
+		weeks = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(h.Summary(hostKey, weeks))
+}
+
+// hashHostKey keeps raw host keys out of memory dumps and logs
+func hashHostKey(hostKey string) string {
+	sum := sha256.Sum256([]byte(hostKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHostSummary(t *testing.T) {
+	history := NewHostHistory()
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC) // Wednesday
+	history.now = func() time.Time { return now }
+
+	history.Record("host-a", Circle{EndedAt: now, Completed: true, Participants: 4, Contributors: 3, Notes: 9})
+	history.Record("host-a", Circle{EndedAt: now.AddDate(0, 0, -7), Completed: true, Participants: 6, Contributors: 6, Notes: 15})
+	history.Record("host-a", Circle{EndedAt: now, Completed: false, Notes: 2})
+	history.Record("host-b", Circle{EndedAt: now, Completed: true, Participants: 10, Contributors: 10, Notes: 40})
+	history.Record("", Circle{EndedAt: now, Completed: true})
+
+	summary := history.Summary("host-a", 4)
+	if summary.CirclesRun != 3 || summary.CirclesCompleted != 2 {
+		t.Errorf("Expected 3 circles with 2 completed, got %+v", summary)
+	}
+	if summary.AvgParticipants != 5 || summary.AvgNotes != 12 {
+		t.Errorf("Expected averages across completed circles, got %+v", summary)
+	}
+	if summary.ParticipationRate != 0.9 {
+		t.Errorf("Expected participation rate 0.9, got %v", summary.ParticipationRate)
+	}
+
+	if len(summary.Weeks) != 4 {
+		t.Fatalf("Expected 4 weekly buckets, got %d", len(summary.Weeks))
+	}
+	if last := summary.Weeks[3]; last.Circles != 2 || last.Completed != 1 || last.Notes != 11 {
+		t.Errorf("Unexpected current week: %+v", last)
+	}
+	if prev := summary.Weeks[2]; prev.Circles != 1 || prev.Notes != 15 {
+		t.Errorf("Unexpected previous week: %+v", prev)
+	}
+
+	if unknown := history.Summary("nobody", 4); unknown.CirclesRun != 0 || len(unknown.Weeks) != 4 {
+		t.Errorf("Expected an empty history for unknown hosts, got %+v", unknown)
+	}
+}
+
+func TestHostHistoryHTTP(t *testing.T) {
+	history := NewHostHistory()
+	history.Record("host-a", Circle{EndedAt: time.Now(), Completed: true, Participants: 3, Notes: 6})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/host/history", nil)
+	rec := httptest.NewRecorder()
+	history.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a host key, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/host/history?weeks=100", nil)
+	req.Header.Set("Authorization", "Bearer host-a")
+	rec = httptest.NewRecorder()
+	history.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for too many weeks, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/host/history", nil)
+	req.Header.Set("Authorization", "Bearer host-a")
+	rec = httptest.NewRecorder()
+	history.ServeHTTP(rec, req)
+
+	var summary HostSummary
+	if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	if summary.CirclesRun != 1 || len(summary.Weeks) != defaultHistoryWeeks {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
@@ -0,0 +1,253 @@
+// ABOUTME: Public HTTP API for scripts, integrations and dashboards that don't speak the WebSocket protocol
+// ABOUTME: Creates sessions, summarises them by code and serves a completed circle's notes by session ID
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/abuse"
+	"github.com/cassiascheffer/uplift/internal/apikeys"
+	"github.com/cassiascheffer/uplift/internal/branding"
+	"github.com/cassiascheffer/uplift/internal/ratelimit"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// maxBodyBytes bounds a create request; the options are a handful of small fields
+const maxBodyBytes = 64 << 10
+
+// Creator creates a session from JSON create_session options for a tenant, returning it and its host
+// remoteIP is the caller's address, for limits on how many sessions one address may create;
+// keyed callers authenticated with an API key, which stands in for the proof-of-work challenge
+type Creator func(options []byte, tenant, remoteIP string, keyed bool) (*session.Session, *session.Participant, error)
+
+// retryable is an error refusing a request for now, saying when to try again
+type retryable interface {
+	error
+	RetryAfter() time.Duration
+}
+
+// Handler serves the session API under /api/sessions
+type Handler struct {
+	sessions  *session.Manager
+	create    Creator
+	branding  *branding.Registry
+	keys      *apikeys.Store                // Keys that may create sessions without a challenge (nil = none)
+	challenge func() map[string]interface{} // Issues a session creation challenge (nil = none required)
+	draining  func() bool                   // Reports whether the server is draining for a restart (nil = never)
+	mux       *http.ServeMux
+}
+
+// Patterns are the routes the server mux must send to a Handler
+var Patterns = []string{"/api/challenge", "/api/sessions", "/api/sessions/"}
+
+// NewHandler creates an API handler that looks sessions up in manager and creates them with create
+func NewHandler(manager *session.Manager, create Creator) *Handler {
+	h := &Handler{
+		sessions: manager,
+		create:   create,
+		mux:      http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /api/challenge", h.handleGetChallenge)
+	h.mux.HandleFunc("POST /api/sessions", h.handleCreateSession)
+	h.mux.HandleFunc("GET /api/sessions/{code}", h.handleGetSession)
+	h.mux.HandleFunc("GET /api/sessions/{id}/notes", h.handleGetNotes)
+
+	return h
+}
+
+// SetBranding sets the tenants sessions are created for, chosen like WebSocket connections'
+func (h *Handler) SetBranding(registry *branding.Registry) {
+	h.branding = registry
+}
+
+// SetAPIKeys lets callers holding a key with the sessions:create scope create sessions
+// without solving a challenge
+func (h *Handler) SetAPIKeys(keys *apikeys.Store) {
+	h.keys = keys
+}
+
+// SetChallenge sets how to issue the proof-of-work challenge that creating a session
+// without an API key requires, as get_challenge does over the WebSocket
+func (h *Handler) SetChallenge(challenge func() map[string]interface{}) {
+	h.challenge = challenge
+}
+
+// SetDraining sets how to tell the server is draining for a restart, when new sessions are refused
+func (h *Handler) SetDraining(draining func() bool) {
+	h.draining = draining
+}
+
+// ServeHTTP routes the request to the session endpoints
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// handleGetChallenge issues a proof-of-work challenge to solve before creating a session
+func (h *Handler) handleGetChallenge(w http.ResponseWriter, r *http.Request) {
+	if h.challenge == nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"required": false})
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, h.challenge())
+}
+
+// handleCreateSession creates a session whose host isn't connected yet
+// Body: the create_session options, e.g. {"userName": "Sam", "welcome": "...", "autoRun": true},
+// with a solved challenge and solution unless the request carries an API key with sessions:create
+// The response's rejoinToken lets the host take their seat with rejoin_session
+func (h *Handler) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	if h.draining != nil && h.draining() {
+		writeError(w, http.StatusServiceUnavailable, "server is restarting, try again shortly")
+		return
+	}
+
+	keyed := false
+	if secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		key, ok := h.keys.Authenticate(secret)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "invalid api key")
+			return
+		}
+		if !key.Allows(apikeys.ScopeSessionsCreate) {
+			slog.Warn("API key lacks scope to create sessions", "keyID", key.ID)
+			writeError(w, http.StatusForbidden, "api key not permitted")
+			return
+		}
+		keyed = true
+	}
+
+	options, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+
+	sess, host, err := h.create(options, h.branding.Resolve(r), ratelimit.ClientIP(r), keyed)
+	var limited retryable
+	if errors.As(err, &limited) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter().Seconds()))))
+		writeError(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if challengeFailed(err) {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, err := sess.IssueRejoinToken(host.ID)
+	if err != nil {
+		slog.Error("API failed to issue host rejoin token", "sessionCode", sess.Code, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to create session")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"sessionId":   sess.ID,
+		"sessionCode": sess.Code,
+		"hostId":      host.ID,
+		"rejoinToken": token,
+		"phase":       sess.Summarize().Phase,
+	})
+}
+
+// handleGetSession summarises a session by its join code
+// The session ID is left out: anyone with the code can see this, but only those
+// given the ID may read the notes
+func (h *Handler) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	sess, err := h.sessions.GetSessionByCode(r.PathValue("code"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sess.Summarize())
+}
+
+// handleGetNotes returns a completed session's read-aloud notes, without their authors
+// Private notes stay with their recipients and are left out
+func (h *Handler) handleGetNotes(w http.ResponseWriter, r *http.Request) {
+	sess, err := h.sessions.GetSessionByID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	summary := sess.Summarize()
+	if summary.Phase != session.PhaseComplete {
+		writeError(w, http.StatusConflict, "notes are available once the session is complete")
+		return
+	}
+
+	names := make(map[string]string)
+	for _, participant := range sess.GetParticipantList() {
+		names[participant.ID] = participant.Name
+	}
+
+	notes := []map[string]interface{}{}
+	for _, note := range sess.GetReadAloudNotes() {
+		entry := map[string]interface{}{
+			"id":          note.ID,
+			"content":     note.Content,
+			"recipientId": note.RecipientID,
+			"recipient":   names[note.RecipientID],
+		}
+		if note.GIFURL != "" {
+			entry["gifUrl"] = note.GIFURL
+		}
+		notes = append(notes, entry)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sessionCode": summary.Code,
+		"title":       summary.Title,
+		"completedAt": summary.CompletedAt,
+		"notes":       notes,
+	})
+}
+
+// challengeFailed reports whether a session was refused for a missing or wrong challenge solution
+func challengeFailed(err error) bool {
+	for _, target := range []error{
+		abuse.ErrChallengeRequired,
+		abuse.ErrChallengeInvalid,
+		abuse.ErrChallengeExpired,
+		abuse.ErrChallengeReused,
+		abuse.ErrChallengeUnsolved,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("API response encoding error", "err", err)
+	}
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": message,
+	})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/abuse"
+	"github.com/cassiascheffer/uplift/internal/apikeys"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// newTestHandler creates an API handler whose sessions are created directly in a manager
+func newTestHandler() (*Handler, *session.Manager) {
+	manager := session.NewManager()
+	create := func(options []byte, tenant, remoteIP string, keyed bool) (*session.Session, *session.Participant, error) {
+		var req struct {
+			UserName string `json:"userName"`
+		}
+		if err := json.Unmarshal(options, &req); err != nil {
+			return nil, nil, err
+		}
+		sess := manager.CreateSession(req.UserName)
+		return sess, sess.GetParticipantList()[0], nil
+	}
+	return NewHandler(manager, create), manager
+}
+
+// doRequest sends a request to the handler and decodes the JSON response
+func doRequest(t *testing.T, handler http.Handler, method, target, body string) (int, map[string]interface{}) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, decoded
+}
+
+func TestCreateAndGetSession(t *testing.T) {
+	handler, manager := newTestHandler()
+
+	if status, _ := doRequest(t, handler, http.MethodPost, "/api/sessions", "not json"); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid body, got %d", status)
+	}
+
+	status, created := doRequest(t, handler, http.MethodPost, "/api/sessions", `{"userName": "Sam"}`)
+	if status != http.StatusCreated || created["rejoinToken"] == "" || created["hostId"] == "" {
+		t.Fatalf("Expected the session created with a host rejoin token, got %d %v", status, created)
+	}
+
+	sess, err := manager.GetSessionByID(created["sessionId"].(string))
+	if err != nil || sess.Code != created["sessionCode"] {
+		t.Fatalf("Expected the created session in the manager, got err=%v", err)
+	}
+
+	status, summary := doRequest(t, handler, http.MethodGet, "/api/sessions/"+strings.ToLower(sess.Code), "")
+	if status != http.StatusOK || summary["sessionCode"] != sess.Code || summary["phase"] != string(session.PhaseJoining) || summary["participants"] != float64(1) {
+		t.Errorf("Expected the session's summary, got %d %v", status, summary)
+	}
+	if _, leaked := summary["sessionId"]; leaked {
+		t.Error("Expected the summary not to reveal the session ID")
+	}
+
+	if status, _ := doRequest(t, handler, http.MethodGet, "/api/sessions/NOPE00", ""); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown code, got %d", status)
+	}
+}
+
+func TestGetNotes(t *testing.T) {
+	handler, manager := newTestHandler()
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+
+	if status, _ := doRequest(t, handler, http.MethodGet, "/api/sessions/"+sess.ID+"/notes", ""); status != http.StatusConflict {
+		t.Errorf("Expected 409 before the session completes, got %d", status)
+	}
+
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, bob.ID, "Thanks for the pairing")
+	sess.AddPrivateNote(bob.ID, alice.ID, "Just for you")
+	sess.Phase = session.PhaseComplete
+
+	status, body := doRequest(t, handler, http.MethodGet, "/api/sessions/"+sess.ID+"/notes", "")
+	notes, _ := body["notes"].([]interface{})
+	if status != http.StatusOK || len(notes) != 1 {
+		t.Fatalf("Expected only the read-aloud note, got %d %v", status, body)
+	}
+	note := notes[0].(map[string]interface{})
+	if note["recipient"] != "Bob" || note["content"] != "Thanks for the pairing" {
+		t.Errorf("Expected the note to Bob, got %v", note)
+	}
+	if _, exposed := note["authorId"]; exposed {
+		t.Error("Expected notes not to reveal their authors")
+	}
+
+	if status, _ := doRequest(t, handler, http.MethodGet, "/api/sessions/"+sess.Code+"/notes", ""); status != http.StatusNotFound {
+		t.Errorf("Expected notes to be looked up by ID rather than code, got %d", status)
+	}
+}
+
+func TestCreateRefusedWhileDraining(t *testing.T) {
+	handler, manager := newTestHandler()
+	handler.SetDraining(func() bool { return true })
+
+	if status, _ := doRequest(t, handler, http.MethodPost, "/api/sessions", `{"userName": "Sam"}`); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while draining, got %d", status)
+	}
+	if manager.GetActiveSessionCount() != 0 {
+		t.Error("Expected no session to be created")
+	}
+}
+
+// limitError refuses a request for now, like a per-IP session limit
+type limitError struct{}
+
+func (limitError) Error() string             { return "too many sessions" }
+func (limitError) RetryAfter() time.Duration { return 90 * time.Second }
+
+func TestCreateRefusedOverSessionLimit(t *testing.T) {
+	var remoteIP string
+	handler := NewHandler(session.NewManager(), func(options []byte, tenant, ip string, keyed bool) (*session.Session, *session.Participant, error) {
+		remoteIP = ip
+		return nil, nil, limitError{}
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sessions", strings.NewReader(`{"userName": "Sam"}`)))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "90" {
+		t.Errorf("Expected 429 with Retry-After 90, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if remoteIP != "192.0.2.1" {
+		t.Errorf("Expected the caller's address to be passed on, got %q", remoteIP)
+	}
+}
+
+func TestCreateWithChallengeOrAPIKey(t *testing.T) {
+	keys, _ := apikeys.NewStore("")
+	_, creator, _ := keys.Create("scheduler", []apikeys.Scope{apikeys.ScopeSessionsCreate})
+	_, reader, _ := keys.Create("reporting", []apikeys.Scope{apikeys.ScopeSessionsRead})
+
+	manager := session.NewManager()
+	var keyedCalls []bool
+	handler := NewHandler(manager, func(options []byte, tenant, ip string, keyed bool) (*session.Session, *session.Participant, error) {
+		keyedCalls = append(keyedCalls, keyed)
+		if !keyed {
+			return nil, nil, abuse.ErrChallengeRequired
+		}
+		sess := manager.CreateSession("Sam")
+		return sess, sess.GetParticipantList()[0], nil
+	})
+	handler.SetAPIKeys(keys)
+	handler.SetChallenge(func() map[string]interface{} {
+		return map[string]interface{}{"required": true, "challenge": "abc", "difficulty": 16}
+	})
+
+	if status, body := doRequest(t, handler, http.MethodGet, "/api/challenge", ""); status != http.StatusOK || body["challenge"] != "abc" {
+		t.Errorf("Expected a challenge to be issued, got %d %v", status, body)
+	}
+
+	post := func(secret string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/sessions", strings.NewReader(`{"userName": "Sam"}`))
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if status := post(""); status != http.StatusForbidden {
+		t.Errorf("Expected 403 without a solved challenge, got %d", status)
+	}
+	if status := post(creator); status != http.StatusCreated {
+		t.Errorf("Expected a sessions:create key to create a session, got %d", status)
+	}
+	if status := post(reader); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a key without sessions:create, got %d", status)
+	}
+	if status := post("upk_nope_nope"); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unknown key, got %d", status)
+	}
+	if len(keyedCalls) != 2 || keyedCalls[0] || !keyedCalls[1] {
+		t.Errorf("Expected only the scoped key's request to be created as keyed, got %v", keyedCalls)
+	}
+}
@@ -0,0 +1,304 @@
+// ABOUTME: Scoped API keys so integrations can call the REST API without the admin token
+// ABOUTME: Stores only key hashes, with optional JSON file persistence and revocation
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scope grants access to one area of the API
+type Scope string
+
+const (
+	ScopeStatsRead         Scope = "stats:read"         // Usage statistics and runtime metrics
+	ScopeFeaturesRead      Scope = "features:read"      // Feature flag state
+	ScopeFeaturesWrite     Scope = "features:write"     // Toggling feature flags
+	ScopeNotificationsRead Scope = "notifications:read" // Notification delivery status
+	ScopeSessionsRead      Scope = "sessions:read"      // Session timelines and observation
+	ScopeSessionsWrite     Scope = "sessions:write"     // Merging sessions
+	ScopeSessionsCreate    Scope = "sessions:create"    // Creating sessions over the session API without a challenge
+	ScopeBansRead          Scope = "bans:read"          // Listing bans
+	ScopeBansWrite         Scope = "bans:write"         // Adding and lifting bans
+	ScopeDiagnosticsRead   Scope = "diagnostics:read"   // Unprocessable messages and other debugging aids
+	ScopeContentRead       Scope = "content:read"       // Writing aids such as sentence starters
+	ScopeContentWrite      Scope = "content:write"      // Changing writing aids
+)
+
+// Scopes lists every scope a key can be granted
+var Scopes = []Scope{
+	ScopeStatsRead,
+	ScopeFeaturesRead,
+	ScopeFeaturesWrite,
+	ScopeNotificationsRead,
+	ScopeSessionsRead,
+	ScopeSessionsWrite,
+	ScopeSessionsCreate,
+	ScopeBansRead,
+	ScopeBansWrite,
+	ScopeDiagnosticsRead,
+	ScopeContentRead,
+	ScopeContentWrite,
+}
+
+// keyPrefix marks uplift API keys so they're recognisable in configs and secret scanners
+const keyPrefix = "upk_"
+
+// maxNameLength bounds the label an operator gives a key
+const maxNameLength = 100
+
+// Key is an issued API key; the secret itself is never stored
+type Key struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []Scope    `json:"scopes"`
+	Hash       string     `json:"hash,omitempty"` // sha256 of the full key, hex
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Allows reports whether the key grants a scope
+func (k *Key) Allows(scope Scope) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// public returns a copy safe to show operators
+func (k *Key) public() Key {
+	c := *k
+	c.Hash = ""
+	c.Scopes = append([]Scope(nil), k.Scopes...)
+	return c
+}
+
+// Store holds issued API keys
+type Store struct {
+	keys map[string]*Key // ID -> key
+	path string          // JSON file keys are persisted to (empty = memory only)
+	mu   sync.Mutex
+}
+
+// NewStore creates a key store persisted to path, loading any existing keys
+// An empty path keeps keys in memory only
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		keys: make(map[string]*Key),
+		path: path,
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading api keys: %w", err)
+	}
+
+	var keys []*Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("parsing api keys: %w", err)
+	}
+	for _, key := range keys {
+		s.keys[key.ID] = key
+	}
+
+	slog.Info("API keys loaded", "path", path, "keys", len(s.keys))
+	return s, nil
+}
+
+// ParseScopes validates requested scopes, rejecting unknown or missing ones
+func ParseScopes(values []string) ([]Scope, error) {
+	if len(values) == 0 {
+		return nil, errors.New("at least one scope required")
+	}
+
+	scopes := make([]Scope, 0, len(values))
+	seen := make(map[Scope]bool)
+	for _, value := range values {
+		scope := Scope(strings.TrimSpace(value))
+		known := false
+		for _, s := range Scopes {
+			if s == scope {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown scope: %s", value)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// Create issues a new key and returns it along with the secret, which is shown only once
+func (s *Store) Create(name string, scopes []Scope) (Key, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Key{}, "", errors.New("name required")
+	}
+	if len(name) > maxNameLength {
+		return Key{}, "", fmt.Errorf("name must be %d characters or fewer", maxNameLength)
+	}
+	if len(scopes) == 0 {
+		return Key{}, "", errors.New("at least one scope required")
+	}
+
+	id := randomHex(8)
+	secret := keyPrefix + id + "_" + randomHex(24)
+	key := &Key{
+		ID:        id,
+		Name:      name,
+		Scopes:    append([]Scope(nil), scopes...),
+		Hash:      hashKey(secret),
+		CreatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[id] = key
+	if err := s.saveUnlocked(); err != nil {
+		delete(s.keys, id)
+		return Key{}, "", err
+	}
+
+	slog.Info("API key created", "keyID", id, "name", name, "scopes", scopes)
+	return key.public(), secret, nil
+}
+
+// Revoke permanently disables a key; revoked keys stay listed for auditing
+func (s *Store) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.keys[id]
+	if !exists {
+		return errors.New("api key not found")
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := s.saveUnlocked(); err != nil {
+		key.RevokedAt = nil
+		return err
+	}
+
+	slog.Info("API key revoked", "keyID", id, "name", key.Name)
+	return nil
+}
+
+// List returns every key, newest first, without hashes
+func (s *Store) List() []Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key.public())
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+// Authenticate returns the active key matching a presented secret
+// Safe to call on a nil *Store, which authenticates nothing
+func (s *Store) Authenticate(secret string) (Key, bool) {
+	if s == nil {
+		return Key{}, false
+	}
+
+	// The ID is embedded in the key so lookup doesn't need to scan every hash
+	rest, ok := strings.CutPrefix(secret, keyPrefix)
+	if !ok {
+		return Key{}, false
+	}
+	id, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return Key{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.keys[id]
+	if !exists || key.RevokedAt != nil {
+		return Key{}, false
+	}
+	if subtle.ConstantTimeCompare([]byte(hashKey(secret)), []byte(key.Hash)) != 1 {
+		return Key{}, false
+	}
+
+	// Last use is informational, so it isn't worth a disk write per request
+	now := time.Now()
+	key.LastUsedAt = &now
+	return key.public(), true
+}
+
+// saveUnlocked writes the keys to disk if persistence is configured
+// Internal helper that assumes caller already holds the lock
+func (s *Store) saveUnlocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding api keys: %w", err)
+	}
+
+	// Write to a temp file and rename so a crash never leaves a partial file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing api keys: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing api keys: %w", err)
+	}
+	return nil
+}
+
+// hashKey hashes a key secret for storage and comparison
+func hashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
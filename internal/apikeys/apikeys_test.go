@@ -0,0 +1,88 @@
+package apikeys
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateAndAuthenticate(t *testing.T) {
+	store, err := NewStore("")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	key, secret, err := store.Create("reporting", []Scope{ScopeStatsRead})
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+	if key.Hash != "" {
+		t.Error("Expected the hash to be hidden from callers")
+	}
+
+	found, ok := store.Authenticate(secret)
+	if !ok || found.ID != key.ID {
+		t.Fatalf("Expected the secret to authenticate, got %+v", found)
+	}
+	if !found.Allows(ScopeStatsRead) || found.Allows(ScopeBansWrite) {
+		t.Errorf("Unexpected scopes: %v", found.Scopes)
+	}
+
+	for _, bad := range []string{"", "upk_", "upk_" + key.ID + "_wrong", secret + "x", "other"} {
+		if _, ok := store.Authenticate(bad); ok {
+			t.Errorf("Expected %q not to authenticate", bad)
+		}
+	}
+
+	if err := store.Revoke(key.ID); err != nil {
+		t.Fatalf("Failed to revoke: %v", err)
+	}
+	if _, ok := store.Authenticate(secret); ok {
+		t.Error("Expected revoked key not to authenticate")
+	}
+	if keys := store.List(); len(keys) != 1 || keys[0].RevokedAt == nil {
+		t.Errorf("Expected revoked key to stay listed, got %+v", keys)
+	}
+	if err := store.Revoke("missing"); err == nil {
+		t.Error("Expected error revoking an unknown key")
+	}
+
+	var nilStore *Store
+	if _, ok := nilStore.Authenticate(secret); ok {
+		t.Error("Expected nil store to authenticate nothing")
+	}
+}
+
+func TestCreateValidation(t *testing.T) {
+	store, _ := NewStore("")
+
+	if _, _, err := store.Create("", []Scope{ScopeStatsRead}); err == nil {
+		t.Error("Expected error for missing name")
+	}
+	if _, _, err := store.Create("reporting", nil); err == nil {
+		t.Error("Expected error for missing scopes")
+	}
+	if _, err := ParseScopes([]string{"stats:read", "root"}); err == nil {
+		t.Error("Expected error for unknown scope")
+	}
+	if scopes, err := ParseScopes([]string{"stats:read", "stats:read"}); err != nil || len(scopes) != 1 {
+		t.Errorf("Expected duplicates to collapse, got %v %v", scopes, err)
+	}
+}
+
+func TestStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	store, _ := NewStore(path)
+	key, secret, err := store.Create("reporting", []Scope{ScopeStatsRead})
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reload store: %v", err)
+	}
+	if found, ok := reloaded.Authenticate(secret); !ok || found.ID != key.ID {
+		t.Error("Expected key to survive a reload")
+	}
+}
@@ -0,0 +1,103 @@
+// ABOUTME: Renders a completed circle's recap as one self-contained HTML file for wikis and shared drives
+// ABOUTME: Styles are inlined and nothing is loaded from the server, so the file opens anywhere, forever
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/branding"
+)
+
+// Note is one note read aloud, anonymous like the in-app recap
+type Note struct {
+	Content string
+	GIFURL  string // Linked rather than embedded so the file has no external dependencies
+}
+
+// Recipient groups the notes one participant received
+type Recipient struct {
+	Name  string
+	Notes []Note
+}
+
+// Recap is what an archive shows
+type Recap struct {
+	Title        string
+	Theme        string // Theme name, if the circle had one
+	CompletedAt  time.Time
+	Participants []string
+	Recipients   []Recipient
+	Branding     branding.Branding
+}
+
+// defaultAccent colours headings when the tenant's palette doesn't set a primary colour
+const defaultAccent = "#4a6fa5"
+
+var page = template.Must(template.New("archive").Funcs(template.FuncMap{
+	"accent": func(b branding.Branding) template.CSS {
+		if colour := b.Colors["primary"]; colour != "" {
+			// Branding only accepts hex colours, so this is safe to inline
+			return template.CSS(colour)
+		}
+		return template.CSS(defaultAccent)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0 auto; max-width: 48rem; padding: 2rem 1rem; font-family: system-ui, sans-serif; line-height: 1.5; color: #222; }
+h1, h2 { color: {{accent .Branding}}; }
+.meta { color: #555; }
+.note { margin: 0 0 1rem; padding: 0.75rem 1rem; border-left: 4px solid {{accent .Branding}}; background: #f7f7f7; white-space: pre-wrap; }
+footer { margin-top: 3rem; font-size: 0.875rem; color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{if .Theme}}{{.Theme}} · {{end}}{{.CompletedAt.Format "2 January 2006"}} · {{len .Participants}} participants</p>
+<p class="meta">With {{range $i, $name := .Participants}}{{if $i}}, {{end}}{{$name}}{{end}}</p>
+{{range .Recipients}}<section>
+<h2>For {{.Name}}</h2>
+{{range .Notes}}<blockquote class="note">{{.Content}}{{if .GIFURL}}
+<a href="{{.GIFURL}}">GIF</a>{{end}}</blockquote>
+{{end}}</section>
+{{end}}<footer>Archived from {{.Branding.ProductName}}. Notes are shown without their authors.</footer>
+</body>
+</html>
+`))
+
+// Render returns the recap as a standalone HTML document
+func Render(recap Recap) ([]byte, error) {
+	if recap.Title == "" {
+		recap.Title = "Gratitude circle"
+	}
+	if recap.Branding.ProductName == "" {
+		recap.Branding.ProductName = branding.DefaultProductName
+	}
+
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, recap); err != nil {
+		return nil, fmt.Errorf("rendering archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// nonSlug matches runs of characters left out of file names
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Filename suggests a download name like "team-retro-2026-10-16.html"
+func Filename(recap Recap) string {
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(recap.Title), "-"), "-")
+	if slug == "" {
+		slug = "gratitude-circle"
+	}
+	return fmt.Sprintf("%s-%s.html", slug, recap.CompletedAt.Format("2006-01-02"))
+}
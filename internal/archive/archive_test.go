@@ -0,0 +1,43 @@
+package archive
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/branding"
+)
+
+func TestRender(t *testing.T) {
+	recap := Recap{
+		Title:        "Team Retro",
+		Theme:        "Year-end",
+		CompletedAt:  time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC),
+		Participants: []string{"Alice", "Bob"},
+		Recipients: []Recipient{
+			{Name: "Bob", Notes: []Note{{Content: "Thanks for <script>fixing</script> the build", GIFURL: "https://media.giphy.com/abc.gif"}}},
+		},
+		Branding: branding.Branding{ProductName: "Acme Kudos", Colors: map[string]string{"primary": "#ff6600"}},
+	}
+
+	html, err := Render(recap)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	page := string(html)
+
+	for _, want := range []string{"Team Retro", "Year-end", "16 October 2026", "For Bob", "Acme Kudos", "#ff6600", "&lt;script&gt;fixing&lt;/script&gt;"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected the archive to contain %q", want)
+		}
+	}
+	// Self-contained: nothing is fetched when the file is opened
+	for _, external := range []string{"<script", "<link", "src="} {
+		if strings.Contains(page, external) {
+			t.Errorf("Expected no external resources, found %q", external)
+		}
+	}
+	if got := Filename(recap); got != "team-retro-2026-10-16.html" {
+		t.Errorf("Unexpected filename: %s", got)
+	}
+}
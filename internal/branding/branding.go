@@ -0,0 +1,166 @@
+// ABOUTME: Per-tenant white-label branding: product name, logo and colour palette
+// ABOUTME: Tenants are matched by request host or a tenant query parameter and loaded from a JSON file
+package branding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultProductName is shown when no branding is configured
+const DefaultProductName = "Uplift"
+
+// TenantParam is the query parameter an embedding page can name its tenant with
+const TenantParam = "tenant"
+
+// maxProductNameLength bounds the product name shown in headings and email senders
+const maxProductNameLength = 60
+
+// Colours a palette may set; the frontend maps each to a CSS custom property
+var paletteKeys = map[string]bool{
+	"primary":    true,
+	"secondary":  true,
+	"accent":     true,
+	"background": true,
+	"text":       true,
+}
+
+// hexColour matches #rgb and #rrggbb
+var hexColour = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Branding is how one tenant's deployment looks
+type Branding struct {
+	ProductName string            `json:"productName"`
+	LogoURL     string            `json:"logoUrl,omitempty"`
+	Colors      map[string]string `json:"colors,omitempty"`
+}
+
+// tenant is one tenant's entry in the branding file
+type tenant struct {
+	Branding
+	Hosts []string `json:"hosts"` // Hostnames that select this tenant, e.g. kudos.example.com
+}
+
+// Registry resolves which tenant's branding applies to a request
+type Registry struct {
+	fallback Branding
+	tenants  map[string]Branding // Tenant ID -> branding
+	hosts    map[string]string   // Lowercased host -> tenant ID
+}
+
+// Load reads a branding file:
+//
+//	{"default": {"productName": "Uplift"},
+//	 "tenants": {"acme": {"hosts": ["kudos.acme.com"], "productName": "Acme Kudos",
+//	                      "logoUrl": "https://acme.com/logo.svg", "colors": {"primary": "#ff6600"}}}}
+//
+// An empty path returns a nil *Registry, which serves the built-in default
+func Load(path string) (*Registry, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading branding: %w", err)
+	}
+
+	var file struct {
+		Default Branding          `json:"default"`
+		Tenants map[string]tenant `json:"tenants"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing branding: %w", err)
+	}
+
+	if file.Default.ProductName == "" {
+		file.Default.ProductName = DefaultProductName
+	}
+	if err := file.Default.validate(); err != nil {
+		return nil, fmt.Errorf("default branding: %w", err)
+	}
+
+	r := &Registry{
+		fallback: file.Default,
+		tenants:  make(map[string]Branding),
+		hosts:    make(map[string]string),
+	}
+	for id, t := range file.Tenants {
+		if t.ProductName == "" {
+			t.ProductName = file.Default.ProductName
+		}
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", id, err)
+		}
+		r.tenants[id] = t.Branding
+		for _, host := range t.Hosts {
+			host = strings.ToLower(host)
+			if other, taken := r.hosts[host]; taken {
+				return nil, fmt.Errorf("host %s is claimed by tenants %s and %s", host, other, id)
+			}
+			r.hosts[host] = id
+		}
+	}
+	return r, nil
+}
+
+// validate checks a branding entry is safe to send to browsers
+func (b Branding) validate() error {
+	if len(b.ProductName) > maxProductNameLength {
+		return fmt.Errorf("product name must be %d characters or fewer", maxProductNameLength)
+	}
+	if b.LogoURL != "" {
+		u, err := url.Parse(b.LogoURL)
+		if err != nil || (u.Scheme != "https" && !(u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/"))) {
+			return errors.New("logo URL must be https or a path on this server")
+		}
+	}
+	for key, colour := range b.Colors {
+		if !paletteKeys[key] {
+			return fmt.Errorf("unknown colour %q", key)
+		}
+		if !hexColour.MatchString(colour) {
+			return fmt.Errorf("colour %s must be a hex value like #ff6600", key)
+		}
+	}
+	return nil
+}
+
+// Get returns a tenant's branding, or the default for an unknown or empty tenant
+// Safe to call on a nil *Registry, which always returns the built-in default
+func (r *Registry) Get(tenantID string) Branding {
+	if r == nil {
+		return Branding{ProductName: DefaultProductName}
+	}
+	if b, ok := r.tenants[tenantID]; ok {
+		return b
+	}
+	return r.fallback
+}
+
+// Resolve picks the tenant for a request: the tenant query parameter if it names
+// a known tenant, otherwise the tenant claiming the request's host, otherwise none
+func (r *Registry) Resolve(req *http.Request) string {
+	if r == nil {
+		return ""
+	}
+
+	if id := req.URL.Query().Get(TenantParam); id != "" {
+		if _, ok := r.tenants[id]; ok {
+			return id
+		}
+	}
+
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return r.hosts[strings.ToLower(host)]
+}
@@ -0,0 +1,78 @@
+package branding
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeBranding writes a branding file and loads it
+func writeBranding(t *testing.T, content string) (*Registry, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "branding.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write branding file: %v", err)
+	}
+	return Load(path)
+}
+
+func TestResolveTenant(t *testing.T) {
+	registry, err := writeBranding(t, `{
+		"default": {"productName": "Team Thanks"},
+		"tenants": {
+			"acme": {"hosts": ["Kudos.Acme.com"], "productName": "Acme Kudos", "logoUrl": "https://acme.com/logo.svg", "colors": {"primary": "#ff6600"}},
+			"globex": {"logoUrl": "/static/globex.svg"}
+		}
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to load branding: %v", err)
+	}
+
+	tests := []struct {
+		target string
+		host   string
+		want   string
+	}{
+		{"/ws", "kudos.acme.com:443", "acme"},
+		{"/ws?tenant=globex", "kudos.acme.com", "globex"},
+		{"/ws?tenant=unknown", "uplift.example.com", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.target, nil)
+		req.Host = tt.host
+		if got := registry.Resolve(req); got != tt.want {
+			t.Errorf("%s on %s: expected tenant %q, got %q", tt.target, tt.host, tt.want, got)
+		}
+	}
+
+	if got := registry.Get("acme"); got.ProductName != "Acme Kudos" || got.Colors["primary"] != "#ff6600" {
+		t.Errorf("Unexpected acme branding: %+v", got)
+	}
+	if got := registry.Get("globex").ProductName; got != "Team Thanks" {
+		t.Errorf("Expected tenants without a name to inherit the default, got %q", got)
+	}
+	if got := registry.Get(""); got.ProductName != "Team Thanks" {
+		t.Errorf("Expected default branding, got %+v", got)
+	}
+
+	var none *Registry
+	if got := none.Get("acme"); got.ProductName != DefaultProductName {
+		t.Errorf("Expected built-in default without a registry, got %+v", got)
+	}
+}
+
+func TestLoadRejectsUnsafeBranding(t *testing.T) {
+	for _, content := range []string{
+		`{"tenants": {"a": {"logoUrl": "javascript:alert(1)"}}}`,
+		`{"tenants": {"a": {"logoUrl": "http://insecure.example.com/logo.png"}}}`,
+		`{"tenants": {"a": {"colors": {"primary": "red; background: url(x)"}}}}`,
+		`{"tenants": {"a": {"colors": {"border": "#fff"}}}}`,
+		`{"tenants": {"a": {"hosts": ["x.com"]}, "b": {"hosts": ["X.com"]}}}`,
+	} {
+		if _, err := writeBranding(t, content); err == nil {
+			t.Errorf("Expected %s to be rejected", content)
+		}
+	}
+}
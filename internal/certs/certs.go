@@ -0,0 +1,113 @@
+// ABOUTME: Serves TLS from certificate files and redirects plain HTTP to HTTPS
+// ABOUTME: Certificate files are re-read when they change, so renewals don't need a restart
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// reloadCheckInterval is how often certificate files are checked for changes
+const reloadCheckInterval = time.Minute
+
+// FileCertificate serves a certificate and key read from files
+type FileCertificate struct {
+	certFile string
+	keyFile  string
+
+	cert      *tls.Certificate
+	modified  time.Time // Latest modification time of either file when last loaded
+	checkedAt time.Time
+	mu        sync.Mutex
+}
+
+// NewFileCertificate loads a PEM certificate chain and its key
+func NewFileCertificate(certFile, keyFile string) (*FileCertificate, error) {
+	f := &FileCertificate{certFile: certFile, keyFile: keyFile}
+	modified, err := f.modifiedAt()
+	if err != nil {
+		return nil, err
+	}
+	if err := f.load(modified); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetCertificate serves the certificate in TLS handshakes, for use in tls.Config
+// A replaced certificate that fails to load is logged and the previous one kept
+func (f *FileCertificate) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(f.checkedAt) >= reloadCheckInterval {
+		f.checkedAt = now
+		modified, err := f.modifiedAt()
+		if err == nil && modified.After(f.modified) {
+			if err := f.load(modified); err != nil {
+				slog.Error("Failed to reload TLS certificate", "err", err)
+			}
+		}
+	}
+	return f.cert, nil
+}
+
+// load reads the files, remembering when they were modified
+func (f *FileCertificate) load(modified time.Time) error {
+	cert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	f.cert = &cert
+	f.modified = modified
+	slog.Info("Loaded TLS certificate", "subject", cert.Leaf.Subject.CommonName, "expires", cert.Leaf.NotAfter)
+	return nil
+}
+
+// modifiedAt returns when the certificate or key file last changed
+func (f *FileCertificate) modifiedAt() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{f.certFile, f.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("loading TLS certificate: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// RedirectHTTPS redirects every request to the same host and path over HTTPS on httpsPort
+func RedirectHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			http.Error(w, "Host header required", http.StatusBadRequest)
+			return
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		} else if net.ParseIP(host) != nil && net.ParseIP(host).To4() == nil {
+			host = "[" + host + "]"
+		}
+
+		// Only GET and HEAD may be rewritten to GET, so other methods keep theirs with 308
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
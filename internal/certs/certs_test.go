@@ -0,0 +1,139 @@
+// ABOUTME: Tests the ACME manager's host policy and challenge handler, certificate reloads and HTTPS redirects
+// ABOUTME: Issuance itself is left to autocert, so no ACME server is faked here
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManagerRefusesOtherDomains(t *testing.T) {
+	if _, err := NewManager(nil, t.TempDir(), "", ""); err == nil {
+		t.Error("expected a manager without domains to be refused")
+	}
+
+	m, err := NewManager([]string{"uplift.example"}, filepath.Join(t.TempDir(), "acme"), "", "")
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if _, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example"}); err == nil {
+		t.Error("expected no certificate for a domain that isn't managed")
+	}
+}
+
+func TestChallengeHandlerFallsBack(t *testing.T) {
+	m, err := NewManager([]string{"uplift.example"}, t.TempDir(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := m.HTTPHandler(RedirectHTTPS("443"))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "http://other.example/.well-known/acme-challenge/abc", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected challenges for other domains to be refused, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "http://uplift.example/join?code=ABC", nil))
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "https://uplift.example/join?code=ABC" {
+		t.Errorf("expected other requests to redirect, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	tests := []struct {
+		name     string
+		port     string
+		method   string
+		target   string
+		status   int
+		location string
+	}{
+		{"default port", "443", "GET", "http://uplift.example/", http.StatusMovedPermanently, "https://uplift.example/"},
+		{"drops the http port", "443", "GET", "http://uplift.example:80/a?b=c", http.StatusMovedPermanently, "https://uplift.example/a?b=c"},
+		{"custom https port", "8443", "HEAD", "http://uplift.example:8080/", http.StatusMovedPermanently, "https://uplift.example:8443/"},
+		{"keeps the method", "443", "POST", "http://uplift.example/api/sessions", http.StatusPermanentRedirect, "https://uplift.example/api/sessions"},
+		{"ipv6 host", "443", "GET", "http://[::1]/", http.StatusMovedPermanently, "https://[::1]/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			RedirectHTTPS(tt.port).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != tt.status || rec.Header().Get("Location") != tt.location {
+				t.Errorf("got %d %q, want %d %q", rec.Code, rec.Header().Get("Location"), tt.status, tt.location)
+			}
+		})
+	}
+}
+
+// writeSelfSigned writes a self-signed certificate for name and its key
+func writeSelfSigned(t *testing.T, certFile, keyFile, name string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+}
+
+func TestFileCertificateReloads(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeSelfSigned(t, certFile, keyFile, "old.uplift.example")
+
+	f, err := NewFileCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewFileCertificate: %v", err)
+	}
+	cert, _ := f.GetCertificate(nil)
+	if cert.Leaf.Subject.CommonName != "old.uplift.example" {
+		t.Fatalf("expected the original certificate, got %s", cert.Leaf.Subject.CommonName)
+	}
+
+	// A renewed certificate is picked up on the next check
+	writeSelfSigned(t, certFile, keyFile, "new.uplift.example")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	f.checkedAt = time.Time{}
+	if cert, _ := f.GetCertificate(nil); cert.Leaf.Subject.CommonName != "new.uplift.example" {
+		t.Errorf("expected the renewed certificate, got %s", cert.Leaf.Subject.CommonName)
+	}
+
+	// A broken replacement keeps the previous certificate
+	os.WriteFile(keyFile, []byte("not a key"), 0600)
+	later = later.Add(time.Minute)
+	os.Chtimes(keyFile, later, later)
+	f.checkedAt = time.Time{}
+	if cert, _ := f.GetCertificate(nil); cert == nil || cert.Leaf.Subject.CommonName != "new.uplift.example" {
+		t.Error("expected the previous certificate to be kept when the new one fails to load")
+	}
+
+	if _, err := NewFileCertificate(filepath.Join(dir, "missing.pem"), keyFile); err == nil {
+		t.Error("expected a missing certificate file to fail")
+	}
+}
@@ -0,0 +1,58 @@
+// ABOUTME: Keeps certificates for the configured domains issued and renewed through ACME, using autocert
+// ABOUTME: The account key and certificates are cached on disk so restarts don't reissue
+package certs
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// RenewBefore is how long before expiry a certificate is renewed
+const RenewBefore = 30 * 24 * time.Hour
+
+// Manager obtains and renews a certificate for each of its domains when first asked for one
+// Its challenge handler must be reachable on port 80 for the domains to be validated
+type Manager struct {
+	autocert *autocert.Manager
+}
+
+// NewManager creates a manager for domains, caching its account and certificates in cacheDir
+// directoryURL selects the ACME server (Let's Encrypt when empty); email is the account contact
+func NewManager(domains []string, cacheDir, directoryURL, email string) (*Manager, error) {
+	if len(domains) == 0 {
+		return nil, errors.New("at least one domain is required")
+	}
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return nil, fmt.Errorf("creating certificate cache: %w", err)
+	}
+
+	m := &autocert.Manager{
+		Prompt:      autocert.AcceptTOS,
+		Cache:       autocert.DirCache(cacheDir),
+		HostPolicy:  autocert.HostWhitelist(domains...),
+		RenewBefore: RenewBefore,
+		Email:       email,
+	}
+	if directoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: directoryURL}
+	}
+	return &Manager{autocert: m}, nil
+}
+
+// GetCertificate serves the domain's certificate in TLS handshakes, obtaining it first if
+// needed, for use in tls.Config. Names other than the managed domains are refused
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return m.autocert.GetCertificate(hello)
+}
+
+// HTTPHandler serves http-01 challenge responses, passing every other request to fallback
+func (m *Manager) HTTPHandler(fallback http.Handler) http.Handler {
+	return m.autocert.HTTPHandler(fallback)
+}
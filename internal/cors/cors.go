@@ -0,0 +1,91 @@
+// ABOUTME: Cross-origin resource sharing for the HTTP API and WebSocket origin checks
+// ABOUTME: A single Policy decides which browser origins may call the server
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Policy configures which cross-origin requests are allowed
+type Policy struct {
+	AllowedOrigins   []string // Exact origins such as https://example.com, or "*" for any
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int // Seconds browsers may cache preflight results (0 = browser default)
+}
+
+// NewPolicy creates a policy for the given origins with default methods and headers
+// An empty origin list allows any origin
+func NewPolicy(origins []string) *Policy {
+	return &Policy{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAge:         600,
+	}
+}
+
+// ParseList splits a comma-separated configuration value, dropping blanks
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// AllowsOrigin reports whether requests from origin are permitted
+func (p *Policy) AllowsOrigin(origin string) bool {
+	if len(p.AllowedOrigins) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrigin reports whether a WebSocket upgrade request comes from an allowed origin
+// Requests without an Origin header (non-browser clients) are allowed
+func (p *Policy) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || p.AllowsOrigin(origin)
+}
+
+// Middleware adds CORS headers and answers preflight requests before they reach next
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !p.AllowsOrigin(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Allow-Origin", origin)
+		if p.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		// Preflight requests are answered here without reaching next
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+			header.Set("Access-Control-Allow-Headers", strings.Join(p.AllowedHeaders, ", "))
+			if p.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(p.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,85 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowsOrigin(t *testing.T) {
+	policy := NewPolicy([]string{"https://app.example.com"})
+
+	if !policy.AllowsOrigin("https://app.example.com") {
+		t.Error("Expected listed origin to be allowed")
+	}
+	if policy.AllowsOrigin("https://evil.example.com") {
+		t.Error("Expected unlisted origin to be rejected")
+	}
+
+	if !NewPolicy(nil).AllowsOrigin("https://anywhere.example.com") {
+		t.Error("Expected empty allowlist to allow any origin")
+	}
+	if !NewPolicy([]string{"*"}).AllowsOrigin("https://anywhere.example.com") {
+		t.Error("Expected wildcard to allow any origin")
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	policy := NewPolicy([]string{"https://app.example.com"})
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	if !policy.CheckOrigin(req) {
+		t.Error("Expected request without Origin to be allowed")
+	}
+
+	req.Header.Set("Origin", "https://evil.example.com")
+	if policy.CheckOrigin(req) {
+		t.Error("Expected request from unlisted origin to be rejected")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	policy := NewPolicy([]string{"https://app.example.com"})
+	policy.AllowCredentials = true
+
+	reached := false
+	handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	// Preflight is answered without reaching the wrapped handler
+	req := httptest.NewRequest(http.MethodOptions, "/admin/api/stats", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected preflight status 204, got %d", rec.Code)
+	}
+	if reached {
+		t.Error("Expected preflight not to reach wrapped handler")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Expected allowed origin header, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Expected credentials header, got %q", got)
+	}
+	if rec.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Error("Expected allowed methods header on preflight")
+	}
+
+	// Disallowed origins get no CORS headers
+	req = httptest.NewRequest(http.MethodGet, "/admin/api/stats", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !reached {
+		t.Error("Expected simple request to reach wrapped handler")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no CORS headers for disallowed origin, got %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+// ABOUTME: Deepgram speech-to-text provider
+// ABOUTME: Sends the recording as-is and reads the top transcript alternative
+package dictation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const deepgramEndpoint = "https://api.deepgram.com/v1/listen"
+
+// Deepgram transcribes with Deepgram's pre-recorded audio API
+type Deepgram struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+// NewDeepgram creates a Deepgram provider
+func NewDeepgram(apiKey string) *Deepgram {
+	return &Deepgram{
+		endpoint: deepgramEndpoint,
+		apiKey:   apiKey,
+		client:   &http.Client{},
+	}
+}
+
+// Transcribe posts the audio and returns the most likely transcript with punctuation
+func (d *Deepgram) Transcribe(ctx context.Context, audio []byte, contentType, language string) (string, error) {
+	params := url.Values{"punctuate": {"true"}, "smart_format": {"true"}}
+	if language != "" {
+		params.Set("language", language)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint+"?"+params.Encode(), bytes.NewReader(audio))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Token "+d.apiKey)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("deepgram request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("deepgram transcription: status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Results struct {
+			Channels []struct {
+				Alternatives []struct {
+					Transcript string `json:"transcript"`
+				} `json:"alternatives"`
+			} `json:"channels"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding deepgram response: %w", err)
+	}
+	if len(result.Results.Channels) == 0 || len(result.Results.Channels[0].Alternatives) == 0 {
+		return "", nil
+	}
+	return result.Results.Channels[0].Alternatives[0].Transcript, nil
+}
@@ -0,0 +1,135 @@
+// ABOUTME: Voice dictation so participants can speak a note instead of typing it
+// ABOUTME: Accepts short audio uploads over HTTP and transcribes them with a pluggable speech-to-text provider
+package dictation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxAudioBytes bounds an upload; about a minute of compressed speech
+	MaxAudioBytes = 1 << 20
+
+	// transcribeTimeout bounds how long a provider may take
+	transcribeTimeout = 30 * time.Second
+
+	// maxLanguageLength bounds the BCP 47 language hint accepted from clients
+	maxLanguageLength = 16
+)
+
+// audioTypes are the upload formats accepted, covering what browsers' MediaRecorder produces
+var audioTypes = map[string]bool{
+	"audio/webm":  true,
+	"audio/ogg":   true,
+	"audio/mp4":   true,
+	"audio/mpeg":  true,
+	"audio/wav":   true,
+	"audio/x-wav": true,
+}
+
+// Provider transcribes speech with one speech-to-text service
+type Provider interface {
+	// Transcribe returns the text spoken in audio; language is a BCP 47 hint and may be empty
+	Transcribe(ctx context.Context, audio []byte, contentType, language string) (string, error)
+}
+
+// Handler serves POST /api/dictation?session=CODE with an audio body and the dictation token
+// the client was given when it joined, and responds with {"text": "..."} for the client to
+// edit and submit as a note
+type Handler struct {
+	provider Provider
+
+	// authorize returns the user a dictation token belongs to, if they may dictate in a session right now
+	authorize func(sessionCode, token string) (string, bool)
+}
+
+// NewHandler creates a dictation endpoint that transcribes with provider for users authorize allows
+func NewHandler(provider Provider, authorize func(sessionCode, token string) (string, bool)) *Handler {
+	return &Handler{
+		provider:  provider,
+		authorize: authorize,
+	}
+}
+
+// ServeHTTP transcribes one uploaded recording
+// The dictation token is sent as ?token= or an Authorization: Bearer header
+// ?lang= passes an optional language hint such as en or pt-BR to the provider
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	sessionCode := query.Get("session")
+	token := query.Get("token")
+	if token == "" {
+		token, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if sessionCode == "" || token == "" {
+		writeError(w, http.StatusForbidden, "dictation is only available to participants while writing")
+		return
+	}
+	userID, ok := h.authorize(sessionCode, token)
+	if !ok {
+		writeError(w, http.StatusForbidden, "dictation is only available to participants while writing")
+		return
+	}
+
+	language := query.Get("lang")
+	if len(language) > maxLanguageLength {
+		writeError(w, http.StatusBadRequest, "invalid language")
+		return
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !audioTypes[contentType] {
+		writeError(w, http.StatusUnsupportedMediaType, "unsupported audio format")
+		return
+	}
+
+	audio, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxAudioBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "recording too long")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read audio")
+		return
+	}
+	if len(audio) == 0 {
+		writeError(w, http.StatusBadRequest, "audio required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), transcribeTimeout)
+	defer cancel()
+
+	text, err := h.provider.Transcribe(ctx, audio, contentType, language)
+	if err != nil {
+		slog.Warn("Dictation failed", "sessionCode", sessionCode, "userID", userID, "err", err)
+		writeError(w, http.StatusBadGateway, "transcription failed")
+		return
+	}
+
+	slog.Info("Dictation transcribed", "sessionCode", sessionCode, "userID", userID, "bytes", len(audio))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"text": strings.TrimSpace(text)})
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
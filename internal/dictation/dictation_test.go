@@ -0,0 +1,145 @@
+package dictation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeProvider returns a fixed transcript, or fails if err is set
+type fakeProvider struct {
+	text     string
+	err      error
+	language string
+}
+
+func (f *fakeProvider) Transcribe(ctx context.Context, audio []byte, contentType, language string) (string, error) {
+	f.language = language
+	return f.text, f.err
+}
+
+func TestDictationHandler(t *testing.T) {
+	provider := &fakeProvider{text: "  Thanks for the help with the launch  "}
+	handler := NewHandler(provider, func(sessionCode, token string) (string, bool) {
+		return "alice", sessionCode == "ABC123" && token == "alice-token"
+	})
+
+	post := func(target, contentType string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("/api/dictation?session=ABC123&token=alice-token&lang=en-GB", "audio/webm;codecs=opus", []byte("audio"))
+	var result struct {
+		Text string `json:"text"`
+	}
+	json.NewDecoder(rec.Body).Decode(&result)
+	if rec.Code != http.StatusOK || result.Text != "Thanks for the help with the launch" {
+		t.Fatalf("Expected the trimmed transcript, got %d %+v", rec.Code, result)
+	}
+	if provider.language != "en-GB" {
+		t.Errorf("Expected the language hint to be passed on, got %q", provider.language)
+	}
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        []byte
+		want        int
+	}{
+		{"wrong token", "/api/dictation?session=ABC123&token=alice-id", "audio/webm", []byte("audio"), http.StatusForbidden},
+		{"not audio", "/api/dictation?session=ABC123&token=alice-token", "text/plain", []byte("audio"), http.StatusUnsupportedMediaType},
+		{"empty", "/api/dictation?session=ABC123&token=alice-token", "audio/webm", nil, http.StatusBadRequest},
+		{"too long", "/api/dictation?session=ABC123&token=alice-token", "audio/webm", make([]byte, MaxAudioBytes+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		if rec := post(tt.target, tt.contentType, tt.body); rec.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/dictation?session=ABC123", bytes.NewReader([]byte("audio")))
+	req.Header.Set("Content-Type", "audio/webm")
+	req.Header.Set("Authorization", "Bearer alice-token")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the token to be accepted as a bearer token, got %d", rec.Code)
+	}
+
+	provider.err = errors.New("provider down")
+	if rec := post("/api/dictation?session=ABC123&token=alice-token", "audio/webm", []byte("audio")); rec.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502 when the provider fails, got %d", rec.Code)
+	}
+}
+
+func TestWhisperTranscribe(t *testing.T) {
+	var filename, language string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer whisper-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		form := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := form.NextPart()
+			if err != nil {
+				break
+			}
+			value, _ := io.ReadAll(part)
+			switch part.FormName() {
+			case "file":
+				filename = part.FileName()
+			case "language":
+				language = string(value)
+			}
+		}
+		w.Write([]byte(`{"text": "You made my week"}`))
+	}))
+	defer server.Close()
+
+	whisper := NewWhisper("whisper-key", server.URL)
+	text, err := whisper.Transcribe(context.Background(), []byte("audio"), "audio/mpeg", "pt-BR")
+	if err != nil || text != "You made my week" {
+		t.Fatalf("Expected a transcript, got %q %v", text, err)
+	}
+	if filename != "dictation.mp3" || language != "pt" {
+		t.Errorf("Expected an mp3 upload with language pt, got %q and %q", filename, language)
+	}
+
+	whisper.apiKey = "wrong"
+	if _, err := whisper.Transcribe(context.Background(), []byte("audio"), "audio/webm", ""); err == nil {
+		t.Error("Expected error for rejected request")
+	}
+}
+
+func TestDeepgramTranscribe(t *testing.T) {
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		w.Write([]byte(`{"results": {"channels": [{"alternatives": [{"transcript": "Thank you for everything"}]}]}}`))
+	}))
+	defer server.Close()
+
+	deepgram := NewDeepgram("deepgram-key")
+	deepgram.endpoint = server.URL
+	text, err := deepgram.Transcribe(context.Background(), []byte("audio"), "audio/ogg", "")
+	if err != nil || text != "Thank you for everything" {
+		t.Fatalf("Expected a transcript, got %q %v", text, err)
+	}
+	if contentType != "audio/ogg" {
+		t.Errorf("Expected the recording's content type, got %q", contentType)
+	}
+}
@@ -0,0 +1,89 @@
+// ABOUTME: OpenAI Whisper speech-to-text provider
+// ABOUTME: Also works with self-hosted services exposing the same transcription API
+package dictation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const (
+	whisperEndpoint = "https://api.openai.com/v1/audio/transcriptions"
+	whisperModel    = "whisper-1"
+)
+
+// Whisper transcribes with OpenAI's audio transcription API
+type Whisper struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+}
+
+// NewWhisper creates a Whisper provider; an empty endpoint uses OpenAI's
+func NewWhisper(apiKey, endpoint string) *Whisper {
+	if endpoint == "" {
+		endpoint = whisperEndpoint
+	}
+	return &Whisper{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		model:    whisperModel,
+		client:   &http.Client{},
+	}
+}
+
+// Transcribe uploads the audio as a multipart form and returns the plain-text transcript
+func (wh *Whisper) Transcribe(ctx context.Context, audio []byte, contentType, language string) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("model", wh.model)
+	form.WriteField("response_format", "json")
+	if language != "" {
+		// Whisper takes ISO 639-1, the first part of a BCP 47 tag
+		primary, _, _ := strings.Cut(language, "-")
+		form.WriteField("language", strings.ToLower(primary))
+	}
+
+	// Whisper detects the format from the file extension
+	extension := strings.TrimPrefix(strings.TrimPrefix(contentType, "audio/"), "x-")
+	if extension == "mpeg" {
+		extension = "mp3"
+	}
+	part, err := form.CreateFormFile("file", "dictation."+extension)
+	if err != nil {
+		return "", err
+	}
+	part.Write(audio)
+	form.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.endpoint, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+wh.apiKey)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("whisper request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("whisper transcription: status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding whisper response: %w", err)
+	}
+	return result.Text, nil
+}
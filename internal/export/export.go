@@ -0,0 +1,225 @@
+// ABOUTME: Keepsake downloads of the notes each participant received in a completed circle
+// ABOUTME: Completed circles are retained briefly, and each participant downloads theirs with a private token
+package export
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRetention is how long keepsakes stay downloadable after a circle completes
+	DefaultRetention = 24 * time.Hour
+
+	// PathPrefix is where exports are served, as /sessions/{id}/export
+	PathPrefix = "/sessions/"
+
+	// pathSuffix ends an export path
+	pathSuffix = "/export"
+)
+
+// Note is one note a participant received
+type Note struct {
+	Content string `json:"content"`
+	GIFURL  string `json:"gifUrl,omitempty"`
+	Private bool   `json:"private"`          // Written only for the recipient, never read aloud
+	Author  string `json:"author,omitempty"` // Only in sessions that share notes with their author
+}
+
+// Event is one entry in the circle's timeline: joins, leaves, phase changes, notes
+// submitted and read. It never says who wrote a note.
+type Event struct {
+	At          time.Time              `json:"at"`
+	Type        string                 `json:"type"`
+	Participant string                 `json:"participant,omitempty"` // Name of who the event concerns, if anyone
+	Data        map[string]interface{} `json:"data,omitempty"`
+}
+
+// Keepsake is everything one participant received in a circle
+type Keepsake struct {
+	Title       string    `json:"title"`
+	Theme       string    `json:"theme,omitempty"` // Theme name, if the circle had one
+	CompletedAt time.Time `json:"completedAt"`
+	Recipient   string    `json:"recipient"`
+	Notes       []Note    `json:"notes"`
+	Timeline    []Event   `json:"timeline,omitempty"` // Only in the host's keepsake, and only as JSON
+}
+
+// circle is a completed session's keepsakes, retained until expiresAt
+type circle struct {
+	keepsakes map[string]*Keepsake // Token -> keepsake
+	tokens    map[string]string    // Participant ID -> token
+	expiresAt time.Time
+}
+
+// Store retains completed circles' keepsakes in memory; they don't survive a restart
+// A nil *Store retains nothing
+type Store struct {
+	circles   map[string]*circle // Session ID -> circle
+	retention time.Duration
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// NewStore creates a store that keeps keepsakes for retention (DefaultRetention when zero)
+func NewStore(retention time.Duration) *Store {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &Store{
+		circles:   make(map[string]*circle),
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// Retain keeps a completed session's keepsakes, keyed by participant ID, and issues
+// each participant an export token. Retaining a session again (after it is rolled back
+// and completed once more) replaces its keepsakes but keeps participants' tokens.
+func (s *Store) Retain(sessionID string, keepsakes map[string]Keepsake) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweepUnlocked(now)
+
+	previous := s.circles[sessionID]
+	c := &circle{
+		keepsakes: make(map[string]*Keepsake, len(keepsakes)),
+		tokens:    make(map[string]string, len(keepsakes)),
+		expiresAt: now.Add(s.retention),
+	}
+	for participantID, keepsake := range keepsakes {
+		token := ""
+		if previous != nil {
+			token = previous.tokens[participantID]
+		}
+		if token == "" {
+			token = randomToken()
+		}
+		keepsake := keepsake
+		c.keepsakes[token] = &keepsake
+		c.tokens[participantID] = token
+	}
+	s.circles[sessionID] = c
+
+	slog.Info("Keepsakes retained for export", "sessionID", sessionID, "participants", len(keepsakes), "expiresAt", c.expiresAt)
+}
+
+// Token returns the export token issued to a participant, or "" if there isn't one
+func (s *Store) Token(sessionID, participantID string) string {
+	if s == nil {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, exists := s.circles[sessionID]
+	if !exists || !s.now().Before(c.expiresAt) {
+		return ""
+	}
+	return c.tokens[participantID]
+}
+
+// Get returns the keepsake a token unlocks in a session, while it is retained
+func (s *Store) Get(sessionID, token string) (Keepsake, bool) {
+	if s == nil || token == "" {
+		return Keepsake{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, exists := s.circles[sessionID]
+	if !exists || !s.now().Before(c.expiresAt) {
+		return Keepsake{}, false
+	}
+	keepsake, exists := c.keepsakes[token]
+	if !exists {
+		return Keepsake{}, false
+	}
+	return *keepsake, true
+}
+
+// URL is the path a participant downloads their keepsake from; add &format= to choose one
+func URL(sessionID, token string) string {
+	return PathPrefix + sessionID + pathSuffix + "?token=" + token
+}
+
+// sweepUnlocked drops circles past their retention
+// Internal helper that assumes caller already holds the lock
+func (s *Store) sweepUnlocked(now time.Time) {
+	for id, c := range s.circles {
+		if !now.Before(c.expiresAt) {
+			delete(s.circles, id)
+		}
+	}
+}
+
+// ServeHTTP serves GET /sessions/{id}/export?format=json|csv|pdf
+// The token comes from the token query parameter or an Authorization bearer header
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, PathPrefix), pathSuffix)
+	if !ok || sessionID == "" || strings.Contains(sessionID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	format, err := ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if token == "" {
+		http.Error(w, "export token required", http.StatusUnauthorized)
+		return
+	}
+
+	// An unknown session and a wrong token look the same, so tokens can't be probed per session
+	keepsake, ok := s.Get(sessionID, token)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := Render(keepsake, format)
+	if err != nil {
+		slog.Error("Failed to render keepsake export", "sessionID", sessionID, "format", format, "err", err)
+		http.Error(w, "failed to export notes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", Filename(keepsake, format)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Write(body)
+}
+
+// randomToken returns an unguessable export token
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKeepsake() Keepsake {
+	return Keepsake{
+		Title:       "Team retro",
+		CompletedAt: time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC),
+		Recipient:   "Sam",
+		Notes: []Note{
+			{Content: "Your demo saved the quarter"},
+			{Content: "=HYPERLINK(\"http://example.com\")", Private: true, Author: "Alice"},
+		},
+	}
+}
+
+func TestRetainAndServe(t *testing.T) {
+	store := NewStore(0)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	store.Retain("session-1", map[string]Keepsake{"sam": testKeepsake()})
+	token := store.Token("session-1", "sam")
+	if token == "" {
+		t.Fatal("Expected an export token")
+	}
+
+	// Completing again after a rollback keeps the participant's token
+	store.Retain("session-1", map[string]Keepsake{"sam": testKeepsake()})
+	if store.Token("session-1", "sam") != token {
+		t.Error("Expected the token to survive retaining again")
+	}
+
+	rec := httptest.NewRecorder()
+	store.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, URL("session-1", token), nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a JSON keepsake, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var keepsake Keepsake
+	if err := json.Unmarshal(rec.Body.Bytes(), &keepsake); err != nil || len(keepsake.Notes) != 2 || keepsake.Recipient != "Sam" {
+		t.Errorf("Expected Sam's two notes, got %+v (%v)", keepsake, err)
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); !strings.Contains(disposition, "team-retro-notes-for-sam-2026-10-16.json") {
+		t.Errorf("Expected a download file name, got %q", disposition)
+	}
+
+	// Keepsakes disappear once the retention passes
+	now = now.Add(DefaultRetention)
+	rec = httptest.NewRecorder()
+	store.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, URL("session-1", token), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an expired keepsake, got %d", rec.Code)
+	}
+}
+
+func TestServeRequiresToken(t *testing.T) {
+	store := NewStore(time.Hour)
+	store.Retain("session-1", map[string]Keepsake{"sam": testKeepsake()})
+	token := store.Token("session-1", "sam")
+
+	tests := []struct {
+		name   string
+		target string
+		bearer string
+		want   int
+	}{
+		{"missing token", "/sessions/session-1/export", "", http.StatusUnauthorized},
+		{"wrong token", "/sessions/session-1/export?token=nope", "", http.StatusNotFound},
+		{"token for another session", "/sessions/session-2/export?token=" + token, "", http.StatusNotFound},
+		{"unknown format", URL("session-1", token) + "&format=docx", "", http.StatusBadRequest},
+		{"bearer token", "/sessions/session-1/export?format=csv", token, http.StatusOK},
+		{"not an export path", "/sessions/session-1/notes?token=" + token, "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			rec := httptest.NewRecorder()
+			store.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	body, err := Render(testKeepsake(), FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	rows := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(rows) != 3 || rows[0] != "circle,completed_at,recipient,note,gif_url,private,author" {
+		t.Fatalf("Expected a header and two notes, got %q", rows)
+	}
+	if !strings.Contains(rows[2], `'=HYPERLINK`) {
+		t.Errorf("Expected a formula to be escaped, got %q", rows[2])
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	keepsake := testKeepsake()
+	keepsake.Notes[0].Content = strings.Repeat("Thank you (really) for everything ", 200)
+
+	body, err := Render(keepsake, FormatPDF)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	pdf := string(body)
+	if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Error("Expected a complete PDF document")
+	}
+	if !strings.Contains(pdf, "(Notes for Sam)") || !strings.Contains(pdf, `\(really\)`) {
+		t.Error("Expected the title and escaped note text")
+	}
+	if !strings.Contains(pdf, "/Count 2") {
+		t.Error("Expected a long note to spill onto a second page")
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	if got := pdfString("Café “thanks” 🎉"); got != `Caf\351 \223thanks\224 ?` {
+		t.Errorf("Unexpected encoding: %q", got)
+	}
+}
@@ -0,0 +1,191 @@
+// ABOUTME: A minimal PDF writer for printable keepsakes, using the standard Helvetica fonts
+// ABOUTME: Text is wrapped and paginated on A4; characters outside Windows-1252 are shown as "?"
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth    = 595 // A4, in points
+	pageHeight   = 842
+	pageMargin   = 56
+	bodySize     = 11
+	titleSize    = 18
+	lineSpacing  = 1.4
+	avgCharWidth = 0.52 // Helvetica's average glyph width as a fraction of the font size
+)
+
+// pdfLine is one line of text laid out on a page
+type pdfLine struct {
+	text string
+	size float64
+	bold bool
+	gap  float64 // Extra space before the line, in points
+}
+
+// renderPDF lays the keepsake out as a title, a subtitle and each note in turn
+func renderPDF(keepsake Keepsake) []byte {
+	title := "Notes for " + keepsake.Recipient
+	subtitle := keepsake.CompletedAt.Format("2 January 2006")
+	if keepsake.Title != "" {
+		subtitle = keepsake.Title + " · " + subtitle
+	}
+	if keepsake.Theme != "" {
+		subtitle += " · " + keepsake.Theme
+	}
+
+	lines := []pdfLine{
+		{text: title, size: titleSize, bold: true},
+		{text: subtitle, size: bodySize},
+	}
+	for _, note := range keepsake.Notes {
+		gap := float64(bodySize)
+		if note.Private {
+			lines = append(lines, pdfLine{text: "Just for you", size: bodySize, bold: true, gap: gap})
+			gap = 0
+		}
+		for _, paragraph := range strings.Split(note.Content, "\n") {
+			for _, text := range wrap(paragraph, bodySize) {
+				lines = append(lines, pdfLine{text: text, size: bodySize, gap: gap})
+				gap = 0
+			}
+		}
+		if note.Author != "" {
+			lines = append(lines, pdfLine{text: "— " + note.Author, size: bodySize})
+		}
+		if note.GIFURL != "" {
+			lines = append(lines, pdfLine{text: "GIF: " + note.GIFURL, size: bodySize})
+		}
+	}
+	if len(keepsake.Notes) == 0 {
+		lines = append(lines, pdfLine{text: "No notes were written to you in this circle.", size: bodySize, gap: bodySize})
+	}
+
+	return writePDF(paginate(lines))
+}
+
+// wrap breaks text into lines that fit the page width at the given font size
+func wrap(text string, size float64) []string {
+	limit := int((pageWidth - 2*pageMargin) / (size * avgCharWidth))
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	line := ""
+	for _, word := range words {
+		for len([]rune(word)) > limit {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:limit]))
+			word = string(runes[limit:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= limit:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	return append(lines, line)
+}
+
+// paginate splits lines into pages and renders each page's content stream
+func paginate(lines []pdfLine) []string {
+	var pages []string
+	var page strings.Builder
+	y := float64(pageHeight - pageMargin)
+	for _, line := range lines {
+		advance := line.gap + line.size*lineSpacing
+		if y-advance < pageMargin && page.Len() > 0 {
+			pages = append(pages, page.String())
+			page.Reset()
+			y = pageHeight - pageMargin
+			advance = line.size * lineSpacing
+		}
+		y -= advance
+
+		font := "F1"
+		if line.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&page, "BT /%s %g Tf %d %.2f Td (%s) Tj ET\n", font, line.size, pageMargin, y, pdfString(line.text))
+	}
+	return append(pages, page.String())
+}
+
+// writePDF assembles the document: catalog, page tree, two fonts, then each page and its content
+func writePDF(pages []string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// winAnsi maps the Windows-1252 characters outside Latin-1 to their byte values
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// pdfString encodes text as the body of a PDF literal string in WinAnsiEncoding
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case winAnsi[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", winAnsi[r])
+		case r == '\t':
+			b.WriteByte(' ')
+		case r == 0xfe0f || r == 0x200d:
+			// Emoji presentation selectors and joiners have nothing to show on their own
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+// ABOUTME: Renders a keepsake as JSON, CSV or PDF
+// ABOUTME: CSV cells that a spreadsheet would run as a formula are escaped
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format is a keepsake file format
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatPDF  Format = "pdf"
+)
+
+// ParseFormat validates an export format; empty selects JSON
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatCSV, FormatPDF:
+		return format, nil
+	default:
+		return "", errors.New("format must be json, csv or pdf")
+	}
+}
+
+// ContentType is the MIME type files in the format are served as
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "application/json"
+	}
+}
+
+// Render returns the keepsake as a file in the given format
+func Render(keepsake Keepsake, format Format) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		if keepsake.Notes == nil {
+			keepsake.Notes = []Note{}
+		}
+		return json.MarshalIndent(keepsake, "", "  ")
+	case FormatCSV:
+		return renderCSV(keepsake)
+	case FormatPDF:
+		return renderPDF(keepsake), nil
+	default:
+		return nil, fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// renderCSV writes one row per note, with the circle and recipient repeated on each
+func renderCSV(keepsake Keepsake) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"circle", "completed_at", "recipient", "note", "gif_url", "private", "author"})
+	for _, note := range keepsake.Notes {
+		writer.Write([]string{
+			safeCell(keepsake.Title),
+			keepsake.CompletedAt.Format("2006-01-02"),
+			safeCell(keepsake.Recipient),
+			safeCell(note.Content),
+			safeCell(note.GIFURL),
+			strconv.FormatBool(note.Private),
+			safeCell(note.Author),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("rendering csv export: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// safeCell stops a spreadsheet treating text a participant wrote as a formula
+func safeCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// nonSlug matches runs of characters left out of file names
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Filename suggests a download name like "team-retro-notes-for-sam-2026-10-16.pdf"
+func Filename(keepsake Keepsake, format Format) string {
+	title := keepsake.Title
+	if title == "" {
+		title = "gratitude circle"
+	}
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(title+" notes for "+keepsake.Recipient), "-"), "-")
+	return fmt.Sprintf("%s-%s.%s", slug, keepsake.CompletedAt.Format("2006-01-02"), format)
+}
@@ -0,0 +1,113 @@
+// ABOUTME: Feature flags for shipping experimental capabilities dark
+// ABOUTME: Flags default per feature, can be set at startup and toggled at runtime via the admin API
+package features
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Known feature flags
+const (
+	Breakouts     = "breakouts"
+	Reactions     = "reactions"
+	AISuggestions = "ai_suggestions"
+)
+
+// defaults lists every known flag and whether it's on when not configured
+var defaults = map[string]bool{
+	Breakouts:     true,
+	Reactions:     false,
+	AISuggestions: false,
+}
+
+// Flag is a feature flag's current state
+type Flag struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Flags holds the enabled state of each known feature
+// A nil *Flags reports every feature at its default
+type Flags struct {
+	enabled map[string]bool
+	mu      sync.RWMutex
+}
+
+// NewFlags creates flags at their defaults with overrides from config
+// Config is a comma-separated list of "name" or "name=bool" entries,
+// e.g. "reactions,breakouts=false"
+func NewFlags(config string) (*Flags, error) {
+	f := &Flags{enabled: make(map[string]bool, len(defaults))}
+	for name, enabled := range defaults {
+		f.enabled[name] = enabled
+	}
+
+	for _, entry := range strings.Split(config, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(entry, "=")
+		enabled := true
+		if hasValue {
+			parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for feature %s: %s", name, value)
+			}
+			enabled = parsed
+		}
+
+		if err := f.Set(strings.TrimSpace(name), enabled); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Enabled reports whether a feature is turned on
+func (f *Flags) Enabled(name string) bool {
+	if f == nil {
+		return defaults[name]
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.enabled[name]
+}
+
+// Set turns a known feature on or off
+func (f *Flags) Set(name string, enabled bool) error {
+	if _, known := defaults[name]; !known {
+		return fmt.Errorf("unknown feature: %s", name)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.enabled[name] = enabled
+	slog.Info("Feature flag set", "name", name, "enabled", enabled)
+	return nil
+}
+
+// List returns every known flag ordered by name
+func (f *Flags) List() []Flag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	flags := make([]Flag, 0, len(f.enabled))
+	for name, enabled := range f.enabled {
+		flags = append(flags, Flag{Name: name, Enabled: enabled})
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+	return flags
+}
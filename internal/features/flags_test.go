@@ -0,0 +1,59 @@
+package features
+
+import "testing"
+
+func TestNewFlagsDefaults(t *testing.T) {
+	flags, err := NewFlags("")
+	if err != nil {
+		t.Fatalf("Failed to create flags: %v", err)
+	}
+
+	if !flags.Enabled(Breakouts) {
+		t.Error("Expected breakouts to be enabled by default")
+	}
+	if flags.Enabled(Reactions) {
+		t.Error("Expected reactions to be disabled by default")
+	}
+	if len(flags.List()) != len(defaults) {
+		t.Errorf("Expected %d flags, got %d", len(defaults), len(flags.List()))
+	}
+}
+
+func TestNewFlagsConfig(t *testing.T) {
+	flags, err := NewFlags("reactions, breakouts=false")
+	if err != nil {
+		t.Fatalf("Failed to create flags: %v", err)
+	}
+
+	if !flags.Enabled(Reactions) {
+		t.Error("Expected reactions to be enabled by config")
+	}
+	if flags.Enabled(Breakouts) {
+		t.Error("Expected breakouts to be disabled by config")
+	}
+
+	for _, config := range []string{"teleport", "reactions=maybe"} {
+		if _, err := NewFlags(config); err == nil {
+			t.Errorf("Expected error for config %q", config)
+		}
+	}
+}
+
+func TestSetAndNilFlags(t *testing.T) {
+	flags, _ := NewFlags("")
+
+	if err := flags.Set(AISuggestions, true); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	if !flags.Enabled(AISuggestions) {
+		t.Error("Expected ai_suggestions to be enabled")
+	}
+	if err := flags.Set("teleport", true); err == nil {
+		t.Error("Expected error setting unknown flag")
+	}
+
+	var unset *Flags
+	if !unset.Enabled(Breakouts) || unset.Enabled(Reactions) {
+		t.Error("Expected nil flags to report defaults")
+	}
+}
@@ -0,0 +1,115 @@
+// ABOUTME: Server-side GIF search so clients can attach celebratory GIFs to notes
+// ABOUTME: Proxies Giphy or Tenor with the deployment's API key and content-rating filter
+package gifs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// MaxQueryLength bounds search terms accepted from clients
+	MaxQueryLength = 100
+
+	// DefaultLimit is how many results a search returns when the client doesn't say
+	DefaultLimit = 12
+
+	// MaxLimit bounds how many results one search may return
+	MaxLimit = 24
+)
+
+// Rating is the most mature content a search may return, using the common MPAA-style scale
+type Rating string
+
+const (
+	RatingG    Rating = "g"
+	RatingPG   Rating = "pg"
+	RatingPG13 Rating = "pg-13"
+	RatingR    Rating = "r"
+)
+
+// ParseRating validates a content rating name
+func ParseRating(value string) (Rating, error) {
+	switch rating := Rating(strings.ToLower(value)); rating {
+	case RatingG, RatingPG, RatingPG13, RatingR:
+		return rating, nil
+	default:
+		return "", fmt.Errorf("unknown gif rating: %s", value)
+	}
+}
+
+// GIF is one search result
+type GIF struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	URL        string `json:"url"`        // Full-size animation to attach to a note
+	PreviewURL string `json:"previewUrl"` // Small animation for the picker
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+}
+
+// Provider searches one GIF service
+type Provider interface {
+	Search(ctx context.Context, query string, limit int, rating Rating) ([]GIF, error)
+
+	// MediaHosts lists the hosts the provider serves GIFs from
+	MediaHosts() []string
+}
+
+// Searcher applies the deployment's rating filter and limits to a provider
+type Searcher struct {
+	provider Provider
+	rating   Rating
+}
+
+// NewSearcher creates a searcher that never returns content above rating
+func NewSearcher(provider Provider, rating Rating) *Searcher {
+	return &Searcher{
+		provider: provider,
+		rating:   rating,
+	}
+}
+
+// Search returns GIFs matching a query
+// limit is clamped to MaxLimit, and DefaultLimit is used when it's not positive
+func (s *Searcher) Search(ctx context.Context, query string, limit int) ([]GIF, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("search query is required")
+	}
+	if len(query) > MaxQueryLength {
+		return nil, fmt.Errorf("search query must be at most %d characters", MaxQueryLength)
+	}
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	return s.provider.Search(ctx, query, limit, s.rating)
+}
+
+// Allows reports whether a URL points at the provider's media, so notes can only
+// embed GIFs that came from a filtered search rather than arbitrary links
+// A nil *Searcher allows nothing
+func (s *Searcher) Allows(rawURL string) bool {
+	if s == nil {
+		return false
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range s.provider.MediaHosts() {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
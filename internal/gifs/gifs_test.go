@@ -0,0 +1,114 @@
+package gifs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGiphySearch(t *testing.T) {
+	var rating, limit string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != "giphy-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		rating = r.URL.Query().Get("rating")
+		limit = r.URL.Query().Get("limit")
+		w.Write([]byte(`{"data": [{"id": "abc", "title": "Party", "images": {
+			"original": {"url": "https://media1.giphy.com/abc.gif", "width": "480", "height": "270"},
+			"fixed_width_small": {"url": "https://media1.giphy.com/abc-small.gif"}
+		}}]}`))
+	}))
+	defer server.Close()
+
+	giphy := NewGiphy("giphy-key")
+	giphy.endpoint = server.URL
+	searcher := NewSearcher(giphy, RatingPG)
+
+	results, err := searcher.Search(context.Background(), "celebrate", 100)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if rating != "pg" {
+		t.Errorf("Expected rating pg, got %q", rating)
+	}
+	if limit != "24" {
+		t.Errorf("Expected limit to be clamped to 24, got %q", limit)
+	}
+	if len(results) != 1 || results[0].URL != "https://media1.giphy.com/abc.gif" || results[0].Width != 480 {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	if _, err := searcher.Search(context.Background(), "  ", 0); err == nil {
+		t.Error("Expected error for empty query")
+	}
+
+	giphy.apiKey = "wrong"
+	if _, err := searcher.Search(context.Background(), "celebrate", 0); err == nil {
+		t.Error("Expected error for rejected request")
+	}
+}
+
+func TestTenorSearch(t *testing.T) {
+	var filter string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("contentfilter")
+		w.Write([]byte(`{"results": [{"id": "123", "content_description": "Clapping", "media_formats": {
+			"gif": {"url": "https://media.tenor.com/123.gif", "dims": [320, 240]},
+			"tinygif": {"url": "https://media.tenor.com/123-tiny.gif", "dims": [160, 120]}
+		}}]}`))
+	}))
+	defer server.Close()
+
+	tenor := NewTenor("tenor-key")
+	tenor.endpoint = server.URL
+
+	results, err := NewSearcher(tenor, RatingG).Search(context.Background(), "applause", 0)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if filter != "high" {
+		t.Errorf("Expected strictest content filter for g, got %q", filter)
+	}
+	if len(results) != 1 || results[0].PreviewURL != "https://media.tenor.com/123-tiny.gif" || results[0].Height != 240 {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
+
+func TestSearcherAllows(t *testing.T) {
+	searcher := NewSearcher(NewGiphy("key"), RatingG)
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://media1.giphy.com/media/abc/giphy.gif", true},
+		{"https://giphy.com/abc.gif", true},
+		{"http://media1.giphy.com/abc.gif", false},
+		{"https://evilgiphy.com/abc.gif", false},
+		{"https://example.com/giphy.com.gif", false},
+		{"javascript:alert(1)", false},
+	}
+
+	for _, tt := range tests {
+		if got := searcher.Allows(tt.url); got != tt.allowed {
+			t.Errorf("Allows(%q) = %v, expected %v", tt.url, got, tt.allowed)
+		}
+	}
+
+	var unset *Searcher
+	if unset.Allows("https://media1.giphy.com/abc.gif") {
+		t.Error("Expected nil searcher to allow nothing")
+	}
+}
+
+func TestParseRating(t *testing.T) {
+	if rating, err := ParseRating("PG-13"); err != nil || rating != RatingPG13 {
+		t.Errorf("Expected pg-13, got %q (%v)", rating, err)
+	}
+	if _, err := ParseRating("nc-17"); err == nil {
+		t.Error("Expected error for unknown rating")
+	}
+}
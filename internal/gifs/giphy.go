@@ -0,0 +1,96 @@
+// ABOUTME: Giphy search provider
+// ABOUTME: Maps Giphy's search API onto the provider-neutral GIF type
+package gifs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const giphyEndpoint = "https://api.giphy.com/v1/gifs/search"
+
+// Giphy searches giphy.com
+type Giphy struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+// NewGiphy creates a Giphy provider
+func NewGiphy(apiKey string) *Giphy {
+	return &Giphy{
+		endpoint: giphyEndpoint,
+		apiKey:   apiKey,
+		client:   &http.Client{},
+	}
+}
+
+// MediaHosts lists the hosts Giphy serves GIFs from
+func (g *Giphy) MediaHosts() []string {
+	return []string{"giphy.com"}
+}
+
+// giphyImage is one rendition of a Giphy result
+type giphyImage struct {
+	URL    string `json:"url"`
+	Width  string `json:"width"`
+	Height string `json:"height"`
+}
+
+// Search queries Giphy; Giphy's rating parameter uses the same scale as Rating
+func (g *Giphy) Search(ctx context.Context, query string, limit int, rating Rating) ([]GIF, error) {
+	params := url.Values{
+		"api_key": {g.apiKey},
+		"q":       {query},
+		"limit":   {strconv.Itoa(limit)},
+		"rating":  {string(rating)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("giphy request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("giphy search: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data []struct {
+			ID     string `json:"id"`
+			Title  string `json:"title"`
+			Images struct {
+				Original     giphyImage `json:"original"`
+				FixedWidthSm giphyImage `json:"fixed_width_small"`
+			} `json:"images"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding giphy response: %w", err)
+	}
+
+	results := make([]GIF, 0, len(body.Data))
+	for _, item := range body.Data {
+		width, _ := strconv.Atoi(item.Images.Original.Width)
+		height, _ := strconv.Atoi(item.Images.Original.Height)
+		results = append(results, GIF{
+			ID:         item.ID,
+			Title:      item.Title,
+			URL:        item.Images.Original.URL,
+			PreviewURL: item.Images.FixedWidthSm.URL,
+			Width:      width,
+			Height:     height,
+		})
+	}
+	return results, nil
+}
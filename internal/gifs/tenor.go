@@ -0,0 +1,105 @@
+// ABOUTME: Tenor search provider using the v2 API
+// ABOUTME: Maps Tenor's search results and content filter onto the provider-neutral types
+package gifs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const tenorEndpoint = "https://tenor.googleapis.com/v2/search"
+
+// tenorContentFilters maps ratings onto Tenor's contentfilter levels
+var tenorContentFilters = map[Rating]string{
+	RatingG:    "high",
+	RatingPG:   "medium",
+	RatingPG13: "low",
+	RatingR:    "off",
+}
+
+// Tenor searches tenor.com
+type Tenor struct {
+	endpoint  string
+	apiKey    string
+	clientKey string // Identifies this integration in Tenor's analytics
+	client    *http.Client
+}
+
+// NewTenor creates a Tenor provider
+func NewTenor(apiKey string) *Tenor {
+	return &Tenor{
+		endpoint:  tenorEndpoint,
+		apiKey:    apiKey,
+		clientKey: "uplift",
+		client:    &http.Client{},
+	}
+}
+
+// MediaHosts lists the hosts Tenor serves GIFs from
+func (t *Tenor) MediaHosts() []string {
+	return []string{"tenor.com"}
+}
+
+// tenorMedia is one rendition of a Tenor result
+type tenorMedia struct {
+	URL  string `json:"url"`
+	Dims []int  `json:"dims"`
+}
+
+// Search queries Tenor
+func (t *Tenor) Search(ctx context.Context, query string, limit int, rating Rating) ([]GIF, error) {
+	params := url.Values{
+		"key":           {t.apiKey},
+		"client_key":    {t.clientKey},
+		"q":             {query},
+		"limit":         {strconv.Itoa(limit)},
+		"contentfilter": {tenorContentFilters[rating]},
+		"media_filter":  {"gif,tinygif"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("tenor request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tenor search: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Results []struct {
+			ID           string                `json:"id"`
+			Description  string                `json:"content_description"`
+			MediaFormats map[string]tenorMedia `json:"media_formats"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding tenor response: %w", err)
+	}
+
+	results := make([]GIF, 0, len(body.Results))
+	for _, item := range body.Results {
+		full := item.MediaFormats["gif"]
+		gif := GIF{
+			ID:         item.ID,
+			Title:      item.Description,
+			URL:        full.URL,
+			PreviewURL: item.MediaFormats["tinygif"].URL,
+		}
+		if len(full.Dims) == 2 {
+			gif.Width, gif.Height = full.Dims[0], full.Dims[1]
+		}
+		results = append(results, gif)
+	}
+	return results, nil
+}
@@ -0,0 +1,117 @@
+// ABOUTME: Signed invite links that carry a session's code, and optionally a name and role to join under
+// ABOUTME: Invites are HMAC-signed and stateless, so they expire and can't be forged for another session
+package invites
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTTL is how long an invite works when the host doesn't say
+	DefaultTTL = 24 * time.Hour
+
+	// MaxTTL bounds how long an invite can work
+	MaxTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalid = errors.New("invalid invite")
+	ErrExpired = errors.New("invite has expired")
+)
+
+// Invite is what an invite link lets someone do: join one session, perhaps under a given name and role
+// Its contents are signed, not encrypted, so clients can read them to fill in the join form
+type Invite struct {
+	SessionCode string `json:"code"`
+	SessionID   string `json:"sessionId"` // So an invite stops working if the code is reused by a later session
+	Name        string `json:"name,omitempty"`
+	Role        string `json:"role,omitempty"`
+	Expires     int64  `json:"exp"` // Unix seconds
+}
+
+// ExpiresAt returns when the invite stops working
+func (i Invite) ExpiresAt() time.Time {
+	return time.Unix(i.Expires, 0)
+}
+
+// Signer issues and verifies invites with a secret key
+// Every server that accepts an invite must share the key
+type Signer struct {
+	secret []byte
+
+	// now returns the current time (replaced in tests)
+	now func() time.Time
+}
+
+// NewSigner creates a signer with the given key, or a random one when it's empty,
+// in which case invites stop working when the process restarts
+func NewSigner(secret string) (*Signer, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Signer{secret: key, now: time.Now}, nil
+}
+
+// Issue signs an invite to a session that works for ttl (DefaultTTL when zero), returning
+// its token and expiry
+// Format: <base64url payload JSON>.<base64url HMAC-SHA256 of the payload>
+func (s *Signer) Issue(invite Invite, ttl time.Duration) (string, time.Time, error) {
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if ttl < 0 || ttl > MaxTTL {
+		return "", time.Time{}, fmt.Errorf("an invite can last at most %d days", int(MaxTTL.Hours()/24))
+	}
+
+	expiresAt := s.now().Add(ttl)
+	invite.Expires = expiresAt.Unix()
+	data, err := json.Marshal(invite)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + s.sign(payload), expiresAt, nil
+}
+
+// Verify checks an invite's signature and expiry and returns what it grants
+func (s *Signer) Verify(token string) (Invite, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return Invite{}, ErrInvalid
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Invite{}, ErrInvalid
+	}
+	var invite Invite
+	if err := json.Unmarshal(data, &invite); err != nil || invite.SessionCode == "" || invite.SessionID == "" {
+		return Invite{}, ErrInvalid
+	}
+
+	if !s.now().Before(invite.ExpiresAt()) {
+		return Invite{}, ErrExpired
+	}
+	return invite, nil
+}
+
+// sign returns the base64url HMAC-SHA256 of a payload
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,82 @@
+package invites
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssueAndVerify(t *testing.T) {
+	signer, err := NewSigner("test-secret")
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	signer.now = func() time.Time { return now }
+
+	token, expiresAt, err := signer.Issue(Invite{SessionCode: "ABC234", SessionID: "session-1", Name: "Sam"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue invite: %v", err)
+	}
+	if !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected the invite to expire in an hour, got %v", expiresAt)
+	}
+
+	invite, err := signer.Verify(token)
+	if err != nil {
+		t.Fatalf("Expected the invite to verify: %v", err)
+	}
+	if invite.SessionCode != "ABC234" || invite.SessionID != "session-1" || invite.Name != "Sam" {
+		t.Errorf("Expected the invite's contents back, got %+v", invite)
+	}
+
+	// Another server with the same secret accepts it
+	other, _ := NewSigner("test-secret")
+	other.now = signer.now
+	if _, err := other.Verify(token); err != nil {
+		t.Errorf("Expected a server sharing the secret to accept the invite: %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := signer.Verify(token); !errors.Is(err, ErrExpired) {
+		t.Errorf("Expected ErrExpired after an hour, got %v", err)
+	}
+}
+
+func TestForgedInvitesRefused(t *testing.T) {
+	signer, _ := NewSigner("test-secret")
+	token, _, _ := signer.Issue(Invite{SessionCode: "ABC234", SessionID: "session-1"}, 0)
+
+	// Pointing the invite at another session breaks the signature
+	payload, signature, _ := strings.Cut(token, ".")
+	data, _ := base64.RawURLEncoding.DecodeString(payload)
+	forged := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(data), "ABC234", "XYZ789", 1)))
+
+	otherKey, _ := NewSigner("")
+	otherToken, _, _ := otherKey.Issue(Invite{SessionCode: "ABC234", SessionID: "session-1"}, 0)
+
+	for name, token := range map[string]string{
+		"changed payload":   forged + "." + signature,
+		"other secret":      otherToken,
+		"no signature":      payload,
+		"empty":             "",
+		"garbage":           "not.an.invite",
+		"unsigned contents": base64.RawURLEncoding.EncodeToString([]byte(`{"code":"ABC234"}`)) + "." + signature,
+	} {
+		if _, err := signer.Verify(token); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestInviteLifetimeBounded(t *testing.T) {
+	signer, _ := NewSigner("test-secret")
+	if _, _, err := signer.Issue(Invite{SessionCode: "ABC234", SessionID: "session-1"}, MaxTTL+time.Hour); err == nil {
+		t.Error("Expected an invite longer than MaxTTL to be refused")
+	}
+	if _, _, err := signer.Issue(Invite{SessionCode: "ABC234", SessionID: "session-1"}, -time.Hour); err == nil {
+		t.Error("Expected a negative lifetime to be refused")
+	}
+}
@@ -0,0 +1,116 @@
+// ABOUTME: QR codes of a session's join link, for facilitators presenting on a shared screen
+// ABOUTME: A room can join by scanning instead of typing the code
+package joinqr
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+const (
+	// Pattern is the route QR codes are served on
+	Pattern = "GET /sessions/{code}/qr.png"
+
+	// DefaultSize is the width and height of a QR code in pixels unless ?size= says otherwise
+	DefaultSize = 512
+
+	// minSize and maxSize bound ?size=; smaller codes don't scan from across a room
+	minSize = 128
+	maxSize = 1024
+)
+
+// Handler serves QR codes of sessions' join links
+type Handler struct {
+	sessions   *session.Manager
+	baseURL    string // Public origin of the app, e.g. https://uplift.example.com ("" = from the request)
+	instanceID string // This instance, added to join links for sticky load balancers ("" = none)
+	mux        *http.ServeMux
+}
+
+// NewHandler creates a QR code handler that looks sessions up in manager
+func NewHandler(manager *session.Manager) *Handler {
+	h := &Handler{
+		sessions: manager,
+		mux:      http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc(Pattern, h.handleQR)
+
+	return h
+}
+
+// SetBaseURL sets the public origin join links point at, for servers behind a proxy
+// that doesn't pass the original scheme and host on
+func (h *Handler) SetBaseURL(baseURL string) {
+	h.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetInstanceID adds ?instance= to join links so sticky load balancers route scanners
+// to the instance holding the session
+func (h *Handler) SetInstanceID(instanceID string) {
+	h.instanceID = instanceID
+}
+
+// ServeHTTP routes the request to the QR code endpoint
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// handleQR renders a PNG QR code of the session's join link
+// ?size= sets the width and height in pixels
+func (h *Handler) handleQR(w http.ResponseWriter, r *http.Request) {
+	sess, err := h.sessions.GetSessionByCode(r.PathValue("code"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	size := DefaultSize
+	if value := r.URL.Query().Get("size"); value != "" {
+		size, err = strconv.Atoi(value)
+		if err != nil || size < minSize || size > maxSize {
+			http.Error(w, fmt.Sprintf("size must be between %d and %d pixels", minSize, maxSize), http.StatusBadRequest)
+			return
+		}
+	}
+
+	png, err := qrcode.Encode(h.JoinURL(r, sess.Code), qrcode.Medium, size)
+	if err != nil {
+		slog.Error("Failed to render join QR code", "sessionCode", sess.Code, "err", err)
+		http.Error(w, "failed to render QR code", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+	// Codes are freed when a session ends, so don't keep the image long
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Write(png)
+}
+
+// JoinURL returns the link that opens the app with the session code filled in
+// Without a base URL it's the origin the request was made to
+func (h *Handler) JoinURL(r *http.Request, code string) string {
+	base := h.baseURL
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+
+	query := url.Values{"code": {code}}
+	if h.instanceID != "" {
+		query.Set("instance", h.instanceID)
+	}
+	return base + "/?" + query.Encode()
+}
@@ -0,0 +1,84 @@
+package joinqr
+
+import (
+	"bytes"
+	"crypto/tls"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestServesJoinLinkQRCode(t *testing.T) {
+	manager := session.NewManager()
+	sess := manager.CreateSession("Host")
+	handler := NewHandler(manager)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://uplift.test/sessions/"+sess.Code+"/qr.png?size=256", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Expected a PNG, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Expected a valid PNG: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 256 || bounds.Dy() != 256 {
+		t.Errorf("Expected a 256px image, got %v", bounds)
+	}
+
+	expected, _ := qrcode.Encode("http://uplift.test/?code="+sess.Code, qrcode.Medium, 256)
+	if !bytes.Equal(rec.Body.Bytes(), expected) {
+		t.Error("Expected the QR code to encode the join link")
+	}
+}
+
+func TestQRCodeRequests(t *testing.T) {
+	manager := session.NewManager()
+	sess := manager.CreateSession("Host")
+	handler := NewHandler(manager)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+	}{
+		{"lowercase code", http.MethodGet, "/sessions/" + strings.ToLower(sess.Code) + "/qr.png", http.StatusOK},
+		{"unknown session", http.MethodGet, "/sessions/NOPE42/qr.png", http.StatusNotFound},
+		{"too small", http.MethodGet, "/sessions/" + sess.Code + "/qr.png?size=64", http.StatusBadRequest},
+		{"not a number", http.MethodGet, "/sessions/" + sess.Code + "/qr.png?size=big", http.StatusBadRequest},
+		{"wrong method", http.MethodPost, "/sessions/" + sess.Code + "/qr.png", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(test.method, test.target, nil))
+			if rec.Code != test.status {
+				t.Errorf("Expected %d, got %d", test.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestJoinURL(t *testing.T) {
+	handler := NewHandler(session.NewManager())
+
+	r := httptest.NewRequest(http.MethodGet, "https://uplift.example.com/sessions/ABC234/qr.png", nil)
+	r.TLS = &tls.ConnectionState{}
+	if got := handler.JoinURL(r, "ABC234"); got != "https://uplift.example.com/?code=ABC234" {
+		t.Errorf("Expected the request's origin, got %s", got)
+	}
+
+	handler.SetBaseURL("https://circles.example.org/")
+	handler.SetInstanceID("eu-1")
+	if got := handler.JoinURL(r, "ABC234"); got != "https://circles.example.org/?code=ABC234&instance=eu-1" {
+		t.Errorf("Expected the configured base URL and instance, got %s", got)
+	}
+}
@@ -0,0 +1,150 @@
+// ABOUTME: Lease-based leader election so background jobs run on exactly one node
+// ABOUTME: A node runs a job only while it holds and keeps renewing the job's lease
+package leader
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Lease is a named, expiring lock shared by every node that could run a job
+// Implementations backed by a shared store (Redis, Postgres) make election span
+// nodes; MemoryLease only spans one process
+type Lease interface {
+	// Acquire takes the named lease for holder, or extends it if holder already
+	// has it, until ttl from now. Reports whether holder has the lease.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+
+	// Release gives up the lease early if holder has it
+	Release(ctx context.Context, name, holder string) error
+}
+
+// Elector runs a job on whichever node holds a lease
+type Elector struct {
+	lease  Lease
+	name   string
+	holder string
+	ttl    time.Duration
+	leader atomic.Bool
+}
+
+// NewElector creates an elector for the named lease, identifying this node as holder
+// Leaders renew every third of ttl, so a node that dies is replaced within ttl
+func NewElector(lease Lease, name, holder string, ttl time.Duration) *Elector {
+	return &Elector{lease: lease, name: name, holder: holder, ttl: ttl}
+}
+
+// IsLeader reports whether this node currently holds the lease
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns for the lease until ctx is done, running job whenever this node
+// is leader. job's context is cancelled as soon as leadership can't be confirmed,
+// and Run waits for job to return before campaigning again.
+func (e *Elector) Run(ctx context.Context, job func(ctx context.Context)) {
+	interval := e.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var jobCancel context.CancelFunc
+	var jobDone sync.WaitGroup
+	stepDown := func() {
+		if jobCancel == nil {
+			return
+		}
+		e.leader.Store(false)
+		jobCancel()
+		jobDone.Wait()
+		jobCancel = nil
+		slog.Warn("Leadership lost", "lease", e.name, "holder", e.holder)
+	}
+
+	for {
+		held, err := e.lease.Acquire(ctx, e.name, e.holder, e.ttl)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("Lease renewal failed", "lease", e.name, "holder", e.holder, "err", err)
+		}
+
+		switch {
+		case held && err == nil && jobCancel == nil:
+			var jobCtx context.Context
+			jobCtx, jobCancel = context.WithCancel(ctx)
+			e.leader.Store(true)
+			slog.Info("Leadership acquired", "lease", e.name, "holder", e.holder)
+			jobDone.Add(1)
+			go func() {
+				defer jobDone.Done()
+				job(jobCtx)
+			}()
+		case !held || err != nil:
+			stepDown()
+		}
+
+		select {
+		case <-ctx.Done():
+			wasLeader := jobCancel != nil
+			stepDown()
+			if wasLeader {
+				// Let another node take over without waiting for the lease to expire
+				release, cancel := context.WithTimeout(context.Background(), interval)
+				if err := e.lease.Release(release, e.name, e.holder); err != nil {
+					slog.Warn("Lease release failed", "lease", e.name, "holder", e.holder, "err", err)
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MemoryLease is a Lease held in process memory, for single-node deployments
+// and tests. Every node using its own MemoryLease is leader of its own jobs.
+type MemoryLease struct {
+	leases map[string]memoryLease
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+// memoryLease is one held lease
+type memoryLease struct {
+	holder  string
+	expires time.Time
+}
+
+// NewMemoryLease creates an empty in-memory lease table
+func NewMemoryLease() *MemoryLease {
+	return &MemoryLease{
+		leases: make(map[string]memoryLease),
+		now:    time.Now,
+	}
+}
+
+// Acquire takes or extends the lease if it is free, expired or already holder's
+func (m *MemoryLease) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	current, exists := m.leases[name]
+	if exists && current.holder != holder && now.Before(current.expires) {
+		return false, nil
+	}
+	m.leases[name] = memoryLease{holder: holder, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// Release frees the lease if holder has it
+func (m *MemoryLease) Release(ctx context.Context, name, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, exists := m.leases[name]; exists && current.holder == holder {
+		delete(m.leases, name)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testTTL = 60 * time.Millisecond
+
+// waitFor polls until cond is true or a second passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOnlyOneElectorRunsTheJob(t *testing.T) {
+	lease := NewMemoryLease()
+	var running atomic.Int32
+	job := func(ctx context.Context) {
+		running.Add(1)
+		<-ctx.Done()
+		running.Add(-1)
+	}
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	a := NewElector(lease, "cleanup", "node-a", testTTL)
+	doneA := make(chan struct{})
+	go func() { a.Run(ctxA, job); close(doneA) }()
+	waitFor(t, a.IsLeader)
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	b := NewElector(lease, "cleanup", "node-b", testTTL)
+	go b.Run(ctxB, job)
+
+	// b keeps campaigning while a renews
+	time.Sleep(2 * testTTL)
+	if b.IsLeader() || running.Load() != 1 {
+		t.Fatalf("Expected only node-a running the job, got %d running", running.Load())
+	}
+
+	// a shutting down releases the lease and b takes over
+	cancelA()
+	<-doneA
+	waitFor(t, b.IsLeader)
+	waitFor(t, func() bool { return running.Load() == 1 })
+}
+
+// failingLease grants the lease until told to fail
+type failingLease struct {
+	*MemoryLease
+	fail atomic.Bool
+}
+
+func (f *failingLease) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	if f.fail.Load() {
+		return false, errors.New("store unreachable")
+	}
+	return f.MemoryLease.Acquire(ctx, name, holder, ttl)
+}
+
+func TestElectorStepsDownWhenRenewalFails(t *testing.T) {
+	lease := &failingLease{MemoryLease: NewMemoryLease()}
+	stopped := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := NewElector(lease, "cleanup", "node-a", testTTL)
+	go e.Run(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+	waitFor(t, e.IsLeader)
+
+	lease.fail.Store(true)
+	select {
+	case <-stopped:
+	case <-time.After(testTTL):
+		t.Fatal("Expected the job to stop before the lease could expire")
+	}
+	if e.IsLeader() {
+		t.Error("Expected elector to step down")
+	}
+}
+
+func TestMemoryLeaseExpires(t *testing.T) {
+	lease := NewMemoryLease()
+	now := time.Now()
+	lease.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if held, _ := lease.Acquire(ctx, "job", "a", time.Minute); !held {
+		t.Fatal("Expected a to take a free lease")
+	}
+	if held, _ := lease.Acquire(ctx, "job", "b", time.Minute); held {
+		t.Fatal("Expected b to be refused a held lease")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if held, _ := lease.Acquire(ctx, "job", "b", time.Minute); !held {
+		t.Error("Expected b to take an expired lease")
+	}
+}
@@ -0,0 +1,53 @@
+// ABOUTME: Lease held in Redis so background jobs run on exactly one node across replicas
+// ABOUTME: Acquire and release are Lua scripts so only the holder can extend or free a lease
+package leader
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/redis"
+)
+
+// acquireScript takes the lease if it's free or already the holder's, for ttl milliseconds
+const acquireScript = `
+local holder = redis.call('GET', KEYS[1])
+if holder and holder ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`
+
+// releaseScript frees the lease only if the holder still has it
+const releaseScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+// RedisLease is a Lease shared by every node using the same Redis
+type RedisLease struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLease creates leases stored under keys starting with prefix
+func NewRedisLease(client *redis.Client, prefix string) *RedisLease {
+	return &RedisLease{client: client, prefix: prefix}
+}
+
+// Acquire takes or extends the lease if it is free, expired or already holder's
+func (r *RedisLease) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	held, err := redis.Int(r.client.Do(ctx, "EVAL", acquireScript, "1", r.prefix+"lease:"+name,
+		holder, strconv.FormatInt(ttl.Milliseconds(), 10)))
+	return held == 1, err
+}
+
+// Release frees the lease if holder has it
+func (r *RedisLease) Release(ctx context.Context, name, holder string) error {
+	_, err := r.client.Do(ctx, "EVAL", releaseScript, "1", r.prefix+"lease:"+name, holder)
+	return err
+}
@@ -0,0 +1,71 @@
+// ABOUTME: Structured logging setup shared by every package through slog's default logger
+// ABOUTME: Level and format (text or JSON) are configurable so logs can feed a log pipeline
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Format is how log records are written
+type Format string
+
+const (
+	FormatText Format = "text" // key=value pairs, easy to read in a terminal
+	FormatJSON Format = "json" // One JSON object per line, for log pipelines
+)
+
+// ParseLevel reads a level name: debug, info (default when empty), warn or error
+func ParseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("log level must be debug, info, warn or error, got %q", value)
+	}
+}
+
+// ParseFormat reads a format name: text (default when empty) or json
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(value))); format {
+	case "":
+		return FormatText, nil
+	case FormatText, FormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("log format must be text or json, got %q", value)
+	}
+}
+
+// New creates a logger writing records at level and above to w in format
+func New(w io.Writer, level slog.Level, format Format) *slog.Logger {
+	options := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, options))
+	}
+	return slog.New(slog.NewTextHandler(w, options))
+}
+
+// Configure parses a level and format and makes a logger writing to w the default,
+// so slog calls and the standard library's log package both go through it
+func Configure(w io.Writer, level, format string) error {
+	parsedLevel, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	parsedFormat, err := ParseFormat(format)
+	if err != nil {
+		return err
+	}
+
+	slog.SetDefault(New(w, parsedLevel, parsedFormat))
+	return nil
+}
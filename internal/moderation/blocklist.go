@@ -0,0 +1,78 @@
+// ABOUTME: Blocklist filter rejecting notes and names containing operator-chosen words or phrases
+// ABOUTME: Matching ignores case, punctuation and spacing, but only matches whole words
+package moderation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Blocklist rejects text containing any of its terms as whole words
+type Blocklist struct {
+	terms []string // Normalised, each padded with spaces
+}
+
+// NewBlocklist creates a blocklist from words or phrases; blank terms are ignored
+func NewBlocklist(terms []string) *Blocklist {
+	b := &Blocklist{}
+	for _, term := range terms {
+		if normalised := normalise(term); normalised != "" {
+			b.terms = append(b.terms, " "+normalised+" ")
+		}
+	}
+	return b
+}
+
+// LoadTerms reads a blocklist file with one word or phrase per line
+// Blank lines and lines starting with # are skipped
+func LoadTerms(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening blocklist: %w", err)
+	}
+	defer file.Close()
+
+	var terms []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading blocklist: %w", err)
+	}
+	return terms, nil
+}
+
+// Len returns how many terms the blocklist holds
+func (b *Blocklist) Len() int {
+	return len(b.terms)
+}
+
+// Check rejects text containing a blocked term
+// The same list applies to every kind of text
+func (b *Blocklist) Check(ctx context.Context, kind Kind, text string) (Decision, error) {
+	padded := " " + normalise(text) + " "
+	for _, term := range b.terms {
+		if strings.Contains(padded, term) {
+			return Decision{Reason: "contains language that isn't allowed here"}, nil
+		}
+	}
+	return Allow, nil
+}
+
+// normalise lowercases text and reduces it to words separated by single spaces,
+// so "Bad-word!" and "bad  word" both match the phrase "bad word"
+func normalise(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,68 @@
+// ABOUTME: Pluggable content moderation for notes and names before they reach a circle
+// ABOUTME: Filters are chained in order, and the first to reject a text decides
+package moderation
+
+import (
+	"context"
+	"fmt"
+)
+
+// Kind is what a moderated text is, so filters can apply different policies
+type Kind string
+
+const (
+	KindNote    Kind = "note"    // Note content, including edits
+	KindName    Kind = "name"    // A participant's display name
+	KindSession Kind = "session" // Text the host shows the whole circle: the title, welcome or writing prompt
+)
+
+// Decision is a filter's verdict on one text
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"` // Shown to the author when the text is rejected
+}
+
+// Allow is the decision for a text a filter has no objection to
+var Allow = Decision{Allowed: true}
+
+// Filter checks text against a content policy
+// An error means the text couldn't be checked, and it is rejected
+type Filter interface {
+	Check(ctx context.Context, kind Kind, text string) (Decision, error)
+}
+
+// chain runs filters in order until one rejects
+type chain []Filter
+
+// Chain combines filters into one, or returns nil if there are none
+func Chain(filters ...Filter) Filter {
+	var combined chain
+	for _, filter := range filters {
+		if filter != nil {
+			combined = append(combined, filter)
+		}
+	}
+
+	switch len(combined) {
+	case 0:
+		return nil
+	case 1:
+		return combined[0]
+	default:
+		return combined
+	}
+}
+
+// Check returns the first rejection, or Allow if every filter allows the text
+func (c chain) Check(ctx context.Context, kind Kind, text string) (Decision, error) {
+	for _, filter := range c {
+		decision, err := filter.Check(ctx, kind, text)
+		if err != nil {
+			return Decision{}, fmt.Errorf("moderation: %w", err)
+		}
+		if !decision.Allowed {
+			return decision, nil
+		}
+	}
+	return Allow, nil
+}
@@ -0,0 +1,98 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlocklist(t *testing.T) {
+	blocklist := NewBlocklist([]string{"Darn", "silly goose", " "})
+	if blocklist.Len() != 2 {
+		t.Fatalf("Expected blank terms to be skipped, got %d terms", blocklist.Len())
+	}
+
+	tests := []struct {
+		text    string
+		allowed bool
+	}{
+		{"Thanks for everything", true},
+		{"Well DARN, you were great", false},
+		{"You silly-goose!", false},
+		{"Silly  goose", false},
+		{"Darning socks together was fun", true}, // Whole words only
+		{"What a goose", true},
+	}
+	for _, tt := range tests {
+		decision, err := blocklist.Check(context.Background(), KindNote, tt.text)
+		if err != nil || decision.Allowed != tt.allowed {
+			t.Errorf("Check(%q) = %+v, %v; want allowed=%t", tt.text, decision, err, tt.allowed)
+		}
+		if !decision.Allowed && decision.Reason == "" {
+			t.Errorf("Expected a reason for rejecting %q", tt.text)
+		}
+	}
+}
+
+func TestLoadTerms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	os.WriteFile(path, []byte("# Workplace policy\ndarn\n\nsilly goose\n"), 0o600)
+
+	terms, err := LoadTerms(path)
+	if err != nil || len(terms) != 2 || terms[1] != "silly goose" {
+		t.Errorf("Expected two terms, got %q (%v)", terms, err)
+	}
+	if _, err := LoadTerms(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected a missing file to fail")
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var received webhookRequest
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+		if received.Text == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(Decision{Allowed: received.Text != "rude", Reason: "be kind"})
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(server.URL, "secret", false)
+	decision, err := webhook.Check(context.Background(), KindName, "rude")
+	if err != nil || decision.Allowed || decision.Reason != "be kind" {
+		t.Errorf("Expected the service's rejection, got %+v (%v)", decision, err)
+	}
+	if received.Kind != KindName || auth != "Bearer secret" {
+		t.Errorf("Expected the kind and bearer token to be sent, got %+v %q", received, auth)
+	}
+
+	if _, err := webhook.Check(context.Background(), KindNote, "fail"); err == nil {
+		t.Error("Expected a failing service to return an error")
+	}
+
+	failOpen := NewWebhook(server.URL, "", true)
+	if decision, err := failOpen.Check(context.Background(), KindNote, "fail"); err != nil || !decision.Allowed {
+		t.Errorf("Expected fail-open to allow the text, got %+v (%v)", decision, err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	if Chain() != nil || Chain(nil) != nil {
+		t.Error("Expected no filters to mean no moderation")
+	}
+
+	filter := Chain(NewBlocklist([]string{"darn"}), NewBlocklist([]string{"heck"}))
+	for text, allowed := range map[string]bool{"thanks": true, "darn": false, "heck": false} {
+		if decision, _ := filter.Check(context.Background(), KindNote, text); decision.Allowed != allowed {
+			t.Errorf("Check(%q) allowed=%t, want %t", text, decision.Allowed, allowed)
+		}
+	}
+}
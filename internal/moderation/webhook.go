@@ -0,0 +1,84 @@
+// ABOUTME: Webhook filter asking an external moderation service about each text
+// ABOUTME: Posts {"kind", "text"} and expects {"allowed", "reason"} back
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// maxResponseBytes bounds how much of a moderation service's reply is read
+const maxResponseBytes = 64 * 1024
+
+// Webhook asks an external service whether a text is allowed
+type Webhook struct {
+	url      string
+	token    string // Sent as a bearer token if set
+	failOpen bool   // Allow texts when the service can't be reached instead of rejecting them
+	client   *http.Client
+}
+
+// NewWebhook creates a filter posting to url, authenticating with token if it isn't empty
+// With failOpen, texts are allowed while the service is failing rather than rejected
+func NewWebhook(url, token string, failOpen bool) *Webhook {
+	return &Webhook{
+		url:      url,
+		token:    token,
+		failOpen: failOpen,
+		client:   &http.Client{},
+	}
+}
+
+// webhookRequest is the body posted to the moderation service
+type webhookRequest struct {
+	Kind Kind   `json:"kind"`
+	Text string `json:"text"`
+}
+
+// Check posts the text to the moderation service and returns its decision
+func (w *Webhook) Check(ctx context.Context, kind Kind, text string) (Decision, error) {
+	decision, err := w.check(ctx, kind, text)
+	if err != nil && w.failOpen {
+		slog.Warn("Moderation webhook failed, allowing text", "kind", kind, "err", err)
+		return Allow, nil
+	}
+	return decision, err
+}
+
+// check makes one request to the moderation service
+func (w *Webhook) check(ctx context.Context, kind Kind, text string) (Decision, error) {
+	body, err := json.Marshal(webhookRequest{Kind: kind, Text: text})
+	if err != nil {
+		return Decision{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Decision{}, fmt.Errorf("webhook: status %d", resp.StatusCode)
+	}
+
+	var decision Decision
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&decision); err != nil {
+		return Decision{}, fmt.Errorf("webhook response: %w", err)
+	}
+	return decision, nil
+}
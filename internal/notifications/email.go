@@ -0,0 +1,100 @@
+// ABOUTME: Email channel sending plain-text notifications over SMTP
+// ABOUTME: Delivers to a fixed list of operator-configured addresses
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Email sends notifications through an SMTP relay
+type Email struct {
+	addr string // SMTP relay host:port
+	auth smtp.Auth
+	from string
+	to   []string
+
+	// sendMail is smtp.SendMail, replaceable in tests
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail creates an email channel delivering to the given addresses
+// username may be empty for relays that don't require authentication
+func NewEmail(addr, username, password, from string, to []string) (*Email, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp address: %w", err)
+	}
+	if from == "" {
+		return nil, errors.New("email sender address is required")
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &Email{
+		addr:     addr,
+		auth:     auth,
+		from:     from,
+		to:       to,
+		sendMail: smtp.SendMail,
+	}, nil
+}
+
+// Destinations returns the configured recipient addresses
+func (e *Email) Destinations() []Contact {
+	contacts := make([]Contact, 0, len(e.to))
+	for _, address := range e.to {
+		contacts = append(contacts, Contact{Channel: ChannelEmail, Address: address, Label: address})
+	}
+	return contacts
+}
+
+// Notify emails one address
+// net/smtp has no context support, so a cancelled context only prevents starting the send
+func (e *Email) Notify(ctx context.Context, address string, n Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(address, "\r\n") {
+		return errors.New("invalid email address")
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.sender(n))
+	fmt.Fprintf(&msg, "To: %s\r\n", address)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", headerSafe(n.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(n.Body)
+	if code := n.Data["sessionCode"]; code != "" {
+		fmt.Fprintf(&msg, "\r\n\r\nSession: %s", code)
+	}
+	msg.WriteString("\r\n")
+
+	return e.sendMail(e.addr, e.auth, e.from, []string{address}, []byte(msg.String()))
+}
+
+// sender is the From header, showing the tenant's product name if the notification has one
+func (e *Email) sender(n Notification) string {
+	name := n.Data["productName"]
+	if name == "" {
+		return e.from
+	}
+	return (&mail.Address{Name: name, Address: e.from}).String()
+}
+
+// headerSafe strips line breaks so values can't inject extra headers
+func headerSafe(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
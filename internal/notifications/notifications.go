@@ -0,0 +1,354 @@
+// ABOUTME: Unified notification delivery across push, email, Slack and Teams
+// ABOUTME: Routes session events to channels by rule, retries with backoff and tracks delivery status
+package notifications
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event identifies what happened in a session
+type Event string
+
+const (
+	EventWritingStarted  Event = "writing_started"  // The host started the writing phase
+	EventYourTurn        Event = "your_turn"        // A participant's turn to read came up
+	EventSessionComplete Event = "session_complete" // Every note has been read
+)
+
+// Channel names for the built-in notifiers
+const (
+	ChannelPush  = "push"
+	ChannelEmail = "email"
+	ChannelSlack = "slack"
+	ChannelTeams = "teams"
+)
+
+const (
+	// attemptTimeout bounds each delivery attempt
+	attemptTimeout = 10 * time.Second
+
+	// maxAttempts is how many times a delivery is tried before it's marked failed
+	maxAttempts = 4
+
+	// defaultRetryBackoff is the wait before the first retry; it doubles each attempt
+	defaultRetryBackoff = 2 * time.Second
+
+	// maxTrackedDeliveries bounds how many recent deliveries are kept for the admin API
+	maxTrackedDeliveries = 500
+)
+
+// Notification is a channel-neutral message about a session event
+type Notification struct {
+	Event Event
+	Title string
+	Body  string
+	Data  map[string]string // Extra key/values, e.g. sessionCode
+}
+
+// Notifier delivers notifications over one channel
+type Notifier interface {
+	// Notify delivers to one address, whose format depends on the channel
+	Notify(ctx context.Context, address string, n Notification) error
+}
+
+// Destinations is implemented by notifiers that always deliver to fixed addresses
+// configured by the operator, such as a team Slack channel, in addition to any
+// participant contacts
+type Destinations interface {
+	Destinations() []Contact
+}
+
+// Contact is somewhere a notification can be delivered
+type Contact struct {
+	Channel string
+	Address string // Channel-specific, e.g. an email address or push device
+	Label   string // Safe to show operators, e.g. a participant ID; addresses may be secret
+}
+
+// DeliveryStatus is where a delivery is in its lifecycle
+type DeliveryStatus string
+
+const (
+	StatusPending   DeliveryStatus = "pending"
+	StatusDelivered DeliveryStatus = "delivered"
+	StatusFailed    DeliveryStatus = "failed"
+)
+
+// Delivery tracks one notification to one contact
+type Delivery struct {
+	ID        string         `json:"id"`
+	Event     Event          `json:"event"`
+	Channel   string         `json:"channel"`
+	Recipient string         `json:"recipient"`
+	Status    DeliveryStatus `json:"status"`
+	Attempts  int            `json:"attempts"`
+	LastError string         `json:"lastError,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// Stats counts deliveries by outcome since startup
+type Stats struct {
+	Pending   int `json:"pending"`
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"`
+}
+
+// DefaultRules sends participants push alerts for the moments they may have tabbed away
+func DefaultRules() map[Event][]string {
+	return map[Event][]string{
+		EventWritingStarted: {ChannelPush},
+		EventYourTurn:       {ChannelPush},
+	}
+}
+
+// ParseRules reads routing rules as "event=channel,channel;event=channel",
+// e.g. "your_turn=push;session_complete=slack,email"
+// Events not listed keep their default routing; an empty channel list disables an event
+func ParseRules(config string) (map[Event][]string, error) {
+	rules := DefaultRules()
+
+	for _, entry := range strings.Split(config, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, channels, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid notification rule %q: expected event=channels", entry)
+		}
+
+		event := Event(strings.TrimSpace(name))
+		switch event {
+		case EventWritingStarted, EventYourTurn, EventSessionComplete:
+		default:
+			return nil, fmt.Errorf("unknown notification event: %s", event)
+		}
+
+		routed := []string{}
+		for _, channel := range strings.Split(channels, ",") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				routed = append(routed, channel)
+			}
+		}
+		rules[event] = routed
+	}
+
+	return rules, nil
+}
+
+// Dispatcher routes events to notifiers and tracks each delivery
+// A nil *Dispatcher delivers nothing
+type Dispatcher struct {
+	notifiers map[string]Notifier
+	rules     map[Event][]string
+	backoff   time.Duration
+
+	deliveries []*Delivery // Oldest first, capped at maxTrackedDeliveries
+	stats      Stats
+	mu         sync.RWMutex
+}
+
+// NewDispatcher creates a dispatcher with the given routing rules and no notifiers
+func NewDispatcher(rules map[Event][]string) *Dispatcher {
+	return &Dispatcher{
+		notifiers: make(map[string]Notifier),
+		rules:     rules,
+		backoff:   defaultRetryBackoff,
+	}
+}
+
+// Register sets the notifier for a channel
+func (d *Dispatcher) Register(channel string, notifier Notifier) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.notifiers[channel] = notifier
+	slog.Info("Notification channel registered", "channel", channel)
+}
+
+// Routes reports whether an event is delivered over a configured channel
+// Safe to call on a nil *Dispatcher
+func (d *Dispatcher) Routes(event Event, channel string) bool {
+	if d == nil {
+		return false
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, ok := d.notifiers[channel]; !ok {
+		return false
+	}
+	for _, routed := range d.rules[event] {
+		if routed == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsPush reports whether push notifications can be delivered to a platform
+// Safe to call on a nil *Dispatcher
+func (d *Dispatcher) SupportsPush(platform string) bool {
+	if d == nil {
+		return false
+	}
+
+	d.mu.RLock()
+	notifier, ok := d.notifiers[ChannelPush].(*PushNotifier)
+	d.mu.RUnlock()
+
+	return ok && notifier.Supports(platform)
+}
+
+// Dispatch delivers a notification to every contact on a channel routed for its event,
+// plus each routed notifier's fixed destinations
+// Deliveries run in the background with retries; safe to call on a nil *Dispatcher
+func (d *Dispatcher) Dispatch(n Notification, contacts []Contact) {
+	if d == nil {
+		return
+	}
+
+	d.mu.RLock()
+	var targets []Contact
+	for _, channel := range d.rules[n.Event] {
+		notifier, ok := d.notifiers[channel]
+		if !ok {
+			continue
+		}
+		for _, contact := range contacts {
+			if contact.Channel == channel {
+				targets = append(targets, contact)
+			}
+		}
+		if fixed, ok := notifier.(Destinations); ok {
+			targets = append(targets, fixed.Destinations()...)
+		}
+	}
+	d.mu.RUnlock()
+
+	for _, contact := range targets {
+		delivery := d.track(n.Event, contact)
+		go d.deliver(delivery, contact, n)
+	}
+}
+
+// deliver attempts one delivery with exponential backoff between failures
+func (d *Dispatcher) deliver(delivery *Delivery, contact Contact, n Notification) {
+	d.mu.RLock()
+	notifier := d.notifiers[contact.Channel]
+	backoff := d.backoff
+	d.mu.RUnlock()
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), attemptTimeout)
+		err := notifier.Notify(ctx, contact.Address, n)
+		cancel()
+
+		if err == nil {
+			d.update(delivery, attempt, StatusDelivered, nil)
+			return
+		}
+
+		if attempt == maxAttempts {
+			d.update(delivery, attempt, StatusFailed, err)
+			slog.Error("Notification failed", "event", n.Event, "channel", contact.Channel, "recipient", contact.Label, "attempts", attempt, "err", err)
+			return
+		}
+
+		d.update(delivery, attempt, StatusPending, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// track records a new pending delivery, evicting the oldest once the log is full
+func (d *Dispatcher) track(event Event, contact Contact) *Delivery {
+	now := time.Now()
+	delivery := &Delivery{
+		ID:        newDeliveryID(),
+		Event:     event,
+		Channel:   contact.Channel,
+		Recipient: contact.Label,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.deliveries = append(d.deliveries, delivery)
+	if len(d.deliveries) > maxTrackedDeliveries {
+		d.deliveries = d.deliveries[len(d.deliveries)-maxTrackedDeliveries:]
+	}
+	d.stats.Pending++
+	return delivery
+}
+
+// update records the outcome of a delivery attempt
+func (d *Dispatcher) update(delivery *Delivery, attempts int, status DeliveryStatus, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delivery.Attempts = attempts
+	delivery.UpdatedAt = time.Now()
+	if err != nil {
+		delivery.LastError = err.Error()
+	}
+
+	if status == delivery.Status {
+		return
+	}
+	d.stats.Pending--
+	switch status {
+	case StatusDelivered:
+		d.stats.Delivered++
+	case StatusFailed:
+		d.stats.Failed++
+	}
+	delivery.Status = status
+}
+
+// Deliveries returns recent deliveries, newest first, optionally filtered by status
+func (d *Dispatcher) Deliveries(status DeliveryStatus) []Delivery {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := []Delivery{}
+	for _, delivery := range d.deliveries {
+		if status == "" || delivery.Status == status {
+			result = append(result, *delivery)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+// Stats returns delivery counts since startup
+func (d *Dispatcher) Stats() Stats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.stats
+}
+
+// newDeliveryID generates a random delivery identifier
+func newDeliveryID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
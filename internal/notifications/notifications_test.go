@@ -0,0 +1,254 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/push"
+)
+
+// flakyNotifier fails a set number of times before succeeding
+type flakyNotifier struct {
+	failures  int
+	addresses []string
+	mu        sync.Mutex
+}
+
+func (f *flakyNotifier) Notify(ctx context.Context, address string, n Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.addresses = append(f.addresses, address)
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+// fixedNotifier also delivers to an operator-configured destination
+type fixedNotifier struct {
+	flakyNotifier
+}
+
+func (f *fixedNotifier) Destinations() []Contact {
+	return []Contact{{Channel: ChannelSlack, Address: "https://hooks.example.com/secret", Label: "slack webhook"}}
+}
+
+// waitForStats polls until the dispatcher reaches the expected counts
+func waitForStats(t *testing.T, d *Dispatcher, expected Stats) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if d.Stats() == expected {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Expected stats %+v, got %+v", expected, d.Stats())
+}
+
+func TestDispatchRouting(t *testing.T) {
+	rules, err := ParseRules("session_complete=slack")
+	if err != nil {
+		t.Fatalf("Failed to parse rules: %v", err)
+	}
+	dispatcher := NewDispatcher(rules)
+	pushChannel := &flakyNotifier{}
+	slack := &fixedNotifier{}
+	dispatcher.Register(ChannelPush, pushChannel)
+	dispatcher.Register(ChannelSlack, slack)
+
+	contacts := []Contact{
+		{Channel: ChannelPush, Address: "fcm:device-1", Label: "alice"},
+		{Channel: ChannelEmail, Address: "alice@example.com", Label: "alice"},
+	}
+
+	// your_turn keeps its default push routing; email isn't registered so is skipped
+	dispatcher.Dispatch(Notification{Event: EventYourTurn}, contacts)
+	// session_complete goes only to the fixed Slack destination
+	dispatcher.Dispatch(Notification{Event: EventSessionComplete}, contacts)
+
+	waitForStats(t, dispatcher, Stats{Delivered: 2})
+
+	if len(pushChannel.addresses) != 1 || pushChannel.addresses[0] != "fcm:device-1" {
+		t.Errorf("Expected one push delivery, got %v", pushChannel.addresses)
+	}
+	if len(slack.addresses) != 1 {
+		t.Errorf("Expected one slack delivery, got %v", slack.addresses)
+	}
+
+	// Webhook URLs are credentials and must not appear in the delivery log
+	for _, delivery := range dispatcher.Deliveries("") {
+		if strings.Contains(delivery.Recipient, "secret") {
+			t.Errorf("Expected recipient label, got %q", delivery.Recipient)
+		}
+	}
+
+	if !dispatcher.Routes(EventYourTurn, ChannelPush) || dispatcher.Routes(EventYourTurn, ChannelSlack) {
+		t.Error("Unexpected routing for your_turn")
+	}
+
+	var unset *Dispatcher
+	unset.Dispatch(Notification{Event: EventYourTurn}, contacts)
+	if unset.Routes(EventYourTurn, ChannelPush) || unset.SupportsPush("fcm") {
+		t.Error("Expected nil dispatcher to route nothing")
+	}
+}
+
+func TestDispatchRetries(t *testing.T) {
+	dispatcher := NewDispatcher(DefaultRules())
+	dispatcher.backoff = time.Millisecond
+
+	recovering := &flakyNotifier{failures: 2}
+	dispatcher.Register(ChannelPush, recovering)
+	dispatcher.Dispatch(Notification{Event: EventYourTurn}, []Contact{{Channel: ChannelPush, Address: "fcm:a"}})
+	waitForStats(t, dispatcher, Stats{Delivered: 1})
+
+	delivery := dispatcher.Deliveries(StatusDelivered)[0]
+	if delivery.Attempts != 3 || delivery.LastError != "unavailable" {
+		t.Errorf("Expected delivery on the third attempt, got %+v", delivery)
+	}
+
+	dispatcher.Register(ChannelPush, &flakyNotifier{failures: maxAttempts})
+	dispatcher.Dispatch(Notification{Event: EventYourTurn}, []Contact{{Channel: ChannelPush, Address: "fcm:b"}})
+	waitForStats(t, dispatcher, Stats{Delivered: 1, Failed: 1})
+
+	failed := dispatcher.Deliveries(StatusFailed)
+	if len(failed) != 1 || failed[0].Attempts != maxAttempts {
+		t.Errorf("Expected one failed delivery after %d attempts, got %+v", maxAttempts, failed)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("your_turn=push,email; writing_started=")
+	if err != nil {
+		t.Fatalf("Failed to parse rules: %v", err)
+	}
+	if len(rules[EventYourTurn]) != 2 {
+		t.Errorf("Expected two channels for your_turn, got %v", rules[EventYourTurn])
+	}
+	if len(rules[EventWritingStarted]) != 0 {
+		t.Errorf("Expected writing_started disabled, got %v", rules[EventWritingStarted])
+	}
+
+	if _, err := ParseRules("lunch=slack"); err == nil {
+		t.Error("Expected error for unknown event")
+	}
+	if _, err := ParseRules("your_turn"); err == nil {
+		t.Error("Expected error for missing channels")
+	}
+}
+
+func TestPushNotifier(t *testing.T) {
+	delivered := make(chan push.Notification, 1)
+	sender := push.NewSender()
+	sender.Register("fcm", providerFunc(func(token string, n push.Notification) {
+		delivered <- n
+	}))
+
+	dispatcher := NewDispatcher(DefaultRules())
+	dispatcher.Register(ChannelPush, NewPushNotifier(sender))
+
+	if !dispatcher.SupportsPush("fcm") || dispatcher.SupportsPush("apns") {
+		t.Error("Expected only fcm to be supported")
+	}
+
+	dispatcher.Dispatch(Notification{Event: EventYourTurn, Title: "It's your turn"}, []Contact{
+		{Channel: ChannelPush, Address: PushAddress("fcm", "device-1")},
+	})
+
+	select {
+	case n := <-delivered:
+		if n.Data["event"] != "your_turn" {
+			t.Errorf("Expected event in push data, got %+v", n.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a push delivery")
+	}
+}
+
+// providerFunc adapts a function to push.Provider
+type providerFunc func(token string, n push.Notification)
+
+func (f providerFunc) Send(ctx context.Context, token string, n push.Notification) error {
+	f(token, n)
+	return nil
+}
+
+func TestWebhooks(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+
+	n := Notification{Event: EventSessionComplete, Title: "Session complete", Body: "12 notes read", Data: map[string]string{"sessionCode": "ABC123"}}
+
+	slack := NewSlack(server.URL)
+	if err := slack.Notify(context.Background(), slack.Destinations()[0].Address, n); err != nil {
+		t.Fatalf("Failed to post to slack: %v", err)
+	}
+	if text, _ := body["text"].(string); !strings.Contains(text, "ABC123") {
+		t.Errorf("Expected session code in slack text, got %q", text)
+	}
+
+	teams := NewTeams(server.URL)
+	if err := teams.Notify(context.Background(), server.URL, n); err != nil {
+		t.Fatalf("Failed to post to teams: %v", err)
+	}
+	if body["@type"] != "MessageCard" || body["title"] != "Session complete" {
+		t.Errorf("Unexpected teams card: %+v", body)
+	}
+}
+
+func TestEmail(t *testing.T) {
+	email, err := NewEmail("smtp.example.com:587", "user", "pass", "uplift@example.com", []string{"ops@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to create email channel: %v", err)
+	}
+
+	var sentTo []string
+	var sent string
+	email.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sentTo = to
+		sent = string(msg)
+		return nil
+	}
+
+	contacts := email.Destinations()
+	if len(contacts) != 1 || contacts[0].Address != "ops@example.com" {
+		t.Fatalf("Unexpected destinations: %+v", contacts)
+	}
+
+	n := Notification{Title: "Done\r\nBcc: attacker@example.com", Body: "All notes read"}
+	if err := email.Notify(context.Background(), "ops@example.com", n); err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if len(sentTo) != 1 || sentTo[0] != "ops@example.com" {
+		t.Errorf("Expected delivery to ops@example.com, got %v", sentTo)
+	}
+	if strings.Contains(sent, "\r\nBcc:") {
+		t.Error("Expected header injection to be neutralised")
+	}
+
+	// Tenant branding shows as the sender's name
+	n = Notification{Title: "Done", Body: "All notes read", Data: map[string]string{"productName": "Acme Kudos"}}
+	email.Notify(context.Background(), "ops@example.com", n)
+	if !strings.Contains(sent, "From: \"Acme Kudos\" <uplift@example.com>\r\n") {
+		t.Errorf("Expected branded sender, got %q", sent)
+	}
+
+	if _, err := NewEmail("no-port", "", "", "uplift@example.com", nil); err == nil {
+		t.Error("Expected error for invalid smtp address")
+	}
+}
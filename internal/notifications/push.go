@@ -0,0 +1,50 @@
+// ABOUTME: Push channel adapter delivering notifications to participants' registered devices
+// ABOUTME: Wraps the per-platform push providers behind the common Notifier interface
+package notifications
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cassiascheffer/uplift/internal/push"
+)
+
+// PushNotifier delivers to mobile and browser devices through a push.Sender
+type PushNotifier struct {
+	sender *push.Sender
+}
+
+// NewPushNotifier creates a push channel using the sender's configured platforms
+func NewPushNotifier(sender *push.Sender) *PushNotifier {
+	return &PushNotifier{sender: sender}
+}
+
+// PushAddress encodes a device as a push contact address
+func PushAddress(platform, token string) string {
+	return platform + ":" + token
+}
+
+// Supports reports whether a provider is configured for a platform
+func (p *PushNotifier) Supports(platform string) bool {
+	return p.sender.Supports(platform)
+}
+
+// Notify delivers to a device addressed as "platform:token"
+func (p *PushNotifier) Notify(ctx context.Context, address string, n Notification) error {
+	platform, token, ok := strings.Cut(address, ":")
+	if !ok || token == "" {
+		return errors.New("push address must be platform:token")
+	}
+
+	data := map[string]string{"event": string(n.Event)}
+	for k, v := range n.Data {
+		data[k] = v
+	}
+
+	return p.sender.Send(ctx, platform, token, push.Notification{
+		Title: n.Title,
+		Body:  n.Body,
+		Data:  data,
+	})
+}
@@ -0,0 +1,96 @@
+// ABOUTME: Slack and Microsoft Teams channels posting to incoming webhooks
+// ABOUTME: Each posts to one operator-configured webhook, typically a team channel
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Webhook posts notifications as JSON to a chat service's incoming webhook
+type Webhook struct {
+	channel string
+	url     string
+	format  func(n Notification) interface{}
+	client  *http.Client
+}
+
+// NewSlack creates a Slack channel posting to an incoming webhook URL
+func NewSlack(webhookURL string) *Webhook {
+	return &Webhook{
+		channel: ChannelSlack,
+		url:     webhookURL,
+		format:  slackMessage,
+		client:  &http.Client{},
+	}
+}
+
+// NewTeams creates a Microsoft Teams channel posting to an incoming webhook URL
+func NewTeams(webhookURL string) *Webhook {
+	return &Webhook{
+		channel: ChannelTeams,
+		url:     webhookURL,
+		format:  teamsMessage,
+		client:  &http.Client{},
+	}
+}
+
+// Destinations returns the configured webhook
+// The URL embeds a credential, so it's labelled by channel only
+func (w *Webhook) Destinations() []Contact {
+	return []Contact{{Channel: w.channel, Address: w.url, Label: w.channel + " webhook"}}
+}
+
+// Notify posts a notification to a webhook URL
+func (w *Webhook) Notify(ctx context.Context, address string, n Notification) error {
+	body, err := json.Marshal(w.format(n))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s webhook request: %w", w.channel, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s webhook: status %d", w.channel, resp.StatusCode)
+	}
+	return nil
+}
+
+// slackMessage formats a notification as Slack mrkdwn text
+func slackMessage(n Notification) interface{} {
+	text := "*" + n.Title + "*\n" + n.Body
+	if code := n.Data["sessionCode"]; code != "" {
+		text += "\nSession: `" + code + "`"
+	}
+	return map[string]string{"text": text}
+}
+
+// teamsMessage formats a notification as an Office 365 connector MessageCard
+func teamsMessage(n Notification) interface{} {
+	card := map[string]interface{}{
+		"@type":    "MessageCard",
+		"@context": "https://schema.org/extensions",
+		"summary":  n.Title,
+		"title":    n.Title,
+		"text":     n.Body,
+	}
+	if code := n.Data["sessionCode"]; code != "" {
+		card["sections"] = []map[string]interface{}{{
+			"facts": []map[string]string{{"name": "Session", "value": code}},
+		}}
+	}
+	return card
+}
@@ -0,0 +1,136 @@
+// ABOUTME: Apple Push Notification service provider using token-based authentication
+// ABOUTME: Signs ES256 provider tokens with the team's .p8 key and reuses them for up to 50 minutes
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	apnsProduction = "https://api.push.apple.com"
+	apnsSandbox    = "https://api.sandbox.push.apple.com"
+
+	// Apple rejects provider tokens older than an hour
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// APNs sends notifications to iOS devices
+type APNs struct {
+	endpoint string
+	keyID    string
+	teamID   string
+	topic    string // App bundle ID
+	key      *ecdsa.PrivateKey
+	client   *http.Client
+
+	token    string
+	issuedAt time.Time
+	mu       sync.Mutex
+}
+
+// NewAPNs creates an APNs provider from a PEM-encoded .p8 signing key
+func NewAPNs(keyPEM, keyID, teamID, topic string, sandbox bool) (*APNs, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, errors.New("apns key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing apns key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("apns key must be an ECDSA key")
+	}
+
+	endpoint := apnsProduction
+	if sandbox {
+		endpoint = apnsSandbox
+	}
+
+	return &APNs{
+		endpoint: endpoint,
+		keyID:    keyID,
+		teamID:   teamID,
+		topic:    topic,
+		key:      key,
+		client:   &http.Client{},
+	}, nil
+}
+
+// Send delivers a notification to one APNs device token
+func (a *APNs) Send(ctx context.Context, token string, n Notification) error {
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{
+				"title": n.Title,
+				"body":  n.Body,
+			},
+			"sound": "default",
+		},
+	}
+	for k, v := range n.Data {
+		payload[k] = v
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	authToken, err := a.providerToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+authToken)
+	req.Header.Set("apns-topic", a.topic)
+	req.Header.Set("apns-push-type", "alert")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("apns request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("apns send: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// providerToken returns a signed ES256 JWT, reusing it until it nears expiry
+func (a *APNs) providerToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Since(a.issuedAt) < apnsTokenLifetime {
+		return a.token, nil
+	}
+
+	now := time.Now()
+	token, err := signES256(a.key,
+		map[string]interface{}{"alg": "ES256", "kid": a.keyID},
+		map[string]interface{}{"iss": a.teamID, "iat": now.Unix()},
+	)
+	if err != nil {
+		return "", fmt.Errorf("signing apns token: %w", err)
+	}
+
+	a.token = token
+	a.issuedAt = now
+	return a.token, nil
+}
@@ -0,0 +1,67 @@
+// ABOUTME: Firebase Cloud Messaging provider using the HTTP v1 API
+// ABOUTME: Authenticates with an OAuth access token supplied by the deployment
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// fcmEndpoint is the FCM HTTP v1 send URL format (project ID)
+const fcmEndpoint = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+
+// FCM sends notifications to Android and web devices via Firebase
+type FCM struct {
+	endpoint    string
+	accessToken func() string // OAuth access token, refreshed externally
+	client      *http.Client
+}
+
+// NewFCM creates an FCM provider for a Firebase project
+// accessToken is called per send so short-lived tokens can be rotated
+func NewFCM(projectID string, accessToken func() string) *FCM {
+	return &FCM{
+		endpoint:    fmt.Sprintf(fcmEndpoint, projectID),
+		accessToken: accessToken,
+		client:      &http.Client{},
+	}
+}
+
+// Send delivers a notification to one FCM registration token
+func (f *FCM) Send(ctx context.Context, token string, n Notification) error {
+	payload := map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": token,
+			"notification": map[string]string{
+				"title": n.Title,
+				"body":  n.Body,
+			},
+			"data": n.Data,
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+f.accessToken())
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fcm request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fcm send: status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// ABOUTME: Push notification subsystem with pluggable per-platform providers
+// ABOUTME: Delivers turn and phase alerts to devices of participants who tabbed away
+package push
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// sendTimeout bounds each delivery to a provider
+const sendTimeout = 10 * time.Second
+
+// Notification is a platform-neutral push message
+type Notification struct {
+	Title string
+	Body  string
+	Data  map[string]string // Extra key/values for the client app, e.g. sessionId
+}
+
+// Provider delivers notifications to one platform's devices
+type Provider interface {
+	Send(ctx context.Context, token string, n Notification) error
+}
+
+// Sender routes notifications to the provider for each device's platform
+type Sender struct {
+	providers map[string]Provider
+	mu        sync.RWMutex
+}
+
+// NewSender creates a sender with no providers configured
+func NewSender() *Sender {
+	return &Sender{
+		providers: make(map[string]Provider),
+	}
+}
+
+// Register sets the provider for a platform such as "fcm" or "apns"
+func (s *Sender) Register(platform string, provider Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.providers[platform] = provider
+	slog.Info("Push provider registered", "platform", platform)
+}
+
+// Supports reports whether a provider is configured for a platform
+// A nil *Sender supports nothing
+func (s *Sender) Supports(platform string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.providers[platform]
+	return ok
+}
+
+// Send delivers a notification to one device
+func (s *Sender) Send(ctx context.Context, platform, token string, n Notification) error {
+	s.mu.RLock()
+	provider, ok := s.providers[platform]
+	s.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("no push provider for platform %s", platform)
+	}
+	return provider.Send(ctx, token, n)
+}
+
+// SendAsync delivers a notification in the background, logging failures
+// Safe to call on a nil *Sender (does nothing)
+func (s *Sender) SendAsync(platform, token string, n Notification) {
+	if s == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		defer cancel()
+
+		if err := s.Send(ctx, platform, token, n); err != nil {
+			slog.Warn("Push delivery failed", "platform", platform, "err", err)
+		}
+	}()
+}
@@ -0,0 +1,269 @@
+package push
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordingProvider captures notifications instead of delivering them
+type recordingProvider struct {
+	tokens []string
+}
+
+func (p *recordingProvider) Send(ctx context.Context, token string, n Notification) error {
+	p.tokens = append(p.tokens, token)
+	return nil
+}
+
+func TestSenderRouting(t *testing.T) {
+	sender := NewSender()
+	fcm := &recordingProvider{}
+	sender.Register("fcm", fcm)
+
+	if !sender.Supports("fcm") || sender.Supports("apns") {
+		t.Error("Expected only fcm to be supported")
+	}
+
+	if err := sender.Send(context.Background(), "fcm", "device-1", Notification{Title: "Hi"}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if len(fcm.tokens) != 1 || fcm.tokens[0] != "device-1" {
+		t.Errorf("Expected delivery to device-1, got %v", fcm.tokens)
+	}
+
+	if err := sender.Send(context.Background(), "apns", "device-2", Notification{}); err == nil {
+		t.Error("Expected error for unconfigured platform")
+	}
+
+	var unset *Sender
+	if unset.Supports("fcm") {
+		t.Error("Expected nil sender to support nothing")
+	}
+	unset.SendAsync("fcm", "device-1", Notification{})
+}
+
+func TestFCMSend(t *testing.T) {
+	var got map[string]map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	fcm := NewFCM("project", func() string { return "access-token" })
+	fcm.endpoint = server.URL
+
+	err := fcm.Send(context.Background(), "device-1", Notification{Title: "Your turn", Body: "Draw a note"})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if got["message"]["token"] != "device-1" {
+		t.Errorf("Expected token device-1, got %v", got["message"]["token"])
+	}
+
+	fcm.accessToken = func() string { return "expired" }
+	if err := fcm.Send(context.Background(), "device-1", Notification{}); err == nil {
+		t.Error("Expected error for rejected request")
+	}
+}
+
+func TestAPNsSend(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	var authorization, topic, path string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		topic = r.Header.Get("apns-topic")
+		path = r.URL.Path
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	apns, err := NewAPNs(keyPEM, "KEY123", "TEAM123", "online.upliftapp", true)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	apns.endpoint = server.URL
+
+	err = apns.Send(context.Background(), "device-1", Notification{Title: "Your turn", Data: map[string]string{"sessionId": "s1"}})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	if path != "/3/device/device-1" || topic != "online.upliftapp" {
+		t.Errorf("Unexpected request: path=%s topic=%s", path, topic)
+	}
+	if !strings.Contains(string(body), `"sessionId":"s1"`) {
+		t.Errorf("Expected custom data in payload, got %s", body)
+	}
+
+	// The provider token must verify against the public key
+	token, ok := strings.CutPrefix(authorization, "bearer ")
+	if !ok {
+		t.Fatalf("Expected bearer token, got %q", authorization)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("Expected JWT, got %q", token)
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&key.PublicKey, digest[:], r, s) {
+		t.Error("Expected provider token signature to verify")
+	}
+
+	// Tokens are reused until they near expiry
+	again, _ := apns.providerToken()
+	if again != token {
+		t.Error("Expected provider token to be reused")
+	}
+
+	if _, err := NewAPNs("not a key", "KEY123", "TEAM123", "online.upliftapp", true); err == nil {
+		t.Error("Expected error for invalid key")
+	}
+}
+
+func TestWebPushSend(t *testing.T) {
+	vapidKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rawVAPID, _ := vapidKey.Bytes()
+
+	// The browser side of the subscription
+	browserKey, _ := ecdh.P256().GenerateKey(rand.Reader)
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	var authorization, encoding string
+	var body []byte
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		encoding = r.Header.Get("Content-Encoding")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	webPush, err := NewWebPush(base64.RawURLEncoding.EncodeToString(rawVAPID), "mailto:ops@upliftapp.online")
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	webPush.client = server.Client()
+
+	subscription, _ := json.Marshal(map[string]interface{}{
+		"endpoint": server.URL + "/push/abc",
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(browserKey.PublicKey().Bytes()),
+			"auth":   base64.URLEncoding.EncodeToString(authSecret), // Padded, as some browsers send
+		},
+	})
+
+	err = webPush.Send(context.Background(), string(subscription), Notification{Title: "It's your turn", Data: map[string]string{"event": "your_turn"}})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if encoding != "aes128gcm" {
+		t.Errorf("Expected aes128gcm encoding, got %q", encoding)
+	}
+
+	// The VAPID header carries a token signed by the application server key
+	params, ok := strings.CutPrefix(authorization, "vapid t=")
+	if !ok {
+		t.Fatalf("Expected vapid authorization, got %q", authorization)
+	}
+	token, publicKey, _ := strings.Cut(params, ", k=")
+	if publicKey != webPush.PublicKey() {
+		t.Errorf("Expected k=%s, got %s", webPush.PublicKey(), publicKey)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("Expected JWT, got %q", token)
+	}
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]interface{}
+	json.Unmarshal(claimsJSON, &claims)
+	if claims["aud"] != server.URL {
+		t.Errorf("Expected audience %s, got %v", server.URL, claims["aud"])
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&vapidKey.PublicKey, digest[:], r, s) {
+		t.Error("Expected VAPID token signature to verify")
+	}
+
+	// Decrypt as the browser would (RFC 8291)
+	salt, senderPublic, ciphertext := body[:16], body[21:86], body[86:]
+	senderKey, err := ecdh.P256().NewPublicKey(senderPublic)
+	if err != nil {
+		t.Fatalf("Invalid sender key in header: %v", err)
+	}
+	shared, _ := browserKey.ECDH(senderKey)
+	info := append([]byte("WebPush: info\x00"), browserKey.PublicKey().Bytes()...)
+	info = append(info, senderPublic...)
+	ikm, _ := hkdf.Key(sha256.New, shared, authSecret, string(info), 32)
+	prk, _ := hkdf.Extract(sha256.New, ikm, salt)
+	cek, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("Failed to decrypt payload: %v", err)
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Error("Expected final record delimiter")
+	}
+	if !strings.Contains(string(plaintext), `"event":"your_turn"`) {
+		t.Errorf("Expected event in payload, got %s", plaintext)
+	}
+}
+
+func TestParseWebPushSubscription(t *testing.T) {
+	browserKey, _ := ecdh.P256().GenerateKey(rand.Reader)
+	p256dh := base64.RawURLEncoding.EncodeToString(browserKey.PublicKey().Bytes())
+	auth := base64.RawURLEncoding.EncodeToString(make([]byte, 16))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid", `{"endpoint":"https://push.example.com/x","keys":{"p256dh":"` + p256dh + `","auth":"` + auth + `"}}`, false},
+		{"not json", "device-token", true},
+		{"http endpoint", `{"endpoint":"http://push.example.com/x","keys":{"p256dh":"` + p256dh + `","auth":"` + auth + `"}}`, true},
+		{"bad key", `{"endpoint":"https://push.example.com/x","keys":{"p256dh":"AAAA","auth":"` + auth + `"}}`, true},
+		{"short auth", `{"endpoint":"https://push.example.com/x","keys":{"p256dh":"` + p256dh + `","auth":"AAAA"}}`, true},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseWebPushSubscription(tt.token)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error=%v, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
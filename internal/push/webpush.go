@@ -0,0 +1,281 @@
+// ABOUTME: Web Push provider for browsers, authenticated with VAPID (RFC 8292)
+// ABOUTME: Encrypts payloads with aes128gcm (RFC 8291) so push services can't read them
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// webPushTTL is how long push services hold a message for an offline browser
+	// Turn alerts are useless once the moment has passed
+	webPushTTL = 10 * time.Minute
+
+	// vapidTokenLifetime bounds the VAPID JWT expiry; push services reject more than 24h
+	vapidTokenLifetime = 12 * time.Hour
+
+	// webPushRecordSize is the aes128gcm record size; payloads fit in a single record
+	webPushRecordSize = 4096
+)
+
+// WebPushSubscription is the PushSubscription JSON a browser produces
+// Clients register it as the device token for platform "webpush"
+type WebPushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"` // Browser's ECDH public key, base64url
+		Auth   string `json:"auth"`   // Browser's 16-byte auth secret, base64url
+	} `json:"keys"`
+}
+
+// ParseWebPushSubscription decodes and validates a subscription token
+func ParseWebPushSubscription(token string) (*WebPushSubscription, error) {
+	var sub WebPushSubscription
+	if err := json.Unmarshal([]byte(token), &sub); err != nil {
+		return nil, fmt.Errorf("invalid web push subscription: %w", err)
+	}
+
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, errors.New("web push endpoint must be an https URL")
+	}
+	if _, err := sub.publicKey(); err != nil {
+		return nil, err
+	}
+	if auth, err := decodeBase64URL(sub.Keys.Auth); err != nil || len(auth) != 16 {
+		return nil, errors.New("web push auth secret must be 16 bytes")
+	}
+	return &sub, nil
+}
+
+// publicKey decodes the browser's P-256 public key
+func (s *WebPushSubscription) publicKey() (*ecdh.PublicKey, error) {
+	raw, err := decodeBase64URL(s.Keys.P256dh)
+	if err != nil {
+		return nil, errors.New("web push p256dh key is not base64url")
+	}
+	key, err := ecdh.P256().NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid web push p256dh key: %w", err)
+	}
+	return key, nil
+}
+
+// WebPush sends notifications to browsers via their push service
+type WebPush struct {
+	key       *ecdsa.PrivateKey
+	publicKey string // Application server key, base64url, given to browsers when subscribing
+	subject   string // Contact for the push service operator, mailto: or https:
+	client    *http.Client
+}
+
+// NewWebPush creates a Web Push provider from a VAPID private key
+// privateKey is the base64url-encoded 32-byte P-256 scalar, as produced by common VAPID key generators
+func NewWebPush(privateKey, subject string) (*WebPush, error) {
+	raw, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, errors.New("vapid private key is not base64url")
+	}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing vapid private key: %w", err)
+	}
+	if subject == "" {
+		return nil, errors.New("vapid subject is required")
+	}
+
+	public, err := key.PublicKey.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebPush{
+		key:       key,
+		publicKey: base64.RawURLEncoding.EncodeToString(public),
+		subject:   subject,
+		client:    &http.Client{},
+	}, nil
+}
+
+// PublicKey returns the application server key browsers pass to pushManager.subscribe
+func (w *WebPush) PublicKey() string {
+	return w.publicKey
+}
+
+// ServeHTTP returns the application server key so the frontend can subscribe
+func (w *WebPush) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(map[string]string{"publicKey": w.publicKey})
+}
+
+// Send delivers a notification to one browser subscription
+// token is the subscription JSON registered by the browser
+func (w *WebPush) Send(ctx context.Context, token string, n Notification) error {
+	sub, err := ParseWebPushSubscription(token)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"title": n.Title,
+		"body":  n.Body,
+		"data":  n.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	body, err := encryptWebPush(sub, payload)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := w.vapidAuthorization(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", fmt.Sprintf("%d", int(webPushTTL.Seconds())))
+	req.Header.Set("Urgency", "high")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("web push request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Push services answer 201 Created; 404/410 mean the subscription has expired
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("web push send: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// vapidAuthorization builds the "vapid t=<jwt>, k=<key>" header for a push service
+func (w *WebPush) vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := signES256(w.key,
+		map[string]interface{}{"typ": "JWT", "alg": "ES256"},
+		map[string]interface{}{
+			"aud": u.Scheme + "://" + u.Host,
+			"exp": time.Now().Add(vapidTokenLifetime).Unix(),
+			"sub": w.subject,
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("signing vapid token: %w", err)
+	}
+
+	return "vapid t=" + token + ", k=" + w.publicKey, nil
+}
+
+// encryptWebPush encrypts a payload for a subscription using aes128gcm (RFC 8291)
+// The result is the header (salt, record size, sender key) followed by one encrypted record
+func encryptWebPush(sub *WebPushSubscription, payload []byte) ([]byte, error) {
+	browserKey, err := sub.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	authSecret, err := decodeBase64URL(sub.Keys.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	// A fresh key pair per message gives each message its own shared secret
+	senderKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := senderKey.ECDH(browserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	senderPublic := senderKey.PublicKey().Bytes()
+	keyInfo := append([]byte("WebPush: info\x00"), browserKey.Bytes()...)
+	keyInfo = append(keyInfo, senderPublic...)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single record ends with the 0x02 delimiter; leave room for it and the GCM tag
+	if len(payload)+1+gcm.Overhead() > webPushRecordSize {
+		return nil, errors.New("web push payload too large")
+	}
+	record := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(senderPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(senderPublic)))
+	header = append(header, senderPublic...)
+
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// decodeBase64URL accepts base64url with or without padding, as browsers vary
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,203 @@
+// ABOUTME: Keyed token-bucket rate limiting shared by the HTTP API and WebSocket paths
+// ABOUTME: Limits are written like "120/m" and report quota for X-RateLimit-* headers
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/abuse"
+)
+
+// sweepInterval is how often buckets that have refilled completely are dropped
+const sweepInterval = time.Minute
+
+// Limit allows Burst requests at once, refilling at Rate per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimit reads a limit written as "count/unit", e.g. "120/m", "10/s" or "1000/h"
+// The count is also the burst, so a full window's worth can be used at once
+// "off" or "0" returns a zero Limit, meaning no limit
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "off" || value == "0" {
+		return Limit{}, nil
+	}
+
+	count, unit, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected count/unit like 120/m", value)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit count: %s", count)
+	}
+
+	var window time.Duration
+	switch unit {
+	case "s":
+		window = time.Second
+	case "m":
+		window = time.Minute
+	case "h":
+		window = time.Hour
+	default:
+		return Limit{}, errors.New("rate limit unit must be s, m or h")
+	}
+
+	return Limit{Rate: float64(n) / window.Seconds(), Burst: n}, nil
+}
+
+// Result describes the quota left after a request
+type Result struct {
+	Allowed    bool
+	Limit      int           // Bucket size
+	Remaining  int           // Whole tokens left
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next request would be allowed (0 if allowed)
+}
+
+// bucket is one key's token bucket
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter applies one limit independently to each key, such as a client IP or API key
+// A nil *Limiter allows everything
+type Limiter struct {
+	limit     Limit
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// NewLimiter creates a limiter, or returns nil if the limit is zero
+func NewLimiter(limit Limit) *Limiter {
+	if limit.Rate <= 0 || limit.Burst <= 0 {
+		return nil
+	}
+	return &Limiter{
+		limit:   limit,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from key's bucket if one is available
+// Safe to call on a nil *Limiter
+func (l *Limiter) Allow(key string) Result {
+	if l == nil {
+		return Result{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweepUnlocked(now)
+
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: float64(l.limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*l.limit.Rate)
+	b.updated = now
+
+	result := Result{Limit: l.limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.durationFor(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = l.durationFor(float64(l.limit.Burst) - b.tokens)
+	return result
+}
+
+// durationFor returns how long the bucket takes to gain tokens
+func (l *Limiter) durationFor(tokens float64) time.Duration {
+	return time.Duration(tokens / l.limit.Rate * float64(time.Second))
+}
+
+// sweepUnlocked drops buckets that have been idle long enough to refill completely,
+// since a fresh bucket behaves identically
+// Internal helper that assumes caller already holds the lock
+func (l *Limiter) sweepUnlocked(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	full := l.durationFor(float64(l.limit.Burst))
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// WriteHeaders sets X-RateLimit-* headers, plus Retry-After when the request was refused
+// Reset and Retry-After are in seconds from now
+func WriteHeaders(w http.ResponseWriter, result Result) {
+	if result.Limit == 0 {
+		return
+	}
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	if !result.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// Middleware refuses requests over the limit with 429, keyed by key(r)
+// Every response carries the caller's quota headers
+func (l *Limiter) Middleware(key func(r *http.Request) string, next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := l.Allow(key(r))
+		WriteHeaders(w, result)
+		if !result.Allowed {
+			WriteLimited(w, result)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIP keys limits by the request's source address
+func ClientIP(r *http.Request) string {
+	if addr, ok := abuse.ClientIP(r); ok {
+		return addr.String()
+	}
+	return r.RemoteAddr
+}
+
+// WriteLimited writes a JSON 429 response for a refused request
+func WriteLimited(w http.ResponseWriter, result Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	fmt.Fprintf(w, `{"error":"rate limit exceeded","retryAfter":%d}`+"\n", ceilSeconds(result.RetryAfter))
+}
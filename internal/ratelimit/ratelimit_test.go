@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		burst   int
+		rate    float64
+		wantErr bool
+	}{
+		{"120/m", 120, 2, false},
+		{"10/s", 10, 10, false},
+		{"3600/h", 3600, 1, false},
+		{"off", 0, 0, false},
+		{"120", 0, 0, true},
+		{"0/m", 0, 0, true},
+		{"10/d", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		limit, err := ParseLimit(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for %q", tt.value)
+			}
+			continue
+		}
+		if err != nil || limit.Burst != tt.burst || limit.Rate != tt.rate {
+			t.Errorf("ParseLimit(%q) = %+v, %v", tt.value, limit, err)
+		}
+	}
+}
+
+func TestLimiterRefills(t *testing.T) {
+	limiter := NewLimiter(Limit{Rate: 1, Burst: 2})
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	if r := limiter.Allow("a"); !r.Allowed || r.Remaining != 1 {
+		t.Errorf("Expected first request allowed with 1 remaining, got %+v", r)
+	}
+	limiter.Allow("a")
+	r := limiter.Allow("a")
+	if r.Allowed || r.RetryAfter != time.Second {
+		t.Errorf("Expected third request refused for 1s, got %+v", r)
+	}
+
+	// Other keys have their own bucket
+	if r := limiter.Allow("b"); !r.Allowed {
+		t.Error("Expected a separate bucket per key")
+	}
+
+	now = now.Add(time.Second)
+	if r := limiter.Allow("a"); !r.Allowed {
+		t.Errorf("Expected a token after refilling, got %+v", r)
+	}
+
+	var disabled *Limiter
+	if r := disabled.Allow("a"); !r.Allowed {
+		t.Error("Expected a nil limiter to allow everything")
+	}
+	if NewLimiter(Limit{}) != nil {
+		t.Error("Expected a zero limit to disable limiting")
+	}
+}
+
+func TestMiddlewareHeaders(t *testing.T) {
+	limiter := NewLimiter(Limit{Rate: 0.5, Burst: 1})
+	handler := limiter.Middleware(ClientIP, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request()
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "1" || rec.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("Unexpected first response: %d %v", rec.Code, rec.Header())
+	}
+
+	rec = request()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", rec.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,93 @@
+// ABOUTME: Redis pub/sub: publishing to channels and receiving from pattern subscriptions
+// ABOUTME: Each subscription holds its own connection, since a subscribed connection can't run other commands
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Message is one message received on a subscription
+type Message struct {
+	Channel string
+	Payload string
+}
+
+// Subscription receives messages published to channels matching its pattern
+type Subscription struct {
+	cn *conn
+}
+
+// Publish sends payload to a channel's subscribers, returning how many received it
+func (c *Client) Publish(ctx context.Context, channel, payload string) (int64, error) {
+	return Int(c.Do(ctx, "PUBLISH", channel, payload))
+}
+
+// PSubscribe opens a connection subscribed to channels matching pattern, such as "hub:*"
+// The caller must Close the subscription
+func (c *Client) PSubscribe(ctx context.Context, pattern string) (*Subscription, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(dialTimeout)
+	}
+	cn.SetDeadline(deadline)
+
+	reply, err := cn.do([]string{"PSUBSCRIBE", pattern})
+	if err != nil {
+		cn.Close()
+		return nil, err
+	}
+	if items, ok := reply.([]interface{}); !ok || len(items) != 3 || items[0] != "psubscribe" {
+		cn.Close()
+		return nil, fmt.Errorf("redis: unexpected psubscribe reply %#v", reply)
+	}
+
+	// Messages arrive whenever they're published, so reads wait indefinitely
+	cn.SetDeadline(time.Time{})
+	return &Subscription{cn: cn}, nil
+}
+
+// Receive waits for the next message
+// It returns an error once the connection fails or the subscription is closed
+func (s *Subscription) Receive() (Message, error) {
+	for {
+		reply, err := readReply(s.cn.r)
+		if err != nil {
+			return Message{}, err
+		}
+
+		items, ok := reply.([]interface{})
+		if !ok || len(items) == 0 {
+			return Message{}, fmt.Errorf("redis: unexpected subscription reply %#v", reply)
+		}
+		switch items[0] {
+		case "pmessage":
+			if len(items) != 4 {
+				return Message{}, errors.New("redis: malformed pmessage")
+			}
+			channel, _ := items[2].(string)
+			payload, _ := items[3].(string)
+			return Message{Channel: channel, Payload: payload}, nil
+		case "message":
+			if len(items) != 3 {
+				return Message{}, errors.New("redis: malformed message")
+			}
+			channel, _ := items[1].(string)
+			payload, _ := items[2].(string)
+			return Message{Channel: channel, Payload: payload}, nil
+		}
+		// Skip subscribe confirmations and pongs
+	}
+}
+
+// Close ends the subscription and closes its connection, unblocking Receive
+func (s *Subscription) Close() error {
+	return s.cn.Close()
+}
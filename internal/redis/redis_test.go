@@ -0,0 +1,208 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer answers a handful of commands from an in-memory map
+type fakeServer struct {
+	listener net.Listener
+	values   map[string]string
+	commands []string
+	patterns map[net.Conn]string // Subscribed connections and their patterns
+	mu       sync.Mutex
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &fakeServer{listener: listener, values: make(map[string]string), patterns: make(map[net.Conn]string)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := make([]string, n)
+		for i := range args {
+			header, _ := r.ReadString('\n')
+			size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+			arg := make([]byte, size+2)
+			if _, err := io.ReadFull(r, arg); err != nil {
+				return
+			}
+			args[i] = string(arg[:size])
+		}
+		fmt.Fprint(conn, s.reply(conn, args))
+	}
+}
+
+func (s *fakeServer) reply(conn net.Conn, args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commands = append(s.commands, strings.Join(args, " "))
+	switch args[0] {
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "SET":
+		s.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "GET":
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "INCR":
+		n, _ := strconv.Atoi(s.values[args[1]])
+		s.values[args[1]] = strconv.Itoa(n + 1)
+		return fmt.Sprintf(":%d\r\n", n+1)
+	case "PSUBSCRIBE":
+		s.patterns[conn] = args[1]
+		return fmt.Sprintf("*3\r\n$10\r\npsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(args[1]), args[1])
+	case "PUBLISH":
+		received := 0
+		for subscriber, pattern := range s.patterns {
+			if matched, _ := path.Match(pattern, args[1]); matched {
+				fmt.Fprintf(subscriber, "*4\r\n$8\r\npmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n",
+					len(pattern), pattern, len(args[1]), args[1], len(args[2]), args[2])
+				received++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", received)
+	case "MGET":
+		reply := fmt.Sprintf("*%d\r\n", len(args)-1)
+		for _, key := range args[1:] {
+			if value, ok := s.values[key]; ok {
+				reply += fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			} else {
+				reply += "$-1\r\n"
+			}
+		}
+		return reply
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func TestDo(t *testing.T) {
+	server := newFakeServer(t)
+	client, err := New("redis://:secret@" + server.listener.Addr().String() + "/2")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	if _, err := client.Do(ctx, "SET", "greeting", "thank you\r\nall"); err != nil {
+		t.Fatalf("SET failed: %v", err)
+	}
+	if value, err := String(client.Do(ctx, "GET", "greeting")); err != nil || value != "thank you\r\nall" {
+		t.Errorf("Expected the stored value back, got %q err=%v", value, err)
+	}
+	if n, err := Int(client.Do(ctx, "INCR", "count")); err != nil || n != 1 {
+		t.Errorf("Expected 1, got %d err=%v", n, err)
+	}
+	if _, err := client.Do(ctx, "GET", "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("Expected ErrNil for a missing key, got %v", err)
+	}
+
+	var replyErr Error
+	if _, err := client.Do(ctx, "NOPE"); !errors.As(err, &replyErr) {
+		t.Errorf("Expected an error reply, got %v", err)
+	}
+
+	reply, err := client.Do(ctx, "MGET", "greeting", "missing")
+	items, _ := reply.([]interface{})
+	if err != nil || len(items) != 2 || items[0] != "thank you\r\nall" || items[1] != nil {
+		t.Errorf("Expected the value and a nil, got %#v err=%v", reply, err)
+	}
+
+	// One connection was authenticated, selected the database and then reused throughout
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.commands[0] != "AUTH secret" || server.commands[1] != "SELECT 2" {
+		t.Errorf("Expected AUTH and SELECT first, got %v", server.commands)
+	}
+	for _, command := range server.commands[2:] {
+		if strings.HasPrefix(command, "AUTH") {
+			t.Error("Expected the connection to be reused")
+		}
+	}
+}
+
+func TestNewValidatesURL(t *testing.T) {
+	for _, rawURL := range []string{"http://localhost", "redis://localhost/db", "redis://localhost/-1"} {
+		if _, err := New(rawURL); err == nil {
+			t.Errorf("Expected %q to be rejected", rawURL)
+		}
+	}
+
+	client, err := New("rediss://cache.example.com")
+	if err != nil || client.addr != "cache.example.com:6379" || client.tls == nil {
+		t.Errorf("Expected TLS on the default port, got %+v err=%v", client, err)
+	}
+}
+
+func TestPubSub(t *testing.T) {
+	server := newFakeServer(t)
+	client, err := New("redis://" + server.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	sub, err := client.PSubscribe(ctx, "hub:*")
+	if err != nil {
+		t.Fatalf("PSubscribe failed: %v", err)
+	}
+
+	if n, err := client.Publish(ctx, "other:1", "ignored"); err != nil || n != 0 {
+		t.Errorf("Expected nobody to receive a non-matching channel, got %d err=%v", n, err)
+	}
+	if n, err := client.Publish(ctx, "hub:session-1", "thanks\r\nall"); err != nil || n != 1 {
+		t.Errorf("Expected one subscriber, got %d err=%v", n, err)
+	}
+
+	msg, err := sub.Receive()
+	if err != nil || msg.Channel != "hub:session-1" || msg.Payload != "thanks\r\nall" {
+		t.Errorf("Expected the published message, got %+v err=%v", msg, err)
+	}
+
+	sub.Close()
+	if _, err := sub.Receive(); err == nil {
+		t.Error("Expected Receive to fail once closed")
+	}
+}
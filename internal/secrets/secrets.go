@@ -0,0 +1,157 @@
+// ABOUTME: Loads sensitive configuration from environment, mounted files or HashiCorp Vault
+// ABOUTME: Secrets can be refreshed periodically so keys rotate without a restart
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// vaultPrefix marks an environment value as a Vault reference: vault:<path>#<key>
+const vaultPrefix = "vault:"
+
+// vaultTimeout bounds each Vault read
+const vaultTimeout = 10 * time.Second
+
+// Lookup resolves a secret by name, checking in order:
+//   - NAME_FILE: path to a file holding the secret (e.g. a mounted Kubernetes secret)
+//   - NAME=vault:<path>#<key>: a key in a Vault KV secret, read using VAULT_ADDR and VAULT_TOKEN
+//   - NAME: the secret itself
+//
+// Returns an empty string if the secret isn't configured
+func Lookup(name string) (string, error) {
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		return readFile(path)
+	}
+
+	value := os.Getenv(name)
+	if ref, ok := strings.CutPrefix(value, vaultPrefix); ok {
+		return readVault(ref)
+	}
+	return value, nil
+}
+
+// readFile reads a secret from a file, trimming surrounding whitespace
+func readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading secret file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readVault reads one key of a Vault KV secret given as <path>#<key>
+// Supports both KV v1 ({"data": {...}}) and v2 ({"data": {"data": {...}}}) responses
+func readVault(ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("invalid vault reference %q: expected vault:<path>#<key>", ref)
+	}
+
+	addr := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return "", errors.New("VAULT_ADDR is required for vault secrets")
+	}
+	token, err := Lookup("VAULT_TOKEN")
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), vaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("reading vault secret: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("reading vault secret %s: status %d", path, resp.StatusCode)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding vault secret: %w", err)
+	}
+
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no key %s", path, key)
+	}
+	return value, nil
+}
+
+// Secret is a named secret whose current value can be refreshed while running
+type Secret struct {
+	name  string
+	value atomic.Value // string
+}
+
+// Load resolves a secret once; call Refresh or Watch to pick up rotations
+func Load(name string) (*Secret, error) {
+	s := &Secret{name: name}
+	if err := s.Refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Value returns the secret's current value
+func (s *Secret) Value() string {
+	value, _ := s.value.Load().(string)
+	return value
+}
+
+// Refresh re-reads the secret from its source
+func (s *Secret) Refresh() error {
+	value, err := Lookup(s.name)
+	if err != nil {
+		return err
+	}
+
+	if previous := s.Value(); previous != "" && previous != value {
+		slog.Info("Secret rotated", "name", s.name)
+	}
+	s.value.Store(value)
+	return nil
+}
+
+// Watch refreshes the secret every interval until ctx is cancelled
+// Failed refreshes keep the previous value
+func (s *Secret) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(); err != nil {
+				slog.Warn("Failed to refresh secret, keeping previous value", "name", s.name, "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookupEnv(t *testing.T) {
+	t.Setenv("TEST_SECRET", "from-env")
+
+	value, err := Lookup("TEST_SECRET")
+	if err != nil {
+		t.Fatalf("Failed to look up secret: %v", err)
+	}
+	if value != "from-env" {
+		t.Errorf("Expected from-env, got %q", value)
+	}
+}
+
+func TestLookupFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(path, []byte("from-file\n"), 0o600)
+	t.Setenv("TEST_SECRET", "from-env")
+	t.Setenv("TEST_SECRET_FILE", path)
+
+	value, err := Lookup("TEST_SECRET")
+	if err != nil {
+		t.Fatalf("Failed to look up secret: %v", err)
+	}
+	if value != "from-file" {
+		t.Errorf("Expected file to take precedence, got %q", value)
+	}
+
+	t.Setenv("TEST_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := Lookup("TEST_SECRET"); err == nil {
+		t.Error("Expected error for missing secret file")
+	}
+}
+
+func TestLookupVault(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/uplift":
+			w.Write([]byte(`{"data": {"data": {"admin_token": "from-vault-v2"}}}`))
+		case "/v1/kv/uplift":
+			w.Write([]byte(`{"data": {"admin_token": "from-vault-v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer vault.Close()
+
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "vault-token")
+
+	tests := []struct {
+		ref      string
+		expected string
+		wantErr  bool
+	}{
+		{"vault:secret/data/uplift#admin_token", "from-vault-v2", false},
+		{"vault:kv/uplift#admin_token", "from-vault-v1", false},
+		{"vault:secret/data/uplift#missing", "", true},
+		{"vault:secret/data/other#admin_token", "", true},
+		{"vault:secret/data/uplift", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("TEST_SECRET", tt.ref)
+		value, err := Lookup("TEST_SECRET")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for %s", tt.ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Failed to look up %s: %v", tt.ref, err)
+		}
+		if value != tt.expected {
+			t.Errorf("Expected %q for %s, got %q", tt.expected, tt.ref, value)
+		}
+	}
+}
+
+func TestSecretRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(path, []byte("first"), 0o600)
+	t.Setenv("TEST_KEY_FILE", path)
+
+	secret, err := Load("TEST_KEY")
+	if err != nil {
+		t.Fatalf("Failed to load secret: %v", err)
+	}
+	if secret.Value() != "first" {
+		t.Errorf("Expected first, got %q", secret.Value())
+	}
+
+	// Rotation is picked up on refresh
+	os.WriteFile(path, []byte("second"), 0o600)
+	if err := secret.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	if secret.Value() != "second" {
+		t.Errorf("Expected second, got %q", secret.Value())
+	}
+
+	// A failed refresh keeps the previous value
+	os.Remove(path)
+	if err := secret.Refresh(); err == nil {
+		t.Error("Expected refresh error for removed file")
+	}
+	if secret.Value() != "second" {
+		t.Errorf("Expected previous value to be kept, got %q", secret.Value())
+	}
+}
@@ -0,0 +1,235 @@
+// ABOUTME: Breakout circles that split one large session into smaller sub-circles
+// ABOUTME: Each breakout is a child session with its own note pool and reading rotation
+package session
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// BreakoutStatus summarises a breakout circle's progress for the parent host
+type BreakoutStatus struct {
+	ID               string `json:"id"`
+	Code             string `json:"code"`
+	Title            string `json:"title"`
+	Phase            Phase  `json:"phase"`
+	ParticipantCount int    `json:"participantCount"`
+	NotesWritten     int    `json:"notesWritten"`
+	NotesRead        int    `json:"notesRead"`
+}
+
+// SplitEvenly randomly assigns participant IDs to count groups of near-equal size
+func SplitEvenly(participantIDs []string, count int) ([][]string, error) {
+	if count < 2 {
+		return nil, errors.New("need at least 2 breakout circles")
+	}
+
+	if len(participantIDs) < count*2 {
+		return nil, errors.New("not enough participants for that many breakout circles")
+	}
+
+	shuffled := make([]string, len(participantIDs))
+	copy(shuffled, participantIDs)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	groups := make([][]string, count)
+	for i, id := range shuffled {
+		groups[i%count] = append(groups[i%count], id)
+	}
+	return groups, nil
+}
+
+// CreateBreakouts splits a joining session into breakout circles, one per group.
+// Every participant must appear in exactly one group and each group needs at
+// least 2 people. The parent moves to PhaseBreakout and keeps its participant
+// list so the host can monitor all circles.
+func (m *Manager) CreateBreakouts(parent *Session, groups [][]string) ([]*Session, error) {
+	parent.mu.Lock()
+
+	if parent.Phase != PhaseJoining {
+		parent.mu.Unlock()
+		return nil, errors.New("can only create breakouts while joining")
+	}
+
+	if parent.ParentID != "" {
+		parent.mu.Unlock()
+		return nil, errors.New("cannot split a breakout circle")
+	}
+
+	if len(groups) < 2 {
+		parent.mu.Unlock()
+		return nil, errors.New("need at least 2 breakout circles")
+	}
+
+	// Validate group membership
+	assigned := make(map[string]bool, len(parent.Participants))
+	for _, group := range groups {
+		if len(group) < 2 {
+			parent.mu.Unlock()
+			return nil, errors.New("each breakout circle needs at least 2 participants")
+		}
+		for _, id := range group {
+			if _, exists := parent.Participants[id]; !exists {
+				parent.mu.Unlock()
+				return nil, fmt.Errorf("participant not found: %s", id)
+			}
+			if assigned[id] {
+				parent.mu.Unlock()
+				return nil, errors.New("participant assigned to more than one breakout circle")
+			}
+			assigned[id] = true
+		}
+	}
+	if len(assigned) != len(parent.Participants) {
+		parent.mu.Unlock()
+		return nil, errors.New("every participant must be assigned to a breakout circle")
+	}
+
+	breakouts := make([]*Session, 0, len(groups))
+	for i, group := range groups {
+		breakouts = append(breakouts, newBreakoutSession(parent, group, i+1))
+	}
+
+	parent.BreakoutIDs = make([]string, 0, len(breakouts))
+	for _, breakout := range breakouts {
+		parent.BreakoutIDs = append(parent.BreakoutIDs, breakout.ID)
+	}
+	parent.recordPhaseChangeUnlocked(parent.Phase, PhaseBreakout)
+	parent.Phase = PhaseBreakout
+	parent.PhaseChangedAt = time.Time{}
+	parent.mu.Unlock()
+
+	for _, breakout := range breakouts {
+		m.addNewSession(breakout)
+	}
+
+	slog.Info("Breakouts created", "sessionID", parent.ID, "sessionCode", parent.Code, "count", len(breakouts), "totalSessions", m.sessions.Len())
+	return breakouts, nil
+}
+
+// newBreakoutSession creates a child session for one group of the parent's participants
+// Settings are inherited from the parent, and its code is given when it's stored;
+// assumes caller holds the parent's lock
+func newBreakoutSession(parent *Session, group []string, number int) *Session {
+	participants := make(map[string]*Participant, len(group))
+	for _, id := range group {
+		p := parent.Participants[id]
+		participants[id] = &Participant{
+			ID:       p.ID,
+			Name:     p.Name,
+			Role:     p.Role,
+			JoinedAt: p.JoinedAt,
+		}
+	}
+
+	// The parent host hosts their own circle; other circles get the earliest joiner
+	hostID := parent.HostID
+	if _, inGroup := participants[hostID]; !inGroup {
+		members := make([]*Participant, 0, len(participants))
+		for _, p := range participants {
+			members = append(members, p)
+		}
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].JoinedAt.Before(members[j].JoinedAt)
+		})
+		hostID = members[0].ID
+	}
+	participants[hostID].IsHost = true
+
+	title := fmt.Sprintf("Circle %d", number)
+	if parent.Title != "" {
+		title = fmt.Sprintf("%s – Circle %d", parent.Title, number)
+	}
+
+	return &Session{
+		ID:              generateID(),
+		Title:           title,
+		Welcome:         parent.Welcome,
+		Prompt:          parent.Prompt,
+		Countdown:       parent.Countdown,
+		Settings:        parent.Settings,
+		MinNoteChars:    parent.MinNoteChars,
+		MinNoteWords:    parent.MinNoteWords,
+		AutoRun:         parent.AutoRun,
+		RatingsEnabled:  parent.RatingsEnabled,
+		DuplicatePolicy: parent.DuplicatePolicy,
+		Tenant:          parent.Tenant,
+		Theme:           parent.Theme,
+		Phase:           PhaseJoining,
+		Participants:    participants,
+		Notes:           []*Note{},
+		CreatedAt:       time.Now(),
+		HostID:          hostID,
+		ParentID:        parent.ID,
+	}
+}
+
+// GetBreakouts returns the parent's breakout circles that are still active
+func (m *Manager) GetBreakouts(parent *Session) []*Session {
+	parent.mu.RLock()
+	ids := make([]string, len(parent.BreakoutIDs))
+	copy(ids, parent.BreakoutIDs)
+	parent.mu.RUnlock()
+
+	breakouts := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		if breakout, exists := m.sessions.Get(id); exists {
+			breakouts = append(breakouts, breakout)
+		}
+	}
+	return breakouts
+}
+
+// GetBreakoutStatus returns a progress summary for each active breakout circle
+func (m *Manager) GetBreakoutStatus(parent *Session) []BreakoutStatus {
+	breakouts := m.GetBreakouts(parent)
+	statuses := make([]BreakoutStatus, 0, len(breakouts))
+	for _, breakout := range breakouts {
+		breakout.mu.RLock()
+		status := BreakoutStatus{
+			ID:               breakout.ID,
+			Code:             breakout.Code,
+			Title:            breakout.Title,
+			Phase:            breakout.Phase,
+			ParticipantCount: len(breakout.Participants),
+			NotesWritten:     len(breakout.Notes),
+		}
+		for _, note := range breakout.Notes {
+			if note.Read {
+				status.NotesRead++
+			}
+		}
+		breakout.mu.RUnlock()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// CompleteBreakoutsIfDone marks the parent complete once every active breakout
+// circle has finished, returning true if it did so
+func (m *Manager) CompleteBreakoutsIfDone(parent *Session) bool {
+	for _, breakout := range m.GetBreakouts(parent) {
+		if breakout.Phase != PhaseComplete {
+			return false
+		}
+	}
+
+	parent.mu.Lock()
+	defer parent.mu.Unlock()
+
+	if parent.Phase != PhaseBreakout {
+		return false
+	}
+
+	now := time.Now()
+	parent.Phase = PhaseComplete
+	parent.CompletedAt = &now
+	parent.recordPhaseChangeUnlocked(PhaseBreakout, PhaseComplete)
+	return true
+}
@@ -0,0 +1,168 @@
+package session
+
+import (
+	"testing"
+)
+
+func TestSplitEvenly(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+
+	groups, err := SplitEvenly(ids, 2)
+	if err != nil {
+		t.Fatalf("Failed to split participants: %v", err)
+	}
+
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+
+	total := 0
+	for _, group := range groups {
+		if len(group) < 2 || len(group) > 3 {
+			t.Errorf("Expected group of 2 or 3 participants, got %d", len(group))
+		}
+		total += len(group)
+	}
+
+	if total != len(ids) {
+		t.Errorf("Expected all %d participants to be assigned, got %d", len(ids), total)
+	}
+
+	// Too many groups for the number of participants
+	if _, err := SplitEvenly(ids, 3); err == nil {
+		t.Error("Expected error when groups would have fewer than 2 participants")
+	}
+}
+
+func TestCreateBreakouts(t *testing.T) {
+	manager := NewManager()
+	parent := manager.CreateSession("Host")
+	parent.SetTitle("Retro")
+	parent.SetMaxNoteLength(280)
+	alice, _ := parent.AddParticipant("Alice")
+	bob, _ := parent.AddParticipant("Bob")
+	carol, _ := parent.AddParticipant("Carol")
+
+	groups := [][]string{
+		{parent.HostID, alice.ID},
+		{bob.ID, carol.ID},
+	}
+
+	breakouts, err := manager.CreateBreakouts(parent, groups)
+	if err != nil {
+		t.Fatalf("Failed to create breakouts: %v", err)
+	}
+
+	if len(breakouts) != 2 {
+		t.Fatalf("Expected 2 breakouts, got %d", len(breakouts))
+	}
+
+	if parent.Phase != PhaseBreakout {
+		t.Errorf("Expected parent phase to be BREAKOUT, got %s", parent.Phase)
+	}
+
+	if manager.GetActiveSessionCount() != 3 {
+		t.Errorf("Expected 3 active sessions, got %d", manager.GetActiveSessionCount())
+	}
+
+	// Parent host keeps hosting their own circle
+	if breakouts[0].HostID != parent.HostID {
+		t.Error("Expected parent host to host their breakout circle")
+	}
+
+	// Other circles get a host from their own members
+	if !breakouts[1].Participants[breakouts[1].HostID].IsHost {
+		t.Error("Expected second circle to have a host")
+	}
+
+	for _, breakout := range breakouts {
+		if breakout.ParentID != parent.ID {
+			t.Errorf("Expected breakout parent ID %s, got %s", parent.ID, breakout.ParentID)
+		}
+		if breakout.Settings.MaxNoteLength != 280 {
+			t.Errorf("Expected breakout to inherit note length limit, got %d", breakout.Settings.MaxNoteLength)
+		}
+		if len(breakout.Participants) != 2 {
+			t.Errorf("Expected 2 participants per breakout, got %d", len(breakout.Participants))
+		}
+		if _, err := manager.GetSessionByCode(breakout.Code); err != nil {
+			t.Errorf("Expected breakout to be found by code: %v", err)
+		}
+	}
+
+	// Nobody can join the parent once split
+	if _, err := parent.AddParticipant("Dave"); err == nil {
+		t.Error("Expected error when joining a split session")
+	}
+}
+
+func TestCreateBreakoutsValidation(t *testing.T) {
+	manager := NewManager()
+	parent := manager.CreateSession("Host")
+	alice, _ := parent.AddParticipant("Alice")
+	bob, _ := parent.AddParticipant("Bob")
+	carol, _ := parent.AddParticipant("Carol")
+
+	tests := []struct {
+		name   string
+		groups [][]string
+	}{
+		{"single group", [][]string{{parent.HostID, alice.ID, bob.ID, carol.ID}}},
+		{"group too small", [][]string{{parent.HostID}, {alice.ID, bob.ID, carol.ID}}},
+		{"unknown participant", [][]string{{parent.HostID, alice.ID}, {bob.ID, "nobody"}}},
+		{"duplicate assignment", [][]string{{parent.HostID, alice.ID}, {bob.ID, alice.ID}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.CreateBreakouts(parent, tt.groups); err == nil {
+				t.Error("Expected error creating invalid breakouts")
+			}
+			if parent.Phase != PhaseJoining {
+				t.Errorf("Expected parent to remain JOINING, got %s", parent.Phase)
+			}
+		})
+	}
+}
+
+func TestBreakoutStatusAndCompletion(t *testing.T) {
+	manager := NewManager()
+	parent := manager.CreateSession("Host")
+	alice, _ := parent.AddParticipant("Alice")
+	bob, _ := parent.AddParticipant("Bob")
+	carol, _ := parent.AddParticipant("Carol")
+
+	breakouts, err := manager.CreateBreakouts(parent, [][]string{
+		{parent.HostID, alice.ID},
+		{bob.ID, carol.ID},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create breakouts: %v", err)
+	}
+
+	first := breakouts[0]
+	first.TransitionToWriting()
+	first.AddNote(parent.HostID, alice.ID, "Note 1")
+
+	statuses := manager.GetBreakoutStatus(parent)
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 statuses, got %d", len(statuses))
+	}
+	if statuses[0].Phase != PhaseWriting || statuses[0].NotesWritten != 1 {
+		t.Errorf("Expected first circle writing with 1 note, got %s with %d", statuses[0].Phase, statuses[0].NotesWritten)
+	}
+
+	first.Phase = PhaseComplete
+	if manager.CompleteBreakoutsIfDone(parent) {
+		t.Error("Expected parent not to complete while a circle is still running")
+	}
+
+	breakouts[1].Phase = PhaseComplete
+	if !manager.CompleteBreakoutsIfDone(parent) {
+		t.Error("Expected parent to complete once all circles are done")
+	}
+
+	if parent.Phase != PhaseComplete || parent.CompletedAt == nil {
+		t.Error("Expected parent to be marked complete")
+	}
+}
@@ -0,0 +1,118 @@
+// ABOUTME: Session join codes: how long they are, which characters they use, and keeping them unique
+// ABOUTME: Characters easily misread for each other (0/O, 1/I) are left out so codes can be read aloud
+package session
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+)
+
+const (
+	// DefaultCodeLength is how many characters session codes have unless configured
+	DefaultCodeLength = 6
+
+	// DefaultCodeAlphabet is the uppercase letters and digits without 0, O, 1 and I
+	DefaultCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	// minCodeLength and maxCodeLength bound a configured code length
+	minCodeLength = 4
+	maxCodeLength = 12
+
+	// minCodeAlphabet is the fewest characters a configured alphabet may have
+	minCodeAlphabet = 10
+
+	// codeAttempts is how many codes are tried before codes are made a character longer
+	codeAttempts = 10
+
+	// ambiguousCodeCharacters are read and typed as each other, so codes never use them
+	ambiguousCodeCharacters = "0O1I"
+)
+
+// CodeFormat is the length and alphabet of session codes
+type CodeFormat struct {
+	Length   int
+	Alphabet string
+}
+
+// DefaultCodeFormat returns the format codes have unless configured
+func DefaultCodeFormat() CodeFormat {
+	return CodeFormat{Length: DefaultCodeLength, Alphabet: DefaultCodeAlphabet}
+}
+
+// NewCodeFormat checks a code length and alphabet; an empty alphabet means the default
+// Codes are looked up case-insensitively, so the alphabet is uppercase letters and digits
+func NewCodeFormat(length int, alphabet string) (CodeFormat, error) {
+	if alphabet == "" {
+		alphabet = DefaultCodeAlphabet
+	}
+	alphabet = strings.ToUpper(alphabet)
+
+	if length < minCodeLength || length > maxCodeLength {
+		return CodeFormat{}, fmt.Errorf("code length must be between %d and %d", minCodeLength, maxCodeLength)
+	}
+
+	seen := make(map[rune]bool)
+	for _, r := range alphabet {
+		switch {
+		case strings.ContainsRune(ambiguousCodeCharacters, r):
+			return CodeFormat{}, fmt.Errorf("code alphabet must not contain %q, which is easily misread", r)
+		case (r < 'A' || r > 'Z') && (r < '2' || r > '9'):
+			return CodeFormat{}, fmt.Errorf("code alphabet may only contain letters and digits, not %q", r)
+		case seen[r]:
+			return CodeFormat{}, fmt.Errorf("code alphabet contains %q more than once", r)
+		}
+		seen[r] = true
+	}
+	if len(seen) < minCodeAlphabet {
+		return CodeFormat{}, fmt.Errorf("code alphabet must have at least %d characters", minCodeAlphabet)
+	}
+
+	return CodeFormat{Length: length, Alphabet: alphabet}, nil
+}
+
+// generate returns a random code of the given length from the format's alphabet
+func (f CodeFormat) generate(length int) string {
+	limit := big.NewInt(int64(len(f.Alphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		n, _ := rand.Int(rand.Reader, limit)
+		code[i] = f.Alphabet[n.Int64()]
+	}
+	return string(code)
+}
+
+// SetCodeFormat sets the length and alphabet of codes for sessions created from now on
+func (m *Manager) SetCodeFormat(format CodeFormat) {
+	m.codesMu.Lock()
+	defer m.codesMu.Unlock()
+
+	m.codes = format
+}
+
+// addNewSession gives a newly created session a code no other session has, then stores it
+// Codes are allocated one at a time, so two new sessions can't be given the same code
+func (m *Manager) addNewSession(session *Session) {
+	m.codesMu.Lock()
+	defer m.codesMu.Unlock()
+
+	session.Code = m.uniqueCodeUnlocked()
+	m.addSession(session)
+}
+
+// uniqueCodeUnlocked returns a code not in use, lengthening codes by a character whenever
+// codeAttempts in a row are taken, which means the code space is filling up
+// Internal helper that assumes caller holds codesMu
+func (m *Manager) uniqueCodeUnlocked() string {
+	for length := m.codes.Length; ; length++ {
+		for range codeAttempts {
+			code := m.codes.generate(length)
+			if _, taken := m.sessions.GetByCode(code); !taken {
+				return code
+			}
+		}
+		slog.Warn("Session codes colliding, lengthening", "length", length+1, "totalSessions", m.sessions.Len())
+	}
+}
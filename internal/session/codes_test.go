@@ -0,0 +1,84 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCodeFormat(t *testing.T) {
+	format, err := NewCodeFormat(8, "")
+	if err != nil || format.Length != 8 || format.Alphabet != DefaultCodeAlphabet {
+		t.Errorf("Expected 8 characters from the default alphabet, got %+v err=%v", format, err)
+	}
+	if format, err := NewCodeFormat(5, "abcdefghjk"); err != nil || format.Alphabet != "ABCDEFGHJK" {
+		t.Errorf("Expected a lowercase alphabet to be upper-cased, got %+v err=%v", format, err)
+	}
+
+	invalid := []struct {
+		name     string
+		length   int
+		alphabet string
+	}{
+		{"too short", 3, ""},
+		{"too long", 13, ""},
+		{"ambiguous zero", 6, "ABCDEFGHJK0"},
+		{"ambiguous letter O", 6, "ABCDEFGHJKO"},
+		{"ambiguous one", 6, "ABCDEFGHJK1"},
+		{"ambiguous letter I", 6, "ABCDEFGHJKi"},
+		{"punctuation", 6, "ABCDEFGHJK-"},
+		{"repeated characters", 6, "ABCDEFGHJKA"},
+		{"too few characters", 6, "ABCDEF"},
+	}
+	for _, test := range invalid {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewCodeFormat(test.length, test.alphabet); err == nil {
+				t.Errorf("Expected length %d and alphabet %q to be refused", test.length, test.alphabet)
+			}
+		})
+	}
+}
+
+func TestCodesUseConfiguredFormat(t *testing.T) {
+	manager := NewManager()
+	format, _ := NewCodeFormat(8, "ABCDEFGHJK")
+	manager.SetCodeFormat(format)
+
+	sess := manager.CreateSession("Host")
+	if len(sess.Code) != 8 || strings.Trim(sess.Code, "ABCDEFGHJK") != "" {
+		t.Errorf("Expected an 8-character code from the configured alphabet, got %s", sess.Code)
+	}
+}
+
+func TestCodeCollisionsAreRegenerated(t *testing.T) {
+	// A two-code space fills after two sessions
+	manager := NewManager()
+	manager.SetCodeFormat(CodeFormat{Length: 1, Alphabet: "AB"})
+
+	first := manager.CreateSession("Host")
+	second := manager.CreateSession("Host")
+	if first.Code == second.Code {
+		t.Fatalf("Expected distinct codes, both got %s", first.Code)
+	}
+
+	// Once every code is taken, codes grow rather than replacing an existing session
+	third := manager.CreateSession("Host")
+	if len(third.Code) != 2 {
+		t.Errorf("Expected a longer code once the space was full, got %s", third.Code)
+	}
+	for _, sess := range []*Session{first, second, third} {
+		if found, err := manager.GetSessionByCode(sess.Code); err != nil || found != sess {
+			t.Errorf("Expected %s to still find its own session", sess.Code)
+		}
+	}
+
+	// Split sessions are given a free code too
+	alice, _ := first.AddParticipant("Alice")
+	bob, _ := first.AddParticipant("Bob")
+	split, err := manager.SplitSession(first, []string{alice.ID, bob.ID}, "")
+	if err != nil {
+		t.Fatalf("Failed to split: %v", err)
+	}
+	if found, _ := manager.GetSessionByCode(first.Code); found != first {
+		t.Errorf("Expected the split's code %s not to replace the original session", split.Code)
+	}
+}
@@ -0,0 +1,43 @@
+// ABOUTME: Lets an operator end a circle early from any phase it can be in
+// ABOUTME: Notes written so far are kept, so everyone still gets what was written to them
+package session
+
+import (
+	"errors"
+	"time"
+)
+
+// ForceComplete moves the session straight to complete from joining, writing or reading,
+// returning how many notes were never read aloud. Breakout parents complete when their
+// circles do, so they can't be forced. Timers outside the session are the caller's to cancel.
+func (s *Session) ForceComplete() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.Phase {
+	case PhaseComplete:
+		return 0, errors.New("session is already complete")
+	case PhaseBreakout:
+		return 0, errors.New("cannot complete a session split into breakout circles")
+	}
+
+	unread := 0
+	for _, note := range s.Notes {
+		if !note.Read && !note.Private {
+			unread++
+		}
+	}
+
+	from := s.Phase
+	now := time.Now()
+	s.Phase = PhaseComplete
+	s.CompletedAt = &now
+	s.PhaseChangedAt = time.Time{}
+	s.recordEventUnlocked(EventPhaseChanged, "", map[string]interface{}{
+		"from":       from,
+		"to":         PhaseComplete,
+		"forced":     true,
+		"unreadLeft": unread > 0,
+	})
+	return unread, nil
+}
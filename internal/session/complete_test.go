@@ -0,0 +1,48 @@
+package session
+
+import "testing"
+
+func TestForceComplete(t *testing.T) {
+	for _, phase := range []Phase{PhaseJoining, PhaseWriting, PhaseReading} {
+		t.Run(string(phase), func(t *testing.T) {
+			sess := sessionInPhase(t, phase)
+			version := sess.Version
+			notes := len(sess.Notes)
+
+			unread, err := sess.ForceComplete()
+			if err != nil {
+				t.Fatalf("ForceComplete: %v", err)
+			}
+			if sess.Phase != PhaseComplete || sess.CompletedAt == nil {
+				t.Errorf("expected a completed session, got phase %s", sess.Phase)
+			}
+			if sess.Version != version+1 {
+				t.Errorf("expected the version to move on, got %d from %d", sess.Version, version)
+			}
+			if len(sess.Notes) != notes {
+				t.Errorf("expected notes to be kept, got %d of %d", len(sess.Notes), notes)
+			}
+
+			want := notes
+			if phase == PhaseReading {
+				want = notes - 1
+			}
+			if unread != want {
+				t.Errorf("expected %d unread notes, got %d", want, unread)
+			}
+
+			timeline := sess.Timeline()
+			last := timeline[len(timeline)-1]
+			if last.Type != EventPhaseChanged || last.Data["forced"] != true || last.Data["from"] != phase {
+				t.Errorf("expected a forced phase change from %s on the timeline, got %+v", phase, last)
+			}
+		})
+	}
+
+	for _, phase := range []Phase{PhaseComplete, PhaseBreakout} {
+		sess := sessionInPhase(t, phase)
+		if _, err := sess.ForceComplete(); err == nil {
+			t.Errorf("expected forcing a %s session to fail", phase)
+		}
+	}
+}
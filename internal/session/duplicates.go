@@ -0,0 +1,100 @@
+// ABOUTME: Detects an author sending the same or nearly the same note to several recipients
+// ABOUTME: Compares word sets with recipient names removed so "Thanks Alice" matches "Thanks Bob"
+package session
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// DuplicatePolicy is what happens when an author submits a near-identical note
+type DuplicatePolicy string
+
+const (
+	DuplicateOff    DuplicatePolicy = "off"    // Allow duplicates silently
+	DuplicateWarn   DuplicatePolicy = "warn"   // Accept the note and warn the author
+	DuplicateFlag   DuplicatePolicy = "flag"   // Accept the note and tell the host privately
+	DuplicateReject DuplicatePolicy = "reject" // Refuse the note
+)
+
+// duplicateThreshold is the word-set similarity at or above which two notes count as duplicates
+const duplicateThreshold = 0.85
+
+// ParseDuplicatePolicy validates a duplicate note policy; empty selects off
+func ParseDuplicatePolicy(value string) (DuplicatePolicy, error) {
+	switch policy := DuplicatePolicy(value); policy {
+	case "":
+		return DuplicateOff, nil
+	case DuplicateOff, DuplicateWarn, DuplicateFlag, DuplicateReject:
+		return policy, nil
+	default:
+		return "", errors.New("duplicate note policy must be off, warn, flag or reject")
+	}
+}
+
+// SetDuplicatePolicy sets how near-identical notes from one author are handled
+func (s *Session) SetDuplicatePolicy(policy DuplicatePolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.DuplicatePolicy = policy
+}
+
+// FindDuplicateNote returns the author's existing note to someone else that is the same
+// as or nearly the same as content written for recipientID, or nil if there isn't one
+func (s *Session) FindDuplicateNote(authorID, recipientID, content string) *Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, note := range s.Notes {
+		if note.AuthorID != authorID || note.RecipientID == recipientID {
+			continue
+		}
+
+		// Ignore both recipients' names so personalising only the greeting doesn't count
+		ignore := make(map[string]bool)
+		for _, id := range []string{recipientID, note.RecipientID} {
+			if p, exists := s.Participants[id]; exists {
+				for word := range noteWords(p.Name, nil) {
+					ignore[word] = true
+				}
+			}
+		}
+
+		if strings.EqualFold(strings.TrimSpace(content), strings.TrimSpace(note.Content)) ||
+			similarity(noteWords(content, ignore), noteWords(note.Content, ignore)) >= duplicateThreshold {
+			return note
+		}
+	}
+	return nil
+}
+
+// noteWords returns the set of lowercased words in text, skipping any in ignore
+func noteWords(text string, ignore map[string]bool) map[string]bool {
+	words := make(map[string]bool)
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range fields {
+		if !ignore[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// similarity is the Jaccard index of two word sets, or 0 if either is empty
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
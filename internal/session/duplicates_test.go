@@ -0,0 +1,50 @@
+package session
+
+import (
+	"testing"
+)
+
+func TestFindDuplicateNote(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice for always helping me debug the build!")
+
+	tests := []struct {
+		name      string
+		content   string
+		duplicate bool
+	}{
+		{"identical", "Thanks Alice for always helping me debug the build!", true},
+		{"name swapped", "Thanks Bob for always helping me debug the build", true},
+		{"different", "Bob, your calm in incidents keeps the whole team steady.", false},
+		{"partly similar", "Thanks Bob for helping me plan the offsite", false},
+	}
+	for _, tt := range tests {
+		found := sess.FindDuplicateNote(sess.HostID, bob.ID, tt.content)
+		if (found != nil) != tt.duplicate {
+			t.Errorf("%s: expected duplicate=%v, got %v", tt.name, tt.duplicate, found)
+		}
+	}
+
+	// Other authors' notes and the same recipient don't count
+	if sess.FindDuplicateNote(bob.ID, sess.HostID, "Thanks Alice for always helping me debug the build!") != nil {
+		t.Error("Expected other authors' notes to be ignored")
+	}
+	if sess.FindDuplicateNote(sess.HostID, alice.ID, "Thanks Alice for always helping me debug the build!") != nil {
+		t.Error("Expected a note to the same recipient to be ignored")
+	}
+}
+
+func TestParseDuplicatePolicy(t *testing.T) {
+	if policy, err := ParseDuplicatePolicy(""); err != nil || policy != DuplicateOff {
+		t.Errorf("Expected empty to select off, got %q %v", policy, err)
+	}
+	if policy, err := ParseDuplicatePolicy("flag"); err != nil || policy != DuplicateFlag {
+		t.Errorf("Expected flag, got %q %v", policy, err)
+	}
+	if _, err := ParseDuplicatePolicy("block"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
@@ -0,0 +1,81 @@
+// ABOUTME: A point-in-time view of a session for operators, without note content
+// ABOUTME: Counts notes rather than listing them, so inspecting a circle never reveals what was written
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// Inspection is everything an operator sees about one session
+type Inspection struct {
+	ID              string          `json:"id"`
+	Code            string          `json:"sessionCode"`
+	Title           string          `json:"title"`
+	Phase           Phase           `json:"phase"`
+	Version         uint64          `json:"version"`
+	Settings        SessionSettings `json:"settings"`
+	Tenant          string          `json:"tenant,omitempty"`
+	ParentID        string          `json:"parentId,omitempty"`
+	BreakoutIDs     []string        `json:"breakoutIds,omitempty"`
+	HostID          string          `json:"hostId"`
+	CurrentReaderID string          `json:"currentReaderId,omitempty"`
+	Participants    []Participant   `json:"participants"` // Sorted by when they joined, host first on ties
+	Notes           int             `json:"notes"`
+	NotesRead       int             `json:"notesRead"`
+	PrivateNotes    int             `json:"privateNotes"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	CompletedAt     *time.Time      `json:"completedAt,omitempty"`
+}
+
+// Inspect returns the session's current state for operators
+func (s *Session) Inspect() Inspection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inspection := Inspection{
+		ID:          s.ID,
+		Code:        s.Code,
+		Title:       s.Title,
+		Phase:       s.Phase,
+		Version:     s.Version,
+		Settings:    s.Settings,
+		Tenant:      s.Tenant,
+		ParentID:    s.ParentID,
+		BreakoutIDs: append([]string(nil), s.BreakoutIDs...),
+		HostID:      s.HostID,
+		CreatedAt:   s.CreatedAt,
+		CompletedAt: s.CompletedAt,
+	}
+
+	for _, p := range s.Participants {
+		inspection.Participants = append(inspection.Participants, *p)
+	}
+	sort.Slice(inspection.Participants, func(i, j int) bool {
+		a, b := inspection.Participants[i], inspection.Participants[j]
+		if !a.JoinedAt.Equal(b.JoinedAt) {
+			return a.JoinedAt.Before(b.JoinedAt)
+		}
+		if a.IsHost != b.IsHost {
+			return a.IsHost
+		}
+		return a.ID < b.ID
+	})
+
+	if s.Phase == PhaseReading {
+		if participants := s.getParticipantsSorted(); len(participants) > 0 {
+			inspection.CurrentReaderID = participants[s.CurrentTurn%len(participants)].ID
+		}
+	}
+
+	for _, note := range s.Notes {
+		inspection.Notes++
+		if note.Read {
+			inspection.NotesRead++
+		}
+		if note.Private {
+			inspection.PrivateNotes++
+		}
+	}
+	return inspection
+}
@@ -1,52 +1,55 @@
-// ABOUTME: SessionManager handles in-memory storage and retrieval of gratitude circle sessions
+// ABOUTME: SessionManager handles storage and retrieval of gratitude circle sessions
 // ABOUTME: Provides thread-safe access to session data with lookup by ID or code
 package session
 
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"strings"
 	"sync"
 	"time"
 )
 
-// Manager manages all active sessions in memory
+// Manager manages all active sessions
 type Manager struct {
-	sessions       map[string]*Session // sessionID -> Session
-	sessionsByCode map[string]*Session // sessionCode -> Session
-	mu             sync.RWMutex
+	sessions Store
+	codes    CodeFormat // Length and alphabet of new sessions' codes
+	codesMu  sync.Mutex
 }
 
-// NewManager creates a new session manager
+// NewManager creates a session manager that keeps sessions in memory
 func NewManager() *Manager {
-	return &Manager{
-		sessions:       make(map[string]*Session),
-		sessionsByCode: make(map[string]*Session),
-	}
+	return NewManagerWithStore(NewMemoryStore())
 }
 
-// CreateSession creates a new session and stores it
-func (m *Manager) CreateSession(hostName string) *Session {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// NewManagerWithStore creates a session manager backed by store
+func NewManagerWithStore(store Store) *Manager {
+	return &Manager{sessions: store, codes: DefaultCodeFormat()}
+}
 
+// normalizeCode normalizes a session code to uppercase for consistent lookups
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// addSession stores a session under its ID and code
+func (m *Manager) addSession(session *Session) {
+	m.sessions.Put(session)
+}
+
+// CreateSession creates a new session with a code no other session has, and stores it
+func (m *Manager) CreateSession(hostName string) *Session {
 	session := NewSession(hostName)
-	m.sessions[session.ID] = session
-	// Normalize session code to uppercase for consistent lookups
-	normalizedCode := strings.ToUpper(strings.TrimSpace(session.Code))
-	m.sessionsByCode[normalizedCode] = session
+	m.addNewSession(session)
 
-	log.Printf("Session created: id=%s code=%s totalSessions=%d", session.ID, normalizedCode, len(m.sessions))
+	slog.Info("Session created", "sessionID", session.ID, "sessionCode", normalizeCode(session.Code), "totalSessions", m.sessions.Len())
 	return session
 }
 
 // GetSessionByID retrieves a session by its ID
 func (m *Manager) GetSessionByID(sessionID string) (*Session, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	session, exists := m.sessions[sessionID]
+	session, exists := m.sessions.Get(sessionID)
 	if !exists {
 		return nil, errors.New("session not found")
 	}
@@ -56,59 +59,35 @@ func (m *Manager) GetSessionByID(sessionID string) (*Session, error) {
 
 // GetSessionByCode retrieves a session by its code (case-insensitive)
 func (m *Manager) GetSessionByCode(code string) (*Session, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
 	// Normalize code to uppercase for case-insensitive lookup
-	normalizedCode := strings.ToUpper(strings.TrimSpace(code))
+	normalizedCode := normalizeCode(code)
 
-	session, exists := m.sessionsByCode[normalizedCode]
+	session, exists := m.sessions.GetByCode(normalizedCode)
 	if !exists {
-		log.Printf("Session lookup failed: code=%s (normalized=%s) totalSessions=%d", code, normalizedCode, len(m.sessions))
+		slog.Debug("Session lookup failed", "code", code, "sessionCode", normalizedCode)
 		return nil, errors.New("session not found")
 	}
 
-	log.Printf("Session found: code=%s id=%s", normalizedCode, session.ID)
+	slog.Debug("Session found", "sessionID", session.ID, "sessionCode", normalizedCode)
 	return session, nil
 }
 
 // RemoveSession removes a session from the manager
 func (m *Manager) RemoveSession(sessionID string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	session, exists := m.sessions[sessionID]
-	if !exists {
+	if _, exists := m.sessions.Remove(sessionID); !exists {
 		return errors.New("session not found")
 	}
-
-	delete(m.sessions, sessionID)
-	// Normalize session code for deletion
-	normalizedCode := strings.ToUpper(strings.TrimSpace(session.Code))
-	delete(m.sessionsByCode, normalizedCode)
-
 	return nil
 }
 
 // GetActiveSessionCount returns the number of active sessions
 func (m *Manager) GetActiveSessionCount() int {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	return len(m.sessions)
+	return m.sessions.Len()
 }
 
 // GetAllSessions returns all active sessions (for debugging/admin purposes)
 func (m *Manager) GetAllSessions() []*Session {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	sessions := make([]*Session, 0, len(m.sessions))
-	for _, session := range m.sessions {
-		sessions = append(sessions, session)
-	}
-
-	return sessions
+	return m.sessions.All()
 }
 
 // StartCleanupRoutine starts a background goroutine that periodically cleans up old sessions
@@ -116,12 +95,12 @@ func (m *Manager) StartCleanupRoutine(ctx context.Context) {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 
-	log.Printf("Session cleanup routine started (runs every 5 minutes)")
+	slog.Info("Session cleanup routine started", "interval", "5m")
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("Session cleanup routine stopped")
+			slog.Info("Session cleanup routine stopped")
 			return
 		case <-ticker.C:
 			m.cleanupSessions()
@@ -130,21 +109,19 @@ func (m *Manager) StartCleanupRoutine(ctx context.Context) {
 }
 
 // cleanupSessions removes old completed sessions and abandoned sessions
+// Works from a snapshot so no shard is locked while sessions are inspected
 func (m *Manager) cleanupSessions() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	now := time.Now()
 	completedThreshold := now.Add(-1 * time.Hour)
 	cleanedCount := 0
 
-	for sessionID, session := range m.sessions {
+	for _, session := range m.sessions.All() {
 		session.mu.RLock()
 		shouldRemove := false
 		reason := ""
 
-		// Remove abandoned sessions (no participants)
-		if len(session.Participants) == 0 {
+		// Remove abandoned sessions (no participants, and nobody who left can still rejoin)
+		if len(session.Participants) == 0 && !session.awaitingRejoinUnlocked(now) {
 			shouldRemove = true
 			reason = "abandoned (no participants)"
 		} else if session.Phase == PhaseComplete && session.CompletedAt != nil {
@@ -153,21 +130,31 @@ func (m *Manager) cleanupSessions() {
 				shouldRemove = true
 				reason = "completed over 1 hour ago"
 			}
+		} else if session.Phase == PhaseBreakout {
+			// Remove split sessions once all their breakout circles are gone
+			remaining := 0
+			for _, breakoutID := range session.BreakoutIDs {
+				if _, exists := m.sessions.Get(breakoutID); exists {
+					remaining++
+				}
+			}
+			if remaining == 0 {
+				shouldRemove = true
+				reason = "all breakout circles ended"
+			}
 		}
 
+		sessionID := session.ID
 		sessionCode := session.Code
 		session.mu.RUnlock()
 
-		if shouldRemove {
-			delete(m.sessions, sessionID)
-			normalizedCode := strings.ToUpper(strings.TrimSpace(sessionCode))
-			delete(m.sessionsByCode, normalizedCode)
+		if shouldRemove && m.RemoveSession(sessionID) == nil {
 			cleanedCount++
-			log.Printf("Cleaned up session: id=%s code=%s reason=%s", sessionID, sessionCode, reason)
+			slog.Info("Cleaned up session", "sessionID", sessionID, "sessionCode", sessionCode, "reason", reason)
 		}
 	}
 
 	if cleanedCount > 0 {
-		log.Printf("Session cleanup complete: removed=%d remaining=%d", cleanedCount, len(m.sessions))
+		slog.Info("Session cleanup complete", "removed", cleanedCount, "remaining", m.sessions.Len())
 	}
 }
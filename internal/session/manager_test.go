@@ -2,6 +2,10 @@ package session
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
 	"testing"
 	"time"
 )
@@ -10,11 +14,7 @@ func TestNewManager(t *testing.T) {
 	manager := NewManager()
 
 	if manager.sessions == nil {
-		t.Error("Expected sessions map to be initialized")
-	}
-
-	if manager.sessionsByCode == nil {
-		t.Error("Expected sessionsByCode map to be initialized")
+		t.Error("Expected the session store to be initialized")
 	}
 
 	count := manager.GetActiveSessionCount()
@@ -87,7 +87,10 @@ func TestGetSessionByCode(t *testing.T) {
 	manager2 := NewManager()
 	testSession := manager2.CreateSession("Test")
 	testSession.Code = lowerCode
-	manager2.sessionsByCode[upperCode] = testSession
+	manager2.sessions.Put(testSession)
+	if _, ok := manager2.sessions.GetByCode(upperCode); !ok {
+		t.Fatal("Expected the store to index the code in upper case")
+	}
 
 	retrieved, err := manager2.GetSessionByCode(lowerCode)
 	if err != nil {
@@ -280,3 +283,101 @@ func TestMultipleSessionsByDifferentHosts(t *testing.T) {
 		t.Error("Failed to retrieve all sessions by code")
 	}
 }
+
+func TestSessionMapShards(t *testing.T) {
+	m := newSessionMap()
+
+	for i := 0; i < 1000; i++ {
+		m.set(fmt.Sprintf("session-%d", i), &Session{})
+	}
+
+	if got := m.len(); got != 1000 {
+		t.Errorf("Expected 1000 sessions, got %d", got)
+	}
+
+	// Keys should spread across shards rather than piling into one
+	used := 0
+	for i := range m.shards {
+		if len(m.shards[i].sessions) > 0 {
+			used++
+		}
+	}
+	if used < shardCount/2 {
+		t.Errorf("Expected keys spread across shards, only %d of %d used", used, shardCount)
+	}
+
+	if _, removed := m.remove("session-0"); !removed {
+		t.Error("Expected session to be removed")
+	}
+	if _, exists := m.get("session-0"); exists {
+		t.Error("Expected removed session to be gone")
+	}
+	if got := len(m.values()); got != 999 {
+		t.Errorf("Expected 999 values, got %d", got)
+	}
+}
+
+// benchmarkManager creates a manager holding count sessions with logging silenced
+func benchmarkManager(b *testing.B, count int) (*Manager, []string) {
+	b.Helper()
+
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	manager := NewManager()
+	ids := make([]string, count)
+	for i := range ids {
+		ids[i] = manager.CreateSession("Host").ID
+	}
+	return manager, ids
+}
+
+func BenchmarkGetSessionByID(b *testing.B) {
+	for _, count := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("sessions=%d", count), func(b *testing.B) {
+			manager, ids := benchmarkManager(b, count)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					manager.GetSessionByID(ids[i%len(ids)])
+					i++
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkGetSessionByIDWithWrites(b *testing.B) {
+	manager, ids := benchmarkManager(b, 10000)
+
+	// Keep creating and removing sessions while lookups run
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				sess := manager.CreateSession("Churn")
+				manager.RemoveSession(sess.ID)
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			manager.GetSessionByID(ids[i%len(ids)])
+			i++
+		}
+	})
+	b.StopTimer()
+
+	close(stop)
+	<-done
+}
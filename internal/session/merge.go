@@ -0,0 +1,98 @@
+// ABOUTME: Merges a second joining session into another, for when a team accidentally splits
+// ABOUTME: Moves participants and their push devices across and retires the emptied session
+package session
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// EventMerged is recorded on the surviving session's timeline when another session merges in
+const EventMerged = "merged"
+
+// MergeSessions moves every participant of source into target and removes source
+// Both sessions must still be joining; participants keep their IDs, and source's
+// host joins target as a regular participant. Returns the moved participants.
+func (m *Manager) MergeSessions(target, source *Session) ([]*Participant, error) {
+	if target.ID == source.ID {
+		return nil, errors.New("cannot merge a session into itself")
+	}
+
+	// Lock in a consistent order so concurrent merges can't deadlock
+	first, second := target, source
+	if source.ID < target.ID {
+		first, second = source, target
+	}
+	first.mu.Lock()
+	second.mu.Lock()
+	moved, err := mergeUnlocked(target, source)
+	second.mu.Unlock()
+	first.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.RemoveSession(source.ID); err != nil {
+		slog.Error("Failed to remove merged session", "sessionID", source.ID, "sessionCode", source.Code, "err", err)
+	}
+
+	slog.Info("Sessions merged", "sessionID", target.ID, "sessionCode", target.Code, "sourceSessionID", source.ID, "sourceSessionCode", source.Code, "moved", len(moved))
+	return moved, nil
+}
+
+// mergeUnlocked moves source's participants into target
+// Internal helper that assumes caller holds both sessions' write locks
+func mergeUnlocked(target, source *Session) ([]*Participant, error) {
+	if target.Phase != PhaseJoining || source.Phase != PhaseJoining {
+		return nil, errors.New("can only merge sessions that haven't started writing")
+	}
+	if target.ParentID != "" || source.ParentID != "" {
+		return nil, errors.New("cannot merge breakout circles")
+	}
+
+	moved := make([]*Participant, 0, len(source.Participants))
+	movedIDs := make([]string, 0, len(source.Participants))
+	for id, p := range source.Participants {
+		if _, exists := target.Participants[id]; exists {
+			return nil, errors.New("participant already in session")
+		}
+
+		participant := &Participant{
+			ID:       p.ID,
+			Name:     p.Name,
+			Role:     p.Role,
+			JoinedAt: p.JoinedAt,
+		}
+		moved = append(moved, participant)
+		movedIDs = append(movedIDs, id)
+	}
+	if err := target.checkRoomUnlocked(len(moved)); err != nil {
+		return nil, err
+	}
+
+	for _, participant := range moved {
+		// A merge can't refuse one person, so clashing names are always numbered
+		if target.nameTakenUnlocked(participant.Name, "") {
+			participant.Name = target.suffixedNameUnlocked(participant.Name, "")
+		}
+		target.Participants[participant.ID] = participant
+
+		if devices := source.pushDevices[participant.ID]; len(devices) > 0 {
+			if target.pushDevices == nil {
+				target.pushDevices = make(map[string][]PushDevice)
+			}
+			target.pushDevices[participant.ID] = devices
+		}
+	}
+
+	// Nothing is left to run in the source session
+	source.Participants = map[string]*Participant{}
+	source.pushDevices = nil
+
+	target.recordEventUnlocked(EventMerged, "", map[string]interface{}{
+		"fromSessionCode": source.Code,
+		"participantIds":  movedIDs,
+	})
+	return moved, nil
+}
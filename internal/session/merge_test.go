@@ -0,0 +1,68 @@
+package session
+
+import (
+	"testing"
+)
+
+func TestMergeSessions(t *testing.T) {
+	manager := NewManager()
+	target := manager.CreateSession("Host")
+	source := manager.CreateSession("Other Host")
+	bob, _ := source.AddParticipant("Bob")
+	source.RegisterPushDevice(bob.ID, "fcm", "device-1")
+
+	moved, err := manager.MergeSessions(target, source)
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if len(moved) != 2 {
+		t.Errorf("Expected 2 participants moved, got %d", len(moved))
+	}
+	if len(target.Participants) != 3 {
+		t.Errorf("Expected 3 participants after merge, got %d", len(target.Participants))
+	}
+
+	// The other host joins as a regular participant
+	hosts := 0
+	for _, p := range target.Participants {
+		if p.IsHost {
+			hosts++
+		}
+	}
+	if hosts != 1 || !target.IsHostParticipant(target.HostID) {
+		t.Errorf("Expected exactly one host, got %d", hosts)
+	}
+
+	if devices := target.GetPushDevices(bob.ID); len(devices) != 1 {
+		t.Errorf("Expected push devices to move with the participant, got %v", devices)
+	}
+
+	if _, err := manager.GetSessionByCode(source.Code); err == nil {
+		t.Error("Expected the merged session to be removed")
+	}
+
+	timeline := target.Timeline()
+	if last := timeline[len(timeline)-1]; last.Type != EventMerged || last.Data["fromSessionCode"] != source.Code {
+		t.Errorf("Expected merge on the timeline, got %+v", last)
+	}
+}
+
+func TestMergeSessionsRejectedAfterWritingBegins(t *testing.T) {
+	manager := NewManager()
+	target := manager.CreateSession("Host")
+	target.AddParticipant("Alice")
+	source := manager.CreateSession("Other Host")
+	source.AddParticipant("Bob")
+
+	if _, err := manager.MergeSessions(target, target); err == nil {
+		t.Error("Expected error merging a session into itself")
+	}
+
+	source.TransitionToWriting()
+	if _, err := manager.MergeSessions(target, source); err == nil {
+		t.Error("Expected error merging a session that has started writing")
+	}
+	if len(target.Participants) != 2 || len(source.Participants) != 2 {
+		t.Error("Expected a rejected merge to leave both sessions unchanged")
+	}
+}
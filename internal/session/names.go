@@ -0,0 +1,48 @@
+// ABOUTME: Keeps participants' display names distinct within a session
+// ABOUTME: A name already taken is refused or given a number, depending on the session's settings
+package session
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDuplicateName is returned when a name is already taken in a session that refuses duplicates
+var ErrDuplicateName = errors.New("someone in this session already has that name")
+
+// nameTakenUnlocked reports whether anyone other than exceptID goes by name
+// Names differing only in case count as the same, since nobody could tell them apart
+// Internal helper that assumes caller holds the lock
+func (s *Session) nameTakenUnlocked(name, exceptID string) bool {
+	for id, participant := range s.Participants {
+		if id != exceptID && strings.EqualFold(participant.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueNameUnlocked returns name if nobody other than exceptID has it; otherwise it refuses
+// it or numbers it ("Alex (2)") as the session's settings say
+// Internal helper that assumes caller holds the lock
+func (s *Session) uniqueNameUnlocked(name, exceptID string) (string, error) {
+	if !s.nameTakenUnlocked(name, exceptID) {
+		return name, nil
+	}
+	if s.Settings.DuplicateNames == DuplicateNamesReject {
+		return "", ErrDuplicateName
+	}
+	return s.suffixedNameUnlocked(name, exceptID), nil
+}
+
+// suffixedNameUnlocked numbers name with the lowest number nobody other than exceptID has
+// Internal helper that assumes caller holds the lock
+func (s *Session) suffixedNameUnlocked(name, exceptID string) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if !s.nameTakenUnlocked(candidate, exceptID) {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDuplicateNamesAreNumbered(t *testing.T) {
+	sess := NewSession("Alex")
+
+	second, err := sess.AddParticipant("Alex")
+	if err != nil || second.Name != "Alex (2)" {
+		t.Fatalf("Expected the second Alex to be Alex (2), got %+v err=%v", second, err)
+	}
+	third, _ := sess.AddParticipant("alex")
+	if third.Name != "alex (3)" {
+		t.Errorf("Expected names differing only in case to clash, got %q", third.Name)
+	}
+
+	// Renaming to your own name, or one that's free, leaves it as it is
+	if renamed, _ := sess.ChangeName(second.ID, "Alex (2)"); renamed.Name != "Alex (2)" {
+		t.Errorf("Expected keeping your own name to be allowed, got %q", renamed.Name)
+	}
+	if renamed, _ := sess.RenameParticipant(third.ID, "Alex"); renamed.Name != "Alex (3)" {
+		t.Errorf("Expected a rename onto a taken name to be numbered, got %q", renamed.Name)
+	}
+}
+
+func TestDuplicateNamesCanBeRefused(t *testing.T) {
+	sess := NewSession("Alex")
+	sess.SetSettings(SessionSettings{DuplicateNames: DuplicateNamesReject})
+
+	if _, err := sess.AddParticipant("ALEX"); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Expected a duplicate name to be refused, got %v", err)
+	}
+	sam, err := sess.AddParticipant("Sam")
+	if err != nil {
+		t.Fatalf("Expected a distinct name to be allowed, got %v", err)
+	}
+	if _, err := sess.ChangeName(sam.ID, "Alex"); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Expected changing to a taken name to be refused, got %v", err)
+	}
+}
+
+func TestMergedDuplicateNamesAreNumbered(t *testing.T) {
+	manager := NewManager()
+	target := manager.CreateSession("Alex")
+	target.SetSettings(SessionSettings{DuplicateNames: DuplicateNamesReject})
+	source := manager.CreateSession("Alex")
+
+	moved, err := manager.MergeSessions(target, source)
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if len(moved) != 1 || moved[0].Name != "Alex (2)" {
+		t.Errorf("Expected the merged Alex to be numbered, got %+v", moved)
+	}
+}
@@ -0,0 +1,78 @@
+// ABOUTME: Lets authors fix or take back a note they submitted while writing is still open
+// ABOUTME: Notes are looked up by author and recipient, so nobody can change a note they didn't write
+package session
+
+import (
+	"errors"
+	"time"
+)
+
+// UpdateNote replaces the content of the note an author wrote to a recipient
+// The content must already be validated by the caller
+func (s *Session) UpdateNote(authorID, recipientID, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Phase != PhaseWriting {
+		return errors.New("cannot edit note: not in writing phase")
+	}
+
+	note := s.authoredNoteUnlocked(authorID, recipientID)
+	if note == nil {
+		return errors.New("note not found")
+	}
+
+	now := time.Now()
+	note.Content = content
+	note.EditedAt = &now
+	return nil
+}
+
+// SetNoteGIF replaces or, with an empty URL, removes the GIF on the note an author wrote to a recipient
+// The URL must already be validated against the GIF provider by the caller
+func (s *Session) SetNoteGIF(authorID, recipientID, gifURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Phase != PhaseWriting {
+		return errors.New("cannot edit note: not in writing phase")
+	}
+
+	note := s.authoredNoteUnlocked(authorID, recipientID)
+	if note == nil {
+		return errors.New("note not found")
+	}
+
+	note.GIFURL = gifURL
+	return nil
+}
+
+// DeleteNote retracts the note an author wrote to a recipient, so they can write it again
+func (s *Session) DeleteNote(authorID, recipientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Phase != PhaseWriting {
+		return errors.New("cannot delete note: not in writing phase")
+	}
+
+	for i, note := range s.Notes {
+		if note.AuthorID == authorID && note.RecipientID == recipientID {
+			s.Notes = append(s.Notes[:i:i], s.Notes[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.New("note not found")
+}
+
+// authoredNoteUnlocked returns the note an author wrote to a recipient, or nil
+// Internal helper that assumes caller already holds a lock
+func (s *Session) authoredNoteUnlocked(authorID, recipientID string) *Note {
+	for _, note := range s.Notes {
+		if note.AuthorID == authorID && note.RecipientID == recipientID {
+			return note
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package session
+
+import "testing"
+
+func TestUpdateAndDeleteNote(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alcie")
+
+	if err := sess.UpdateNote(alice.ID, sess.HostID, "Not mine"); err == nil {
+		t.Error("Expected editing a note someone else wrote to fail")
+	}
+	if err := sess.UpdateNote(sess.HostID, alice.ID, "Thanks Alice"); err != nil {
+		t.Fatalf("Expected author to edit their note: %v", err)
+	}
+	if sess.Notes[0].Content != "Thanks Alice" || sess.Notes[0].EditedAt == nil {
+		t.Errorf("Expected edited content and time, got %+v", sess.Notes[0])
+	}
+
+	if err := sess.DeleteNote(alice.ID, sess.HostID); err == nil {
+		t.Error("Expected deleting a missing note to fail")
+	}
+	if err := sess.DeleteNote(sess.HostID, alice.ID); err != nil {
+		t.Fatalf("Expected author to delete their note: %v", err)
+	}
+	if len(sess.Notes) != 0 {
+		t.Errorf("Expected the note to be gone, got %d notes", len(sess.Notes))
+	}
+
+	// A retracted note can be written again
+	if err := sess.AddNote(sess.HostID, alice.ID, "Thank you, Alice"); err != nil {
+		t.Errorf("Expected to rewrite a deleted note: %v", err)
+	}
+}
+
+func TestNotesCannotChangeOnceReading(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	sess.TransitionToReading()
+
+	if err := sess.UpdateNote(sess.HostID, alice.ID, "Changed"); err == nil {
+		t.Error("Expected edits to be refused while reading")
+	}
+	if err := sess.DeleteNote(sess.HostID, alice.ID); err == nil {
+		t.Error("Expected deletes to be refused while reading")
+	}
+}
+
+func TestAllNotesWrittenIgnoresDepartedParticipants(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.TransitionToWriting()
+	sess.AddNote(bob.ID, sess.HostID, "Thanks Host")
+	sess.AddNote(bob.ID, alice.ID, "Thanks Alice")
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.RemoveParticipant(bob.ID)
+
+	// Bob's two notes make the count match, but the host and Alice still owe one each
+	if sess.AllNotesWritten() {
+		t.Error("Expected notes from a departed participant not to count")
+	}
+
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	if !sess.AllNotesWritten() {
+		t.Error("Expected every note between current participants to be written")
+	}
+}
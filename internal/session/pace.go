@@ -0,0 +1,72 @@
+// ABOUTME: Reading-pace analytics: how long each note spends between being drawn and read
+// ABOUTME: Helps facilitators plan how much meeting time a circle of a given size needs
+package session
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// maxLongestPauses is how many of the slowest notes the pace summary lists
+const maxLongestPauses = 3
+
+// NotePause is how long one note took from being drawn to being read
+type NotePause struct {
+	NoteID      string  `json:"noteId"`
+	RecipientID string  `json:"recipientId"`
+	Seconds     float64 `json:"seconds"`
+}
+
+// ReadingPace summarises how long notes took to read aloud
+type ReadingPace struct {
+	NotesTimed     int         `json:"notesTimed"`
+	TotalSeconds   float64     `json:"totalSeconds"`
+	AverageSeconds float64     `json:"averageSeconds"`
+	LongestPauses  []NotePause `json:"longestPauses"` // Slowest notes first
+}
+
+// RecordNoteDrawn records when a note was drawn to be read aloud
+func (s *Session) RecordNoteDrawn(noteID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, note := range s.Notes {
+		if note.ID == noteID {
+			now := time.Now()
+			note.DrawnAt = &now
+			return nil
+		}
+	}
+
+	return errors.New("note not found")
+}
+
+// GetReadingPace summarises the time between draw and read across every read note
+func (s *Session) GetReadingPace() ReadingPace {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pace := ReadingPace{LongestPauses: []NotePause{}}
+	var pauses []NotePause
+	for _, note := range s.Notes {
+		if note.DrawnAt == nil || note.ReadAt == nil || note.ReadAt.Before(*note.DrawnAt) {
+			continue
+		}
+		seconds := note.ReadAt.Sub(*note.DrawnAt).Seconds()
+		pace.NotesTimed++
+		pace.TotalSeconds += seconds
+		pauses = append(pauses, NotePause{NoteID: note.ID, RecipientID: note.RecipientID, Seconds: seconds})
+	}
+
+	if pace.NotesTimed == 0 {
+		return pace
+	}
+	pace.AverageSeconds = pace.TotalSeconds / float64(pace.NotesTimed)
+
+	sort.Slice(pauses, func(i, j int) bool {
+		return pauses[i].Seconds > pauses[j].Seconds
+	})
+	pace.LongestPauses = pauses[:min(len(pauses), maxLongestPauses)]
+	return pace
+}
@@ -0,0 +1,46 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadingPace(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, bob.ID, "Thanks for the pairing")
+	sess.AddNote(bob.ID, alice.ID, "Thanks for the reviews")
+	sess.AddNote(sess.HostID, alice.ID, "Thanks for the demo")
+
+	if pace := sess.GetReadingPace(); pace.NotesTimed != 0 || len(pace.LongestPauses) != 0 {
+		t.Errorf("Expected no timings before reading, got %+v", pace)
+	}
+
+	// Times a note as drawn secondsAgo and read just now
+	start := time.Now()
+	timeNote := func(note *Note, seconds int) {
+		drawn := start.Add(-time.Duration(seconds) * time.Second)
+		note.DrawnAt = &drawn
+		note.ReadAt = &start
+	}
+	timeNote(sess.Notes[0], 20)
+	timeNote(sess.Notes[1], 40)
+
+	// A note drawn but not yet read isn't counted
+	sess.RecordNoteDrawn(sess.Notes[2].ID)
+
+	pace := sess.GetReadingPace()
+	if pace.NotesTimed != 2 || pace.AverageSeconds != 30 || pace.TotalSeconds != 60 {
+		t.Errorf("Expected two notes averaging 30s, got %+v", pace)
+	}
+	if len(pace.LongestPauses) != 2 || pace.LongestPauses[0].NoteID != sess.Notes[1].ID {
+		t.Errorf("Expected the slowest note first, got %+v", pace.LongestPauses)
+	}
+
+	sess.MarkNoteAsRead(sess.Notes[2].ID)
+	if pace := sess.GetReadingPace(); pace.NotesTimed != 3 {
+		t.Errorf("Expected reading to complete the third timing, got %+v", pace)
+	}
+}
@@ -0,0 +1,75 @@
+// ABOUTME: Emoji reactions listeners send while a note is being read aloud
+// ABOUTME: Counts are kept per note; each participant adds each emoji to a note at most once
+package session
+
+import (
+	"errors"
+	"strings"
+)
+
+// Reactions is the set of emoji listeners may react with, in display order
+var Reactions = []string{"👏", "❤️", "🙌", "🎉"}
+
+// ParseReaction validates a reaction emoji against the set offered to listeners
+// A heart without its emoji presentation selector counts as ❤️
+func ParseReaction(emoji string) (string, error) {
+	if emoji == "❤" {
+		emoji = "❤️"
+	}
+	for _, reaction := range Reactions {
+		if reaction == emoji {
+			return reaction, nil
+		}
+	}
+	return "", errors.New("reaction must be one of " + strings.Join(Reactions, " "))
+}
+
+// ReactToNote adds a participant's reaction to the note being read aloud and returns
+// the note's reaction counts, and whether this reaction was new
+// The emoji must already be validated with ParseReaction
+func (s *Session) ReactToNote(participantID, noteID, emoji string) (map[string]int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Phase != PhaseReading {
+		return nil, false, errors.New("can only react while notes are being read")
+	}
+
+	if _, exists := s.Participants[participantID]; !exists {
+		return nil, false, errors.New("participant not found")
+	}
+
+	for _, note := range s.Notes {
+		if note.ID != noteID {
+			continue
+		}
+		if note.DrawnAt == nil || note.Read {
+			return nil, false, errors.New("can only react to the note being read")
+		}
+
+		key := participantID + " " + emoji
+		if note.reactedBy[key] {
+			return copyCounts(note.Reactions), false, nil
+		}
+		if note.reactedBy == nil {
+			note.reactedBy = make(map[string]bool)
+		}
+		if note.Reactions == nil {
+			note.Reactions = make(map[string]int)
+		}
+		note.reactedBy[key] = true
+		note.Reactions[emoji]++
+		return copyCounts(note.Reactions), true, nil
+	}
+
+	return nil, false, errors.New("note not found")
+}
+
+// copyCounts copies reaction counts so callers can't race later reactions
+func copyCounts(counts map[string]int) map[string]int {
+	c := make(map[string]int, len(counts))
+	for emoji, count := range counts {
+		c[emoji] = count
+	}
+	return c
+}
@@ -0,0 +1,54 @@
+package session
+
+import "testing"
+
+func TestParseReaction(t *testing.T) {
+	if emoji, err := ParseReaction("👏"); err != nil || emoji != "👏" {
+		t.Errorf("Expected 👏 to be accepted, got %q %v", emoji, err)
+	}
+	if emoji, err := ParseReaction("❤"); err != nil || emoji != "❤️" {
+		t.Errorf("Expected a plain heart to count as ❤️, got %q %v", emoji, err)
+	}
+	if _, err := ParseReaction("💩"); err == nil {
+		t.Error("Expected an emoji outside the set to be refused")
+	}
+}
+
+func TestReactToNote(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	sess.TransitionToReading()
+	note := sess.Notes[0]
+
+	if _, _, err := sess.ReactToNote(alice.ID, note.ID, "👏"); err == nil {
+		t.Error("Expected reacting to a note that hasn't been drawn to fail")
+	}
+
+	sess.RecordNoteDrawn(note.ID)
+	counts, added, err := sess.ReactToNote(alice.ID, note.ID, "👏")
+	if err != nil || !added || counts["👏"] != 1 {
+		t.Fatalf("Expected Alice's reaction to count, got %v %v %v", counts, added, err)
+	}
+
+	// Repeating a reaction doesn't inflate the count
+	counts, added, _ = sess.ReactToNote(alice.ID, note.ID, "👏")
+	if added || counts["👏"] != 1 {
+		t.Errorf("Expected a repeated reaction to be ignored, got %v %v", counts, added)
+	}
+
+	counts, _, _ = sess.ReactToNote(sess.HostID, note.ID, "👏")
+	if counts["👏"] != 2 {
+		t.Errorf("Expected two claps, got %v", counts)
+	}
+
+	sess.MarkNoteAsRead(note.ID)
+	if _, _, err := sess.ReactToNote(alice.ID, note.ID, "❤️"); err == nil {
+		t.Error("Expected reacting to a read note to fail")
+	}
+	if note.Reactions["👏"] != 2 {
+		t.Errorf("Expected counts to stay on the note, got %v", note.Reactions)
+	}
+}
@@ -0,0 +1,89 @@
+// ABOUTME: Redis snapshot backend, so circles survive deploys and can move between replicas
+// ABOUTME: Snapshots are hashes guarded by a revision so a stale replica's saves are refused
+package session
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/cassiascheffer/uplift/internal/redis"
+)
+
+// NewRedisStore creates a store persisting sessions to Redis under keys starting with prefix
+func NewRedisStore(client *redis.Client, prefix string) *SnapshotStore {
+	return newSnapshotStore(&redisSnapshots{client: client, prefix: prefix})
+}
+
+// redisSnapshots keeps snapshots in Redis hashes {revision, data} with a code -> ID index
+type redisSnapshots struct {
+	client *redis.Client
+	prefix string
+}
+
+// saveScript writes a snapshot only if the stored revision is the one the writer last saw
+const saveScript = `
+local current = tonumber(redis.call('HGET', KEYS[1], 'revision') or '0')
+if current ~= tonumber(ARGV[1]) then
+	return -1
+end
+redis.call('HSET', KEYS[1], 'revision', current + 1, 'data', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+redis.call('SET', KEYS[2], ARGV[4], 'PX', ARGV[3])
+return current + 1
+`
+
+func (r *redisSnapshots) sessionKey(sessionID string) string {
+	return r.prefix + "session:" + sessionID
+}
+
+func (r *redisSnapshots) codeKey(code string) string {
+	return r.prefix + "code:" + code
+}
+
+func (r *redisSnapshots) save(ctx context.Context, sessionID, code string, revision uint64, data []byte) (uint64, error) {
+	result, err := redis.Int(r.client.Do(ctx, "EVAL", saveScript, "2",
+		r.sessionKey(sessionID), r.codeKey(code),
+		strconv.FormatUint(revision, 10), string(data),
+		strconv.FormatInt(snapshotTTL.Milliseconds(), 10), sessionID))
+	if err != nil {
+		return 0, err
+	}
+	if result < 0 {
+		return 0, errConflict
+	}
+	return uint64(result), nil
+}
+
+func (r *redisSnapshots) load(ctx context.Context, sessionID string) ([]byte, uint64, error) {
+	reply, err := r.client.Do(ctx, "HMGET", r.sessionKey(sessionID), "revision", "data")
+	if err != nil {
+		return nil, 0, err
+	}
+	fields, _ := reply.([]interface{})
+	if len(fields) != 2 || fields[0] == nil || fields[1] == nil {
+		return nil, 0, errNotStored
+	}
+	revision, err := redis.Int(fields[0], nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := redis.String(fields[1], nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	return []byte(data), uint64(revision), nil
+}
+
+func (r *redisSnapshots) lookupCode(ctx context.Context, code string) (string, error) {
+	sessionID, err := redis.String(r.client.Do(ctx, "GET", r.codeKey(code)))
+	if errors.Is(err, redis.ErrNil) {
+		return "", errNotStored
+	}
+	return sessionID, err
+}
+
+func (r *redisSnapshots) remove(ctx context.Context, sessionID, code string) error {
+	_, err := r.client.Do(ctx, "DEL", r.sessionKey(sessionID), r.codeKey(code))
+	return err
+}
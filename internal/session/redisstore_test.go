@@ -0,0 +1,177 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// memorySnapshots is a snapshotBackend kept in memory, standing in for Redis
+type memorySnapshots struct {
+	data      map[string][]byte
+	revisions map[string]uint64
+	codes     map[string]string
+	saves     int
+	mu        sync.Mutex
+}
+
+func newMemorySnapshots() *memorySnapshots {
+	return &memorySnapshots{
+		data:      make(map[string][]byte),
+		revisions: make(map[string]uint64),
+		codes:     make(map[string]string),
+	}
+}
+
+func (m *memorySnapshots) save(ctx context.Context, sessionID, code string, revision uint64, data []byte) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.revisions[sessionID] != revision {
+		return 0, errConflict
+	}
+	m.saves++
+	m.revisions[sessionID]++
+	m.data[sessionID] = data
+	m.codes[code] = sessionID
+	return m.revisions[sessionID], nil
+}
+
+func (m *memorySnapshots) load(ctx context.Context, sessionID string) ([]byte, uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.data[sessionID]
+	if !ok {
+		return nil, 0, errNotStored
+	}
+	return data, m.revisions[sessionID], nil
+}
+
+func (m *memorySnapshots) lookupCode(ctx context.Context, code string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sessionID, ok := m.codes[code]
+	if !ok {
+		return "", errNotStored
+	}
+	return sessionID, nil
+}
+
+func (m *memorySnapshots) remove(ctx context.Context, sessionID, code string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.data, sessionID)
+	delete(m.revisions, sessionID)
+	delete(m.codes, code)
+	return nil
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	sess := NewManager().CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.SetHostKey("host-key")
+	sess.RegisterPushDevice(alice.ID, "fcm", "device-token")
+	aliceToken, _ := sess.IssueRejoinToken(alice.ID)
+	bobToken, _ := sess.IssueRejoinToken(bob.ID)
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, bob.ID, "Thanks for the pairing")
+	sess.RemoveParticipant(bob.ID)
+
+	data, err := sess.MarshalSnapshot()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	restored, err := UnmarshalSnapshot(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	if restored.Code != sess.Code || restored.Phase != PhaseWriting || len(restored.Notes) != 1 || !restored.HasParticipant(alice.ID) {
+		t.Errorf("Expected the session's state restored, got %+v", restored)
+	}
+	if restored.HostKey() != "host-key" || len(restored.GetPushDevices(alice.ID)) != 1 || len(restored.Timeline()) != len(sess.Timeline()) {
+		t.Error("Expected private state to survive the round trip")
+	}
+
+	// Both the connected participant and the one who just left can reclaim their places
+	if p, _, err := restored.Rejoin(aliceToken); err != nil || p.ID != alice.ID {
+		t.Errorf("Expected Alice's token to work after a restart, got err=%v", err)
+	}
+	if p, restoredBob, err := restored.Rejoin(bobToken); err != nil || !restoredBob || p.Name != "Bob" {
+		t.Errorf("Expected Bob to rejoin after a restart, got err=%v", err)
+	}
+}
+
+func TestRedisStoreSavesChangedSessions(t *testing.T) {
+	backend := newMemorySnapshots()
+	manager := NewManagerWithStore(newSnapshotStore(backend))
+
+	sess := manager.CreateSession("Host")
+	if backend.saves != 1 {
+		t.Fatalf("Expected a new session to be saved straight away, got %d saves", backend.saves)
+	}
+
+	store := manager.sessions.(*SnapshotStore)
+	store.Flush(context.Background())
+	if backend.saves != 1 {
+		t.Error("Expected an unchanged session not to be saved again")
+	}
+
+	sess.AddParticipant("Alice")
+	store.Flush(context.Background())
+	if backend.saves != 2 {
+		t.Error("Expected a changed session to be saved")
+	}
+
+	manager.RemoveSession(sess.ID)
+	if _, err := backend.lookupCode(context.Background(), sess.Code); err == nil {
+		t.Error("Expected a removed session to be deleted from the store")
+	}
+}
+
+func TestRedisStoreAdoptsStoredSessions(t *testing.T) {
+	backend := newMemorySnapshots()
+	before := NewManagerWithStore(newSnapshotStore(backend))
+	sess := before.CreateSession("Host")
+	sess.AddParticipant("Alice")
+	before.sessions.(*SnapshotStore).Flush(context.Background())
+
+	// A new process finds the session when someone looks for it
+	store := newSnapshotStore(backend)
+	var adopted []*Session
+	store.SetLoadHandler(func(s *Session) { adopted = append(adopted, s) })
+	after := NewManagerWithStore(store)
+
+	found, err := after.GetSessionByCode(sess.Code)
+	if err != nil || found.ID != sess.ID || len(found.Participants) != 2 {
+		t.Fatalf("Expected the stored session, got %+v err=%v", found, err)
+	}
+	if again, _ := after.GetSessionByID(sess.ID); again != found {
+		t.Error("Expected later lookups to return the live session")
+	}
+	if len(adopted) != 1 || adopted[0] != found {
+		t.Errorf("Expected the load handler to be called once, got %d", len(adopted))
+	}
+
+	// Once the new process saves, the old copy is stale and stops saving
+	found.AddParticipant("Bob")
+	store.Flush(context.Background())
+	sess.AddParticipant("Carol")
+	before.sessions.(*SnapshotStore).Flush(context.Background())
+	sess.AddParticipant("Dave")
+	before.sessions.(*SnapshotStore).Flush(context.Background())
+
+	data, _, _ := backend.load(context.Background(), sess.ID)
+	stored, _ := UnmarshalSnapshot(data)
+	if len(stored.Participants) != 3 {
+		t.Errorf("Expected the adopting process's copy to win, got %d participants", len(stored.Participants))
+	}
+
+	if _, err := after.GetSessionByCode("NOPE00"); err == nil {
+		t.Error("Expected an unknown code not to be found")
+	}
+}
@@ -0,0 +1,165 @@
+// ABOUTME: Rejoin tokens that let a participant reclaim their place after a refresh or network blip
+// ABOUTME: Departed participants are remembered for RejoinWindow so they come back under the same ID
+package session
+
+import (
+	"errors"
+	"time"
+)
+
+// RejoinWindow is how long a participant who dropped off can reclaim their place
+const RejoinWindow = 5 * time.Minute
+
+// ErrRejoinExpired is returned when a valid token is presented after RejoinWindow
+var ErrRejoinExpired = errors.New("rejoin window has passed")
+
+// departure remembers a participant who left so they can be restored in place
+type departure struct {
+	participant *Participant
+	at          time.Time
+}
+
+// IssueRejoinToken returns the secret a participant presents to reclaim their place
+// Repeated calls for the same participant return the same token
+func (s *Session) IssueRejoinToken(participantID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.Participants[participantID]; !exists {
+		return "", errors.New("participant not found")
+	}
+
+	for token, id := range s.rejoinTokens {
+		if id == participantID {
+			return token, nil
+		}
+	}
+
+	if s.rejoinTokens == nil {
+		s.rejoinTokens = make(map[string]string)
+	}
+	token := generateID()
+	s.rejoinTokens[token] = participantID
+	return token, nil
+}
+
+// Rejoin restores the participant a token was issued to, returning whether they had
+// left (false means they never dropped off, e.g. a new connection replacing a stale one)
+// A host who has been replaced while away comes back as a participant
+func (s *Session) Rejoin(token string) (*Participant, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	participantID, ok := s.rejoinTokens[token]
+	if !ok {
+		return nil, false, errors.New("invalid rejoin token")
+	}
+
+	if participant, exists := s.Participants[participantID]; exists {
+		return participant, false, nil
+	}
+
+	if s.Phase == PhaseBreakout {
+		return nil, false, errors.New("session has split into breakout circles")
+	}
+
+	left, ok := s.departed[participantID]
+	if !ok || time.Since(left.at) > RejoinWindow {
+		return nil, false, ErrRejoinExpired
+	}
+
+	// Someone else may have taken the place they left
+	if err := s.checkRoomUnlocked(1); err != nil {
+		return nil, false, err
+	}
+	delete(s.departed, participantID)
+
+	// Whoever comes back first to an empty session takes over hosting
+	if _, hostPresent := s.Participants[s.HostID]; !hostPresent {
+		s.HostID = participantID
+	}
+
+	participant := left.participant
+	participant.IsHost = participantID == s.HostID
+	s.Participants[participantID] = participant
+	s.recordEventUnlocked(EventRejoined, participantID, nil)
+	return participant, true, nil
+}
+
+// HostedBy reports whether token is the rejoin token of the session's current host, so
+// whoever presents it hosts the session
+func (s *Session) HostedBy(token string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	participantID, ok := s.rejoinTokens[token]
+	return ok && token != "" && participantID == s.HostID
+}
+
+// AwaitingRejoin reports whether anyone who left could still rejoin
+func (s *Session) AwaitingRejoin() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.awaitingRejoinUnlocked(time.Now())
+}
+
+// awaitingRejoinUnlocked reports whether any departure is still within the rejoin window
+// Internal helper that assumes caller already holds the lock
+func (s *Session) awaitingRejoinUnlocked(now time.Time) bool {
+	for _, left := range s.departed {
+		if now.Sub(left.at) <= RejoinWindow {
+			return true
+		}
+	}
+	return false
+}
+
+// recordDepartureUnlocked remembers a participant who left, if they were given a rejoin token,
+// and forgets anyone whose window has passed
+// Internal helper that assumes caller already holds the write lock
+func (s *Session) recordDepartureUnlocked(participant *Participant) {
+	now := time.Now()
+	for id, left := range s.departed {
+		if now.Sub(left.at) > RejoinWindow {
+			delete(s.departed, id)
+		}
+	}
+
+	for _, id := range s.rejoinTokens {
+		if id == participant.ID {
+			if s.departed == nil {
+				s.departed = make(map[string]departure)
+			}
+			s.departed[participant.ID] = departure{participant: participant, at: now}
+			return
+		}
+	}
+}
+
+// RevokeRejoin stops a participant rejoining, e.g. after the host removes them
+func (s *Session) RevokeRejoin(participantID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, id := range s.rejoinTokens {
+		if id == participantID {
+			delete(s.rejoinTokens, token)
+		}
+	}
+	delete(s.departed, participantID)
+}
+
+// RecipientsWrittenTo returns the IDs of everyone the author has submitted a note to
+func (s *Session) RecipientsWrittenTo(authorID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recipients := []string{}
+	for _, note := range s.Notes {
+		if note.AuthorID == authorID {
+			recipients = append(recipients, note.RecipientID)
+		}
+	}
+	return recipients
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRejoinRestoresParticipant(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+
+	token, err := sess.IssueRejoinToken(alice.ID)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	if again, _ := sess.IssueRejoinToken(alice.ID); again != token {
+		t.Error("Expected the same token for repeated calls")
+	}
+
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, bob.ID, "Thanks for the pairing")
+
+	// Still connected: rejoining just hands back the participant
+	if p, restored, err := sess.Rejoin(token); err != nil || restored || p.ID != alice.ID {
+		t.Errorf("Expected Alice without a restore, got %+v restored=%v err=%v", p, restored, err)
+	}
+
+	sess.RemoveParticipant(alice.ID)
+	if !sess.AwaitingRejoin() {
+		t.Error("Expected the session to await Alice rejoining")
+	}
+
+	// Rejoining mid-writing brings her back under the same ID with her notes intact
+	p, restored, err := sess.Rejoin(token)
+	if err != nil || !restored || p.ID != alice.ID {
+		t.Fatalf("Expected Alice restored, got %+v restored=%v err=%v", p, restored, err)
+	}
+	if !sess.HasParticipant(alice.ID) {
+		t.Error("Expected Alice back in the session")
+	}
+	if written := sess.RecipientsWrittenTo(alice.ID); len(written) != 1 || written[0] != bob.ID {
+		t.Errorf("Expected Alice's note to Bob to be kept, got %v", written)
+	}
+
+	if _, _, err := sess.Rejoin("not-a-token"); err == nil {
+		t.Error("Expected an unknown token to be rejected")
+	}
+}
+
+func TestRejoinWindowAndRevocation(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	aliceToken, _ := sess.IssueRejoinToken(alice.ID)
+	bobToken, _ := sess.IssueRejoinToken(bob.ID)
+
+	sess.RemoveParticipant(alice.ID)
+	sess.departed[alice.ID] = departure{participant: alice, at: time.Now().Add(-RejoinWindow - time.Second)}
+	if _, _, err := sess.Rejoin(aliceToken); !errors.Is(err, ErrRejoinExpired) {
+		t.Errorf("Expected the rejoin window to have passed, got %v", err)
+	}
+
+	// Someone the host removed can't come back
+	sess.RemoveParticipant(bob.ID)
+	sess.RevokeRejoin(bob.ID)
+	if _, _, err := sess.Rejoin(bobToken); err == nil {
+		t.Error("Expected a revoked token to be rejected")
+	}
+}
+
+func TestRejoinEmptySessionTakesOverHosting(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	hostToken, _ := sess.IssueRejoinToken(sess.HostID)
+	aliceToken, _ := sess.IssueRejoinToken(alice.ID)
+	hostID := sess.HostID
+
+	// The host leaves and Alice takes over, then Alice leaves too
+	sess.RemoveParticipant(hostID)
+	sess.ReassignHost()
+	sess.RemoveParticipant(alice.ID)
+
+	// The original host comes back first, so hosts again
+	host, _, err := sess.Rejoin(hostToken)
+	if err != nil || !host.IsHost || sess.HostID != hostID {
+		t.Fatalf("Expected the returning host to host, got %+v err=%v", host, err)
+	}
+
+	p, _, _ := sess.Rejoin(aliceToken)
+	if p.IsHost {
+		t.Error("Expected Alice to come back as a participant")
+	}
+}
@@ -0,0 +1,33 @@
+// ABOUTME: Lets the host go back from reading to writing before any note has been read
+// ABOUTME: Returns drawn-but-unread notes to the pool and lets a forgotten person join late
+package session
+
+import (
+	"errors"
+)
+
+// ReopenWriting moves the session from reading back to writing so a forgotten person
+// can join and be written to. Notes already written are kept. Only allowed before any
+// note has been read; a note that was drawn but not finished goes back into the pool
+// and its ID is returned so clients can take it off screen.
+func (s *Session) ReopenWriting() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Phase != PhaseReading {
+		return nil, errors.New("can only reopen writing from reading phase")
+	}
+
+	for _, note := range s.Notes {
+		if note.Read {
+			return nil, errors.New("cannot reopen writing: notes have already been read")
+		}
+	}
+
+	result, err := s.rollbackUnlocked(PhaseWriting, map[string]interface{}{"reopened": true})
+	if err != nil {
+		return nil, err
+	}
+	s.WritingReopened = true
+	return result.ReturnedNoteIDs, nil
+}
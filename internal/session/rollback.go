@@ -0,0 +1,124 @@
+// ABOUTME: Moves a session back to an earlier phase with well-defined reconciliation
+// ABOUTME: Shared by host undo, reopening writing and admin repair so state is never patched ad hoc
+package session
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// phaseOrder ranks the phases a session moves through; breakout parents aren't in it
+// because their participants have already left for other circles
+var phaseOrder = map[Phase]int{
+	PhaseJoining:  0,
+	PhaseWriting:  1,
+	PhaseReading:  2,
+	PhaseComplete: 3,
+}
+
+// RollbackResult reports what a rollback changed
+type RollbackResult struct {
+	From            Phase    `json:"from"`
+	To              Phase    `json:"to"`
+	NotesDiscarded  int      `json:"notesDiscarded"`  // Notes dropped going back to joining
+	NotesUnread     int      `json:"notesUnread"`     // Read notes put back in the pool
+	ReturnedNoteIDs []string `json:"returnedNoteIds"` // Drawn but unread notes put back in the pool
+}
+
+// Rollback moves the session back to an earlier phase:
+//
+//   - to JOINING every note is discarded, since the participant list may change
+//   - to WRITING notes are kept but all become unread so reading starts fresh
+//   - to READING (from COMPLETE) read notes stay read and reading carries on
+//     with the rest; it fails if every note has already been read
+//
+// In every case drawn-but-unread notes return to the pool, completion and
+// ratings are cleared, late joining closes and the undo window ends. Timers
+// outside the session, such as countdowns and auto-run steps, are the caller's
+// to cancel.
+func (s *Session) Rollback(target Phase) (RollbackResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rollbackUnlocked(target, map[string]interface{}{"rollback": true})
+}
+
+// rollbackUnlocked applies the rollback rules and records a phase change event
+// with detail added to it
+// Internal helper that assumes caller already holds the lock
+func (s *Session) rollbackUnlocked(target Phase, detail map[string]interface{}) (RollbackResult, error) {
+	result := RollbackResult{From: s.Phase, To: target, ReturnedNoteIDs: []string{}}
+
+	from, ok := phaseOrder[s.Phase]
+	if !ok {
+		return result, fmt.Errorf("cannot roll back from %s phase", s.Phase)
+	}
+	to, ok := phaseOrder[target]
+	if !ok {
+		return result, fmt.Errorf("cannot roll back to %s phase", target)
+	}
+	if to >= from {
+		return result, fmt.Errorf("%s is not before %s", target, s.Phase)
+	}
+
+	switch target {
+	case PhaseJoining:
+		result.NotesDiscarded = len(s.Notes)
+		s.Notes = []*Note{}
+	case PhaseWriting:
+		for _, note := range s.Notes {
+			if note.Read {
+				result.NotesUnread++
+			} else if note.DrawnAt != nil {
+				result.ReturnedNoteIDs = append(result.ReturnedNoteIDs, note.ID)
+			}
+			note.Read = false
+			note.Attendance = nil
+			note.RecipientMissed = false
+			note.DrawnAt = nil
+			note.ReadAt = nil
+			note.Reactions = nil
+			note.reactedBy = nil
+		}
+	case PhaseReading:
+		unread := false
+		for _, note := range s.Notes {
+			if !note.Read && !note.Private {
+				unread = true
+			}
+		}
+		if !unread {
+			return result, errors.New("every note has already been read")
+		}
+		for _, note := range s.Notes {
+			if !note.Read && note.DrawnAt != nil {
+				result.ReturnedNoteIDs = append(result.ReturnedNoteIDs, note.ID)
+				note.Attendance = nil
+				note.RecipientMissed = false
+				note.DrawnAt = nil
+				note.Reactions = nil
+				note.reactedBy = nil
+			}
+		}
+	}
+
+	if target != PhaseReading {
+		s.CurrentTurn = 0
+	}
+	if s.Phase == PhaseComplete {
+		s.Ratings = [5]int{}
+		s.ratedBy = nil
+	}
+	s.CompletedAt = nil
+	s.WritingReopened = false
+	s.PhaseChangedAt = time.Time{}
+	s.Phase = target
+
+	event := map[string]interface{}{"from": result.From, "to": result.To}
+	for key, value := range detail {
+		event[key] = value
+	}
+	s.recordEventUnlocked(EventPhaseChanged, "", event)
+	return result, nil
+}
@@ -0,0 +1,170 @@
+package session
+
+import (
+	"testing"
+)
+
+// sessionInPhase builds a three-person session in the given phase with every note written.
+// In reading one note has been read and another drawn; complete has every note read and a rating.
+func sessionInPhase(t *testing.T, phase Phase) *Session {
+	t.Helper()
+
+	sess := NewSession("Host")
+	sess.SetRatingsEnabled(true)
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	if phase == PhaseJoining {
+		return sess
+	}
+	if phase == PhaseBreakout {
+		sess.Phase = PhaseBreakout
+		return sess
+	}
+
+	sess.TransitionToWriting()
+	ids := []string{sess.HostID, alice.ID, bob.ID}
+	for _, author := range ids {
+		for _, recipient := range ids {
+			if author != recipient {
+				sess.AddNote(author, recipient, "Thank you")
+			}
+		}
+	}
+	if phase == PhaseWriting {
+		return sess
+	}
+
+	sess.TransitionToReading()
+	sess.MarkNoteAsRead(sess.Notes[0].ID)
+	sess.RecordNoteDrawn(sess.Notes[1].ID)
+	sess.RecordAttendance(sess.Notes[1].ID, ids)
+	if phase == PhaseReading {
+		return sess
+	}
+
+	for _, note := range sess.Notes {
+		note.Read = true
+	}
+	sess.AdvanceTurn()
+	sess.SubmitRating(alice.ID, 5)
+	if sess.Phase != PhaseComplete {
+		t.Fatalf("Expected complete session, got %s", sess.Phase)
+	}
+	return sess
+}
+
+func TestRollbackEveryTransition(t *testing.T) {
+	phases := []Phase{PhaseJoining, PhaseWriting, PhaseReading, PhaseComplete, PhaseBreakout}
+
+	// Allowed rollbacks; every other pair must be refused and leave the session untouched.
+	// Complete -> reading is refused here because every note has been read.
+	allowed := map[[2]Phase]bool{
+		{PhaseWriting, PhaseJoining}:  true,
+		{PhaseReading, PhaseJoining}:  true,
+		{PhaseReading, PhaseWriting}:  true,
+		{PhaseComplete, PhaseJoining}: true,
+		{PhaseComplete, PhaseWriting}: true,
+	}
+
+	for _, from := range phases {
+		for _, to := range phases {
+			t.Run(string(from)+"->"+string(to), func(t *testing.T) {
+				sess := sessionInPhase(t, from)
+				notes := len(sess.Notes)
+
+				result, err := sess.Rollback(to)
+				if !allowed[[2]Phase{from, to}] {
+					if err == nil {
+						t.Fatal("Expected rollback to be refused")
+					}
+					if sess.Phase != from || len(sess.Notes) != notes {
+						t.Errorf("Expected refused rollback to change nothing, got %s with %d notes", sess.Phase, len(sess.Notes))
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("Expected rollback to succeed: %v", err)
+				}
+				if sess.Phase != to || result.From != from || result.To != to {
+					t.Errorf("Expected %s -> %s, got phase %s result %+v", from, to, sess.Phase, result)
+				}
+				if sess.CompletedAt != nil || sess.CurrentTurn != 0 || !sess.PhaseChangedAt.IsZero() {
+					t.Error("Expected completion, turn and undo window to be reset")
+				}
+				if sess.GetRatingSummary().Count != 0 {
+					t.Error("Expected ratings to be cleared")
+				}
+
+				switch to {
+				case PhaseJoining:
+					if len(sess.Notes) != 0 || result.NotesDiscarded != notes {
+						t.Errorf("Expected %d notes discarded, got %d left and result %+v", notes, len(sess.Notes), result)
+					}
+				case PhaseWriting:
+					if len(sess.Notes) != notes {
+						t.Errorf("Expected notes kept, got %d", len(sess.Notes))
+					}
+					for _, note := range sess.Notes {
+						if note.Read || note.Attendance != nil || note.DrawnAt != nil {
+							t.Error("Expected every note unread and undrawn")
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRollbackCompleteToReading(t *testing.T) {
+	sess := sessionInPhase(t, PhaseComplete)
+
+	// An unread note left over, e.g. when nobody could read it
+	sess.Notes[2].Read = false
+	sess.RecordNoteDrawn(sess.Notes[2].ID)
+	sess.Notes[2].Attendance = []string{sess.HostID}
+
+	result, err := sess.Rollback(PhaseReading)
+	if err != nil {
+		t.Fatalf("Expected rollback to reading: %v", err)
+	}
+	if len(result.ReturnedNoteIDs) != 1 || result.ReturnedNoteIDs[0] != sess.Notes[2].ID {
+		t.Errorf("Expected drawn note returned, got %+v", result)
+	}
+	if !sess.Notes[0].Read || sess.Notes[2].Read {
+		t.Error("Expected read notes to stay read and the rest unread")
+	}
+	if _, err := sess.UndoTransition(); err == nil {
+		t.Error("Expected nothing to undo after a rollback")
+	}
+}
+
+func TestRollbackReportsReadingProgress(t *testing.T) {
+	sess := sessionInPhase(t, PhaseReading)
+
+	result, err := sess.Rollback(PhaseWriting)
+	if err != nil {
+		t.Fatalf("Expected rollback to writing: %v", err)
+	}
+	if result.NotesUnread != 1 || len(result.ReturnedNoteIDs) != 1 {
+		t.Errorf("Expected one read and one drawn note returned, got %+v", result)
+	}
+}
+
+func TestRollbackReturnsNoteDrawnWithNobodyAttending(t *testing.T) {
+	sess := sessionInPhase(t, PhaseWriting)
+	sess.TransitionToReading()
+
+	// Drawn while nobody was connected, so attendance is empty
+	note := sess.Notes[0]
+	sess.RecordNoteDrawn(note.ID)
+	sess.RecordAttendance(note.ID, nil)
+
+	result, err := sess.Rollback(PhaseWriting)
+	if err != nil {
+		t.Fatalf("Expected rollback to writing: %v", err)
+	}
+	if len(result.ReturnedNoteIDs) != 1 || result.ReturnedNoteIDs[0] != note.ID {
+		t.Errorf("Expected the drawn note returned, got %+v", result)
+	}
+}
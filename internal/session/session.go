@@ -7,9 +7,9 @@ import (
 	"encoding/base32"
 	"errors"
 	"sort"
-	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // Phase represents the current phase of a gratitude circle session
@@ -20,14 +20,24 @@ const (
 	PhaseWriting  Phase = "WRITING"
 	PhaseReading  Phase = "READING"
 	PhaseComplete Phase = "COMPLETE"
+
+	// PhaseBreakout marks a parent session whose participants have been
+	// split into breakout circles
+	PhaseBreakout Phase = "BREAKOUT"
 )
 
+// UndoWindow is how long after a phase transition the host may undo it
+const UndoWindow = 60 * time.Second
+
 // Participant represents a person in the session
 type Participant struct {
-	ID     string    `json:"id"`
-	Name   string    `json:"name"`
-	IsHost bool      `json:"isHost"`
-	JoinedAt time.Time `json:"joinedAt"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	IsHost    bool      `json:"isHost"`
+	Role      string    `json:"role,omitempty"` // Optional label shown beside the name, e.g. "Team lead"
+	JoinedAt  time.Time `json:"joinedAt"`
+	LatencyMs int       `json:"latencyMs,omitempty"` // Most recent round-trip time (0 = not yet measured)
+	Connected bool      `json:"connected"`           // Whether a client for this participant is connected (set by the hub)
 }
 
 // Note represents a gratitude note
@@ -37,68 +47,291 @@ type Note struct {
 	AuthorID    string `json:"authorId"`
 	RecipientID string `json:"recipientId"`
 	Read        bool   `json:"read"`
+	Private     bool   `json:"private"`          // Delivered only to the recipient at completion, never read aloud
+	GIFURL      string `json:"gifUrl,omitempty"` // Optional celebratory GIF shown with the note
+	Shareable   bool   `json:"shareable"`        // Author agreed to it appearing, anonymised, on a public gratitude wall
+
+	// Set when the recipient wasn't connected while the note was read aloud
+	RecipientMissed bool `json:"recipientMissed"`
+
+	// IDs of participants connected when the note was drawn and read aloud
+	Attendance []string `json:"attendance,omitempty"`
+
+	// When the note was drawn and finished being read aloud, for reading-pace analytics
+	DrawnAt *time.Time `json:"drawnAt,omitempty"`
+	ReadAt  *time.Time `json:"readAt,omitempty"`
+
+	// Emoji reaction counts from listeners while the note was read aloud
+	Reactions map[string]int  `json:"reactions,omitempty"`
+	reactedBy map[string]bool // "participantID emoji" pairs already counted
+
+	SubmittedAt time.Time  `json:"submittedAt"`
+	EditedAt    *time.Time `json:"editedAt,omitempty"` // Last time the author changed the content while writing
+}
+
+// PushDevice is a device a participant registered for push notifications
+type PushDevice struct {
+	Platform string `json:"platform"` // Push provider, e.g. fcm or apns
+	Token    string `json:"-"`        // Provider device token, never sent to other clients
+}
+
+// MaxPushDevices is the most devices one participant may register
+const MaxPushDevices = 5
+
+// RatingSummary aggregates the anonymous 1-5 ratings given at completion
+type RatingSummary struct {
+	Count        int     `json:"count"`
+	Average      float64 `json:"average"`
+	Distribution [5]int  `json:"distribution"` // Distribution[0] counts 1s, Distribution[4] counts 5s
 }
 
 // Session represents a gratitude circle session
 type Session struct {
-	ID           string                  `json:"id"`
-	Code         string                  `json:"code"`
-	Phase        Phase                   `json:"phase"`
-	Participants map[string]*Participant `json:"participants"`
-	Notes        []*Note                 `json:"notes"`
-	CreatedAt    time.Time               `json:"createdAt"`
-	CompletedAt  *time.Time              `json:"completedAt,omitempty"`
-	HostID       string                  `json:"hostId"`
-	CurrentTurn  int                     `json:"currentTurn"` // Index of current reader
-	mu           sync.RWMutex
-}
-
-// NewSession creates a new session with a unique code
+	ID              string                  `json:"id"`
+	Code            string                  `json:"code"`
+	Title           string                  `json:"title"`
+	Welcome         string                  `json:"welcome"`
+	Prompt          string                  `json:"prompt"`      // What everyone is asked to write about (empty = none)
+	AutoStartAt     int                     `json:"autoStartAt"` // Participant count that starts writing automatically (0 = disabled)
+	Countdown       int                     `json:"countdown"`   // Seconds to count down before phase transitions (0 = immediate)
+	Settings        SessionSettings         `json:"settings"`
+	MinNoteChars    int                     `json:"minNoteChars"` // Minimum characters per note (0 = no minimum)
+	MinNoteWords    int                     `json:"minNoteWords"` // Minimum words per note (0 = no minimum)
+	AutoRun         bool                    `json:"autoRun"`      // Server advances phases and turns on timers
+	RatingsEnabled  bool                    `json:"ratingsEnabled"`
+	DuplicatePolicy DuplicatePolicy         `json:"duplicatePolicy"` // How near-identical notes from one author are handled
+	Theme           string                  `json:"theme,omitempty"` // Catalog theme ID the circle is framed around (empty = none)
+	WritingReopened bool                    `json:"writingReopened"` // Writing was reopened from reading, so late joiners are allowed
+	Ratings         [5]int                  `json:"ratings"`         // Count of each 1-5 rating, stored without who gave it
+	Phase           Phase                   `json:"phase"`
+	Participants    map[string]*Participant `json:"participants"`
+	Notes           []*Note                 `json:"notes"`
+	CreatedAt       time.Time               `json:"createdAt"`
+	CompletedAt     *time.Time              `json:"completedAt,omitempty"`
+	HostID          string                  `json:"hostId"`
+	CurrentTurn     int                     `json:"currentTurn"`           // Index of current reader
+	Version         uint64                  `json:"version"`               // Bumped on every phase change and turn advance, for optimistic concurrency
+	PhaseChangedAt  time.Time               `json:"phaseChangedAt"`        // When the last undoable phase transition happened (zero if none)
+	ParentID        string                  `json:"parentId,omitempty"`    // Parent session ID if this is a breakout circle
+	Tenant          string                  `json:"tenant,omitempty"`      // Branding tenant the host created the session under
+	BreakoutIDs     []string                `json:"breakoutIds,omitempty"` // Breakout circle IDs if this session was split
+	ratedBy         map[string]bool         // Who has rated, kept apart from the scores
+	pushDevices     map[string][]PushDevice // participantID -> devices registered for push
+	timeline        []TimelineEvent         // What happened when, oldest first
+	hostKey         string                  // Links circles run by the same facilitator; never sent to clients
+	rejoinTokens    map[string]string       // Rejoin token -> participant ID; never sent to other clients
+	departed        map[string]departure    // participantID -> who left recently, for rejoining in place
+	mu              sync.RWMutex
+}
+
+// NewSession creates a new session hosted by hostName
+// It has no code until a Manager adds it, which gives it one in the manager's CodeFormat
 func NewSession(hostName string) *Session {
-	code := generateSessionCode()
 	hostID := generateID()
 
 	host := &Participant{
-		ID:     hostID,
-		Name:   hostName,
-		IsHost: true,
+		ID:       hostID,
+		Name:     hostName,
+		IsHost:   true,
 		JoinedAt: time.Now(),
 	}
 
-	return &Session{
-		ID:           generateID(),
-		Code:         code,
-		Phase:        PhaseJoining,
-		Participants: map[string]*Participant{hostID: host},
-		Notes:        []*Note{},
-		CreatedAt:    time.Now(),
-		HostID:       hostID,
-		CurrentTurn:  0,
+	s := &Session{
+		ID:              generateID(),
+		Phase:           PhaseJoining,
+		Participants:    map[string]*Participant{hostID: host},
+		Notes:           []*Note{},
+		CreatedAt:       time.Now(),
+		HostID:          hostID,
+		CurrentTurn:     0,
+		DuplicatePolicy: DuplicateOff,
+		Settings:        DefaultSettings(),
 	}
+	s.recordEventUnlocked(EventSessionCreated, hostID, map[string]interface{}{"name": hostName})
+	return s
 }
 
 // AddParticipant adds a new participant to the session
+// Returns a *SessionFullError once the session has as many participants as its settings allow
 func (s *Session) AddParticipant(name string) (*Participant, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.Phase != PhaseJoining {
+	if s.Phase != PhaseJoining && !(s.Phase == PhaseWriting && (s.WritingReopened || s.Settings.AllowLateJoin)) {
 		return nil, errors.New("cannot join: session has already started")
 	}
+	if err := s.checkRoomUnlocked(1); err != nil {
+		return nil, err
+	}
+
+	name, err := s.uniqueNameUnlocked(name, "")
+	if err != nil {
+		return nil, err
+	}
 
 	participant := &Participant{
-		ID:     generateID(),
-		Name:   name,
-		IsHost: false,
+		ID:       generateID(),
+		Name:     name,
+		IsHost:   false,
 		JoinedAt: time.Now(),
 	}
 
 	s.Participants[participant.ID] = participant
+	s.recordEventUnlocked(EventJoined, participant.ID, map[string]interface{}{"name": name})
 	return participant, nil
 }
 
-// AddNote adds a gratitude note to the session
+// SetTitle sets the display title shown alongside the session code
+func (s *Session) SetTitle(title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Title = title
+}
+
+// SetWelcome sets the welcome/instructions blurb delivered to joiners
+func (s *Session) SetWelcome(welcome string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Welcome = welcome
+}
+
+// SetPrompt sets the writing prompt every participant is shown
+func (s *Session) SetPrompt(prompt string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Prompt = prompt
+}
+
+// SetAutoStartAt sets the participant count at which writing starts automatically
+// A threshold of 0 disables auto-start
+func (s *Session) SetAutoStartAt(threshold int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.AutoStartAt = threshold
+}
+
+// SetCountdown sets the number of seconds to count down before phase transitions
+func (s *Session) SetCountdown(seconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Countdown = seconds
+}
+
+// SetMinNoteLength sets the minimum character and word counts for notes in this session
+func (s *Session) SetMinNoteLength(chars, words int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.MinNoteChars = chars
+	s.MinNoteWords = words
+}
+
+// SetAutoRun enables or disables facilitator-less auto-run mode
+func (s *Session) SetAutoRun(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.AutoRun = enabled
+}
+
+// SetRatingsEnabled enables or disables the rating request at completion
+func (s *Session) SetRatingsEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.RatingsEnabled = enabled
+}
+
+// SetTenant records which tenant's branding the session uses
+func (s *Session) SetTenant(tenant string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Tenant = tenant
+}
+
+// SetHostKey links the session to its facilitator's history
+func (s *Session) SetHostKey(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hostKey = key
+}
+
+// HostKey returns the facilitator key the session was created with, if any
+func (s *Session) HostKey() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.hostKey
+}
+
+// NoteStats returns how many notes were written and how many participants wrote them
+func (s *Session) NoteStats() (notes, authors int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	for _, note := range s.Notes {
+		seen[note.AuthorID] = true
+	}
+	return len(s.Notes), len(seen)
+}
+
+// Summary is a session's state without identifiers or note content, safe to show
+// anyone who knows its code
+type Summary struct {
+	Code         string     `json:"sessionCode"`
+	Title        string     `json:"title"`
+	Phase        Phase      `json:"phase"`
+	Participants int        `json:"participants"`
+	Notes        int        `json:"notes"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+}
+
+// Summarize returns the session's summary
+func (s *Session) Summarize() Summary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return Summary{
+		Code:         s.Code,
+		Title:        s.Title,
+		Phase:        s.Phase,
+		Participants: len(s.Participants),
+		Notes:        len(s.Notes),
+		CreatedAt:    s.CreatedAt,
+		CompletedAt:  s.CompletedAt,
+	}
+}
+
+// ShouldAutoStart reports whether enough participants have joined to start writing automatically
+func (s *Session) ShouldAutoStart() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.Phase == PhaseJoining && s.AutoStartAt > 0 && len(s.Participants) >= s.AutoStartAt
+}
+
+// AddNote adds a gratitude note to the session to be read aloud
 func (s *Session) AddNote(authorID, recipientID, content string) error {
+	return s.addNote(authorID, recipientID, content, false)
+}
+
+// AddPrivateNote adds a gratitude note that is delivered only to its recipient
+// at completion instead of being read aloud
+func (s *Session) AddPrivateNote(authorID, recipientID, content string) error {
+	return s.addNote(authorID, recipientID, content, true)
+}
+
+// addNote adds a gratitude note with the given delivery preference
+func (s *Session) addNote(authorID, recipientID, content string, private bool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -134,17 +367,40 @@ func (s *Session) AddNote(authorID, recipientID, content string) error {
 		AuthorID:    authorID,
 		RecipientID: recipientID,
 		Read:        false,
+		Private:     private,
+		SubmittedAt: time.Now(),
 	}
 
 	s.Notes = append(s.Notes, note)
 	return nil
 }
 
+// CanTransitionToWriting checks whether the session could move to writing phase now
+func (s *Session) CanTransitionToWriting() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.checkTransitionToWritingUnlocked()
+}
+
 // TransitionToWriting moves the session to writing phase
 func (s *Session) TransitionToWriting() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.checkTransitionToWritingUnlocked(); err != nil {
+		return err
+	}
+
+	s.Phase = PhaseWriting
+	s.PhaseChangedAt = time.Now()
+	s.recordPhaseChangeUnlocked(PhaseJoining, PhaseWriting)
+	return nil
+}
+
+// checkTransitionToWritingUnlocked validates the joining -> writing transition
+// Internal helper that assumes caller already holds a lock
+func (s *Session) checkTransitionToWritingUnlocked() error {
 	if s.Phase != PhaseJoining {
 		return errors.New("can only transition to writing from joining phase")
 	}
@@ -153,7 +409,6 @@ func (s *Session) TransitionToWriting() error {
 		return errors.New("need at least 2 participants to start")
 	}
 
-	s.Phase = PhaseWriting
 	return nil
 }
 
@@ -167,29 +422,226 @@ func (s *Session) TransitionToReading() error {
 	}
 
 	// Verify all notes have been written
-	expectedNotes := len(s.Participants) * (len(s.Participants) - 1)
-	if len(s.Notes) != expectedNotes {
+	if !s.allNotesWrittenUnlocked() {
 		return errors.New("not all notes have been written")
 	}
 
 	s.Phase = PhaseReading
+	s.WritingReopened = false
+	s.PhaseChangedAt = time.Now()
+	s.recordPhaseChangeUnlocked(PhaseWriting, PhaseReading)
 	return nil
 }
 
-// GetUnreadNotes returns notes that haven't been read yet
+// UndoTransition rolls the session back one phase if the last transition
+// happened within UndoWindow, returning the restored phase.
+// See Rollback for how notes and turns are reconciled.
+func (s *Session) UndoTransition() (Phase, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.PhaseChangedAt.IsZero() || time.Since(s.PhaseChangedAt) > UndoWindow {
+		return s.Phase, errors.New("too late to undo the last phase change")
+	}
+
+	var target Phase
+	switch s.Phase {
+	case PhaseReading:
+		target = PhaseWriting
+	case PhaseWriting:
+		target = PhaseJoining
+	default:
+		return s.Phase, errors.New("cannot undo from this phase")
+	}
+
+	// Only one step back is allowed, which the rollback ensures by ending the window
+	if _, err := s.rollbackUnlocked(target, map[string]interface{}{"undo": true}); err != nil {
+		return s.Phase, err
+	}
+	return s.Phase, nil
+}
+
+// AllNotesWritten reports whether every participant has written to every other participant
+func (s *Session) AllNotesWritten() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.allNotesWrittenUnlocked()
+}
+
+// allNotesWrittenUnlocked counts notes between current participants, so notes to or
+// from someone who has left don't make up for one still to be written
+// Internal helper that assumes caller already holds a lock
+func (s *Session) allNotesWrittenUnlocked() bool {
+	written := 0
+	for _, note := range s.Notes {
+		_, fromParticipant := s.Participants[note.AuthorID]
+		_, toParticipant := s.Participants[note.RecipientID]
+		if fromParticipant && toParticipant {
+			written++
+		}
+	}
+	return written == len(s.Participants)*(len(s.Participants)-1)
+}
+
+// GetReceivedNoteCounts returns how many notes each participant will receive
+// Every participant is included, so anyone with zero notes shows up explicitly
+func (s *Session) GetReceivedNoteCounts() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int, len(s.Participants))
+	for id := range s.Participants {
+		counts[id] = 0
+	}
+	for _, note := range s.Notes {
+		if _, exists := counts[note.RecipientID]; exists {
+			counts[note.RecipientID]++
+		}
+	}
+	return counts
+}
+
+// NotePair is an author and recipient a note could be written between
+type NotePair struct {
+	AuthorID    string `json:"authorId"`
+	RecipientID string `json:"recipientId"`
+}
+
+// NotePoolStatus summarises the notes written so far, without content
+type NotePoolStatus struct {
+	Received     map[string]int `json:"received"` // Participant ID -> notes addressed to them
+	Written      map[string]int `json:"written"`  // Participant ID -> notes they wrote
+	MissingPairs []NotePair     `json:"missingPairs"`
+	TotalNotes   int            `json:"totalNotes"`
+}
+
+// GetNotePoolStatus returns note counts per recipient and per author, and which
+// author/recipient pairs have no note yet; every participant is included with zero counts
+func (s *Session) GetNotePoolStatus() NotePoolStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := NotePoolStatus{
+		Received:     make(map[string]int, len(s.Participants)),
+		Written:      make(map[string]int, len(s.Participants)),
+		MissingPairs: []NotePair{},
+		TotalNotes:   len(s.Notes),
+	}
+
+	written := make(map[NotePair]bool, len(s.Notes))
+	for _, note := range s.Notes {
+		written[NotePair{AuthorID: note.AuthorID, RecipientID: note.RecipientID}] = true
+	}
+
+	participants := s.getParticipantsSorted()
+	for _, p := range participants {
+		status.Received[p.ID] = 0
+		status.Written[p.ID] = 0
+	}
+	for _, author := range participants {
+		for _, recipient := range participants {
+			if author.ID == recipient.ID {
+				continue
+			}
+			pair := NotePair{AuthorID: author.ID, RecipientID: recipient.ID}
+			if written[pair] {
+				status.Written[author.ID]++
+				status.Received[recipient.ID]++
+			} else {
+				status.MissingPairs = append(status.MissingPairs, pair)
+			}
+		}
+	}
+	return status
+}
+
+// AuthorStats is how far one participant has got with writing, without content
+type AuthorStats struct {
+	ParticipantID   string     `json:"participantId"`
+	NotesSubmitted  int        `json:"notesSubmitted"`
+	LastSubmittedAt *time.Time `json:"lastSubmittedAt,omitempty"`
+}
+
+// WritingStats aggregates writing progress across the session
+type WritingStats struct {
+	Authors         []AuthorStats `json:"authors"` // Every participant, ordered by ID
+	TotalNotes      int           `json:"totalNotes"`
+	ExpectedNotes   int           `json:"expectedNotes"` // One note from each participant to each other
+	AverageLength   float64       `json:"averageLength"` // Characters per note
+	LastSubmittedAt *time.Time    `json:"lastSubmittedAt,omitempty"`
+}
+
+// GetWritingStats returns aggregate writing progress; note content never leaves the session
+func (s *Session) GetWritingStats() WritingStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := WritingStats{
+		Authors:       make([]AuthorStats, 0, len(s.Participants)),
+		TotalNotes:    len(s.Notes),
+		ExpectedNotes: len(s.Participants) * (len(s.Participants) - 1),
+	}
+
+	byAuthor := make(map[string]*AuthorStats, len(s.Participants))
+	for _, p := range s.getParticipantsSorted() {
+		stats.Authors = append(stats.Authors, AuthorStats{ParticipantID: p.ID})
+	}
+	for i := range stats.Authors {
+		byAuthor[stats.Authors[i].ParticipantID] = &stats.Authors[i]
+	}
+
+	characters := 0
+	for _, note := range s.Notes {
+		characters += utf8.RuneCountInString(note.Content)
+		submitted := note.SubmittedAt
+
+		if stats.LastSubmittedAt == nil || submitted.After(*stats.LastSubmittedAt) {
+			stats.LastSubmittedAt = &submitted
+		}
+		author, exists := byAuthor[note.AuthorID]
+		if !exists {
+			continue
+		}
+		author.NotesSubmitted++
+		if author.LastSubmittedAt == nil || submitted.After(*author.LastSubmittedAt) {
+			author.LastSubmittedAt = &submitted
+		}
+	}
+	if len(s.Notes) > 0 {
+		stats.AverageLength = float64(characters) / float64(len(s.Notes))
+	}
+	return stats
+}
+
+// GetUnreadNotes returns read-aloud notes that haven't been read yet
 func (s *Session) GetUnreadNotes() []*Note {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	unread := []*Note{}
 	for _, note := range s.Notes {
-		if !note.Read {
+		if !note.Read && !note.Private {
 			unread = append(unread, note)
 		}
 	}
 	return unread
 }
 
+// GetReadAloudNoteCount returns how many notes go through the reading rotation
+func (s *Session) GetReadAloudNoteCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, note := range s.Notes {
+		if !note.Private {
+			count++
+		}
+	}
+	return count
+}
+
 // GetAvailableNotesForReader returns notes that the reader can read
 // (not authored by them, and in 3+ person sessions, not addressed to them)
 // Note: In 2-person sessions, readers CAN read notes written to them
@@ -213,6 +665,11 @@ func (s *Session) getAvailableNotesForReaderUnlocked(readerID string) []*Note {
 			continue
 		}
 
+		// Private notes are delivered at completion, not read aloud
+		if note.Private {
+			continue
+		}
+
 		// Never read notes you authored
 		if note.AuthorID == readerID {
 			continue
@@ -236,7 +693,33 @@ func (s *Session) MarkNoteAsRead(noteID string) error {
 
 	for _, note := range s.Notes {
 		if note.ID == noteID {
+			now := time.Now()
 			note.Read = true
+			note.ReadAt = &now
+			s.recordEventUnlocked(EventNoteRead, "", map[string]interface{}{
+				"noteId":      note.ID,
+				"recipientId": note.RecipientID,
+			})
+			return nil
+		}
+	}
+
+	return errors.New("note not found")
+}
+
+// AttachNoteGIF attaches a GIF to the note an author wrote to a recipient
+// The URL must already be validated against the GIF provider by the caller
+func (s *Session) AttachNoteGIF(authorID, recipientID, gifURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Phase != PhaseWriting {
+		return errors.New("cannot attach gif: not in writing phase")
+	}
+
+	for _, note := range s.Notes {
+		if note.AuthorID == authorID && note.RecipientID == recipientID {
+			note.GIFURL = gifURL
 			return nil
 		}
 	}
@@ -244,6 +727,109 @@ func (s *Session) MarkNoteAsRead(noteID string) error {
 	return errors.New("note not found")
 }
 
+// MarkNoteShareable records the author's consent to the note they wrote to a recipient
+// being published on a public gratitude wall
+func (s *Session) MarkNoteShareable(authorID, recipientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Phase != PhaseWriting {
+		return errors.New("cannot share note: not in writing phase")
+	}
+
+	for _, note := range s.Notes {
+		if note.AuthorID == authorID && note.RecipientID == recipientID {
+			if note.Private {
+				return errors.New("private notes can't be shared")
+			}
+			note.Shareable = true
+			return nil
+		}
+	}
+
+	return errors.New("note not found")
+}
+
+// GetShareableNotes returns read-aloud notes whose authors agreed to public sharing
+func (s *Session) GetShareableNotes() []*Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notes := []*Note{}
+	for _, note := range s.Notes {
+		if note.Shareable && !note.Private {
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// GetReadAloudNotes returns copies of the notes read to the whole circle, leaving out private ones
+func (s *Session) GetReadAloudNotes() []Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notes := []Note{}
+	for _, note := range s.Notes {
+		if !note.Private {
+			notes = append(notes, *note)
+		}
+	}
+	return notes
+}
+
+// RecordRecipientPresence records whether a note's recipient was connected when it was read aloud
+func (s *Session) RecordRecipientPresence(noteID string, connected bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, note := range s.Notes {
+		if note.ID == noteID {
+			note.RecipientMissed = !connected
+			return nil
+		}
+	}
+
+	return errors.New("note not found")
+}
+
+// RecordAttendance records which participants were connected when a note was read aloud
+func (s *Session) RecordAttendance(noteID string, participantIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, note := range s.Notes {
+		if note.ID == noteID {
+			note.Attendance = append([]string(nil), participantIDs...)
+			return nil
+		}
+	}
+
+	return errors.New("note not found")
+}
+
+// GetMissedNotes returns read-aloud notes addressed to the recipient that they weren't connected to hear
+func (s *Session) GetMissedNotes(recipientID string) []*Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	missed := []*Note{}
+	for _, note := range s.Notes {
+		if note.RecipientID == recipientID && note.RecipientMissed {
+			missed = append(missed, note)
+		}
+	}
+	return missed
+}
+
+// GetPhase returns the session's current phase
+func (s *Session) GetPhase() Phase {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.Phase
+}
+
 // GetCurrentReader returns the participant whose turn it is to read
 func (s *Session) GetCurrentReader() *Participant {
 	s.mu.RLock()
@@ -288,6 +874,9 @@ func (s *Session) AdvanceTurn() {
 		availableNotes := s.getAvailableNotesForReaderUnlocked(currentReader.ID)
 		if len(availableNotes) > 0 {
 			// Found a reader with available notes
+			s.recordEventUnlocked(EventTurnChanged, currentReader.ID, map[string]interface{}{
+				"turn": s.CurrentTurn,
+			})
 			return
 		}
 
@@ -298,7 +887,7 @@ func (s *Session) AdvanceTurn() {
 	// check if all notes are actually read
 	allRead := true
 	for _, note := range s.Notes {
-		if !note.Read {
+		if !note.Read && !note.Private {
 			allRead = false
 			break
 		}
@@ -314,19 +903,263 @@ func (s *Session) AdvanceTurn() {
 		s.Phase = PhaseComplete
 		s.CompletedAt = &now
 	}
+	s.recordEventUnlocked(EventPhaseChanged, "", map[string]interface{}{
+		"from":       PhaseReading,
+		"to":         PhaseComplete,
+		"unreadLeft": !allRead,
+	})
+}
+
+// SubmitRating records an anonymous 1-5 rating of a completed session
+// Each participant may rate once; only the score is stored, not who gave it
+func (s *Session) SubmitRating(participantID string, score int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.RatingsEnabled {
+		return errors.New("ratings are not enabled for this session")
+	}
+
+	if s.Phase != PhaseComplete {
+		return errors.New("can only rate a completed session")
+	}
+
+	if _, exists := s.Participants[participantID]; !exists {
+		return errors.New("participant not found")
+	}
+
+	if score < 1 || score > 5 {
+		return errors.New("rating must be between 1 and 5")
+	}
+
+	if s.ratedBy == nil {
+		s.ratedBy = make(map[string]bool)
+	}
+	if s.ratedBy[participantID] {
+		return errors.New("already rated this session")
+	}
+
+	s.ratedBy[participantID] = true
+	s.Ratings[score-1]++
+	return nil
+}
+
+// GetRatingSummary returns the aggregated ratings for the session
+func (s *Session) GetRatingSummary() RatingSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summary := RatingSummary{Distribution: s.Ratings}
+	total := 0
+	for i, count := range s.Ratings {
+		summary.Count += count
+		total += (i + 1) * count
+	}
+	if summary.Count > 0 {
+		summary.Average = float64(total) / float64(summary.Count)
+	}
+	return summary
 }
 
-// RemoveParticipant removes a participant from the session
+// RemoveParticipant removes a participant who has left the session
 func (s *Session) RemoveParticipant(participantID string) (*Participant, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	participant, err := s.removeParticipantUnlocked(participantID)
+	if err != nil {
+		return nil, err
+	}
+	s.recordEventUnlocked(EventLeft, participantID, map[string]interface{}{"name": participant.Name})
+	return participant, nil
+}
+
+// KickParticipant removes a participant on someone else's say-so, such as "host" or "admin"
+func (s *Session) KickParticipant(participantID, by string) (*Participant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	participant, err := s.removeParticipantUnlocked(participantID)
+	if err != nil {
+		return nil, err
+	}
+	s.recordEventUnlocked(EventKicked, participantID, map[string]interface{}{"name": participant.Name, "by": by})
+	return participant, nil
+}
+
+// removeParticipantUnlocked takes a participant out, remembering them for rejoining
+// Internal helper that assumes caller already holds the lock
+func (s *Session) removeParticipantUnlocked(participantID string) (*Participant, error) {
 	participant, exists := s.Participants[participantID]
 	if !exists {
 		return nil, errors.New("participant not found")
 	}
 
 	delete(s.Participants, participantID)
+	delete(s.pushDevices, participantID)
+	s.recordDepartureUnlocked(participant)
+	return participant, nil
+}
+
+// RegisterPushDevice registers a device to receive push notifications for a participant
+// Registering a token again replaces its platform
+func (s *Session) RegisterPushDevice(participantID, platform, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.Participants[participantID]; !exists {
+		return errors.New("participant not found")
+	}
+
+	if s.pushDevices == nil {
+		s.pushDevices = make(map[string][]PushDevice)
+	}
+
+	devices := s.pushDevices[participantID]
+	for i, device := range devices {
+		if device.Token == token {
+			devices[i].Platform = platform
+			return nil
+		}
+	}
+
+	if len(devices) >= MaxPushDevices {
+		return errors.New("too many push devices registered")
+	}
+	s.pushDevices[participantID] = append(devices, PushDevice{Platform: platform, Token: token})
+	return nil
+}
+
+// UnregisterPushDevice removes a participant's push device
+func (s *Session) UnregisterPushDevice(participantID, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	devices := s.pushDevices[participantID]
+	for i, device := range devices {
+		if device.Token == token {
+			s.pushDevices[participantID] = append(devices[:i], devices[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("push device not found")
+}
+
+// GetPushDevices returns the devices a participant registered for push notifications
+func (s *Session) GetPushDevices(participantID string) []PushDevice {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	devices := make([]PushDevice, len(s.pushDevices[participantID]))
+	copy(devices, s.pushDevices[participantID])
+	return devices
+}
+
+// IsHostParticipant reports whether a participant is the session host
+func (s *Session) IsHostParticipant(participantID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.HostID == participantID
+}
+
+// ReassignHost makes the first remaining participant the host
+// Returns the new host, or nil if no participants remain
+func (s *Session) ReassignHost() *Participant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.Participants {
+		p.IsHost = true
+		s.HostID = p.ID
+		return p
+	}
+	return nil
+}
+
+// SetParticipantLatency records a participant's latest round-trip time
+func (s *Session) SetParticipantLatency(participantID string, latencyMs int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	participant, exists := s.Participants[participantID]
+	if !exists {
+		return errors.New("participant not found")
+	}
+
+	participant.LatencyMs = latencyMs
+	return nil
+}
+
+// SetParticipantConnected records whether a participant has a connected client,
+// reporting whether that changed
+func (s *Session) SetParticipantConnected(participantID string, connected bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	participant, exists := s.Participants[participantID]
+	if !exists {
+		return false, errors.New("participant not found")
+	}
+
+	changed := participant.Connected != connected
+	participant.Connected = connected
+	return changed, nil
+}
+
+// RenameParticipant changes a participant's display name
+func (s *Session) RenameParticipant(participantID, name string) (*Participant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	participant, exists := s.Participants[participantID]
+	if !exists {
+		return nil, errors.New("participant not found")
+	}
+
+	name, err := s.uniqueNameUnlocked(name, participantID)
+	if err != nil {
+		return nil, err
+	}
+
+	participant.Name = name
+	return participant, nil
+}
+
+// ChangeName lets a participant change their own name before writing starts
+func (s *Session) ChangeName(participantID, name string) (*Participant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Phase != PhaseJoining {
+		return nil, errors.New("cannot change name: session has already started")
+	}
+
+	participant, exists := s.Participants[participantID]
+	if !exists {
+		return nil, errors.New("participant not found")
+	}
+
+	name, err := s.uniqueNameUnlocked(name, participantID)
+	if err != nil {
+		return nil, err
+	}
+
+	participant.Name = name
+	return participant, nil
+}
+
+// SetParticipantRole sets the label shown beside a participant's name ("" = none)
+func (s *Session) SetParticipantRole(participantID, role string) (*Participant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	participant, exists := s.Participants[participantID]
+	if !exists {
+		return nil, errors.New("participant not found")
+	}
+
+	participant.Role = role
 	return participant, nil
 }
 
@@ -368,19 +1201,6 @@ func (s *Session) getParticipantsSorted() []*Participant {
 	return participants
 }
 
-// generateSessionCode generates a short, memorable session code
-func generateSessionCode() string {
-	b := make([]byte, 4)
-	rand.Read(b)
-	code := base32.StdEncoding.EncodeToString(b)
-	// Remove padding and limit to 6 characters
-	code = strings.TrimRight(code, "=")
-	if len(code) > 6 {
-		code = code[:6]
-	}
-	return code
-}
-
 // generateID generates a unique identifier
 func generateID() string {
 	b := make([]byte, 16)
@@ -1,6 +1,8 @@
 package session
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -13,12 +15,8 @@ func TestNewSession(t *testing.T) {
 		t.Error("Expected session ID to be generated")
 	}
 
-	if sess.Code == "" {
-		t.Error("Expected session code to be generated")
-	}
-
-	if len(sess.Code) > 6 {
-		t.Errorf("Session code too long: got %d characters, max 6", len(sess.Code))
+	if sess.Code != "" {
+		t.Errorf("Expected no code until a manager adds the session, got %s", sess.Code)
 	}
 
 	if sess.Phase != PhaseJoining {
@@ -85,6 +83,61 @@ func TestAddParticipant(t *testing.T) {
 	}
 }
 
+func TestSetTitle(t *testing.T) {
+	sess := NewSession("Host")
+
+	if sess.Title != "" {
+		t.Errorf("Expected new session to have no title, got %s", sess.Title)
+	}
+
+	sess.SetTitle("Q3 Platform Retro Gratitude")
+	if sess.Title != "Q3 Platform Retro Gratitude" {
+		t.Errorf("Expected title to be set, got %s", sess.Title)
+	}
+
+	sess.SetTitle("")
+	if sess.Title != "" {
+		t.Errorf("Expected title to be cleared, got %s", sess.Title)
+	}
+}
+
+func TestSetWelcome(t *testing.T) {
+	sess := NewSession("Host")
+
+	if sess.Welcome != "" {
+		t.Errorf("Expected new session to have no welcome message, got %s", sess.Welcome)
+	}
+
+	sess.SetWelcome("Think about the last quarter")
+	if sess.Welcome != "Think about the last quarter" {
+		t.Errorf("Expected welcome message to be set, got %s", sess.Welcome)
+	}
+}
+
+func TestShouldAutoStart(t *testing.T) {
+	sess := NewSession("Host")
+	sess.AddParticipant("Alice")
+
+	if sess.ShouldAutoStart() {
+		t.Error("Expected auto-start to be disabled by default")
+	}
+
+	sess.SetAutoStartAt(3)
+	if sess.ShouldAutoStart() {
+		t.Error("Expected no auto-start below threshold")
+	}
+
+	sess.AddParticipant("Bob")
+	if !sess.ShouldAutoStart() {
+		t.Error("Expected auto-start once threshold is reached")
+	}
+
+	sess.TransitionToWriting()
+	if sess.ShouldAutoStart() {
+		t.Error("Expected no auto-start after writing phase started")
+	}
+}
+
 func TestTransitionToWriting(t *testing.T) {
 	sess := NewSession("Host")
 	sess.AddParticipant("Alice")
@@ -99,6 +152,23 @@ func TestTransitionToWriting(t *testing.T) {
 	}
 }
 
+func TestCanTransitionToWriting(t *testing.T) {
+	sess := NewSession("Host")
+
+	if err := sess.CanTransitionToWriting(); err == nil {
+		t.Error("Expected error with only one participant")
+	}
+
+	sess.AddParticipant("Alice")
+	if err := sess.CanTransitionToWriting(); err != nil {
+		t.Errorf("Expected transition to be possible: %v", err)
+	}
+
+	if sess.Phase != PhaseJoining {
+		t.Errorf("Expected check not to change phase, got %s", sess.Phase)
+	}
+}
+
 func TestAddNote(t *testing.T) {
 	sess := NewSession("Host")
 	alice, _ := sess.AddParticipant("Alice")
@@ -132,6 +202,35 @@ func TestAddNote(t *testing.T) {
 	}
 }
 
+func TestGetReceivedNoteCounts(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.TransitionToWriting()
+
+	sess.AddNote(sess.HostID, alice.ID, "Host to Alice")
+	sess.AddNote(bob.ID, alice.ID, "Bob to Alice")
+	sess.AddNote(alice.ID, sess.HostID, "Alice to Host")
+
+	counts := sess.GetReceivedNoteCounts()
+
+	if len(counts) != 3 {
+		t.Fatalf("Expected counts for 3 participants, got %d", len(counts))
+	}
+
+	if counts[alice.ID] != 2 {
+		t.Errorf("Expected Alice to receive 2 notes, got %d", counts[alice.ID])
+	}
+
+	if counts[sess.HostID] != 1 {
+		t.Errorf("Expected host to receive 1 note, got %d", counts[sess.HostID])
+	}
+
+	if count, exists := counts[bob.ID]; !exists || count != 0 {
+		t.Errorf("Expected Bob to be listed with 0 notes, got %d (exists=%v)", count, exists)
+	}
+}
+
 func TestTransitionToReading(t *testing.T) {
 	sess := NewSession("Host")
 	alice, _ := sess.AddParticipant("Alice")
@@ -156,6 +255,95 @@ func TestTransitionToReading(t *testing.T) {
 	}
 }
 
+func TestUndoTransitionFromWriting(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Note 1")
+
+	phase, err := sess.UndoTransition()
+	if err != nil {
+		t.Fatalf("Failed to undo transition: %v", err)
+	}
+
+	if phase != PhaseJoining || sess.Phase != PhaseJoining {
+		t.Errorf("Expected phase to be JOINING, got %s", sess.Phase)
+	}
+
+	if len(sess.Notes) != 0 {
+		t.Errorf("Expected notes to be discarded, got %d", len(sess.Notes))
+	}
+
+	// Participants can join again
+	if _, err := sess.AddParticipant("Bob"); err != nil {
+		t.Errorf("Expected to be able to join after undo: %v", err)
+	}
+
+	// Only one step back is allowed
+	if _, err := sess.UndoTransition(); err == nil {
+		t.Error("Expected error when undoing twice")
+	}
+}
+
+func TestUndoTransitionFromReading(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Note 1")
+	sess.AddNote(alice.ID, sess.HostID, "Note 2")
+	sess.TransitionToReading()
+	sess.MarkNoteAsRead(sess.Notes[0].ID)
+	sess.CurrentTurn = 1
+
+	phase, err := sess.UndoTransition()
+	if err != nil {
+		t.Fatalf("Failed to undo transition: %v", err)
+	}
+
+	if phase != PhaseWriting {
+		t.Errorf("Expected phase to be WRITING, got %s", phase)
+	}
+
+	if sess.CurrentTurn != 0 {
+		t.Errorf("Expected turn counter to be reset, got %d", sess.CurrentTurn)
+	}
+
+	if len(sess.Notes) != 2 {
+		t.Fatalf("Expected notes to be kept, got %d", len(sess.Notes))
+	}
+
+	for _, note := range sess.Notes {
+		if note.Read {
+			t.Error("Expected all notes to be unread after undo")
+		}
+	}
+
+	if !sess.AllNotesWritten() {
+		t.Error("Expected all notes to still be written")
+	}
+}
+
+func TestUndoTransitionWindow(t *testing.T) {
+	sess := NewSession("Host")
+
+	// Nothing to undo yet
+	if _, err := sess.UndoTransition(); err == nil {
+		t.Error("Expected error when no transition has happened")
+	}
+
+	sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.PhaseChangedAt = time.Now().Add(-UndoWindow - time.Second)
+
+	if _, err := sess.UndoTransition(); err == nil {
+		t.Error("Expected error when undo window has passed")
+	}
+
+	if sess.Phase != PhaseWriting {
+		t.Errorf("Expected phase to remain WRITING, got %s", sess.Phase)
+	}
+}
+
 func TestMarkNoteAsRead(t *testing.T) {
 	sess := NewSession("Host")
 	alice, _ := sess.AddParticipant("Alice")
@@ -173,6 +361,52 @@ func TestMarkNoteAsRead(t *testing.T) {
 	}
 }
 
+func TestRecordRecipientPresence(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Note 1")
+	sess.AddNote(alice.ID, sess.HostID, "Note 2")
+
+	if err := sess.RecordRecipientPresence(sess.Notes[0].ID, false); err != nil {
+		t.Fatalf("Failed to record recipient presence: %v", err)
+	}
+	sess.RecordRecipientPresence(sess.Notes[1].ID, true)
+
+	missed := sess.GetMissedNotes(alice.ID)
+	if len(missed) != 1 || missed[0].ID != sess.Notes[0].ID {
+		t.Errorf("Expected Alice to have missed 1 note, got %d", len(missed))
+	}
+
+	if missed := sess.GetMissedNotes(sess.HostID); len(missed) != 0 {
+		t.Errorf("Expected host to have missed no notes, got %d", len(missed))
+	}
+
+	if err := sess.RecordRecipientPresence("nonexistent", true); err == nil {
+		t.Error("Expected error for non-existent note")
+	}
+}
+
+func TestRecordAttendance(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Note 1")
+
+	present := []string{sess.HostID}
+	if err := sess.RecordAttendance(sess.Notes[0].ID, present); err != nil {
+		t.Fatalf("Failed to record attendance: %v", err)
+	}
+	present[0] = "changed"
+	if got := sess.Notes[0].Attendance; len(got) != 1 || got[0] != sess.HostID {
+		t.Errorf("Expected attendance to be copied, got %v", got)
+	}
+
+	if err := sess.RecordAttendance("nonexistent", nil); err == nil {
+		t.Error("Expected error for non-existent note")
+	}
+}
+
 func TestAdvanceTurn(t *testing.T) {
 	sess := NewSession("Host")
 	alice, _ := sess.AddParticipant("Alice")
@@ -222,6 +456,94 @@ func TestRemoveParticipant(t *testing.T) {
 	}
 }
 
+func TestRenameParticipant(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alcie")
+
+	renamed, err := sess.RenameParticipant(alice.ID, "Alice")
+	if err != nil {
+		t.Fatalf("Failed to rename participant: %v", err)
+	}
+
+	if renamed.Name != "Alice" {
+		t.Errorf("Expected renamed participant name Alice, got %s", renamed.Name)
+	}
+
+	if sess.Participants[alice.ID].Name != "Alice" {
+		t.Errorf("Expected stored participant name Alice, got %s", sess.Participants[alice.ID].Name)
+	}
+
+	// Try to rename non-existent participant
+	_, err = sess.RenameParticipant("nonexistent", "Bob")
+	if err == nil {
+		t.Error("Expected error when renaming non-existent participant")
+	}
+}
+
+func TestSetParticipantLatency(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+
+	if err := sess.SetParticipantLatency(alice.ID, 120); err != nil {
+		t.Fatalf("Failed to set latency: %v", err)
+	}
+
+	if sess.Participants[alice.ID].LatencyMs != 120 {
+		t.Errorf("Expected latency 120, got %d", sess.Participants[alice.ID].LatencyMs)
+	}
+
+	if err := sess.SetParticipantLatency("nonexistent", 50); err == nil {
+		t.Error("Expected error for non-existent participant")
+	}
+}
+
+func TestSetParticipantConnected(t *testing.T) {
+	sess := NewManager().CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+
+	if changed, err := sess.SetParticipantConnected(alice.ID, true); err != nil || !changed {
+		t.Fatalf("Expected connecting to change presence, got changed=%v err=%v", changed, err)
+	}
+	if changed, _ := sess.SetParticipantConnected(alice.ID, true); changed {
+		t.Error("Expected connecting again not to change presence")
+	}
+	if _, err := sess.SetParticipantConnected("nonexistent", true); err == nil {
+		t.Error("Expected error for unknown participant")
+	}
+
+	// Nobody is connected to a session restored after a restart
+	data, _ := sess.MarshalSnapshot()
+	restored, _ := UnmarshalSnapshot(data)
+	if restored.Participants[alice.ID].Connected {
+		t.Error("Expected restored participants to start disconnected")
+	}
+}
+
+func TestChangeName(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alcie")
+
+	changed, err := sess.ChangeName(alice.ID, "Alice")
+	if err != nil {
+		t.Fatalf("Failed to change name: %v", err)
+	}
+
+	if changed.Name != "Alice" {
+		t.Errorf("Expected changed name Alice, got %s", changed.Name)
+	}
+
+	// Try to change name after writing phase started
+	sess.TransitionToWriting()
+	_, err = sess.ChangeName(alice.ID, "Alicia")
+	if err == nil {
+		t.Error("Expected error when changing name after session started")
+	}
+
+	if sess.Participants[alice.ID].Name != "Alice" {
+		t.Errorf("Expected name to remain Alice, got %s", sess.Participants[alice.ID].Name)
+	}
+}
+
 func TestGetAvailableNotesForReader(t *testing.T) {
 	sess := NewSession("Host")
 	alice, _ := sess.AddParticipant("Alice")
@@ -244,6 +566,54 @@ func TestGetAvailableNotesForReader(t *testing.T) {
 	}
 }
 
+func TestPrivateNotes(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.TransitionToWriting()
+
+	sess.AddNote(sess.HostID, alice.ID, "Host to Alice")
+	sess.AddPrivateNote(bob.ID, alice.ID, "Bob to Alice, privately")
+
+	if !sess.Notes[1].Private {
+		t.Error("Expected note to be marked private")
+	}
+
+	if count := sess.GetReadAloudNoteCount(); count != 1 {
+		t.Errorf("Expected 1 read-aloud note, got %d", count)
+	}
+
+	if unread := sess.GetUnreadNotes(); len(unread) != 1 {
+		t.Errorf("Expected 1 unread read-aloud note, got %d", len(unread))
+	}
+
+	// Private notes are never drawn
+	for _, note := range sess.GetAvailableNotesForReader(sess.HostID) {
+		if note.Private {
+			t.Error("Expected private notes not to be available for reading")
+		}
+	}
+}
+
+func TestSessionCompletionWithPrivateNotes(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+
+	sess.AddNote(sess.HostID, alice.ID, "Note 1")
+	sess.AddPrivateNote(alice.ID, sess.HostID, "Note 2")
+
+	sess.TransitionToReading()
+	sess.MarkNoteAsRead(sess.Notes[0].ID)
+
+	// Private notes don't hold up completion
+	sess.AdvanceTurn()
+
+	if sess.Phase != PhaseComplete {
+		t.Errorf("Expected phase to be COMPLETE, got %s", sess.Phase)
+	}
+}
+
 func TestSessionCompletion(t *testing.T) {
 	sess := NewSession("Host")
 	alice, _ := sess.AddParticipant("Alice")
@@ -276,6 +646,54 @@ func TestSessionCompletion(t *testing.T) {
 	}
 }
 
+func TestSubmitRating(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.Phase = PhaseComplete
+
+	if err := sess.SubmitRating(alice.ID, 5); err == nil {
+		t.Error("Expected error when ratings are not enabled")
+	}
+
+	sess.SetRatingsEnabled(true)
+
+	if err := sess.SubmitRating(alice.ID, 5); err != nil {
+		t.Fatalf("Failed to submit rating: %v", err)
+	}
+	if err := sess.SubmitRating(bob.ID, 4); err != nil {
+		t.Fatalf("Failed to submit rating: %v", err)
+	}
+
+	if err := sess.SubmitRating(alice.ID, 1); err == nil {
+		t.Error("Expected error when rating twice")
+	}
+
+	if err := sess.SubmitRating(sess.HostID, 6); err == nil {
+		t.Error("Expected error for out of range rating")
+	}
+
+	summary := sess.GetRatingSummary()
+	if summary.Count != 2 {
+		t.Errorf("Expected 2 ratings, got %d", summary.Count)
+	}
+	if summary.Average != 4.5 {
+		t.Errorf("Expected average 4.5, got %v", summary.Average)
+	}
+	if summary.Distribution[4] != 1 || summary.Distribution[3] != 1 {
+		t.Errorf("Unexpected distribution: %v", summary.Distribution)
+	}
+}
+
+func TestSubmitRatingBeforeCompletion(t *testing.T) {
+	sess := NewSession("Host")
+	sess.SetRatingsEnabled(true)
+
+	if err := sess.SubmitRating(sess.HostID, 5); err == nil {
+		t.Error("Expected error when rating before completion")
+	}
+}
+
 func TestGetParticipantList(t *testing.T) {
 	sess := NewSession("Host")
 	alice, _ := sess.AddParticipant("Alice")
@@ -323,20 +741,188 @@ func TestIDGeneration(t *testing.T) {
 	}
 }
 
-func TestSessionCodeGeneration(t *testing.T) {
-	// Test that session codes are unique
-	code1 := generateSessionCode()
-	code2 := generateSessionCode()
+func TestPushDevices(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+
+	if err := sess.RegisterPushDevice(alice.ID, "fcm", "token-1"); err != nil {
+		t.Fatalf("Failed to register device: %v", err)
+	}
+	// Re-registering the same token doesn't duplicate it
+	if err := sess.RegisterPushDevice(alice.ID, "apns", "token-1"); err != nil {
+		t.Fatalf("Failed to re-register device: %v", err)
+	}
+
+	devices := sess.GetPushDevices(alice.ID)
+	if len(devices) != 1 || devices[0].Platform != "apns" {
+		t.Errorf("Expected one apns device, got %+v", devices)
+	}
+
+	for i := 2; i <= MaxPushDevices; i++ {
+		sess.RegisterPushDevice(alice.ID, "fcm", fmt.Sprintf("token-%d", i))
+	}
+	if err := sess.RegisterPushDevice(alice.ID, "fcm", "one-too-many"); err == nil {
+		t.Error("Expected error beyond the device limit")
+	}
+
+	if err := sess.UnregisterPushDevice(alice.ID, "token-1"); err != nil {
+		t.Errorf("Failed to unregister device: %v", err)
+	}
+	if err := sess.UnregisterPushDevice(alice.ID, "token-1"); err == nil {
+		t.Error("Expected error unregistering missing device")
+	}
+
+	if err := sess.RegisterPushDevice("nonexistent", "fcm", "token"); err == nil {
+		t.Error("Expected error for non-existent participant")
+	}
+
+	// Devices are forgotten with the participant
+	sess.RemoveParticipant(alice.ID)
+	if len(sess.GetPushDevices(alice.ID)) != 0 {
+		t.Error("Expected devices to be removed with participant")
+	}
+}
+
+func TestGetWritingStats(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, bob.ID, "Thanks!")
+	sess.AddNote(alice.ID, sess.HostID, "Merci")
+	sess.AddNote(bob.ID, alice.ID, "Grazie mille")
 
-	if code1 == code2 {
-		t.Error("Expected unique session codes (may rarely fail due to randomness)")
+	stats := sess.GetWritingStats()
+	if stats.TotalNotes != 3 || stats.ExpectedNotes != 6 {
+		t.Errorf("Expected 3 of 6 notes, got %d of %d", stats.TotalNotes, stats.ExpectedNotes)
+	}
+	if stats.AverageLength != 8 {
+		t.Errorf("Expected average length 8, got %v", stats.AverageLength)
+	}
+	if stats.LastSubmittedAt == nil {
+		t.Error("Expected a last submission time")
 	}
 
-	if len(code1) > 6 || len(code2) > 6 {
-		t.Error("Session codes should be maximum 6 characters")
+	submitted := map[string]int{}
+	for _, author := range stats.Authors {
+		submitted[author.ParticipantID] = author.NotesSubmitted
 	}
+	if len(stats.Authors) != 3 || submitted[alice.ID] != 2 || submitted[bob.ID] != 1 || submitted[sess.HostID] != 0 {
+		t.Errorf("Unexpected per-author counts: %+v", stats.Authors)
+	}
+}
+
+func TestGetNotePoolStatus(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, bob.ID, "Thanks!")
+	sess.AddNote(alice.ID, sess.HostID, "Merci")
+	sess.AddNote(bob.ID, alice.ID, "Grazie")
 
-	if code1 == "" || code2 == "" {
-		t.Error("Expected non-empty session codes")
+	status := sess.GetNotePoolStatus()
+	if status.TotalNotes != 3 {
+		t.Errorf("Expected 3 notes, got %d", status.TotalNotes)
+	}
+	if status.Written[alice.ID] != 2 || status.Written[sess.HostID] != 0 {
+		t.Errorf("Unexpected written counts: %v", status.Written)
+	}
+	if status.Received[sess.HostID] != 1 || status.Received[alice.ID] != 1 || status.Received[bob.ID] != 1 {
+		t.Errorf("Unexpected received counts: %v", status.Received)
+	}
+
+	if len(status.MissingPairs) != 3 {
+		t.Fatalf("Expected 3 missing pairs, got %+v", status.MissingPairs)
+	}
+	for _, pair := range status.MissingPairs {
+		if pair.AuthorID == alice.ID {
+			t.Errorf("Expected Alice to have written to everyone, got %+v", pair)
+		}
+	}
+}
+
+func TestReopenWriting(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	sess.TransitionToReading()
+
+	// A drawn but unread note goes back into the pool
+	drawn := sess.Notes[0]
+	sess.RecordNoteDrawn(drawn.ID)
+	sess.RecordRecipientPresence(drawn.ID, false)
+	sess.RecordAttendance(drawn.ID, []string{sess.HostID})
+
+	returned, err := sess.ReopenWriting()
+	if err != nil {
+		t.Fatalf("Expected reopen to succeed: %v", err)
+	}
+	if len(returned) != 1 || returned[0] != drawn.ID {
+		t.Errorf("Expected drawn note to be returned, got %v", returned)
+	}
+	if drawn.Attendance != nil || drawn.RecipientMissed || drawn.DrawnAt != nil {
+		t.Error("Expected drawn note's reading records to be cleared")
+	}
+	if sess.Phase != PhaseWriting || len(sess.Notes) != 2 {
+		t.Errorf("Expected writing phase with notes kept, got %s with %d notes", sess.Phase, len(sess.Notes))
+	}
+	if _, err := sess.UndoTransition(); err == nil {
+		t.Error("Expected undo after reopen to be refused")
+	}
+
+	// The forgotten person can join and must be written to before reading again
+	bob, err := sess.AddParticipant("Bob")
+	if err != nil {
+		t.Fatalf("Expected late join after reopen: %v", err)
+	}
+	if err := sess.TransitionToReading(); err == nil {
+		t.Error("Expected reading to wait for notes to the late joiner")
+	}
+	sess.AddNote(sess.HostID, bob.ID, "Thanks Bob")
+	sess.AddNote(alice.ID, bob.ID, "Thanks Bob")
+	sess.AddNote(bob.ID, sess.HostID, "Thanks Host")
+	sess.AddNote(bob.ID, alice.ID, "Thanks Alice")
+	if err := sess.TransitionToReading(); err != nil {
+		t.Fatalf("Expected reading to start: %v", err)
+	}
+	if _, err := sess.AddParticipant("Carol"); err == nil {
+		t.Error("Expected joining to close once reading starts again")
+	}
+
+	// Once a note has been read it's too late
+	sess.MarkNoteAsRead(sess.Notes[0].ID)
+	if _, err := sess.ReopenWriting(); err == nil {
+		t.Error("Expected reopen to be refused after a note was read")
+	}
+}
+
+func TestVersionTracksPhaseAndTurnChanges(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	start := sess.Version
+
+	sess.TransitionToWriting()
+	if sess.Version != start+1 {
+		t.Errorf("Expected phase change to bump version, got %d", sess.Version)
+	}
+	if err := sess.CheckVersion(start); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected stale version to conflict, got %v", err)
+	}
+
+	// Notes don't move the version
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	if err := sess.CheckVersion(start + 1); err != nil {
+		t.Errorf("Expected version unchanged by notes: %v", err)
+	}
+
+	sess.TransitionToReading()
+	before := sess.Version
+	sess.AdvanceTurn()
+	if sess.Version <= before {
+		t.Error("Expected turn advance to bump version")
 	}
 }
@@ -0,0 +1,145 @@
+// ABOUTME: Per-session settings the host chooses when creating a circle
+// ABOUTME: Unset values fall back to server defaults, so clients only send what they want to change
+package session
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// DefaultMaxParticipants is how many people may join a session unless the host chooses fewer
+	DefaultMaxParticipants = 50
+
+	// DefaultMaxNoteLength is the note length limit, in characters, unless the host chooses another
+	DefaultMaxNoteLength = 2000
+)
+
+// SessionFullError is returned when someone would join a session that already has as many
+// participants as it allows
+type SessionFullError struct {
+	Max int
+}
+
+func (e *SessionFullError) Error() string {
+	return fmt.Sprintf("session is full (max %d participants)", e.Max)
+}
+
+// AnonymityMode is whether notes are shared with or without their author
+type AnonymityMode string
+
+const (
+	AnonymityAnonymous  AnonymityMode = "anonymous"  // Notes are read aloud and recapped without their author
+	AnonymityAttributed AnonymityMode = "attributed" // Notes carry their author's name
+)
+
+// ParseAnonymityMode validates an anonymity mode; empty selects anonymous
+func ParseAnonymityMode(value string) (AnonymityMode, error) {
+	switch mode := AnonymityMode(value); mode {
+	case "":
+		return AnonymityAnonymous, nil
+	case AnonymityAnonymous, AnonymityAttributed:
+		return mode, nil
+	default:
+		return "", errors.New("anonymity must be anonymous or attributed")
+	}
+}
+
+// DuplicateNameMode is what happens when someone takes a name already in the session
+type DuplicateNameMode string
+
+const (
+	DuplicateNamesSuffix DuplicateNameMode = "suffix" // The newcomer becomes "Alex (2)"
+	DuplicateNamesReject DuplicateNameMode = "reject" // The name is refused with ErrDuplicateName
+)
+
+// ParseDuplicateNameMode validates a duplicate name mode; empty selects suffix
+func ParseDuplicateNameMode(value string) (DuplicateNameMode, error) {
+	switch mode := DuplicateNameMode(value); mode {
+	case "":
+		return DuplicateNamesSuffix, nil
+	case DuplicateNamesSuffix, DuplicateNamesReject:
+		return mode, nil
+	default:
+		return "", errors.New("duplicateNames must be suffix or reject")
+	}
+}
+
+// SessionSettings are the limits and behaviours a host chooses for their session
+type SessionSettings struct {
+	MaxParticipants int               `json:"maxParticipants"` // Most people who may join, host included
+	MaxNoteLength   int               `json:"maxNoteLength"`   // Longest note, in characters
+	Anonymity       AnonymityMode     `json:"anonymity"`
+	AllowLateJoin   bool              `json:"allowLateJoin"` // People may still join once writing has started
+	WritingTimer    int               `json:"writingTimer"`  // Seconds the circle has to write (0 = untimed)
+	DuplicateNames  DuplicateNameMode `json:"duplicateNames"`
+	InviteOnly      bool              `json:"inviteOnly"` // Joining needs a signed invite link, not just the code
+}
+
+// DefaultSettings returns the settings sessions get when the host doesn't choose any
+func DefaultSettings() SessionSettings {
+	return SessionSettings{}.WithDefaults()
+}
+
+// WithDefaults returns the settings with unset values replaced by the defaults
+func (s SessionSettings) WithDefaults() SessionSettings {
+	if s.MaxParticipants == 0 {
+		s.MaxParticipants = DefaultMaxParticipants
+	}
+	if s.MaxNoteLength == 0 {
+		s.MaxNoteLength = DefaultMaxNoteLength
+	}
+	if s.Anonymity == "" {
+		s.Anonymity = AnonymityAnonymous
+	}
+	if s.DuplicateNames == "" {
+		s.DuplicateNames = DuplicateNamesSuffix
+	}
+	return s
+}
+
+// SetSettings replaces the session's settings, filling in defaults for unset values
+func (s *Session) SetSettings(settings SessionSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Settings = settings.WithDefaults()
+}
+
+// GetSettings returns a copy of the session's settings
+func (s *Session) GetSettings() SessionSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.Settings
+}
+
+// SetMaxNoteLength sets the maximum note length for this session
+func (s *Session) SetMaxNoteLength(length int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Settings.MaxNoteLength = length
+}
+
+// AttributesNotes reports whether notes are shared with their author's name
+func (s *Session) AttributesNotes() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.Settings.Anonymity == AnonymityAttributed
+}
+
+// checkRoomUnlocked returns a *SessionFullError unless joining more people still keeps the
+// session within its participant limit
+// Internal helper that assumes caller holds the lock
+func (s *Session) checkRoomUnlocked(joining int) error {
+	limit := s.Settings.MaxParticipants
+	if limit == 0 {
+		limit = DefaultMaxParticipants
+	}
+	if len(s.Participants)+joining > limit {
+		return &SessionFullError{Max: limit}
+	}
+	return nil
+}
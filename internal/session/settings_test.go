@@ -0,0 +1,84 @@
+package session
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSettingsDefaults(t *testing.T) {
+	settings := SessionSettings{MaxNoteLength: 280}.WithDefaults()
+	if settings.MaxNoteLength != 280 {
+		t.Errorf("Expected chosen note length to be kept, got %d", settings.MaxNoteLength)
+	}
+	if settings.MaxParticipants != DefaultMaxParticipants || settings.Anonymity != AnonymityAnonymous {
+		t.Errorf("Expected defaults for unset values, got %+v", settings)
+	}
+
+	if sess := NewSession("Host"); sess.GetSettings() != DefaultSettings() {
+		t.Errorf("Expected new sessions to start with default settings, got %+v", sess.GetSettings())
+	}
+}
+
+func TestParseAnonymityMode(t *testing.T) {
+	if mode, err := ParseAnonymityMode(""); err != nil || mode != AnonymityAnonymous {
+		t.Errorf("Expected empty to select anonymous, got %q %v", mode, err)
+	}
+	if mode, err := ParseAnonymityMode("attributed"); err != nil || mode != AnonymityAttributed {
+		t.Errorf("Expected attributed, got %q %v", mode, err)
+	}
+	if _, err := ParseAnonymityMode("named"); err == nil {
+		t.Error("Expected an unknown mode to be refused")
+	}
+}
+
+func TestAllowLateJoin(t *testing.T) {
+	sess := NewSession("Host")
+	sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+
+	if _, err := sess.AddParticipant("Bob"); err == nil {
+		t.Error("Expected joining during writing to be refused by default")
+	}
+
+	sess.SetSettings(SessionSettings{AllowLateJoin: true})
+	if _, err := sess.AddParticipant("Bob"); err != nil {
+		t.Errorf("Expected late join to be allowed: %v", err)
+	}
+}
+
+func TestParticipantLimitEnforcedOnEveryJoin(t *testing.T) {
+	sess := NewSession("Host")
+	sess.SetSettings(SessionSettings{MaxParticipants: 2})
+
+	alice, err := sess.AddParticipant("Alice")
+	if err != nil {
+		t.Fatalf("Expected Alice to fit, got %v", err)
+	}
+	var full *SessionFullError
+	if _, err := sess.AddParticipant("Bob"); !errors.As(err, &full) || full.Max != 2 {
+		t.Errorf("Expected a full session error, got %v", err)
+	}
+
+	// Alice's place is taken while she's away, so she can't come back over the limit
+	token, _ := sess.IssueRejoinToken(alice.ID)
+	sess.RemoveParticipant(alice.ID)
+	if _, err := sess.AddParticipant("Carol"); err != nil {
+		t.Fatalf("Expected Carol to take the free place, got %v", err)
+	}
+	if _, _, err := sess.Rejoin(token); !errors.As(err, &full) {
+		t.Errorf("Expected rejoining a full session to be refused, got %v", err)
+	}
+
+	// Merging can't take the target past its limit either
+	manager := NewManager()
+	target := manager.CreateSession("Host")
+	target.SetSettings(SessionSettings{MaxParticipants: 2})
+	source := manager.CreateSession("Other Host")
+	source.AddParticipant("Dan")
+	if _, err := manager.MergeSessions(target, source); !errors.As(err, &full) {
+		t.Errorf("Expected a merge past the limit to be refused, got %v", err)
+	}
+	if len(target.Participants) != 1 || len(source.Participants) != 2 {
+		t.Errorf("Expected a refused merge to move nobody, got %d and %d", len(target.Participants), len(source.Participants))
+	}
+}
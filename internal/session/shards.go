@@ -0,0 +1,98 @@
+// ABOUTME: Sharded concurrent map of sessions used by the Manager
+// ABOUTME: Spreads lock contention across shards keyed by an FNV-1a hash of the key
+package session
+
+import "sync"
+
+// shardCount is the number of independently locked shards (power of two)
+const shardCount = 32
+
+// sessionShard is one locked partition of a sessionMap
+type sessionShard struct {
+	sessions map[string]*Session
+	mu       sync.RWMutex
+}
+
+// sessionMap is a string-keyed map of sessions split across shards so that
+// lookups, creations and cleanup sweeps only contend within a single shard
+type sessionMap struct {
+	shards [shardCount]sessionShard
+}
+
+// newSessionMap creates an empty sharded session map
+func newSessionMap() *sessionMap {
+	m := &sessionMap{}
+	for i := range m.shards {
+		m.shards[i].sessions = make(map[string]*Session)
+	}
+	return m
+}
+
+// shard returns the shard responsible for key
+func (m *sessionMap) shard(key string) *sessionShard {
+	// FNV-1a
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return &m.shards[hash&(shardCount-1)]
+}
+
+// get returns the session stored under key
+func (m *sessionMap) get(key string) (*Session, bool) {
+	shard := m.shard(key)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	session, exists := shard.sessions[key]
+	return session, exists
+}
+
+// set stores a session under key
+func (m *sessionMap) set(key string, session *Session) {
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.sessions[key] = session
+}
+
+// remove deletes and returns the session stored under key
+func (m *sessionMap) remove(key string) (*Session, bool) {
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	session, exists := shard.sessions[key]
+	if exists {
+		delete(shard.sessions, key)
+	}
+	return session, exists
+}
+
+// len returns the total number of sessions across all shards
+func (m *sessionMap) len() int {
+	total := 0
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.RLock()
+		total += len(shard.sessions)
+		shard.mu.RUnlock()
+	}
+	return total
+}
+
+// values returns a snapshot of all sessions, locking one shard at a time
+func (m *sessionMap) values() []*Session {
+	sessions := make([]*Session, 0, m.len())
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.RLock()
+		for _, session := range shard.sessions {
+			sessions = append(sessions, session)
+		}
+		shard.mu.RUnlock()
+	}
+	return sessions
+}
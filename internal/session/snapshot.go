@@ -0,0 +1,114 @@
+// ABOUTME: Serialises a whole session, including state never sent to clients, for persistent stores
+// ABOUTME: Restored sessions carry their rejoin tokens so participants can reclaim their places after a restart
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// sessionFields has Session's fields without its methods, so it marshals with the default encoding
+type sessionFields Session
+
+// snapshot is a session as persisted, with the private state clients never see
+type snapshot struct {
+	*sessionFields
+	RatedBy      []string                      `json:"ratedBy,omitempty"`
+	PushDevices  map[string][]pushDeviceRecord `json:"pushDevices,omitempty"`
+	Timeline     []TimelineEvent               `json:"timeline,omitempty"`
+	HostKey      string                        `json:"hostKey,omitempty"`
+	RejoinTokens map[string]string             `json:"rejoinTokens,omitempty"`
+	Departed     map[string]departureRecord    `json:"departed,omitempty"`
+}
+
+// pushDeviceRecord is a PushDevice including its token, which PushDevice never marshals
+type pushDeviceRecord struct {
+	Platform string `json:"platform"`
+	Token    string `json:"token"`
+}
+
+// departureRecord is a departure with exported fields
+type departureRecord struct {
+	Participant *Participant `json:"participant"`
+	At          time.Time    `json:"at"`
+}
+
+// MarshalSnapshot encodes the whole session for a persistent store
+func (s *Session) MarshalSnapshot() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := snapshot{
+		sessionFields: (*sessionFields)(s),
+		Timeline:      s.timeline,
+		HostKey:       s.hostKey,
+		RejoinTokens:  s.rejoinTokens,
+	}
+	for id := range s.ratedBy {
+		snap.RatedBy = append(snap.RatedBy, id)
+	}
+	if len(s.pushDevices) > 0 {
+		snap.PushDevices = make(map[string][]pushDeviceRecord, len(s.pushDevices))
+		for id, devices := range s.pushDevices {
+			for _, device := range devices {
+				snap.PushDevices[id] = append(snap.PushDevices[id], pushDeviceRecord(device))
+			}
+		}
+	}
+	if len(s.departed) > 0 {
+		snap.Departed = make(map[string]departureRecord, len(s.departed))
+		for id, left := range s.departed {
+			snap.Departed[id] = departureRecord{Participant: left.participant, At: left.at}
+		}
+	}
+	return json.Marshal(snap)
+}
+
+// UnmarshalSnapshot restores a session encoded by MarshalSnapshot
+func UnmarshalSnapshot(data []byte) (*Session, error) {
+	s := &Session{}
+	snap := snapshot{sessionFields: (*sessionFields)(s)}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("decoding session snapshot: %w", err)
+	}
+	if s.ID == "" || s.Code == "" {
+		return nil, fmt.Errorf("decoding session snapshot: missing id or code")
+	}
+	if s.Participants == nil {
+		s.Participants = make(map[string]*Participant)
+	}
+	// Nobody is connected to a session that's just been loaded
+	for _, participant := range s.Participants {
+		participant.Connected = false
+	}
+	if s.Notes == nil {
+		s.Notes = []*Note{}
+	}
+	s.Settings = s.Settings.WithDefaults()
+
+	s.timeline = snap.Timeline
+	s.hostKey = snap.HostKey
+	s.rejoinTokens = snap.RejoinTokens
+	if len(snap.RatedBy) > 0 {
+		s.ratedBy = make(map[string]bool, len(snap.RatedBy))
+		for _, id := range snap.RatedBy {
+			s.ratedBy[id] = true
+		}
+	}
+	if len(snap.PushDevices) > 0 {
+		s.pushDevices = make(map[string][]PushDevice, len(snap.PushDevices))
+		for id, devices := range snap.PushDevices {
+			for _, device := range devices {
+				s.pushDevices[id] = append(s.pushDevices[id], PushDevice(device))
+			}
+		}
+	}
+	if len(snap.Departed) > 0 {
+		s.departed = make(map[string]departure, len(snap.Departed))
+		for id, left := range snap.Departed {
+			s.departed[id] = departure{participant: left.Participant, at: left.At}
+		}
+	}
+	return s, nil
+}
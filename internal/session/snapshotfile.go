@@ -0,0 +1,135 @@
+// ABOUTME: Saves every session in memory to a file and loads them back on startup
+// ABOUTME: Lets a quick restart keep circles in progress when no persistent store is configured
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// snapshotFile is the saved form of every session, each encoded by MarshalSnapshot
+type snapshotFile struct {
+	SavedAt  time.Time         `json:"savedAt"`
+	Sessions []json.RawMessage `json:"sessions"`
+}
+
+// SaveSnapshots writes every session to path, replacing the previous file in one step
+// so a crash mid-write leaves the last complete save; returns how many were saved
+func (m *Manager) SaveSnapshots(path string) (int, error) {
+	sessions, err := m.marshalSessions()
+	if err != nil {
+		return 0, err
+	}
+	if err := writeSnapshotFile(path, sessions); err != nil {
+		return 0, err
+	}
+	return len(sessions), nil
+}
+
+// RestoreSnapshots loads the sessions saved at path, returning those it added
+// A missing file restores nothing; a file older than a day is ignored, as are
+// sessions the manager already has
+func (m *Manager) RestoreSnapshots(path string) ([]*Session, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading session snapshots: %w", err)
+	}
+
+	var file snapshotFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decoding session snapshots: %w", err)
+	}
+	if time.Since(file.SavedAt) > snapshotTTL {
+		slog.Info("Ignoring stale session snapshots", "path", path, "savedAt", file.SavedAt)
+		return nil, nil
+	}
+
+	var restored []*Session
+	for _, raw := range file.Sessions {
+		session, err := UnmarshalSnapshot(raw)
+		if err != nil {
+			slog.Warn("Skipping unreadable session snapshot", "path", path, "error", err)
+			continue
+		}
+		if _, exists := m.sessions.Get(session.ID); exists {
+			continue
+		}
+		m.addSession(session)
+		restored = append(restored, session)
+	}
+	return restored, nil
+}
+
+// RunSnapshots saves every session to path each interval until ctx is done,
+// skipping saves when nothing has changed
+func (m *Manager) RunSnapshots(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastSum [sha256.Size]byte
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sessions, err := m.marshalSessions()
+			if err == nil {
+				hash := sha256.New()
+				for _, data := range sessions {
+					hash.Write(data)
+				}
+				if sum := [sha256.Size]byte(hash.Sum(nil)); sum != lastSum {
+					if err = writeSnapshotFile(path, sessions); err == nil {
+						lastSum = sum
+					}
+				}
+			}
+			if err != nil {
+				slog.Error("Failed to save session snapshots", "path", path, "error", err)
+			}
+		}
+	}
+}
+
+// marshalSessions encodes every session, ordered by ID so unchanged sessions encode identically
+func (m *Manager) marshalSessions() ([]json.RawMessage, error) {
+	all := m.sessions.All()
+	slices.SortFunc(all, func(a, b *Session) int { return strings.Compare(a.ID, b.ID) })
+
+	sessions := make([]json.RawMessage, 0, len(all))
+	for _, session := range all {
+		data, err := session.MarshalSnapshot()
+		if err != nil {
+			return nil, fmt.Errorf("encoding session %s: %w", session.ID, err)
+		}
+		sessions = append(sessions, data)
+	}
+	return sessions, nil
+}
+
+// writeSnapshotFile replaces path with the sessions, readable only by its owner since they hold notes
+func writeSnapshotFile(path string, sessions []json.RawMessage) error {
+	data, err := json.Marshal(snapshotFile{SavedAt: time.Now(), Sessions: sessions})
+	if err != nil {
+		return fmt.Errorf("encoding session snapshots: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing session snapshots: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing session snapshots: %w", err)
+	}
+	return nil
+}
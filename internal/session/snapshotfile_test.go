@@ -0,0 +1,54 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotsSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	before := NewManager()
+	writing := before.CreateSession("Host")
+	alice, _ := writing.AddParticipant("Alice")
+	writing.TransitionToWriting()
+	writing.AddNote(alice.ID, writing.HostID, "Thanks for the demo")
+	before.CreateSession("Other host")
+
+	if count, err := before.SaveSnapshots(path); err != nil || count != 2 {
+		t.Fatalf("Expected 2 sessions saved, got %d err=%v", count, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the snapshot file to be private, got %v", info.Mode().Perm())
+	}
+
+	after := NewManager()
+	restored, err := after.RestoreSnapshots(path)
+	if err != nil || len(restored) != 2 {
+		t.Fatalf("Expected 2 sessions restored, got %d err=%v", len(restored), err)
+	}
+	found, err := after.GetSessionByCode(writing.Code)
+	if err != nil || found.Phase != PhaseWriting || len(found.Notes) != 1 || !found.HasParticipant(alice.ID) {
+		t.Fatalf("Expected the circle restored with its note, got %+v err=%v", found, err)
+	}
+
+	// Restoring again doesn't replace live sessions
+	if again, _ := after.RestoreSnapshots(path); len(again) != 0 {
+		t.Errorf("Expected sessions already served to be skipped, got %d", len(again))
+	}
+}
+
+func TestRestoreSnapshotsWithoutFile(t *testing.T) {
+	manager := NewManager()
+	restored, err := manager.RestoreSnapshots(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(restored) != 0 {
+		t.Errorf("Expected nothing restored from a missing file, got %d err=%v", len(restored), err)
+	}
+
+	corrupt := filepath.Join(t.TempDir(), "corrupt.json")
+	os.WriteFile(corrupt, []byte("{"), 0600)
+	if _, err := manager.RestoreSnapshots(corrupt); err == nil {
+		t.Error("Expected an unreadable file to be reported")
+	}
+}
@@ -0,0 +1,244 @@
+// ABOUTME: Session store persisted as snapshots so circles survive restarts and deploys
+// ABOUTME: Serves live sessions from memory, saving changed snapshots and loading unknown sessions on demand
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// SyncInterval is how often changed sessions are saved; a crash loses at most this much
+	SyncInterval = time.Second
+
+	// snapshotTTL is how long a session nobody touches is kept
+	snapshotTTL = 24 * time.Hour
+
+	// storeTimeout bounds each round trip made while serving a lookup
+	storeTimeout = 2 * time.Second
+)
+
+// errConflict means another replica saved the session since this one loaded it
+var errConflict = errors.New("session was saved by another replica")
+
+// errNotStored means the session isn't in the shared store
+var errNotStored = errors.New("session not stored")
+
+// snapshotBackend is where SnapshotStore keeps session snapshots
+type snapshotBackend interface {
+	// save writes data if the stored revision is still revision, returning the new revision
+	save(ctx context.Context, sessionID, code string, revision uint64, data []byte) (uint64, error)
+
+	// load returns a session's snapshot and revision
+	load(ctx context.Context, sessionID string) ([]byte, uint64, error)
+
+	// lookupCode returns the ID of the session with a normalized code
+	lookupCode(ctx context.Context, code string) (string, error)
+
+	// remove deletes a session's snapshot and code
+	remove(ctx context.Context, sessionID, code string) error
+}
+
+// savedState is what was last written for a session
+type savedState struct {
+	sum      [sha256.Size]byte
+	revision uint64
+	conflict bool // Another replica owns the session now, so this copy stops saving
+}
+
+// SnapshotStore keeps the sessions this process serves in memory and persists them to a
+// snapshot backend: Redis (NewRedisStore) or a SQLite database (NewSQLStore)
+// Sessions other processes saved are loaded the first time they're looked up, so after a
+// deploy participants can rejoin the circle they were in
+// Each session should be served by one replica at a time; saves from a stale copy are refused
+type SnapshotStore struct {
+	live    *MemoryStore
+	backend snapshotBackend
+	onLoad  func(*Session)        // Called for sessions loaded from the backend (nil = none)
+	saved   map[string]savedState // sessionID -> last write
+	mu      sync.Mutex
+}
+
+// newSnapshotStore creates a store over any snapshot backend
+func newSnapshotStore(backend snapshotBackend) *SnapshotStore {
+	return &SnapshotStore{
+		live:    NewMemoryStore(),
+		backend: backend,
+		saved:   make(map[string]savedState),
+	}
+}
+
+// SetLoadHandler sets a function called with each session loaded from the backend, before it's
+// returned, so the caller can resume timers and expire participants who don't come back
+func (s *SnapshotStore) SetLoadHandler(handler func(*Session)) {
+	s.onLoad = handler
+}
+
+// Put adds a new session and saves it straight away
+func (s *SnapshotStore) Put(session *Session) {
+	s.live.Put(session)
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := s.sync(ctx, session); err != nil {
+		slog.Error("Failed to save new session", "sessionID", session.ID, "err", err)
+	}
+}
+
+// Get returns a live session, loading it from the backend if this process isn't serving it
+func (s *SnapshotStore) Get(sessionID string) (*Session, bool) {
+	if session, exists := s.live.Get(sessionID); exists || sessionID == "" {
+		return session, exists
+	}
+	return s.adopt(sessionID)
+}
+
+// GetByCode returns a live session by code, loading it from the backend if necessary
+func (s *SnapshotStore) GetByCode(code string) (*Session, bool) {
+	if session, exists := s.live.GetByCode(code); exists || code == "" {
+		return session, exists
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	sessionID, err := s.backend.lookupCode(ctx, code)
+	if err != nil {
+		if !errors.Is(err, errNotStored) {
+			slog.Error("Failed to look up session code", "sessionCode", code, "err", err)
+		}
+		return nil, false
+	}
+	return s.adopt(sessionID)
+}
+
+// Remove deletes a session here and in the backend
+func (s *SnapshotStore) Remove(sessionID string) (*Session, bool) {
+	session, exists := s.live.Remove(sessionID)
+	if !exists {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	state := s.saved[sessionID]
+	delete(s.saved, sessionID)
+	s.mu.Unlock()
+
+	// A replica that has taken the session over decides when it ends
+	if state.conflict {
+		return session, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := s.backend.remove(ctx, sessionID, normalizeCode(session.Code)); err != nil {
+		slog.Error("Failed to remove stored session", "sessionID", sessionID, "err", err)
+	}
+	return session, true
+}
+
+// All returns the sessions this process is serving
+func (s *SnapshotStore) All() []*Session {
+	return s.live.All()
+}
+
+// Len returns how many sessions this process is serving
+func (s *SnapshotStore) Len() int {
+	return s.live.Len()
+}
+
+// Run saves changed sessions every SyncInterval until ctx is done
+func (s *SnapshotStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Flush(ctx)
+		}
+	}
+}
+
+// Flush saves every session that has changed since it was last saved
+func (s *SnapshotStore) Flush(ctx context.Context) {
+	for _, session := range s.live.All() {
+		if err := s.sync(ctx, session); err != nil {
+			slog.Error("Failed to save session", "sessionID", session.ID, "err", err)
+		}
+	}
+}
+
+// sync saves a session if its snapshot has changed
+func (s *SnapshotStore) sync(ctx context.Context, session *Session) error {
+	data, err := session.MarshalSnapshot()
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+
+	s.mu.Lock()
+	state := s.saved[session.ID]
+	s.mu.Unlock()
+	if state.conflict || (state.revision > 0 && state.sum == sum) {
+		return nil
+	}
+
+	revision, err := s.backend.save(ctx, session.ID, normalizeCode(session.Code), state.revision, data)
+	if errors.Is(err, errConflict) {
+		slog.Info("Session saved elsewhere, no longer saving this copy", "sessionID", session.ID, "sessionCode", session.Code)
+		state.conflict = true
+	} else if err != nil {
+		return err
+	} else {
+		state = savedState{sum: sum, revision: revision}
+	}
+
+	s.mu.Lock()
+	// Don't resurrect the record of a session removed while it was being saved
+	if _, live := s.live.Get(session.ID); live {
+		s.saved[session.ID] = state
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// adopt loads a session from the backend and starts serving it
+func (s *SnapshotStore) adopt(sessionID string) (*Session, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	data, revision, err := s.backend.load(ctx, sessionID)
+	if err != nil {
+		if !errors.Is(err, errNotStored) {
+			slog.Error("Failed to load session", "sessionID", sessionID, "err", err)
+		}
+		return nil, false
+	}
+	session, err := UnmarshalSnapshot(data)
+	if err != nil {
+		slog.Error("Failed to restore session", "sessionID", sessionID, "err", err)
+		return nil, false
+	}
+
+	s.mu.Lock()
+	// Another lookup may have loaded it first
+	if existing, exists := s.live.Get(sessionID); exists {
+		s.mu.Unlock()
+		return existing, true
+	}
+	s.live.Put(session)
+	s.saved[sessionID] = savedState{sum: sha256.Sum256(data), revision: revision}
+	s.mu.Unlock()
+
+	slog.Info("Session loaded from store", "sessionID", session.ID, "sessionCode", session.Code, "phase", session.Phase, "participants", len(session.Participants))
+	if s.onLoad != nil {
+		s.onLoad(session)
+	}
+	return session, true
+}
@@ -0,0 +1,143 @@
+// ABOUTME: Splits an over-large joining session into two independent circles
+// ABOUTME: The chosen participants move to a new session with its own code and host
+package session
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// EventSplit is recorded on both sessions' timelines when a session is split
+const EventSplit = "split"
+
+// SplitSession moves the given participants out of a joining session into a new
+// session with the same settings. newHostID hosts the new circle; if empty the
+// earliest joiner among the moved participants does. The current host can't be moved.
+func (m *Manager) SplitSession(sess *Session, participantIDs []string, newHostID string) (*Session, error) {
+	// The new code goes on sess's timeline, so it's chosen first and held until stored
+	m.codesMu.Lock()
+	defer m.codesMu.Unlock()
+	code := m.uniqueCodeUnlocked()
+
+	sess.mu.Lock()
+	split, err := splitUnlocked(sess, participantIDs, newHostID, code)
+	sess.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.addSession(split)
+	slog.Info("Session split", "sessionID", sess.ID, "sessionCode", sess.Code, "splitSessionID", split.ID, "splitSessionCode", split.Code, "moved", len(participantIDs), "totalSessions", m.sessions.Len())
+	return split, nil
+}
+
+// splitUnlocked builds the new session, with the given code, and removes its participants from sess
+// Internal helper that assumes caller holds sess's write lock
+func splitUnlocked(sess *Session, participantIDs []string, newHostID, code string) (*Session, error) {
+	if sess.Phase != PhaseJoining {
+		return nil, errors.New("can only split a session while joining")
+	}
+	if sess.ParentID != "" {
+		return nil, errors.New("cannot split a breakout circle")
+	}
+	if len(participantIDs) == 0 {
+		return nil, errors.New("choose at least one participant to move")
+	}
+
+	moved := make(map[string]*Participant, len(participantIDs))
+	for _, id := range participantIDs {
+		p, exists := sess.Participants[id]
+		if !exists {
+			return nil, fmt.Errorf("participant not found: %s", id)
+		}
+		if id == sess.HostID {
+			return nil, errors.New("the host stays in the current circle")
+		}
+		if moved[id] != nil {
+			return nil, errors.New("participant listed more than once")
+		}
+		moved[id] = &Participant{
+			ID:       p.ID,
+			Name:     p.Name,
+			Role:     p.Role,
+			JoinedAt: p.JoinedAt,
+		}
+	}
+
+	if newHostID == "" {
+		members := make([]*Participant, 0, len(moved))
+		for _, p := range moved {
+			members = append(members, p)
+		}
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].JoinedAt.Before(members[j].JoinedAt)
+		})
+		newHostID = members[0].ID
+	}
+	if moved[newHostID] == nil {
+		return nil, errors.New("the new host must be one of the moved participants")
+	}
+	moved[newHostID].IsHost = true
+
+	split := &Session{
+		ID:              generateID(),
+		Code:            code,
+		Title:           sess.Title,
+		Welcome:         sess.Welcome,
+		Prompt:          sess.Prompt,
+		AutoStartAt:     sess.AutoStartAt,
+		Countdown:       sess.Countdown,
+		Settings:        sess.Settings,
+		MinNoteChars:    sess.MinNoteChars,
+		MinNoteWords:    sess.MinNoteWords,
+		AutoRun:         sess.AutoRun,
+		RatingsEnabled:  sess.RatingsEnabled,
+		DuplicatePolicy: sess.DuplicatePolicy,
+		Tenant:          sess.Tenant,
+		Theme:           sess.Theme,
+		Phase:           PhaseJoining,
+		Participants:    moved,
+		Notes:           []*Note{},
+		CreatedAt:       time.Now(),
+		HostID:          newHostID,
+	}
+
+	// Rejoin tokens move with their participants, so anyone who drops off can come back
+	// to the circle they were moved to
+	for token, id := range sess.rejoinTokens {
+		if moved[id] == nil {
+			continue
+		}
+		if split.rejoinTokens == nil {
+			split.rejoinTokens = make(map[string]string)
+		}
+		split.rejoinTokens[token] = id
+		delete(sess.rejoinTokens, token)
+	}
+
+	for id := range moved {
+		if devices := sess.pushDevices[id]; len(devices) > 0 {
+			if split.pushDevices == nil {
+				split.pushDevices = make(map[string][]PushDevice)
+			}
+			split.pushDevices[id] = devices
+		}
+		delete(sess.Participants, id)
+		delete(sess.pushDevices, id)
+	}
+
+	split.recordEventUnlocked(EventSessionCreated, newHostID, nil)
+	split.recordEventUnlocked(EventSplit, "", map[string]interface{}{
+		"fromSessionCode": sess.Code,
+		"participantIds":  participantIDs,
+	})
+	sess.recordEventUnlocked(EventSplit, "", map[string]interface{}{
+		"toSessionCode":  split.Code,
+		"participantIds": participantIDs,
+	})
+	return split, nil
+}
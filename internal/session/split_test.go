@@ -0,0 +1,102 @@
+package session
+
+import (
+	"testing"
+)
+
+func TestSplitSession(t *testing.T) {
+	manager := NewManager()
+	sess := manager.CreateSession("Host")
+	sess.SetMaxNoteLength(280)
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.AddParticipant("Carol")
+	sess.RegisterPushDevice(bob.ID, "fcm", "device-1")
+	sess.AutoStartAt = 3
+	bobToken, _ := sess.IssueRejoinToken(bob.ID)
+	sess.SetParticipantRole(bob.ID, "Team lead")
+
+	split, err := manager.SplitSession(sess, []string{alice.ID, bob.ID}, bob.ID)
+	if err != nil {
+		t.Fatalf("Failed to split: %v", err)
+	}
+	if len(sess.Participants) != 2 || len(split.Participants) != 2 {
+		t.Errorf("Expected 2 participants in each circle, got %d and %d", len(sess.Participants), len(split.Participants))
+	}
+	if split.Code == sess.Code || split.ParentID != "" {
+		t.Errorf("Expected an independent session with its own code, got %+v", split)
+	}
+	if split.HostID != bob.ID || !split.IsHostParticipant(bob.ID) {
+		t.Errorf("Expected Bob to host the new circle, got %s", split.HostID)
+	}
+	if split.Settings.MaxNoteLength != 280 {
+		t.Errorf("Expected settings to carry over, got maxNoteLength %d", split.Settings.MaxNoteLength)
+	}
+	if split.AutoStartAt != 3 {
+		t.Errorf("Expected auto-start to carry over, got %d", split.AutoStartAt)
+	}
+	if token, _ := split.IssueRejoinToken(bob.ID); token != bobToken {
+		t.Errorf("Expected Bob's rejoin token to move with him")
+	}
+	if role := split.Participants[bob.ID].Role; role != "Team lead" {
+		t.Errorf("Expected Bob's role to move with him, got %q", role)
+	}
+	if devices := split.GetPushDevices(bob.ID); len(devices) != 1 {
+		t.Errorf("Expected push devices to move with the participant, got %v", devices)
+	}
+	if found, err := manager.GetSessionByCode(split.Code); err != nil || found != split {
+		t.Error("Expected the new session to be registered")
+	}
+
+	timeline := sess.Timeline()
+	if last := timeline[len(timeline)-1]; last.Type != EventSplit || last.Data["toSessionCode"] != split.Code {
+		t.Errorf("Expected split on the timeline, got %+v", last)
+	}
+}
+
+func TestSplitSessionDefaultsHostToEarliestJoiner(t *testing.T) {
+	manager := NewManager()
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+
+	split, err := manager.SplitSession(sess, []string{bob.ID, alice.ID}, "")
+	if err != nil {
+		t.Fatalf("Failed to split: %v", err)
+	}
+	if split.HostID != alice.ID {
+		t.Errorf("Expected earliest joiner to host, got %s", split.HostID)
+	}
+}
+
+func TestSplitSessionValidation(t *testing.T) {
+	manager := NewManager()
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+
+	tests := []struct {
+		name   string
+		ids    []string
+		hostID string
+	}{
+		{"nobody", nil, ""},
+		{"unknown participant", []string{"missing"}, ""},
+		{"current host", []string{sess.HostID, alice.ID}, ""},
+		{"duplicate", []string{alice.ID, alice.ID}, ""},
+		{"host not moved", []string{alice.ID}, bob.ID},
+	}
+	for _, tt := range tests {
+		if _, err := manager.SplitSession(sess, tt.ids, tt.hostID); err == nil {
+			t.Errorf("Expected error for %s", tt.name)
+		}
+	}
+	if len(sess.Participants) != 3 {
+		t.Errorf("Expected rejected splits to leave the session unchanged, got %d participants", len(sess.Participants))
+	}
+
+	sess.TransitionToWriting()
+	if _, err := manager.SplitSession(sess, []string{alice.ID}, ""); err == nil {
+		t.Error("Expected error splitting after writing begins")
+	}
+}
@@ -0,0 +1,111 @@
+// ABOUTME: Session snapshots kept in a SQLite database, for single-binary self-hosting without Redis
+// ABOUTME: Uses only database/sql; the caller opens the database with whichever driver it links in
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// sqlSchema creates the snapshot table
+// Statements are written for SQLite, using ? placeholders and ON CONFLICT upserts
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		code TEXT NOT NULL,
+		revision INTEGER NOT NULL,
+		data BLOB NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS sessions_code ON sessions (code)`,
+}
+
+// NewSQLStore creates a store persisting sessions to a SQLite database, creating its table if needed
+// It works as with Redis: sessions are served from memory, changes are saved every
+// SyncInterval, and sessions saved before a restart are loaded the first time they're looked up
+// Snapshots nobody has touched for a day are deleted
+func NewSQLStore(ctx context.Context, db *sql.DB) (*SnapshotStore, error) {
+	for _, statement := range sqlSchema {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("creating sessions table: %w", err)
+		}
+	}
+
+	snapshots := &sqlSnapshots{db: db, now: time.Now}
+	if _, err := db.ExecContext(ctx, `DELETE FROM sessions WHERE updated_at < ?`, snapshots.expiredBefore()); err != nil {
+		return nil, fmt.Errorf("removing expired sessions: %w", err)
+	}
+	return newSnapshotStore(snapshots), nil
+}
+
+// sqlSnapshots keeps snapshots as rows {id, code, revision, data, updated_at}
+type sqlSnapshots struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// expiredBefore is the update time before which a snapshot counts as abandoned
+func (s *sqlSnapshots) expiredBefore() int64 {
+	return s.now().Add(-snapshotTTL).UnixMilli()
+}
+
+func (s *sqlSnapshots) save(ctx context.Context, sessionID, code string, revision uint64, data []byte) (uint64, error) {
+	var result sql.Result
+	var err error
+	if revision == 0 {
+		result, err = s.db.ExecContext(ctx,
+			`INSERT INTO sessions (id, code, revision, data, updated_at) VALUES (?, ?, 1, ?, ?) ON CONFLICT (id) DO NOTHING`,
+			sessionID, code, data, s.now().UnixMilli())
+	} else {
+		// Only the copy that wrote the stored revision may replace it
+		result, err = s.db.ExecContext(ctx,
+			`UPDATE sessions SET code = ?, revision = revision + 1, data = ?, updated_at = ? WHERE id = ? AND revision = ?`,
+			code, data, s.now().UnixMilli(), sessionID, revision)
+	}
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rows == 0 {
+		return 0, errConflict
+	}
+	return revision + 1, nil
+}
+
+func (s *sqlSnapshots) load(ctx context.Context, sessionID string) ([]byte, uint64, error) {
+	var data []byte
+	var revision int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT data, revision FROM sessions WHERE id = ? AND updated_at >= ?`,
+		sessionID, s.expiredBefore()).Scan(&data, &revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, errNotStored
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, uint64(revision), nil
+}
+
+func (s *sqlSnapshots) lookupCode(ctx context.Context, code string) (string, error) {
+	// Codes are reused once a session ends, so the most recently saved session wins
+	var sessionID string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id FROM sessions WHERE code = ? AND updated_at >= ? ORDER BY updated_at DESC LIMIT 1`,
+		code, s.expiredBefore()).Scan(&sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errNotStored
+	}
+	return sessionID, err
+}
+
+func (s *sqlSnapshots) remove(ctx context.Context, sessionID, code string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, sessionID)
+	return err
+}
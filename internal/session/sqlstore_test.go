@@ -0,0 +1,174 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRow is a row of the sessions table held by fakeSQL
+type fakeRow struct {
+	code      string
+	revision  int64
+	data      []byte
+	updatedAt int64
+}
+
+// fakeSQL is a database/sql driver that understands just the statements sqlSnapshots runs,
+// standing in for SQLite so the store can be tested without linking a driver
+type fakeSQL struct {
+	rows map[string]*fakeRow
+	mu   sync.Mutex
+}
+
+func (f *fakeSQL) Open(name string) (driver.Conn, error) { return fakeConn{f}, nil }
+
+type fakeConn struct{ db *fakeSQL }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{db: c.db, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+func (c fakeConn) Close() error              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type fakeStmt struct {
+	db    *fakeSQL
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		id := args[0].(string)
+		if _, exists := s.db.rows[id]; exists {
+			return driver.RowsAffected(0), nil
+		}
+		s.db.rows[id] = &fakeRow{code: args[1].(string), revision: 1, data: args[2].([]byte), updatedAt: args[3].(int64)}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "UPDATE"):
+		row, exists := s.db.rows[args[3].(string)]
+		if !exists || row.revision != args[4].(int64) {
+			return driver.RowsAffected(0), nil
+		}
+		*row = fakeRow{code: args[0].(string), revision: row.revision + 1, data: args[1].([]byte), updatedAt: args[2].(int64)}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE FROM sessions WHERE id"):
+		delete(s.db.rows, args[0].(string))
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE FROM sessions WHERE updated_at"):
+		for id, row := range s.db.rows {
+			if row.updatedAt < args[0].(int64) {
+				delete(s.db.rows, id)
+			}
+		}
+		return driver.RowsAffected(0), nil
+	}
+	panic("unexpected statement: " + s.query)
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var values [][]driver.Value
+	columns := 1
+	switch {
+	case strings.HasPrefix(s.query, "SELECT data"):
+		columns = 2
+		if row, exists := s.db.rows[args[0].(string)]; exists && row.updatedAt >= args[1].(int64) {
+			values = append(values, []driver.Value{row.data, row.revision})
+		}
+	case strings.HasPrefix(s.query, "SELECT id"):
+		var latest *fakeRow
+		for id, row := range s.db.rows {
+			if row.code == args[0].(string) && row.updatedAt >= args[1].(int64) && (latest == nil || row.updatedAt > latest.updatedAt) {
+				latest = row
+				values = [][]driver.Value{{id}}
+			}
+		}
+	default:
+		panic("unexpected query: " + s.query)
+	}
+	return &fakeRows{columns: columns, values: values}, nil
+}
+
+type fakeRows struct {
+	columns int
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return make([]string, r.columns) }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// openFakeSQL opens a fresh database on the fake driver
+func openFakeSQL(t *testing.T) *sql.DB {
+	db := sql.OpenDB(fakeConnector{&fakeSQL{rows: make(map[string]*fakeRow)}})
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+type fakeConnector struct{ db *fakeSQL }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{c.db}, nil }
+func (c fakeConnector) Driver() driver.Driver                        { return c.db }
+
+func TestSQLStoreKeepsSessionsAcrossRestarts(t *testing.T) {
+	db := openFakeSQL(t)
+	store, err := NewSQLStore(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	before := NewManagerWithStore(store)
+	sess := before.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, sess.HostID, "Thanks for running the retro")
+	store.Flush(context.Background())
+
+	// The next process on the same database picks the circle up, notes and all
+	restarted, err := NewSQLStore(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	after := NewManagerWithStore(restarted)
+	found, err := after.GetSessionByCode(sess.Code)
+	if err != nil || found.ID != sess.ID || found.Phase != PhaseWriting || len(found.Notes) != 1 {
+		t.Fatalf("Expected the saved session with its note, got %+v err=%v", found, err)
+	}
+
+	// The stale copy stops saving once the new one has
+	found.AddNote(sess.HostID, alice.ID, "And for the snacks")
+	restarted.Flush(context.Background())
+	sess.AddNote(sess.HostID, alice.ID, "Written on the old process")
+	store.Flush(context.Background())
+	data, _, _ := restarted.backend.load(context.Background(), sess.ID)
+	stored, _ := UnmarshalSnapshot(data)
+	if len(stored.Notes) != 2 || stored.Notes[1].Content != "And for the snacks" {
+		t.Errorf("Expected the restarted process's copy to win, got %+v", stored.Notes)
+	}
+
+	after.RemoveSession(sess.ID)
+	if _, _, err := restarted.backend.load(context.Background(), sess.ID); err != errNotStored {
+		t.Errorf("Expected a removed session to be deleted, got %v", err)
+	}
+}
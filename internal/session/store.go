@@ -0,0 +1,76 @@
+// ABOUTME: Store interface the Manager keeps sessions in, with the default in-memory implementation
+// ABOUTME: Other stores (see SnapshotStore) persist sessions so they outlive the process
+package session
+
+// Store holds the sessions a Manager serves
+// Sessions are live objects mutated in place; persistent stores snapshot them
+type Store interface {
+	// Put adds a newly created session
+	Put(session *Session)
+
+	// Get returns a session by ID
+	Get(sessionID string) (*Session, bool)
+
+	// GetByCode returns a session by its normalized code
+	GetByCode(code string) (*Session, bool)
+
+	// Remove deletes a session, returning it if it existed
+	Remove(sessionID string) (*Session, bool)
+
+	// All returns a snapshot of the sessions this process is serving
+	All() []*Session
+
+	// Len returns how many sessions this process is serving
+	Len() int
+}
+
+// MemoryStore keeps sessions in process memory, so they end with the process
+// Sessions are held in sharded maps so lookups don't contend with each other
+// or with creation and cleanup
+type MemoryStore struct {
+	sessions       *sessionMap // sessionID -> Session
+	sessionsByCode *sessionMap // sessionCode -> Session
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions:       newSessionMap(),
+		sessionsByCode: newSessionMap(),
+	}
+}
+
+// Put stores a session under its ID and code
+func (m *MemoryStore) Put(session *Session) {
+	m.sessions.set(session.ID, session)
+	m.sessionsByCode.set(normalizeCode(session.Code), session)
+}
+
+// Get returns a session by ID
+func (m *MemoryStore) Get(sessionID string) (*Session, bool) {
+	return m.sessions.get(sessionID)
+}
+
+// GetByCode returns a session by its normalized code
+func (m *MemoryStore) GetByCode(code string) (*Session, bool) {
+	return m.sessionsByCode.get(code)
+}
+
+// Remove deletes a session from both indexes
+func (m *MemoryStore) Remove(sessionID string) (*Session, bool) {
+	session, exists := m.sessions.remove(sessionID)
+	if exists {
+		m.sessionsByCode.remove(normalizeCode(session.Code))
+	}
+	return session, exists
+}
+
+// All returns a snapshot of all sessions
+func (m *MemoryStore) All() []*Session {
+	return m.sessions.values()
+}
+
+// Len returns the number of sessions
+func (m *MemoryStore) Len() int {
+	return m.sessions.len()
+}
@@ -0,0 +1,50 @@
+// ABOUTME: Catalog of occasion themes a host can give a circle, such as year-end or welcoming a teammate
+// ABOUTME: The server owns the list so every client shows the same names and descriptions
+package session
+
+import "fmt"
+
+// Theme is an occasion a circle can be styled and framed around
+type Theme struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Themes is the catalog of themes offered to hosts, in display order
+var Themes = []Theme{
+	{ID: "year-end", Name: "Year-end", Description: "Look back on the year and thank the people who made it"},
+	{ID: "new-teammate", Name: "New teammate welcome", Description: "Welcome someone who has just joined the team"},
+	{ID: "farewell", Name: "Farewell", Description: "Send off a teammate who is moving on"},
+	{ID: "project-wrap", Name: "Project wrap-up", Description: "Celebrate the people behind a finished project"},
+	{ID: "milestone", Name: "Milestone", Description: "Mark a launch, anniversary or other team milestone"},
+}
+
+// LookupTheme finds a theme in the catalog by ID
+func LookupTheme(id string) (Theme, bool) {
+	for _, theme := range Themes {
+		if theme.ID == id {
+			return theme, true
+		}
+	}
+	return Theme{}, false
+}
+
+// ParseTheme validates a theme ID against the catalog; empty means no theme
+func ParseTheme(id string) (string, error) {
+	if id == "" {
+		return "", nil
+	}
+	if _, ok := LookupTheme(id); !ok {
+		return "", fmt.Errorf("unknown theme: %s", id)
+	}
+	return id, nil
+}
+
+// SetTheme sets the occasion the circle is themed around
+func (s *Session) SetTheme(theme string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Theme = theme
+}
@@ -0,0 +1,84 @@
+// ABOUTME: Append-only timeline of what happened in a session and when
+// ABOUTME: Lets facilitators review pacing and engineers debug how a session got stuck
+package session
+
+import "time"
+
+// Timeline event types
+const (
+	EventSessionCreated = "session_created"
+	EventJoined         = "joined"
+	EventRejoined       = "rejoined"
+	EventPhaseChanged   = "phase_changed"
+	EventTurnChanged    = "turn_changed"
+	EventNoteDrawn      = "note_drawn"
+	EventNoteRead       = "note_read"
+	EventLeft           = "left"
+	EventKicked         = "kicked"
+	EventNotesSubmitted = "notes_submitted"
+)
+
+// maxTimelineEvents bounds each session's timeline; the oldest events are dropped first
+const maxTimelineEvents = 2000
+
+// TimelineEvent is one entry in a session's timeline
+// Events never include note content or who wrote a note; joins, leaves and kicks
+// carry the participant's name so the timeline reads on after they've gone
+type TimelineEvent struct {
+	At            time.Time              `json:"at"`
+	OffsetMs      int64                  `json:"offsetMs"` // Time since the first event, filled in by Timeline
+	Type          string                 `json:"type"`
+	ParticipantID string                 `json:"participantId,omitempty"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+}
+
+// RecordEvent appends an event to the session's timeline
+func (s *Session) RecordEvent(eventType, participantID string, data map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordEventUnlocked(eventType, participantID, data)
+}
+
+// recordEventUnlocked appends an event to the timeline
+// Internal helper that assumes caller already holds the write lock
+func (s *Session) recordEventUnlocked(eventType, participantID string, data map[string]interface{}) {
+	// Every phase change and turn advance records an event, so this is the one
+	// place the session's version needs to move
+	if eventType == EventPhaseChanged || eventType == EventTurnChanged {
+		s.Version++
+	}
+
+	s.timeline = append(s.timeline, TimelineEvent{
+		At:            time.Now(),
+		Type:          eventType,
+		ParticipantID: participantID,
+		Data:          data,
+	})
+
+	if len(s.timeline) > maxTimelineEvents {
+		s.timeline = s.timeline[len(s.timeline)-maxTimelineEvents:]
+	}
+}
+
+// recordPhaseChangeUnlocked records a transition between phases
+func (s *Session) recordPhaseChangeUnlocked(from, to Phase) {
+	s.recordEventUnlocked(EventPhaseChanged, "", map[string]interface{}{
+		"from": from,
+		"to":   to,
+	})
+}
+
+// Timeline returns a copy of the session's timeline, oldest first, with each
+// event's offset from the first so pacing can be reviewed or replayed
+func (s *Session) Timeline() []TimelineEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	timeline := make([]TimelineEvent, len(s.timeline))
+	copy(timeline, s.timeline)
+	for i := range timeline {
+		timeline[i].OffsetMs = timeline[i].At.Sub(timeline[0].At).Milliseconds()
+	}
+	return timeline
+}
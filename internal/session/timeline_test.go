@@ -0,0 +1,94 @@
+package session
+
+import (
+	"testing"
+)
+
+func TestTimelineRecordsSessionProgress(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thank you for the help")
+	sess.AddNote(alice.ID, sess.HostID, "Thanks for running this")
+	sess.TransitionToReading()
+
+	note := sess.GetAvailableNotesForReader(sess.GetCurrentReader().ID)[0]
+	sess.RecordEvent(EventNoteDrawn, sess.GetCurrentReader().ID, map[string]interface{}{"noteId": note.ID})
+	sess.MarkNoteAsRead(note.ID)
+	sess.AdvanceTurn()
+
+	timeline := sess.Timeline()
+	expected := []string{
+		EventSessionCreated,
+		EventJoined,
+		EventPhaseChanged,
+		EventPhaseChanged,
+		EventNoteDrawn,
+		EventNoteRead,
+		EventTurnChanged,
+	}
+	if len(timeline) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(expected), len(timeline), timeline)
+	}
+	for i, eventType := range expected {
+		if timeline[i].Type != eventType {
+			t.Errorf("Event %d: expected %s, got %s", i, eventType, timeline[i].Type)
+		}
+	}
+
+	if timeline[1].ParticipantID != alice.ID {
+		t.Errorf("Expected join by Alice, got %q", timeline[1].ParticipantID)
+	}
+	if timeline[3].Data["to"] != PhaseReading {
+		t.Errorf("Expected transition to reading, got %+v", timeline[3].Data)
+	}
+	if timeline[0].OffsetMs != 0 || timeline[len(timeline)-1].OffsetMs < 0 {
+		t.Errorf("Expected offsets from the first event, got %+v", timeline)
+	}
+
+	// Timelines never carry note content
+	for _, event := range timeline {
+		for _, value := range event.Data {
+			if s, ok := value.(string); ok && s == note.Content {
+				t.Errorf("Expected no note content in %s event", event.Type)
+			}
+		}
+	}
+}
+
+func TestTimelineRecordsDepartures(t *testing.T) {
+	sess := NewSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+
+	sess.RemoveParticipant(alice.ID)
+	sess.KickParticipant(bob.ID, "admin")
+	if _, err := sess.KickParticipant(bob.ID, "host"); err == nil {
+		t.Error("Expected kicking someone who has gone to fail")
+	}
+
+	timeline := sess.Timeline()
+	left, kicked := timeline[len(timeline)-2], timeline[len(timeline)-1]
+	if left.Type != EventLeft || left.ParticipantID != alice.ID || left.Data["name"] != "Alice" {
+		t.Errorf("Expected Alice's leaving to be recorded, got %+v", left)
+	}
+	if kicked.Type != EventKicked || kicked.ParticipantID != bob.ID || kicked.Data["by"] != "admin" || kicked.Data["name"] != "Bob" {
+		t.Errorf("Expected Bob's removal by an admin to be recorded, got %+v", kicked)
+	}
+}
+
+func TestTimelineIsBounded(t *testing.T) {
+	sess := NewSession("Host")
+	for i := 0; i < maxTimelineEvents+10; i++ {
+		sess.RecordEvent(EventNoteRead, "", nil)
+	}
+
+	timeline := sess.Timeline()
+	if len(timeline) != maxTimelineEvents {
+		t.Errorf("Expected timeline capped at %d, got %d", maxTimelineEvents, len(timeline))
+	}
+	if timeline[0].Type == EventSessionCreated {
+		t.Error("Expected the oldest events to be dropped first")
+	}
+}
@@ -0,0 +1,22 @@
+// ABOUTME: Optimistic versioning of a session's phase and turn
+// ABOUTME: Lets a handler refuse an action based on a stale view instead of interleaving conflicting changes
+package session
+
+import "errors"
+
+// ErrVersionConflict is returned when an action was based on an out-of-date view of the session
+var ErrVersionConflict = errors.New("session has changed since this action was requested")
+
+// CheckVersion returns ErrVersionConflict unless the session is still at version.
+// Version goes up with every phase change and turn advance (see recordEventUnlocked),
+// so a check fails if another handler, on this node or another sharing the store,
+// has moved the session on since the caller looked.
+func (s *Session) CheckVersion(version uint64) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.Version != version {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+// ABOUTME: Sentence starters offered during writing to participants who freeze at a blank note
+// ABOUTME: A built-in set can be replaced deployment-wide or per tenant, with optional JSON file persistence
+package starters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultTenant names the deployment-wide set that tenants without their own fall back to
+	DefaultTenant = "default"
+
+	maxStarters      = 20
+	maxStarterLength = 100
+	maxTenantLength  = 60
+)
+
+// Defaults are the starters offered when none have been configured
+var Defaults = []string{
+	"I appreciated when you…",
+	"You made my week when…",
+	"Thank you for…",
+	"I learned from you that…",
+	"Working with you is great because…",
+}
+
+// Store holds configured starter sets keyed by tenant
+// A nil *Store serves the built-in defaults
+type Store struct {
+	sets map[string][]string // Tenant ID (or DefaultTenant) -> starters
+	path string              // JSON file sets are persisted to (empty = memory only)
+	mu   sync.RWMutex
+}
+
+// NewStore creates a starter store persisted to path, loading any existing sets
+// An empty path keeps sets in memory only
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		sets: make(map[string][]string),
+		path: path,
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading sentence starters: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.sets); err != nil {
+		return nil, fmt.Errorf("parsing sentence starters: %w", err)
+	}
+
+	slog.Info("Sentence starters loaded", "path", path, "sets", len(s.sets))
+	return s, nil
+}
+
+// For returns the starters for a tenant: its own set, else the deployment-wide set,
+// else the built-in defaults
+func (s *Store) For(tenant string) []string {
+	if s == nil {
+		return append([]string(nil), Defaults...)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set, exists := s.sets[tenant]
+	if !exists {
+		set, exists = s.sets[DefaultTenant]
+	}
+	if !exists {
+		set = Defaults
+	}
+	return append([]string(nil), set...)
+}
+
+// Set replaces a tenant's starters, or the deployment-wide set for DefaultTenant
+// Starters are trimmed and blank ones dropped
+func (s *Store) Set(tenant string, starters []string) ([]string, error) {
+	if tenant == "" || len(tenant) > maxTenantLength {
+		return nil, fmt.Errorf("tenant must be 1 to %d characters", maxTenantLength)
+	}
+
+	cleaned := make([]string, 0, len(starters))
+	for _, starter := range starters {
+		starter = strings.TrimSpace(starter)
+		if starter == "" {
+			continue
+		}
+		if utf8.RuneCountInString(starter) > maxStarterLength {
+			return nil, fmt.Errorf("starters must be %d characters or fewer", maxStarterLength)
+		}
+		cleaned = append(cleaned, starter)
+	}
+	if len(cleaned) == 0 {
+		return nil, errors.New("at least one starter required")
+	}
+	if len(cleaned) > maxStarters {
+		return nil, fmt.Errorf("at most %d starters allowed", maxStarters)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.sets[tenant]
+	s.sets[tenant] = cleaned
+	if err := s.saveUnlocked(); err != nil {
+		if existed {
+			s.sets[tenant] = previous
+		} else {
+			delete(s.sets, tenant)
+		}
+		return nil, err
+	}
+
+	slog.Info("Sentence starters set", "tenant", tenant, "count", len(cleaned))
+	return append([]string(nil), cleaned...), nil
+}
+
+// Reset removes a tenant's own starters so it falls back to the deployment-wide set
+func (s *Store) Reset(tenant string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.sets[tenant]
+	if !existed {
+		return nil
+	}
+	delete(s.sets, tenant)
+	if err := s.saveUnlocked(); err != nil {
+		s.sets[tenant] = previous
+		return err
+	}
+
+	slog.Info("Sentence starters reset", "tenant", tenant)
+	return nil
+}
+
+// TenantSet is one tenant's configured starters
+type TenantSet struct {
+	Tenant   string   `json:"tenant"`
+	Starters []string `json:"starters"`
+}
+
+// List returns every configured set ordered by tenant
+func (s *Store) List() []TenantSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sets := make([]TenantSet, 0, len(s.sets))
+	for tenant, starters := range s.sets {
+		sets = append(sets, TenantSet{Tenant: tenant, Starters: append([]string(nil), starters...)})
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		return sets[i].Tenant < sets[j].Tenant
+	})
+	return sets
+}
+
+// saveUnlocked writes the sets to disk if persistence is configured
+// Internal helper that assumes caller already holds the lock
+func (s *Store) saveUnlocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.sets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding sentence starters: %w", err)
+	}
+
+	// Write to a temp file and rename so a crash never leaves a partial file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing sentence starters: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing sentence starters: %w", err)
+	}
+	return nil
+}
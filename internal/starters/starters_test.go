@@ -0,0 +1,72 @@
+package starters
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStarterFallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "starters.json")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if got := store.For("acme"); len(got) != len(Defaults) {
+		t.Errorf("Expected the built-in defaults, got %v", got)
+	}
+
+	store.Set(DefaultTenant, []string{"Cheers for…"})
+	store.Set("acme", []string{"Acme thanks you for…"})
+
+	if got := store.For("globex"); len(got) != 1 || got[0] != "Cheers for…" {
+		t.Errorf("Expected the deployment-wide set, got %v", got)
+	}
+	if got := store.For(""); len(got) != 1 || got[0] != "Cheers for…" {
+		t.Errorf("Expected the deployment-wide set without a tenant, got %v", got)
+	}
+
+	// Sets survive a restart
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reload store: %v", err)
+	}
+	if got := reloaded.For("acme"); len(got) != 1 || got[0] != "Acme thanks you for…" {
+		t.Errorf("Expected acme's own set after reload, got %v", got)
+	}
+
+	var none *Store
+	if got := none.For("acme"); len(got) != len(Defaults) {
+		t.Errorf("Expected defaults from a nil store, got %v", got)
+	}
+}
+
+func TestSetValidation(t *testing.T) {
+	store, _ := NewStore("")
+
+	tests := []struct {
+		name     string
+		tenant   string
+		starters []string
+	}{
+		{"no tenant", "", []string{"Thanks"}},
+		{"only blanks", "acme", []string{" ", ""}},
+		{"too long", "acme", []string{strings.Repeat("a", maxStarterLength+1)}},
+		{"too many", "acme", tooMany()},
+	}
+	for _, tt := range tests {
+		if _, err := store.Set(tt.tenant, tt.starters); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+// tooMany returns one more starter than a set may hold
+func tooMany() []string {
+	list := make([]string, maxStarters+1)
+	for i := range list {
+		list[i] = "Thanks"
+	}
+	return list
+}
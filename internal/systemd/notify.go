@@ -0,0 +1,73 @@
+// ABOUTME: Minimal sd_notify client for systemd Type=notify services
+// ABOUTME: Signals readiness and shutdown and sends watchdog pings while the app is healthy
+package systemd
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Notify sends a state string such as "READY=1" to systemd
+// It is a no-op when the process isn't running under systemd notify
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	// Abstract namespace sockets are given with a leading @
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// WatchdogInterval returns the watchdog timeout systemd expects pings within
+// Returns 0 if the watchdog isn't enabled for this process
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	// WATCHDOG_PID, when set, names the process the watchdog is meant for
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
+
+// RunWatchdog pings the systemd watchdog at half the given interval for as long
+// as healthy reports true, so systemd restarts the service if it stops responding
+func RunWatchdog(ctx context.Context, interval time.Duration, healthy func() bool) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !healthy() {
+				slog.Warn("Health check failed, skipping watchdog ping")
+				continue
+			}
+			if err := Notify("WATCHDOG=1"); err != nil {
+				slog.Warn("Failed to send watchdog ping", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// ABOUTME: Public gratitude walls showing notes a host chose to share after a circle
+// ABOUTME: Walls live at an unguessable token URL until they expire or the host takes them down
+package wall
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultExpiry is how long a wall stays up when the host doesn't say
+	DefaultExpiry = 7 * 24 * time.Hour
+
+	// MaxExpiry bounds how long a wall can stay up
+	MaxExpiry = 90 * 24 * time.Hour
+
+	// MaxNotes bounds how many notes one wall shows
+	MaxNotes = 200
+
+	// PathPrefix is where walls are served
+	PathPrefix = "/wall/"
+)
+
+// Note is an anonymised note on a wall: no author, recipient or session identifiers
+type Note struct {
+	Content string `json:"content"`
+	GIFURL  string `json:"gifUrl,omitempty"`
+}
+
+// Wall is a published set of notes
+type Wall struct {
+	Token     string    `json:"token"`
+	Title     string    `json:"title"`
+	Notes     []Note    `json:"notes"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	owner     string    // Session the wall was published from; only it may take the wall down
+}
+
+// URL is the wall's path on this server
+func (w *Wall) URL() string {
+	return PathPrefix + w.Token
+}
+
+// Store holds published walls in memory; they don't survive a restart
+// A nil *Store publishes nothing
+type Store struct {
+	walls map[string]*Wall // Token -> wall
+	now   func() time.Time
+	mu    sync.Mutex
+}
+
+// NewStore creates an empty wall store
+func NewStore() *Store {
+	return &Store{
+		walls: make(map[string]*Wall),
+		now:   time.Now,
+	}
+}
+
+// Publish puts notes on a new wall for ttl (DefaultExpiry when zero), owned by a session
+func (s *Store) Publish(owner, title string, notes []Note, ttl time.Duration) (*Wall, error) {
+	if s == nil {
+		return nil, errors.New("gratitude walls are not available")
+	}
+	if len(notes) == 0 {
+		return nil, errors.New("no shareable notes selected")
+	}
+	if len(notes) > MaxNotes {
+		return nil, fmt.Errorf("a wall can show at most %d notes", MaxNotes)
+	}
+	if ttl == 0 {
+		ttl = DefaultExpiry
+	}
+	if ttl < 0 || ttl > MaxExpiry {
+		return nil, fmt.Errorf("expiry must be at most %d days", int(MaxExpiry.Hours()/24))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweepUnlocked(now)
+
+	wall := &Wall{
+		Token:     randomToken(),
+		Title:     title,
+		Notes:     append([]Note(nil), notes...),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		owner:     owner,
+	}
+	s.walls[wall.Token] = wall
+
+	slog.Info("Gratitude wall published", "notes", len(notes), "expiresAt", wall.ExpiresAt)
+	return wall, nil
+}
+
+// Get returns an unexpired wall
+func (s *Store) Get(token string) (*Wall, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wall, exists := s.walls[token]
+	if !exists || !s.now().Before(wall.ExpiresAt) {
+		return nil, false
+	}
+	return wall, true
+}
+
+// Remove takes down a wall published by owner
+func (s *Store) Remove(owner, token string) error {
+	if s == nil {
+		return errors.New("gratitude walls are not available")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wall, exists := s.walls[token]
+	if !exists || wall.owner != owner {
+		return errors.New("wall not found")
+	}
+	delete(s.walls, token)
+
+	slog.Info("Gratitude wall removed")
+	return nil
+}
+
+// sweepUnlocked drops expired walls
+// Internal helper that assumes caller already holds the lock
+func (s *Store) sweepUnlocked(now time.Time) {
+	for token, wall := range s.walls {
+		if !now.Before(wall.ExpiresAt) {
+			delete(s.walls, token)
+		}
+	}
+}
+
+// page renders a wall; html/template escapes note content
+var page = template.Must(template.New("wall").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{if .Title}}{{.Title}}{{else}}Gratitude wall{{end}}</title>
+<style>
+body { margin: 0; padding: 2rem 1rem; font-family: system-ui, sans-serif; background: #faf7f2; color: #222; }
+h1 { text-align: center; font-weight: 600; }
+.notes { display: grid; gap: 1rem; grid-template-columns: repeat(auto-fill, minmax(16rem, 1fr)); max-width: 64rem; margin: 0 auto; padding: 0; list-style: none; }
+.note { background: #fff; border-radius: 0.5rem; padding: 1rem; box-shadow: 0 1px 3px rgba(0, 0, 0, 0.1); white-space: pre-wrap; }
+.note img { display: block; max-width: 100%; margin-top: 0.75rem; border-radius: 0.25rem; }
+footer { text-align: center; margin-top: 2rem; font-size: 0.875rem; color: #666; }
+</style>
+</head>
+<body>
+<h1>{{if .Title}}{{.Title}}{{else}}Gratitude wall{{end}}</h1>
+<ul class="notes">
+{{range .Notes}}<li class="note">{{.Content}}{{if .GIFURL}}<img src="{{.GIFURL}}" alt="" loading="lazy">{{end}}</li>
+{{end}}</ul>
+<footer>Shared with permission from the people who wrote them. Available until {{.ExpiresAt.Format "2 January 2006"}}.</footer>
+</body>
+</html>
+`))
+
+// ServeHTTP renders the wall named in the path, e.g. /wall/<token>
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	wall, ok := s.Get(strings.TrimPrefix(r.URL.Path, PathPrefix))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; img-src https:")
+	if err := page.Execute(w, wall); err != nil {
+		slog.Error("Failed to render gratitude wall", "err", err)
+	}
+}
+
+// randomToken returns an unguessable wall token
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
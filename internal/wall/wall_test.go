@@ -0,0 +1,63 @@
+package wall
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPublishAndServe(t *testing.T) {
+	store := NewStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	published, err := store.Publish("session-1", "Year-end thanks", []Note{{Content: "You made <b>launch</b> week fun"}}, 0)
+	if err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if !published.ExpiresAt.Equal(now.Add(DefaultExpiry)) {
+		t.Errorf("Expected the default expiry, got %v", published.ExpiresAt)
+	}
+
+	rec := httptest.NewRecorder()
+	store.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, published.URL(), nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Year-end thanks") {
+		t.Fatalf("Expected the wall page, got %d: %s", rec.Code, body)
+	}
+	if !strings.Contains(body, "You made &lt;b&gt;launch&lt;/b&gt; week fun") {
+		t.Error("Expected note content to be escaped")
+	}
+
+	// Walls disappear once expired
+	now = now.Add(DefaultExpiry)
+	rec = httptest.NewRecorder()
+	store.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, published.URL(), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an expired wall, got %d", rec.Code)
+	}
+}
+
+func TestPublishLimits(t *testing.T) {
+	store := NewStore()
+
+	if _, err := store.Publish("session-1", "", nil, 0); err == nil {
+		t.Error("Expected an empty wall to be refused")
+	}
+	if _, err := store.Publish("session-1", "", []Note{{Content: "Thanks"}}, MaxExpiry+time.Hour); err == nil {
+		t.Error("Expected an expiry over the maximum to be refused")
+	}
+
+	published, _ := store.Publish("session-1", "", []Note{{Content: "Thanks"}}, time.Hour)
+	if err := store.Remove("session-2", published.Token); err == nil {
+		t.Error("Expected another session not to remove the wall")
+	}
+	if err := store.Remove("session-1", published.Token); err != nil {
+		t.Errorf("Expected the owner to remove the wall: %v", err)
+	}
+	if _, ok := store.Get(published.Token); ok {
+		t.Error("Expected the removed wall to be gone")
+	}
+}
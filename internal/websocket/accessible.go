@@ -0,0 +1,193 @@
+// ABOUTME: Accessible rendering of outbound messages for clients that negotiated the a11y capability
+// ABOUTME: Adds a plain-text announcement and a reading-order hint so screen readers needn't interpret the UI
+package websocket
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// readingOrders lists, per message type, the data fields worth reading first
+// Fields not listed follow in alphabetical order
+var readingOrders = map[string][]string{
+	"session_created":    {"title", "sessionCode", "userName", "phase", "participants"},
+	"session_joined":     {"title", "userName", "phase", "participants"},
+	"participant_joined": {"participant", "participants"},
+	"participant_left":   {"participant", "wasHost", "participants"},
+	"phase_changed":      {"phase", "currentReader", "prompt", "welcome", "totalNotesNeeded", "participants"},
+	"phase_starting_in":  {"phase", "seconds"},
+	"writing_reopened":   {"message", "phase", "participants"},
+	"turn_changed":       {"reader", "remaining", "total"},
+	"note_drawn":         {"note", "remaining", "total"},
+	"session_complete":   {"message", "notes", "missedNotes"},
+	"error":              {"message", "code"},
+}
+
+// accessible returns a copy of msg with an "announcement" describing the event in
+// plain text, without relying on colour or emoji, and a "readingOrder" listing its
+// data fields in the order assistive tech should present them
+func accessible(msg *Message) *Message {
+	adapted := *msg
+	adapted.Data = make(map[string]interface{}, len(msg.Data)+2)
+	for key, value := range msg.Data {
+		adapted.Data[key] = value
+	}
+	adapted.Data["announcement"] = announce(msg)
+	adapted.Data["readingOrder"] = readingOrder(msg)
+	return &adapted
+}
+
+// announce describes a message as a sentence a screen reader can speak
+func announce(msg *Message) string {
+	data := msg.Data
+	text := func(key string) string {
+		value, _ := data[key].(string)
+		return value
+	}
+
+	switch msg.Type {
+	case "session_created":
+		return fmt.Sprintf("You created %s. Share the code %s so others can join.", sessionName(text("title")), spellCode(text("sessionCode")))
+	case "session_joined":
+		return fmt.Sprintf("You joined %s as %s. %s.", sessionName(text("title")), text("userName"), countParticipants(data["participants"]))
+	case "participant_joined":
+		return fmt.Sprintf("%s joined. %s.", participantName(data["participant"]), countParticipants(data["participants"]))
+	case "participant_left":
+		if wasHost, _ := data["wasHost"].(bool); wasHost {
+			return fmt.Sprintf("%s, the host, left. %s.", participantName(data["participant"]), countParticipants(data["participants"]))
+		}
+		return fmt.Sprintf("%s left. %s.", participantName(data["participant"]), countParticipants(data["participants"]))
+	case "participant_renamed":
+		return fmt.Sprintf("A participant is now called %s.", participantName(data["participant"]))
+	case "session_title_changed":
+		return fmt.Sprintf("The session is now called %s.", text("title"))
+	case "prompt_changed":
+		if text("prompt") == "" {
+			return "The writing prompt was cleared."
+		}
+		return "The writing prompt is now: " + text("prompt")
+	case "phase_changed":
+		return announcePhase(data)
+	case "phase_starting_in":
+		return fmt.Sprintf("%s starts in %v seconds.", phaseName(data["phase"]), data["seconds"])
+	case "turn_changed":
+		return fmt.Sprintf("It is %s's turn to read. %v notes left.", participantName(data["reader"]), data["remaining"])
+	case "note_drawn":
+		note, _ := data["note"].(map[string]interface{})
+		content, _ := note["content"].(string)
+		recipient, _ := note["recipient"].(string)
+		return fmt.Sprintf("A note for %s: %s", recipient, content)
+	case "notes_submitted":
+		return "Your notes were submitted."
+	case "error":
+		return "Error: " + text("message")
+	}
+
+	if message := text("message"); message != "" {
+		return message
+	}
+	return "Update: " + strings.ReplaceAll(msg.Type, "_", " ") + "."
+}
+
+// announcePhase describes a phase change, including why it happened if it went backwards
+func announcePhase(data map[string]interface{}) string {
+	var sentence string
+	phase, _ := data["phase"].(session.Phase)
+	if name, ok := data["phase"].(string); ok {
+		// Relayed from another replica, so decoded from JSON
+		phase = session.Phase(name)
+	}
+	switch phase {
+	case session.PhaseJoining:
+		sentence = "Waiting for participants to join."
+	case session.PhaseWriting:
+		sentence = fmt.Sprintf("Writing has started. Write a note for each of the other %v participants.", data["totalNotesNeeded"])
+	case session.PhaseReading:
+		sentence = fmt.Sprintf("Reading has started. %s reads first.", participantName(data["currentReader"]))
+	default:
+		sentence = phaseName(phase) + " has started."
+	}
+
+	if undone, _ := data["undone"].(bool); undone {
+		return "The host undid the last step. " + sentence
+	}
+	if rolledBack, _ := data["rolledBack"].(bool); rolledBack {
+		return "The session was moved back. " + sentence
+	}
+	return sentence
+}
+
+// readingOrder returns the message's data fields, most important first
+func readingOrder(msg *Message) []string {
+	order := []string{"announcement"}
+	listed := map[string]bool{"announcement": true, "readingOrder": true}
+	for _, key := range readingOrders[msg.Type] {
+		if _, ok := msg.Data[key]; ok {
+			order = append(order, key)
+			listed[key] = true
+		}
+	}
+
+	rest := make([]string, 0, len(msg.Data))
+	for key := range msg.Data {
+		if !listed[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(order, rest...)
+}
+
+// sessionName names a session by its title, if it has one
+func sessionName(title string) string {
+	if title == "" {
+		return "the session"
+	}
+	return "the session " + title
+}
+
+// spellCode separates a session code's characters so it is read letter by letter
+func spellCode(code string) string {
+	return strings.Join(strings.Split(code, ""), " ")
+}
+
+// phaseName is a phase in words, e.g. "Writing"
+func phaseName(value interface{}) string {
+	phase := fmt.Sprint(value)
+	if phase == "" {
+		return "The next phase"
+	}
+	return strings.ToUpper(phase[:1]) + strings.ToLower(phase[1:])
+}
+
+// participantName reads a participant's name from message data
+func participantName(value interface{}) string {
+	switch p := value.(type) {
+	case *session.Participant:
+		if p != nil {
+			return p.Name
+		}
+	case map[string]interface{}:
+		name, _ := p["name"].(string)
+		return name
+	}
+	return "Someone"
+}
+
+// countParticipants describes how many participants a list holds
+func countParticipants(value interface{}) string {
+	count := 0
+	switch list := value.(type) {
+	case []*session.Participant:
+		count = len(list)
+	case []interface{}:
+		count = len(list)
+	}
+	if count == 1 {
+		return "1 participant"
+	}
+	return fmt.Sprintf("%d participants", count)
+}
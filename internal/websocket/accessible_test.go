@@ -0,0 +1,50 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestAccessibleCapability(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.HandleMessage(aliceClient, &Message{Type: "hello", Data: map[string]interface{}{
+		"capabilities": []interface{}{"a11y", "telepathy"},
+	}})
+	reply := nextMessage(t, aliceClient)
+	granted, _ := reply.Data["capabilities"].([]interface{})
+	if reply.Type != "hello" || len(granted) != 1 || granted[0] != "a11y" {
+		t.Fatalf("Expected only a11y to be granted, got %+v", reply)
+	}
+
+	sess.TransitionToWriting()
+	mh.broadcastWritingStarted(sess)
+
+	plain := nextMessage(t, host)
+	if _, ok := plain.Data["announcement"]; ok {
+		t.Errorf("Expected no announcement without the capability, got %+v", plain.Data)
+	}
+
+	adapted := nextMessage(t, aliceClient)
+	if adapted.Data["announcement"] != "Writing has started. Write a note for each of the other 1 participants." {
+		t.Errorf("Unexpected announcement: %v", adapted.Data["announcement"])
+	}
+	order, _ := adapted.Data["readingOrder"].([]interface{})
+	if len(order) < 2 || order[0] != "announcement" || order[1] != "phase" {
+		t.Errorf("Expected the announcement then the phase first, got %v", order)
+	}
+
+	// Messages sent to one client are adapted too, falling back to the message text
+	mh.sendError(aliceClient, "session not found")
+	if msg := nextMessage(t, aliceClient); msg.Data["announcement"] != "Error: session not found" {
+		t.Errorf("Expected an error announcement, got %+v", msg.Data)
+	}
+}
@@ -0,0 +1,123 @@
+// ABOUTME: Acknowledgements for critical messages, for clients that negotiated the ack capability
+// ABOUTME: Unacknowledged messages are resent a couple of times, then the client is flagged as missing them
+package websocket
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// ackTimeout is how long a client has to acknowledge a critical message before it's resent
+	ackTimeout = 5 * time.Second
+
+	// maxAckResends is how many times an unacknowledged message is resent before giving up
+	maxAckResends = 2
+
+	// ackCheckInterval is how often outstanding acknowledgements are checked
+	ackCheckInterval = time.Second
+)
+
+// criticalMessages are the message types a client must acknowledge: missing one leaves
+// it in the wrong phase, showing the wrong note, or in a session it was removed from
+var criticalMessages = map[string]bool{
+	"phase_changed": true,
+	"kicked":        true,
+	"note_drawn":    true,
+}
+
+// pendingAck is a critical message sent to a client and not yet acknowledged
+type pendingAck struct {
+	data    []byte // As encoded for the client, so a resend is identical
+	msgType string
+	sentAt  time.Time
+	resends int
+}
+
+// ackTracker holds a client's outstanding acknowledgements
+// Sends happen on whichever goroutine broadcasts, so it has its own lock
+type ackTracker struct {
+	pending map[uint64]*pendingAck
+	missed  uint64 // Critical messages given up on
+	mu      sync.Mutex
+}
+
+// stampAck gives a critical message an ID for clients to acknowledge, returning a copy
+func (h *Hub) stampAck(message *Message) *Message {
+	if !criticalMessages[message.Type] || message.AckID != 0 {
+		return message
+	}
+	stamped := *message
+	stamped.AckID = h.ackIDs.Add(1)
+	return &stamped
+}
+
+// expectAck starts waiting for a client to acknowledge a critical message it was just sent
+func (c *Client) expectAck(message *Message, data []byte) {
+	if message.AckID == 0 || !c.has(CapabilityAck) {
+		return
+	}
+
+	c.acks.mu.Lock()
+	defer c.acks.mu.Unlock()
+	if c.acks.pending == nil {
+		c.acks.pending = make(map[uint64]*pendingAck)
+	}
+	c.acks.pending[message.AckID] = &pendingAck{data: data, msgType: message.Type, sentAt: time.Now()}
+}
+
+// handleAck records a client's acknowledgement; unknown or repeated IDs are ignored
+func (mh *MessageHandler) handleAck(client *Client, req *ackRequest) {
+	client.acks.mu.Lock()
+	defer client.acks.mu.Unlock()
+
+	delete(client.acks.pending, req.AckID)
+}
+
+// checkAcks resends critical messages that weren't acknowledged in time, and flags
+// clients that still haven't acknowledged them after maxAckResends
+// Runs on the hub goroutine
+func (h *Hub) checkAcks(now time.Time) {
+	for client := range h.connections {
+		var resend [][]byte
+		var missed []string
+
+		client.acks.mu.Lock()
+		// Oldest first, so resends keep their original order
+		for _, id := range slices.Sorted(maps.Keys(client.acks.pending)) {
+			pending := client.acks.pending[id]
+			if now.Sub(pending.sentAt) < ackTimeout {
+				continue
+			}
+			if pending.resends >= maxAckResends {
+				delete(client.acks.pending, id)
+				client.acks.missed++
+				missed = append(missed, pending.msgType)
+				continue
+			}
+			pending.resends++
+			pending.sentAt = now
+			resend = append(resend, pending.data)
+		}
+		client.acks.mu.Unlock()
+
+		for _, data := range resend {
+			h.counters.ackResends.Add(1)
+			client.SendRaw(data)
+		}
+		for _, msgType := range missed {
+			h.counters.ackFailures.Add(1)
+			client.logger().Warn("Client never acknowledged critical message", "messageType", msgType, "attempts", maxAckResends+1)
+		}
+	}
+}
+
+// missedAcks returns how many critical messages the client never acknowledged
+func (c *Client) missedAcks() uint64 {
+	c.acks.mu.Lock()
+	defer c.acks.mu.Unlock()
+
+	return c.acks.missed
+}
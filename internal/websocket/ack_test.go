@@ -0,0 +1,71 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCriticalMessagesResentUntilAcknowledged(t *testing.T) {
+	hub := NewHub(nil)
+	mh := NewMessageHandler(hub, nil)
+	acking := newTestClient(hub, "session-1", "alice")
+	acking.capabilities.Store(capabilityBits[CapabilityAck])
+	legacy := newTestClient(hub, "session-1", "bob")
+	hub.connections[acking] = true
+	hub.connections[legacy] = true
+
+	hub.BroadcastToSession("session-1", &Message{Type: "participant_joined"})
+	hub.BroadcastToSession("session-1", &Message{Type: "phase_changed", Data: map[string]interface{}{"phase": "READING"}})
+	if msg := nextMessage(t, acking); msg.AckID != 0 {
+		t.Errorf("Expected no ackId on a routine message, got %d", msg.AckID)
+	}
+	original := <-acking.send
+	drain([]*Client{legacy})
+
+	// Not acknowledged in time, so it's resent as it was
+	hub.checkAcks(time.Now().Add(ackTimeout))
+	select {
+	case resent := <-acking.send:
+		if !bytes.Equal(resent, original) {
+			t.Errorf("Expected the same message resent, got %s", resent)
+		}
+	default:
+		t.Fatal("Expected the phase change resent")
+	}
+	if len(legacy.send) != 0 {
+		t.Error("Expected clients without the ack capability not to get resends")
+	}
+
+	// Once acknowledged it stops
+	var msg Message
+	json.Unmarshal(original, &msg)
+	mh.HandleMessage(acking, &Message{Type: "ack", Data: map[string]interface{}{"ackId": msg.AckID}})
+	hub.checkAcks(time.Now().Add(2 * ackTimeout))
+	if len(acking.send) != 0 {
+		t.Error("Expected no resend after the ack")
+	}
+}
+
+func TestUnacknowledgedMessagesAreFlagged(t *testing.T) {
+	hub := NewHub(nil)
+	client := newTestClient(hub, "session-1", "alice")
+	client.capabilities.Store(capabilityBits[CapabilityAck])
+	hub.connections[client] = true
+
+	hub.SendToUser("session-1", "alice", &Message{Type: "kicked"})
+	now := time.Now()
+	for i := 0; i <= maxAckResends; i++ {
+		now = now.Add(ackTimeout)
+		hub.checkAcks(now)
+	}
+
+	if got := len(client.send); got != maxAckResends+1 {
+		t.Errorf("Expected the original and %d resends, got %d messages", maxAckResends, got)
+	}
+	metrics := hub.Metrics()
+	if metrics.AckResends != maxAckResends || metrics.AckFailures != 1 || metrics.Clients[0].MissedAcks != 1 {
+		t.Errorf("Expected the client flagged for missing the kick, got %+v", metrics)
+	}
+}
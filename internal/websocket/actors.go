@@ -0,0 +1,176 @@
+// ABOUTME: Per-session actors that handle each session's messages and timers on their own goroutine
+// ABOUTME: Sessions no longer queue behind each other; work spanning sessions still runs on the hub goroutine
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// actorInboxSize is how much work can queue for a session before senders wait
+	actorInboxSize = 64
+
+	// actorIdleTimeout is how long an actor with nothing to do waits before exiting
+	actorIdleTimeout = time.Minute
+)
+
+// exclusiveMessages are message types whose handlers reach beyond the sender's session,
+// creating, joining, splitting or merging sessions; they run on the hub goroutine
+var exclusiveMessages = map[string]bool{
+	"create_session":      true,
+	"join_session":        true,
+	"rejoin_session":      true,
+	"create_breakouts":    true,
+	"get_breakout_status": true,
+	"merge_session":       true,
+	"split_session":       true,
+}
+
+// sessionActor runs one session's work in order on its own goroutine
+type sessionActor struct {
+	inbox   chan func()
+	pending int // Tasks queued or being queued (guarded by Hub.actorsMu)
+}
+
+// ScheduleSession queues a function to run on a session's actor, serialised with the
+// session's message handling but not with other sessions'; with no session it runs
+// on the hub goroutine instead
+// Tasks must not call it and wait for the result, since the inbox may be full
+func (h *Hub) ScheduleSession(sessionID string, task func()) {
+	if sessionID == "" {
+		h.Schedule(task)
+		return
+	}
+
+	h.actorsMu.Lock()
+	actor, exists := h.actors[sessionID]
+	if !exists {
+		actor = &sessionActor{inbox: make(chan func(), actorInboxSize)}
+		h.actors[sessionID] = actor
+		go h.runActor(sessionID, actor)
+	}
+	actor.pending++
+	h.actorsMu.Unlock()
+
+	actor.inbox <- task
+}
+
+// runActor handles a session's tasks until it has been idle for actorIdleTimeout
+// Tasks hold the world lock for reading, so work on the hub goroutine never overlaps them
+func (h *Hub) runActor(sessionID string, actor *sessionActor) {
+	idle := time.NewTimer(actorIdleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case task := <-actor.inbox:
+			h.world.RLock()
+			task()
+			h.world.RUnlock()
+
+			h.actorsMu.Lock()
+			actor.pending--
+			h.actorsMu.Unlock()
+			idle.Reset(actorIdleTimeout)
+
+		case <-idle.C:
+			h.actorsMu.Lock()
+			if actor.pending == 0 {
+				delete(h.actors, sessionID)
+				h.actorsMu.Unlock()
+				return
+			}
+			h.actorsMu.Unlock()
+			idle.Reset(actorIdleTimeout)
+		}
+	}
+}
+
+// exclusive runs task on the hub goroutine with every actor paused between tasks,
+// so it can touch any session
+func (h *Hub) exclusive(task func()) {
+	h.world.Lock()
+	defer h.world.Unlock()
+
+	task()
+}
+
+// submit routes a message read from a client to the actor of its session, or to the
+// hub goroutine if the client hasn't joined one or the message reaches beyond it
+func (h *Hub) submit(clientMsg *ClientMessage) {
+	sessionID := clientMsg.message.SessionID
+	if sessionID == "" || exclusiveMessages[clientMsg.message.Type] {
+		h.process <- clientMsg
+		return
+	}
+	h.ScheduleSession(sessionID, func() { h.handle(clientMsg) })
+}
+
+// handle passes a client's message to the message handler
+func (h *Hub) handle(clientMsg *ClientMessage) {
+	if h.messageHandler != nil {
+		h.messageHandler(clientMsg.client, clientMsg.message)
+	}
+	clientMsg.client.recordProcessing(clientMsg.received)
+}
+
+// actorQueues returns how many sessions have a running actor and how many tasks are waiting for them
+func (h *Hub) actorQueues() (actors, queued int) {
+	h.actorsMu.Lock()
+	defer h.actorsMu.Unlock()
+
+	for _, actor := range h.actors {
+		queued += len(actor.inbox)
+	}
+	return len(h.actors), queued
+}
+
+// perSession is a map keyed by session ID that actors for different sessions can share
+type perSession[V any] struct {
+	values map[string]V
+	mu     sync.Mutex
+}
+
+func newPerSession[V any]() *perSession[V] {
+	return &perSession[V]{values: make(map[string]V)}
+}
+
+// get returns the session's value, or the zero value if it has none
+func (p *perSession[V]) get(sessionID string) V {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.values[sessionID]
+}
+
+// set replaces the session's value
+func (p *perSession[V]) set(sessionID string, value V) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.values[sessionID] = value
+}
+
+// update replaces the session's value with change applied to it, returning the new value
+func (p *perSession[V]) update(sessionID string, change func(V) V) V {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	value := change(p.values[sessionID])
+	p.values[sessionID] = value
+	return value
+}
+
+// delete removes the session's value
+func (p *perSession[V]) delete(sessionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.values, sessionID)
+}
+
+// increment adds one, for counting with perSession.update
+func increment(n int) int {
+	return n + 1
+}
@@ -0,0 +1,87 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlowSessionDoesNotDelayAnother(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan string, 2)
+	hub := NewHub(func(client *Client, msg *Message) {
+		if msg.SessionID == "slow" {
+			<-release
+		}
+		handled <- msg.SessionID
+	})
+	go hub.Run()
+
+	slow := newTestClient(hub, "slow", "alice")
+	fast := newTestClient(hub, "fast", "bob")
+	hub.submit(&ClientMessage{client: slow, message: &Message{Type: "submit_notes", SessionID: "slow"}})
+	hub.submit(&ClientMessage{client: fast, message: &Message{Type: "submit_notes", SessionID: "fast"}})
+
+	select {
+	case sessionID := <-handled:
+		if sessionID != "fast" {
+			t.Fatalf("Expected the fast session's message first, got %s", sessionID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Fast session's message waited behind the slow one")
+	}
+
+	close(release)
+	if sessionID := <-handled; sessionID != "slow" {
+		t.Errorf("Expected the slow session's message to finish, got %s", sessionID)
+	}
+}
+
+func TestSessionTasksRunInOrder(t *testing.T) {
+	hub := NewHub(nil)
+
+	order := make(chan int, 20)
+	for i := range 20 {
+		hub.ScheduleSession("sess", func() { order <- i })
+	}
+	for i := range 20 {
+		if got := <-order; got != i {
+			t.Fatalf("Expected task %d, got %d", i, got)
+		}
+	}
+
+	if metrics := hub.Metrics(); metrics.SessionActors != 1 {
+		t.Errorf("Expected one session actor, got %d", metrics.SessionActors)
+	}
+}
+
+func TestExclusiveWorkWaitsForActors(t *testing.T) {
+	hub := NewHub(func(client *Client, msg *Message) {})
+	go hub.Run()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	hub.ScheduleSession("sess", func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	// Joining reaches beyond the sender's session, so it waits for the session to be idle
+	done := make(chan struct{})
+	client := newTestClient(hub, "sess", "alice")
+	hub.submit(&ClientMessage{client: client, message: &Message{Type: "join_session", SessionID: "sess"}})
+	hub.Schedule(func() { close(done) })
+
+	select {
+	case <-done:
+		t.Fatal("Expected hub work to wait for the running session task")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Hub work never ran after the session task finished")
+	}
+}
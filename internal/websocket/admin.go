@@ -0,0 +1,82 @@
+// ABOUTME: Operator actions on live sessions for the admin API
+// ABOUTME: Force-completes or closes a session and removes participants, without a restart
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// ForceCompleteSession ends the session with the given code early, sending everyone the
+// notes written so far as if reading had finished
+// Safe to call from any goroutine; the work runs on the session's actor
+func (mh *MessageHandler) ForceCompleteSession(code string) error {
+	return mh.onSession(code, func(sess *session.Session) error {
+		unread, err := sess.ForceComplete()
+		if err != nil {
+			return err
+		}
+		mh.cancelCountdown(sess)
+		mh.cancelAutoRun(sess)
+		mh.broadcastSessionComplete(sess)
+
+		sessionLogger(sess).Info("Session completed by admin", "unreadNotes", unread)
+		return nil
+	})
+}
+
+// CloseSession tells everyone in the session with the given code that it has been closed
+// and removes it, so the code stops working
+// Safe to call from any goroutine; the work runs on the session's actor
+func (mh *MessageHandler) CloseSession(code string) error {
+	return mh.onSession(code, func(sess *session.Session) error {
+		mh.cancelCountdown(sess)
+		mh.hub.BroadcastToSession(sess.ID, &Message{
+			Type: "session_closed",
+			Data: map[string]interface{}{
+				"message": "This session has been closed by an administrator",
+			},
+		})
+
+		if sess.Phase != session.PhaseComplete {
+			mh.analytics.RecordSessionAbandoned()
+			mh.recordHostCircle(sess, false)
+		}
+		mh.autoRunSteps.delete(sess.ID)
+		if err := mh.sessionManager.RemoveSession(sess.ID); err != nil {
+			return err
+		}
+
+		sessionLogger(sess).Info("Session closed by admin")
+		return nil
+	})
+}
+
+// KickParticipant removes a participant from the session with the given code for good
+// Removing the host hands hosting to someone else
+// Safe to call from any goroutine; the work runs on the session's actor
+func (mh *MessageHandler) KickParticipant(code, participantID string) error {
+	return mh.onSession(code, func(sess *session.Session) error {
+		participant, err := mh.removeParticipant(sess, participantID, "admin")
+		if err != nil {
+			return err
+		}
+
+		sessionLogger(sess).Info("Participant removed by admin", "participantID", participant.ID)
+		return nil
+	})
+}
+
+// onSession looks up a session by code and runs action on it on the session's actor,
+// waiting for its result
+func (mh *MessageHandler) onSession(code string, action func(sess *session.Session) error) error {
+	sess, err := mh.sessionManager.GetSessionByCode(code)
+	if err != nil {
+		return err
+	}
+
+	result := make(chan error, 1)
+	mh.hub.ScheduleSession(sess.ID, func() {
+		result <- action(sess)
+	})
+	return <-result
+}
@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestForceCompleteSession(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks for the pairing")
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	if err := mh.ForceCompleteSession(sess.Code); err != nil {
+		t.Fatalf("ForceCompleteSession: %v", err)
+	}
+
+	reply := nextMessage(t, aliceClient)
+	if reply.Type != "session_complete" {
+		t.Fatalf("Expected session_complete, got %+v", reply)
+	}
+	if notes, _ := reply.Data["notes"].([]interface{}); len(notes) != 1 {
+		t.Errorf("Expected the note written so far to be delivered, got %v", reply.Data["notes"])
+	}
+	if sess.Phase != session.PhaseComplete {
+		t.Errorf("Expected session to be complete, got %s", sess.Phase)
+	}
+
+	if err := mh.ForceCompleteSession(sess.Code); err == nil {
+		t.Error("Expected completing a complete session to fail")
+	}
+}
+
+func TestCloseSession(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+
+	if err := mh.CloseSession(sess.Code); err != nil {
+		t.Fatalf("CloseSession: %v", err)
+	}
+	if reply := nextMessage(t, host); reply.Type != "session_closed" {
+		t.Errorf("Expected session_closed, got %+v", reply)
+	}
+	if _, err := manager.GetSessionByCode(sess.Code); err == nil {
+		t.Error("Expected closed session to be removed")
+	}
+	if err := mh.CloseSession(sess.Code); err == nil {
+		t.Error("Expected closing an unknown session to fail")
+	}
+}
+
+func TestKickParticipant(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	// Removing the host hands hosting on
+	if err := mh.KickParticipant(sess.Code, sess.HostID); err != nil {
+		t.Fatalf("KickParticipant: %v", err)
+	}
+	if reply := nextMessage(t, host); reply.Type != "kicked" {
+		t.Errorf("Expected kicked, got %+v", reply)
+	}
+	reply := nextMessage(t, aliceClient)
+	if reply.Type != "participant_left" || reply.Data["wasHost"] != true || reply.Data["wasRemoved"] != true {
+		t.Errorf("Expected participant_left for the removed host, got %+v", reply)
+	}
+	if sess.HostID != alice.ID {
+		t.Errorf("Expected Alice to become host, got %s", sess.HostID)
+	}
+
+	if err := mh.KickParticipant(sess.Code, "nobody"); err == nil {
+		t.Error("Expected removing an unknown participant to fail")
+	}
+
+	// Removing the last participant cleans the session up
+	if err := mh.KickParticipant(sess.Code, alice.ID); err != nil {
+		t.Fatalf("KickParticipant: %v", err)
+	}
+	if _, err := manager.GetSessionByCode(sess.Code); err == nil {
+		t.Error("Expected the emptied session to be removed")
+	}
+}
@@ -0,0 +1,73 @@
+// ABOUTME: Sticky load-balancer support so all of a session's clients reach the node holding it
+// ABOUTME: Tags connections and payloads with this instance's ID and flags clients that land elsewhere
+package websocket
+
+import (
+	"net/http"
+)
+
+const (
+	// AffinityCookie is set on upgrade so cookie-based sticky load balancers route back here
+	AffinityCookie = "uplift_instance"
+
+	// AffinityParam is the /ws query parameter clients use to name the instance they want
+	AffinityParam = "instance"
+)
+
+// SetInstanceID names this server instance for load-balancer affinity
+// Must be called before Run; an empty ID disables affinity
+func (h *Hub) SetInstanceID(id string) {
+	h.instanceID = id
+}
+
+// affinity returns the upgrade response headers pinning the client to this instance,
+// and the instance the client asked for, if any
+func (h *Handler) affinity(r *http.Request) (http.Header, string) {
+	if h.hub.instanceID == "" {
+		return nil, ""
+	}
+
+	hint := r.URL.Query().Get(AffinityParam)
+	if hint == "" {
+		if cookie, err := r.Cookie(AffinityCookie); err == nil {
+			hint = cookie.Value
+		}
+	}
+
+	cookie := &http.Cookie{
+		Name:     AffinityCookie,
+		Value:    h.hub.instanceID,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	header := http.Header{}
+	header.Add("Set-Cookie", cookie.String())
+	return header, hint
+}
+
+// onWrongInstance reports whether a session the client asked for is missing because
+// the client reached a different instance than the one it was pinned to
+func (mh *MessageHandler) onWrongInstance(client *Client) bool {
+	instanceID := mh.hub.instanceID
+	return instanceID != "" && client.instanceHint != "" && client.instanceHint != instanceID
+}
+
+// sendWrongInstance tells a misrouted client to reconnect to its session's instance
+func (mh *MessageHandler) sendWrongInstance(client *Client) {
+	client.SendMessage(&Message{
+		Type: "error",
+		Data: map[string]interface{}{
+			"code":       "wrong_instance",
+			"message":    "This session is on another server. Reconnect to reach it.",
+			"instanceId": client.instanceHint,
+		},
+	})
+}
+
+// addInstance adds this instance's ID to a session payload so clients can pin to it
+func (mh *MessageHandler) addInstance(data map[string]interface{}) {
+	if mh.hub.instanceID != "" {
+		data["instanceId"] = mh.hub.instanceID
+	}
+}
@@ -0,0 +1,57 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestAffinityCookieAndHint(t *testing.T) {
+	hub := NewHub(nil)
+	hub.SetInstanceID("node-a")
+	handler := NewHandler(hub)
+
+	req := httptest.NewRequest(http.MethodGet, "/ws?instance=node-b", nil)
+	header, hint := handler.affinity(req)
+	if hint != "node-b" {
+		t.Errorf("Expected hint from the query, got %q", hint)
+	}
+	response := http.Response{Header: header}
+	cookies := response.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != AffinityCookie || cookies[0].Value != "node-a" {
+		t.Errorf("Expected affinity cookie for node-a, got %v", cookies)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.AddCookie(&http.Cookie{Name: AffinityCookie, Value: "node-c"})
+	if _, hint := handler.affinity(req); hint != "node-c" {
+		t.Errorf("Expected hint from the cookie, got %q", hint)
+	}
+}
+
+func TestWrongInstance(t *testing.T) {
+	hub := NewHub(nil)
+	hub.SetInstanceID("node-a")
+	mh := NewMessageHandler(hub, session.NewManager())
+
+	client := &Client{send: make(chan []byte, 16), hub: hub, instanceHint: "node-b"}
+	mh.HandleMessage(client, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": "ABC123", "userName": "Alice"}})
+	if msg := nextMessage(t, client); msg.Data["code"] != "wrong_instance" || msg.Data["instanceId"] != "node-b" {
+		t.Errorf("Expected wrong_instance naming node-b, got %+v", msg)
+	}
+
+	mh.HandleMessage(client, &Message{Type: "create_session", Data: map[string]interface{}{"userName": "Host"}})
+	if msg := nextMessage(t, client); msg.Type != "session_created" || msg.Data["instanceId"] != "node-a" {
+		t.Errorf("Expected session_created with instanceId, got %+v", msg)
+	}
+
+	// Without affinity a missing session is just missing
+	plain := NewMessageHandler(NewHub(nil), session.NewManager())
+	other := &Client{send: make(chan []byte, 16), hub: hub, instanceHint: "node-b"}
+	plain.HandleMessage(other, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": "ABC123", "userName": "Alice"}})
+	if msg := nextMessage(t, other); msg.Data["code"] == "wrong_instance" {
+		t.Error("Expected plain session not found when affinity is off")
+	}
+}
@@ -0,0 +1,79 @@
+// ABOUTME: Host command to download a completed circle as a standalone HTML archive
+// ABOUTME: Sent over the WebSocket so only the connected host can export, with no download URL to leak
+package websocket
+
+import (
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/archive"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// handleExportArchive renders the completed session's recap and sends it to the host
+// The client saves the html field as a file named filename
+func (mh *MessageHandler) handleExportArchive(client *Client) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if client.userID != sess.HostID {
+		mh.sendError(client, "only host can export the circle")
+		return
+	}
+
+	if sess.Phase != session.PhaseComplete {
+		mh.sendError(client, "the circle can only be exported once it is complete")
+		return
+	}
+
+	recap := sessionRecap(sess)
+	recap.Branding = mh.branding.Get(sess.Tenant)
+	html, err := archive.Render(recap)
+	if err != nil {
+		client.logger().Error("Failed to export archive", "err", err)
+		mh.sendError(client, "failed to export the circle")
+		return
+	}
+
+	client.SendMessage(&Message{
+		Type: "archive_export",
+		Data: map[string]interface{}{
+			"filename":    archive.Filename(recap),
+			"contentType": "text/html",
+			"html":        string(html),
+		},
+	})
+	client.logger().Info("Archive exported", "bytes", len(html))
+}
+
+// sessionRecap collects the read-aloud notes by recipient, in participant order
+// Private notes stay with their recipients and are left out
+func sessionRecap(sess *session.Session) archive.Recap {
+	recap := archive.Recap{
+		Title:       sess.Title,
+		CompletedAt: time.Now(),
+	}
+	if sess.CompletedAt != nil {
+		recap.CompletedAt = *sess.CompletedAt
+	}
+	if theme, ok := session.LookupTheme(sess.Theme); ok {
+		recap.Theme = theme.Name
+	}
+
+	for _, participant := range sess.GetParticipantList() {
+		recap.Participants = append(recap.Participants, participant.Name)
+
+		recipient := archive.Recipient{Name: participant.Name}
+		for _, note := range sess.Notes {
+			if note.RecipientID == participant.ID && !note.Private {
+				recipient.Notes = append(recipient.Notes, archive.Note{Content: note.Content, GIFURL: note.GIFURL})
+			}
+		}
+		if len(recipient.Notes) > 0 {
+			recap.Recipients = append(recap.Recipients, recipient)
+		}
+	}
+	return recap
+}
@@ -0,0 +1,44 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestExportArchive(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	sess.SetTitle("Launch Party")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, sess.HostID, "Thanks for steering the launch")
+	sess.AddPrivateNote(sess.HostID, alice.ID, "Just between us: you were brilliant")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.HandleMessage(host, &Message{Type: "export_archive"})
+	if reply := nextMessage(t, host); reply.Type != "error" {
+		t.Errorf("Expected export to wait for completion, got %+v", reply)
+	}
+
+	sess.Phase = session.PhaseComplete
+	mh.HandleMessage(aliceClient, &Message{Type: "export_archive"})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected a participant to be refused, got %+v", reply)
+	}
+
+	mh.HandleMessage(host, &Message{Type: "export_archive"})
+	exported := nextMessage(t, host)
+	html, _ := exported.Data["html"].(string)
+	if exported.Type != "archive_export" || !strings.HasPrefix(exported.Data["filename"].(string), "launch-party-") {
+		t.Fatalf("Expected an archive export, got %+v", exported)
+	}
+	if !strings.Contains(html, "Thanks for steering the launch") || strings.Contains(html, "brilliant") {
+		t.Error("Expected read-aloud notes in the archive and private notes left out")
+	}
+}
@@ -0,0 +1,95 @@
+// ABOUTME: Facilitator-less auto-run mode that advances sessions on timers
+// ABOUTME: Starts writing, draws notes, and finishes turns without host or reader commands
+package websocket
+
+import (
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+const (
+	// How long the lobby stays open before writing starts automatically
+	autoRunLobbyWait = 2 * time.Minute
+
+	// Pause between turns before the next note is drawn
+	autoRunDrawDelay = 5 * time.Second
+
+	// How long a drawn note stays up before it is marked read
+	autoRunReadTime = 30 * time.Second
+)
+
+// scheduleAutoStart starts writing once the lobby wait has passed, retrying
+// until at least two participants have joined
+func (mh *MessageHandler) scheduleAutoStart(sess *session.Session) {
+	mh.scheduleAutoRun(sess, autoRunLobbyWait, func() {
+		if sess.Phase != session.PhaseJoining || mh.countingDown(sess.ID) {
+			return
+		}
+
+		if err := sess.CanTransitionToWriting(); err != nil {
+			sessionLogger(sess).Debug("Auto-run waiting for participants")
+			mh.scheduleAutoStart(sess)
+			return
+		}
+
+		sessionLogger(sess).Info("Auto-run starting writing phase")
+		mh.startWriting(nil, sess)
+	})
+}
+
+// scheduleAutoDraw draws a note for the current reader after a short pause
+func (mh *MessageHandler) scheduleAutoDraw(sess *session.Session) {
+	mh.scheduleAutoRun(sess, autoRunDrawDelay, func() {
+		reader := sess.GetCurrentReader()
+		if reader == nil {
+			return
+		}
+
+		sessionLogger(sess).Info("Auto-run drawing note", "readerID", reader.ID)
+		mh.drawNote(sess, reader.ID)
+	})
+}
+
+// scheduleAutoRead marks a drawn note as read and advances the turn after the reading time
+func (mh *MessageHandler) scheduleAutoRead(sess *session.Session, noteID string) {
+	mh.scheduleAutoRun(sess, autoRunReadTime, func() {
+		if sess.Phase != session.PhaseReading {
+			return
+		}
+
+		if err := sess.MarkNoteAsRead(noteID); err != nil {
+			sessionLogger(sess).Error("Failed to mark note as read", "noteID", noteID, "err", err)
+		}
+
+		sessionLogger(sess).Info("Auto-run finishing turn")
+		mh.advanceTurn(sess)
+	})
+}
+
+// scheduleAutoRun runs step on the session's actor after delay if the session is in
+// auto-run mode. Scheduling a new step supersedes any pending one for the session,
+// so manual actions by readers or the host never race a stale timer.
+func (mh *MessageHandler) scheduleAutoRun(sess *session.Session, delay time.Duration, step func()) {
+	if !sess.AutoRun {
+		return
+	}
+
+	generation := mh.autoRunSteps.update(sess.ID, increment)
+
+	time.AfterFunc(delay, func() {
+		mh.hub.ScheduleSession(sess.ID, func() {
+			if mh.autoRunSteps.get(sess.ID) != generation {
+				return
+			}
+
+			// Stop once the session has been cleaned up
+			if _, err := mh.sessionManager.GetSessionByID(sess.ID); err != nil {
+				mh.autoRunSteps.delete(sess.ID)
+				return
+			}
+
+			step()
+		})
+	})
+}
@@ -0,0 +1,191 @@
+// ABOUTME: Redis pub/sub backplane relaying broadcasts between server replicas
+// ABOUTME: Each session has its own channel, so clients on any replica get its messages
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/redis"
+)
+
+const (
+	// backplaneQueueSize bounds broadcasts waiting to be published; more are dropped
+	backplaneQueueSize = 1024
+
+	// backplanePublishTimeout bounds each publish
+	backplanePublishTimeout = 5 * time.Second
+
+	// backplaneMaxRetry is the longest wait between attempts to resubscribe
+	backplaneMaxRetry = 30 * time.Second
+)
+
+// relayed is a broadcast or direct message passed to the other replicas
+type relayed struct {
+	Origin    string          `json:"origin"` // Replica that sent it, which has already delivered it locally
+	SessionID string          `json:"sessionId"`
+	Except    string          `json:"except,omitempty"` // User left out of a broadcast
+	UserID    string          `json:"userId,omitempty"` // Only user to deliver to
+	Message   *Message        `json:"message,omitempty"`
+	Raw       json.RawMessage `json:"raw,omitempty"` // Already serialized message, from BroadcastRaw
+}
+
+// publication is one relayed message waiting to be published
+type publication struct {
+	channel string
+	payload []byte
+}
+
+// Backplane relays the hub's broadcasts to other replicas over Redis pub/sub and
+// delivers theirs to this replica's clients. Messages reach other replicas' clients
+// after a round trip through Redis, in the order each replica sent them
+type Backplane struct {
+	client   *redis.Client
+	prefix   string // Channels are prefix + "hub:" + session ID
+	origin   string // This replica's ID
+	outbound chan publication
+	dropped  atomic.Uint64
+}
+
+// NewBackplane creates a backplane on client's Redis, naming this replica origin
+func NewBackplane(client *redis.Client, prefix, origin string) *Backplane {
+	return &Backplane{
+		client:   client,
+		prefix:   prefix,
+		origin:   origin,
+		outbound: make(chan publication, backplaneQueueSize),
+	}
+}
+
+// SetBackplane relays broadcasts to other replicas through b; call before Run
+func (h *Hub) SetBackplane(b *Backplane) {
+	h.backplane = b
+}
+
+// channel returns the pub/sub channel for a session
+func (b *Backplane) channel(sessionID string) string {
+	return b.prefix + "hub:" + sessionID
+}
+
+// Dropped returns how many relayed messages were dropped because publishing fell behind
+// Safe to call on a nil *Backplane
+func (b *Backplane) Dropped() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.dropped.Load()
+}
+
+// relay queues a message for the other replicas without waiting for Redis
+// Safe to call from any goroutine
+func (h *Hub) relay(msg relayed) {
+	b := h.backplane
+	if b == nil {
+		return
+	}
+
+	msg.Origin = b.origin
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("Failed to encode relayed message", "sessionID", msg.SessionID, "err", err)
+		return
+	}
+
+	select {
+	case b.outbound <- publication{channel: b.channel(msg.SessionID), payload: payload}:
+	default:
+		b.dropped.Add(1)
+		slog.Warn("Backplane queue full, message not relayed", "sessionID", msg.SessionID)
+	}
+}
+
+// Run publishes this replica's messages and delivers other replicas' to hub's clients
+// until ctx is cancelled, resubscribing with backoff whenever Redis is unavailable
+func (b *Backplane) Run(ctx context.Context, hub *Hub) {
+	go b.publish(ctx)
+
+	retry := time.Second
+	for ctx.Err() == nil {
+		subscribed, err := b.subscribe(ctx, hub)
+		if ctx.Err() != nil {
+			return
+		}
+		if subscribed {
+			retry = time.Second
+		}
+		slog.Warn("Backplane subscription lost, retrying", "err", err, "retryIn", retry)
+
+		select {
+		case <-time.After(retry):
+		case <-ctx.Done():
+			return
+		}
+		retry = min(retry*2, backplaneMaxRetry)
+	}
+}
+
+// publish sends queued messages to Redis one at a time, keeping this replica's order
+func (b *Backplane) publish(ctx context.Context) {
+	for {
+		select {
+		case p := <-b.outbound:
+			publishCtx, cancel := context.WithTimeout(ctx, backplanePublishTimeout)
+			if _, err := b.client.Publish(publishCtx, p.channel, string(p.payload)); err != nil {
+				b.dropped.Add(1)
+				slog.Warn("Failed to relay message", "channel", p.channel, "err", err)
+			}
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// subscribe receives other replicas' messages until the subscription fails
+// Reports whether it subscribed at all, so a working connection resets the backoff
+func (b *Backplane) subscribe(ctx context.Context, hub *Hub) (bool, error) {
+	sub, err := b.client.PSubscribe(ctx, b.prefix+"hub:*")
+	if err != nil {
+		return false, err
+	}
+	slog.Info("Backplane subscribed", "origin", b.origin)
+
+	// Closing the connection is the only way to interrupt a blocked Receive
+	stop := context.AfterFunc(ctx, func() { sub.Close() })
+	defer stop()
+	defer sub.Close()
+
+	for {
+		message, err := sub.Receive()
+		if err != nil {
+			return true, err
+		}
+
+		var msg relayed
+		if err := json.Unmarshal([]byte(message.Payload), &msg); err != nil {
+			slog.Warn("Failed to decode relayed message", "channel", message.Channel, "err", err)
+			continue
+		}
+		if msg.Origin == b.origin {
+			continue
+		}
+		hub.deliverRelayed(&msg)
+	}
+}
+
+// deliverRelayed delivers another replica's message to this replica's clients only
+func (h *Hub) deliverRelayed(msg *relayed) {
+	switch {
+	case msg.Raw != nil:
+		h.deliver(msg.SessionID, h.sessionClients(msg.SessionID, ""), msg.Raw)
+	case msg.Message == nil:
+		return
+	case msg.UserID != "":
+		h.sendToLocalUser(msg.SessionID, msg.UserID, msg.Message)
+	default:
+		h.broadcast(msg.SessionID, msg.Except, msg.Message)
+	}
+}
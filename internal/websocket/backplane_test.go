@@ -0,0 +1,80 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// relayTo passes every message a replica queued for the backplane to another replica
+func relayTo(t *testing.T, from *Backplane, to *Hub) {
+	t.Helper()
+	for len(from.outbound) > 0 {
+		p := <-from.outbound
+		var msg relayed
+		if err := json.Unmarshal(p.payload, &msg); err != nil {
+			t.Fatalf("Failed to decode relayed message: %v", err)
+		}
+		if p.channel != "uplift:hub:"+msg.SessionID {
+			t.Errorf("Expected the session's channel, got %q", p.channel)
+		}
+		to.deliverRelayed(&msg)
+	}
+}
+
+func TestBackplaneRelays(t *testing.T) {
+	first, second := NewHub(nil), NewHub(nil)
+	firstPlane := NewBackplane(nil, "uplift:", "first")
+	first.SetBackplane(firstPlane)
+	second.SetBackplane(NewBackplane(nil, "uplift:", "second"))
+
+	alice := newTestClient(first, "session-1", "alice")
+	bob := newTestClient(second, "session-1", "bob")
+	carol := newTestClient(second, "session-1", "carol")
+
+	// Broadcasts reach the session's clients on both replicas
+	first.BroadcastToSession("session-1", &Message{Type: "phase_changed", Data: map[string]interface{}{"phase": "writing"}})
+	relayTo(t, firstPlane, second)
+	for _, client := range []*Client{alice, bob, carol} {
+		if msg := nextMessage(t, client); msg.Type != "phase_changed" || msg.Data["phase"] != "writing" {
+			t.Errorf("Expected phase_changed on every replica, got %+v", msg)
+		}
+	}
+
+	// Exclusions apply on the other replica too
+	first.BroadcastToSessionExcept("session-1", "bob", &Message{Type: "participant_joined"})
+	relayTo(t, firstPlane, second)
+	nextMessage(t, alice)
+	nextMessage(t, carol)
+	if len(bob.send) != 0 {
+		t.Error("Expected the excluded user to be skipped on the other replica")
+	}
+
+	// Direct messages to users connected locally aren't relayed; others are
+	first.SendToUser("session-1", "alice", &Message{Type: "your_turn"})
+	if len(firstPlane.outbound) != 0 {
+		t.Error("Expected a local user's message not to be relayed")
+	}
+	nextMessage(t, alice)
+
+	first.SendToUser("session-1", "bob", &Message{Type: "your_turn"})
+	relayTo(t, firstPlane, second)
+	if msg := nextMessage(t, bob); msg.Type != "your_turn" {
+		t.Errorf("Expected bob to get their message on the other replica, got %+v", msg)
+	}
+	if len(carol.send) != 0 {
+		t.Error("Expected only the addressed user to get a direct message")
+	}
+}
+
+func TestBackplaneQueueFull(t *testing.T) {
+	hub := NewHub(nil)
+	plane := NewBackplane(nil, "uplift:", "first")
+	hub.SetBackplane(plane)
+
+	for i := 0; i < backplaneQueueSize+1; i++ {
+		hub.BroadcastToSession("session-1", &Message{Type: "ping"})
+	}
+	if hub.Metrics().RelayDropped != 1 {
+		t.Errorf("Expected 1 dropped relay, got %d", hub.Metrics().RelayDropped)
+	}
+}
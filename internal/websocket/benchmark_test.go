@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// benchmarkSessionSizes are the participant counts exercised by fan-out benchmarks
+var benchmarkSessionSizes = []int{2, 10, 50}
+
+// silenceLogs discards log output for the duration of a benchmark
+func silenceLogs(b *testing.B) {
+	b.Helper()
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+// drain empties each client's send buffer so it never fills up
+func drain(clients []*Client) {
+	for _, client := range clients {
+		for len(client.send) > 0 {
+			<-client.send
+		}
+	}
+}
+
+// benchmarkBroadcast is a typical broadcast, as fanned out to every participant
+var benchmarkBroadcast = &Message{
+	Type: "note_drawn",
+	Data: map[string]interface{}{
+		"content":  "Thank you for always making time to help the rest of us.",
+		"readerId": "participant-1",
+		"noteId":   "note-1",
+	},
+}
+
+func BenchmarkBroadcastToSession(b *testing.B) {
+	silenceLogs(b)
+
+	for _, size := range benchmarkSessionSizes {
+		b.Run(fmt.Sprintf("participants=%d", size), func(b *testing.B) {
+			hub := NewHub(nil)
+			clients := make([]*Client, size)
+			for i := range clients {
+				clients[i] = newTestClient(hub, "session-1", fmt.Sprintf("participant-%d", i))
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				hub.BroadcastToSession("session-1", benchmarkBroadcast)
+				drain(clients)
+			}
+		})
+	}
+}
+
+// BenchmarkSendMessageEach is the baseline BenchmarkBroadcastToSession improves on:
+// marshaling the message separately for every client
+func BenchmarkSendMessageEach(b *testing.B) {
+	silenceLogs(b)
+
+	for _, size := range benchmarkSessionSizes {
+		b.Run(fmt.Sprintf("participants=%d", size), func(b *testing.B) {
+			hub := NewHub(nil)
+			clients := make([]*Client, size)
+			for i := range clients {
+				clients[i] = newTestClient(hub, "session-1", fmt.Sprintf("participant-%d", i))
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, client := range clients {
+					client.SendMessage(benchmarkBroadcast)
+				}
+				drain(clients)
+			}
+		})
+	}
+}
+
+func BenchmarkMessageHandlerDispatch(b *testing.B) {
+	silenceLogs(b)
+
+	for _, size := range benchmarkSessionSizes {
+		b.Run(fmt.Sprintf("participants=%d", size), func(b *testing.B) {
+			hub := NewHub(nil)
+			manager := session.NewManager()
+			mh := NewMessageHandler(hub, manager)
+
+			sess := manager.CreateSession("Host")
+			clients := []*Client{newTestClient(hub, sess.ID, sess.HostID)}
+			for i := 1; i < size; i++ {
+				participant, err := sess.AddParticipant(fmt.Sprintf("Participant %d", i))
+				if err != nil {
+					b.Fatalf("Failed to add participant: %v", err)
+				}
+				clients = append(clients, newTestClient(hub, sess.ID, participant.ID))
+			}
+
+			host := clients[0]
+			message := &Message{
+				Type: "set_session_title",
+				Data: map[string]interface{}{
+					"title": "Team appreciation",
+				},
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				mh.HandleMessage(host, message)
+				drain(clients)
+			}
+		})
+	}
+}
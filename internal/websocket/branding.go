@@ -0,0 +1,31 @@
+// ABOUTME: Sends each connection its tenant's branding and brands notifications
+// ABOUTME: Tenants come from the request host or a tenant query parameter on /ws
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/branding"
+)
+
+// SetBranding sets the tenant branding sent to new connections in a branding message
+func (h *Handler) SetBranding(registry *branding.Registry) {
+	h.branding = registry
+}
+
+// SetBranding sets the tenant branding used in notifications
+func (mh *MessageHandler) SetBranding(registry *branding.Registry) {
+	mh.branding = registry
+}
+
+// brandingMessage describes how the frontend should look
+func brandingMessage(b branding.Branding) *Message {
+	data := map[string]interface{}{
+		"productName": b.ProductName,
+	}
+	if b.LogoURL != "" {
+		data["logoUrl"] = b.LogoURL
+	}
+	if len(b.Colors) > 0 {
+		data["colors"] = b.Colors
+	}
+	return &Message{Type: "branding", Data: data}
+}
@@ -0,0 +1,20 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/branding"
+)
+
+func TestBrandingMessage(t *testing.T) {
+	msg := brandingMessage(branding.Branding{
+		ProductName: "Acme Kudos",
+		Colors:      map[string]string{"primary": "#ff6600"},
+	})
+	if msg.Type != "branding" || msg.Data["productName"] != "Acme Kudos" {
+		t.Errorf("Unexpected branding message: %+v", msg)
+	}
+	if _, ok := msg.Data["logoUrl"]; ok {
+		t.Error("Expected no logoUrl when none is configured")
+	}
+}
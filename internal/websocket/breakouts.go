@@ -0,0 +1,221 @@
+// ABOUTME: Message handlers for splitting a session into breakout circles
+// ABOUTME: Moves clients into their circles and keeps the parent host informed of progress
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/features"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// handleCreateBreakouts splits the host's joining session into breakout circles (host only)
+// Accepts either "count" for random even groups or "groups" as lists of participant IDs
+func (mh *MessageHandler) handleCreateBreakouts(client *Client, req *createBreakoutsRequest) {
+	if !mh.features.Enabled(features.Breakouts) {
+		mh.sendErrorCode(client, "feature_disabled", "breakout circles are not available")
+		return
+	}
+
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	// Verify client is host
+	if client.userID != sess.HostID {
+		client.logger().Warn("Non-host tried to create breakouts", "hostID", sess.HostID)
+		mh.sendError(client, "only host can create breakout circles")
+		return
+	}
+
+	if mh.countingDown(sess.ID) {
+		mh.sendError(client, "phase change already in progress")
+		return
+	}
+
+	var groups [][]string
+	if req.Count != nil {
+		participantIDs := make([]string, 0, len(sess.Participants))
+		for _, p := range sess.GetParticipantList() {
+			participantIDs = append(participantIDs, p.ID)
+		}
+		groups, err = session.SplitEvenly(participantIDs, *req.Count)
+		if err != nil {
+			mh.sendError(client, err.Error())
+			return
+		}
+	} else {
+		if req.Groups == nil {
+			mh.sendError(client, "breakout count or groups required")
+			return
+		}
+		groups = req.Groups
+	}
+
+	breakouts, err := mh.sessionManager.CreateBreakouts(sess, groups)
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	// Move every participant's client into their circle and tell them where they are
+	for _, breakout := range breakouts {
+		for _, participant := range breakout.GetParticipantList() {
+			moved := mh.hub.MoveUser(sess.ID, participant.ID, breakout.ID)
+			if moved == nil {
+				continue
+			}
+
+			assigned := &Message{
+				Type: "breakout_assigned",
+				Data: map[string]interface{}{
+					"parentSessionId": sess.ID,
+					"sessionCode":     breakout.Code,
+					"sessionId":       breakout.ID,
+					"title":           breakout.Title,
+					"welcome":         breakout.Welcome,
+					"countdown":       breakout.Countdown,
+					"maxNoteLength":   breakout.Settings.MaxNoteLength,
+					"settings":        breakout.Settings,
+					"minNoteChars":    breakout.MinNoteChars,
+					"minNoteWords":    breakout.MinNoteWords,
+					"userId":          participant.ID,
+					"userName":        participant.Name,
+					"isHost":          participant.ID == breakout.HostID,
+					"participants":    breakout.GetParticipantList(),
+					"phase":           breakout.Phase,
+					"theme":           breakout.Theme,
+					"prompt":          breakout.Prompt,
+				},
+			}
+			mh.addRejoinToken(breakout, participant.ID, assigned.Data)
+			moved.SendMessage(assigned)
+		}
+	}
+
+	mh.sendBreakoutStatus(sess)
+
+	sessionLogger(sess).Info("Breakout circles created", "count", len(breakouts))
+}
+
+// handleGetBreakoutStatus sends the parent host a progress summary of all breakout circles
+func (mh *MessageHandler) handleGetBreakoutStatus(client *Client) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	parent := mh.parentSession(sess)
+	if parent == nil {
+		mh.sendError(client, "session has no breakout circles")
+		return
+	}
+
+	// Verify client is the parent session's host
+	if client.userID != parent.HostID {
+		mh.sendError(client, "only host can view breakout circles")
+		return
+	}
+
+	mh.sendBreakoutStatus(parent)
+}
+
+// notifyBreakoutProgress updates the parent host after a breakout circle changes phase,
+// and sends the merged recap to every circle once they have all completed
+func (mh *MessageHandler) notifyBreakoutProgress(sess *session.Session) {
+	if sess.ParentID == "" {
+		return
+	}
+
+	parent, err := mh.sessionManager.GetSessionByID(sess.ParentID)
+	if err != nil {
+		return
+	}
+
+	mh.sendBreakoutStatus(parent)
+
+	if mh.sessionManager.CompleteBreakoutsIfDone(parent) {
+		mh.broadcastBreakoutRecap(parent)
+	}
+}
+
+// sendBreakoutStatus sends the parent host a progress summary of all breakout circles
+func (mh *MessageHandler) sendBreakoutStatus(parent *session.Session) {
+	status := &Message{
+		Type: "breakout_status",
+		Data: map[string]interface{}{
+			"parentSessionId": parent.ID,
+			"breakouts":       mh.sessionManager.GetBreakoutStatus(parent),
+		},
+	}
+
+	// The parent host's client lives in whichever circle they were assigned to
+	for _, breakout := range mh.sessionManager.GetBreakouts(parent) {
+		if breakout.HasParticipant(parent.HostID) {
+			mh.hub.SendToUser(breakout.ID, parent.HostID, status)
+			return
+		}
+	}
+}
+
+// broadcastBreakoutRecap sends every breakout circle the merged read-aloud notes of
+// all circles. Notes stay anonymous - no author names
+func (mh *MessageHandler) broadcastBreakoutRecap(parent *session.Session) {
+	breakouts := mh.sessionManager.GetBreakouts(parent)
+
+	recaps := make([]map[string]interface{}, 0, len(breakouts))
+	for _, breakout := range breakouts {
+		notes := []map[string]interface{}{}
+		for _, note := range breakout.Notes {
+			// Private notes only ever go to their recipient
+			if note.Private {
+				continue
+			}
+			notes = append(notes, map[string]interface{}{
+				"id":          note.ID,
+				"content":     note.Content,
+				"recipientId": note.RecipientID,
+			})
+		}
+		recaps = append(recaps, map[string]interface{}{
+			"sessionId":    breakout.ID,
+			"title":        breakout.Title,
+			"participants": breakout.GetParticipantList(),
+			"notes":        notes,
+		})
+	}
+
+	recap := &Message{
+		Type: "breakouts_complete",
+		Data: map[string]interface{}{
+			"parentSessionId": parent.ID,
+			"title":           parent.Title,
+			"breakouts":       recaps,
+			"theme":           parent.Theme,
+		},
+	}
+	for _, breakout := range breakouts {
+		mh.hub.BroadcastToSession(breakout.ID, recap)
+	}
+
+	sessionLogger(parent).Info("All breakout circles complete")
+}
+
+// parentSession returns the split session a breakout circle belongs to,
+// the session itself if it was split, or nil otherwise
+func (mh *MessageHandler) parentSession(sess *session.Session) *session.Session {
+	if sess.Phase == session.PhaseBreakout {
+		return sess
+	}
+
+	if sess.ParentID == "" {
+		return nil
+	}
+
+	parent, err := mh.sessionManager.GetSessionByID(sess.ParentID)
+	if err != nil {
+		return nil
+	}
+	return parent
+}
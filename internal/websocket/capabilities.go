@@ -0,0 +1,83 @@
+// ABOUTME: Hello handshake in which a client opts into optional message formats
+// ABOUTME: Capabilities are per connection and change how outbound messages are rendered
+package websocket
+
+import (
+	"sort"
+)
+
+// Capability is an optional behaviour a client can ask for in its hello
+type Capability string
+
+const (
+	// CapabilityAccessible adds plain-text announcements and reading-order hints for assistive tech
+	CapabilityAccessible Capability = "a11y"
+
+	// CapabilityLite skips non-essential updates and trims payloads for poor connections
+	CapabilityLite Capability = "lite"
+
+	// CapabilityDiagnostics pushes connection diagnostics periodically, without asking
+	CapabilityDiagnostics Capability = "diagnostics"
+
+	// CapabilityAck has critical messages resent until the client acknowledges them
+	CapabilityAck Capability = "ack"
+)
+
+// capabilityBits assigns each known capability a bit in a client's capability set
+var capabilityBits = map[Capability]uint32{
+	CapabilityAccessible:  1 << 0,
+	CapabilityLite:        1 << 1,
+	CapabilityDiagnostics: 1 << 2,
+	CapabilityAck:         1 << 3,
+}
+
+// renderCapabilities are the capabilities that change how outbound messages are rendered
+// Others don't need a broadcast variant of their own
+var renderCapabilities = capabilityBits[CapabilityAccessible] | capabilityBits[CapabilityLite]
+
+// has reports whether the client negotiated a capability
+// Safe from any goroutine since broadcasts render off the hub goroutine too
+func (c *Client) has(capability Capability) bool {
+	return c.capabilities.Load()&capabilityBits[capability] != 0
+}
+
+// capabilityList names the capabilities in a set, sorted for stable replies
+func capabilityList(set uint32) []Capability {
+	list := []Capability{}
+	for capability, bit := range capabilityBits {
+		if set&bit != 0 {
+			list = append(list, capability)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// handleHello records the capabilities a client asked for and replies with those granted
+// Unknown capabilities are ignored so newer clients can talk to older servers
+// A later hello replaces the earlier set
+func (mh *MessageHandler) handleHello(client *Client, req *helloRequest) {
+	var set uint32
+	for _, capability := range req.Capabilities {
+		set |= capabilityBits[capability]
+	}
+	client.capabilities.Store(set)
+
+	build := req.Build
+	client.build = build
+
+	granted := capabilityList(set)
+	client.logger().Info("Hello", "capabilities", granted, "build", build)
+	reply := &Message{
+		Type: "hello",
+		Data: map[string]interface{}{
+			"capabilities": granted,
+			"protocol":     client.version(),
+		},
+	}
+	if mh.assetVersion != "" {
+		reply.Data["build"] = mh.assetVersion
+	}
+	client.SendMessage(reply)
+	mh.checkOutdated(client)
+}
@@ -0,0 +1,120 @@
+// ABOUTME: Fault injection for soak testing: random disconnects, delayed and dropped frames
+// ABOUTME: Only usable in binaries built with -tags chaos so it can never be switched on in production
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChaosConfig sets how often faults are injected into outbound frames
+// Rates are probabilities between 0 and 1, rolled once per frame
+type ChaosConfig struct {
+	DisconnectRate float64       // Drop the client's connection instead of writing
+	DropRate       float64       // Silently discard the frame
+	DelayRate      float64       // Hold the frame (and everything behind it) before writing
+	MaxDelay       time.Duration // Upper bound for delays
+	Seed           int64         // Random seed, so a failing soak run can be replayed (0 = time-based)
+}
+
+// ParseChaosConfig reads settings like "disconnect=0.01,drop=0.05,delay=0.1,maxdelay=2s,seed=42"
+func ParseChaosConfig(value string) (ChaosConfig, error) {
+	config := ChaosConfig{MaxDelay: time.Second}
+
+	for _, part := range strings.Split(value, ",") {
+		key, raw, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ChaosConfig{}, fmt.Errorf("invalid chaos setting %q: expected key=value", part)
+		}
+
+		var err error
+		switch key {
+		case "disconnect":
+			config.DisconnectRate, err = parseRate(raw)
+		case "drop":
+			config.DropRate, err = parseRate(raw)
+		case "delay":
+			config.DelayRate, err = parseRate(raw)
+		case "maxdelay":
+			config.MaxDelay, err = time.ParseDuration(raw)
+			if err == nil && config.MaxDelay <= 0 {
+				err = errors.New("must be positive")
+			}
+		case "seed":
+			config.Seed, err = strconv.ParseInt(raw, 10, 64)
+		default:
+			return ChaosConfig{}, fmt.Errorf("unknown chaos setting %q", key)
+		}
+		if err != nil {
+			return ChaosConfig{}, fmt.Errorf("invalid chaos %s: %v", key, err)
+		}
+	}
+
+	if config.DisconnectRate+config.DropRate+config.DelayRate > 1 {
+		return ChaosConfig{}, errors.New("chaos rates must add up to 1 or less")
+	}
+	return config, nil
+}
+
+// parseRate reads a probability between 0 and 1
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, errors.New("must be between 0 and 1")
+	}
+	return rate, nil
+}
+
+// chaosAction is what happens to one outbound frame
+type chaosAction int
+
+const (
+	chaosDeliver chaosAction = iota
+	chaosDrop
+	chaosDisconnect
+)
+
+// chaos rolls faults for outbound frames
+type chaos struct {
+	config ChaosConfig
+	rand   *rand.Rand
+	mu     sync.Mutex // rand.Rand isn't safe for concurrent use
+}
+
+// roll decides the fate of the next frame, and how long to hold it if delivered
+func (c *chaos) roll() (chaosAction, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r := c.rand.Float64()
+	switch {
+	case r < c.config.DisconnectRate:
+		return chaosDisconnect, 0
+	case r < c.config.DisconnectRate+c.config.DropRate:
+		return chaosDrop, 0
+	case r < c.config.DisconnectRate+c.config.DropRate+c.config.DelayRate:
+		return chaosDeliver, time.Duration(c.rand.Int63n(int64(c.config.MaxDelay)) + 1)
+	default:
+		return chaosDeliver, 0
+	}
+}
+
+// SetChaos turns on fault injection; call before Run
+// Fails unless the binary was built with -tags chaos
+func (h *Hub) SetChaos(config ChaosConfig) error {
+	if !chaosAvailable {
+		return errors.New("chaos mode requires a binary built with -tags chaos")
+	}
+
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	h.chaos = &chaos{config: config, rand: rand.New(rand.NewSource(seed))}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !chaos
+
+// ABOUTME: Keeps fault injection out of normal builds
+// ABOUTME: Replaced by chaos_on.go when built with -tags chaos
+package websocket
+
+// chaosAvailable reports whether SetChaos may be used in this build
+const chaosAvailable = false
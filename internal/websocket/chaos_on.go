@@ -0,0 +1,8 @@
+//go:build chaos
+
+// ABOUTME: Enables fault injection in binaries built for soak testing
+// ABOUTME: Compiled only with -tags chaos
+package websocket
+
+// chaosAvailable reports whether SetChaos may be used in this build
+const chaosAvailable = true
@@ -0,0 +1,85 @@
+//go:build chaos
+
+package websocket
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gorillaws "github.com/gorilla/websocket"
+)
+
+// Run with: go test -tags chaos ./internal/websocket -run Soak
+func TestSoakDeliveryStaysOrderedUnderChaos(t *testing.T) {
+	hub := NewHub(nil)
+	if err := hub.SetChaos(ChaosConfig{DropRate: 0.2, DelayRate: 0.2, MaxDelay: 5 * time.Millisecond, Seed: 7}); err != nil {
+		t.Fatalf("Failed to enable chaos: %v", err)
+	}
+	go hub.Run()
+
+	server := httptest.NewServer(NewHandler(hub))
+	defer server.Close()
+
+	conn, _, err := gorillaws.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	// Put the connection in a session so it receives broadcasts
+	var client *Client
+	for client == nil {
+		done := make(chan *Client)
+		hub.Schedule(func() {
+			var found *Client
+			for c := range hub.connections {
+				found = c
+			}
+			done <- found
+		})
+		client = <-done
+	}
+	client.sessionID = "soak"
+	hub.register <- client
+
+	// Wait for the hub to finish registering
+	registered := make(chan struct{})
+	hub.Schedule(func() { close(registered) })
+	<-registered
+
+	const sent = 200
+	for i := 0; i < sent; i++ {
+		hub.BroadcastToSession("soak", &Message{Type: "tick", Data: map[string]interface{}{"seq": i}})
+	}
+
+	// Dropped frames leave gaps, but nothing may arrive out of order
+	last, received := -1, 0
+	for {
+		conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			break
+		}
+		seq := int(msg.Data["seq"].(float64))
+		if seq <= last {
+			t.Fatalf("Frame %d arrived after %d", seq, last)
+		}
+		last = seq
+		received++
+	}
+
+	if received == sent || received < sent/2 {
+		t.Errorf("Expected roughly 80%% of %d frames with some dropped, got %d", sent, received)
+	}
+
+	// Let the pumps exit so later goroutine-leak checks start clean
+	conn.Close()
+	for remaining := 1; remaining > 0; {
+		done := make(chan int)
+		hub.Schedule(func() { done <- len(hub.connections) })
+		remaining = <-done
+		time.Sleep(10 * time.Millisecond)
+	}
+}
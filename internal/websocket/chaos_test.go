@@ -0,0 +1,56 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseChaosConfig(t *testing.T) {
+	config, err := ParseChaosConfig("disconnect=0.01, drop=0.05,delay=0.1,maxdelay=2s,seed=42")
+	if err != nil {
+		t.Fatalf("Expected valid config: %v", err)
+	}
+	want := ChaosConfig{DisconnectRate: 0.01, DropRate: 0.05, DelayRate: 0.1, MaxDelay: 2 * time.Second, Seed: 42}
+	if config != want {
+		t.Errorf("Expected %+v, got %+v", want, config)
+	}
+
+	for _, value := range []string{"drop", "drop=2", "loss=0.1", "maxdelay=0s", "drop=0.6,delay=0.6"} {
+		if _, err := ParseChaosConfig(value); err == nil {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestChaosRollsAtConfiguredRates(t *testing.T) {
+	hub := NewHub(nil)
+	err := hub.SetChaos(ChaosConfig{DisconnectRate: 0.1, DropRate: 0.2, DelayRate: 0.3, MaxDelay: time.Millisecond, Seed: 1})
+	if !chaosAvailable {
+		if err == nil {
+			t.Fatal("Expected chaos mode to be refused without the chaos build tag")
+		}
+		t.Skip("built without -tags chaos")
+	}
+	if err != nil {
+		t.Fatalf("Expected chaos mode in a chaos build: %v", err)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		action, delay := hub.chaos.roll()
+		switch {
+		case action == chaosDisconnect:
+			counts["disconnect"]++
+		case action == chaosDrop:
+			counts["drop"]++
+		case delay > 0:
+			counts["delay"]++
+		}
+	}
+
+	for kind, want := range map[string]int{"disconnect": 1000, "drop": 2000, "delay": 3000} {
+		if got := counts[kind]; got < want*8/10 || got > want*12/10 {
+			t.Errorf("Expected about %d %s rolls, got %d", want, kind, got)
+		}
+	}
+}
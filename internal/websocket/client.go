@@ -3,9 +3,14 @@
 package websocket
 
 import (
+	"bytes"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -21,13 +26,16 @@ const (
 	// Send pings to peer with this period (must be less than pongWait)
 	pingPeriod = (pongWait * 9) / 10
 
-	// Maximum inactivity time before disconnecting (30 minutes)
-	inactivityTimeout = 30 * time.Minute
+	// How often the hub sweeps connected clients for inactivity
+	inactivityCheckInterval = 15 * time.Second
 
 	// Maximum message size allowed from peer
 	maxMessageSize = 512 * 1024 // 512 KB
 )
 
+// errMessageTooLarge is returned when a peer's message exceeds maxMessageSize
+var errMessageTooLarge = errors.New("message too large")
+
 // Client represents a WebSocket client connection
 type Client struct {
 	// The WebSocket connection
@@ -39,6 +47,12 @@ type Client struct {
 	// The hub managing this client
 	hub *Hub
 
+	// Random ID for this connection, attached to its log lines
+	connID string
+
+	// Source IP the client connected from, for per-IP rate limits
+	remoteIP string
+
 	// Session ID this client is connected to
 	sessionID string
 
@@ -48,11 +62,60 @@ type Client struct {
 	// User name for this client
 	userName string
 
-	// Last activity timestamp for inactivity timeout
-	lastActivity time.Time
+	// Secret the connection proves itself with on the dictation endpoint, sent only to it
+	// when it joins (empty = can't dictate)
+	dictationToken string
+
+	// Read-only admin connection that isn't a participant
+	observer bool
+
+	// Branding tenant the client connected under (empty = default)
+	tenant string
+
+	// Instance the client asked to be routed to, for spotting misrouted clients
+	instanceHint string
+
+	// Protocol version the client speaks (0 = current)
+	protocolVersion int
+
+	// Optional behaviours negotiated in the hello handshake, as capabilityBits
+	capabilities atomic.Uint32
+
+	// Frontend build the client reported in hello (empty = unknown)
+	build string
+
+	// Whether the client still needs a client_outdated prompt
+	// (only touched while handling its session's messages)
+	outdated bool
+
+	// Times the client reports having reconnected before this connection
+	reconnects int
+
+	// Message being handled for this client, whose msgId replies echo (nil = none)
+	reply atomic.Pointer[pendingReply]
+
+	// Last broadcast the client saw before rejoining; later ones are replayed
+	// when it registers (0 = nothing to replay)
+	resumeSeq uint64
+
+	// Latest round trip measured by a latency probe (0 = not yet measured)
+	// (only touched while handling the client's messages, or on the hub goroutine with actors paused)
+	rtt time.Duration
+
+	// Time from reading the client's latest message to finishing handling it, and the longest so far
+	// (only touched while handling the client's messages, or on the hub goroutine with actors paused)
+	processingDelay    time.Duration
+	maxProcessingDelay time.Duration
+
+	// Messages smaller than this are written uncompressed
+	compressMinSize int
 
-	// Channel to signal shutdown of inactivity checker
-	stopInactivityCheck chan struct{}
+	// Last activity timestamp (unix nanoseconds) for inactivity timeout
+	lastActivity atomic.Int64
+
+	// Whether an inactivity warning has been sent since the last activity
+	// (only touched on the hub goroutine)
+	inactivityWarned bool
 
 	// Ensures send channel is only closed once
 	closeOnce sync.Once
@@ -60,99 +123,210 @@ type Client struct {
 	// Tracks if send channel has been closed
 	sendClosed bool
 
+	// Close frame the write pump sends once the send channel closes (nil = no status)
+	closeFrame []byte
+
+	// Messages discarded because the send buffer was full
+	dropped atomic.Uint64
+
+	// Critical messages awaiting acknowledgement
+	acks ackTracker
+
 	// Protects sendClosed flag
 	sendMu sync.RWMutex
 }
 
 // Message represents a WebSocket message
+// ProtocolVersion is the format Data is in: outbound messages carry the version they
+// were rendered for, and a client may set it on a message to override the version it
+// reported when connecting
 type Message struct {
-	Type      string                 `json:"type"`
-	Data      map[string]interface{} `json:"data,omitempty"`
-	SessionID string                 `json:"sessionId,omitempty"`
-	UserID    string                 `json:"userId,omitempty"`
-	UserName  string                 `json:"userName,omitempty"`
+	Type            string                 `json:"type"`
+	Seq             uint64                 `json:"seq,omitempty"`   // Position among the session's broadcasts (0 = not a broadcast)
+	MsgID           string                 `json:"msgId,omitempty"` // Client-chosen ID, echoed in replies; resends are handled once
+	AckID           uint64                 `json:"ackId,omitempty"` // Set on critical messages, for clients with the ack capability to acknowledge
+	ProtocolVersion int                    `json:"protocolVersion,omitempty"`
+	Data            map[string]interface{} `json:"data,omitempty"`
+	SessionID       string                 `json:"sessionId,omitempty"`
+	UserID          string                 `json:"userId,omitempty"`
+	UserName        string                 `json:"userName,omitempty"`
 }
 
 // readPump pumps messages from the WebSocket connection to the hub
 func (c *Client) readPump() {
 	defer func() {
-		close(c.stopInactivityCheck)
 		c.hub.unregister <- c
 		c.conn.Close()
+		c.hub.releaseConnection(c.remoteIP)
 	}()
 
-	c.lastActivity = time.Now()
+	c.touch()
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetReadLimit(maxMessageSize)
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
-		c.lastActivity = time.Now()
+		c.touch()
 		return nil
 	})
 
-	// Start goroutine to check for inactivity
-	go func() {
-		ticker := time.NewTicker(1 * time.Minute)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-c.stopInactivityCheck:
-				return
-			case <-ticker.C:
-				if time.Since(c.lastActivity) > inactivityTimeout {
-					log.Printf("Client inactive for %v, disconnecting: userId=%s session=%s", inactivityTimeout, c.userID, c.sessionID)
-					// Send timeout message before closing
-					timeoutMsg := &Message{
-						Type: "timeout",
-						Data: map[string]interface{}{
-							"message": "Disconnected due to inactivity. Please start again.",
-						},
-					}
-					c.SendMessage(timeoutMsg)
-					time.Sleep(100 * time.Millisecond) // Give time for message to send
-					// Close with policy violation code (1008) for timeout
-					c.conn.WriteControl(
-						websocket.CloseMessage,
-						websocket.FormatCloseMessage(1008, "Inactivity timeout"),
-						time.Now().Add(writeWait),
-					)
-					c.conn.Close()
-					return
-				}
-			}
-		}
-	}()
-
 	for {
-		_, message, err := c.conn.ReadMessage()
+		message, err := c.readMessage()
+		if errors.Is(err, errMessageTooLarge) {
+			c.rejectOversized()
+			break
+		}
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("websocket error: %v", err)
+				c.logger().Warn("WebSocket read error", "err", err)
 			}
 			break
 		}
 
-		// Update last activity timestamp
-		c.lastActivity = time.Now()
+		// Every frame counts against the connection's limit, even ones that won't parse
+		if !c.allowMessage() {
+			continue
+		}
 
-		// Parse message
+		// Parse message, refusing envelopes with fields the protocol doesn't define
 		var msg Message
-		if err := json.Unmarshal(message, &msg); err != nil {
-			log.Printf("error parsing message: %v", err)
+		if err := decodeStrict(message, &msg); err != nil {
+			c.logger().Warn("Failed to parse message", "err", err)
+			c.hub.recordDeadLetter(c, DeadLetterDecodeError, "", message, err)
+			c.rejectMessage("invalid_message", "invalid message: "+err.Error())
+			continue
+		}
+
+		// New sessions are limited per IP as well, so reconnecting doesn't reset the count
+		if msg.Type == "create_session" && !c.allowCreate() {
 			continue
 		}
 
+		// Translate older clients' messages into the current format
+		version := c.version()
+		if msg.ProtocolVersion != 0 {
+			if !c.hub.protocol.Supports(msg.ProtocolVersion) {
+				c.rejectMessage("protocol_unsupported", fmt.Sprintf("unsupported protocolVersion %d", msg.ProtocolVersion))
+				continue
+			}
+			version = msg.ProtocolVersion
+		}
+		c.hub.protocol.Upgrade(&msg, version)
+
+		// Update last activity timestamp (latency probes don't count as activity)
+		if msg.Type != "ping" && msg.Type != "pong" {
+			c.touch()
+		}
+
 		// Set client context on message
 		msg.SessionID = c.sessionID
 		msg.UserID = c.userID
 		msg.UserName = c.userName
 
-		// Send to hub for processing
-		c.hub.process <- &ClientMessage{
-			client:  c,
-			message: &msg,
+		// Send to the session's actor, or the hub, for processing
+		c.hub.submit(&ClientMessage{
+			client:   c,
+			message:  &msg,
+			received: time.Now(),
+		})
+	}
+}
+
+// rejectMessage tells the client a message it sent couldn't be processed
+func (c *Client) rejectMessage(code, message string) {
+	c.SendMessage(&Message{
+		Type: "error",
+		Data: map[string]interface{}{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
+
+// readMessage reads the next message from the peer, up to maxMessageSize
+// Enforced here rather than with SetReadLimit so the client can be told why it's disconnected
+func (c *Client) readMessage() ([]byte, error) {
+	_, r, err := c.conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := io.ReadAll(io.LimitReader(r, maxMessageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(message) > maxMessageSize {
+		return nil, errMessageTooLarge
+	}
+	return message, nil
+}
+
+// rejectOversized explains the size limit to the client and closes with 1009 (message too big)
+func (c *Client) rejectOversized() {
+	c.logger().Warn("Message too large, disconnecting", "limit", maxMessageSize)
+	c.SendMessage(&Message{
+		Type: "error",
+		Data: map[string]interface{}{
+			"code":    "message_too_large",
+			"message": "Message too large",
+			"limit":   maxMessageSize,
+		},
+	})
+
+	time.Sleep(100 * time.Millisecond) // Give time for message to send
+	c.conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseMessageTooBig, "Message too large"),
+		time.Now().Add(writeWait),
+	)
+}
+
+// version returns the protocol version the client speaks
+func (c *Client) version() int {
+	if c.protocolVersion == 0 {
+		return ProtocolVersion
+	}
+	return c.protocolVersion
+}
+
+// variant describes how outbound messages are rendered for a client, so broadcasts
+// encode once per variant in use rather than once per client
+type variant struct {
+	version      int
+	capabilities uint32
+}
+
+// variant returns the client's current rendering variant
+func (c *Client) variant() variant {
+	return variant{version: c.version(), capabilities: c.capabilities.Load() & renderCapabilities}
+}
+
+// render returns msg as a client on v should receive it, or nil if it shouldn't get it
+// msg itself is left untouched since it may be going to other clients too
+func (h *Hub) render(msg *Message, v variant) *Message {
+	msg = h.protocol.Downgrade(msg, v.version)
+	if v.capabilities&capabilityBits[CapabilityLite] != 0 {
+		if msg = lite(msg); msg == nil {
+			return nil
 		}
 	}
+	if v.capabilities&capabilityBits[CapabilityAccessible] != 0 {
+		msg = accessible(msg)
+	}
+	return msg
+}
+
+// encodeFor renders and serializes msg for a client on v
+// Returns nil data without error when the client shouldn't receive the message
+func (h *Hub) encodeFor(msg *Message, v variant) ([]byte, error) {
+	rendered := h.render(msg, v)
+	if rendered == nil {
+		return nil, nil
+	}
+	if rendered.ProtocolVersion != v.version {
+		stamped := *rendered
+		stamped.ProtocolVersion = v.version
+		rendered = &stamped
+	}
+	return encodeMessage(rendered)
 }
 
 // writePump pumps messages from the hub to the WebSocket connection
@@ -169,10 +343,29 @@ func (c *Client) writePump() {
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// Hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.closeFrame)
 				return
 			}
 
+			if c.hub.chaos != nil {
+				action, delay := c.hub.chaos.roll()
+				if action == chaosDisconnect {
+					c.logger().Info("Chaos: disconnecting")
+					return
+				}
+				if action == chaosDrop {
+					continue
+				}
+				if delay > 0 {
+					time.Sleep(delay)
+					c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				}
+			}
+
+			// Small messages aren't worth the CPU to compress
+			// (no effect unless compression was negotiated)
+			c.conn.EnableWriteCompression(len(message) >= c.compressMinSize)
+
 			w, err := c.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				return
@@ -192,36 +385,160 @@ func (c *Client) writePump() {
 	}
 }
 
+// touch records activity from the client
+func (c *Client) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+// logger returns a logger that tags lines with the connection, session and user
+func (c *Client) logger() *slog.Logger {
+	return slog.With("connID", c.connID, "sessionID", c.sessionID, "userID", c.userID)
+}
+
+// idleFor returns how long it has been since the client was last active
+func (c *Client) idleFor() time.Duration {
+	return time.Since(time.Unix(0, c.lastActivity.Load()))
+}
+
+// disconnectInactive tells the client it timed out and closes the connection
+func (c *Client) disconnectInactive(timeout time.Duration) {
+	c.logger().Info("Client inactive, disconnecting", "timeout", timeout)
+	// Send timeout message before closing
+	timeoutMsg := &Message{
+		Type: "timeout",
+		Data: map[string]interface{}{
+			"message": "Disconnected due to inactivity. Please start again.",
+		},
+	}
+	c.SendMessage(timeoutMsg)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond) // Give time for message to send
+		// Close with policy violation code (1008) for timeout
+		c.conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(1008, "Inactivity timeout"),
+			time.Now().Add(writeWait),
+		)
+		c.conn.Close()
+	}()
+}
+
+// encodeBuffers reuses serialization buffers across outbound messages
+var encodeBuffers = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// encodeMessage serializes a message to JSON using a pooled buffer
+// The returned slice is freshly allocated so it can be shared between clients
+func encodeMessage(msg *Message) ([]byte, error) {
+	buf := encodeBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer encodeBuffers.Put(buf)
+
+	if err := json.NewEncoder(buf).Encode(msg); err != nil {
+		return nil, err
+	}
+
+	// Encoder appends a newline that json.Marshal would not
+	encoded := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	data := make([]byte, len(encoded))
+	copy(data, encoded)
+	return data, nil
+}
+
 // SendMessage sends a message to this client
 func (c *Client) SendMessage(msg *Message) error {
-	data, err := json.Marshal(msg)
-	if err != nil {
+	msg = c.stampReply(msg)
+	data, err := c.hub.encodeFor(msg, c.variant())
+	if err != nil || data == nil {
 		return err
 	}
+	return c.SendRaw(data)
+}
+
+// SendRaw queues an already serialized message for this client
+// data must not be modified afterwards since it may be shared with other clients
+func (c *Client) SendRaw(data []byte) error {
+	if c.enqueue(data) {
+		return nil
+	}
+
+	// Client's send buffer is full and the policy is to disconnect; 1013 (try again later)
+	// tells it to reconnect, and rejoining brings it back up to date
+	c.hub.counters.overflowDisconnects.Add(1)
+	c.logger().Warn("Send buffer full, disconnecting")
+	c.closeSend(websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "Send buffer full"))
+	return nil
+}
 
-	// Check if send channel is closed
+// enqueue adds data to the send buffer, applying the hub's drop policy if it's full
+// Returns false if the client should be disconnected instead
+func (c *Client) enqueue(data []byte) bool {
+	// Hold the read lock so the channel can't be closed mid-send
 	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+
 	if c.sendClosed {
-		c.sendMu.RUnlock()
-		return nil
+		return true
 	}
-	c.sendMu.RUnlock()
 
 	select {
 	case c.send <- data:
-		return nil
+		return true
 	default:
-		// Client's send buffer is full, close connection
-		c.closeSendChannel()
-		return nil
+	}
+
+	switch c.hub.dropPolicy {
+	case DropPolicyNewest:
+		c.recordDrop()
+		return true
+	case DropPolicyOldest:
+		select {
+		case <-c.send:
+			c.recordDrop()
+		default:
+		}
+		select {
+		case c.send <- data:
+		default:
+			c.recordDrop()
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// recordDrop counts a message discarded because the send buffer was full
+func (c *Client) recordDrop() {
+	c.hub.counters.dropped.Add(1)
+	if c.dropped.Add(1) == 1 {
+		c.logger().Warn("Send buffer full, dropping messages", "policy", c.hub.dropPolicy)
 	}
 }
 
 // closeSendChannel safely closes the send channel exactly once
 func (c *Client) closeSendChannel() {
+	c.closeSend(nil)
+}
+
+// closeSend closes the send channel exactly once, after which the write pump sends frame
+// as its close frame; with a frame, messages still queued are discarded so it goes out
+// straight away rather than behind a backlog the client is too slow to read
+func (c *Client) closeSend(frame []byte) {
 	c.closeOnce.Do(func() {
 		c.sendMu.Lock()
 		c.sendClosed = true
+		c.closeFrame = frame
+		for frame != nil && len(c.send) > 0 {
+			select {
+			case <-c.send:
+			default:
+			}
+		}
 		c.sendMu.Unlock()
 		close(c.send)
 	})
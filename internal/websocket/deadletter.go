@@ -0,0 +1,169 @@
+// ABOUTME: Bounded buffer of inbound messages that couldn't be decoded, had unknown types or invalid payloads
+// ABOUTME: Keeps a short sanitised preview of each so client and protocol bugs can be diagnosed
+package websocket
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultDeadLetterSize is how many dead letters are kept when not configured
+	DefaultDeadLetterSize = 200
+
+	// deadLetterPreviewBytes bounds how much of each message is kept
+	deadLetterPreviewBytes = 256
+)
+
+// Dead letter reasons
+const (
+	DeadLetterDecodeError    = "decode_error"
+	DeadLetterUnknownType    = "unknown_type"
+	DeadLetterInvalidPayload = "invalid_payload"
+)
+
+// DeadLetter is an inbound message the server couldn't process
+type DeadLetter struct {
+	At        time.Time `json:"at"`
+	Reason    string    `json:"reason"`
+	Type      string    `json:"type,omitempty"`
+	Size      int       `json:"size"` // Bytes received
+	Error     string    `json:"error,omitempty"`
+	SessionID string    `json:"sessionId,omitempty"`
+	UserID    string    `json:"userId,omitempty"`
+	Preview   string    `json:"preview"` // Start of the message with control characters replaced
+}
+
+// DeadLetterSnapshot is what the admin API serves
+type DeadLetterSnapshot struct {
+	Total   uint64       `json:"total"`   // Dead letters since startup, including ones no longer kept
+	Letters []DeadLetter `json:"letters"` // Newest first
+}
+
+// DeadLetters keeps the most recent dead letters in a ring buffer
+// A nil *DeadLetters discards everything
+type DeadLetters struct {
+	letters []DeadLetter
+	next    int // Slot the next letter is written to
+	total   uint64
+	mu      sync.Mutex
+}
+
+// NewDeadLetters creates a buffer keeping up to size dead letters, or returns nil if size is zero
+func NewDeadLetters(size int) *DeadLetters {
+	if size <= 0 {
+		return nil
+	}
+	return &DeadLetters{letters: make([]DeadLetter, 0, size)}
+}
+
+// Add records a dead letter, replacing the oldest once the buffer is full
+func (d *DeadLetters) Add(letter DeadLetter) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.total++
+	if len(d.letters) < cap(d.letters) {
+		d.letters = append(d.letters, letter)
+		return
+	}
+	d.letters[d.next] = letter
+	d.next = (d.next + 1) % len(d.letters)
+}
+
+// Snapshot returns the kept dead letters, newest first
+func (d *DeadLetters) Snapshot() DeadLetterSnapshot {
+	if d == nil {
+		return DeadLetterSnapshot{Letters: []DeadLetter{}}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	letters := make([]DeadLetter, 0, len(d.letters))
+	for i := range d.letters {
+		// Walk backwards from the most recently written slot
+		index := (d.next - 1 - i + 2*len(d.letters)) % len(d.letters)
+		letters = append(letters, d.letters[index])
+	}
+	return DeadLetterSnapshot{Total: d.total, Letters: letters}
+}
+
+// SetDeadLetters sets where unprocessable messages are captured; call before Run
+func (h *Hub) SetDeadLetters(letters *DeadLetters) {
+	h.deadLetters = letters
+}
+
+// DeadLetters returns the hub's dead letter buffer, which may be nil
+func (h *Hub) DeadLetters() *DeadLetters {
+	return h.deadLetters
+}
+
+// recordDeadLetter captures a message from client that couldn't be processed
+func (h *Hub) recordDeadLetter(client *Client, reason, msgType string, raw []byte, err error) {
+	if h.deadLetters == nil {
+		return
+	}
+
+	letter := DeadLetter{
+		At:        time.Now(),
+		Reason:    reason,
+		Type:      msgType,
+		Size:      len(raw),
+		SessionID: client.sessionID,
+		UserID:    client.userID,
+		Preview:   previewBytes(raw),
+	}
+	if err != nil {
+		letter.Error = err.Error()
+	}
+	h.deadLetters.Add(letter)
+}
+
+// recordUnknownMessage captures a decoded message whose type no handler recognises
+func (h *Hub) recordUnknownMessage(client *Client, msg *Message) {
+	if h.deadLetters == nil {
+		return
+	}
+
+	raw, _ := json.Marshal(&Message{Type: msg.Type, Data: msg.Data})
+	h.recordDeadLetter(client, DeadLetterUnknownType, msg.Type, raw, nil)
+}
+
+// recordInvalidPayload captures a message whose data didn't match its type's payload
+func (h *Hub) recordInvalidPayload(client *Client, msg *Message, err error) {
+	if h.deadLetters == nil {
+		return
+	}
+
+	raw, _ := json.Marshal(&Message{Type: msg.Type, Data: msg.Data})
+	h.recordDeadLetter(client, DeadLetterInvalidPayload, msg.Type, raw, err)
+}
+
+// previewBytes returns the start of raw as valid UTF-8 with control characters
+// replaced, so it is safe to show in logs and dashboards
+func previewBytes(raw []byte) string {
+	if len(raw) > deadLetterPreviewBytes {
+		raw = raw[:deadLetterPreviewBytes]
+	}
+
+	var b strings.Builder
+	for len(raw) > 0 {
+		r, size := utf8.DecodeRune(raw)
+		raw = raw[size:]
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			b.WriteRune('�')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
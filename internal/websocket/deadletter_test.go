@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestDeadLettersKeepNewest(t *testing.T) {
+	letters := NewDeadLetters(3)
+	for i := 0; i < 5; i++ {
+		letters.Add(DeadLetter{Type: fmt.Sprintf("type_%d", i)})
+	}
+
+	snapshot := letters.Snapshot()
+	if snapshot.Total != 5 || len(snapshot.Letters) != 3 {
+		t.Fatalf("Expected 3 of 5 letters kept, got %d of %d", len(snapshot.Letters), snapshot.Total)
+	}
+	for i, want := range []string{"type_4", "type_3", "type_2"} {
+		if snapshot.Letters[i].Type != want {
+			t.Errorf("Expected letter %d to be %s, got %s", i, want, snapshot.Letters[i].Type)
+		}
+	}
+
+	// A nil buffer discards everything
+	var disabled *DeadLetters
+	disabled.Add(DeadLetter{})
+	if len(disabled.Snapshot().Letters) != 0 {
+		t.Error("Expected nil buffer to keep nothing")
+	}
+}
+
+func TestPreviewBytesSanitises(t *testing.T) {
+	preview := previewBytes([]byte("{\"type\":\x00\"bad\xff\"}\n"))
+	if preview != "{\"type\":�\"bad�\"}�" {
+		t.Errorf("Expected control characters and invalid UTF-8 replaced, got %q", preview)
+	}
+
+	long := make([]byte, deadLetterPreviewBytes*2)
+	for i := range long {
+		long[i] = 'a'
+	}
+	if got := len(previewBytes(long)); got != deadLetterPreviewBytes {
+		t.Errorf("Expected preview capped at %d bytes, got %d", deadLetterPreviewBytes, got)
+	}
+}
+
+func TestUnknownMessageTypeIsDeadLettered(t *testing.T) {
+	hub := NewHub(nil)
+	hub.SetDeadLetters(NewDeadLetters(10))
+	mh := NewMessageHandler(hub, session.NewManager())
+
+	client := newTestClient(hub, "session-1", "user-1")
+	mh.HandleMessage(client, &Message{Type: "sumbit_notes", Data: map[string]interface{}{"notes": []interface{}{}}})
+
+	letters := hub.DeadLetters().Snapshot().Letters
+	if len(letters) != 1 {
+		t.Fatalf("Expected one dead letter, got %d", len(letters))
+	}
+	letter := letters[0]
+	if letter.Reason != DeadLetterUnknownType || letter.Type != "sumbit_notes" || letter.UserID != "user-1" {
+		t.Errorf("Unexpected dead letter: %+v", letter)
+	}
+}
@@ -0,0 +1,80 @@
+// ABOUTME: Per-connection diagnostics so "the app feels laggy" reports come with real numbers
+// ABOUTME: Reports round-trip time, reconnects, send-buffer use and server processing delay
+package websocket
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// ReconnectsParam is the /ws query parameter clients report how often they've reconnected in
+const ReconnectsParam = "reconnects"
+
+// parseReconnects reads a client's reported reconnect count, treating anything invalid as 0
+func parseReconnects(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// handleGetDiagnostics replies with the client's connection diagnostics
+func (mh *MessageHandler) handleGetDiagnostics(client *Client) {
+	client.SendMessage(&Message{Type: "diagnostics", Data: client.diagnostics()})
+}
+
+// sendDiagnostics pushes diagnostics to every connection that negotiated the capability
+// Runs on the hub goroutine
+func (h *Hub) sendDiagnostics() {
+	for client := range h.connections {
+		if client.has(CapabilityDiagnostics) {
+			client.SendMessage(&Message{Type: "diagnostics", Data: client.diagnostics()})
+		}
+	}
+}
+
+// recordProcessing notes how long a message took from being read to being handled
+// Runs where the message was handled: the session's actor or the hub goroutine
+func (c *Client) recordProcessing(received time.Time) {
+	if received.IsZero() {
+		return
+	}
+	c.processingDelay = time.Since(received)
+	c.maxProcessingDelay = max(c.maxProcessingDelay, c.processingDelay)
+}
+
+// diagnostics describes the client's connection as the server sees it
+// Runs while handling the client's messages, or on the hub goroutine with actors paused
+func (c *Client) diagnostics() map[string]interface{} {
+	queued, capacity := len(c.send), cap(c.send)
+	utilization := 0.0
+	if capacity > 0 {
+		utilization = math.Round(float64(queued)/float64(capacity)*1000) / 1000
+	}
+
+	data := map[string]interface{}{
+		"reconnects": c.reconnects,
+		"missedAcks": c.missedAcks(),
+		"sendBuffer": map[string]interface{}{
+			"queued":      queued,
+			"capacity":    capacity,
+			"utilization": utilization,
+			"dropped":     c.dropped.Load(),
+		},
+		"processingDelayMs":    milliseconds(c.processingDelay),
+		"maxProcessingDelayMs": milliseconds(c.maxProcessingDelay),
+		"serverTime":           time.Now().UnixMilli(),
+	}
+	// Unmeasured until the client answers a latency probe
+	if c.rtt > 0 {
+		data["rttMs"] = milliseconds(c.rtt)
+	}
+	return data
+}
+
+// milliseconds converts a duration to milliseconds with one decimal place
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*10) / 10
+}
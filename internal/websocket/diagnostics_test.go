@@ -0,0 +1,84 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestGetDiagnostics(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	client := newTestClient(hub, sess.ID, alice.ID)
+	client.reconnects = 2
+
+	mh.HandleMessage(client, &Message{Type: "get_diagnostics"})
+	report := nextMessage(t, client)
+	if report.Type != "diagnostics" || report.Data["reconnects"] != float64(2) {
+		t.Fatalf("Expected diagnostics with 2 reconnects, got %+v", report)
+	}
+	if _, ok := report.Data["rttMs"]; ok {
+		t.Error("Expected no RTT before a latency probe is answered")
+	}
+	buffer, _ := report.Data["sendBuffer"].(map[string]interface{})
+	if buffer["capacity"] != float64(256) {
+		t.Errorf("Expected the send buffer capacity, got %+v", buffer)
+	}
+
+	// Answering a probe and processing a message fill in the timings
+	mh.HandleMessage(client, &Message{Type: "pong", Data: map[string]interface{}{
+		"sentAt": float64(time.Now().Add(-40 * time.Millisecond).UnixMilli()),
+	}})
+	nextMessage(t, host) // participant_latency
+	client.recordProcessing(time.Now().Add(-5 * time.Millisecond))
+
+	mh.HandleMessage(client, &Message{Type: "get_diagnostics"})
+	report = nextMessage(t, client)
+	if rtt, _ := report.Data["rttMs"].(float64); rtt < 40 {
+		t.Errorf("Expected an RTT of at least 40ms, got %v", report.Data["rttMs"])
+	}
+	if delay, _ := report.Data["processingDelayMs"].(float64); delay < 5 {
+		t.Errorf("Expected a processing delay of at least 5ms, got %v", report.Data["processingDelayMs"])
+	}
+}
+
+func TestDiagnosticsCapability(t *testing.T) {
+	hub := NewHub(nil)
+	mh := NewMessageHandler(hub, session.NewManager())
+	subscribed := &Client{send: make(chan []byte, 16), hub: hub}
+	other := &Client{send: make(chan []byte, 16), hub: hub}
+	hub.connections[subscribed] = true
+	hub.connections[other] = true
+
+	mh.HandleMessage(subscribed, &Message{Type: "hello", Data: map[string]interface{}{
+		"capabilities": []interface{}{"diagnostics"},
+	}})
+	nextMessage(t, subscribed) // hello
+
+	// Diagnostics don't change rendering, so broadcasts needn't encode separately
+	if subscribed.variant() != other.variant() {
+		t.Error("Expected the diagnostics capability not to split the broadcast variant")
+	}
+
+	hub.sendDiagnostics()
+	if msg := nextMessage(t, subscribed); msg.Type != "diagnostics" {
+		t.Errorf("Expected periodic diagnostics, got %+v", msg)
+	}
+	if len(other.send) != 0 {
+		t.Error("Expected no diagnostics for a client that didn't ask")
+	}
+}
+
+func TestParseReconnects(t *testing.T) {
+	for value, expected := range map[string]int{"": 0, "3": 3, "-1": 0, "many": 0} {
+		if got := parseReconnects(value); got != expected {
+			t.Errorf("parseReconnects(%q) = %d, expected %d", value, got, expected)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// ABOUTME: Decides who may use voice dictation, which runs over HTTP outside the WebSocket
+// ABOUTME: Only participants connected to a session that is collecting notes qualify
+package websocket
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// newDictationToken generates the secret a connection proves itself with on the dictation endpoint
+func newDictationToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// addDictationToken gives a client the token it dictates with
+// It's sent only to that client, unlike user IDs, which every participant sees
+func addDictationToken(client *Client, data map[string]interface{}) {
+	if client.dictationToken != "" {
+		data["dictationToken"] = client.dictationToken
+	}
+}
+
+// dictatingUser returns the participant whose connection to a session holds token
+func (h *Hub) dictatingUser(sessionID, token string) (string, bool) {
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+
+	for client := range h.clients[sessionID] {
+		if !client.observer && client.dictationToken != "" &&
+			subtle.ConstantTimeCompare([]byte(client.dictationToken), []byte(token)) == 1 {
+			return client.userID, true
+		}
+	}
+	return "", false
+}
+
+// CanDictate returns the participant whose connection to the session with the given code
+// was issued token, if the session is in the writing phase
+// Safe to call from any goroutine; the check runs on the session's actor
+func (mh *MessageHandler) CanDictate(sessionCode, token string) (string, bool) {
+	sess, err := mh.sessionManager.GetSessionByCode(sessionCode)
+	if err != nil || token == "" {
+		return "", false
+	}
+
+	type result struct {
+		userID  string
+		allowed bool
+	}
+	checked := make(chan result, 1)
+	mh.hub.ScheduleSession(sess.ID, func() {
+		userID, connected := mh.hub.dictatingUser(sess.ID, token)
+		checked <- result{userID, connected && sess.Phase == session.PhaseWriting && sess.HasParticipant(userID)}
+	})
+	r := <-checked
+	return r.userID, r.allowed
+}
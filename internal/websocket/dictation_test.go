@@ -0,0 +1,59 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestCanDictate(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	newTestClient(hub, sess.ID, alice.ID).dictationToken = "alice-token"
+	newTestClient(hub, sess.ID, bob.ID)
+
+	if _, ok := mh.CanDictate(sess.Code, "alice-token"); ok {
+		t.Error("Expected dictation to be refused before writing starts")
+	}
+
+	sess.TransitionToWriting()
+	if userID, ok := mh.CanDictate(sess.Code, "alice-token"); !ok || userID != alice.ID {
+		t.Errorf("Expected a connected participant to dictate while writing, got %q %v", userID, ok)
+	}
+	if _, ok := mh.CanDictate(sess.Code, alice.ID); ok {
+		t.Error("Expected a user ID, which every participant sees, not to be accepted as a token")
+	}
+	if _, ok := mh.CanDictate(sess.Code, ""); ok {
+		t.Error("Expected a connection without a token to be refused")
+	}
+	if _, ok := mh.CanDictate("NOPE99", "alice-token"); ok {
+		t.Error("Expected an unknown session to be refused")
+	}
+}
+
+func TestJoinSendsDictationTokenOnlyToJoiner(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+
+	joiner := &Client{send: make(chan []byte, 16), hub: hub, dictationToken: newDictationToken()}
+	mh.handleJoinSession(joiner, &joinSessionRequest{SessionCode: sess.Code, UserName: "Alice"})
+
+	joined := nextMessage(t, joiner)
+	if joined.Type != "session_joined" || joined.Data["dictationToken"] != joiner.dictationToken {
+		t.Fatalf("Expected the joiner's dictation token, got %+v", joined)
+	}
+	if msg := nextMessage(t, host); msg.Data["dictationToken"] != nil {
+		t.Errorf("Expected other participants not to see the token, got %+v", msg)
+	}
+}
@@ -0,0 +1,98 @@
+// ABOUTME: Drain mode ahead of a restart: new sessions are refused and clients are warned
+// ABOUTME: Shutdown waits for circles that are reading aloud to finish, up to a deadline
+package websocket
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// drainPollInterval is how often shutdown checks whether reading circles have finished
+const drainPollInterval = time.Second
+
+// ErrServerDraining is returned for new sessions while the server drains for a restart
+var ErrServerDraining = errors.New("the server is restarting, please try again in a moment")
+
+// drainNotice is what clients are told when the server starts draining
+type drainNotice struct {
+	deadline time.Time     // When the server stops, at the latest
+	downtime time.Duration // How long the restart is expected to take
+}
+
+// Drain stops new sessions being created and tells every connected client the server
+// will stop by deadline and expects to be back downtime later
+// Safe to call from any goroutine
+func (mh *MessageHandler) Drain(deadline time.Time, downtime time.Duration) {
+	mh.draining.Store(true)
+	mh.hub.Schedule(func() {
+		mh.drain = &drainNotice{deadline: deadline, downtime: downtime}
+		for _, sess := range mh.sessionManager.GetAllSessions() {
+			mh.hub.BroadcastToSession(sess.ID, mh.serverRestartingMessage())
+		}
+		slog.Info("Draining for restart", "deadline", deadline, "downtime", downtime)
+	})
+}
+
+// Draining reports whether the server is draining for a restart
+// Safe to call from any goroutine
+func (mh *MessageHandler) Draining() bool {
+	return mh.draining.Load()
+}
+
+// serverRestartingMessage tells clients when the server will stop and how long it expects to be away
+// Runs on the hub goroutine
+func (mh *MessageHandler) serverRestartingMessage() *Message {
+	return &Message{
+		Type: "server_restarting",
+		Data: map[string]interface{}{
+			"deadline":          mh.drain.deadline.UnixMilli(),
+			"estimatedDowntime": int(mh.drain.downtime.Seconds()),
+		},
+	}
+}
+
+// sendDrainNotice warns a client that arrives while the server is draining
+// Runs on the hub goroutine
+func (mh *MessageHandler) sendDrainNotice(client *Client) {
+	if mh.drain != nil {
+		client.SendMessage(mh.serverRestartingMessage())
+	}
+}
+
+// WaitForReading blocks until no circle with connected clients is reading aloud, or ctx is done
+// Reports whether every reading circle finished
+func (mh *MessageHandler) WaitForReading(ctx context.Context) bool {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if mh.readingSessions() == 0 {
+			return true
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// readingSessions counts sessions in the reading phase that still have someone connected
+// Safe to call from any goroutine
+func (mh *MessageHandler) readingSessions() int {
+	result := make(chan int, 1)
+	mh.hub.Schedule(func() {
+		count := 0
+		for _, sess := range mh.sessionManager.GetAllSessions() {
+			if sess.Phase == session.PhaseReading && mh.hub.GetSessionClientCount(sess.ID) > 0 {
+				count++
+			}
+		}
+		result <- count
+	})
+	return <-result
+}
@@ -0,0 +1,55 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestDrain(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	sess.TransitionToReading()
+	host := newTestClient(hub, sess.ID, sess.HostID)
+
+	deadline := time.Now().Add(time.Minute)
+	mh.Drain(deadline, 30*time.Second)
+	notice := nextMessage(t, host)
+	if notice.Type != "server_restarting" || notice.Data["deadline"] != float64(deadline.UnixMilli()) || notice.Data["estimatedDowntime"] != float64(30) {
+		t.Errorf("Expected server_restarting with the deadline and downtime, got %+v", notice)
+	}
+	if !mh.Draining() {
+		t.Error("Expected the handler to report draining")
+	}
+
+	// New sessions are refused
+	creator := &Client{send: make(chan []byte, 16), hub: hub}
+	hub.Schedule(func() {
+		mh.HandleMessage(creator, &Message{Type: "create_session", Data: map[string]interface{}{"userName": "Sam"}})
+	})
+	if reply := nextMessage(t, creator); reply.Type != "error" || reply.Data["code"] != "server_draining" {
+		t.Errorf("Expected create_session to be refused while draining, got %+v", reply)
+	}
+
+	// Shutdown waits while the circle is reading, until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if mh.WaitForReading(ctx) {
+		t.Error("Expected the wait to time out while a circle is reading")
+	}
+
+	hub.Schedule(func() { sess.Phase = session.PhaseComplete })
+	if !mh.WaitForReading(context.Background()) {
+		t.Error("Expected the wait to end once reading finished")
+	}
+}
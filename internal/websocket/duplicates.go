@@ -0,0 +1,42 @@
+// ABOUTME: Applies a session's duplicate note policy as notes are submitted
+// ABOUTME: Warns the author or privately flags the host when one note is sent to several people
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// findDuplicateNote returns the author's earlier note that content nearly repeats,
+// or nil if there isn't one or the session doesn't check
+func findDuplicateNote(sess *session.Session, authorID, recipientID, content string) *session.Note {
+	if sess.DuplicatePolicy == session.DuplicateOff {
+		return nil
+	}
+	return sess.FindDuplicateNote(authorID, recipientID, content)
+}
+
+// reportDuplicateNote tells the author or the host about an accepted near-duplicate note
+// Neither message includes note content
+func (mh *MessageHandler) reportDuplicateNote(client *Client, sess *session.Session, recipientID string, duplicate *session.Note) {
+	client.logger().Info("Duplicate note", "policy", sess.DuplicatePolicy)
+
+	switch sess.DuplicatePolicy {
+	case session.DuplicateWarn:
+		client.SendMessage(&Message{
+			Type: "duplicate_note_warning",
+			Data: map[string]interface{}{
+				"recipientId": recipientID,
+				"similarTo":   duplicate.RecipientID,
+				"message":     "This note is very similar to one you wrote to someone else. Consider making it personal.",
+			},
+		})
+	case session.DuplicateFlag:
+		mh.hub.SendToUser(sess.ID, sess.HostID, &Message{
+			Type: "duplicate_note_flagged",
+			Data: map[string]interface{}{
+				"authorId":     client.userID,
+				"recipientIds": []string{duplicate.RecipientID, recipientID},
+			},
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// submitNote sends one note from client to recipientID
+func submitNote(mh *MessageHandler, client *Client, recipientID, content string) {
+	mh.HandleMessage(client, &Message{
+		Type: "submit_notes",
+		Data: map[string]interface{}{
+			"notes": []interface{}{
+				map[string]interface{}{"recipientId": recipientID, "content": content},
+			},
+		},
+	})
+}
+
+func TestDuplicateNotePolicies(t *testing.T) {
+	tests := []struct {
+		policy      session.DuplicatePolicy
+		authorReply string // Second message the author receives, if any
+		hostReply   string
+		notes       int
+	}{
+		{session.DuplicateWarn, "duplicate_note_warning", "", 2},
+		{session.DuplicateFlag, "", "duplicate_note_flagged", 2},
+		{session.DuplicateReject, "error", "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			hub := NewHub(nil)
+			manager := session.NewManager()
+			mh := NewMessageHandler(hub, manager)
+
+			sess := manager.CreateSession("Host")
+			sess.SetMaxNoteLength(500)
+			sess.SetDuplicatePolicy(tt.policy)
+			alice, _ := sess.AddParticipant("Alice")
+			bob, _ := sess.AddParticipant("Bob")
+			sess.TransitionToWriting()
+
+			host := newTestClient(hub, sess.ID, sess.HostID)
+			aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+			submitNote(mh, aliceClient, bob.ID, "Thank you Bob for everything you do for the team")
+			nextMessage(t, aliceClient) // notes_submitted
+			nextMessage(t, host)        // received_note_counts
+
+			submitNote(mh, aliceClient, sess.HostID, "Thank you Host for everything you do for the team")
+			if tt.authorReply != "" {
+				if reply := nextMessage(t, aliceClient); reply.Type != tt.authorReply {
+					t.Errorf("Expected %s for the author, got %+v", tt.authorReply, reply)
+				}
+			}
+			if tt.hostReply != "" {
+				if reply := nextMessage(t, host); reply.Type != tt.hostReply {
+					t.Errorf("Expected %s for the host, got %+v", tt.hostReply, reply)
+				}
+			}
+			if len(sess.Notes) != tt.notes {
+				t.Errorf("Expected %d notes, got %d", tt.notes, len(sess.Notes))
+			}
+		})
+	}
+}
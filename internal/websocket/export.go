@@ -0,0 +1,109 @@
+// ABOUTME: Retains a completed circle's keepsakes for download and gives each participant their export token
+// ABOUTME: Each keepsake holds only the notes its participant received, private ones included
+package websocket
+
+import (
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/export"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// SetExports sets where completed circles' keepsakes are retained for download (nil = disabled)
+func (mh *MessageHandler) SetExports(store *export.Store) {
+	mh.exports = store
+}
+
+// retainExports keeps the keepsake of every participant in a session that just completed
+func (mh *MessageHandler) retainExports(sess *session.Session) {
+	if mh.exports == nil {
+		return
+	}
+
+	keepsakes := make(map[string]export.Keepsake)
+	for _, participant := range sess.GetParticipantList() {
+		keepsakes[participant.ID] = keepsakeFor(sess, participant)
+	}
+	mh.exports.Retain(sess.ID, keepsakes)
+}
+
+// keepsakeFor collects the notes a participant received, naming authors only in attributed sessions
+func keepsakeFor(sess *session.Session, participant *session.Participant) export.Keepsake {
+	keepsake := export.Keepsake{
+		Title:       sess.Title,
+		CompletedAt: time.Now(),
+		Recipient:   participant.Name,
+		Notes:       []export.Note{},
+	}
+	if sess.CompletedAt != nil {
+		keepsake.CompletedAt = *sess.CompletedAt
+	}
+	if theme, ok := session.LookupTheme(sess.Theme); ok {
+		keepsake.Theme = theme.Name
+	}
+
+	attributed := sess.AttributesNotes()
+	for _, note := range sess.Notes {
+		if note.RecipientID != participant.ID {
+			continue
+		}
+		entry := export.Note{Content: note.Content, GIFURL: note.GIFURL, Private: note.Private}
+		if author, exists := sess.Participants[note.AuthorID]; attributed && exists {
+			entry.Author = author.Name
+		}
+		keepsake.Notes = append(keepsake.Notes, entry)
+	}
+
+	// The host gets the circle's timeline to look back on how it went
+	if participant.ID == sess.HostID {
+		keepsake.Timeline = exportTimeline(sess)
+	}
+	return keepsake
+}
+
+// exportTimeline converts the session's timeline for export, naming participants
+// (including those who have since left) instead of giving their IDs
+func exportTimeline(sess *session.Session) []export.Event {
+	timeline := sess.Timeline()
+
+	names := make(map[string]string)
+	for _, event := range timeline {
+		if name, ok := event.Data["name"].(string); ok {
+			names[event.ParticipantID] = name
+		}
+	}
+	for _, participant := range sess.GetParticipantList() {
+		names[participant.ID] = participant.Name
+	}
+
+	events := make([]export.Event, len(timeline))
+	for i, event := range timeline {
+		var data map[string]interface{}
+		for key, value := range event.Data {
+			if key == "name" {
+				continue
+			}
+			if data == nil {
+				data = make(map[string]interface{})
+			}
+			data[key] = value
+		}
+		events[i] = export.Event{
+			At:          event.At,
+			Type:        event.Type,
+			Participant: names[event.ParticipantID],
+			Data:        data,
+		}
+	}
+	return events
+}
+
+// addExportToken adds a participant's export token and download URL to their session_complete
+func (mh *MessageHandler) addExportToken(sess *session.Session, participantID string, data map[string]interface{}) {
+	token := mh.exports.Token(sess.ID, participantID)
+	if token == "" {
+		return
+	}
+	data["exportToken"] = token
+	data["exportUrl"] = export.URL(sess.ID, token)
+}
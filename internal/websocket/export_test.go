@@ -0,0 +1,60 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/export"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestExportOnCompletion(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	exports := export.NewStore(0)
+	mh.SetExports(exports)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	sess.Phase = session.PhaseComplete
+	mh.broadcastSessionComplete(sess)
+
+	reply := nextMessage(t, aliceClient)
+	token, _ := reply.Data["exportToken"].(string)
+	if reply.Type != "session_complete" || token == "" || reply.Data["exportUrl"] != export.URL(sess.ID, token) {
+		t.Fatalf("Expected session_complete with an export token, got %+v", reply)
+	}
+	hostReply := nextMessage(t, host)
+	hostToken, _ := hostReply.Data["exportToken"].(string)
+	if hostToken == token {
+		t.Error("Expected each participant to get their own token")
+	}
+
+	// Alice's keepsake holds only the note written to her, without its author
+	keepsake, ok := exports.Get(sess.ID, token)
+	if !ok || keepsake.Recipient != "Alice" || len(keepsake.Notes) != 1 || keepsake.Notes[0].Content != "Thanks Alice" || keepsake.Notes[0].Author != "" {
+		t.Errorf("Expected Alice's anonymous keepsake, got %+v", keepsake)
+	}
+	if keepsake.Timeline != nil {
+		t.Error("Expected only the host's keepsake to carry the timeline")
+	}
+
+	// The host's keepsake names who each event concerns
+	hostKeepsake, _ := exports.Get(sess.ID, hostToken)
+	if len(hostKeepsake.Timeline) < 2 || hostKeepsake.Timeline[1].Type != session.EventJoined || hostKeepsake.Timeline[1].Participant != "Alice" {
+		t.Errorf("Expected the host's keepsake to include the named timeline, got %+v", hostKeepsake.Timeline)
+	}
+
+	// Rejoining after completion hands the same token back
+	rejoined := sessionCompleteMessage(sess, alice.ID)
+	mh.addExportToken(sess, alice.ID, rejoined.Data)
+	if rejoined.Data["exportToken"] != token {
+		t.Errorf("Expected the same token on rejoin, got %v", rejoined.Data["exportToken"])
+	}
+}
@@ -0,0 +1,47 @@
+// ABOUTME: GIF search for notes, proxied through the server so API keys stay out of the browser
+// ABOUTME: Searches run off the hub goroutine and reply to the requesting client only
+package websocket
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// gifSearchTimeout bounds each proxied search
+	gifSearchTimeout = 5 * time.Second
+
+	// maxGIFURLLength bounds GIF URLs attached to notes
+	maxGIFURLLength = 2048
+)
+
+// handleSearchGIFs searches the configured GIF provider for a client
+func (mh *MessageHandler) handleSearchGIFs(client *Client, req *searchGIFsRequest) {
+	if mh.gifs == nil {
+		mh.sendErrorCode(client, "gifs_unavailable", "gif search is not available")
+		return
+	}
+
+	query, limit := req.Query, req.Limit
+
+	// Provider calls can be slow, so don't hold up the hub loop
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), gifSearchTimeout)
+		defer cancel()
+
+		results, err := mh.gifs.Search(ctx, query, limit)
+		if err != nil {
+			client.logger().Error("GIF search failed", "err", err)
+			mh.sendErrorCode(client, "gif_search_failed", "gif search failed")
+			return
+		}
+
+		client.SendMessage(&Message{
+			Type: "gif_results",
+			Data: map[string]interface{}{
+				"query":   query,
+				"results": results,
+			},
+		})
+	}()
+}
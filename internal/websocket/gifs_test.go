@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/gifs"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// stubGIFProvider returns a fixed result from a fake media host
+type stubGIFProvider struct{}
+
+func (stubGIFProvider) Search(ctx context.Context, query string, limit int, rating gifs.Rating) ([]gifs.GIF, error) {
+	return []gifs.GIF{{ID: "1", URL: "https://media.gifs.test/party.gif"}}, nil
+}
+
+func (stubGIFProvider) MediaHosts() []string {
+	return []string{"gifs.test"}
+}
+
+// nextMessage reads the next message queued for a client
+func nextMessage(t *testing.T, client *Client) Message {
+	t.Helper()
+
+	select {
+	case data := <-client.send:
+		var msg Message
+		json.Unmarshal(data, &msg)
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("Expected a message")
+		return Message{}
+	}
+}
+
+func TestSearchGIFsAndAttach(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	sess.SetMaxNoteLength(500)
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	// Without a provider, search is unavailable and GIFs can't be attached
+	mh.HandleMessage(aliceClient, &Message{Type: "search_gifs", Data: map[string]interface{}{"query": "party"}})
+	if reply := nextMessage(t, aliceClient); reply.Data["code"] != "gifs_unavailable" {
+		t.Errorf("Expected gifs_unavailable, got %+v", reply)
+	}
+
+	mh.SetGIFs(gifs.NewSearcher(stubGIFProvider{}, gifs.RatingG))
+
+	mh.HandleMessage(aliceClient, &Message{Type: "search_gifs", Data: map[string]interface{}{"query": "party"}})
+	reply := nextMessage(t, aliceClient)
+	if reply.Type != "gif_results" {
+		t.Fatalf("Expected gif_results, got %+v", reply)
+	}
+	results, _ := reply.Data["results"].([]interface{})
+	if len(results) != 1 {
+		t.Fatalf("Expected one result, got %+v", reply.Data)
+	}
+
+	sess.TransitionToWriting()
+
+	mh.HandleMessage(aliceClient, &Message{Type: "submit_notes", Data: map[string]interface{}{
+		"notes": []interface{}{map[string]interface{}{
+			"recipientId": bob.ID,
+			"content":     "Thanks for all the help this sprint",
+			"gifUrl":      "https://example.com/tracker.gif",
+		}},
+	}})
+	if reply := nextMessage(t, aliceClient); reply.Data["code"] != "invalid_gif" {
+		t.Errorf("Expected invalid_gif for foreign URL, got %+v", reply)
+	}
+
+	mh.HandleMessage(aliceClient, &Message{Type: "submit_notes", Data: map[string]interface{}{
+		"notes": []interface{}{map[string]interface{}{
+			"recipientId": bob.ID,
+			"content":     "Thanks for all the help this sprint",
+			"gifUrl":      "https://media.gifs.test/party.gif",
+		}},
+	}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "notes_submitted" {
+		t.Fatalf("Expected notes_submitted, got %+v", reply)
+	}
+	if len(sess.Notes) != 1 || sess.Notes[0].GIFURL != "https://media.gifs.test/party.gif" {
+		t.Errorf("Expected GIF attached to note, got %+v", sess.Notes)
+	}
+}
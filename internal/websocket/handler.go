@@ -3,10 +3,17 @@
 package websocket
 
 import (
-	"log"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/cassiascheffer/uplift/internal/branding"
+	"github.com/cassiascheffer/uplift/internal/ratelimit"
 )
 
 var upgrader = websocket.Upgrader{
@@ -14,43 +21,132 @@ var upgrader = websocket.Upgrader{
 	WriteBufferSize:   4096,
 	EnableCompression: true,
 	CheckOrigin: func(r *http.Request) bool {
-		// Allow all origins for development
-		// In production, implement proper origin checking
+		// Allow all origins unless an allowlist is configured via SetCheckOrigin
 		return true
 	},
 }
 
+// CompressionConfig controls per-message compression of outbound messages
+type CompressionConfig struct {
+	Enabled bool // Negotiate permessage-deflate with clients
+	Level   int  // flate level from -2 (Huffman only) to 9 (best compression)
+	MinSize int  // Messages smaller than this many bytes are sent uncompressed
+}
+
+// DefaultCompressionConfig returns the compression settings used when none are configured
+func DefaultCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		Enabled: true,
+		Level:   flate.BestSpeed,
+		MinSize: 256,
+	}
+}
+
 // Handler handles WebSocket upgrade requests
 type Handler struct {
-	hub *Hub
+	hub         *Hub
+	upgrader    websocket.Upgrader
+	compression CompressionConfig
+	branding    *branding.Registry
 }
 
 // NewHandler creates a new WebSocket handler
 func NewHandler(hub *Hub) *Handler {
 	return &Handler{
-		hub: hub,
+		hub:         hub,
+		upgrader:    upgrader,
+		compression: DefaultCompressionConfig(),
 	}
 }
 
+// SetCompression replaces the compression policy for new connections
+func (h *Handler) SetCompression(config CompressionConfig) error {
+	if config.Level < flate.HuffmanOnly || config.Level > flate.BestCompression {
+		return errors.New("compression level must be between -2 and 9")
+	}
+	if config.MinSize < 0 {
+		return errors.New("compression minimum size must not be negative")
+	}
+
+	h.compression = config
+	h.upgrader.EnableCompression = config.Enabled
+	return nil
+}
+
+// SetCheckOrigin replaces the origin check applied to upgrade requests
+func (h *Handler) SetCheckOrigin(checkOrigin func(r *http.Request) bool) {
+	h.upgrader.CheckOrigin = checkOrigin
+}
+
 // ServeHTTP handles the WebSocket connection upgrade
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	remoteIP := ratelimit.ClientIP(r)
+	if !h.allowUpgrade(w, remoteIP) {
+		slog.Warn("WebSocket upgrade rate limited", "remoteAddr", r.RemoteAddr)
+		return
+	}
+
+	header, instanceHint := h.affinity(r)
+	conn, err := h.upgrader.Upgrade(w, r, header)
 	if err != nil {
-		log.Printf("websocket upgrade error: %v", err)
+		slog.Warn("WebSocket upgrade failed", "remoteAddr", r.RemoteAddr, "err", err)
+		return
+	}
+
+	if h.compression.Enabled {
+		if err := conn.SetCompressionLevel(h.compression.Level); err != nil {
+			slog.Warn("Failed to set WebSocket compression level", "err", err)
+		}
+	}
+
+	// Refused after upgrading so the client can be told why
+	if !h.hub.acquireConnection(remoteIP) {
+		slog.Warn("WebSocket connection cap reached", "remoteIP", remoteIP)
+		rejectConnectionCap(conn, h.hub.rateLimits.Connections)
+		return
+	}
+
+	version, err := h.hub.protocol.ParseVersion(r.URL.Query().Get(ProtocolParam))
+	if err != nil {
+		h.hub.releaseConnection(remoteIP)
+		rejectProtocol(conn, err)
 		return
 	}
 
 	client := &Client{
-		conn:                conn,
-		send:                make(chan []byte, 256),
-		hub:                 h.hub,
-		stopInactivityCheck: make(chan struct{}),
+		conn:            conn,
+		connID:          newConnID(),
+		dictationToken:  newDictationToken(),
+		remoteIP:        remoteIP,
+		send:            make(chan []byte, 256),
+		hub:             h.hub,
+		compressMinSize: h.compression.MinSize,
+		instanceHint:    instanceHint,
+		protocolVersion: version,
+		reconnects:      parseReconnects(r.URL.Query().Get(ReconnectsParam)),
+		tenant:          h.branding.Resolve(r),
 	}
+	client.touch()
+
+	// Track the connection for inactivity sweeps before any pump can unregister it
+	h.hub.connect <- client
 
 	// Don't register yet - wait until we know their sessionID
 	// Registration happens in handleCreateSession and handleJoinSession
 
+	// Tell white-labelled frontends how to look before anything else
+	if h.branding != nil {
+		client.SendMessage(brandingMessage(h.branding.Get(client.tenant)))
+	}
+
 	// Start client pumps
 	go client.writePump()
 	go client.readPump()
 }
+
+// newConnID generates the ID that correlates a connection's log lines
+func newConnID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,111 @@
+package websocket
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	gorillaws "github.com/gorilla/websocket"
+)
+
+func TestSetCompression(t *testing.T) {
+	handler := NewHandler(NewHub(nil))
+
+	if !handler.upgrader.EnableCompression {
+		t.Error("Expected compression to be enabled by default")
+	}
+
+	if err := handler.SetCompression(CompressionConfig{Enabled: false, Level: 1}); err != nil {
+		t.Fatalf("Failed to disable compression: %v", err)
+	}
+	if handler.upgrader.EnableCompression {
+		t.Error("Expected compression to be disabled")
+	}
+
+	invalid := []CompressionConfig{
+		{Enabled: true, Level: 10},
+		{Enabled: true, Level: -3},
+		{Enabled: true, Level: 1, MinSize: -1},
+	}
+	for _, config := range invalid {
+		if err := handler.SetCompression(config); err == nil {
+			t.Errorf("Expected error for config %+v", config)
+		}
+	}
+}
+
+func TestConnectionsDoNotLeakGoroutines(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	hub := NewHub(nil)
+	go hub.Run()
+
+	server := httptest.NewServer(NewHandler(hub))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	baseline := runtime.NumGoroutine()
+
+	for i := 0; i < 20; i++ {
+		conn, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		conn.WriteMessage(gorillaws.CloseMessage, gorillaws.FormatCloseMessage(gorillaws.CloseNormalClosure, ""))
+		conn.Close()
+	}
+
+	// Pumps exit asynchronously once the connections close
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := runtime.NumGoroutine(); got > baseline {
+		t.Errorf("Expected goroutines to return to %d after disconnects, got %d", baseline, got)
+	}
+
+	// The hub should have forgotten every connection
+	done := make(chan int)
+	hub.Schedule(func() { done <- len(hub.connections) })
+	if remaining := <-done; remaining != 0 {
+		t.Errorf("Expected no tracked connections, got %d", remaining)
+	}
+}
+
+func TestOversizedMessageExplainsDisconnect(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	hub := NewHub(nil)
+	go hub.Run()
+
+	server := httptest.NewServer(NewHandler(hub))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	conn, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	conn.WriteMessage(gorillaws.TextMessage, []byte(strings.Repeat("x", maxMessageSize+1)))
+
+	msg := readMessage(t, conn)
+	if msg.Type != "error" || msg.Data["code"] != "message_too_large" || msg.Data["limit"] != float64(maxMessageSize) {
+		t.Fatalf("Expected message_too_large with the limit, got %+v", msg)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = conn.ReadMessage()
+	if !gorillaws.IsCloseError(err, gorillaws.CloseMessageTooBig) {
+		t.Errorf("Expected close code 1009, got %v", err)
+	}
+}
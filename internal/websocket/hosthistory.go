@@ -0,0 +1,53 @@
+// ABOUTME: Links sessions to the facilitator who ran them for the host history dashboard
+// ABOUTME: Issues host keys on session creation and records each circle when it ends
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/analytics"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// hostKeyPattern accepts keys we issued, or similar ones a client generated itself
+var hostKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{32,128}$`)
+
+// SetHostHistory enables per-host history, served to hosts who present their host key
+func (mh *MessageHandler) SetHostHistory(history *analytics.HostHistory) {
+	mh.hostHistory = history
+}
+
+// hostKeyFor returns the host key a create_session message carried, or a new one
+// Returns "" when host history is disabled
+func (mh *MessageHandler) hostKeyFor(key string) string {
+	if mh.hostHistory == nil {
+		return ""
+	}
+
+	if hostKeyPattern.MatchString(key) {
+		return key
+	}
+
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// recordHostCircle adds an ending session to its host's history
+func (mh *MessageHandler) recordHostCircle(sess *session.Session, completed bool) {
+	if mh.hostHistory == nil {
+		return
+	}
+
+	notes, authors := sess.NoteStats()
+	mh.hostHistory.Record(sess.HostKey(), analytics.Circle{
+		EndedAt:      time.Now(),
+		Completed:    completed,
+		Participants: len(sess.GetParticipantList()),
+		Contributors: authors,
+		Notes:        notes,
+	})
+}
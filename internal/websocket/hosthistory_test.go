@@ -0,0 +1,45 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/analytics"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestCreateSessionIssuesHostKey(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	history := analytics.NewHostHistory()
+	mh.SetHostHistory(history)
+
+	client := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(client, &Message{Type: "create_session", Data: map[string]interface{}{"userName": "Host"}})
+	reply := nextMessage(t, client)
+	issued, _ := reply.Data["hostKey"].(string)
+	if reply.Type != "session_created" || len(issued) < 32 {
+		t.Fatalf("Expected session_created with a host key, got %+v", reply)
+	}
+
+	// A returning host keeps their key
+	again := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(again, &Message{Type: "create_session", Data: map[string]interface{}{"userName": "Host", "hostKey": issued}})
+	if reply := nextMessage(t, again); reply.Data["hostKey"] != issued {
+		t.Errorf("Expected the host key to be reused, got %v", reply.Data["hostKey"])
+	}
+
+	// Keys that don't look like ours are replaced
+	odd := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(odd, &Message{Type: "create_session", Data: map[string]interface{}{"userName": "Host", "hostKey": strings.Repeat("!", 40)}})
+	if reply := nextMessage(t, odd); reply.Data["hostKey"] == strings.Repeat("!", 40) {
+		t.Error("Expected an invalid host key to be replaced")
+	}
+
+	sess, _ := manager.GetSessionByID(again.sessionID)
+	mh.recordHostCircle(sess, true)
+	if summary := history.Summary(issued, 1); summary.CirclesRun != 1 || summary.AvgParticipants != 1 {
+		t.Errorf("Expected the circle in the host's history, got %+v", summary)
+	}
+}
@@ -1,16 +1,37 @@
 // ABOUTME: WebSocket hub for managing all client connections and message broadcasting
-// ABOUTME: Central coordinator for real-time communication between clients in sessions
+// ABOUTME: Central coordinator for connections; each session's messages are handled by its actor (see actors.go)
 package websocket
 
 import (
-	"log"
+	"errors"
+	"log/slog"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// InactivityConfig controls when idle clients are warned and disconnected
+type InactivityConfig struct {
+	Timeout     time.Duration // Idle time before a participant is disconnected
+	HostTimeout time.Duration // Idle time before a host is disconnected
+	Warning     time.Duration // How long before disconnect to send inactivity_warning (0 = no warning)
+}
+
+// DefaultInactivityConfig returns the inactivity settings used when none are configured
+func DefaultInactivityConfig() InactivityConfig {
+	return InactivityConfig{
+		Timeout:     30 * time.Minute,
+		HostTimeout: 30 * time.Minute,
+		Warning:     5 * time.Minute,
+	}
+}
+
 // ClientMessage wraps a message with its client
 type ClientMessage struct {
-	client  *Client
-	message *Message
+	client   *Client
+	message  *Message
+	received time.Time // When the read pump read it, for measuring processing delay
 }
 
 // Hub maintains the set of active clients and broadcasts messages
@@ -18,23 +39,92 @@ type Hub struct {
 	// Registered clients (sessionID -> map of clients)
 	clients map[string]map[*Client]bool
 
-	// Mutex to protect clients map
+	// Mutex to protect clients and outboxes maps
 	clientsMu sync.RWMutex
 
-	// Inbound messages from clients
+	// Per-session delivery locks so every client in a session sees
+	// messages in the same order, whichever goroutine sends them
+	outboxes map[string]*sync.Mutex
+
+	// Last ID given to a critical message for clients to acknowledge (see ack.go)
+	ackIDs atomic.Uint64
+
+	// Recent broadcasts per session, replayed to clients that rejoin (see replay.go)
+	history   map[string]*replayBuffer
+	historyMu sync.Mutex
+
+	// Inbound messages from clients not in a session, or reaching beyond it
 	process chan *ClientMessage
 
+	// Per-session goroutines handling each session's messages (see actors.go)
+	actors   map[string]*sessionActor
+	actorsMu sync.Mutex
+
+	// Held for reading by actors while they run a task, and for writing by
+	// hub goroutine work that may touch any session
+	world sync.RWMutex
+
+	// Every open connection, registered with a session or not
+	// (only touched on the hub goroutine)
+	connections map[*Client]bool
+
+	// New connections to track
+	connect chan *Client
+
 	// Register requests from clients
 	register chan *Client
 
 	// Unregister requests from clients
 	unregister chan *Client
 
+	// Deferred work to run on the hub goroutine
+	tasks chan func()
+
 	// Message handler function
 	messageHandler func(*Client, *Message)
 
+	// Connect handler function, called once a client has joined a session
+	connectHandler func(*Client)
+
 	// Disconnect handler function
 	disconnectHandler func(*Client)
+
+	// Attaches admin observers to a session by code (called on the hub goroutine)
+	observerHandler func(client *Client, sessionCode, remoteAddr string)
+
+	// Translates messages for clients on older protocol versions
+	protocol *Protocol
+
+	// Injects faults into outbound frames for soak testing (nil = off)
+	chaos *chaos
+
+	// Captures inbound messages that couldn't be processed (nil = discard)
+	deadLetters *DeadLetters
+
+	// Inactivity timeouts and warning lead time
+	inactivity InactivityConfig
+
+	// Reports whether a client is its session's host (called on the hub goroutine)
+	hostChecker func(*Client) bool
+
+	// What to do when a client's send buffer is full
+	dropPolicy DropPolicy
+
+	// Cumulative send-queue counters
+	counters hubCounters
+
+	// This server instance's ID for load-balancer affinity (empty = disabled)
+	instanceID string
+
+	// Limits on upgrades, inbound messages and session creation
+	rateLimits RateLimits
+
+	// Open connections per source IP, for RateLimits.Connections
+	ipConnections   map[string]int
+	ipConnectionsMu sync.Mutex
+
+	// Relays broadcasts to clients on other replicas (nil = this replica only)
+	backplane *Backplane
 }
 
 // NewHub creates a new Hub
@@ -42,108 +132,279 @@ func NewHub(messageHandler func(*Client, *Message)) *Hub {
 	return &Hub{
 		clients:        make(map[string]map[*Client]bool),
 		process:        make(chan *ClientMessage, 256),
+		outboxes:       make(map[string]*sync.Mutex),
+		history:        make(map[string]*replayBuffer),
+		actors:         make(map[string]*sessionActor),
+		ipConnections:  make(map[string]int),
+		connections:    make(map[*Client]bool),
+		connect:        make(chan *Client),
 		register:       make(chan *Client),
 		unregister:     make(chan *Client),
+		tasks:          make(chan func(), 64),
 		messageHandler: messageHandler,
+		inactivity:     DefaultInactivityConfig(),
+		dropPolicy:     DropPolicyDisconnect,
+		protocol:       defaultProtocol(),
 	}
 }
 
 // Run starts the hub's main loop
 func (h *Hub) Run() {
+	sweep := time.NewTicker(inactivityCheckInterval)
+	defer sweep.Stop()
+	acks := time.NewTicker(ackCheckInterval)
+	defer acks.Stop()
+
 	for {
 		select {
+		case client := <-h.connect:
+			h.connections[client] = true
+
 		case client := <-h.register:
-			h.clientsMu.Lock()
-			sessionClients, exists := h.clients[client.sessionID]
-			if !exists {
-				sessionClients = make(map[*Client]bool)
-				h.clients[client.sessionID] = sessionClients
-			}
-			sessionClients[client] = true
-			h.clientsMu.Unlock()
-			log.Printf("Client registered: userId=%s session=%s", client.userID, client.sessionID)
+			h.exclusive(func() { h.registerClient(client) })
 
 		case client := <-h.unregister:
-			h.clientsMu.Lock()
-			if sessionClients, ok := h.clients[client.sessionID]; ok {
-				if _, ok := sessionClients[client]; ok {
-					delete(sessionClients, client)
-					client.closeSendChannel()
-					log.Printf("Client unregistered: userId=%s session=%s", client.userID, client.sessionID)
-
-					// Call disconnect handler if registered
-					if h.disconnectHandler != nil {
-						h.disconnectHandler(client)
-					}
-
-					// Remove session if no clients left
-					if len(sessionClients) == 0 {
-						delete(h.clients, client.sessionID)
-					}
-				}
-			}
-			h.clientsMu.Unlock()
+			h.exclusive(func() { h.unregisterClient(client) })
 
 		case clientMsg := <-h.process:
-			// Handle message with the registered handler
-			if h.messageHandler != nil {
-				h.messageHandler(clientMsg.client, clientMsg.message)
+			h.exclusive(func() { h.handle(clientMsg) })
+
+		case task := <-h.tasks:
+			h.exclusive(task)
+
+		case now := <-acks.C:
+			h.checkAcks(now)
+
+		case <-sweep.C:
+			h.exclusive(func() {
+				h.sweepInactive()
+				h.sendDiagnostics()
+			})
+			h.pruneHistory(time.Now())
+			h.sendLatencyProbes()
+		}
+	}
+}
+
+// registerClient adds a client to its session
+// Runs on the hub goroutine
+func (h *Hub) registerClient(client *Client) {
+	// Hold the outbox so no broadcast falls between joining and replaying
+	outbox := h.sessionOutbox(client.sessionID)
+	outbox.Lock()
+	h.clientsMu.Lock()
+	sessionClients, exists := h.clients[client.sessionID]
+	if !exists {
+		sessionClients = make(map[*Client]bool)
+		h.clients[client.sessionID] = sessionClients
+	}
+	sessionClients[client] = true
+	h.clientsMu.Unlock()
+	if client.resumeSeq > 0 {
+		h.replay(client)
+	}
+	outbox.Unlock()
+	client.logger().Info("Client registered")
+
+	if h.connectHandler != nil {
+		h.connectHandler(client)
+	}
+}
+
+// unregisterClient forgets a closed connection and removes it from its session
+// Runs on the hub goroutine
+func (h *Hub) unregisterClient(client *Client) {
+	delete(h.connections, client)
+
+	// Stop the write pump even if the client never joined a session
+	client.closeSendChannel()
+
+	h.clientsMu.Lock()
+	removed := false
+	if sessionClients, ok := h.clients[client.sessionID]; ok {
+		if _, ok := sessionClients[client]; ok {
+			delete(sessionClients, client)
+			removed = true
+
+			// Remove session if no clients left
+			if len(sessionClients) == 0 {
+				delete(h.clients, client.sessionID)
+				delete(h.outboxes, client.sessionID)
 			}
 		}
 	}
+	h.clientsMu.Unlock()
+
+	if removed {
+		client.logger().Info("Client unregistered")
+
+		// Call disconnect handler if registered (after releasing the
+		// lock, since it broadcasts to the remaining clients)
+		if h.disconnectHandler != nil {
+			h.disconnectHandler(client)
+		}
+	}
+}
+
+// sweepInactive checks every open connection for inactivity
+// Runs on the hub goroutine
+func (h *Hub) sweepInactive() {
+	for client := range h.connections {
+		h.checkInactivity(client)
+	}
+}
+
+// Schedule queues a function to run on the hub goroutine, serialised with message handling
+// in every session; work on a single session belongs on its actor (see ScheduleSession)
+func (h *Hub) Schedule(task func()) {
+	h.tasks <- task
+}
+
+// Alive reports whether the hub loop picks up and runs a task within timeout
+func (h *Hub) Alive(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	done := make(chan struct{})
+	select {
+	case h.tasks <- func() { close(done) }:
+	case <-timer.C:
+		return false
+	}
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
 }
 
-// BroadcastToSession sends a message to all clients in a session
+// BroadcastToSession sends a message to all clients in a session, on every replica
 func (h *Hub) BroadcastToSession(sessionID string, message *Message) {
-	h.clientsMu.RLock()
-	sessionClients, ok := h.clients[sessionID]
-	if !ok {
-		h.clientsMu.RUnlock()
+	h.broadcast(sessionID, "", message)
+	h.relay(relayed{SessionID: sessionID, Message: message})
+}
+
+// BroadcastToSessionExcept sends a message to all clients except one, on every replica
+func (h *Hub) BroadcastToSessionExcept(sessionID string, exceptUserID string, message *Message) {
+	h.broadcast(sessionID, exceptUserID, message)
+	h.relay(relayed{SessionID: sessionID, Except: exceptUserID, Message: message})
+}
+
+// BroadcastRaw sends an already serialized message to all clients in a session, on every replica
+// data is shared between recipients and must not be modified afterwards
+// It goes out as-is, so it must be a message every supported protocol version understands
+func (h *Hub) BroadcastRaw(sessionID string, data []byte) {
+	h.deliver(sessionID, h.sessionClients(sessionID, ""), data)
+	h.relay(relayed{SessionID: sessionID, Raw: data})
+}
+
+// broadcast numbers a message, serializes it once and fans it out to a session's clients
+// It's remembered for replay even if nobody is connected to receive it
+func (h *Hub) broadcast(sessionID string, exceptUserID string, message *Message) {
+	// Numbering, choosing recipients and queueing all happen under the outbox,
+	// so every client sees broadcasts in numbered order
+	outbox := h.sessionOutbox(sessionID)
+	outbox.Lock()
+	defer outbox.Unlock()
+
+	message = h.remember(sessionID, exceptUserID, h.stampAck(message))
+	clients := h.sessionClients(sessionID, exceptUserID)
+
+	// Encode once per rendering variant in use, usually just one
+	byVariant := make(map[variant][]byte)
+	for _, client := range clients {
+		v := client.variant()
+		data, done := byVariant[v]
+		if !done {
+			var err error
+			data, err = h.encodeFor(message, v)
+			if err != nil {
+				slog.Error("Failed to encode broadcast", "messageType", message.Type, "sessionID", sessionID, "err", err)
+				return
+			}
+			byVariant[v] = data
+		}
+		if data != nil {
+			client.SendRaw(data)
+			client.expectAck(message, data)
+		}
+	}
+}
+
+// deliver queues a message for clients of a session
+// Deliveries to the same session are serialised, so concurrent broadcasts
+// can't reach different clients in different orders
+func (h *Hub) deliver(sessionID string, clients []*Client, data []byte) {
+	if len(clients) == 0 {
 		return
 	}
 
-	// Copy client pointers to avoid holding lock during send
-	clients := make([]*Client, 0, len(sessionClients))
-	for client := range sessionClients {
-		clients = append(clients, client)
+	outbox := h.sessionOutbox(sessionID)
+	outbox.Lock()
+	defer outbox.Unlock()
+
+	for _, client := range clients {
+		client.SendRaw(data)
 	}
+}
+
+// sessionOutbox returns the delivery lock for a session, creating it if needed
+func (h *Hub) sessionOutbox(sessionID string) *sync.Mutex {
+	h.clientsMu.RLock()
+	outbox, exists := h.outboxes[sessionID]
 	h.clientsMu.RUnlock()
+	if exists {
+		return outbox
+	}
 
-	for _, client := range clients {
-		client.SendMessage(message)
+	h.clientsMu.Lock()
+	defer h.clientsMu.Unlock()
+
+	if outbox, exists = h.outboxes[sessionID]; !exists {
+		outbox = &sync.Mutex{}
+		h.outboxes[sessionID] = outbox
 	}
+	return outbox
 }
 
-// BroadcastToSessionExcept sends a message to all clients except one
-func (h *Hub) BroadcastToSessionExcept(sessionID string, exceptUserID string, message *Message) {
+// sessionClients returns a snapshot of a session's clients, optionally excluding one user
+// Copies client pointers to avoid holding the lock during send
+func (h *Hub) sessionClients(sessionID string, exceptUserID string) []*Client {
 	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+
 	sessionClients, ok := h.clients[sessionID]
 	if !ok {
-		h.clientsMu.RUnlock()
-		return
+		return nil
 	}
 
-	// Copy client pointers to avoid holding lock during send
 	clients := make([]*Client, 0, len(sessionClients))
 	for client := range sessionClients {
-		if client.userID != exceptUserID {
+		if exceptUserID == "" || client.userID != exceptUserID {
 			clients = append(clients, client)
 		}
 	}
-	h.clientsMu.RUnlock()
-
-	for _, client := range clients {
-		client.SendMessage(message)
-	}
+	return clients
 }
 
 // SendToUser sends a message to a specific user in a session
+// Users not connected here are reached through the backplane, if there is one
 func (h *Hub) SendToUser(sessionID string, userID string, message *Message) {
+	if !h.sendToLocalUser(sessionID, userID, message) {
+		h.relay(relayed{SessionID: sessionID, UserID: userID, Message: message})
+	}
+}
+
+// sendToLocalUser sends a message to a user's client on this replica
+// Reports whether the user is connected here
+func (h *Hub) sendToLocalUser(sessionID string, userID string, message *Message) bool {
 	h.clientsMu.RLock()
 	sessionClients, ok := h.clients[sessionID]
 	if !ok {
 		h.clientsMu.RUnlock()
-		return
+		return false
 	}
 
 	var targetClient *Client
@@ -155,21 +416,107 @@ func (h *Hub) SendToUser(sessionID string, userID string, message *Message) {
 	}
 	h.clientsMu.RUnlock()
 
-	if targetClient != nil {
-		targetClient.SendMessage(message)
+	if targetClient == nil {
+		return false
+	}
+	message = h.stampAck(message)
+
+	data, err := h.encodeFor(message, targetClient.variant())
+	if err != nil {
+		slog.Error("Failed to encode message", "messageType", message.Type, "sessionID", sessionID, "err", err)
+		return true
+	}
+	if data != nil {
+		h.deliver(sessionID, []*Client{targetClient}, data)
+		targetClient.expectAck(message, data)
+	}
+	return true
+}
+
+// MoveUser re-registers a user's client from one session to another
+// Returns the moved client, or nil if the user has no client in the source session
+func (h *Hub) MoveUser(fromSessionID, userID, toSessionID string) *Client {
+	h.clientsMu.Lock()
+	defer h.clientsMu.Unlock()
+
+	sessionClients, ok := h.clients[fromSessionID]
+	if !ok {
+		return nil
+	}
+
+	var moved *Client
+	for client := range sessionClients {
+		if client.userID == userID {
+			moved = client
+			break
+		}
+	}
+	if moved == nil {
+		return nil
+	}
+
+	delete(sessionClients, moved)
+	if len(sessionClients) == 0 {
+		delete(h.clients, fromSessionID)
+		delete(h.outboxes, fromSessionID)
+	}
+
+	targetClients, exists := h.clients[toSessionID]
+	if !exists {
+		targetClients = make(map[*Client]bool)
+		h.clients[toSessionID] = targetClients
+	}
+	targetClients[moved] = true
+	moved.sessionID = toSessionID
+
+	moved.logger().Info("Client moved", "fromSessionID", fromSessionID)
+	return moved
+}
+
+// IsUserConnected reports whether a user has a connected client in a session
+func (h *Hub) IsUserConnected(sessionID string, userID string) bool {
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+
+	for client := range h.clients[sessionID] {
+		if client.userID == userID {
+			return true
+		}
 	}
+	return false
+}
+
+// ConnectedUserIDs returns the sorted IDs of participants with a connected client
+// Admin observers aren't included
+func (h *Hub) ConnectedUserIDs(sessionID string) []string {
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+
+	seen := make(map[string]bool)
+	ids := []string{}
+	for client := range h.clients[sessionID] {
+		if !client.observer && !seen[client.userID] {
+			seen[client.userID] = true
+			ids = append(ids, client.userID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
 }
 
 // GetSessionClientCount returns the number of connected clients for a session
+// Admin observers aren't counted
 func (h *Hub) GetSessionClientCount(sessionID string) int {
 	h.clientsMu.RLock()
 	defer h.clientsMu.RUnlock()
 
-	sessionClients, ok := h.clients[sessionID]
-	if !ok {
-		return 0
+	count := 0
+	for client := range h.clients[sessionID] {
+		if !client.observer {
+			count++
+		}
 	}
-	return len(sessionClients)
+	return count
 }
 
 // SetMessageHandler sets the message handler function
@@ -177,7 +524,95 @@ func (h *Hub) SetMessageHandler(handler func(*Client, *Message)) {
 	h.messageHandler = handler
 }
 
+// SetConnectHandler sets the function called when a client is registered with a session
+func (h *Hub) SetConnectHandler(handler func(*Client)) {
+	h.connectHandler = handler
+}
+
 // SetDisconnectHandler sets the disconnect handler function
 func (h *Hub) SetDisconnectHandler(handler func(*Client)) {
 	h.disconnectHandler = handler
 }
+
+// SetObserverHandler sets the function that attaches admin observers to sessions
+func (h *Hub) SetObserverHandler(handler func(client *Client, sessionCode, remoteAddr string)) {
+	h.observerHandler = handler
+}
+
+// sendLatencyProbes sends an application-level ping to every client in a session
+// Clients echo sentAt back in a pong so their round-trip time can be measured
+func (h *Hub) sendLatencyProbes() {
+	probe, err := encodeMessage(&Message{
+		Type: "ping",
+		Data: map[string]interface{}{
+			"sentAt": time.Now().UnixMilli(),
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to encode latency probe", "err", err)
+		return
+	}
+
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+
+	for _, sessionClients := range h.clients {
+		for client := range sessionClients {
+			if !client.observer {
+				client.SendRaw(probe)
+			}
+		}
+	}
+}
+
+// SetInactivity replaces the inactivity timeouts; call before Run
+func (h *Hub) SetInactivity(config InactivityConfig) error {
+	if config.Timeout <= 0 || config.HostTimeout <= 0 {
+		return errors.New("inactivity timeouts must be positive")
+	}
+	if config.Warning < 0 || config.Warning >= config.Timeout || config.Warning >= config.HostTimeout {
+		return errors.New("inactivity warning must be shorter than the timeouts")
+	}
+
+	h.inactivity = config
+	return nil
+}
+
+// SetHostChecker sets the function used to apply the host inactivity timeout
+func (h *Hub) SetHostChecker(checker func(*Client) bool) {
+	h.hostChecker = checker
+}
+
+// checkInactivity warns or disconnects a client that has been idle too long
+// Runs on the hub goroutine
+func (h *Hub) checkInactivity(client *Client) {
+	timeout := h.inactivity.Timeout
+	if h.hostChecker != nil && h.hostChecker(client) {
+		timeout = h.inactivity.HostTimeout
+	}
+
+	idle := client.idleFor()
+	if idle > timeout {
+		// Stop sweeping it; readPump unregisters once the connection closes
+		delete(h.connections, client)
+		client.disconnectInactive(timeout)
+		return
+	}
+
+	// Activity since the last warning re-arms it
+	if h.inactivity.Warning == 0 || idle <= timeout-h.inactivity.Warning {
+		client.inactivityWarned = false
+		return
+	}
+
+	if !client.inactivityWarned {
+		client.inactivityWarned = true
+		client.SendMessage(&Message{
+			Type: "inactivity_warning",
+			Data: map[string]interface{}{
+				"secondsRemaining": int((timeout - idle).Seconds()),
+			},
+		})
+		client.logger().Info("Inactivity warning sent")
+	}
+}
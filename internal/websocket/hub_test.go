@@ -0,0 +1,275 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// newTestClient creates a client with a send buffer but no connection
+func newTestClient(hub *Hub, sessionID, userID string) *Client {
+	client := &Client{
+		send:      make(chan []byte, 256),
+		hub:       hub,
+		sessionID: sessionID,
+		userID:    userID,
+	}
+
+	hub.clientsMu.Lock()
+	if hub.clients[sessionID] == nil {
+		hub.clients[sessionID] = make(map[*Client]bool)
+	}
+	hub.clients[sessionID][client] = true
+	hub.clientsMu.Unlock()
+
+	return client
+}
+
+func TestEncodeMessageMatchesMarshal(t *testing.T) {
+	msg := &Message{
+		Type: "note_drawn",
+		Data: map[string]interface{}{
+			"content": "<b>Thanks</b> & well done",
+			"count":   3,
+		},
+	}
+
+	expected, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	// Encode twice so the second call reuses a pooled buffer
+	for i := 0; i < 2; i++ {
+		data, err := encodeMessage(msg)
+		if err != nil {
+			t.Fatalf("Failed to encode: %v", err)
+		}
+		if string(data) != string(expected) {
+			t.Errorf("Expected %s, got %s", expected, data)
+		}
+	}
+}
+
+func TestBroadcastSharesPayload(t *testing.T) {
+	hub := NewHub(nil)
+	alice := newTestClient(hub, "session-1", "alice")
+	bob := newTestClient(hub, "session-1", "bob")
+	other := newTestClient(hub, "session-2", "carol")
+
+	hub.BroadcastToSession("session-1", &Message{Type: "turn_changed"})
+
+	fromAlice := <-alice.send
+	fromBob := <-bob.send
+	if &fromAlice[0] != &fromBob[0] {
+		t.Error("Expected recipients to share one serialized payload")
+	}
+	if len(other.send) != 0 {
+		t.Error("Expected clients in other sessions not to receive the broadcast")
+	}
+
+	hub.BroadcastToSessionExcept("session-1", "alice", &Message{Type: "participant_joined"})
+	if len(alice.send) != 0 {
+		t.Error("Expected excluded user not to receive the broadcast")
+	}
+	if len(bob.send) != 1 {
+		t.Errorf("Expected bob to receive 1 message, got %d", len(bob.send))
+	}
+
+	hub.BroadcastRaw("session-2", []byte(`{"type":"ping"}`))
+	if got := string(<-other.send); got != `{"type":"ping"}` {
+		t.Errorf("Expected raw payload, got %s", got)
+	}
+}
+
+func TestCheckInactivityWarning(t *testing.T) {
+	hub := NewHub(nil)
+	if err := hub.SetInactivity(InactivityConfig{
+		Timeout:     10 * time.Minute,
+		HostTimeout: time.Hour,
+		Warning:     2 * time.Minute,
+	}); err != nil {
+		t.Fatalf("Failed to set inactivity config: %v", err)
+	}
+	hub.SetHostChecker(func(c *Client) bool { return c.userID == "host" })
+
+	participant := newTestClient(hub, "session-1", "participant")
+	host := newTestClient(hub, "session-1", "host")
+	idleSince := time.Now().Add(-9 * time.Minute).UnixNano()
+	participant.lastActivity.Store(idleSince)
+	host.lastActivity.Store(idleSince)
+
+	hub.checkInactivity(participant)
+	hub.checkInactivity(participant)
+	if len(participant.send) != 1 {
+		t.Fatalf("Expected exactly 1 warning, got %d messages", len(participant.send))
+	}
+	var warning Message
+	json.Unmarshal(<-participant.send, &warning)
+	if warning.Type != "inactivity_warning" {
+		t.Errorf("Expected inactivity_warning, got %s", warning.Type)
+	}
+	if remaining, _ := warning.Data["secondsRemaining"].(float64); remaining <= 0 || remaining > 60 {
+		t.Errorf("Expected about 60 seconds remaining, got %v", remaining)
+	}
+
+	// Hosts get the longer timeout and aren't warned yet
+	hub.checkInactivity(host)
+	if len(host.send) != 0 {
+		t.Error("Expected no warning for host within host timeout")
+	}
+
+	// Activity re-arms the warning
+	participant.touch()
+	hub.checkInactivity(participant)
+	if participant.inactivityWarned {
+		t.Error("Expected activity to reset the warning")
+	}
+}
+
+func TestSetInactivityValidation(t *testing.T) {
+	hub := NewHub(nil)
+
+	invalid := []InactivityConfig{
+		{Timeout: 0, HostTimeout: time.Minute},
+		{Timeout: time.Minute, HostTimeout: time.Minute, Warning: time.Minute},
+		{Timeout: time.Minute, HostTimeout: time.Minute, Warning: -time.Second},
+	}
+	for _, config := range invalid {
+		if err := hub.SetInactivity(config); err == nil {
+			t.Errorf("Expected error for config %+v", config)
+		}
+	}
+}
+
+func TestBroadcastOrderConsistentAcrossClients(t *testing.T) {
+	hub := NewHub(nil)
+	clients := make([]*Client, 5)
+	for i := range clients {
+		clients[i] = newTestClient(hub, "session-1", fmt.Sprintf("participant-%d", i))
+	}
+
+	// Concurrent broadcasters must not reach clients in different orders
+	const senders, perSender = 4, 40
+	var wg sync.WaitGroup
+	for s := 0; s < senders; s++ {
+		wg.Add(1)
+		go func(s int) {
+			defer wg.Done()
+			for i := 0; i < perSender; i++ {
+				hub.BroadcastToSession("session-1", &Message{Type: fmt.Sprintf("msg-%d-%d", s, i)})
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	var expected []string
+	for i, client := range clients {
+		var got []string
+		for len(client.send) > 0 {
+			var msg Message
+			json.Unmarshal(<-client.send, &msg)
+			got = append(got, msg.Type)
+		}
+		if len(got) != senders*perSender {
+			t.Fatalf("Expected %d messages, got %d", senders*perSender, len(got))
+		}
+		if i == 0 {
+			expected = got
+			continue
+		}
+		if strings.Join(got, ",") != strings.Join(expected, ",") {
+			t.Fatalf("Client %d received messages in a different order", i)
+		}
+	}
+}
+
+func TestDisconnectHandlerCanBroadcast(t *testing.T) {
+	hub := NewHub(nil)
+	leaving := newTestClient(hub, "session-1", "leaving")
+	staying := newTestClient(hub, "session-1", "staying")
+	hub.SetDisconnectHandler(func(c *Client) {
+		hub.BroadcastToSession(c.sessionID, &Message{Type: "participant_left"})
+	})
+	go hub.Run()
+
+	hub.unregister <- leaving
+
+	select {
+	case data := <-staying.send:
+		var msg Message
+		json.Unmarshal(data, &msg)
+		if msg.Type != "participant_left" {
+			t.Errorf("Expected participant_left, got %s", msg.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Disconnect handler broadcast never arrived (hub deadlocked)")
+	}
+}
+
+func TestAlive(t *testing.T) {
+	hub := NewHub(nil)
+
+	if hub.Alive(20 * time.Millisecond) {
+		t.Error("Expected hub that isn't running to be reported dead")
+	}
+
+	go hub.Run()
+	if !hub.Alive(time.Second) {
+		t.Error("Expected running hub to be reported alive")
+	}
+
+	// A hung handler stalls the loop
+	release := make(chan struct{})
+	defer close(release)
+	hub.Schedule(func() { <-release })
+	if hub.Alive(20 * time.Millisecond) {
+		t.Error("Expected blocked hub to be reported dead")
+	}
+}
+
+func TestDrawNoteRecordsAttendance(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, bob.ID, "Note 1")
+	sess.TransitionToReading()
+
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	newTestClient(hub, sess.ID, bob.ID)
+	observer := newTestClient(hub, sess.ID, "observer-1")
+	observer.observer = true
+
+	note := mh.drawNote(sess, sess.HostID)
+	if note == nil {
+		t.Fatal("Expected a note to be drawn")
+	}
+	want := []string{sess.HostID, bob.ID}
+	sort.Strings(want)
+	if got := note.Attendance; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Expected host and Bob in attendance, got %v", got)
+	}
+
+	nextMessage(t, host) // note_drawn
+	sess.MarkNoteAsRead(note.ID)
+	mh.broadcastSessionComplete(sess)
+	reply := nextMessage(t, host)
+	notes, _ := reply.Data["notes"].([]interface{})
+	if len(notes) != 1 {
+		t.Fatalf("Expected 1 note in the archive, got %+v", reply)
+	}
+	if attendance, _ := notes[0].(map[string]interface{})["attendance"].([]interface{}); len(attendance) != 2 {
+		t.Errorf("Expected the host's archive to include attendance, got %+v", notes[0])
+	}
+}
@@ -0,0 +1,157 @@
+// ABOUTME: Deduplicates client messages carrying a msgId, so a resent message isn't handled twice
+// ABOUTME: Replies sent while handling a message echo its msgId, and a retry gets the same replies again
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// handledRetention is how long a msgId is remembered after its message was handled
+	handledRetention = 2 * time.Minute
+
+	// maxHandledMessages bounds how many msgIds are remembered across all clients
+	maxHandledMessages = 10000
+
+	// maxMsgIDLength bounds the msgIds clients may send
+	maxMsgIDLength = 64
+)
+
+// pendingReply is a message being or already handled, and what its sender was sent in reply
+// Work deferred until after moderation adds its replies later, so a retry gets them too
+type pendingReply struct {
+	msgID   string
+	replies []*Message
+	at      time.Time
+	mu      sync.Mutex
+}
+
+// sent returns the replies so far
+func (r *pendingReply) sent() []*Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Message(nil), r.replies...)
+}
+
+// handledMessages remembers recently handled msgIds, oldest first
+// Shared by every session's actor, so it has its own lock
+type handledMessages struct {
+	byKey map[string]*pendingReply
+	order []string
+	mu    sync.Mutex
+}
+
+func newHandledMessages() *handledMessages {
+	return &handledMessages{byKey: make(map[string]*pendingReply)}
+}
+
+// handledKeys identifies a msgId by its sender: the connection, and once joined the
+// participant too, so a retry on a new connection after rejoining is still recognised
+// Nobody can have another client's replies sent to them by guessing its msgIds
+func handledKeys(client *Client, msgID string) []string {
+	keys := []string{"conn:" + client.connID + ":" + msgID}
+	if client.userID != "" {
+		keys = append(keys, "user:"+client.sessionID+":"+client.userID+":"+msgID)
+	}
+	return keys
+}
+
+// lookup returns the record of a message handled for any of keys
+func (h *handledMessages) lookup(keys []string, now time.Time) *pendingReply {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.expire(now)
+	for _, key := range keys {
+		if handled, exists := h.byKey[key]; exists {
+			return handled
+		}
+	}
+	return nil
+}
+
+// store records a handled message under every key
+func (h *handledMessages) store(keys []string, handled *pendingReply) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, key := range keys {
+		if _, exists := h.byKey[key]; exists {
+			continue
+		}
+		h.byKey[key] = handled
+		h.order = append(h.order, key)
+	}
+	for len(h.order) > maxHandledMessages {
+		delete(h.byKey, h.order[0])
+		h.order = h.order[1:]
+	}
+}
+
+// expire forgets messages handled more than handledRetention ago
+// Called with the lock held
+func (h *handledMessages) expire(now time.Time) {
+	for len(h.order) > 0 {
+		handled, exists := h.byKey[h.order[0]]
+		if exists && now.Sub(handled.at) < handledRetention {
+			return
+		}
+		delete(h.byKey, h.order[0])
+		h.order = h.order[1:]
+	}
+}
+
+// handleOnce handles a message carrying a msgId unless it already has been, in which case
+// the replies it got the first time are sent again
+func (mh *MessageHandler) handleOnce(client *Client, msg *Message, handle func()) {
+	if len(msg.MsgID) > maxMsgIDLength {
+		mh.sendErrorCode(client, "invalid_message", "msgId is too long")
+		return
+	}
+
+	now := time.Now()
+	if handled := mh.handled.lookup(handledKeys(client, msg.MsgID), now); handled != nil {
+		client.logger().Info("Duplicate message, resending replies", "messageType", msg.Type, "msgId", msg.MsgID)
+		for _, reply := range handled.sent() {
+			client.SendMessage(reply)
+		}
+		return
+	}
+
+	before := handledKeys(client, msg.MsgID)
+	reply := &pendingReply{msgID: msg.MsgID, at: now}
+	mh.replying(client, reply, handle)
+	mh.handled.store(before, reply)
+}
+
+// replying runs fn with the client's replies stamped with reply's msgId and recorded for
+// retries, then records the message under the client's keys, since joining gives the client
+// an identity a retry can be matched by. Work deferred past moderation runs through here
+// again with the same reply (nil = not replying to a msgId)
+func (mh *MessageHandler) replying(client *Client, reply *pendingReply, fn func()) {
+	if reply == nil {
+		fn()
+		return
+	}
+
+	client.reply.Store(reply)
+	fn()
+	client.reply.CompareAndSwap(reply, nil)
+	mh.handled.store(handledKeys(client, reply.msgID), reply)
+}
+
+// stampReply marks msg as a reply to the message the client is being handled for, if any
+func (c *Client) stampReply(msg *Message) *Message {
+	reply := c.reply.Load()
+	if reply == nil || msg.MsgID != "" {
+		return msg
+	}
+
+	stamped := *msg
+	stamped.MsgID = reply.msgID
+	reply.mu.Lock()
+	reply.replies = append(reply.replies, &stamped)
+	reply.mu.Unlock()
+	return &stamped
+}
@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/moderation"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// nextReply returns the next message sent to client that echoes a msgId, skipping broadcasts
+func nextReply(t *testing.T, client *Client) Message {
+	t.Helper()
+	for {
+		if msg := nextMessage(t, client); msg.MsgID != "" {
+			return msg
+		}
+	}
+}
+
+func TestResentMessageIsHandledOnce(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+	aliceClient.connID = "alice-conn"
+	bobClient := newTestClient(hub, sess.ID, bob.ID)
+	bobClient.connID = "bob-conn"
+	sess.TransitionToWriting()
+
+	submit := func(client *Client, recipientID string) Message {
+		mh.HandleMessage(client, &Message{Type: "submit_notes", MsgID: "msg-1", Data: map[string]interface{}{
+			"notes": []interface{}{map[string]interface{}{"recipientId": recipientID, "content": "Thanks for pairing on the migration"}},
+		}})
+		return nextReply(t, client)
+	}
+
+	first := submit(aliceClient, bob.ID)
+	if first.Type != "notes_submitted" || first.MsgID != "msg-1" {
+		t.Fatalf("Expected notes_submitted echoing the msgId, got %s msgId=%q", first.Type, first.MsgID)
+	}
+
+	// The retry gets the same reply instead of "note already written"
+	retry := submit(aliceClient, bob.ID)
+	if retry.Type != "notes_submitted" || retry.MsgID != "msg-1" {
+		t.Errorf("Expected the original reply resent, got %s %v", retry.Type, retry.Data)
+	}
+	if len(sess.Notes) != 1 {
+		t.Errorf("Expected the note stored once, got %d", len(sess.Notes))
+	}
+
+	// msgIds are per sender, so another participant reusing one is handled normally
+	if reply := submit(bobClient, alice.ID); reply.Type != "notes_submitted" || len(sess.Notes) != 2 {
+		t.Errorf("Expected Bob's note handled, got %s with %d notes", reply.Type, len(sess.Notes))
+	}
+}
+
+func TestMsgIDLengthLimit(t *testing.T) {
+	hub := NewHub(nil)
+	mh := NewMessageHandler(hub, session.NewManager())
+	client := newTestClient(hub, "", "")
+
+	mh.HandleMessage(client, &Message{Type: "ping", MsgID: strings.Repeat("x", maxMsgIDLength+1)})
+	if reply := nextMessage(t, client); reply.Type != "error" || reply.Data["code"] != "invalid_message" {
+		t.Errorf("Expected an overlong msgId refused, got %s %v", reply.Type, reply.Data)
+	}
+}
+
+func TestResentMessageIsHandledOnceWithModeration(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	mh.SetModeration(moderation.NewBlocklist([]string{"darn"}))
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	newTestClient(hub, sess.ID, sess.HostID)
+
+	// Moderated work finishes after the handler returns; its replies still echo the msgId
+	joiner := &Client{send: make(chan []byte, 16), hub: hub, connID: "joiner-conn"}
+	join := func(msgID, name string) Message {
+		hub.Schedule(func() {
+			mh.HandleMessage(joiner, &Message{Type: "join_session", MsgID: msgID, Data: map[string]interface{}{"sessionCode": sess.Code, "userName": name}})
+		})
+		return nextReply(t, joiner)
+	}
+
+	if rejected := join("join-1", "Darn Dan"); rejected.Data["code"] != "content_rejected" || rejected.MsgID != "join-1" {
+		t.Errorf("Expected the rejection to echo the msgId, got %s %v msgId=%q", rejected.Type, rejected.Data, rejected.MsgID)
+	}
+
+	first := join("join-2", "Dan")
+	if first.Type != "session_joined" || first.MsgID != "join-2" {
+		t.Fatalf("Expected session_joined echoing the msgId, got %s msgId=%q", first.Type, first.MsgID)
+	}
+	retry := join("join-2", "Dan")
+	if retry.Type != "session_joined" || retry.Data["userId"] != first.Data["userId"] {
+		t.Errorf("Expected the original reply resent, got %s %v", retry.Type, retry.Data)
+	}
+	if len(sess.GetParticipantList()) != 2 {
+		t.Errorf("Expected Dan to join once, got %d participants", len(sess.GetParticipantList()))
+	}
+}
@@ -0,0 +1,110 @@
+// ABOUTME: Host command to create signed invite links, and checking the invites people join with
+// ABOUTME: Invite-only sessions can't be joined with the code alone
+package websocket
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/invites"
+	"github.com/cassiascheffer/uplift/internal/moderation"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// ErrInviteRequired is returned when joining an invite-only session without an invite
+var ErrInviteRequired = errors.New("this session can only be joined with an invite link")
+
+// SetInvites sets the signer for invite links (nil = invites disabled)
+func (mh *MessageHandler) SetInvites(signer *invites.Signer) {
+	mh.invites = signer
+}
+
+// handleCreateInvite signs an invite link to the client's session (host only) once the
+// name and role it fills in pass moderation
+func (mh *MessageHandler) handleCreateInvite(client *Client, req *createInviteRequest) {
+	if mh.invites == nil {
+		mh.sendError(client, "invite links are not available")
+		return
+	}
+
+	if req.UserName != "" && !mh.cleaned(client, &req.UserName, validateUserName) {
+		return
+	}
+	if !mh.cleaned(client, &req.Role, validateRole) {
+		return
+	}
+
+	mh.moderated(client, client.sessionID, moderation.KindName, []string{req.UserName, req.Role}, func() {
+		mh.createInvite(client, req)
+	})
+}
+
+// createInvite signs an invite link to the client's session (host only)
+// userName and role fill in who uses it; expiresInHours sets how long it works
+func (mh *MessageHandler) createInvite(client *Client, req *createInviteRequest) {
+
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if client.userID != sess.HostID {
+		client.logger().Warn("Non-host tried to create an invite", "hostID", sess.HostID)
+		mh.sendError(client, "only host can create invite links")
+		return
+	}
+
+	invite := invites.Invite{SessionCode: sess.Code, SessionID: sess.ID, Name: req.UserName, Role: req.Role}
+
+	token, expiresAt, err := mh.invites.Issue(invite, time.Duration(req.ExpiresInHours*float64(time.Hour)))
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	data := map[string]interface{}{
+		"token":     token,
+		"url":       "/?" + url.Values{"invite": {token}}.Encode(),
+		"expiresAt": expiresAt,
+	}
+	if invite.Name != "" {
+		data["userName"] = invite.Name
+	}
+	if invite.Role != "" {
+		data["role"] = invite.Role
+	}
+	client.SendMessage(&Message{Type: "invite_created", Data: data})
+	client.logger().Info("Invite created", "sessionCode", sess.Code, "named", invite.Name != "", "role", invite.Role != "", "expiresAt", expiresAt)
+}
+
+// verifyInvite checks an invite token sent with join_session; a session code sent
+// alongside it must be the one the invite is for
+func (mh *MessageHandler) verifyInvite(token, sessionCode string) (invites.Invite, error) {
+	if mh.invites == nil {
+		return invites.Invite{}, invites.ErrInvalid
+	}
+
+	invite, err := mh.invites.Verify(token)
+	if err != nil {
+		return invites.Invite{}, err
+	}
+	if sessionCode != "" && !strings.EqualFold(strings.TrimSpace(sessionCode), invite.SessionCode) {
+		return invites.Invite{}, invites.ErrInvalid
+	}
+	return invite, nil
+}
+
+// checkInvite checks that an invite (nil if none was sent) lets someone join sess
+// An invite for an earlier session whose code has since been reused doesn't
+func checkInvite(sess *session.Session, invite *invites.Invite) error {
+	if invite != nil && invite.SessionID != sess.ID {
+		return invites.ErrInvalid
+	}
+	if invite == nil && sess.GetSettings().InviteOnly {
+		return ErrInviteRequired
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/invites"
+	"github.com/cassiascheffer/uplift/internal/moderation"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestJoinWithInvite(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	signer, _ := invites.NewSigner("test-secret")
+	mh.SetInvites(signer)
+
+	sess := manager.CreateSession("Host")
+	sess.SetSettings(session.SessionSettings{InviteOnly: true})
+	host := newTestClient(hub, sess.ID, sess.HostID)
+
+	// Only the host can create invites
+	alice, _ := sess.AddParticipant("Alice")
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+	mh.HandleMessage(aliceClient, &Message{Type: "create_invite", Data: map[string]interface{}{}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected a participant's invite to be refused, got %+v", reply)
+	}
+
+	mh.HandleMessage(host, &Message{Type: "create_invite", Data: map[string]interface{}{"userName": "Sam", "role": "Team lead", "expiresInHours": 2}})
+	created := nextMessage(t, host)
+	if created.Type != "invite_created" || created.Data["userName"] != "Sam" || created.Data["role"] != "Team lead" {
+		t.Fatalf("Expected invite_created naming Sam as team lead, got %+v", created)
+	}
+	token := created.Data["token"].(string)
+	if created.Data["url"] != "/?invite="+token {
+		t.Errorf("Expected a join URL carrying the token, got %v", created.Data["url"])
+	}
+
+	// The code alone doesn't get into an invite-only session
+	stranger := newTestClient(hub, "", "")
+	mh.HandleMessage(stranger, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": sess.Code, "userName": "Eve"}})
+	if data := nextMessage(t, stranger).Data; data["code"] != "invite_required" {
+		t.Errorf("Expected invite_required, got %+v", data)
+	}
+
+	// Nor does a forged or mismatched invite
+	for _, data := range []map[string]interface{}{
+		{"invite": token + "x"},
+		{"invite": token, "sessionCode": "OTHER2"},
+	} {
+		mh.HandleMessage(stranger, &Message{Type: "join_session", Data: data})
+		if reply := nextMessage(t, stranger).Data; reply["code"] != "invite_invalid" {
+			t.Errorf("Expected invite_invalid for %v, got %+v", data, reply)
+		}
+	}
+
+	// The invite alone names the session and the person joining
+	sam := newTestClient(hub, "", "")
+	mh.HandleMessage(sam, &Message{Type: "join_session", Data: map[string]interface{}{"invite": token}})
+	if joined := nextMessage(t, sam); joined.Type != "session_joined" || joined.Data["userName"] != "Sam" || joined.Data["role"] != "Team lead" {
+		t.Fatalf("Expected to join as Sam, team lead, got %+v", joined)
+	}
+	if sam.sessionID != sess.ID {
+		t.Errorf("Expected Sam in the invited session, got %q", sam.sessionID)
+	}
+	if participant := sess.Participants[sam.userID]; participant.Role != "Team lead" {
+		t.Errorf("Expected Sam's role to be filled in from the invite, got %q", participant.Role)
+	}
+
+	// A role sent with the join replaces the invite's
+	kim := newTestClient(hub, "", "")
+	mh.HandleMessage(kim, &Message{Type: "join_session", Data: map[string]interface{}{"invite": token, "userName": "Kim", "role": "Guest"}})
+	if joined := nextMessage(t, kim); joined.Type != "session_joined" || sess.Participants[kim.userID].Role != "Guest" {
+		t.Errorf("Expected Kim to join as a guest, got %+v", joined)
+	}
+}
+
+func TestInviteNameAndRoleModerated(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	signer, _ := invites.NewSigner("test-secret")
+	mh.SetInvites(signer)
+	mh.SetModeration(moderation.NewBlocklist([]string{"darn"}))
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+
+	handle := func(client *Client, msg *Message) {
+		hub.Schedule(func() { mh.HandleMessage(client, msg) })
+	}
+
+	// Whoever joins with an invite takes its name and role without them being checked again
+	for _, data := range []map[string]interface{}{
+		{"userName": "Darn Sam"},
+		{"userName": "Sam", "role": "Darn lead"},
+	} {
+		handle(host, &Message{Type: "create_invite", Data: data})
+		if reply := nextMessage(t, host); reply.Data["code"] != "content_rejected" {
+			t.Errorf("Expected the invite for %v to be refused, got %+v", data, reply)
+		}
+	}
+
+	handle(host, &Message{Type: "create_invite", Data: map[string]interface{}{}})
+	created := nextMessage(t, host)
+	if created.Type != "invite_created" {
+		t.Fatalf("Expected invite_created, got %+v", created)
+	}
+
+	joiner := newTestClient(hub, "", "")
+	handle(joiner, &Message{Type: "join_session", Data: map[string]interface{}{"invite": created.Data["token"], "userName": "Sam", "role": "Darn lead"}})
+	if reply := nextMessage(t, joiner); reply.Data["code"] != "content_rejected" {
+		t.Errorf("Expected the role sent with the join to be refused, got %+v", reply)
+	}
+	if len(sess.GetParticipantList()) != 1 {
+		t.Error("Expected nobody to join")
+	}
+}
+
+func TestInviteForEndedSessionRefused(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	signer, _ := invites.NewSigner("test-secret")
+	mh.SetInvites(signer)
+
+	// An invite to a session that has ended doesn't work in a later one given its code
+	later := manager.CreateSession("Host")
+	token, _, _ := signer.Issue(invites.Invite{SessionCode: later.Code, SessionID: "ended-session"}, 0)
+
+	client := newTestClient(hub, "", "")
+	mh.HandleMessage(client, &Message{Type: "join_session", Data: map[string]interface{}{"invite": token, "userName": "Sam"}})
+	if data := nextMessage(t, client).Data; data["code"] != "invite_invalid" {
+		t.Errorf("Expected invite_invalid, got %+v", data)
+	}
+}
@@ -0,0 +1,55 @@
+// ABOUTME: Application-level ping/pong for measuring each client's round-trip time
+// ABOUTME: Latest RTTs are stored on participants and reported to the session host
+package websocket
+
+import (
+	"time"
+)
+
+// maxPlausibleLatency discards pong replies too old to reflect current connection quality
+const maxPlausibleLatency = time.Minute
+
+// handlePing answers a client's latency probe so the client can measure its own round trip
+func (mh *MessageHandler) handlePing(client *Client, req *pingRequest) {
+	client.SendMessage(&Message{
+		Type: "pong",
+		Data: map[string]interface{}{
+			"clientTime": req.ClientTime,
+			"serverTime": time.Now().UnixMilli(),
+		},
+	})
+}
+
+// handlePong records the round-trip time for a server latency probe
+func (mh *MessageHandler) handlePong(client *Client, req *pongRequest) {
+	sentAt := req.SentAt
+	if sentAt <= 0 {
+		return
+	}
+
+	rtt := time.Since(time.UnixMilli(int64(sentAt)))
+	if rtt < 0 || rtt > maxPlausibleLatency {
+		return
+	}
+	client.rtt = rtt
+
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		return
+	}
+
+	latencyMs := max(int(rtt.Milliseconds()), 1)
+	if err := sess.SetParticipantLatency(client.userID, latencyMs); err != nil {
+		client.logger().Error("Failed to record latency", "err", err)
+		return
+	}
+
+	// Let the host spot participants on poor connections
+	mh.hub.SendToUser(sess.ID, sess.HostID, &Message{
+		Type: "participant_latency",
+		Data: map[string]interface{}{
+			"userId":    client.userID,
+			"latencyMs": latencyMs,
+		},
+	})
+}
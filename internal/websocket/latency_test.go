@@ -0,0 +1,49 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestHandlePongRecordsLatency(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	participant, _ := sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	alice := newTestClient(hub, sess.ID, participant.ID)
+
+	mh.HandleMessage(alice, &Message{
+		Type: "pong",
+		Data: map[string]interface{}{
+			"sentAt": float64(time.Now().Add(-80 * time.Millisecond).UnixMilli()),
+		},
+	})
+
+	if got := sess.Participants[participant.ID].LatencyMs; got < 80 {
+		t.Errorf("Expected latency of at least 80ms, got %d", got)
+	}
+
+	var report Message
+	json.Unmarshal(<-host.send, &report)
+	if report.Type != "participant_latency" || report.Data["userId"] != participant.ID {
+		t.Errorf("Expected participant_latency for Alice, got %+v", report)
+	}
+
+	// Stale or malformed replies are ignored
+	mh.HandleMessage(alice, &Message{
+		Type: "pong",
+		Data: map[string]interface{}{
+			"sentAt": float64(time.Now().Add(-time.Hour).UnixMilli()),
+		},
+	})
+	mh.HandleMessage(alice, &Message{Type: "pong"})
+	if len(host.send) != 0 {
+		t.Errorf("Expected stale pongs to be ignored, host got %d messages", len(host.send))
+	}
+}
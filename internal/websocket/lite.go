@@ -0,0 +1,88 @@
+// ABOUTME: Low-bandwidth rendering for clients that negotiated the lite capability
+// ABOUTME: Skips non-essential updates and trims payloads so phase, turn and note messages get through
+package websocket
+
+import "github.com/cassiascheffer/uplift/internal/session"
+
+// nonEssential lists message types lite clients don't receive: progress ticks and
+// live statistics whose absence leaves the circle fully usable
+var nonEssential = map[string]bool{
+	"phase_starting_in":   true, // The phase_changed that follows is what matters
+	"writing_stats":       true,
+	"participant_latency": true,
+	"note_reaction":       true, // Cheers while a note is read; the note itself still arrives
+	"presence_changed":    true, // Lite participant lists don't carry presence to update
+}
+
+// lite returns msg trimmed for a low-bandwidth client, or nil if it shouldn't be sent
+// Participants are cut down to ID, name and host flag, GIFs are left out since
+// loading them is the expensive part, and empty text fields are dropped
+func lite(msg *Message) *Message {
+	if nonEssential[msg.Type] {
+		return nil
+	}
+
+	trimmed := *msg
+	trimmed.Data = make(map[string]interface{}, len(msg.Data))
+	for key, value := range msg.Data {
+		if value, keep := compact(key, value); keep {
+			trimmed.Data[key] = value
+		}
+	}
+	return &trimmed
+}
+
+// compact returns the lite form of one data field, and whether to keep it at all
+func compact(key string, value interface{}) (interface{}, bool) {
+	if key == "gifUrl" {
+		return nil, false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, v != ""
+	case *session.Participant:
+		if v == nil {
+			return v, true
+		}
+		return liteParticipant(v), true
+	case []*session.Participant:
+		list := make([]map[string]interface{}, len(v))
+		for i, p := range v {
+			list[i] = liteParticipant(p)
+		}
+		return list, true
+	case map[string]interface{}:
+		nested := make(map[string]interface{}, len(v))
+		for k, inner := range v {
+			if inner, keep := compact(k, inner); keep {
+				nested[k] = inner
+			}
+		}
+		return nested, true
+	case []map[string]interface{}:
+		list := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			entry, _ := compact("", item)
+			list[i] = entry.(map[string]interface{})
+		}
+		return list, true
+	case []interface{}:
+		// Lists in messages relayed from another replica, decoded from JSON
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i], _ = compact("", item)
+		}
+		return list, true
+	}
+	return value, true
+}
+
+// liteParticipant is the part of a participant a lite client needs
+func liteParticipant(p *session.Participant) map[string]interface{} {
+	data := map[string]interface{}{"id": p.ID, "name": p.Name}
+	if p.IsHost {
+		data["isHost"] = true
+	}
+	return data
+}
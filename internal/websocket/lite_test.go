@@ -0,0 +1,55 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestLiteCapability(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.HandleMessage(aliceClient, &Message{Type: "hello", Data: map[string]interface{}{
+		"capabilities": []interface{}{"lite"},
+	}})
+	nextMessage(t, aliceClient) // hello
+
+	// Countdown ticks go to full clients only
+	hub.BroadcastToSession(sess.ID, &Message{Type: "phase_starting_in", Data: map[string]interface{}{"phase": session.PhaseWriting, "seconds": 3}})
+	if msg := nextMessage(t, host); msg.Type != "phase_starting_in" {
+		t.Errorf("Expected the host to get the tick, got %+v", msg)
+	}
+
+	sess.TransitionToWriting()
+	mh.broadcastWritingStarted(sess)
+	nextMessage(t, host)
+
+	changed := nextMessage(t, aliceClient)
+	if changed.Type != "phase_changed" {
+		t.Fatalf("Expected phase_changed first on the lite client, got %+v", changed)
+	}
+	if _, ok := changed.Data["welcome"]; ok {
+		t.Error("Expected the empty welcome to be dropped")
+	}
+	participants, _ := changed.Data["participants"].([]interface{})
+	if len(participants) != 2 {
+		t.Fatalf("Expected both participants, got %v", changed.Data["participants"])
+	}
+	first, _ := participants[0].(map[string]interface{})
+	if _, ok := first["joinedAt"]; ok || first["name"] == nil {
+		t.Errorf("Expected participants trimmed to ID, name and host flag, got %v", first)
+	}
+
+	select {
+	case data := <-aliceClient.send:
+		t.Errorf("Expected nothing more for the lite client, got %s", data)
+	default:
+	}
+}
@@ -0,0 +1,124 @@
+// ABOUTME: Merges another joining session into the host's, for when a team accidentally splits
+// ABOUTME: Moves the other session's clients across and tells everyone about the combined circle
+package websocket
+
+import (
+	"errors"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// handleMergeSession pulls the session with the given code into the host's session
+// The requester must host both: the other session's host rejoin token proves it. Merging
+// sessions someone else hosts is left to the admin API
+func (mh *MessageHandler) handleMergeSession(client *Client, req *mergeSessionRequest) {
+	target, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if client.userID != target.HostID {
+		client.logger().Warn("Non-host tried to merge sessions", "hostID", target.HostID)
+		mh.sendError(client, "only host can merge sessions")
+		return
+	}
+
+	source, err := mh.sessionManager.GetSessionByCode(req.SessionCode)
+	if err != nil {
+		mh.sendError(client, "session to merge not found")
+		return
+	}
+
+	if !source.HostedBy(req.RejoinToken) {
+		client.logger().Warn("Host tried to merge a session they don't host", "sourceSessionCode", source.Code)
+		mh.sendError(client, "only the host of both sessions can merge them")
+		return
+	}
+
+	if err := mh.mergeSessions(target, source); err != nil {
+		mh.sendError(client, err.Error())
+	}
+}
+
+// MergeSessions merges the session with sourceCode into the one with targetCode
+// Safe to call from any goroutine; the merge runs on the hub goroutine
+func (mh *MessageHandler) MergeSessions(targetCode, sourceCode string) error {
+	target, err := mh.sessionManager.GetSessionByCode(targetCode)
+	if err != nil {
+		return err
+	}
+	source, err := mh.sessionManager.GetSessionByCode(sourceCode)
+	if err != nil {
+		return errors.New("session to merge not found")
+	}
+
+	result := make(chan error, 1)
+	mh.hub.Schedule(func() {
+		result <- mh.mergeSessions(target, source)
+	})
+	return <-result
+}
+
+// mergeSessions moves source's participants and clients into target
+// Runs on the hub goroutine
+func (mh *MessageHandler) mergeSessions(target, source *session.Session) error {
+	if mh.countingDown(target.ID) || mh.countingDown(source.ID) {
+		return errors.New("phase change already in progress")
+	}
+
+	moved, err := mh.sessionManager.MergeSessions(target, source)
+	if err != nil {
+		return err
+	}
+	mh.autoRunSteps.delete(source.ID)
+
+	participants := target.GetParticipantList()
+
+	// Tell the people already here before the newcomers arrive
+	mh.hub.BroadcastToSession(target.ID, &Message{
+		Type: "sessions_merged",
+		Data: map[string]interface{}{
+			"fromSessionCode": source.Code,
+			"participants":    participants,
+		},
+	})
+
+	for _, participant := range moved {
+		client := mh.hub.MoveUser(source.ID, participant.ID, target.ID)
+		if client == nil {
+			continue
+		}
+
+		merged := &Message{
+			Type: "session_merged",
+			Data: map[string]interface{}{
+				"fromSessionCode": source.Code,
+				"sessionCode":     target.Code,
+				"sessionId":       target.ID,
+				"title":           target.Title,
+				"welcome":         target.Welcome,
+				"autoStartAt":     target.AutoStartAt,
+				"countdown":       target.Countdown,
+				"maxNoteLength":   target.Settings.MaxNoteLength,
+				"settings":        target.Settings,
+				"minNoteChars":    target.MinNoteChars,
+				"minNoteWords":    target.MinNoteWords,
+				"autoRun":         target.AutoRun,
+				"ratings":         target.RatingsEnabled,
+				"userId":          participant.ID,
+				"userName":        participant.Name,
+				"participants":    participants,
+				"phase":           target.Phase,
+				"theme":           target.Theme,
+				"prompt":          target.Prompt,
+			},
+		}
+		// The old session's rejoin token stops working once it's merged away
+		mh.addRejoinToken(target, participant.ID, merged.Data)
+		client.SendMessage(merged)
+	}
+
+	sessionLogger(target).Info("Session merged", "sourceSessionID", source.ID, "sourceSessionCode", source.Code, "moved", len(moved))
+	return nil
+}
@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestMergeSessionMovesClients(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	hub.SetMessageHandler(mh.HandleMessage)
+	go hub.Run()
+
+	target := manager.CreateSession("Host")
+	alice, _ := target.AddParticipant("Alice")
+	source := manager.CreateSession("Other Host")
+
+	host := newTestClient(hub, target.ID, target.HostID)
+	aliceClient := newTestClient(hub, target.ID, alice.ID)
+	otherHost := newTestClient(hub, source.ID, source.HostID)
+
+	if err := mh.MergeSessions(target.Code, source.Code); err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+
+	if reply := nextMessage(t, aliceClient); reply.Type != "sessions_merged" {
+		t.Errorf("Expected sessions_merged for existing participants, got %+v", reply)
+	}
+	nextMessage(t, host)
+
+	reply := nextMessage(t, otherHost)
+	if reply.Type != "session_merged" || reply.Data["sessionCode"] != target.Code {
+		t.Fatalf("Expected session_merged into %s, got %+v", target.Code, reply)
+	}
+	if otherHost.sessionID != target.ID {
+		t.Errorf("Expected client to move to the target session")
+	}
+
+	// Later broadcasts reach the moved client
+	hub.BroadcastToSession(target.ID, &Message{Type: "session_title_changed"})
+	select {
+	case <-otherHost.send:
+	case <-time.After(time.Second):
+		t.Error("Expected moved client to receive target broadcasts")
+	}
+
+	if err := mh.MergeSessions(target.Code, "NOPE00"); err == nil {
+		t.Error("Expected error for unknown session")
+	}
+}
+
+func TestMergeSessionNeedsHostOfBoth(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	target := manager.CreateSession("Host")
+	alice, _ := target.AddParticipant("Alice")
+	source := manager.CreateSession("Other Host")
+	bob, _ := source.AddParticipant("Bob")
+	host := newTestClient(hub, target.ID, target.HostID)
+	aliceClient := newTestClient(hub, target.ID, alice.ID)
+
+	mh.HandleMessage(aliceClient, &Message{Type: "merge_session", Data: map[string]interface{}{"sessionCode": source.Code}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected non-hosts to be refused, got %+v", reply)
+	}
+
+	// Knowing the other session's code isn't enough, nor is a participant's token
+	bobToken, _ := source.IssueRejoinToken(bob.ID)
+	for _, token := range []string{"", bobToken} {
+		mh.HandleMessage(host, &Message{Type: "merge_session", Data: map[string]interface{}{"sessionCode": source.Code, "rejoinToken": token}})
+		if reply := nextMessage(t, host); reply.Type != "error" {
+			t.Errorf("Expected a merge without the other host's token to be refused, got %+v", reply)
+		}
+	}
+	if _, err := manager.GetSessionByCode(source.Code); err != nil {
+		t.Fatal("Expected source session to be untouched")
+	}
+
+	hostToken, _ := source.IssueRejoinToken(source.HostID)
+	mh.HandleMessage(host, &Message{Type: "merge_session", Data: map[string]interface{}{"sessionCode": source.Code, "rejoinToken": hostToken}})
+	if reply := nextMessage(t, host); reply.Type != "sessions_merged" {
+		t.Errorf("Expected the host of both sessions to merge them, got %+v", reply)
+	}
+	if _, err := manager.GetSessionByCode(source.Code); err == nil {
+		t.Error("Expected the source session to be merged away")
+	}
+}
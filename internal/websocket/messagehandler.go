@@ -3,95 +3,306 @@
 package websocket
 
 import (
-	"log"
+	"errors"
+	"log/slog"
 	"math/rand"
-
+	"sync/atomic"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/abuse"
+	"github.com/cassiascheffer/uplift/internal/analytics"
+	"github.com/cassiascheffer/uplift/internal/branding"
+	"github.com/cassiascheffer/uplift/internal/export"
+	"github.com/cassiascheffer/uplift/internal/features"
+	"github.com/cassiascheffer/uplift/internal/gifs"
+	"github.com/cassiascheffer/uplift/internal/invites"
+	"github.com/cassiascheffer/uplift/internal/moderation"
+	"github.com/cassiascheffer/uplift/internal/notifications"
 	"github.com/cassiascheffer/uplift/internal/session"
+	"github.com/cassiascheffer/uplift/internal/starters"
+	"github.com/cassiascheffer/uplift/internal/wall"
 )
 
 // MessageHandler handles incoming WebSocket messages
 type MessageHandler struct {
 	hub            *Hub
 	sessionManager *session.Manager
+	analytics      *analytics.Collector
+
+	// Proof-of-work required before create_session (nil = disabled)
+	challenger *abuse.Challenger
+
+	// Experimental capabilities that can be switched off (nil = defaults)
+	features *features.Flags
+
+	// Push, email and chat notifications (nil = disabled)
+	notifications *notifications.Dispatcher
+
+	// GIF search proxy for notes (nil = disabled)
+	gifs *gifs.Searcher
+
+	// Content policy notes and names must pass (nil = no moderation)
+	moderation moderation.Filter
+
+	// Set once the server starts draining for a restart; drain is only set on the hub goroutine
+	draining atomic.Bool
+	drain    *drainNotice
+
+	// Per-host history of circles run (nil = disabled)
+	hostHistory *analytics.HostHistory
+
+	// Tenant branding for notifications (nil = default product name)
+	branding *branding.Registry
+
+	// Sentence starters offered while writing (nil = built-in defaults)
+	starters *starters.Store
+
+	// Public gratitude walls hosts publish shared notes to (nil = disabled)
+	walls *wall.Store
+
+	// Signs and checks invite links hosts share (nil = disabled)
+	invites *invites.Signer
+
+	// Completed circles' keepsakes, retained briefly for download (nil = disabled)
+	exports *export.Store
+
+	// Frontend build currently deployed, for prompting stale clients to refresh (empty = off)
+	assetVersion string
+
+	// Sessions with a phase countdown in progress, each with a channel closed to cancel it
+	countdowns *perSession[chan struct{}]
+
+	// Latest scheduled auto-run step per session
+	autoRunSteps *perSession[int]
+
+	// Sessions with a writing stats update pending for the host
+	writingStatsPending *perSession[bool]
+
+	// Recently handled msgIds and their replies
+	handled *handledMessages
 }
 
 // NewMessageHandler creates a new message handler
 func NewMessageHandler(hub *Hub, sessionManager *session.Manager) *MessageHandler {
 	return &MessageHandler{
-		hub:            hub,
-		sessionManager: sessionManager,
+		hub:                 hub,
+		sessionManager:      sessionManager,
+		countdowns:          newPerSession[chan struct{}](),
+		autoRunSteps:        newPerSession[int](),
+		writingStatsPending: newPerSession[bool](),
+		handled:             newHandledMessages(),
+	}
+}
+
+// SetAnalytics sets the collector that records session lifecycle events
+func (mh *MessageHandler) SetAnalytics(collector *analytics.Collector) {
+	mh.analytics = collector
+}
+
+// SetFeatures sets the feature flags gating experimental capabilities
+func (mh *MessageHandler) SetFeatures(flags *features.Flags) {
+	mh.features = flags
+}
+
+// SetNotifications sets the dispatcher for push, email and chat notifications
+func (mh *MessageHandler) SetNotifications(dispatcher *notifications.Dispatcher) {
+	mh.notifications = dispatcher
+}
+
+// SetGIFs enables GIF search and lets notes carry GIFs from the searcher's provider
+func (mh *MessageHandler) SetGIFs(searcher *gifs.Searcher) {
+	mh.gifs = searcher
+}
+
+// SetChallenger requires clients to solve a proof-of-work challenge before creating sessions
+func (mh *MessageHandler) SetChallenger(challenger *abuse.Challenger) {
+	mh.challenger = challenger
+}
+
+// IsHost reports whether a client is the host of its session
+func (mh *MessageHandler) IsHost(client *Client) bool {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		return false
 	}
+	return sess.IsHostParticipant(client.userID)
 }
 
 // HandleMessage processes an incoming message from a client
+// A message carrying a msgId is handled once; resending it gets the same replies
 func (mh *MessageHandler) HandleMessage(client *Client, msg *Message) {
-	log.Printf("HandleMessage: type=%s sessionID=%s userID=%s", msg.Type, client.sessionID, client.userID)
+	if msg.MsgID == "" {
+		mh.handleMessage(client, msg)
+		return
+	}
+	mh.handleOnce(client, msg, func() { mh.handleMessage(client, msg) })
+}
+
+// handleMessage dispatches a message to its handler
+func (mh *MessageHandler) handleMessage(client *Client, msg *Message) {
+	client.logger().Debug("Handling message", "messageType", msg.Type)
+
+	// Admin observers watch without taking part
+	if client.observer && msg.Type != "ping" && msg.Type != "hello" && msg.Type != "get_diagnostics" && msg.Type != "ack" {
+		if msg.Type != "pong" {
+			mh.sendErrorCode(client, "read_only", "observers can't act in a session")
+		}
+		return
+	}
+
+	var err error
 	switch msg.Type {
+	case "hello":
+		err = dispatch(client, msg, mh.handleHello)
+	case "ping":
+		err = dispatch(client, msg, mh.handlePing)
+	case "pong":
+		err = dispatch(client, msg, mh.handlePong)
+	case "ack":
+		err = dispatch(client, msg, mh.handleAck)
+	case "get_diagnostics":
+		err = dispatchEmpty(client, msg, mh.handleGetDiagnostics)
+	case "register_push":
+		err = dispatch(client, msg, mh.handleRegisterPush)
+	case "unregister_push":
+		err = dispatch(client, msg, mh.handleUnregisterPush)
+	case "search_gifs":
+		err = dispatch(client, msg, mh.handleSearchGIFs)
 	case "validate_session":
-		mh.handleValidateSession(client, msg)
+		err = dispatch(client, msg, mh.handleValidateSession)
+	case "list_themes":
+		err = dispatchEmpty(client, msg, mh.handleListThemes)
+	case "get_challenge":
+		err = dispatchEmpty(client, msg, mh.handleGetChallenge)
 	case "create_session":
-		mh.handleCreateSession(client, msg)
+		err = dispatch(client, msg, mh.handleCreateSession)
+	case "rejoin_session":
+		err = dispatch(client, msg, mh.handleRejoinSession)
 	case "join_session":
-		mh.handleJoinSession(client, msg)
+		err = dispatch(client, msg, mh.handleJoinSession)
 	case "start_writing":
-		mh.handleStartWriting(client, msg)
+		err = dispatch(client, msg, mh.handleStartWriting)
+	case "start_reading":
+		err = dispatch(client, msg, mh.handleStartReading)
+	case "undo_transition":
+		err = dispatch(client, msg, mh.handleUndoTransition)
+	case "reopen_writing":
+		err = dispatch(client, msg, mh.handleReopenWriting)
 	case "submit_notes":
-		mh.handleSubmitNotes(client, msg)
+		err = dispatch(client, msg, mh.handleSubmitNotes)
+	case "update_note":
+		err = dispatch(client, msg, mh.handleUpdateNote)
+	case "delete_note":
+		err = dispatch(client, msg, mh.handleDeleteNote)
 	case "draw_note":
-		mh.handleDrawNote(client, msg)
+		err = dispatch(client, msg, mh.handleDrawNote)
 	case "note_read":
-		mh.handleNoteRead(client, msg)
+		err = dispatch(client, msg, mh.handleNoteRead)
+	case "react_note":
+		err = dispatch(client, msg, mh.handleReactNote)
 	case "remove_participant":
-		mh.handleRemoveParticipant(client, msg)
+		err = dispatch(client, msg, mh.handleRemoveParticipant)
+	case "rename_participant":
+		err = dispatch(client, msg, mh.handleRenameParticipant)
+	case "change_name":
+		err = dispatch(client, msg, mh.handleChangeName)
+	case "set_session_title":
+		err = dispatch(client, msg, mh.handleSetSessionTitle)
+	case "set_prompt":
+		err = dispatch(client, msg, mh.handleSetPrompt)
+	case "publish_wall":
+		err = dispatch(client, msg, mh.handlePublishWall)
+	case "unpublish_wall":
+		err = dispatch(client, msg, mh.handleUnpublishWall)
+	case "create_invite":
+		err = dispatch(client, msg, mh.handleCreateInvite)
+	case "export_archive":
+		err = dispatchEmpty(client, msg, mh.handleExportArchive)
+	case "submit_rating":
+		err = dispatch(client, msg, mh.handleSubmitRating)
+	case "create_breakouts":
+		err = dispatch(client, msg, mh.handleCreateBreakouts)
+	case "get_breakout_status":
+		err = dispatchEmpty(client, msg, mh.handleGetBreakoutStatus)
+	case "merge_session":
+		err = dispatch(client, msg, mh.handleMergeSession)
+	case "split_session":
+		err = dispatch(client, msg, mh.handleSplitSession)
+	case "get_note_pool_status":
+		err = dispatchEmpty(client, msg, mh.handleGetNotePoolStatus)
+	case "get_timeline":
+		err = dispatchEmpty(client, msg, mh.handleGetTimeline)
 	default:
-		log.Printf("unknown message type: %s", msg.Type)
+		client.logger().Warn("Unknown message type", "messageType", msg.Type)
+		mh.hub.recordUnknownMessage(client, msg)
+	}
+	if err != nil {
+		client.logger().Warn("Invalid message payload", "messageType", msg.Type, "err", err)
+		mh.sendInvalidPayload(client, msg, err)
 	}
 }
 
+// sessionLogger returns a logger carrying a session's ID and join code
+func sessionLogger(sess *session.Session) *slog.Logger {
+	return slog.With("sessionID", sess.ID, "sessionCode", sess.Code)
+}
+
 // HandleClientDisconnect processes a client disconnection
 func (mh *MessageHandler) HandleClientDisconnect(client *Client) {
 	if client.sessionID == "" || client.userID == "" {
 		return // Client never joined a session
 	}
+	if client.observer {
+		client.logger().Info("Admin observer detached")
+		return
+	}
 
-	log.Printf("HandleClientDisconnect: sessionID=%s userID=%s", client.sessionID, client.userID)
+	client.logger().Debug("Handling client disconnect")
 
 	// Get session
 	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
 	if err != nil {
-		log.Printf("Session not found for disconnecting client: %v", err)
+		client.logger().Warn("Session not found for disconnecting client", "err", err)
 		return
 	}
 
+	// A rejoin may have replaced this connection before it closed
+	if mh.hub.IsUserConnected(sess.ID, client.userID) {
+		client.logger().Info("Stale connection closed after rejoin")
+		return
+	}
+
+	// Leaving is announced as participant_left, so there's no presence_changed to send
+	sess.SetParticipantConnected(client.userID, false)
+	mh.participantLeft(sess, client.userID)
+}
+
+// participantLeft removes a participant who is no longer connected and tells everyone else
+func (mh *MessageHandler) participantLeft(sess *session.Session, userID string) {
 	// Check if this was the host
-	wasHost := client.userID == sess.HostID
+	wasHost := userID == sess.HostID
 
 	// Remove participant from session
-	participant, err := sess.RemoveParticipant(client.userID)
+	participant, err := sess.RemoveParticipant(userID)
 	if err != nil {
-		log.Printf("Error removing participant: %v", err)
+		sessionLogger(sess).Error("Failed to remove participant", "userID", userID, "err", err)
 		return
 	}
 
 	// If host left and there are participants remaining, assign new host
-	if wasHost && len(sess.Participants) > 0 {
-		// Get first remaining participant as new host
-		for _, p := range sess.Participants {
-			p.IsHost = true
-			sess.HostID = p.ID
-			log.Printf("New host assigned: session=%s userId=%s", sess.Code, p.ID)
-			break
+	if wasHost {
+		if newHost := sess.ReassignHost(); newHost != nil {
+			sessionLogger(sess).Info("New host assigned", "userID", newHost.ID)
 		}
 	}
 
-	// Check if session is now empty
+	// Check if session is now empty, keeping it while anyone could still rejoin
 	if len(sess.Participants) == 0 {
-		// Remove session from manager
-		if err := mh.sessionManager.RemoveSession(sess.ID); err != nil {
-			log.Printf("Error removing empty session: %v", err)
-		} else {
-			log.Printf("Empty session cleaned up: session=%s", sess.Code)
+		if sess.AwaitingRejoin() {
+			mh.scheduleEmptyCleanup(sess)
+			return
 		}
+		mh.removeEmptySession(sess)
 		return
 	}
 
@@ -106,13 +317,13 @@ func (mh *MessageHandler) HandleClientDisconnect(client *Client) {
 	}
 	mh.hub.BroadcastToSession(sess.ID, broadcast)
 
-	log.Printf("Participant removed from session: session=%s userId=%s wasHost=%v", sess.Code, participant.ID, wasHost)
+	sessionLogger(sess).Info("Participant removed from session", "userID", participant.ID, "wasHost", wasHost)
 }
 
 // handleValidateSession validates if a session code exists without joining
-func (mh *MessageHandler) handleValidateSession(client *Client, msg *Message) {
-	sessionCode, ok := msg.Data["sessionCode"].(string)
-	if !ok || sessionCode == "" {
+func (mh *MessageHandler) handleValidateSession(client *Client, req *validateSessionRequest) {
+	sessionCode := req.SessionCode
+	if sessionCode == "" {
 		response := &Message{
 			Type: "session_validation",
 			Data: map[string]interface{}{
@@ -126,6 +337,10 @@ func (mh *MessageHandler) handleValidateSession(client *Client, msg *Message) {
 
 	// Check if session exists
 	_, err := mh.sessionManager.GetSessionByCode(sessionCode)
+	if err != nil && mh.onWrongInstance(client) {
+		mh.sendWrongInstance(client)
+		return
+	}
 	if err != nil {
 		response := &Message{
 			Type: "session_validation",
@@ -135,7 +350,7 @@ func (mh *MessageHandler) handleValidateSession(client *Client, msg *Message) {
 			},
 		}
 		client.SendMessage(response)
-		log.Printf("Session validation failed: code=%s", sessionCode)
+		client.logger().Info("Session validation failed", "sessionCode", sessionCode)
 		return
 	}
 
@@ -147,33 +362,89 @@ func (mh *MessageHandler) handleValidateSession(client *Client, msg *Message) {
 		},
 	}
 	client.SendMessage(response)
-	log.Printf("Session validated: code=%s", sessionCode)
+	client.logger().Info("Session validated", "sessionCode", sessionCode)
 }
 
-// handleCreateSession creates a new session
-func (mh *MessageHandler) handleCreateSession(client *Client, msg *Message) {
-	userName, ok := msg.Data["userName"].(string)
-	if !ok || userName == "" {
-		userName = "Host"
+// handleGetChallenge issues a proof-of-work challenge for session creation
+func (mh *MessageHandler) handleGetChallenge(client *Client) {
+	client.SendMessage(&Message{Type: "challenge", Data: mh.Challenge()})
+}
+
+// Challenge issues a proof-of-work challenge for session creation, or reports that none is required
+// Safe to call from any goroutine
+func (mh *MessageHandler) Challenge() map[string]interface{} {
+	if mh.challenger == nil {
+		return map[string]interface{}{"required": false}
 	}
 
-	// Validate and sanitise user name
-	validatedName, err := validateUserName(userName)
+	return map[string]interface{}{
+		"required":   true,
+		"challenge":  mh.challenger.Issue(),
+		"difficulty": mh.challenger.Difficulty(),
+	}
+}
+
+// checkChallenge checks the proof-of-work solution sent with a session creation
+func (mh *MessageHandler) checkChallenge(challenge, solution string) error {
+	if mh.challenger == nil {
+		return nil
+	}
+	if challenge == "" {
+		return abuse.ErrChallengeRequired
+	}
+	return mh.challenger.Verify(challenge, solution)
+}
+
+// verifyChallenge checks the proof-of-work solution sent with create_session
+func (mh *MessageHandler) verifyChallenge(client *Client, challenge, solution string) bool {
+	err := mh.checkChallenge(challenge, solution)
+	if errors.Is(err, abuse.ErrChallengeRequired) {
+		mh.sendErrorCode(client, "challenge_required", err.Error())
+		return false
+	}
 	if err != nil {
+		mh.sendErrorCode(client, "challenge_failed", err.Error())
+		return false
+	}
+	return true
+}
+
+// handleCreateSession creates a new session once the host's name, welcome and prompt
+// pass moderation
+func (mh *MessageHandler) handleCreateSession(client *Client, req *createSessionRequest) {
+	if !mh.verifyChallenge(client, req.Challenge, req.Solution) {
+		return
+	}
+	if err := cleanCreateRequest(req); err != nil {
 		mh.sendError(client, err.Error())
 		return
 	}
 
-	// Create session
-	sess := mh.sessionManager.CreateSession(validatedName)
+	mh.moderated(client, "", moderation.KindName, []string{req.UserName}, func() {
+		mh.moderated(client, "", moderation.KindSession, []string{req.Welcome, req.Prompt}, func() {
+			mh.createSession(client, req)
+		})
+	})
+}
 
-	// Get the host participant (first and only participant)
-	participants := sess.GetParticipantList()
-	if len(participants) == 0 {
-		mh.sendError(client, "failed to create session")
+// createSession creates a new session with the client as its host
+func (mh *MessageHandler) createSession(client *Client, req *createSessionRequest) {
+	sess, host, err := mh.newSession(req, client.tenant, client.remoteIP)
+	if errors.Is(err, ErrServerDraining) {
+		mh.sendErrorCode(client, "server_draining", err.Error())
+		return
+	}
+	var limited *SessionLimitError
+	if errors.As(err, &limited) {
+		client.sendSessionLimit(limited)
+		return
+	}
+	if err != nil {
+		mh.sendError(client, err.Error())
 		return
 	}
-	host := participants[0]
+	hostKey := mh.hostKeyFor(req.HostKey)
+	sess.SetHostKey(hostKey)
 
 	// Associate client with session
 	client.sessionID = sess.ID
@@ -190,29 +461,188 @@ func (mh *MessageHandler) handleCreateSession(client *Client, msg *Message) {
 	response := &Message{
 		Type: "session_created",
 		Data: map[string]interface{}{
-			"sessionCode":  sess.Code,
-			"sessionId":    sess.ID,
-			"userId":       host.ID,
-			"userName":     host.Name,
-			"participants": participants,
-			"phase":        sess.Phase,
+			"sessionCode":    sess.Code,
+			"sessionId":      sess.ID,
+			"title":          sess.Title,
+			"welcome":        sess.Welcome,
+			"autoStartAt":    sess.AutoStartAt,
+			"countdown":      sess.Countdown,
+			"maxNoteLength":  sess.Settings.MaxNoteLength,
+			"settings":       sess.Settings,
+			"minNoteChars":   sess.MinNoteChars,
+			"minNoteWords":   sess.MinNoteWords,
+			"autoRun":        sess.AutoRun,
+			"ratings":        sess.RatingsEnabled,
+			"duplicateNotes": sess.DuplicatePolicy,
+			"userId":         host.ID,
+			"userName":       host.Name,
+			"participants":   sess.GetParticipantList(),
+			"phase":          sess.Phase,
+			"theme":          sess.Theme,
+			"prompt":         sess.Prompt,
+			"version":        sess.Version,
 		},
 	}
+	mh.addInstance(response.Data)
+	mh.addRejoinToken(sess, host.ID, response.Data)
+	addDictationToken(client, response.Data)
+	if hostKey != "" {
+		// Clients keep this to see their history and send it back when creating the next session
+		response.Data["hostKey"] = hostKey
+	}
 	client.SendMessage(response)
 
-	log.Printf("Session created: code=%s id=%s", sess.Code, sess.ID)
+	client.logger().Info("Session created", "sessionCode", sess.Code)
+}
+
+// newSession validates create_session options and creates a session hosted by the
+// named user, returning the session and its host
+// remoteIP is charged against the per-IP session limit once the options are valid
+// Runs on the hub goroutine
+func (mh *MessageHandler) newSession(req *createSessionRequest, tenant, remoteIP string) (*session.Session, *session.Participant, error) {
+	if mh.Draining() {
+		return nil, nil, ErrServerDraining
+	}
+
+	userName := req.UserName
+	if userName == "" {
+		userName = "Host"
+	}
+
+	// Validate and sanitise user name
+	validatedName, err := validateUserName(userName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate optional welcome message
+	validatedWelcome, err := validateWelcome(req.Welcome)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate optional writing prompt
+	validatedPrompt, err := validatePrompt(req.Prompt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate optional settings, falling back to the server defaults
+	settings, err := validateSettings(req.Settings, req.MaxNoteLength)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate optional auto-start threshold
+	validatedAutoStartAt, err := validateAutoStartAt(req.AutoStartAt, settings.MaxParticipants)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate optional countdown before phase transitions
+	validatedCountdown, err := validateCountdown(req.Countdown)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate optional minimum note length
+	validatedMinChars, validatedMinWords, err := validateMinNoteLength(req.MinNoteChars, req.MinNoteWords, settings.MaxNoteLength)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate optional handling of near-identical notes
+	duplicatePolicy, err := session.ParseDuplicatePolicy(req.DuplicateNotes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate optional occasion theme against the catalog
+	theme, err := session.ParseTheme(req.Theme)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Refuse once this address has created too many sessions
+	if err := mh.hub.allowSession(remoteIP); err != nil {
+		return nil, nil, err
+	}
+
+	// Create session
+	sess := mh.sessionManager.CreateSession(validatedName)
+	mh.analytics.RecordSessionCreated()
+	sess.SetSettings(settings)
+	sess.SetMinNoteLength(validatedMinChars, validatedMinWords)
+	sess.SetWelcome(validatedWelcome)
+	sess.SetPrompt(validatedPrompt)
+	sess.SetAutoStartAt(validatedAutoStartAt)
+	sess.SetCountdown(validatedCountdown)
+	sess.SetDuplicatePolicy(duplicatePolicy)
+	sess.SetTheme(theme)
+	sess.SetTenant(tenant)
+
+	// Ask for a quick rating at completion if requested
+	if req.Ratings {
+		sess.SetRatingsEnabled(true)
+	}
+
+	// Auto-run sessions advance on timers without host commands
+	if req.AutoRun {
+		sess.SetAutoRun(true)
+		mh.scheduleAutoStart(sess)
+	}
+
+	// Get the host participant (first and only participant)
+	participants := sess.GetParticipantList()
+	if len(participants) == 0 {
+		return nil, nil, errors.New("failed to create session")
+	}
+	return sess, participants[0], nil
+}
+
+// handleJoinSession joins an existing session once the name and role pass moderation
+// An invite's name and role were moderated when it was created
+func (mh *MessageHandler) handleJoinSession(client *Client, req *joinSessionRequest) {
+	if req.UserName != "" && !mh.cleaned(client, &req.UserName, validateUserName) {
+		return
+	}
+	if !mh.cleaned(client, &req.Role, validateRole) {
+		return
+	}
+
+	mh.moderated(client, "", moderation.KindName, []string{req.UserName, req.Role}, func() {
+		mh.joinSession(client, req)
+	})
 }
 
-// handleJoinSession joins an existing session
-func (mh *MessageHandler) handleJoinSession(client *Client, msg *Message) {
-	sessionCode, ok := msg.Data["sessionCode"].(string)
-	if !ok || sessionCode == "" {
+// joinSession adds the client to a session as a new participant
+func (mh *MessageHandler) joinSession(client *Client, req *joinSessionRequest) {
+	sessionCode, userName, role := req.SessionCode, req.UserName, req.Role
+
+	// An invite names the session, and perhaps who's joining
+	var invite *invites.Invite
+	if req.Invite != "" {
+		verified, err := mh.verifyInvite(req.Invite, sessionCode)
+		if err != nil {
+			mh.sendErrorCode(client, errorCodeFor(err), err.Error())
+			return
+		}
+		invite = &verified
+		sessionCode = verified.SessionCode
+		if userName == "" {
+			userName = verified.Name
+		}
+		if role == "" {
+			role = verified.Role
+		}
+	}
+
+	if sessionCode == "" {
 		mh.sendError(client, "session code required")
 		return
 	}
 
-	userName, ok := msg.Data["userName"].(string)
-	if !ok || userName == "" {
+	if userName == "" {
 		mh.sendError(client, "user name required")
 		return
 	}
@@ -226,23 +656,30 @@ func (mh *MessageHandler) handleJoinSession(client *Client, msg *Message) {
 
 	// Get session by code
 	sess, err := mh.sessionManager.GetSessionByCode(sessionCode)
+	if err != nil && mh.onWrongInstance(client) {
+		mh.sendWrongInstance(client)
+		return
+	}
 	if err != nil {
 		mh.sendError(client, "session not found")
 		return
 	}
 
-	// Check participant limit
-	if err := checkParticipantLimit(len(sess.Participants)); err != nil {
-		mh.sendError(client, err.Error())
+	// Invites are only good for the session they were issued in, and some sessions need one
+	if err := checkInvite(sess, invite); err != nil {
+		mh.sendErrorCode(client, errorCodeFor(err), err.Error())
 		return
 	}
 
 	// Add participant to session
 	participant, err := sess.AddParticipant(validatedName)
 	if err != nil {
-		mh.sendError(client, err.Error())
+		mh.sendErrorCode(client, errorCodeFor(err), err.Error())
 		return
 	}
+	if role != "" {
+		sess.SetParticipantRole(participant.ID, role)
+	}
 
 	// Associate client with session
 	client.sessionID = sess.ID
@@ -259,15 +696,34 @@ func (mh *MessageHandler) handleJoinSession(client *Client, msg *Message) {
 	response := &Message{
 		Type: "session_joined",
 		Data: map[string]interface{}{
-			"sessionCode":  sess.Code,
-			"sessionId":    sess.ID,
-			"userId":       participant.ID,
-			"userName":     participant.Name,
-			"participants": sess.GetParticipantList(),
-			"phase":        sess.Phase,
+			"sessionCode":    sess.Code,
+			"sessionId":      sess.ID,
+			"title":          sess.Title,
+			"welcome":        sess.Welcome,
+			"autoStartAt":    sess.AutoStartAt,
+			"countdown":      sess.Countdown,
+			"maxNoteLength":  sess.Settings.MaxNoteLength,
+			"settings":       sess.Settings,
+			"minNoteChars":   sess.MinNoteChars,
+			"minNoteWords":   sess.MinNoteWords,
+			"autoRun":        sess.AutoRun,
+			"ratings":        sess.RatingsEnabled,
+			"duplicateNotes": sess.DuplicatePolicy,
+			"userId":         participant.ID,
+			"userName":       participant.Name,
+			"role":           role,
+			"participants":   sess.GetParticipantList(),
+			"phase":          sess.Phase,
+			"theme":          sess.Theme,
+			"prompt":         sess.Prompt,
+			"version":        sess.Version,
 		},
 	}
+	mh.addInstance(response.Data)
+	mh.addRejoinToken(sess, participant.ID, response.Data)
+	addDictationToken(client, response.Data)
 	client.SendMessage(response)
+	mh.sendDrainNotice(client)
 
 	// Broadcast participant joined to all other clients
 	broadcast := &Message{
@@ -279,93 +735,162 @@ func (mh *MessageHandler) handleJoinSession(client *Client, msg *Message) {
 	}
 	mh.hub.BroadcastToSessionExcept(sess.ID, participant.ID, broadcast)
 
-	log.Printf("Participant joined: session=%s userId=%s", sess.Code, participant.ID)
+	client.logger().Info("Participant joined", "sessionCode", sess.Code)
+
+	// Someone joining a reopened writing phase raises how many notes are expected
+	if sess.Phase == session.PhaseWriting {
+		mh.scheduleWritingStats(sess)
+	}
+
+	// Start writing automatically once the configured threshold is reached
+	if sess.ShouldAutoStart() && !mh.countingDown(sess.ID) {
+		sessionLogger(sess).Info("Auto-starting writing phase", "participants", len(sess.Participants))
+		mh.startWriting(client, sess)
+	}
 }
 
 // handleStartWriting transitions session to writing phase
-func (mh *MessageHandler) handleStartWriting(client *Client, msg *Message) {
-	log.Printf("handleStartWriting: sessionID=%s userID=%s", client.sessionID, client.userID)
+func (mh *MessageHandler) handleStartWriting(client *Client, req *phaseActionRequest) {
+	client.logger().Debug("Starting writing")
 
 	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
 	if err != nil {
-		log.Printf("Error getting session: %v", err)
+		client.logger().Warn("Session not found", "err", err)
 		mh.sendError(client, "session not found")
 		return
 	}
 
-	log.Printf("Session found: %s, HostID=%s, ClientUserID=%s", sess.Code, sess.HostID, client.userID)
+	client.logger().Debug("Session found", "sessionCode", sess.Code, "hostID", sess.HostID)
 
 	// Verify client is host
 	if client.userID != sess.HostID {
-		log.Printf("User is not host: userID=%s hostID=%s", client.userID, sess.HostID)
+		client.logger().Warn("Non-host tried to start writing", "hostID", sess.HostID)
 		mh.sendError(client, "only host can start writing phase")
 		return
 	}
 
-	// Transition to writing phase
-	if err := sess.TransitionToWriting(); err != nil {
+	if !mh.checkVersion(client, sess, req.versioned) {
+		return
+	}
+
+	// Ignore repeated starts while a countdown is running
+	if mh.countingDown(sess.ID) {
+		mh.sendError(client, "phase change already in progress")
+		return
+	}
+
+	// Check the transition is possible before counting down
+	if err := sess.CanTransitionToWriting(); err != nil {
 		mh.sendError(client, err.Error())
 		return
 	}
 
-	// Broadcast phase change to all clients
+	mh.startWriting(client, sess)
+}
+
+// startWriting counts down and then transitions the session to writing phase
+// Errors are reported to the client that triggered the start, if any
+func (mh *MessageHandler) startWriting(client *Client, sess *session.Session) {
+	mh.runCountdown(sess, session.PhaseWriting, func() {
+		// Transition to writing phase
+		if err := sess.TransitionToWriting(); err != nil {
+			if client != nil {
+				mh.sendError(client, err.Error())
+			} else {
+				sessionLogger(sess).Error("Failed to start writing", "err", err)
+			}
+			return
+		}
+
+		mh.broadcastWritingStarted(sess)
+
+		sessionLogger(sess).Info("Writing phase started")
+	})
+}
+
+// broadcastWritingStarted sends the writing phase change to all clients
+func (mh *MessageHandler) broadcastWritingStarted(sess *session.Session) {
 	broadcast := &Message{
 		Type: "phase_changed",
 		Data: map[string]interface{}{
-			"phase":             sess.Phase,
-			"participants":      sess.GetParticipantList(),
+			"phase":            sess.Phase,
+			"theme":            sess.Theme,
+			"prompt":           sess.Prompt,
+			"version":          sess.Version,
+			"participants":     sess.GetParticipantList(),
 			"totalNotesNeeded": len(sess.Participants) - 1,
+			"welcome":          sess.Welcome,
+			"maxNoteLength":    sess.Settings.MaxNoteLength,
+			"minNoteChars":     sess.MinNoteChars,
+			"minNoteWords":     sess.MinNoteWords,
 		},
 	}
+	mh.addWritingTimer(sess, broadcast.Data)
+	mh.addStarters(sess, broadcast.Data)
 	mh.hub.BroadcastToSession(sess.ID, broadcast)
+	mh.notifyWritingStarted(sess)
+	mh.sendWritingStats(sess)
 
-	log.Printf("Writing phase started: session=%s", sess.Code)
+	mh.notifyBreakoutProgress(sess)
 }
 
-// handleSubmitNotes processes submitted gratitude notes
-func (mh *MessageHandler) handleSubmitNotes(client *Client, msg *Message) {
+// handleSubmitNotes processes submitted gratitude notes once they pass moderation
+func (mh *MessageHandler) handleSubmitNotes(client *Client, req *submitNotesRequest) {
 	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
 	if err != nil {
 		mh.sendError(client, "session not found")
 		return
 	}
-
-	notes, ok := msg.Data["notes"].([]interface{})
-	if !ok {
-		mh.sendError(client, "invalid notes format")
-		return
+	validate := func(content string) (string, error) {
+		return validateNoteContent(content, sess.Settings.MaxNoteLength)
 	}
 
-	// Add each note to the session
-	for _, noteData := range notes {
-		noteMap, ok := noteData.(map[string]interface{})
-		if !ok {
+	contents := make([]string, 0, len(req.Notes))
+	for i := range req.Notes {
+		if req.Notes[i].Content == "" {
 			continue
 		}
-
-		recipientID, ok := noteMap["recipientId"].(string)
-		if !ok {
-			continue
+		if !mh.cleaned(client, &req.Notes[i].Content, validate) {
+			return
 		}
+		contents = append(contents, req.Notes[i].Content)
+	}
+	mh.moderated(client, client.sessionID, moderation.KindNote, contents, func() {
+		mh.submitNotes(client, req)
+	})
+}
 
-		content, ok := noteMap["content"].(string)
-		if !ok || content == "" {
-			continue
-		}
+// submitNotes adds the client's notes to their session
+func (mh *MessageHandler) submitNotes(client *Client, req *submitNotesRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
 
-		// Validate and sanitise note content
-		validatedContent, err := validateNoteContent(content)
-		if err != nil {
-			log.Printf("note validation error: %v", err)
-			mh.sendError(client, err.Error())
-			return
-		}
+	if req.Notes == nil {
+		mh.sendError(client, "invalid notes format")
+		return
+	}
 
-		if err := sess.AddNote(client.userID, recipientID, validatedContent); err != nil {
-			log.Printf("error adding note: %v", err)
-			mh.sendError(client, err.Error())
-			return
+	// Add each note to the session; the timeline records how many arrived, never who wrote them
+	submitted, failed := 0, false
+	for _, note := range req.Notes {
+		if note.Content == "" {
+			continue
 		}
+		if !mh.addSubmittedNote(client, sess, note) {
+			failed = true
+			break
+		}
+		submitted++
+	}
+	if submitted > 0 {
+		total, _ := sess.NoteStats()
+		sess.RecordEvent(session.EventNotesSubmitted, "", map[string]interface{}{"count": submitted, "total": total})
+	}
+	if failed {
+		return
 	}
 
 	// Send confirmation
@@ -377,96 +902,367 @@ func (mh *MessageHandler) handleSubmitNotes(client *Client, msg *Message) {
 	}
 	client.SendMessage(response)
 
+	// Let the host see who is receiving notes and how writing is going (no content)
+	mh.sendReceivedNoteCounts(sess)
+	mh.scheduleWritingStats(sess)
+
 	// Check if all notes have been submitted
-	expectedNotes := len(sess.Participants) * (len(sess.Participants) - 1)
-	if len(sess.Notes) == expectedNotes {
+	if sess.AllNotesWritten() && !mh.countingDown(sess.ID) {
 		// Automatically transition to reading phase
-		if err := sess.TransitionToReading(); err != nil {
-			log.Printf("error transitioning to reading: %v", err)
-			return
+		mh.startReading(sess)
+	}
+}
+
+// addSubmittedNote validates one submitted note and adds it to the session, telling the
+// client why if it can't be added
+func (mh *MessageHandler) addSubmittedNote(client *Client, sess *session.Session, note submittedNote) bool {
+	recipientID, content := note.RecipientID, note.Content
+
+	// Validate and sanitise note content
+	validatedContent, err := validateNoteContent(content, sess.Settings.MaxNoteLength)
+	if err != nil {
+		client.logger().Info("Note failed validation", "err", err)
+		mh.sendError(client, err.Error())
+		return false
+	}
+
+	// Reject notes below the session's minimum length with a specific code
+	if err := checkNoteMinimum(validatedContent, sess.MinNoteChars, sess.MinNoteWords); err != nil {
+		mh.sendErrorCode(client, errorCodeFor(err), err.Error())
+		return false
+	}
+
+	// GIFs must come from the configured provider so notes can't embed arbitrary links
+	gifURL := note.GIFURL
+	if gifURL != "" && (len(gifURL) > maxGIFURLLength || !mh.gifs.Allows(gifURL)) {
+		mh.sendErrorCode(client, "invalid_gif", "gif must come from gif search")
+		return false
+	}
+
+	// Discourage copy-pasting the same note to everyone
+	duplicate := findDuplicateNote(sess, client.userID, recipientID, validatedContent)
+	if duplicate != nil && sess.DuplicatePolicy == session.DuplicateReject {
+		mh.sendErrorCode(client, "duplicate_note", "this note is very similar to one you wrote to someone else")
+		return false
+	}
+
+	// Authors may keep a note private instead of having it read aloud
+	private := note.Private
+	addNote := sess.AddNote
+	if private {
+		addNote = sess.AddPrivateNote
+	}
+
+	if err := addNote(client.userID, recipientID, validatedContent); err != nil {
+		client.logger().Warn("Failed to add note", "err", err)
+		mh.sendError(client, err.Error())
+		return false
+	}
+
+	if gifURL != "" {
+		if err := sess.AttachNoteGIF(client.userID, recipientID, gifURL); err != nil {
+			client.logger().Warn("Failed to attach GIF", "err", err)
 		}
+	}
 
-		// Broadcast phase change
-		currentReader := sess.GetCurrentReader()
-		broadcast := &Message{
-			Type: "phase_changed",
-			Data: map[string]interface{}{
-				"phase":         sess.Phase,
-				"currentReader": currentReader,
-			},
+	// Authors opt each note in to a public gratitude wall the host may publish later
+	if note.Shareable && !private {
+		if err := sess.MarkNoteShareable(client.userID, recipientID); err != nil {
+			client.logger().Warn("Failed to mark note shareable", "err", err)
 		}
-		mh.hub.BroadcastToSession(sess.ID, broadcast)
+	}
 
-		log.Printf("Reading phase started: session=%s", sess.Code)
+	if duplicate != nil {
+		mh.reportDuplicateNote(client, sess, recipientID, duplicate)
 	}
+	return true
 }
 
-// handleDrawNote draws a random note for the current reader
-func (mh *MessageHandler) handleDrawNote(client *Client, msg *Message) {
+// sendReceivedNoteCounts sends the host how many notes each participant will receive
+func (mh *MessageHandler) sendReceivedNoteCounts(sess *session.Session) {
+	counts := sess.GetReceivedNoteCounts()
+	message := &Message{
+		Type: "received_note_counts",
+		Data: map[string]interface{}{
+			"counts":       counts,
+			"participants": sess.GetParticipantList(),
+		},
+	}
+	mh.hub.SendToUser(sess.ID, sess.HostID, message)
+}
+
+// handleStartReading transitions a fully written session to reading phase (host only)
+// Reading normally starts automatically; this restarts it after an undo
+func (mh *MessageHandler) handleStartReading(client *Client, req *phaseActionRequest) {
 	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
 	if err != nil {
 		mh.sendError(client, "session not found")
 		return
 	}
 
-	// Verify it's the client's turn
-	currentReader := sess.GetCurrentReader()
-	if currentReader == nil || currentReader.ID != client.userID {
-		mh.sendError(client, "not your turn")
+	// Verify client is host
+	if client.userID != sess.HostID {
+		mh.sendError(client, "only host can start reading phase")
 		return
 	}
 
-	// Get available notes (not authored by or for the reader)
-	availableNotes := sess.GetAvailableNotesForReader(client.userID)
-	if len(availableNotes) == 0 {
-		// Current reader has no available notes - auto-advance turn
-		log.Printf("No available notes for reader: session=%s readerId=%s, auto-advancing turn", sess.Code, client.userID)
-		sess.AdvanceTurn()
-
-		// Check if session is complete
-		if sess.Phase == session.PhaseComplete {
-			// Prepare notes (anonymous - no author names)
-			anonymousNotes := []map[string]interface{}{}
-			for _, note := range sess.Notes {
-				anonymousNotes = append(anonymousNotes, map[string]interface{}{
-					"id":          note.ID,
-					"content":     note.Content,
-					"recipientId": note.RecipientID,
-				})
-			}
-
-			broadcast := &Message{
-				Type: "session_complete",
-				Data: map[string]interface{}{
-					"message": "All notes have been read. Thank you for participating!",
-					"notes":   anonymousNotes,
-				},
-			}
-			mh.hub.BroadcastToSession(sess.ID, broadcast)
-			log.Printf("Session complete: session=%s", sess.Code)
+	if !mh.checkVersion(client, sess, req.versioned) {
+		return
+	}
+
+	if mh.countingDown(sess.ID) {
+		mh.sendError(client, "phase change already in progress")
+		return
+	}
+
+	if sess.Phase != session.PhaseWriting {
+		mh.sendError(client, "can only start reading from writing phase")
+		return
+	}
+
+	if !sess.AllNotesWritten() {
+		mh.sendError(client, "not all notes have been written")
+		return
+	}
+
+	mh.startReading(sess)
+}
+
+// startReading counts down and then transitions the session to reading phase
+func (mh *MessageHandler) startReading(sess *session.Session) {
+	mh.runCountdown(sess, session.PhaseReading, func() {
+		if err := sess.TransitionToReading(); err != nil {
+			sessionLogger(sess).Error("Failed to start reading", "err", err)
 			return
 		}
 
-		// Broadcast turn change to all clients
-		newReader := sess.GetCurrentReader()
-		unreadNotes := sess.GetUnreadNotes()
-		totalNotes := len(sess.Notes)
+		// Broadcast phase change
+		currentReader := sess.GetCurrentReader()
 		broadcast := &Message{
-			Type: "turn_changed",
+			Type: "phase_changed",
 			Data: map[string]interface{}{
-				"reader":    newReader,
-				"remaining": len(unreadNotes),
-				"total":     totalNotes,
+				"phase":         sess.Phase,
+				"theme":         sess.Theme,
+				"prompt":        sess.Prompt,
+				"version":       sess.Version,
+				"currentReader": currentReader,
 			},
 		}
 		mh.hub.BroadcastToSession(sess.ID, broadcast)
-		log.Printf("Turn auto-advanced: session=%s newReaderId=%s", sess.Code, newReader.ID)
+		mh.notifyTurn(sess, currentReader)
+		mh.notifyBreakoutProgress(sess)
+
+		sessionLogger(sess).Info("Reading phase started")
+
+		mh.scheduleAutoDraw(sess)
+	})
+}
+
+// handleUndoTransition rolls the session back one phase shortly after a transition (host only)
+func (mh *MessageHandler) handleUndoTransition(client *Client, req *phaseActionRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	// Verify client is host
+	if client.userID != sess.HostID {
+		client.logger().Warn("Non-host tried to undo transition", "hostID", sess.HostID)
+		mh.sendError(client, "only host can undo a phase change")
+		return
+	}
+
+	if !mh.checkVersion(client, sess, req.versioned) {
+		return
+	}
+
+	// Undoing during a countdown calls off the phase change it was counting down to
+	if mh.cancelCountdown(sess) {
+		return
+	}
+
+	phase, err := sess.UndoTransition()
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+	mh.cancelAutoRun(sess)
+	if phase == session.PhaseJoining {
+		mh.scheduleAutoStart(sess)
+	}
+
+	// Broadcast restored phase to all clients
+	broadcast := &Message{
+		Type: "phase_changed",
+		Data: map[string]interface{}{
+			"phase":            phase,
+			"version":          sess.Version,
+			"theme":            sess.Theme,
+			"prompt":           sess.Prompt,
+			"participants":     sess.GetParticipantList(),
+			"totalNotesNeeded": len(sess.Participants) - 1,
+			"maxNoteLength":    sess.Settings.MaxNoteLength,
+			"minNoteChars":     sess.MinNoteChars,
+			"minNoteWords":     sess.MinNoteWords,
+			"undone":           true,
+		},
+	}
+	mh.addStarters(sess, broadcast.Data)
+	mh.hub.BroadcastToSession(sess.ID, broadcast)
+	mh.refreshOutdated(sess)
+
+	sessionLogger(sess).Info("Phase transition undone", "phase", phase)
+}
+
+// runCountdown broadcasts a phase_starting_in tick each second for the session's
+// configured countdown, then runs transition on the session's actor.
+// Without a countdown the transition runs immediately.
+// The countdown stops early if it's cancelled, the session leaves the phase it
+// started in, or the session is removed.
+func (mh *MessageHandler) runCountdown(sess *session.Session, phase session.Phase, transition func()) {
+	seconds := sess.Countdown
+	if seconds <= 0 {
+		transition()
+		return
+	}
+
+	stop := make(chan struct{})
+	mh.countdowns.set(sess.ID, stop)
+	from := sess.Phase
+	sessionLogger(sess).Info("Countdown started", "phase", phase, "seconds", seconds)
+
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for remaining := seconds; remaining > 0; remaining-- {
+			if !mh.countdownCurrent(sess, stop, from) {
+				return
+			}
+			mh.hub.BroadcastToSession(sess.ID, &Message{
+				Type: "phase_starting_in",
+				Data: map[string]interface{}{
+					"phase":   phase,
+					"seconds": remaining,
+				},
+			})
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+
+		mh.hub.ScheduleSession(sess.ID, func() {
+			if !mh.countdownCurrent(sess, stop, from) {
+				return
+			}
+			mh.countdowns.delete(sess.ID)
+			transition()
+		})
+	}()
+}
+
+// countdownCurrent reports whether a countdown should carry on: it hasn't been
+// cancelled or replaced, the session still exists and is still in the phase it
+// was in when the countdown started
+func (mh *MessageHandler) countdownCurrent(sess *session.Session, stop chan struct{}, from session.Phase) bool {
+	if mh.countdowns.get(sess.ID) != stop {
+		return false
+	}
+	if _, err := mh.sessionManager.GetSessionByID(sess.ID); err != nil || sess.GetPhase() != from {
+		// Only clear this countdown, not one started since
+		mh.countdowns.update(sess.ID, func(current chan struct{}) chan struct{} {
+			if current == stop {
+				return nil
+			}
+			return current
+		})
+		return false
+	}
+	return true
+}
+
+// countingDown reports whether the session has a countdown to a phase change running
+func (mh *MessageHandler) countingDown(sessionID string) bool {
+	return mh.countdowns.get(sessionID) != nil
+}
+
+// cancelCountdown stops the session's running countdown, if any, and tells everyone
+// Reports whether there was one
+func (mh *MessageHandler) cancelCountdown(sess *session.Session) bool {
+	stop := mh.countdowns.get(sess.ID)
+	if stop == nil {
+		return false
+	}
+	mh.countdowns.delete(sess.ID)
+	close(stop)
+
+	mh.hub.BroadcastToSession(sess.ID, &Message{Type: "countdown_cancelled"})
+	sessionLogger(sess).Info("Countdown cancelled")
+	return true
+}
+
+// handleDrawNote draws a random note for the current reader
+func (mh *MessageHandler) handleDrawNote(client *Client, req *phaseActionRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	// Verify it's the client's turn
+	currentReader := sess.GetCurrentReader()
+	if currentReader == nil || currentReader.ID != client.userID {
+		mh.sendError(client, "not your turn")
 		return
 	}
 
+	if !mh.checkVersion(client, sess, req.versioned) {
+		return
+	}
+
+	mh.drawNote(sess, client.userID)
+}
+
+// drawNote picks a random available note for the reader and broadcasts it.
+// If the reader has nothing to draw the turn advances instead and nil is returned.
+func (mh *MessageHandler) drawNote(sess *session.Session, readerID string) *session.Note {
+	// Get available notes (not authored by or for the reader)
+	availableNotes := sess.GetAvailableNotesForReader(readerID)
+	if len(availableNotes) == 0 {
+		// Current reader has no available notes - auto-advance turn
+		sessionLogger(sess).Info("No available notes for reader, auto-advancing turn", "readerID", readerID)
+		mh.advanceTurn(sess)
+		return nil
+	}
+
 	// Pick a random note
 	randomNote := availableNotes[rand.Intn(len(availableNotes))]
 
+	// Remember whether the recipient is here to hear it, so missed notes can be resent
+	connected := mh.hub.IsUserConnected(sess.ID, randomNote.RecipientID)
+	if err := sess.RecordRecipientPresence(randomNote.ID, connected); err != nil {
+		sessionLogger(sess).Warn("Failed to record recipient presence", "err", err)
+	}
+
+	// Time how long it stays on screen, for the host's reading-pace summary
+	if err := sess.RecordNoteDrawn(randomNote.ID); err != nil {
+		sessionLogger(sess).Warn("Failed to record note drawn", "err", err)
+	}
+
+	// Remember who heard it, so the host knows which notes to resend to people who dropped
+	if err := sess.RecordAttendance(randomNote.ID, mh.hub.ConnectedUserIDs(sess.ID)); err != nil {
+		sessionLogger(sess).Warn("Failed to record attendance", "err", err)
+	}
+	sess.RecordEvent(session.EventNoteDrawn, readerID, map[string]interface{}{
+		"noteId":             randomNote.ID,
+		"recipientId":        randomNote.RecipientID,
+		"recipientConnected": connected,
+	})
+
 	// Get recipient name
 	var recipientName string
 	if recipient, exists := sess.Participants[randomNote.RecipientID]; exists {
@@ -475,26 +1271,32 @@ func (mh *MessageHandler) handleDrawNote(client *Client, msg *Message) {
 
 	// Send note to all clients
 	unreadNotes := sess.GetUnreadNotes()
-	totalNotes := len(sess.Notes)
+	totalNotes := sess.GetReadAloudNoteCount()
+	drawn := map[string]interface{}{
+		"id":        randomNote.ID,
+		"content":   randomNote.Content,
+		"recipient": recipientName,
+		"gifUrl":    randomNote.GIFURL,
+	}
+	addAuthor(sess, randomNote, drawn)
 	broadcast := &Message{
 		Type: "note_drawn",
 		Data: map[string]interface{}{
-			"note": map[string]interface{}{
-				"id":        randomNote.ID,
-				"content":   randomNote.Content,
-				"recipient": recipientName,
-			},
+			"note":      drawn,
 			"remaining": len(unreadNotes) - 1,
 			"total":     totalNotes,
 		},
 	}
 	mh.hub.BroadcastToSession(sess.ID, broadcast)
 
-	log.Printf("Note drawn: session=%s readerId=%s", sess.Code, client.userID)
+	sessionLogger(sess).Info("Note drawn", "readerID", readerID)
+
+	mh.scheduleAutoRead(sess, randomNote.ID)
+	return randomNote
 }
 
 // handleNoteRead marks the current note as read and advances turn
-func (mh *MessageHandler) handleNoteRead(client *Client, msg *Message) {
+func (mh *MessageHandler) handleNoteRead(client *Client, req *noteReadRequest) {
 	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
 	if err != nil {
 		mh.sendError(client, "session not found")
@@ -508,65 +1310,177 @@ func (mh *MessageHandler) handleNoteRead(client *Client, msg *Message) {
 		return
 	}
 
+	if !mh.checkVersion(client, sess, req.versioned) {
+		return
+	}
+
 	// Get the note ID from the message
-	noteID, ok := msg.Data["noteId"].(string)
-	if !ok {
+	noteID := req.NoteID
+	if noteID == "" {
 		// If no noteID provided, we can't mark it as read
 		// This shouldn't happen but we'll handle it gracefully
-		log.Printf("no noteId provided in note_read message")
+		client.logger().Warn("No noteId provided in note_read message")
 	} else {
 		// Mark note as read
 		if err := sess.MarkNoteAsRead(noteID); err != nil {
-			log.Printf("error marking note as read: %v", err)
+			client.logger().Warn("Failed to mark note as read", "noteID", noteID, "err", err)
 		}
 	}
 
-	// Advance turn
+	mh.advanceTurn(sess)
+}
+
+// advanceTurn moves to the next reader and broadcasts the turn change,
+// or completes the session if every note has been read
+func (mh *MessageHandler) advanceTurn(sess *session.Session) {
 	sess.AdvanceTurn()
 
 	// Check if session is complete
 	if sess.Phase == session.PhaseComplete {
-		// Prepare notes (anonymous - no author names)
-		anonymousNotes := []map[string]interface{}{}
-		for _, note := range sess.Notes {
-			anonymousNotes = append(anonymousNotes, map[string]interface{}{
-				"id":          note.ID,
-				"content":     note.Content,
-				"recipientId": note.RecipientID,
-			})
-		}
-
-		broadcast := &Message{
-			Type: "session_complete",
-			Data: map[string]interface{}{
-				"message": "All notes have been read. Thank you for participating!",
-				"notes":   anonymousNotes,
-			},
-		}
-		mh.hub.BroadcastToSession(sess.ID, broadcast)
-		log.Printf("Session complete: session=%s", sess.Code)
+		mh.broadcastSessionComplete(sess)
 		return
 	}
 
 	// Send turn change to all clients
 	newReader := sess.GetCurrentReader()
 	unreadNotes := sess.GetUnreadNotes()
-	totalNotes := len(sess.Notes)
+	totalNotes := sess.GetReadAloudNoteCount()
 	broadcast := &Message{
 		Type: "turn_changed",
 		Data: map[string]interface{}{
 			"reader":    newReader,
 			"remaining": len(unreadNotes),
 			"total":     totalNotes,
+			"version":   sess.Version,
 		},
 	}
 	mh.hub.BroadcastToSession(sess.ID, broadcast)
+	mh.notifyTurn(sess, newReader)
+
+	sessionLogger(sess).Info("Turn advanced", "readerID", newReader.ID)
+
+	mh.scheduleAutoDraw(sess)
+}
+
+// broadcastSessionComplete sends every client the read-aloud notes plus any private
+// notes addressed to them and any they missed while disconnected (anonymous - no author names)
+func (mh *MessageHandler) broadcastSessionComplete(sess *session.Session) {
+	mh.retainExports(sess)
+	for _, participant := range sess.GetParticipantList() {
+		message := sessionCompleteMessage(sess, participant.ID)
+		mh.addExportToken(sess, participant.ID, message.Data)
+		mh.hub.SendToUser(sess.ID, participant.ID, message)
+	}
+	sessionLogger(sess).Info("Session complete")
+
+	mh.analytics.RecordSessionCompleted(len(sess.Participants))
+	mh.recordHostCircle(sess, true)
+	mh.notifySessionComplete(sess)
+	mh.autoRunSteps.delete(sess.ID)
+
+	mh.notifyBreakoutProgress(sess)
+	mh.refreshOutdated(sess)
+}
+
+// sessionCompleteMessage builds the session_complete message for one participant
+func sessionCompleteMessage(sess *session.Session, participantID string) *Message {
+	notes := []map[string]interface{}{}
+	for _, note := range sess.Notes {
+		if note.Private && note.RecipientID != participantID {
+			continue
+		}
+		entry := map[string]interface{}{
+			"id":          note.ID,
+			"content":     note.Content,
+			"recipientId": note.RecipientID,
+			"private":     note.Private,
+			"gifUrl":      note.GIFURL,
+		}
+		if len(note.Reactions) > 0 {
+			entry["reactions"] = note.Reactions
+		}
+		// The host's archive shows who was there for each note and which may go on a wall
+		if participantID == sess.HostID && !note.Private {
+			entry["attendance"] = note.Attendance
+			entry["shareable"] = note.Shareable
+		}
+		addAuthor(sess, note, entry)
+		notes = append(notes, entry)
+	}
+
+	// Notes read aloud while this participant was disconnected
+	missedNotes := []map[string]interface{}{}
+	for _, note := range sess.GetMissedNotes(participantID) {
+		entry := map[string]interface{}{
+			"id":          note.ID,
+			"content":     note.Content,
+			"recipientId": note.RecipientID,
+			"gifUrl":      note.GIFURL,
+		}
+		addAuthor(sess, note, entry)
+		missedNotes = append(missedNotes, entry)
+	}
+
+	message := &Message{
+		Type: "session_complete",
+		Data: map[string]interface{}{
+			"message":     "All notes have been read. Thank you for participating!",
+			"title":       sess.Title,
+			"notes":       notes,
+			"missedNotes": missedNotes,
+			"rateSession": sess.RatingsEnabled,
+			"version":     sess.Version,
+			"theme":       sess.Theme,
+		},
+	}
+	// Pacing helps the facilitator plan how long future circles need
+	if participantID == sess.HostID {
+		message.Data["readingPace"] = sess.GetReadingPace()
+	}
+	return message
+}
+
+// handleSubmitRating records an anonymous 1-5 rating after the session completes
+func (mh *MessageHandler) handleSubmitRating(client *Client, req *submitRatingRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if req.Score == nil {
+		mh.sendError(client, "score required")
+		return
+	}
+
+	if err := sess.SubmitRating(client.userID, *req.Score); err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+	mh.analytics.RecordRating(*req.Score)
+
+	response := &Message{
+		Type: "rating_submitted",
+		Data: map[string]interface{}{
+			"success": true,
+		},
+	}
+	client.SendMessage(response)
+
+	// Keep the host's aggregate up to date (no individual ratings are shared)
+	summary := &Message{
+		Type: "rating_summary",
+		Data: map[string]interface{}{
+			"ratings": sess.GetRatingSummary(),
+		},
+	}
+	mh.hub.SendToUser(sess.ID, sess.HostID, summary)
 
-	log.Printf("Turn advanced: session=%s newReaderId=%s", sess.Code, newReader.ID)
+	client.logger().Info("Rating submitted")
 }
 
 // handleRemoveParticipant removes a participant from the session (host only)
-func (mh *MessageHandler) handleRemoveParticipant(client *Client, msg *Message) {
+func (mh *MessageHandler) handleRemoveParticipant(client *Client, req *participantRequest) {
 	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
 	if err != nil {
 		mh.sendError(client, "session not found")
@@ -575,14 +1489,14 @@ func (mh *MessageHandler) handleRemoveParticipant(client *Client, msg *Message)
 
 	// Verify client is host
 	if client.userID != sess.HostID {
-		log.Printf("Non-host tried to remove participant: userID=%s hostID=%s", client.userID, sess.HostID)
+		client.logger().Warn("Non-host tried to remove participant", "hostID", sess.HostID)
 		mh.sendError(client, "only host can remove participants")
 		return
 	}
 
 	// Get participant ID to remove
-	participantID, ok := msg.Data["participantId"].(string)
-	if !ok || participantID == "" {
+	participantID := req.ParticipantID
+	if participantID == "" {
 		mh.sendError(client, "participant ID required")
 		return
 	}
@@ -593,35 +1507,288 @@ func (mh *MessageHandler) handleRemoveParticipant(client *Client, msg *Message)
 		return
 	}
 
-	// Remove participant from session
-	participant, err := sess.RemoveParticipant(participantID)
+	participant, err := mh.removeParticipant(sess, participantID, "host")
 	if err != nil {
 		mh.sendError(client, err.Error())
 		return
 	}
 
+	client.logger().Info("Participant removed by host", "participantID", participant.ID)
+}
+
+// removeParticipant takes a participant out of the session for good on the say-so of
+// by ("host" or "admin"), telling them and everyone else; a removed host is replaced
+func (mh *MessageHandler) removeParticipant(sess *session.Session, participantID, by string) (*session.Participant, error) {
+	wasHost := participantID == sess.HostID
+
+	participant, err := sess.KickParticipant(participantID, by)
+	if err != nil {
+		return nil, err
+	}
+	sess.RevokeRejoin(participantID)
+
 	// Send kicked message to the removed user
+	reason := "You have been removed from the session by the host"
+	if by == "admin" {
+		reason = "You have been removed from the session by an administrator"
+	}
 	kickedMsg := &Message{
 		Type: "kicked",
 		Data: map[string]interface{}{
-			"message": "You have been removed from the session by the host",
+			"message": reason,
 		},
 	}
 	mh.hub.SendToUser(sess.ID, participantID, kickedMsg)
 
+	if wasHost {
+		if newHost := sess.ReassignHost(); newHost != nil {
+			sessionLogger(sess).Info("New host assigned", "userID", newHost.ID)
+		}
+	}
+
+	if len(sess.Participants) == 0 {
+		if sess.AwaitingRejoin() {
+			mh.scheduleEmptyCleanup(sess)
+		} else {
+			mh.removeEmptySession(sess)
+		}
+		return participant, nil
+	}
+
 	// Broadcast participant left to remaining clients
 	broadcast := &Message{
 		Type: "participant_left",
 		Data: map[string]interface{}{
 			"participant":  participant,
 			"participants": sess.GetParticipantList(),
-			"wasHost":      false,
+			"wasHost":      wasHost,
 			"wasRemoved":   true,
 		},
 	}
 	mh.hub.BroadcastToSession(sess.ID, broadcast)
+	return participant, nil
+}
+
+// handleRenameParticipant changes a participant's display name (host only) once it passes moderation
+func (mh *MessageHandler) handleRenameParticipant(client *Client, req *renameParticipantRequest) {
+	if req.UserName != "" && !mh.cleaned(client, &req.UserName, validateUserName) {
+		return
+	}
+
+	mh.moderated(client, client.sessionID, moderation.KindName, []string{req.UserName}, func() {
+		mh.renameParticipant(client, req)
+	})
+}
+
+// renameParticipant changes a participant's display name (host only)
+func (mh *MessageHandler) renameParticipant(client *Client, req *renameParticipantRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	// Verify client is host
+	if client.userID != sess.HostID {
+		client.logger().Warn("Non-host tried to rename participant", "hostID", sess.HostID)
+		mh.sendError(client, "only host can rename participants")
+		return
+	}
+
+	// Get participant ID to rename
+	participantID, userName := req.ParticipantID, req.UserName
+	if participantID == "" {
+		mh.sendError(client, "participant ID required")
+		return
+	}
+
+	if userName == "" {
+		mh.sendError(client, "user name required")
+		return
+	}
+
+	// Validate and sanitise user name
+	validatedName, err := validateUserName(userName)
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	participant, err := sess.RenameParticipant(participantID, validatedName)
+	if err != nil {
+		mh.sendErrorCode(client, errorCodeFor(err), err.Error())
+		return
+	}
+
+	// Broadcast rename to all clients
+	broadcast := &Message{
+		Type: "participant_renamed",
+		Data: map[string]interface{}{
+			"participant":  participant,
+			"participants": sess.GetParticipantList(),
+			"byHost":       true,
+		},
+	}
+	mh.hub.BroadcastToSession(sess.ID, broadcast)
+
+	client.logger().Info("Participant renamed by host", "participantID", participant.ID)
+}
+
+// handleChangeName lets a participant fix their own name during the joining phase, once
+// the new name passes moderation
+func (mh *MessageHandler) handleChangeName(client *Client, req *changeNameRequest) {
+	if req.UserName != "" && !mh.cleaned(client, &req.UserName, validateUserName) {
+		return
+	}
+
+	mh.moderated(client, client.sessionID, moderation.KindName, []string{req.UserName}, func() {
+		mh.changeName(client, req)
+	})
+}
+
+// changeName changes the client's own display name
+func (mh *MessageHandler) changeName(client *Client, req *changeNameRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	userName := req.UserName
+	if userName == "" {
+		mh.sendError(client, "user name required")
+		return
+	}
+
+	// Validate and sanitise user name
+	validatedName, err := validateUserName(userName)
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	participant, err := sess.ChangeName(client.userID, validatedName)
+	if err != nil {
+		mh.sendErrorCode(client, errorCodeFor(err), err.Error())
+		return
+	}
+
+	client.userName = participant.Name
+
+	// Broadcast rename to all clients
+	broadcast := &Message{
+		Type: "participant_renamed",
+		Data: map[string]interface{}{
+			"participant":  participant,
+			"participants": sess.GetParticipantList(),
+			"byHost":       false,
+		},
+	}
+	mh.hub.BroadcastToSession(sess.ID, broadcast)
 
-	log.Printf("Participant removed by host: session=%s userId=%s", sess.Code, participant.ID)
+	client.logger().Info("Participant changed name")
+}
+
+// handleSetSessionTitle sets the session's display title (host only) once it passes moderation
+func (mh *MessageHandler) handleSetSessionTitle(client *Client, req *setSessionTitleRequest) {
+	if req.Title == nil {
+		mh.sendError(client, "title required")
+		return
+	}
+	if !mh.cleaned(client, req.Title, validateSessionTitle) {
+		return
+	}
+
+	mh.moderated(client, client.sessionID, moderation.KindSession, []string{*req.Title}, func() {
+		mh.setSessionTitle(client, req)
+	})
+}
+
+// setSessionTitle sets the session's display title (host only)
+func (mh *MessageHandler) setSessionTitle(client *Client, req *setSessionTitleRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	// Verify client is host
+	if client.userID != sess.HostID {
+		client.logger().Warn("Non-host tried to set session title", "hostID", sess.HostID)
+		mh.sendError(client, "only host can set the session title")
+		return
+	}
+
+	// Validate and sanitise title
+	validatedTitle, err := validateSessionTitle(*req.Title)
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	sess.SetTitle(validatedTitle)
+
+	// Broadcast new title to all clients
+	broadcast := &Message{
+		Type: "session_title_changed",
+		Data: map[string]interface{}{
+			"title": validatedTitle,
+		},
+	}
+	mh.hub.BroadcastToSession(sess.ID, broadcast)
+
+	client.logger().Info("Session title set")
+}
+
+// handleSetPrompt changes the writing prompt shown to everyone (host only) once it passes moderation
+func (mh *MessageHandler) handleSetPrompt(client *Client, req *setPromptRequest) {
+	if req.Prompt == nil {
+		mh.sendError(client, "prompt required")
+		return
+	}
+	if !mh.cleaned(client, req.Prompt, validatePrompt) {
+		return
+	}
+
+	mh.moderated(client, client.sessionID, moderation.KindSession, []string{*req.Prompt}, func() {
+		mh.setPrompt(client, req)
+	})
+}
+
+// setPrompt changes the writing prompt shown to everyone (host only)
+func (mh *MessageHandler) setPrompt(client *Client, req *setPromptRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	// Verify client is host
+	if client.userID != sess.HostID {
+		client.logger().Warn("Non-host tried to set prompt", "hostID", sess.HostID)
+		mh.sendError(client, "only host can set the writing prompt")
+		return
+	}
+
+	// Validate and sanitise prompt
+	validatedPrompt, err := validatePrompt(*req.Prompt)
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	sess.SetPrompt(validatedPrompt)
+
+	// Broadcast new prompt to all clients
+	broadcast := &Message{
+		Type: "prompt_changed",
+		Data: map[string]interface{}{
+			"prompt": validatedPrompt,
+		},
+	}
+	mh.hub.BroadcastToSession(sess.ID, broadcast)
+
+	client.logger().Info("Writing prompt set")
 }
 
 // sendError sends an error message to a client
@@ -633,5 +1800,42 @@ func (mh *MessageHandler) sendError(client *Client, message string) {
 		},
 	}
 	client.SendMessage(response)
-	log.Printf("Error sent to client: %s", message)
+	client.logger().Info("Error sent to client", "error", message)
+}
+
+// sendErrorCode sends an error message with a machine-readable code to a client
+func (mh *MessageHandler) sendErrorCode(client *Client, code, message string) {
+	response := &Message{
+		Type: "error",
+		Data: map[string]interface{}{
+			"code":    code,
+			"message": message,
+		},
+	}
+	client.SendMessage(response)
+	client.logger().Info("Error sent to client", "code", code, "error", message)
+}
+
+// errorCodeFor maps validation errors to machine-readable error codes
+func errorCodeFor(err error) string {
+	switch {
+	case errors.Is(err, ErrNoteTooShort):
+		return "note_too_short"
+	case errors.Is(err, ErrContentRejected):
+		return "content_rejected"
+	case errors.Is(err, ErrModerationUnavailable):
+		return "moderation_unavailable"
+	case errors.Is(err, session.ErrDuplicateName):
+		return "name_taken"
+	case errors.As(err, new(*session.SessionFullError)):
+		return "session_full"
+	case errors.Is(err, invites.ErrExpired):
+		return "invite_expired"
+	case errors.Is(err, invites.ErrInvalid):
+		return "invite_invalid"
+	case errors.Is(err, ErrInviteRequired):
+		return "invite_required"
+	default:
+		return "invalid_request"
+	}
 }
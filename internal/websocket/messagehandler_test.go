@@ -0,0 +1,79 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestWritingPrompt(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess, _, err := mh.newSession(&createSessionRequest{UserName: "Host", Prompt: "  Thank someone for something this sprint  "}, "", "")
+	if err != nil {
+		t.Fatalf("Expected session to be created: %v", err)
+	}
+	if sess.Prompt != "Thank someone for something this sprint" {
+		t.Errorf("Expected trimmed prompt, got %q", sess.Prompt)
+	}
+
+	if _, _, err := mh.newSession(&createSessionRequest{Prompt: strings.Repeat("a", maxPromptLength+1)}, "", ""); err == nil {
+		t.Error("Expected an overlong prompt to be refused")
+	}
+
+	alice, _ := sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.HandleMessage(aliceClient, &Message{Type: "set_prompt", Data: map[string]interface{}{"prompt": "Mine"}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected non-host set_prompt to be refused, got %+v", reply)
+	}
+
+	mh.HandleMessage(host, &Message{Type: "set_prompt", Data: map[string]interface{}{"prompt": "Thank someone who helped you learn"}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "prompt_changed" || reply.Data["prompt"] != "Thank someone who helped you learn" {
+		t.Errorf("Expected prompt_changed broadcast, got %+v", reply)
+	}
+	nextMessage(t, host)
+
+	mh.HandleMessage(host, &Message{Type: "start_writing", Data: map[string]interface{}{}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "phase_changed" || reply.Data["prompt"] != "Thank someone who helped you learn" {
+		t.Errorf("Expected phase_changed to carry the prompt, got %+v", reply)
+	}
+}
+
+func TestCountdownCancelled(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	sess.SetCountdown(1)
+	host := newTestClient(hub, sess.ID, sess.HostID)
+
+	transitioned := make(chan struct{}, 1)
+	mh.runCountdown(sess, session.PhaseWriting, func() { transitioned <- struct{}{} })
+	if tick := nextMessage(t, host); tick.Type != "phase_starting_in" {
+		t.Fatalf("Expected a countdown tick, got %+v", tick)
+	}
+
+	if !mh.cancelCountdown(sess) {
+		t.Fatal("Expected a running countdown to cancel")
+	}
+	if msg := nextMessage(t, host); msg.Type != "countdown_cancelled" {
+		t.Errorf("Expected countdown_cancelled, got %+v", msg)
+	}
+	if mh.countingDown(sess.ID) {
+		t.Error("Expected the countdown to be cleared")
+	}
+
+	select {
+	case <-transitioned:
+		t.Error("Expected a cancelled countdown not to change phase")
+	case <-time.After(1500 * time.Millisecond):
+	}
+}
@@ -0,0 +1,109 @@
+// ABOUTME: Send-queue metrics and the policy applied when a client's send buffer fills
+// ABOUTME: Exposes buffer occupancy, drop counts and hub queue depth for operators
+package websocket
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// DropPolicy decides what happens when a message can't fit in a client's send buffer
+type DropPolicy string
+
+const (
+	DropPolicyDisconnect DropPolicy = "disconnect"  // Close the slow client's connection
+	DropPolicyOldest     DropPolicy = "drop-oldest" // Discard the oldest queued message to make room
+	DropPolicyNewest     DropPolicy = "drop-newest" // Discard the message being sent
+)
+
+// ParseDropPolicy validates a drop policy name
+func ParseDropPolicy(value string) (DropPolicy, error) {
+	switch policy := DropPolicy(value); policy {
+	case DropPolicyDisconnect, DropPolicyOldest, DropPolicyNewest:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown send queue policy: %s", value)
+	}
+}
+
+// hubCounters are cumulative send-queue counters shared by all clients of a hub
+type hubCounters struct {
+	dropped             atomic.Uint64 // Messages discarded by a drop policy
+	overflowDisconnects atomic.Uint64 // Clients disconnected because their buffer filled
+	rateLimited         atomic.Uint64 // Inbound messages refused by a rate limit
+	ackResends          atomic.Uint64 // Critical messages resent for want of an acknowledgement
+	ackFailures         atomic.Uint64 // Critical messages never acknowledged despite resends
+}
+
+// ClientQueueMetrics describes one client's send buffer
+type ClientQueueMetrics struct {
+	SessionID  string `json:"sessionId"`
+	UserID     string `json:"userId"`
+	Queued     int    `json:"queued"`
+	Capacity   int    `json:"capacity"`
+	Dropped    uint64 `json:"dropped"`
+	MissedAcks uint64 `json:"missedAcks"` // Critical messages the client never acknowledged
+}
+
+// HubMetrics is a snapshot of the hub's send queues
+type HubMetrics struct {
+	DropPolicy           DropPolicy           `json:"dropPolicy"`
+	ProcessQueueDepth    int                  `json:"processQueueDepth"`
+	ProcessQueueCapacity int                  `json:"processQueueCapacity"`
+	TaskQueueDepth       int                  `json:"taskQueueDepth"`
+	SessionActors        int                  `json:"sessionActors"`
+	ActorQueueDepth      int                  `json:"actorQueueDepth"` // Tasks waiting across every session's actor
+	Dropped              uint64               `json:"dropped"`
+	OverflowDisconnects  uint64               `json:"overflowDisconnects"`
+	RateLimited          uint64               `json:"rateLimited"`
+	RelayDropped         uint64               `json:"relayDropped"` // Broadcasts the backplane failed to relay
+	AckResends           uint64               `json:"ackResends"`
+	AckFailures          uint64               `json:"ackFailures"`
+	Clients              []ClientQueueMetrics `json:"clients"`
+}
+
+// SetDropPolicy sets what happens when a client's send buffer is full; call before Run
+func (h *Hub) SetDropPolicy(policy DropPolicy) {
+	h.dropPolicy = policy
+}
+
+// Metrics returns a snapshot of send-queue occupancy and drop counters
+// Safe to call from any goroutine
+func (h *Hub) Metrics() HubMetrics {
+	metrics := HubMetrics{
+		DropPolicy:           h.dropPolicy,
+		ProcessQueueDepth:    len(h.process),
+		ProcessQueueCapacity: cap(h.process),
+		TaskQueueDepth:       len(h.tasks),
+		Dropped:              h.counters.dropped.Load(),
+		OverflowDisconnects:  h.counters.overflowDisconnects.Load(),
+		RateLimited:          h.counters.rateLimited.Load(),
+		RelayDropped:         h.backplane.Dropped(),
+		AckResends:           h.counters.ackResends.Load(),
+		AckFailures:          h.counters.ackFailures.Load(),
+		Clients:              []ClientQueueMetrics{},
+	}
+	metrics.SessionActors, metrics.ActorQueueDepth = h.actorQueues()
+
+	h.clientsMu.RLock()
+	for sessionID, sessionClients := range h.clients {
+		for client := range sessionClients {
+			metrics.Clients = append(metrics.Clients, ClientQueueMetrics{
+				SessionID:  sessionID,
+				UserID:     client.userID,
+				Queued:     len(client.send),
+				Capacity:   cap(client.send),
+				Dropped:    client.dropped.Load(),
+				MissedAcks: client.missedAcks(),
+			})
+		}
+	}
+	h.clientsMu.RUnlock()
+
+	// Fullest buffers first
+	sort.Slice(metrics.Clients, func(i, j int) bool {
+		return metrics.Clients[i].Queued > metrics.Clients[j].Queued
+	})
+	return metrics
+}
@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	gorillaws "github.com/gorilla/websocket"
+)
+
+// fillClient creates a client with a small buffer and sends more messages than fit
+func fillClient(t *testing.T, policy DropPolicy) (*Hub, *Client) {
+	t.Helper()
+
+	hub := NewHub(nil)
+	hub.SetDropPolicy(policy)
+	client := newTestClient(hub, "session-1", "slow")
+	client.send = make(chan []byte, 2)
+
+	for i := 0; i < 3; i++ {
+		client.SendRaw([]byte(fmt.Sprintf("%d", i)))
+	}
+	return hub, client
+}
+
+func TestDropPolicies(t *testing.T) {
+	t.Run("drop-newest", func(t *testing.T) {
+		hub, client := fillClient(t, DropPolicyNewest)
+		if got := string(<-client.send) + string(<-client.send); got != "01" {
+			t.Errorf("Expected oldest messages kept, got %s", got)
+		}
+		if hub.Metrics().Dropped != 1 {
+			t.Errorf("Expected 1 drop, got %d", hub.Metrics().Dropped)
+		}
+	})
+
+	t.Run("drop-oldest", func(t *testing.T) {
+		hub, client := fillClient(t, DropPolicyOldest)
+		if got := string(<-client.send) + string(<-client.send); got != "12" {
+			t.Errorf("Expected newest messages kept, got %s", got)
+		}
+		if client.dropped.Load() != 1 {
+			t.Errorf("Expected 1 client drop, got %d", client.dropped.Load())
+		}
+		if hub.Metrics().Dropped != 1 {
+			t.Errorf("Expected 1 drop, got %d", hub.Metrics().Dropped)
+		}
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		hub, client := fillClient(t, DropPolicyDisconnect)
+		if !client.sendClosed {
+			t.Error("Expected slow client to be disconnected")
+		}
+		if _, open := <-client.send; open {
+			t.Error("Expected the backlog to be discarded so the close frame goes out first")
+		}
+		if code := binary.BigEndian.Uint16(client.closeFrame); code != gorillaws.CloseTryAgainLater {
+			t.Errorf("Expected close code %d, got %d", gorillaws.CloseTryAgainLater, code)
+		}
+		if hub.Metrics().OverflowDisconnects != 1 {
+			t.Errorf("Expected 1 overflow disconnect, got %d", hub.Metrics().OverflowDisconnects)
+		}
+	})
+}
+
+func TestHubMetrics(t *testing.T) {
+	hub := NewHub(nil)
+	busy := newTestClient(hub, "session-1", "busy")
+	newTestClient(hub, "session-1", "idle")
+
+	busy.SendRaw([]byte("queued"))
+
+	metrics := hub.Metrics()
+	if metrics.DropPolicy != DropPolicyDisconnect {
+		t.Errorf("Expected default policy disconnect, got %s", metrics.DropPolicy)
+	}
+	if len(metrics.Clients) != 2 {
+		t.Fatalf("Expected 2 clients, got %d", len(metrics.Clients))
+	}
+	if metrics.Clients[0].UserID != "busy" || metrics.Clients[0].Queued != 1 {
+		t.Errorf("Expected fullest buffer first, got %+v", metrics.Clients[0])
+	}
+	if metrics.ProcessQueueCapacity != cap(hub.process) {
+		t.Errorf("Expected process capacity %d, got %d", cap(hub.process), metrics.ProcessQueueCapacity)
+	}
+}
+
+func TestParseDropPolicy(t *testing.T) {
+	if _, err := ParseDropPolicy("drop-oldest"); err != nil {
+		t.Errorf("Expected drop-oldest to parse, got %v", err)
+	}
+	if _, err := ParseDropPolicy("block"); err == nil {
+		t.Error("Expected unknown policy to fail")
+	}
+}
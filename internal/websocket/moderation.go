@@ -0,0 +1,97 @@
+// ABOUTME: Checks notes, names and session text against the deployment's content moderation filter
+// ABOUTME: Checks run off the hub and session goroutines, since a moderation service can be slow
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/moderation"
+)
+
+// moderationTimeout bounds the checks for one message
+const moderationTimeout = 5 * time.Second
+
+var (
+	ErrContentRejected       = errors.New("rejected by content policy")
+	ErrModerationUnavailable = errors.New("content could not be checked, please try again")
+)
+
+// SetModeration sets the filter notes, names and session text are checked against (nil = no moderation)
+func (mh *MessageHandler) SetModeration(filter moderation.Filter) {
+	mh.moderation = filter
+}
+
+// moderate checks texts against the moderation filter, returning an error for the
+// first one rejected or that couldn't be checked. Empty texts are skipped
+// Refusals are logged to logger
+// Blocks on the filter, so never call it on the hub goroutine or a session's actor
+func (mh *MessageHandler) moderate(logger *slog.Logger, kind moderation.Kind, texts []string) error {
+	if mh.moderation == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), moderationTimeout)
+	defer cancel()
+
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+
+		decision, err := mh.moderation.Check(ctx, kind, text)
+		if err != nil {
+			logger.Warn("Moderation check failed", "kind", kind, "err", err)
+			return ErrModerationUnavailable
+		}
+		if !decision.Allowed {
+			logger.Info("Moderation rejected text", "kind", kind, "reason", decision.Reason)
+			if decision.Reason == "" {
+				return fmt.Errorf("%s %w", kind, ErrContentRejected)
+			}
+			return fmt.Errorf("%s %w: %s", kind, ErrContentRejected, decision.Reason)
+		}
+	}
+	return nil
+}
+
+// cleaned replaces *text with its validated, cleaned form, or tells the client why it isn't
+// valid. Handlers clean text before moderating it, so the filter sees exactly what would be
+// stored: NFC-normalised, with the invisible characters that could hide a blocked word removed
+func (mh *MessageHandler) cleaned(client *Client, text *string, validate func(string) (string, error)) bool {
+	value, err := validate(*text)
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return false
+	}
+	*text = value
+	return true
+}
+
+// moderated runs next once texts pass moderation, or tells the client why they didn't
+// Without a filter next runs straight away; otherwise it runs later on the actor of
+// sessionID, where the message is being handled ("" = the hub goroutine)
+func (mh *MessageHandler) moderated(client *Client, sessionID string, kind moderation.Kind, texts []string, next func()) {
+	if mh.moderation == nil {
+		next()
+		return
+	}
+
+	// Replies sent once moderation is done still answer the message's msgId
+	reply := client.reply.Load()
+	go func() {
+		err := mh.moderate(client.logger(), kind, texts)
+		mh.hub.ScheduleSession(sessionID, func() {
+			mh.replying(client, reply, func() {
+				if err != nil {
+					mh.sendErrorCode(client, errorCodeFor(err), err.Error())
+					return
+				}
+				next()
+			})
+		})
+	}()
+}
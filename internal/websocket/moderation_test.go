@@ -0,0 +1,150 @@
+package websocket
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/moderation"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestModeration(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	mh.SetModeration(moderation.NewBlocklist([]string{"darn"}))
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	// Handlers run on the hub goroutine
+	handle := func(client *Client, msg *Message) {
+		hub.Schedule(func() { mh.HandleMessage(client, msg) })
+	}
+
+	handle(aliceClient, &Message{Type: "submit_notes", Data: map[string]interface{}{
+		"notes": []interface{}{
+			map[string]interface{}{"recipientId": sess.HostID, "content": "Darn good hosting"},
+		},
+	}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" || reply.Data["code"] != "content_rejected" {
+		t.Errorf("Expected the note to be rejected, got %+v", reply)
+	}
+
+	handle(aliceClient, &Message{Type: "submit_notes", Data: map[string]interface{}{
+		"notes": []interface{}{
+			map[string]interface{}{"recipientId": sess.HostID, "content": "Great hosting"},
+		},
+	}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "notes_submitted" {
+		t.Errorf("Expected an allowed note to be submitted, got %+v", reply)
+	}
+
+	// Edits are moderated like new notes
+	handle(aliceClient, &Message{Type: "update_note", Data: map[string]interface{}{"recipientId": sess.HostID, "content": "Darn great hosting"}})
+	if reply := nextMessage(t, aliceClient); reply.Data["code"] != "content_rejected" {
+		t.Errorf("Expected the edit to be rejected, got %+v", reply)
+	}
+
+	joiner := &Client{send: make(chan []byte, 16), hub: hub}
+	handle(joiner, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": sess.Code, "userName": "Darn Dan"}})
+	if reply := nextMessage(t, joiner); reply.Data["code"] != "content_rejected" {
+		t.Errorf("Expected the name to be rejected, got %+v", reply)
+	}
+	if len(sess.Participants) != 2 {
+		t.Errorf("Expected the rejected name not to join, got %d participants", len(sess.Participants))
+	}
+}
+
+func TestModerationOfNameChangesAndSessionText(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	mh.SetModeration(moderation.NewBlocklist([]string{"darn"}))
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	handle := func(client *Client, msg *Message) {
+		hub.Schedule(func() { mh.HandleMessage(client, msg) })
+	}
+
+	tests := []struct {
+		name   string
+		client *Client
+		msg    *Message
+	}{
+		{"change_name", aliceClient, &Message{Type: "change_name", Data: map[string]interface{}{"userName": "Darn Alice"}}},
+		{"rename_participant", host, &Message{Type: "rename_participant", Data: map[string]interface{}{"participantId": alice.ID, "userName": "Darn Alice"}}},
+		{"set_session_title", host, &Message{Type: "set_session_title", Data: map[string]interface{}{"title": "Darn retro"}}},
+		{"set_prompt", host, &Message{Type: "set_prompt", Data: map[string]interface{}{"prompt": "Darn, what went well?"}}},
+	}
+	for _, tt := range tests {
+		handle(tt.client, tt.msg)
+		if reply := nextMessage(t, tt.client); reply.Data["code"] != "content_rejected" {
+			t.Errorf("%s: expected the text to be rejected, got %+v", tt.name, reply)
+		}
+	}
+	if alice.Name != "Alice" || sess.Title != "" || sess.Prompt != "" {
+		t.Errorf("Expected nothing to change, got name %q title %q prompt %q", alice.Name, sess.Title, sess.Prompt)
+	}
+
+	creator := &Client{send: make(chan []byte, 16), hub: hub}
+	handle(creator, &Message{Type: "create_session", Data: map[string]interface{}{"userName": "Sam", "welcome": "Darn glad you came"}})
+	if reply := nextMessage(t, creator); reply.Data["code"] != "content_rejected" {
+		t.Errorf("Expected the welcome to be rejected, got %+v", reply)
+	}
+}
+
+func TestModerationChecksCleanedText(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	mh.SetModeration(moderation.NewBlocklist([]string{"darn"}))
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	handle := func(client *Client, msg *Message) {
+		hub.Schedule(func() { mh.HandleMessage(client, msg) })
+	}
+
+	// A zero-width space or bidi override would split the word for the filter, but
+	// cleaning removes them before it looks
+	hidden := "D\u200Barn"
+	tests := []struct {
+		name   string
+		client *Client
+		msg    *Message
+	}{
+		{"submit_notes", aliceClient, &Message{Type: "submit_notes", Data: map[string]interface{}{
+			"notes": []interface{}{map[string]interface{}{"recipientId": sess.HostID, "content": hidden + " good hosting"}},
+		}}},
+		{"update_note", aliceClient, &Message{Type: "update_note", Data: map[string]interface{}{"recipientId": sess.HostID, "content": hidden + " great hosting"}}},
+		{"change_name", aliceClient, &Message{Type: "change_name", Data: map[string]interface{}{"userName": "Da\u202Ern Alice"}}},
+		{"set_session_title", host, &Message{Type: "set_session_title", Data: map[string]interface{}{"title": hidden + " retro"}}},
+		{"set_prompt", host, &Message{Type: "set_prompt", Data: map[string]interface{}{"prompt": hidden + ", what went well?"}}},
+		{"join_session", &Client{send: make(chan []byte, 16), hub: hub}, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": sess.Code, "userName": hidden + " Dan"}}},
+		{"create_session", &Client{send: make(chan []byte, 16), hub: hub}, &Message{Type: "create_session", Data: map[string]interface{}{"userName": "Sam", "prompt": hidden + " good"}}},
+	}
+	for _, tt := range tests {
+		handle(tt.client, tt.msg)
+		if reply := nextMessage(t, tt.client); reply.Data["code"] != "content_rejected" {
+			t.Errorf("%s: expected the hidden word to be rejected, got %+v", tt.name, reply)
+		}
+	}
+
+	if _, _, err := mh.CreateSession([]byte(`{"userName": "D\u200Barn Sam"}`), "", "192.0.2.1", true); !errors.Is(err, ErrContentRejected) {
+		t.Errorf("Expected the HTTP-created host name to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+// ABOUTME: update_note and delete_note let authors fix or retract a note before reading begins
+// ABOUTME: Edits are checked like new submissions and refused once the reading countdown has started
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/moderation"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// handleUpdateNote replaces the content, and optionally the GIF, of a note the client wrote
+// The new content must pass moderation like a new note
+func (mh *MessageHandler) handleUpdateNote(client *Client, req *updateNoteRequest) {
+	sess, ok := mh.editableSession(client)
+	if !ok {
+		return
+	}
+	validate := func(content string) (string, error) {
+		return validateNoteContent(content, sess.Settings.MaxNoteLength)
+	}
+	if !mh.cleaned(client, &req.Content, validate) {
+		return
+	}
+
+	mh.moderated(client, client.sessionID, moderation.KindNote, []string{req.Content}, func() {
+		mh.updateNote(client, req)
+	})
+}
+
+// updateNote applies a moderated edit
+func (mh *MessageHandler) updateNote(client *Client, req *updateNoteRequest) {
+	sess, ok := mh.editableSession(client)
+	if !ok {
+		return
+	}
+
+	validatedContent, err := validateNoteContent(req.Content, sess.Settings.MaxNoteLength)
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	if err := checkNoteMinimum(validatedContent, sess.MinNoteChars, sess.MinNoteWords); err != nil {
+		mh.sendErrorCode(client, errorCodeFor(err), err.Error())
+		return
+	}
+
+	if req.GIFURL != nil && *req.GIFURL != "" && (len(*req.GIFURL) > maxGIFURLLength || !mh.gifs.Allows(*req.GIFURL)) {
+		mh.sendErrorCode(client, "invalid_gif", "gif must come from gif search")
+		return
+	}
+
+	duplicate := findDuplicateNote(sess, client.userID, req.RecipientID, validatedContent)
+	if duplicate != nil && sess.DuplicatePolicy == session.DuplicateReject {
+		mh.sendErrorCode(client, "duplicate_note", "this note is very similar to one you wrote to someone else")
+		return
+	}
+
+	if err := sess.UpdateNote(client.userID, req.RecipientID, validatedContent); err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	// Leaving gifUrl out keeps the note's GIF; an empty one removes it
+	if req.GIFURL != nil {
+		if err := sess.SetNoteGIF(client.userID, req.RecipientID, *req.GIFURL); err != nil {
+			client.logger().Warn("Failed to set note GIF", "err", err)
+		}
+	}
+
+	client.SendMessage(&Message{
+		Type: "note_updated",
+		Data: map[string]interface{}{
+			"recipientId": req.RecipientID,
+			"content":     validatedContent,
+		},
+	})
+
+	if duplicate != nil {
+		mh.reportDuplicateNote(client, sess, req.RecipientID, duplicate)
+	}
+
+	mh.scheduleWritingStats(sess)
+	client.logger().Info("Note updated", "recipientID", req.RecipientID)
+}
+
+// handleDeleteNote retracts a note the client wrote so they can write it again
+func (mh *MessageHandler) handleDeleteNote(client *Client, req *deleteNoteRequest) {
+	sess, ok := mh.editableSession(client)
+	if !ok {
+		return
+	}
+
+	if err := sess.DeleteNote(client.userID, req.RecipientID); err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	client.SendMessage(&Message{
+		Type: "note_deleted",
+		Data: map[string]interface{}{
+			"recipientId": req.RecipientID,
+		},
+	})
+
+	// The host's counts go down again and reading waits for the replacement
+	mh.sendReceivedNoteCounts(sess)
+	mh.scheduleWritingStats(sess)
+	client.logger().Info("Note deleted", "recipientID", req.RecipientID)
+}
+
+// editableSession returns the client's session if its notes may still be changed,
+// telling the client why not otherwise
+func (mh *MessageHandler) editableSession(client *Client) (*session.Session, bool) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return nil, false
+	}
+
+	// Once every note is in, reading is already counting down
+	if mh.countingDown(sess.ID) {
+		mh.sendError(client, "reading is about to start; notes can no longer be changed")
+		return nil, false
+	}
+
+	if sess.Phase != session.PhaseWriting {
+		mh.sendError(client, "notes can only be changed during the writing phase")
+		return nil, false
+	}
+
+	return sess, true
+}
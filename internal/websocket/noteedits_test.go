@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestUpdateNote(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alcie")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+
+	mh.HandleMessage(host, &Message{Type: "update_note", Data: map[string]interface{}{"recipientId": alice.ID, "content": "  Thanks Alice  "}})
+	reply := nextMessage(t, host)
+	if reply.Type != "note_updated" || reply.Data["content"] != "Thanks Alice" {
+		t.Fatalf("Expected note_updated, got %+v", reply)
+	}
+	if sess.Notes[0].Content != "Thanks Alice" {
+		t.Errorf("Expected the note to be updated, got %q", sess.Notes[0].Content)
+	}
+
+	mh.HandleMessage(host, &Message{Type: "update_note", Data: map[string]interface{}{"recipientId": alice.ID, "content": ""}})
+	if reply := nextMessage(t, host); reply.Type != "error" {
+		t.Errorf("Expected empty content to be refused, got %+v", reply)
+	}
+
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+	mh.HandleMessage(aliceClient, &Message{Type: "update_note", Data: map[string]interface{}{"recipientId": alice.ID, "content": "Mine now"}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected editing someone else's note to be refused, got %+v", reply)
+	}
+}
+
+func TestDeleteNoteDelaysReading(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.HandleMessage(aliceClient, &Message{Type: "delete_note", Data: map[string]interface{}{"recipientId": sess.HostID}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "note_deleted" {
+		t.Fatalf("Expected note_deleted, got %+v", reply)
+	}
+	if len(sess.Notes) != 0 {
+		t.Fatalf("Expected the note to be gone, got %d", len(sess.Notes))
+	}
+
+	// The host's note alone doesn't complete writing now Alice's was retracted
+	mh.HandleMessage(host, &Message{Type: "submit_notes", Data: map[string]interface{}{
+		"notes": []interface{}{map[string]interface{}{"recipientId": alice.ID, "content": "Thanks Alice"}},
+	}})
+	if sess.Phase != session.PhaseWriting {
+		t.Errorf("Expected writing to continue, got %s", sess.Phase)
+	}
+
+	mh.HandleMessage(aliceClient, &Message{Type: "submit_notes", Data: map[string]interface{}{
+		"notes": []interface{}{map[string]interface{}{"recipientId": sess.HostID, "content": "Thank you, Host"}},
+	}})
+	if sess.Phase != session.PhaseReading {
+		t.Errorf("Expected reading once the rewritten note is in, got %s", sess.Phase)
+	}
+
+	drain([]*Client{aliceClient})
+	mh.HandleMessage(aliceClient, &Message{Type: "delete_note", Data: map[string]interface{}{"recipientId": sess.HostID}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected deleting once reading has started to be refused, got %+v", reply)
+	}
+}
@@ -0,0 +1,33 @@
+// ABOUTME: Host-only preview of the note pool before reading starts
+// ABOUTME: Reports note counts per recipient and author and any missing pairs, never content
+package websocket
+
+import ()
+
+// handleGetNotePoolStatus sends the host who has written to and received notes from whom (host only)
+func (mh *MessageHandler) handleGetNotePoolStatus(client *Client) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if client.userID != sess.HostID {
+		client.logger().Warn("Non-host tried to view note pool", "hostID", sess.HostID)
+		mh.sendError(client, "only host can view the note pool")
+		return
+	}
+
+	status := sess.GetNotePoolStatus()
+	client.SendMessage(&Message{
+		Type: "note_pool_status",
+		Data: map[string]interface{}{
+			"received":     status.Received,
+			"written":      status.Written,
+			"missingPairs": status.MissingPairs,
+			"totalNotes":   status.TotalNotes,
+			"participants": sess.GetParticipantList(),
+			"phase":        sess.Phase,
+		},
+	})
+}
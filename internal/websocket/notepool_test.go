@@ -0,0 +1,39 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestNotePoolStatusHostOnly(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, sess.HostID, "A secret thank you")
+
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.HandleMessage(aliceClient, &Message{Type: "get_note_pool_status"})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected non-hosts to be refused, got %+v", reply)
+	}
+
+	mh.HandleMessage(host, &Message{Type: "get_note_pool_status"})
+	reply := nextMessage(t, host)
+	if reply.Type != "note_pool_status" || reply.Data["totalNotes"] != float64(1) {
+		t.Fatalf("Expected note_pool_status, got %+v", reply)
+	}
+	if missing, _ := reply.Data["missingPairs"].([]interface{}); len(missing) != 1 {
+		t.Errorf("Expected the host's note to Alice to be missing, got %v", reply.Data["missingPairs"])
+	}
+	if raw, _ := encodeMessage(&reply); strings.Contains(string(raw), "secret") {
+		t.Error("Expected no note content in the note pool status")
+	}
+}
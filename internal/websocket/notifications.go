@@ -0,0 +1,141 @@
+// ABOUTME: Notifications for participants who may have tabbed away and for operator channels
+// ABOUTME: Handles device registration and dispatches writing, turn and completion events
+package websocket
+
+import (
+	"fmt"
+
+	"github.com/cassiascheffer/uplift/internal/notifications"
+	"github.com/cassiascheffer/uplift/internal/push"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// maxPushTokenLength bounds device tokens accepted from clients
+const maxPushTokenLength = 4096
+
+// handleRegisterPush registers one of the client's devices for push notifications
+func (mh *MessageHandler) handleRegisterPush(client *Client, req *registerPushRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	platform := req.Platform
+	if !mh.notifications.SupportsPush(platform) {
+		mh.sendErrorCode(client, "push_unsupported", "push notifications are not available for this platform")
+		return
+	}
+
+	token := req.Token
+	if token == "" || len(token) > maxPushTokenLength {
+		mh.sendError(client, "invalid push token")
+		return
+	}
+
+	// Browser subscriptions are checked up front so a bad one fails here, not on every alert
+	if platform == "webpush" {
+		if _, err := push.ParseWebPushSubscription(token); err != nil {
+			mh.sendError(client, "invalid push subscription")
+			return
+		}
+	}
+
+	if err := sess.RegisterPushDevice(client.userID, platform, token); err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	client.SendMessage(&Message{
+		Type: "push_registered",
+		Data: map[string]interface{}{
+			"platform": platform,
+		},
+	})
+	client.logger().Info("Push device registered", "platform", platform)
+}
+
+// handleUnregisterPush stops push notifications to one of the client's devices
+func (mh *MessageHandler) handleUnregisterPush(client *Client, req *unregisterPushRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if err := sess.UnregisterPushDevice(client.userID, req.Token); err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	client.SendMessage(&Message{Type: "push_unregistered"})
+}
+
+// notify dispatches a session event, reaching the given participants on any devices they
+// registered plus whatever operator channels the event is routed to
+func (mh *MessageHandler) notify(sess *session.Session, n notifications.Notification, participantIDs ...string) {
+	if mh.notifications == nil {
+		return
+	}
+
+	if n.Data == nil {
+		n.Data = make(map[string]string)
+	}
+	n.Data["sessionCode"] = sess.Code
+	n.Data["productName"] = mh.branding.Get(sess.Tenant).ProductName
+
+	var contacts []notifications.Contact
+	for _, participantID := range participantIDs {
+		for _, device := range sess.GetPushDevices(participantID) {
+			contacts = append(contacts, notifications.Contact{
+				Channel: notifications.ChannelPush,
+				Address: notifications.PushAddress(device.Platform, device.Token),
+				Label:   participantID,
+			})
+		}
+	}
+
+	mh.notifications.Dispatch(n, contacts)
+}
+
+// notifyWritingStarted alerts every participant that the writing phase has begun
+func (mh *MessageHandler) notifyWritingStarted(sess *session.Session) {
+	var participantIDs []string
+	for _, participant := range sess.GetParticipantList() {
+		participantIDs = append(participantIDs, participant.ID)
+	}
+
+	mh.notify(sess, notifications.Notification{
+		Event: notifications.EventWritingStarted,
+		Title: "Time to write",
+		Body:  "The writing phase has started. Write your notes of appreciation.",
+	}, participantIDs...)
+}
+
+// notifyTurn alerts a participant that it's their turn to read
+func (mh *MessageHandler) notifyTurn(sess *session.Session, reader *session.Participant) {
+	if reader == nil {
+		return
+	}
+
+	mh.notify(sess, notifications.Notification{
+		Event: notifications.EventYourTurn,
+		Title: "It's your turn",
+		Body:  "Draw a note and read it aloud to the group.",
+	}, reader.ID)
+}
+
+// notifySessionComplete tells operator channels a session has finished
+// Participants see completion in the session itself, so it isn't pushed to their devices
+func (mh *MessageHandler) notifySessionComplete(sess *session.Session) {
+	title := sess.Title
+	if title == "" {
+		title = mh.branding.Get(sess.Tenant).ProductName + " session " + sess.Code
+	}
+
+	mh.notify(sess, notifications.Notification{
+		Event: notifications.EventSessionComplete,
+		Title: title + " is complete",
+		Body:  fmt.Sprintf("%d participants shared %d notes of appreciation.", len(sess.GetParticipantList()), len(sess.Notes)),
+	})
+}
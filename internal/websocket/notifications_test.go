@@ -0,0 +1,67 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/notifications"
+	"github.com/cassiascheffer/uplift/internal/push"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// channelProvider hands delivered notifications to the test
+type channelProvider chan push.Notification
+
+func (p channelProvider) Send(ctx context.Context, token string, n push.Notification) error {
+	p <- n
+	return nil
+}
+
+func TestRegisterPushAndTurnAlert(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	delivered := make(channelProvider, 1)
+	sender := push.NewSender()
+	sender.Register("fcm", delivered)
+	dispatcher := notifications.NewDispatcher(notifications.DefaultRules())
+	dispatcher.Register(notifications.ChannelPush, notifications.NewPushNotifier(sender))
+	mh.SetNotifications(dispatcher)
+
+	sess := manager.CreateSession("Host")
+	participant, _ := sess.AddParticipant("Alice")
+	alice := newTestClient(hub, sess.ID, participant.ID)
+
+	mh.HandleMessage(alice, &Message{
+		Type: "register_push",
+		Data: map[string]interface{}{"platform": "apns", "token": "device-1"},
+	})
+	var reply Message
+	json.Unmarshal(<-alice.send, &reply)
+	if reply.Data["code"] != "push_unsupported" {
+		t.Errorf("Expected push_unsupported for unconfigured platform, got %+v", reply)
+	}
+
+	mh.HandleMessage(alice, &Message{
+		Type: "register_push",
+		Data: map[string]interface{}{"platform": "fcm", "token": "device-1"},
+	})
+	json.Unmarshal(<-alice.send, &reply)
+	if reply.Type != "push_registered" {
+		t.Fatalf("Expected push_registered, got %+v", reply)
+	}
+
+	mh.notifyTurn(sess, participant)
+
+	select {
+	case n := <-delivered:
+		if n.Data["event"] != "your_turn" || n.Data["sessionCode"] != sess.Code {
+			t.Errorf("Unexpected notification: %+v", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a turn notification")
+	}
+}
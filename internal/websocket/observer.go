@@ -0,0 +1,105 @@
+// ABOUTME: Read-only admin observers that attach to live sessions for troubleshooting
+// ABOUTME: Observers receive session broadcasts but are never participants and can't send actions
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// observerName is shown in logs for admin observers
+const observerName = "Admin observer"
+
+// ServeObserver upgrades an already-authenticated admin request to a read-only
+// connection attached to the session with the given code
+func (h *Handler) ServeObserver(w http.ResponseWriter, r *http.Request, sessionCode string) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Warn("Observer WebSocket upgrade failed", "remote", r.RemoteAddr, "err", err)
+		return
+	}
+
+	client := &Client{
+		conn:            conn,
+		send:            make(chan []byte, 256),
+		hub:             h.hub,
+		compressMinSize: h.compression.MinSize,
+		observer:        true,
+	}
+	client.touch()
+
+	h.hub.connect <- client
+	h.hub.Schedule(func() {
+		if h.hub.observerHandler != nil {
+			h.hub.observerHandler(client, sessionCode, r.RemoteAddr)
+		}
+	})
+
+	go client.writePump()
+	go client.readPump()
+}
+
+// AttachObserver joins an observer client to a session without adding a participant
+// Runs on the hub goroutine
+func (mh *MessageHandler) AttachObserver(client *Client, sessionCode, remoteAddr string) {
+	sess, err := mh.sessionManager.GetSessionByCode(sessionCode)
+	if err != nil {
+		mh.sendErrorCode(client, "session_not_found", "session not found")
+		return
+	}
+
+	client.sessionID = sess.ID
+	client.userID = newObserverID()
+	client.userName = observerName
+
+	go func() {
+		mh.hub.register <- client
+	}()
+
+	client.SendMessage(&Message{
+		Type: "observing",
+		Data: mh.observerSnapshot(sess),
+	})
+
+	client.logger().Info("Admin observer attached", "remote", remoteAddr, "phase", sess.Phase)
+}
+
+// observerSnapshot describes where a session is, without note content or authors
+func (mh *MessageHandler) observerSnapshot(sess *session.Session) map[string]interface{} {
+	participants := []map[string]interface{}{}
+	for _, participant := range sess.GetParticipantList() {
+		participants = append(participants, map[string]interface{}{
+			"id":        participant.ID,
+			"name":      participant.Name,
+			"role":      participant.Role,
+			"isHost":    participant.IsHost,
+			"connected": mh.hub.IsUserConnected(sess.ID, participant.ID),
+			"latencyMs": participant.LatencyMs,
+		})
+	}
+
+	return map[string]interface{}{
+		"sessionCode":        sess.Code,
+		"sessionId":          sess.ID,
+		"title":              sess.Title,
+		"phase":              sess.Phase,
+		"theme":              sess.Theme,
+		"prompt":             sess.Prompt,
+		"participants":       participants,
+		"currentReader":      sess.GetCurrentReader(),
+		"receivedNoteCounts": sess.GetReceivedNoteCounts(),
+		"unreadNotes":        len(sess.GetUnreadNotes()),
+		"readAloudNotes":     sess.GetReadAloudNoteCount(),
+	}
+}
+
+// newObserverID generates a user ID that can't collide with participant IDs
+func newObserverID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "observer-" + hex.EncodeToString(b)
+}
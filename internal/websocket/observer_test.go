@@ -0,0 +1,108 @@
+package websocket
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	gorillaws "github.com/gorilla/websocket"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// readMessage reads the next JSON message from a test connection
+func readMessage(t *testing.T, conn *gorillaws.Conn) Message {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var msg Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	return msg
+}
+
+func TestObserverWatchesWithoutParticipating(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	hub.SetMessageHandler(mh.HandleMessage)
+	hub.SetDisconnectHandler(mh.HandleClientDisconnect)
+	hub.SetObserverHandler(mh.AttachObserver)
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	sess.AddParticipant("Alice")
+
+	handler := NewHandler(hub)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeObserver(w, r, r.URL.Query().Get("code"))
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	conn, _, err := gorillaws.DefaultDialer.Dial(url+"?code="+sess.Code, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	snapshot := readMessage(t, conn)
+	if snapshot.Type != "observing" || snapshot.Data["sessionCode"] != sess.Code {
+		t.Fatalf("Expected observing snapshot, got %+v", snapshot)
+	}
+	if participants, _ := snapshot.Data["participants"].([]interface{}); len(participants) != 2 {
+		t.Errorf("Expected two participants in snapshot, got %v", snapshot.Data["participants"])
+	}
+
+	// Wait for registration, then check the observer isn't counted anywhere
+	deadline := time.Now().Add(time.Second)
+	for len(hub.sessionClients(sess.ID, "")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(sess.GetParticipantList()) != 2 {
+		t.Errorf("Expected observer not to be added as a participant")
+	}
+	if count := hub.GetSessionClientCount(sess.ID); count != 0 {
+		t.Errorf("Expected observers not to be counted, got %d", count)
+	}
+
+	// Session broadcasts reach the observer
+	hub.BroadcastToSession(sess.ID, &Message{Type: "session_title_changed"})
+	if msg := readMessage(t, conn); msg.Type != "session_title_changed" {
+		t.Errorf("Expected broadcast, got %+v", msg)
+	}
+
+	// Actions are refused
+	conn.WriteJSON(Message{Type: "start_writing"})
+	if msg := readMessage(t, conn); msg.Data["code"] != "read_only" {
+		t.Errorf("Expected read_only error, got %+v", msg)
+	}
+	if sess.Phase != session.PhaseJoining {
+		t.Errorf("Expected phase unchanged, got %s", sess.Phase)
+	}
+
+	// Leaving doesn't touch the session
+	conn.Close()
+	time.Sleep(50 * time.Millisecond)
+	if _, err := manager.GetSessionByID(sess.ID); err != nil || len(sess.GetParticipantList()) != 2 {
+		t.Error("Expected session to be unaffected by the observer leaving")
+	}
+
+	unknown, _, err := gorillaws.DefaultDialer.Dial(url+"?code=NOPE00", nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer unknown.Close()
+	if msg := readMessage(t, unknown); msg.Data["code"] != "session_not_found" {
+		t.Errorf("Expected session_not_found, got %+v", msg)
+	}
+}
@@ -0,0 +1,66 @@
+// ABOUTME: Tells clients running frontend assets from an older deploy to refresh
+// ABOUTME: Waits for a safe moment, between circles rather than mid-writing or mid-reading
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// SetAssetVersion sets the frontend build the server is deployed with (empty = never prompt)
+// Clients that report a different build in hello are asked to refresh
+func (mh *MessageHandler) SetAssetVersion(version string) {
+	mh.assetVersion = version
+}
+
+// checkOutdated prompts a client whose build differs from the deployed one to refresh,
+// now if its session is at a safe moment, otherwise once the session reaches one
+func (mh *MessageHandler) checkOutdated(client *Client) {
+	if mh.assetVersion == "" || client.build == "" || client.build == mh.assetVersion {
+		client.outdated = false
+		return
+	}
+
+	client.outdated = true
+	if mh.safeToRefresh(client.sessionID) {
+		mh.sendOutdated(client)
+	}
+}
+
+// safeToRefresh reports whether reloading the page would interrupt nothing: the client
+// isn't in a session, or the session is still gathering people or has finished
+func (mh *MessageHandler) safeToRefresh(sessionID string) bool {
+	if sessionID == "" {
+		return true
+	}
+	sess, err := mh.sessionManager.GetSessionByID(sessionID)
+	if err != nil {
+		return true
+	}
+	return sess.Phase == session.PhaseJoining || sess.Phase == session.PhaseComplete
+}
+
+// refreshOutdated delivers held-back refresh prompts once a session reaches a safe moment
+func (mh *MessageHandler) refreshOutdated(sess *session.Session) {
+	if mh.assetVersion == "" || !mh.safeToRefresh(sess.ID) {
+		return
+	}
+	for _, client := range mh.hub.sessionClients(sess.ID, "") {
+		if client.outdated {
+			mh.sendOutdated(client)
+		}
+	}
+}
+
+// sendOutdated sends a client_outdated prompt; each client is prompted once
+func (mh *MessageHandler) sendOutdated(client *Client) {
+	client.outdated = false
+	client.logger().Info("Client outdated", "build", client.build, "current", mh.assetVersion)
+	client.SendMessage(&Message{
+		Type: "client_outdated",
+		Data: map[string]interface{}{
+			"build":        client.build,
+			"currentBuild": mh.assetVersion,
+			"message":      "A new version is available. Refresh the page to update.",
+		},
+	})
+}
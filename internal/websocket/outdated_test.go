@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestClientOutdatedWaitsForSafeMoment(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	mh.SetAssetVersion("v2")
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	// A current client is never prompted
+	mh.HandleMessage(host, &Message{Type: "hello", Data: map[string]interface{}{"build": "v2"}})
+	if reply := nextMessage(t, host); reply.Data["build"] != "v2" {
+		t.Errorf("Expected hello to report the deployed build, got %+v", reply)
+	}
+	if len(host.send) != 0 {
+		t.Error("Expected no prompt for a current client")
+	}
+
+	// A stale client mid-circle is prompted only once the session completes
+	sess.TransitionToWriting()
+	mh.HandleMessage(aliceClient, &Message{Type: "hello", Data: map[string]interface{}{"build": "v1"}})
+	nextMessage(t, aliceClient) // hello
+	if len(aliceClient.send) != 0 {
+		t.Fatal("Expected the prompt to wait until writing is over")
+	}
+
+	sess.Phase = session.PhaseComplete
+	mh.refreshOutdated(sess)
+	msg := nextMessage(t, aliceClient)
+	if msg.Type != "client_outdated" || msg.Data["build"] != "v1" || msg.Data["currentBuild"] != "v2" {
+		t.Fatalf("Expected client_outdated, got %+v", msg)
+	}
+
+	// Each client is prompted once
+	mh.refreshOutdated(sess)
+	if len(aliceClient.send) != 0 || len(host.send) != 0 {
+		t.Error("Expected no further prompts")
+	}
+}
+
+func TestClientOutdatedOutsideSession(t *testing.T) {
+	hub := NewHub(nil)
+	mh := NewMessageHandler(hub, session.NewManager())
+	client := &Client{send: make(chan []byte, 16), hub: hub}
+
+	// Without a deployed build there's nothing to compare against
+	mh.HandleMessage(client, &Message{Type: "hello", Data: map[string]interface{}{"build": "v1"}})
+	nextMessage(t, client) // hello
+	if len(client.send) != 0 {
+		t.Fatal("Expected no prompt when the asset version isn't set")
+	}
+
+	mh.SetAssetVersion("v2")
+	mh.HandleMessage(client, &Message{Type: "hello", Data: map[string]interface{}{"build": "v1"}})
+	nextMessage(t, client) // hello
+	if msg := nextMessage(t, client); msg.Type != "client_outdated" {
+		t.Errorf("Expected an immediate prompt outside a session, got %+v", msg)
+	}
+}
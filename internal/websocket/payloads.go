@@ -0,0 +1,275 @@
+// ABOUTME: Typed payloads for every client message, decoded strictly from the envelope's data
+// ABOUTME: Unknown fields and wrongly typed values are refused with a structured invalid_payload error
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// PayloadError describes why a message's data couldn't be decoded
+type PayloadError struct {
+	Field  string // Offending field, when known, e.g. "notes.0.content"
+	Reason string
+}
+
+func (e *PayloadError) Error() string {
+	if e.Field == "" {
+		return e.Reason
+	}
+	return e.Field + ": " + e.Reason
+}
+
+// decodePayload decodes a message's data into req, refusing unknown fields and values of the wrong type
+func decodePayload(data map[string]interface{}, req interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return &PayloadError{Reason: "data is not valid JSON"}
+	}
+	return decodeStrict(raw, req)
+}
+
+// decodeStrict decodes JSON into req, refusing unknown fields and values of the wrong type
+func decodeStrict(raw []byte, req interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(req); err != nil {
+		return payloadError(err)
+	}
+	return nil
+}
+
+// payloadError turns a JSON decoding error into a PayloadError naming the field at fault
+func payloadError(err error) *PayloadError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &PayloadError{Field: typeErr.Field, Reason: "must be " + jsonTypeName(typeErr.Type.Kind().String())}
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &PayloadError{Field: strings.Trim(field, `"`), Reason: "unknown field"}
+	}
+	return &PayloadError{Reason: err.Error()}
+}
+
+// jsonTypeName describes a Go kind as the JSON type a client should send
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "string":
+		return "a string"
+	case "bool":
+		return "true or false"
+	case "slice", "array":
+		return "an array"
+	case "struct", "map":
+		return "an object"
+	case "int", "int64", "uint64", "uint32", "int32":
+		return "a whole number"
+	case "float64", "float32":
+		return "a number"
+	}
+	return "a " + kind
+}
+
+// dispatch strictly decodes msg's data into a new T and passes it to handle
+func dispatch[T any](client *Client, msg *Message, handle func(*Client, *T)) error {
+	var req T
+	if err := decodePayload(msg.Data, &req); err != nil {
+		return err
+	}
+	handle(client, &req)
+	return nil
+}
+
+// dispatchEmpty checks msg carries no data fields before calling handle
+func dispatchEmpty(client *Client, msg *Message, handle func(*Client)) error {
+	if err := decodePayload(msg.Data, &struct{}{}); err != nil {
+		return err
+	}
+	handle(client)
+	return nil
+}
+
+// sendInvalidPayload tells a client which field of its message was refused
+func (mh *MessageHandler) sendInvalidPayload(client *Client, msg *Message, err error) {
+	data := map[string]interface{}{
+		"code":        "invalid_payload",
+		"message":     fmt.Sprintf("invalid %s: %v", msg.Type, err),
+		"messageType": msg.Type,
+	}
+	var payloadErr *PayloadError
+	if errors.As(err, &payloadErr) && payloadErr.Field != "" {
+		data["field"] = payloadErr.Field
+	}
+	client.SendMessage(&Message{Type: "error", Data: data})
+	mh.hub.recordInvalidPayload(client, msg, err)
+}
+
+// versioned is embedded in phase and turn actions, which may echo the session version they saw
+type versioned struct {
+	Version *uint64 `json:"version"`
+}
+
+type helloRequest struct {
+	Capabilities []Capability `json:"capabilities"`
+	Build        string       `json:"build"`
+}
+
+type pingRequest struct {
+	ClientTime float64 `json:"clientTime"`
+}
+
+type pongRequest struct {
+	SentAt float64 `json:"sentAt"`
+}
+
+type ackRequest struct {
+	AckID uint64 `json:"ackId"`
+}
+
+type registerPushRequest struct {
+	Platform string `json:"platform"`
+	Token    string `json:"token"`
+}
+
+type unregisterPushRequest struct {
+	Token string `json:"token"`
+}
+
+type searchGIFsRequest struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit"`
+}
+
+type validateSessionRequest struct {
+	SessionCode string `json:"sessionCode"`
+}
+
+// createSessionRequest is shared by create_session and POST /api/sessions
+type createSessionRequest struct {
+	UserName       string `json:"userName"`
+	Welcome        string `json:"welcome"`
+	Prompt         string `json:"prompt"`
+	AutoStartAt    int    `json:"autoStartAt"`
+	Countdown      int    `json:"countdown"`
+	MaxNoteLength  int    `json:"maxNoteLength"` // Superseded by settings.maxNoteLength, still honoured
+	MinNoteChars   int    `json:"minNoteChars"`
+	MinNoteWords   int    `json:"minNoteWords"`
+	DuplicateNotes string `json:"duplicateNotes"`
+	Theme          string `json:"theme"`
+	Ratings        bool   `json:"ratings"`
+	AutoRun        bool   `json:"autoRun"`
+	HostKey        string `json:"hostKey"`
+	Challenge      string `json:"challenge"`
+	Solution       string `json:"solution"`
+
+	Settings session.SessionSettings `json:"settings"`
+}
+
+type rejoinSessionRequest struct {
+	SessionCode string `json:"sessionCode"`
+	RejoinToken string `json:"rejoinToken"`
+	LastSeq     uint64 `json:"lastSeq"`
+}
+
+type joinSessionRequest struct {
+	SessionCode string `json:"sessionCode"`
+	UserName    string `json:"userName"`
+	Role        string `json:"role"`   // Optional label shown beside the name; the invite's when left out
+	Invite      string `json:"invite"` // Signed invite token; names the session when sessionCode is left out
+}
+
+type phaseActionRequest struct {
+	versioned
+}
+
+type submittedNote struct {
+	RecipientID string `json:"recipientId"`
+	Content     string `json:"content"`
+	GIFURL      string `json:"gifUrl"`
+	Private     bool   `json:"private"`
+	Shareable   bool   `json:"shareable"`
+}
+
+type submitNotesRequest struct {
+	Notes []submittedNote `json:"notes"`
+}
+
+type updateNoteRequest struct {
+	RecipientID string  `json:"recipientId"`
+	Content     string  `json:"content"`
+	GIFURL      *string `json:"gifUrl"` // nil keeps the note's GIF, "" removes it
+}
+
+type deleteNoteRequest struct {
+	RecipientID string `json:"recipientId"`
+}
+
+type noteReadRequest struct {
+	versioned
+	NoteID string `json:"noteId"`
+}
+
+type reactNoteRequest struct {
+	NoteID string `json:"noteId"`
+	Emoji  string `json:"emoji"`
+}
+
+type participantRequest struct {
+	ParticipantID string `json:"participantId"`
+}
+
+type renameParticipantRequest struct {
+	ParticipantID string `json:"participantId"`
+	UserName      string `json:"userName"`
+}
+
+type changeNameRequest struct {
+	UserName string `json:"userName"`
+}
+
+type setSessionTitleRequest struct {
+	Title *string `json:"title"`
+}
+
+type setPromptRequest struct {
+	Prompt *string `json:"prompt"`
+}
+
+type publishWallRequest struct {
+	NoteIDs       []string `json:"noteIds"` // nil publishes every shareable note
+	ExpiresInDays float64  `json:"expiresInDays"`
+}
+
+type createInviteRequest struct {
+	UserName       string  `json:"userName"`       // Name to fill in for whoever uses the invite
+	Role           string  `json:"role"`           // Role to fill in for whoever uses the invite
+	ExpiresInHours float64 `json:"expiresInHours"` // 0 = invites.DefaultTTL
+}
+
+type unpublishWallRequest struct {
+	Token string `json:"token"`
+}
+
+type submitRatingRequest struct {
+	Score *int `json:"score"`
+}
+
+type createBreakoutsRequest struct {
+	Count  *int       `json:"count"`
+	Groups [][]string `json:"groups"`
+}
+
+type mergeSessionRequest struct {
+	SessionCode string `json:"sessionCode"`
+	RejoinToken string `json:"rejoinToken"` // The other session's host rejoin token
+}
+
+type splitSessionRequest struct {
+	ParticipantIDs []string `json:"participantIds"`
+	HostID         string   `json:"hostId"`
+}
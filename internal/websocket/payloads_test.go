@@ -0,0 +1,80 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestInvalidPayloadsAreRefused(t *testing.T) {
+	hub := NewHub(nil)
+	hub.SetDeadLetters(NewDeadLetters(10))
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	client := newTestClient(hub, sess.ID, alice.ID)
+
+	tests := []struct {
+		name  string
+		msg   *Message
+		field string
+	}{
+		{"unknown field", &Message{Type: "change_name", Data: map[string]interface{}{"userName": "Al", "nickname": "Al"}}, "nickname"},
+		{"wrong type", &Message{Type: "change_name", Data: map[string]interface{}{"userName": 42}}, "userName"},
+		{"nested field", &Message{Type: "submit_notes", Data: map[string]interface{}{"notes": []interface{}{map[string]interface{}{"recipientId": sess.HostID, "content": true}}}}, "notes.0.content"},
+		{"fraction for a count", &Message{Type: "submit_rating", Data: map[string]interface{}{"score": 4.5}}, "score"},
+		{"data where none is expected", &Message{Type: "list_themes", Data: map[string]interface{}{"all": true}}, "all"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mh.HandleMessage(client, tt.msg)
+
+			reply := nextMessage(t, client)
+			if reply.Type != "error" || reply.Data["code"] != "invalid_payload" || reply.Data["messageType"] != tt.msg.Type || reply.Data["field"] != tt.field {
+				t.Errorf("Expected invalid_payload naming %s, got %+v", tt.field, reply.Data)
+			}
+		})
+	}
+
+	if sess.Participants[alice.ID].Name != "Alice" || len(sess.Notes) != 0 {
+		t.Error("Expected refused messages to have no effect")
+	}
+	if letters := hub.DeadLetters().Snapshot(); letters.Total != uint64(len(tests)) || letters.Letters[0].Reason != DeadLetterInvalidPayload {
+		t.Errorf("Expected each refused message captured as a dead letter, got %+v", letters)
+	}
+
+	// Well-formed payloads still work
+	mh.HandleMessage(client, &Message{Type: "change_name", Data: map[string]interface{}{"userName": "Al"}})
+	if renamed := nextMessage(t, client); renamed.Type == "error" {
+		t.Errorf("Expected a valid change_name to succeed, got %+v", renamed.Data)
+	}
+}
+
+func TestOutboundMessagesCarryProtocolVersion(t *testing.T) {
+	hub := NewHub(nil)
+	client := &Client{send: make(chan []byte, 16), hub: hub}
+	client.SendMessage(&Message{Type: "pong"})
+
+	var envelope map[string]interface{}
+	json.Unmarshal(<-client.send, &envelope)
+	if envelope["protocolVersion"] != float64(ProtocolVersion) {
+		t.Errorf("Expected protocolVersion %d in the envelope, got %v", ProtocolVersion, envelope)
+	}
+}
+
+func TestDecodeStrictRejectsUnknownEnvelopeFields(t *testing.T) {
+	var msg Message
+	err := decodeStrict([]byte(`{"type": "ping", "id": 7}`), &msg)
+	payloadErr, ok := err.(*PayloadError)
+	if !ok || payloadErr.Field != "id" {
+		t.Errorf("Expected the unknown envelope field to be named, got %v", err)
+	}
+
+	if err := decodeStrict([]byte(`{"type": "ping", "protocolVersion": 1, "data": {"clientTime": 5}}`), &msg); err != nil || msg.ProtocolVersion != 1 {
+		t.Errorf("Expected a versioned envelope to decode, got %v", err)
+	}
+}
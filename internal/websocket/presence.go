@@ -0,0 +1,29 @@
+// ABOUTME: Tracks which participants have a client connected and tells the session when that changes
+// ABOUTME: The hub knows who is connected; participants carry it so clients can grey out absent people
+package websocket
+
+// HandleClientConnect marks a client's participant as connected once the hub has registered it,
+// telling everyone else in the session if they weren't already
+// Runs on the hub goroutine
+func (mh *MessageHandler) HandleClientConnect(client *Client) {
+	if client.sessionID == "" || client.userID == "" || client.observer {
+		return
+	}
+
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		return // Ended before the client was registered
+	}
+	changed, err := sess.SetParticipantConnected(client.userID, true)
+	if err != nil || !changed {
+		return
+	}
+
+	mh.hub.BroadcastToSessionExcept(sess.ID, client.userID, &Message{
+		Type: "presence_changed",
+		Data: map[string]interface{}{
+			"participantId": client.userID,
+			"connected":     true,
+		},
+	})
+}
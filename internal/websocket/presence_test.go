@@ -0,0 +1,48 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestPresenceChangesWhenParticipantConnects(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	hub.SetConnectHandler(mh.HandleClientConnect)
+	go hub.Run()
+
+	// A session created over HTTP has participants before anyone connects
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	watcher := newTestClient(hub, sess.ID, bob.ID)
+	hub.register <- &Client{send: make(chan []byte, 256), hub: hub, sessionID: sess.ID, userID: sess.HostID}
+
+	msg := nextMessage(t, watcher)
+	if msg.Type != "presence_changed" || msg.Data["participantId"] != sess.HostID || msg.Data["connected"] != true {
+		t.Fatalf("Expected presence_changed for the host, got %s %v", msg.Type, msg.Data)
+	}
+
+	hub.register <- &Client{send: make(chan []byte, 256), hub: hub, sessionID: sess.ID, userID: alice.ID}
+	msg = nextMessage(t, watcher)
+	if msg.Type != "presence_changed" || msg.Data["participantId"] != alice.ID {
+		t.Fatalf("Expected presence_changed for Alice, got %s %v", msg.Type, msg.Data)
+	}
+	for _, participant := range sess.GetParticipantList() {
+		if participant.Connected != (participant.ID != bob.ID) {
+			t.Errorf("Expected only registered clients listed as connected, got %s connected=%v", participant.Name, participant.Connected)
+		}
+	}
+
+	// A second connection for someone already connected changes nothing
+	hub.register <- &Client{send: make(chan []byte, 256), hub: hub, sessionID: sess.ID, userID: alice.ID}
+	hub.Alive(time.Second)
+	select {
+	case data := <-watcher.send:
+		t.Errorf("Expected no presence change, got %s", data)
+	default:
+	}
+}
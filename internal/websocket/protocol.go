@@ -0,0 +1,152 @@
+// ABOUTME: Versioned translation between client protocol versions and the server's message format
+// ABOUTME: Upgrades inbound messages from older clients and downgrades what they're sent
+package websocket
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// ProtocolVersion is the message format handlers read and write
+	ProtocolVersion = 1
+
+	// MinProtocolVersion is the oldest client version still translated
+	MinProtocolVersion = 1
+
+	// legacyProtocolVersion is assumed for clients that don't send a version,
+	// which are frontends built before versioning existed
+	legacyProtocolVersion = 1
+
+	// ProtocolParam is the /ws query parameter clients report their version in
+	ProtocolParam = "protocol"
+)
+
+// Migration translates messages between protocol version From and From+1
+// Functions may replace top-level Data keys but must not modify nested values,
+// which can be shared with other recipients
+type Migration struct {
+	From      int
+	Upgrade   func(msg *Message) // Client message in version From to From+1
+	Downgrade func(msg *Message) // Server message in version From+1 to From
+}
+
+// migrations lists every format change, oldest first. To change a message
+// format, bump ProtocolVersion and add a migration from the previous version;
+// raise MinProtocolVersion (and drop its migrations) once old frontends are gone.
+var migrations = []Migration{}
+
+// Protocol applies a chain of migrations between a minimum and current version
+type Protocol struct {
+	current    int
+	minimum    int
+	migrations map[int]Migration // From -> migration
+}
+
+// NewProtocol builds a translation chain, checking every step between minimum and current is covered
+func NewProtocol(current, minimum int, steps []Migration) (*Protocol, error) {
+	if minimum < 1 || minimum > current {
+		return nil, fmt.Errorf("minimum protocol version %d must be between 1 and %d", minimum, current)
+	}
+
+	p := &Protocol{current: current, minimum: minimum, migrations: make(map[int]Migration)}
+	for _, step := range steps {
+		if step.Upgrade == nil || step.Downgrade == nil {
+			return nil, fmt.Errorf("migration from version %d needs both directions", step.From)
+		}
+		p.migrations[step.From] = step
+	}
+	for version := minimum; version < current; version++ {
+		if _, ok := p.migrations[version]; !ok {
+			return nil, fmt.Errorf("no migration from protocol version %d", version)
+		}
+	}
+	return p, nil
+}
+
+// defaultProtocol is the server's translation chain
+func defaultProtocol() *Protocol {
+	p, err := NewProtocol(ProtocolVersion, MinProtocolVersion, migrations)
+	if err != nil {
+		panic(err) // A gap in migrations is a programming error
+	}
+	return p
+}
+
+// ParseVersion reads a client's reported version; empty means a legacy client
+func (p *Protocol) ParseVersion(value string) (int, error) {
+	if value == "" {
+		return legacyProtocolVersion, nil
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil || !p.Supports(version) {
+		return 0, fmt.Errorf("unsupported protocol version %q (supported %d to %d)", value, p.minimum, p.current)
+	}
+	return version, nil
+}
+
+// Supports reports whether messages in version can be translated
+func (p *Protocol) Supports(version int) bool {
+	return version >= p.minimum && version <= p.current
+}
+
+// Upgrade translates a message from a client on version into the current format, in place
+func (p *Protocol) Upgrade(msg *Message, version int) {
+	for v := version; v < p.current; v++ {
+		p.migrations[v].Upgrade(msg)
+	}
+}
+
+// Downgrade returns msg translated for a client on version
+// msg itself is left untouched since it may be going to other clients too
+func (p *Protocol) Downgrade(msg *Message, version int) *Message {
+	if version >= p.current {
+		return msg
+	}
+
+	downgraded := *msg
+	downgraded.Data = make(map[string]interface{}, len(msg.Data))
+	for key, value := range msg.Data {
+		downgraded.Data[key] = value
+	}
+	for v := p.current - 1; v >= version; v-- {
+		p.migrations[v].Downgrade(&downgraded)
+	}
+	return &downgraded
+}
+
+// rejectProtocol tells a client its protocol version isn't supported and closes the
+// connection, so a stale frontend knows to reload rather than reconnect
+// Written directly since the client never joins the hub
+func rejectProtocol(conn *websocket.Conn, err error) {
+	defer conn.Close()
+	slog.Warn("Unsupported protocol version, disconnecting", "err", err)
+
+	data, encodeErr := encodeMessage(&Message{
+		Type: "error",
+		Data: map[string]interface{}{
+			"code":            "protocol_unsupported",
+			"message":         "This version of the app is out of date. Please reload the page.",
+			"protocolVersion": ProtocolVersion,
+			"minProtocol":     MinProtocolVersion,
+		},
+	})
+	if encodeErr != nil {
+		return
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		return
+	}
+	conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "Unsupported protocol version"),
+		time.Now().Add(writeWait),
+	)
+}
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gorillaws "github.com/gorilla/websocket"
+)
+
+// renameKey moves a Data key if msg has the given type
+func renameKey(msgType, from, to string) func(*Message) {
+	return func(msg *Message) {
+		if msg.Type != msgType {
+			return
+		}
+		if value, ok := msg.Data[from]; ok {
+			delete(msg.Data, from)
+			msg.Data[to] = value
+		}
+	}
+}
+
+// testProtocol is a version 2 protocol where version 1 called sessionCode "code"
+func testProtocol(t *testing.T) *Protocol {
+	t.Helper()
+
+	p, err := NewProtocol(2, 1, []Migration{{
+		From:      1,
+		Upgrade:   renameKey("join_session", "code", "sessionCode"),
+		Downgrade: renameKey("session_joined", "sessionCode", "code"),
+	}})
+	if err != nil {
+		t.Fatalf("Failed to build protocol: %v", err)
+	}
+	return p
+}
+
+func TestNewProtocolRequiresEveryStep(t *testing.T) {
+	if _, err := NewProtocol(3, 1, []Migration{{From: 1, Upgrade: func(*Message) {}, Downgrade: func(*Message) {}}}); err == nil {
+		t.Error("Expected error for missing migration from version 2")
+	}
+	if _, err := NewProtocol(2, 3, nil); err == nil {
+		t.Error("Expected error for minimum above current")
+	}
+	if _, err := NewProtocol(ProtocolVersion, MinProtocolVersion, migrations); err != nil {
+		t.Errorf("Expected the server's migrations to be complete: %v", err)
+	}
+}
+
+func TestProtocolParseVersion(t *testing.T) {
+	p := testProtocol(t)
+
+	if version, err := p.ParseVersion(""); err != nil || version != legacyProtocolVersion {
+		t.Errorf("Expected missing version to be legacy, got %d %v", version, err)
+	}
+	if version, err := p.ParseVersion("2"); err != nil || version != 2 {
+		t.Errorf("Expected version 2, got %d %v", version, err)
+	}
+	for _, value := range []string{"0", "3", "v2"} {
+		if _, err := p.ParseVersion(value); err == nil {
+			t.Errorf("Expected %q to be unsupported", value)
+		}
+	}
+}
+
+func TestProtocolTranslatesOldClients(t *testing.T) {
+	p := testProtocol(t)
+
+	inbound := &Message{Type: "join_session", Data: map[string]interface{}{"code": "ABC123"}}
+	p.Upgrade(inbound, 1)
+	if inbound.Data["sessionCode"] != "ABC123" {
+		t.Errorf("Expected upgraded sessionCode, got %v", inbound.Data)
+	}
+
+	outbound := &Message{Type: "session_joined", Data: map[string]interface{}{"sessionCode": "ABC123"}}
+	old := p.Downgrade(outbound, 1)
+	if old.Data["code"] != "ABC123" || old.Data["sessionCode"] != nil {
+		t.Errorf("Expected downgraded code, got %v", old.Data)
+	}
+	if outbound.Data["sessionCode"] != "ABC123" {
+		t.Error("Expected the original message to be left untouched")
+	}
+	if p.Downgrade(outbound, 2) != outbound {
+		t.Error("Expected current clients to get the message as-is")
+	}
+}
+
+func TestBroadcastDowngradesPerClient(t *testing.T) {
+	hub := NewHub(nil)
+	hub.protocol = testProtocol(t)
+
+	current := newTestClient(hub, "session-1", "user-1")
+	current.protocolVersion = 2
+	legacy := newTestClient(hub, "session-1", "user-2")
+	legacy.protocolVersion = 1
+
+	hub.BroadcastToSession("session-1", &Message{
+		Type: "session_joined",
+		Data: map[string]interface{}{"sessionCode": "ABC123"},
+	})
+
+	if msg := nextMessage(t, current); msg.Data["sessionCode"] != "ABC123" {
+		t.Errorf("Expected current format, got %v", msg.Data)
+	}
+	if msg := nextMessage(t, legacy); msg.Data["code"] != "ABC123" {
+		t.Errorf("Expected legacy format, got %v", msg.Data)
+	}
+}
+
+func TestUnsupportedProtocolIsRejected(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+	server := httptest.NewServer(NewHandler(hub))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?protocol=99"
+	conn, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	msg := readMessage(t, conn)
+	if msg.Type != "error" || msg.Data["code"] != "protocol_unsupported" {
+		t.Errorf("Expected protocol_unsupported error, got %+v", msg)
+	}
+	if _, _, err := conn.ReadMessage(); !gorillaws.IsCloseError(err, gorillaws.ClosePolicyViolation) {
+		t.Errorf("Expected policy violation close, got %v", err)
+	}
+}
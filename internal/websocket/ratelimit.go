@@ -0,0 +1,169 @@
+// ABOUTME: Rate limits on WebSocket upgrades, inbound messages and session creation, and per-IP caps
+// ABOUTME: Refused upgrades get a 429; refused messages get a rate_limited error with retryAfter
+package websocket
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/cassiascheffer/uplift/internal/ratelimit"
+)
+
+// RateLimits are the WebSocket limits; a nil limiter leaves that path unlimited
+type RateLimits struct {
+	Upgrades    *ratelimit.Limiter // Connections each IP may open
+	Messages    *ratelimit.Limiter // Messages each connection may send
+	Creates     *ratelimit.Limiter // create_session messages each IP may send, across its connections
+	Sessions    *ratelimit.Limiter // Sessions each IP may create, over WebSocket or the HTTP API
+	Connections int                // Connections each IP may hold open at once (0 = unlimited)
+}
+
+// SessionLimitError is returned when an IP has created as many sessions as it may for now
+type SessionLimitError struct {
+	retryAfter time.Duration
+}
+
+func (e *SessionLimitError) Error() string {
+	return "too many sessions created from this address, try again later"
+}
+
+// RetryAfter returns how long until the IP may create another session
+func (e *SessionLimitError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// allowSession takes a token from the IP's session limit
+// An empty remoteIP (not known) is never limited
+func (h *Hub) allowSession(remoteIP string) error {
+	if remoteIP == "" {
+		return nil
+	}
+	result := h.rateLimits.Sessions.Allow(remoteIP)
+	if result.Allowed {
+		return nil
+	}
+
+	slog.Warn("Session creation capped", "remoteIP", remoteIP, "retryAfter", result.RetryAfter)
+	h.counters.rateLimited.Add(1)
+	return &SessionLimitError{retryAfter: result.RetryAfter}
+}
+
+// sendSessionLimit tells a client its address has created too many sessions for now
+func (c *Client) sendSessionLimit(err *SessionLimitError) {
+	c.SendMessage(&Message{
+		Type: "error",
+		Data: map[string]interface{}{
+			"code":       "session_limit",
+			"message":    err.Error(),
+			"retryAfter": int(math.Ceil(err.RetryAfter().Seconds())),
+		},
+	})
+}
+
+// acquireConnection counts a new connection from remoteIP, refusing it if the IP
+// already holds as many as it may; an empty remoteIP (not known) isn't counted
+func (h *Hub) acquireConnection(remoteIP string) bool {
+	if remoteIP == "" {
+		return true
+	}
+
+	h.ipConnectionsMu.Lock()
+	defer h.ipConnectionsMu.Unlock()
+
+	if limit := h.rateLimits.Connections; limit > 0 && h.ipConnections[remoteIP] >= limit {
+		return false
+	}
+	h.ipConnections[remoteIP]++
+	return true
+}
+
+// releaseConnection stops counting a closed connection from remoteIP
+func (h *Hub) releaseConnection(remoteIP string) {
+	if remoteIP == "" {
+		return
+	}
+
+	h.ipConnectionsMu.Lock()
+	defer h.ipConnectionsMu.Unlock()
+
+	if h.ipConnections[remoteIP]--; h.ipConnections[remoteIP] <= 0 {
+		delete(h.ipConnections, remoteIP)
+	}
+}
+
+// rejectConnectionCap tells a client its IP holds too many connections, then closes
+// with 1008 (policy violation)
+func rejectConnectionCap(conn *websocket.Conn, limit int) {
+	if data, err := encodeMessage(&Message{
+		Type: "error",
+		Data: map[string]interface{}{
+			"code":    "too_many_connections",
+			"message": "Too many connections from this address",
+			"limit":   limit,
+		},
+	}); err == nil {
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
+		conn.WriteMessage(websocket.TextMessage, data)
+	}
+	conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "Too many connections"),
+		time.Now().Add(writeWait),
+	)
+	conn.Close()
+}
+
+// SetRateLimits sets the limits applied to inbound messages; call before Run
+func (h *Hub) SetRateLimits(limits RateLimits) {
+	h.rateLimits = limits
+}
+
+// allowUpgrade refuses an upgrade over the IP's connection limit with a 429
+func (h *Handler) allowUpgrade(w http.ResponseWriter, remoteIP string) bool {
+	result := h.hub.rateLimits.Upgrades.Allow(remoteIP)
+	if result.Allowed {
+		return true
+	}
+
+	ratelimit.WriteHeaders(w, result)
+	ratelimit.WriteLimited(w, result)
+	return false
+}
+
+// allowMessage takes a token from the connection's message limit, checked before decoding
+// so malformed messages count too
+// Called on the client's read goroutine
+func (c *Client) allowMessage() bool {
+	return c.allow(c.hub.rateLimits.Messages, c.connID, "")
+}
+
+// allowCreate takes a token from the IP's session creation limit, shared by all its connections
+// Called on the client's read goroutine
+func (c *Client) allowCreate() bool {
+	return c.allow(c.hub.rateLimits.Creates, c.remoteIP, "create_session")
+}
+
+// allow takes a token from key's bucket, telling the client when to retry if there isn't one
+func (c *Client) allow(limiter *ratelimit.Limiter, key, msgType string) bool {
+	result := limiter.Allow(key)
+	if result.Allowed {
+		return true
+	}
+
+	c.logger().Warn("Message rate limited", "type", msgType, "retryAfter", result.RetryAfter)
+	c.hub.counters.rateLimited.Add(1)
+	data := map[string]interface{}{
+		"code":       "rate_limited",
+		"message":    "Too many messages, slow down",
+		"retryAfter": int(math.Ceil(result.RetryAfter.Seconds())),
+	}
+	if msgType != "" {
+		data["type"] = msgType
+	}
+	c.SendMessage(&Message{Type: "error", Data: data})
+	return false
+}
@@ -0,0 +1,172 @@
+package websocket
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gorillaws "github.com/gorilla/websocket"
+
+	"github.com/cassiascheffer/uplift/internal/ratelimit"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// readReply reads the next message from conn
+func readReply(t *testing.T, conn *gorillaws.Conn) *Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("Expected a reply: %v", err)
+	}
+	return &msg
+}
+
+func TestRateLimits(t *testing.T) {
+	// Echo each message that gets through
+	hub := NewHub(func(client *Client, msg *Message) {
+		client.SendMessage(&Message{Type: msg.Type})
+	})
+	hub.SetRateLimits(RateLimits{
+		Upgrades: ratelimit.NewLimiter(ratelimit.Limit{Rate: 0.01, Burst: 2}),
+		Messages: ratelimit.NewLimiter(ratelimit.Limit{Rate: 0.01, Burst: 2}),
+		Creates:  ratelimit.NewLimiter(ratelimit.Limit{Rate: 0.01, Burst: 1}),
+	})
+	go hub.Run()
+
+	server := httptest.NewServer(NewHandler(hub))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	first, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer first.Close()
+	second, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer second.Close()
+
+	// A third connection from the same IP is refused before upgrading
+	_, resp, err := gorillaws.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("Expected the third connection to get 429 with Retry-After, got %v", err)
+	}
+
+	// Session creation is shared across the IP's connections
+	first.WriteJSON(Message{Type: "create_session"})
+	if reply := readReply(t, first); reply.Type != "create_session" {
+		t.Fatalf("Expected the first create_session through, got %+v", reply)
+	}
+	second.WriteJSON(Message{Type: "create_session"})
+	data := readReply(t, second).Data
+	if data["code"] != "rate_limited" || data["type"] != "create_session" || data["retryAfter"].(float64) < 1 {
+		t.Errorf("Expected create_session to be rate limited with retryAfter, got %+v", data)
+	}
+
+	// Each connection has its own message budget, and malformed frames count against it
+	first.WriteMessage(gorillaws.TextMessage, []byte("not json"))
+	if data := readReply(t, first).Data; data["code"] != "invalid_message" {
+		t.Errorf("Expected the malformed message to be processed, got %+v", data)
+	}
+	first.WriteJSON(Message{Type: "ping"})
+	if data := readReply(t, first).Data; data["code"] != "rate_limited" || data["type"] != nil {
+		t.Errorf("Expected the third message to be rate limited, got %+v", data)
+	}
+
+	if hub.Metrics().RateLimited != 2 {
+		t.Errorf("Expected 2 rate-limited messages, got %d", hub.Metrics().RateLimited)
+	}
+}
+
+func TestConnectionsPerIPCapped(t *testing.T) {
+	hub := NewHub(func(client *Client, msg *Message) {
+		client.SendMessage(&Message{Type: msg.Type})
+	})
+	hub.SetRateLimits(RateLimits{Connections: 1})
+	go hub.Run()
+
+	server := httptest.NewServer(NewHandler(hub))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	first, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+
+	// The second connection is told why, then closed
+	second, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer second.Close()
+	if data := readReply(t, second).Data; data["code"] != "too_many_connections" || data["limit"] != float64(1) {
+		t.Errorf("Expected too_many_connections, got %+v", data)
+	}
+	if _, _, err := second.ReadMessage(); !gorillaws.IsCloseError(err, gorillaws.ClosePolicyViolation) {
+		t.Errorf("Expected a policy violation close, got %v", err)
+	}
+
+	// Closing the first frees its place
+	first.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		hub.ipConnectionsMu.Lock()
+		open := len(hub.ipConnections)
+		hub.ipConnectionsMu.Unlock()
+		if open == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the closed connection to stop being counted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	third, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer third.Close()
+	third.WriteJSON(Message{Type: "ping"})
+	if reply := readReply(t, third); reply.Type != "ping" {
+		t.Errorf("Expected the third connection to be accepted, got %+v", reply.Data)
+	}
+}
+
+func TestSessionsPerIPLimited(t *testing.T) {
+	hub := NewHub(nil)
+	hub.SetRateLimits(RateLimits{Sessions: ratelimit.NewLimiter(ratelimit.Limit{Rate: 0.01, Burst: 1})})
+	mh := NewMessageHandler(hub, session.NewManager())
+
+	if _, _, err := mh.newSession(&createSessionRequest{UserName: "Sam"}, "", "203.0.113.7"); err != nil {
+		t.Fatalf("Expected the first session to be created: %v", err)
+	}
+
+	// Invalid options don't use up the allowance, so this is refused for its options
+	if _, _, err := mh.newSession(&createSessionRequest{Countdown: -1}, "", "203.0.113.7"); err == nil || errors.As(err, new(*SessionLimitError)) {
+		t.Errorf("Expected invalid options to be refused before the limit, got %v", err)
+	}
+
+	_, _, err := mh.newSession(&createSessionRequest{UserName: "Sam"}, "", "203.0.113.7")
+	var limited *SessionLimitError
+	if !errors.As(err, &limited) || limited.RetryAfter() <= 0 {
+		t.Fatalf("Expected the second session to be refused with a retry time, got %v", err)
+	}
+
+	// Other addresses have their own allowance
+	if _, _, err := mh.newSession(&createSessionRequest{UserName: "Sam"}, "", "198.51.100.2"); err != nil {
+		t.Errorf("Expected another address to create a session: %v", err)
+	}
+
+	client := newTestClient(hub, "", "")
+	client.sendSessionLimit(limited)
+	if data := nextMessage(t, client).Data; data["code"] != "session_limit" || data["retryAfter"].(float64) < 1 {
+		t.Errorf("Expected a session_limit error with retryAfter, got %+v", data)
+	}
+}
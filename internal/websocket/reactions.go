@@ -0,0 +1,47 @@
+// ABOUTME: react_note lets listeners cheer the note being read aloud with an emoji
+// ABOUTME: Each new reaction is broadcast as note_reaction with the note's updated counts
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// handleReactNote adds the client's reaction to the note being read and shares the new counts
+func (mh *MessageHandler) handleReactNote(client *Client, req *reactNoteRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if req.NoteID == "" {
+		mh.sendError(client, "noteId required")
+		return
+	}
+
+	emoji, err := session.ParseReaction(req.Emoji)
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	counts, added, err := sess.ReactToNote(client.userID, req.NoteID, emoji)
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	// Repeating a reaction changes nothing, so there's nothing to tell anyone
+	if !added {
+		return
+	}
+
+	mh.hub.BroadcastToSession(sess.ID, &Message{
+		Type: "note_reaction",
+		Data: map[string]interface{}{
+			"noteId":    req.NoteID,
+			"emoji":     emoji,
+			"reactions": counts,
+		},
+	})
+}
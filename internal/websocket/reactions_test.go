@@ -0,0 +1,52 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestReactNote(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	sess.TransitionToReading()
+
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	note := mh.drawNote(sess, sess.GetCurrentReader().ID)
+	drain([]*Client{host, aliceClient})
+
+	mh.HandleMessage(aliceClient, &Message{Type: "react_note", Data: map[string]interface{}{"noteId": note.ID, "emoji": "❤️"}})
+	reply := nextMessage(t, host)
+	reactions, _ := reply.Data["reactions"].(map[string]interface{})
+	if reply.Type != "note_reaction" || reply.Data["noteId"] != note.ID || reactions["❤️"] != float64(1) {
+		t.Errorf("Expected note_reaction with the count, got %+v", reply)
+	}
+	nextMessage(t, aliceClient)
+
+	mh.HandleMessage(aliceClient, &Message{Type: "react_note", Data: map[string]interface{}{"noteId": note.ID, "emoji": "🤷"}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected an unknown emoji to be refused, got %+v", reply)
+	}
+
+	complete := sessionCompleteMessage(sess, sess.HostID)
+	for _, entry := range complete.Data["notes"].([]map[string]interface{}) {
+		if entry["id"] == note.ID && entry["reactions"] == nil {
+			t.Errorf("Expected session_complete to include reaction counts, got %+v", entry)
+		}
+	}
+}
+
+func TestLiteSkipsReactions(t *testing.T) {
+	if lite(&Message{Type: "note_reaction", Data: map[string]interface{}{}}) != nil {
+		t.Error("Expected lite clients not to receive note_reaction")
+	}
+}
@@ -0,0 +1,147 @@
+// ABOUTME: Lets a participant whose browser refreshed or whose network dropped reclaim their place
+// ABOUTME: Handles rejoin_session and keeps emptied sessions around while anyone could still rejoin
+package websocket
+
+import (
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// addRejoinToken gives a participant the token they rejoin with
+func (mh *MessageHandler) addRejoinToken(sess *session.Session, participantID string, data map[string]interface{}) {
+	token, err := sess.IssueRejoinToken(participantID)
+	if err != nil {
+		sessionLogger(sess).Error("Failed to issue rejoin token", "userID", participantID, "err", err)
+		return
+	}
+	data["rejoinToken"] = token
+}
+
+// handleRejoinSession restores a participant under their original ID and sends them the
+// session's current state, including which notes they've already submitted
+func (mh *MessageHandler) handleRejoinSession(client *Client, req *rejoinSessionRequest) {
+	sessionCode, token := req.SessionCode, req.RejoinToken
+	if sessionCode == "" || token == "" {
+		mh.sendError(client, "session code and rejoin token required")
+		return
+	}
+	if client.sessionID != "" {
+		mh.sendError(client, "already in a session")
+		return
+	}
+
+	sess, err := mh.sessionManager.GetSessionByCode(sessionCode)
+	if err != nil && mh.onWrongInstance(client) {
+		mh.sendWrongInstance(client)
+		return
+	}
+	if err != nil {
+		mh.sendErrorCode(client, "rejoin_failed", "session not found")
+		return
+	}
+
+	participant, restored, err := sess.Rejoin(token)
+	if err != nil {
+		mh.sendErrorCode(client, "rejoin_failed", err.Error())
+		return
+	}
+
+	client.sessionID = sess.ID
+	client.userID = participant.ID
+	client.userName = participant.Name
+	client.resumeSeq = req.LastSeq
+
+	// Register client with hub now that we have sessionID
+	// Use goroutine to avoid blocking the hub's Run loop
+	go func() {
+		mh.hub.register <- client
+	}()
+
+	response := &Message{
+		Type: "session_rejoined",
+		Data: map[string]interface{}{
+			"sessionCode":      sess.Code,
+			"sessionId":        sess.ID,
+			"title":            sess.Title,
+			"welcome":          sess.Welcome,
+			"countdown":        sess.Countdown,
+			"maxNoteLength":    sess.Settings.MaxNoteLength,
+			"settings":         sess.Settings,
+			"minNoteChars":     sess.MinNoteChars,
+			"minNoteWords":     sess.MinNoteWords,
+			"autoRun":          sess.AutoRun,
+			"ratings":          sess.RatingsEnabled,
+			"duplicateNotes":   sess.DuplicatePolicy,
+			"userId":           participant.ID,
+			"userName":         participant.Name,
+			"isHost":           participant.ID == sess.HostID,
+			"participants":     sess.GetParticipantList(),
+			"phase":            sess.Phase,
+			"theme":            sess.Theme,
+			"prompt":           sess.Prompt,
+			"version":          sess.Version,
+			"rejoinToken":      token,
+			"totalNotesNeeded": len(sess.Participants) - 1,
+			"notesWrittenTo":   sess.RecipientsWrittenTo(participant.ID),
+		},
+	}
+	if sess.Phase == session.PhaseReading {
+		response.Data["currentReader"] = sess.GetCurrentReader()
+	}
+	mh.addStarters(sess, response.Data)
+	mh.addInstance(response.Data)
+	addDictationToken(client, response.Data)
+	client.SendMessage(response)
+	mh.sendDrainNotice(client)
+
+	// Someone coming back after the end still gets their notes
+	if sess.Phase == session.PhaseComplete {
+		complete := sessionCompleteMessage(sess, participant.ID)
+		mh.addExportToken(sess, participant.ID, complete.Data)
+		client.SendMessage(complete)
+	}
+
+	client.logger().Info("Participant rejoined", "sessionCode", sess.Code, "restored", restored)
+	if !restored {
+		return
+	}
+
+	mh.hub.BroadcastToSessionExcept(sess.ID, participant.ID, &Message{
+		Type: "participant_joined",
+		Data: map[string]interface{}{
+			"participant":  participant,
+			"participants": sess.GetParticipantList(),
+			"rejoined":     true,
+		},
+	})
+	if sess.Phase == session.PhaseWriting {
+		mh.scheduleWritingStats(sess)
+	}
+}
+
+// scheduleEmptyCleanup removes a session nobody is in once no one can rejoin it
+func (mh *MessageHandler) scheduleEmptyCleanup(sess *session.Session) {
+	sessionLogger(sess).Info("Empty session held for rejoining")
+	time.AfterFunc(session.RejoinWindow+time.Second, func() {
+		mh.hub.ScheduleSession(sess.ID, func() {
+			if len(sess.Participants) == 0 && !sess.AwaitingRejoin() {
+				mh.removeEmptySession(sess)
+			}
+		})
+	})
+}
+
+// removeEmptySession records an abandoned session and removes it from the manager
+func (mh *MessageHandler) removeEmptySession(sess *session.Session) {
+	if sess.Phase != session.PhaseComplete {
+		mh.analytics.RecordSessionAbandoned()
+		mh.recordHostCircle(sess, false)
+	}
+
+	if err := mh.sessionManager.RemoveSession(sess.ID); err != nil {
+		sessionLogger(sess).Error("Failed to remove empty session", "err", err)
+	} else {
+		sessionLogger(sess).Info("Empty session cleaned up")
+	}
+}
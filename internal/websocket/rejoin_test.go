@@ -0,0 +1,113 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// disconnect drops a test client from the hub and runs the disconnect handler
+func disconnect(mh *MessageHandler, hub *Hub, client *Client) {
+	hub.clientsMu.Lock()
+	delete(hub.clients[client.sessionID], client)
+	hub.clientsMu.Unlock()
+	mh.HandleClientDisconnect(client)
+}
+
+func TestRejoinSessionAfterDisconnect(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	bob, _ := sess.AddParticipant("Bob")
+	newTestClient(hub, sess.ID, bob.ID)
+
+	// Joining hands out a rejoin token
+	alice := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(alice, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": sess.Code, "userName": "Alice"}})
+	joined := nextMessage(t, alice)
+	token, _ := joined.Data["rejoinToken"].(string)
+	if joined.Type != "session_joined" || token == "" {
+		t.Fatalf("Expected session_joined with a rejoin token, got %+v", joined)
+	}
+	aliceID := alice.userID
+	newTestClient(hub, sess.ID, aliceID)
+	nextMessage(t, host) // participant_joined
+
+	sess.TransitionToWriting()
+	sess.AddNote(aliceID, bob.ID, "Thanks for the pairing")
+
+	// Her browser refreshes mid-writing
+	for _, client := range hub.sessionClients(sess.ID, "") {
+		if client.userID == aliceID {
+			disconnect(mh, hub, client)
+		}
+	}
+	if left := nextMessage(t, host); left.Type != "participant_left" {
+		t.Fatalf("Expected participant_left, got %+v", left)
+	}
+
+	refreshed := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(refreshed, &Message{Type: "rejoin_session", Data: map[string]interface{}{"sessionCode": sess.Code, "rejoinToken": token}})
+	rejoined := nextMessage(t, refreshed)
+	if rejoined.Type != "session_rejoined" || rejoined.Data["userId"] != aliceID || rejoined.Data["phase"] != string(session.PhaseWriting) {
+		t.Fatalf("Expected session_rejoined as Alice in writing, got %+v", rejoined)
+	}
+	if written, _ := rejoined.Data["notesWrittenTo"].([]interface{}); len(written) != 1 || written[0] != bob.ID {
+		t.Errorf("Expected her note to Bob to count as submitted, got %v", rejoined.Data["notesWrittenTo"])
+	}
+	if refreshed.userID != aliceID || refreshed.sessionID != sess.ID {
+		t.Error("Expected the new connection to take over Alice's place")
+	}
+
+	back := nextMessage(t, host)
+	if back.Type != "participant_joined" || back.Data["rejoined"] != true {
+		t.Errorf("Expected participant_joined marked as a rejoin, got %+v", back)
+	}
+
+	// A bad token is refused with a code the client can fall back on
+	stranger := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(stranger, &Message{Type: "rejoin_session", Data: map[string]interface{}{"sessionCode": sess.Code, "rejoinToken": "nope"}})
+	if msg := nextMessage(t, stranger); msg.Type != "error" || msg.Data["code"] != "rejoin_failed" {
+		t.Errorf("Expected rejoin_failed, got %+v", msg)
+	}
+}
+
+func TestStaleConnectionCloseAfterRejoin(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	stale := newTestClient(hub, sess.ID, alice.ID)
+	newTestClient(hub, sess.ID, alice.ID) // The replacement connection
+
+	// The old socket closing late mustn't remove her
+	disconnect(mh, hub, stale)
+	if !sess.HasParticipant(alice.ID) {
+		t.Error("Expected Alice to stay while she has another connection")
+	}
+}
+
+func TestEmptySessionHeldForRejoin(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	sess.IssueRejoinToken(sess.HostID)
+	host := newTestClient(hub, sess.ID, sess.HostID)
+
+	disconnect(mh, hub, host)
+	if _, err := manager.GetSessionByID(sess.ID); err != nil {
+		t.Error("Expected the empty session to be kept while the host could rejoin")
+	}
+
+	mh.removeEmptySession(sess)
+	if _, err := manager.GetSessionByID(sess.ID); err == nil {
+		t.Error("Expected the session to be removed")
+	}
+}
@@ -0,0 +1,68 @@
+// ABOUTME: Host command to reopen writing from reading when someone was forgotten
+// ABOUTME: Cancels any pending auto-run read and tells everyone why the circle went back
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// reopenWritingMessage explains the reopen to everyone in the circle
+const reopenWritingMessage = "The host reopened writing so someone who was missed can join and be written to. Reading will start again from the beginning."
+
+// handleReopenWriting moves a session back from reading to writing before any note is read (host only)
+func (mh *MessageHandler) handleReopenWriting(client *Client, req *phaseActionRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if client.userID != sess.HostID {
+		client.logger().Warn("Non-host tried to reopen writing", "hostID", sess.HostID)
+		mh.sendError(client, "only host can reopen writing")
+		return
+	}
+
+	if !mh.checkVersion(client, sess, req.versioned) {
+		return
+	}
+
+	if mh.countingDown(sess.ID) {
+		mh.sendError(client, "phase change already in progress")
+		return
+	}
+
+	returned, err := sess.ReopenWriting()
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	// A pending auto-run read would otherwise finish a turn in the writing phase
+	mh.cancelAutoRun(sess)
+
+	broadcast := &Message{
+		Type: "writing_reopened",
+		Data: map[string]interface{}{
+			"phase":            session.PhaseWriting,
+			"version":          sess.Version,
+			"theme":            sess.Theme,
+			"prompt":           sess.Prompt,
+			"participants":     sess.GetParticipantList(),
+			"totalNotesNeeded": len(sess.Participants) - 1,
+			"maxNoteLength":    sess.Settings.MaxNoteLength,
+			"minNoteChars":     sess.MinNoteChars,
+			"minNoteWords":     sess.MinNoteWords,
+			"returnedNoteIds":  returned,
+			"sessionCode":      sess.Code,
+			"message":          reopenWritingMessage,
+		},
+	}
+	mh.addWritingTimer(sess, broadcast.Data)
+	mh.addStarters(sess, broadcast.Data)
+	mh.hub.BroadcastToSession(sess.ID, broadcast)
+	mh.sendWritingStats(sess)
+	mh.notifyBreakoutProgress(sess)
+
+	sessionLogger(sess).Info("Writing reopened", "returnedNotes", len(returned))
+}
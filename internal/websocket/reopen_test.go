@@ -0,0 +1,97 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestReopenWriting(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	sess.TransitionToReading()
+
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.HandleMessage(aliceClient, &Message{Type: "reopen_writing", Data: map[string]interface{}{}})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected non-host reopen to be refused, got %+v", reply)
+	}
+
+	mh.HandleMessage(host, &Message{Type: "reopen_writing", Data: map[string]interface{}{}})
+	reply := nextMessage(t, aliceClient)
+	if reply.Type != "writing_reopened" || reply.Data["message"] != reopenWritingMessage {
+		t.Errorf("Expected writing_reopened broadcast, got %+v", reply)
+	}
+	if sess.Phase != session.PhaseWriting {
+		t.Errorf("Expected writing phase, got %s", sess.Phase)
+	}
+}
+
+func TestRollbackSession(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	hub.SetMessageHandler(mh.HandleMessage)
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	sess.TransitionToReading()
+
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	result, err := mh.RollbackSession(sess.Code, session.PhaseJoining)
+	if err != nil {
+		t.Fatalf("Expected rollback to succeed: %v", err)
+	}
+	if result.NotesDiscarded != 2 || sess.Phase != session.PhaseJoining {
+		t.Errorf("Expected joining with notes discarded, got %s and %+v", sess.Phase, result)
+	}
+
+	reply := nextMessage(t, aliceClient)
+	if reply.Type != "phase_changed" || reply.Data["rolledBack"] != true {
+		t.Errorf("Expected rolled back phase_changed, got %+v", reply)
+	}
+
+	if _, err := mh.RollbackSession(sess.Code, session.PhaseReading); err == nil {
+		t.Error("Expected rolling forward to be refused")
+	}
+}
+
+func TestStaleVersionIsRefused(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	stale := float64(sess.Version)
+
+	mh.HandleMessage(host, &Message{Type: "start_writing", Data: map[string]interface{}{"version": stale}})
+	if reply := nextMessage(t, host); reply.Type != "phase_changed" {
+		t.Fatalf("Expected writing to start, got %+v", reply)
+	}
+	nextMessage(t, host) // writing_stats
+
+	// A second tab that hadn't seen the change tries to undo
+	mh.HandleMessage(host, &Message{Type: "undo_transition", Data: map[string]interface{}{"version": stale}})
+	if reply := nextMessage(t, host); reply.Type != "error" || reply.Data["code"] != "version_conflict" {
+		t.Errorf("Expected version_conflict, got %+v", reply)
+	}
+	if sess.Phase != session.PhaseWriting {
+		t.Errorf("Expected stale undo to be ignored, got %s", sess.Phase)
+	}
+}
@@ -0,0 +1,127 @@
+// ABOUTME: Keeps a short history of each session's broadcasts, numbered in the order they went out
+// ABOUTME: A client rejoining with the last number it saw is sent the broadcasts it missed
+package websocket
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+const (
+	// replayCapacity is how many recent broadcasts each session keeps for replay
+	replayCapacity = 256
+
+	// replayRetention is how long a broadcast is kept: as long as anyone could rejoin
+	replayRetention = session.RejoinWindow
+)
+
+// replayEntry is one numbered broadcast
+type replayEntry struct {
+	message *Message // Carries its Seq
+	except  string   // User the broadcast skipped ("" = nobody)
+	at      time.Time
+}
+
+// replayBuffer is a session's recent broadcasts, oldest first
+type replayBuffer struct {
+	seq     uint64 // Number of the latest broadcast
+	entries []replayEntry
+}
+
+// remember numbers a broadcast and keeps it for replay, returning the numbered copy
+// Called with the session's outbox held, so numbers follow delivery order
+func (h *Hub) remember(sessionID, exceptUserID string, message *Message) *Message {
+	h.historyMu.Lock()
+	defer h.historyMu.Unlock()
+
+	buffer, exists := h.history[sessionID]
+	if !exists {
+		buffer = &replayBuffer{}
+		h.history[sessionID] = buffer
+	}
+	buffer.seq++
+
+	numbered := *message
+	numbered.Seq = buffer.seq
+	if len(buffer.entries) == replayCapacity {
+		copy(buffer.entries, buffer.entries[1:])
+		buffer.entries = buffer.entries[:replayCapacity-1]
+	}
+	buffer.entries = append(buffer.entries, replayEntry{message: &numbered, except: exceptUserID, at: time.Now()})
+	return &numbered
+}
+
+// replay sends a registering client every broadcast after the last one it saw
+// If any of them are no longer kept, or the numbers are from another server or an earlier
+// run, it sends replay_unavailable instead so the client relies on the state it rejoined with
+// Called with the session's outbox held, so nothing can be broadcast in between
+func (h *Hub) replay(client *Client) {
+	h.historyMu.Lock()
+	buffer := h.history[client.sessionID]
+	var missed []replayEntry
+	var latest uint64
+	available := false
+	if buffer != nil {
+		latest = buffer.seq
+		first := buffer.seq + 1 // Oldest number still kept
+		if len(buffer.entries) > 0 {
+			first = buffer.entries[0].message.Seq
+		}
+		available = client.resumeSeq <= buffer.seq && first <= client.resumeSeq+1
+		for _, entry := range buffer.entries {
+			if available && entry.message.Seq > client.resumeSeq && entry.except != client.userID {
+				missed = append(missed, entry)
+			}
+		}
+	}
+	h.historyMu.Unlock()
+
+	if !available {
+		client.logger().Info("Missed broadcasts unavailable", "lastSeq", client.resumeSeq, "seq", latest)
+		client.SendMessage(&Message{
+			Type: "replay_unavailable",
+			Data: map[string]interface{}{
+				"lastSeq": client.resumeSeq,
+				"seq":     latest,
+			},
+		})
+		return
+	}
+
+	for _, entry := range missed {
+		if err := client.SendMessage(entry.message); err != nil {
+			slog.Error("Failed to replay broadcast", "messageType", entry.message.Type, "sessionID", client.sessionID, "err", err)
+		}
+	}
+	if len(missed) > 0 {
+		client.logger().Info("Replayed missed broadcasts", "lastSeq", client.resumeSeq, "count", len(missed))
+	}
+}
+
+// pruneHistory drops broadcasts older than replayRetention, and the history and outbox
+// of sessions with nobody connected once their history is empty
+// Runs on the hub goroutine
+func (h *Hub) pruneHistory(now time.Time) {
+	cutoff := now.Add(-replayRetention)
+
+	h.clientsMu.Lock()
+	defer h.clientsMu.Unlock()
+	h.historyMu.Lock()
+	defer h.historyMu.Unlock()
+
+	for sessionID, buffer := range h.history {
+		expired := 0
+		for expired < len(buffer.entries) && buffer.entries[expired].at.Before(cutoff) {
+			expired++
+		}
+		buffer.entries = append(buffer.entries[:0], buffer.entries[expired:]...)
+
+		// Numbering carries on while anyone is connected
+		if len(buffer.entries) == 0 && len(h.clients[sessionID]) == 0 {
+			delete(h.history, sessionID)
+			delete(h.outboxes, sessionID)
+		}
+	}
+}
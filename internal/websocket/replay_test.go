@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// registerResuming registers a client for userID that last saw broadcast lastSeq
+func registerResuming(hub *Hub, sessionID, userID string, lastSeq uint64) *Client {
+	client := &Client{send: make(chan []byte, 512), hub: hub, sessionID: sessionID, userID: userID, resumeSeq: lastSeq}
+	hub.register <- client
+	hub.Alive(time.Second)
+	return client
+}
+
+func TestRejoiningClientGetsMissedBroadcasts(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+	staying := newTestClient(hub, "session-1", "alice")
+
+	hub.BroadcastToSession("session-1", &Message{Type: "phase_changed", Data: map[string]interface{}{"phase": "WRITING"}})
+	hub.BroadcastToSessionExcept("session-1", "bob", &Message{Type: "participant_left"})
+	hub.BroadcastToSession("session-1", &Message{Type: "phase_changed", Data: map[string]interface{}{"phase": "READING"}})
+
+	for want := uint64(1); want <= 3; want++ {
+		if msg := nextMessage(t, staying); msg.Seq != want {
+			t.Fatalf("Expected broadcasts numbered in order, got seq %d want %d", msg.Seq, want)
+		}
+	}
+
+	// Bob saw the first broadcast before dropping; the second wasn't for him
+	bob := registerResuming(hub, "session-1", "bob", 1)
+	msg := nextMessage(t, bob)
+	if msg.Type != "phase_changed" || msg.Seq != 3 || msg.Data["phase"] != "READING" {
+		t.Fatalf("Expected the missed READING broadcast, got %s seq=%d %v", msg.Type, msg.Seq, msg.Data)
+	}
+	select {
+	case data := <-bob.send:
+		t.Errorf("Expected nothing else replayed, got %s", data)
+	default:
+	}
+
+	// Broadcasts after registering arrive as usual
+	hub.BroadcastToSession("session-1", &Message{Type: "note_drawn"})
+	if msg := nextMessage(t, bob); msg.Type != "note_drawn" || msg.Seq != 4 {
+		t.Errorf("Expected the next broadcast live, got %s seq=%d", msg.Type, msg.Seq)
+	}
+}
+
+func TestReplayUnavailable(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+
+	// Nobody is connected, but broadcasts are still kept for whoever comes back
+	for i := 0; i < replayCapacity+10; i++ {
+		hub.BroadcastToSession("session-1", &Message{Type: "countdown", Data: map[string]interface{}{"i": i}})
+	}
+
+	tests := []struct {
+		name      string
+		lastSeq   uint64
+		available bool
+	}{
+		{"still kept", 20, true},
+		{"fallen out of the buffer", 5, false},
+		{"from another server", replayCapacity + 50, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := registerResuming(hub, "session-1", fmt.Sprintf("user-%d", tt.lastSeq), tt.lastSeq)
+			msg := nextMessage(t, client)
+			if (msg.Type != "replay_unavailable") != tt.available {
+				t.Errorf("Expected available=%v, got %s %v", tt.available, msg.Type, msg.Data)
+			}
+			if tt.available && msg.Seq != tt.lastSeq+1 {
+				t.Errorf("Expected replay to start after seq %d, got %d", tt.lastSeq, msg.Seq)
+			}
+		})
+	}
+
+	if msg := nextMessage(t, registerResuming(hub, "session-2", "carol", 3)); msg.Type != "replay_unavailable" {
+		t.Errorf("Expected replay_unavailable for a session with no history, got %s", msg.Type)
+	}
+}
+
+func TestPruneHistory(t *testing.T) {
+	hub := NewHub(nil)
+	hub.BroadcastToSession("empty", &Message{Type: "phase_changed"})
+	newTestClient(hub, "connected", "alice")
+	hub.BroadcastToSession("connected", &Message{Type: "phase_changed"})
+
+	hub.pruneHistory(time.Now().Add(replayRetention + time.Second))
+
+	if _, exists := hub.history["empty"]; exists {
+		t.Error("Expected the history of a session nobody is connected to to be dropped")
+	}
+	if buffer := hub.history["connected"]; buffer == nil || len(buffer.entries) != 0 || buffer.seq != 1 {
+		t.Errorf("Expected old broadcasts dropped but numbering kept, got %+v", buffer)
+	}
+}
@@ -0,0 +1,71 @@
+// ABOUTME: Creates sessions for HTTP API clients that don't hold a WebSocket connection
+// ABOUTME: The host claims their seat later with a rejoin token, or the session is cleaned up
+package websocket
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/moderation"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// CreateSession creates a session from the JSON options create_session accepts, for
+// a host who isn't connected yet. They take their seat by sending rejoin_session with
+// a token from the session; if nobody has within the rejoin window they're removed
+// and the session is cleaned up
+// remoteIP counts towards the same per-IP limits as create_session, and unless keyed
+// (the caller authenticated with an API key) the options must carry a solved challenge
+// Safe to call from any goroutine
+func (mh *MessageHandler) CreateSession(options []byte, tenant, remoteIP string, keyed bool) (*session.Session, *session.Participant, error) {
+	if result := mh.hub.rateLimits.Creates.Allow(remoteIP); !result.Allowed {
+		slog.Warn("HTTP session creation rate limited", "remoteIP", remoteIP, "retryAfter", result.RetryAfter)
+		mh.hub.counters.rateLimited.Add(1)
+		return nil, nil, &SessionLimitError{retryAfter: result.RetryAfter}
+	}
+
+	var req createSessionRequest
+	if err := decodeStrict(options, &req); err != nil {
+		return nil, nil, err
+	}
+	if !keyed {
+		if err := mh.checkChallenge(req.Challenge, req.Solution); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := cleanCreateRequest(&req); err != nil {
+		return nil, nil, err
+	}
+	logger := slog.With("remoteIP", remoteIP)
+	if err := mh.moderate(logger, moderation.KindName, []string{req.UserName}); err != nil {
+		return nil, nil, err
+	}
+	if err := mh.moderate(logger, moderation.KindSession, []string{req.Welcome, req.Prompt}); err != nil {
+		return nil, nil, err
+	}
+
+	type created struct {
+		sess *session.Session
+		host *session.Participant
+		err  error
+	}
+
+	result := make(chan created, 1)
+	mh.hub.Schedule(func() {
+		sess, host, err := mh.newSession(&req, tenant, remoteIP)
+		result <- created{sess, host, err}
+	})
+	c := <-result
+	if c.err != nil {
+		return nil, nil, c.err
+	}
+
+	time.AfterFunc(session.RejoinWindow, func() {
+		mh.hub.ScheduleSession(c.sess.ID, func() {
+			mh.expireAbsent(c.sess)
+		})
+	})
+
+	sessionLogger(c.sess).Info("Session created over HTTP")
+	return c.sess, c.host, nil
+}
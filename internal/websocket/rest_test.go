@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/abuse"
+	"github.com/cassiascheffer/uplift/internal/ratelimit"
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestCreateSessionForHTTPHost(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	go hub.Run()
+
+	if _, _, err := mh.CreateSession([]byte(`{"userName": "Sam", "countdown": -1}`), "", "", true); err == nil {
+		t.Error("Expected create_session validation to apply")
+	}
+
+	sess, host, err := mh.CreateSession([]byte(`{"userName": "Sam", "ratings": true}`), "", "", true)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if host.Name != "Sam" || sess.HostID != host.ID || !sess.RatingsEnabled {
+		t.Errorf("Expected Sam hosting a session with ratings, got host=%+v", host)
+	}
+
+	// The host takes their seat over the WebSocket with a rejoin token
+	token, _ := sess.IssueRejoinToken(host.ID)
+	client := &Client{send: make(chan []byte, 16), hub: hub}
+	done := make(chan struct{})
+	hub.Schedule(func() {
+		mh.HandleMessage(client, &Message{Type: "rejoin_session", Data: map[string]interface{}{"sessionCode": sess.Code, "rejoinToken": token}})
+		close(done)
+	})
+	<-done
+
+	rejoined := nextMessage(t, client)
+	if rejoined.Type != "session_rejoined" || rejoined.Data["isHost"] != true {
+		t.Errorf("Expected the host to rejoin, got %+v", rejoined)
+	}
+}
+
+func TestCreateSessionOverHTTPNeedsChallengeOrKey(t *testing.T) {
+	hub := NewHub(nil)
+	hub.SetRateLimits(RateLimits{Creates: ratelimit.NewLimiter(ratelimit.Limit{Rate: 0.01, Burst: 3})})
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	go hub.Run()
+
+	challenger, _ := abuse.NewChallenger(4)
+	mh.SetChallenger(challenger)
+
+	if _, _, err := mh.CreateSession([]byte(`{"userName": "Sam"}`), "", "10.0.0.1", false); !errors.Is(err, abuse.ErrChallengeRequired) {
+		t.Errorf("Expected a challenge to be required without an API key, got %v", err)
+	}
+
+	challenge := challenger.Issue()
+	solution := ""
+	for i := 0; abuse.LeadingZeroBits(challenge, solution) < challenger.Difficulty(); i++ {
+		solution = strconv.Itoa(i)
+	}
+	options, _ := json.Marshal(map[string]string{"userName": "Sam", "challenge": challenge, "solution": solution})
+	if _, _, err := mh.CreateSession(options, "", "10.0.0.1", false); err != nil {
+		t.Errorf("Expected a solved challenge to be accepted, got %v", err)
+	}
+
+	if _, _, err := mh.CreateSession([]byte(`{"userName": "Sam"}`), "", "10.0.0.1", true); err != nil {
+		t.Errorf("Expected an API key to stand in for the challenge, got %v", err)
+	}
+
+	// The same per-IP create limit as create_session applies
+	var limited *SessionLimitError
+	if _, _, err := mh.CreateSession([]byte(`{"userName": "Sam"}`), "", "10.0.0.1", true); !errors.As(err, &limited) {
+		t.Errorf("Expected the create limit to apply, got %v", err)
+	}
+}
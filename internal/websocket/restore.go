@@ -0,0 +1,50 @@
+// ABOUTME: Picks up sessions loaded from a persistent store after a restart or from another replica
+// ABOUTME: Resumes auto-run timers and removes participants who don't reconnect within the rejoin window
+package websocket
+
+import (
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// AdoptSession takes over a session loaded from a persistent store
+// Nobody is connected yet, so participants get the rejoin window to come back
+// Safe to call from any goroutine
+func (mh *MessageHandler) AdoptSession(sess *session.Session) {
+	go mh.hub.ScheduleSession(sess.ID, func() {
+		mh.resumeAutoRun(sess)
+	})
+
+	time.AfterFunc(session.RejoinWindow, func() {
+		mh.hub.ScheduleSession(sess.ID, func() {
+			mh.expireAbsent(sess)
+		})
+	})
+}
+
+// resumeAutoRun restarts the timer for an auto-run session's current phase
+// Runs on the session's actor
+func (mh *MessageHandler) resumeAutoRun(sess *session.Session) {
+	switch sess.Phase {
+	case session.PhaseJoining:
+		mh.scheduleAutoStart(sess)
+	case session.PhaseReading:
+		mh.scheduleAutoDraw(sess)
+	}
+}
+
+// expireAbsent removes participants of an adopted or HTTP-created session who never connected
+// Runs on the session's actor
+func (mh *MessageHandler) expireAbsent(sess *session.Session) {
+	if _, err := mh.sessionManager.GetSessionByID(sess.ID); err != nil {
+		return // Already ended
+	}
+
+	for _, participant := range sess.GetParticipantList() {
+		if !mh.hub.IsUserConnected(sess.ID, participant.ID) {
+			sessionLogger(sess).Info("Participant didn't connect within the rejoin window", "userID", participant.ID)
+			mh.participantLeft(sess, participant.ID)
+		}
+	}
+}
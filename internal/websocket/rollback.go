@@ -0,0 +1,77 @@
+// ABOUTME: Admin repair that moves a live session back to an earlier phase
+// ABOUTME: Cancels pending timers, tells every client the restored phase and restarts auto-run
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// RollbackSession moves the session with the given code back to an earlier phase
+// Safe to call from any goroutine; the rollback runs on the session's actor
+func (mh *MessageHandler) RollbackSession(code string, phase session.Phase) (session.RollbackResult, error) {
+	sess, err := mh.sessionManager.GetSessionByCode(code)
+	if err != nil {
+		return session.RollbackResult{}, err
+	}
+
+	type outcome struct {
+		result session.RollbackResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	mh.hub.ScheduleSession(sess.ID, func() {
+		result, err := mh.rollbackSession(sess, phase)
+		done <- outcome{result, err}
+	})
+	o := <-done
+	return o.result, o.err
+}
+
+// rollbackSession rolls the session back and broadcasts the restored phase
+// Runs on the session's actor
+func (mh *MessageHandler) rollbackSession(sess *session.Session, phase session.Phase) (session.RollbackResult, error) {
+	result, err := sess.Rollback(phase)
+	if err != nil {
+		return result, err
+	}
+	mh.cancelCountdown(sess)
+	mh.cancelAutoRun(sess)
+
+	data := map[string]interface{}{
+		"phase":            sess.Phase,
+		"theme":            sess.Theme,
+		"prompt":           sess.Prompt,
+		"version":          sess.Version,
+		"participants":     sess.GetParticipantList(),
+		"totalNotesNeeded": len(sess.Participants) - 1,
+		"maxNoteLength":    sess.Settings.MaxNoteLength,
+		"minNoteChars":     sess.MinNoteChars,
+		"minNoteWords":     sess.MinNoteWords,
+		"returnedNoteIds":  result.ReturnedNoteIDs,
+		"rolledBack":       true,
+	}
+	if phase == session.PhaseReading {
+		data["currentReader"] = sess.GetCurrentReader()
+	}
+	mh.addStarters(sess, data)
+	mh.hub.BroadcastToSession(sess.ID, &Message{Type: "phase_changed", Data: data})
+	mh.notifyBreakoutProgress(sess)
+	mh.refreshOutdated(sess)
+
+	// Pick auto-run back up from the restored phase
+	switch phase {
+	case session.PhaseJoining:
+		mh.scheduleAutoStart(sess)
+	case session.PhaseReading:
+		mh.scheduleAutoDraw(sess)
+	}
+
+	sessionLogger(sess).Info("Session rolled back", "from", result.From, "to", result.To)
+	return result, nil
+}
+
+// cancelAutoRun supersedes any pending auto-run step so it can't act on a phase
+// the session has left
+func (mh *MessageHandler) cancelAutoRun(sess *session.Session) {
+	mh.autoRunSteps.update(sess.ID, increment)
+}
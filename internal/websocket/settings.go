@@ -0,0 +1,63 @@
+// ABOUTME: Applies a session's host-chosen settings to what clients are sent
+// ABOUTME: Names authors in attributed sessions and announces when a timed writing phase runs out
+package websocket
+
+import (
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// addAuthor names a note's author in entry if the session shares notes with their author
+func addAuthor(sess *session.Session, note *session.Note, entry map[string]interface{}) {
+	if !sess.AttributesNotes() {
+		return
+	}
+
+	entry["authorId"] = note.AuthorID
+	if author, exists := sess.Participants[note.AuthorID]; exists {
+		entry["author"] = author.Name
+	}
+}
+
+// addWritingTimer adds when writing ends to a writing phase broadcast and starts the timer
+// Untimed sessions are left as they are
+// Runs on the session's actor
+func (mh *MessageHandler) addWritingTimer(sess *session.Session, data map[string]interface{}) {
+	seconds := sess.Settings.WritingTimer
+	if seconds <= 0 {
+		return
+	}
+
+	duration := time.Duration(seconds) * time.Second
+	data["writingTimer"] = seconds
+	data["writingEndsAt"] = time.Now().Add(duration).UnixMilli()
+
+	// Any phase change since bumps the version, making this timer stale
+	version := sess.Version
+	time.AfterFunc(duration, func() {
+		mh.hub.ScheduleSession(sess.ID, func() {
+			if sess.Phase != session.PhaseWriting || sess.Version != version {
+				return
+			}
+			mh.writingTimeUp(sess)
+		})
+	})
+}
+
+// writingTimeUp tells the circle the writing time has run out
+// Reading still waits for every note, so the host can nudge or remove whoever is left
+func (mh *MessageHandler) writingTimeUp(sess *session.Session) {
+	stats := sess.GetWritingStats()
+	mh.hub.BroadcastToSession(sess.ID, &Message{
+		Type: "writing_time_up",
+		Data: map[string]interface{}{
+			"version":       sess.Version,
+			"totalNotes":    stats.TotalNotes,
+			"expectedNotes": stats.ExpectedNotes,
+		},
+	})
+	mh.sendWritingStats(sess)
+
+	sessionLogger(sess).Info("Writing time up", "notes", stats.TotalNotes, "expected", stats.ExpectedNotes)
+}
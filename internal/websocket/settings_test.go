@@ -0,0 +1,168 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestCreateSessionAppliesSettings(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess, _, err := mh.newSession(&createSessionRequest{
+		UserName: "Host",
+		Settings: session.SessionSettings{MaxParticipants: 2, Anonymity: session.AnonymityAttributed},
+	}, "", "")
+	if err != nil {
+		t.Fatalf("Expected session to be created: %v", err)
+	}
+
+	settings := sess.GetSettings()
+	if settings.MaxParticipants != 2 || settings.Anonymity != session.AnonymityAttributed {
+		t.Errorf("Expected chosen settings, got %+v", settings)
+	}
+	if settings.MaxNoteLength != session.DefaultMaxNoteLength {
+		t.Errorf("Expected default note length, got %d", settings.MaxNoteLength)
+	}
+
+	alice := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(alice, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": sess.Code, "userName": "Alice"}})
+	if reply := nextMessage(t, alice); reply.Type != "session_joined" {
+		t.Fatalf("Expected Alice to join, got %+v", reply)
+	}
+
+	bob := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(bob, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": sess.Code, "userName": "Bob"}})
+	if reply := nextMessage(t, bob); reply.Type != "error" || reply.Data["code"] != "session_full" || reply.Data["message"] != "session is full (max 2 participants)" {
+		t.Errorf("Expected full session to refuse Bob, got %+v", reply)
+	}
+}
+
+func TestCreateSessionRejectsInvalidSettings(t *testing.T) {
+	hub := NewHub(nil)
+	mh := NewMessageHandler(hub, session.NewManager())
+
+	cases := []session.SessionSettings{
+		{MaxParticipants: 1},
+		{MaxParticipants: maxParticipants + 1},
+		{MaxNoteLength: 10},
+		{Anonymity: "secret"},
+		{WritingTimer: -1},
+		{DuplicateNames: "allow"},
+	}
+	for _, settings := range cases {
+		if _, _, err := mh.newSession(&createSessionRequest{Settings: settings}, "", ""); err == nil {
+			t.Errorf("Expected %+v to be refused", settings)
+		}
+	}
+
+	if _, _, err := mh.newSession(&createSessionRequest{AutoStartAt: 5, Settings: session.SessionSettings{MaxParticipants: 4}}, "", ""); err == nil {
+		t.Error("Expected an auto-start threshold above the participant limit to be refused")
+	}
+}
+
+func TestLegacyMaxNoteLengthIsHonoured(t *testing.T) {
+	hub := NewHub(nil)
+	mh := NewMessageHandler(hub, session.NewManager())
+
+	sess, _, err := mh.newSession(&createSessionRequest{MaxNoteLength: 280}, "", "")
+	if err != nil {
+		t.Fatalf("Expected session to be created: %v", err)
+	}
+	if sess.Settings.MaxNoteLength != 280 {
+		t.Errorf("Expected top-level maxNoteLength to apply, got %d", sess.Settings.MaxNoteLength)
+	}
+}
+
+func TestLateJoinDuringWriting(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+
+	bob := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(bob, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": sess.Code, "userName": "Bob"}})
+	if reply := nextMessage(t, bob); reply.Type != "error" {
+		t.Fatalf("Expected late join to be refused by default, got %+v", reply)
+	}
+
+	sess.SetSettings(session.SessionSettings{AllowLateJoin: true})
+	mh.HandleMessage(bob, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": sess.Code, "userName": "Bob"}})
+	if reply := nextMessage(t, bob); reply.Type != "session_joined" || reply.Data["phase"] != string(session.PhaseWriting) {
+		t.Errorf("Expected Bob to join during writing, got %+v", reply)
+	}
+}
+
+func TestAttributedNotesNameTheirAuthor(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	sess.SetSettings(session.SessionSettings{Anonymity: session.AnonymityAttributed})
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, sess.HostID, "Thanks Host")
+	sess.AddNote(sess.HostID, alice.ID, "Thanks Alice")
+	sess.TransitionToReading()
+
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	reader := sess.GetCurrentReader()
+	note := mh.drawNote(sess, reader.ID)
+	if note == nil {
+		t.Fatal("Expected a note to be drawn")
+	}
+
+	reply := nextMessage(t, host)
+	drawn, _ := reply.Data["note"].(map[string]interface{})
+	if reply.Type != "note_drawn" || drawn["authorId"] != note.AuthorID || drawn["author"] == nil {
+		t.Errorf("Expected the drawn note to name its author, got %+v", reply)
+	}
+}
+
+func TestWritingTimerIsAnnounced(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	sess.SetSettings(session.SessionSettings{WritingTimer: 300})
+	sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+
+	mh.HandleMessage(host, &Message{Type: "start_writing", Data: map[string]interface{}{}})
+	reply := nextMessage(t, host)
+	if reply.Type != "phase_changed" || reply.Data["writingTimer"] != float64(300) || reply.Data["writingEndsAt"] == nil {
+		t.Errorf("Expected writing phase to carry its timer, got %+v", reply)
+	}
+}
+
+func TestDuplicateNamesCanBeRefused(t *testing.T) {
+	hub := NewHub(nil)
+	mh := NewMessageHandler(hub, session.NewManager())
+
+	sess, _, err := mh.newSession(&createSessionRequest{
+		UserName: "Host",
+		Settings: session.SessionSettings{DuplicateNames: session.DuplicateNamesReject},
+	}, "", "")
+	if err != nil {
+		t.Fatalf("Expected session to be created: %v", err)
+	}
+
+	alex := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(alex, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": sess.Code, "userName": "Alex"}})
+	if reply := nextMessage(t, alex); reply.Type != "session_joined" {
+		t.Fatalf("Expected Alex to join, got %+v", reply)
+	}
+
+	another := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(another, &Message{Type: "join_session", Data: map[string]interface{}{"sessionCode": sess.Code, "userName": "alex"}})
+	if reply := nextMessage(t, another); reply.Type != "error" || reply.Data["code"] != "name_taken" {
+		t.Errorf("Expected a second Alex to be refused with name_taken, got %+v", reply)
+	}
+}
@@ -0,0 +1,91 @@
+// ABOUTME: Splits an over-large joining session so the host can run two smaller circles
+// ABOUTME: Moves the chosen participants' clients to the new session and tells both circles
+package websocket
+
+import (
+	"errors"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// handleSplitSession moves the chosen participants into a new session (host only)
+func (mh *MessageHandler) handleSplitSession(client *Client, req *splitSessionRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if client.userID != sess.HostID {
+		client.logger().Warn("Non-host tried to split session", "hostID", sess.HostID)
+		mh.sendError(client, "only host can split the session")
+		return
+	}
+
+	if err := mh.splitSession(sess, req.ParticipantIDs, req.HostID); err != nil {
+		mh.sendError(client, err.Error())
+	}
+}
+
+// splitSession moves the participants into a new session and migrates their clients
+// Runs on the hub goroutine
+func (mh *MessageHandler) splitSession(sess *session.Session, participantIDs []string, hostID string) error {
+	if mh.countingDown(sess.ID) {
+		return errors.New("phase change already in progress")
+	}
+
+	split, err := mh.sessionManager.SplitSession(sess, participantIDs, hostID)
+	if err != nil {
+		return err
+	}
+
+	splitParticipants := split.GetParticipantList()
+
+	// Move everyone first so the remaining circle's broadcast doesn't reach them
+	for _, participant := range splitParticipants {
+		client := mh.hub.MoveUser(sess.ID, participant.ID, split.ID)
+		if client == nil {
+			continue
+		}
+
+		splitMessage := &Message{
+			Type: "session_split",
+			Data: map[string]interface{}{
+				"fromSessionCode": sess.Code,
+				"sessionCode":     split.Code,
+				"sessionId":       split.ID,
+				"title":           split.Title,
+				"welcome":         split.Welcome,
+				"autoStartAt":     split.AutoStartAt,
+				"countdown":       split.Countdown,
+				"maxNoteLength":   split.Settings.MaxNoteLength,
+				"settings":        split.Settings,
+				"minNoteChars":    split.MinNoteChars,
+				"minNoteWords":    split.MinNoteWords,
+				"autoRun":         split.AutoRun,
+				"ratings":         split.RatingsEnabled,
+				"userId":          participant.ID,
+				"userName":        participant.Name,
+				"isHost":          participant.ID == split.HostID,
+				"participants":    splitParticipants,
+				"phase":           split.Phase,
+				"theme":           split.Theme,
+				"prompt":          split.Prompt,
+			},
+		}
+		mh.addRejoinToken(split, participant.ID, splitMessage.Data)
+		client.SendMessage(splitMessage)
+	}
+
+	mh.hub.BroadcastToSession(sess.ID, &Message{
+		Type: "participants_split",
+		Data: map[string]interface{}{
+			"newSessionCode": split.Code,
+			"movedIds":       participantIDs,
+			"participants":   sess.GetParticipantList(),
+		},
+	})
+
+	sessionLogger(sess).Info("Session split", "splitSessionID", split.ID, "splitSessionCode", split.Code, "moved", len(participantIDs))
+	return nil
+}
@@ -0,0 +1,105 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestSplitSessionMovesClients(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	hub.SetMessageHandler(mh.HandleMessage)
+	go hub.Run()
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+	bobClient := newTestClient(hub, sess.ID, bob.ID)
+
+	hub.Schedule(func() {
+		mh.HandleMessage(host, &Message{
+			Type: "split_session",
+			Data: map[string]interface{}{"participantIds": []interface{}{bob.ID}},
+		})
+	})
+
+	reply := nextMessage(t, bobClient)
+	if reply.Type != "session_split" || reply.Data["isHost"] != true {
+		t.Fatalf("Expected session_split making Bob host, got %+v", reply)
+	}
+	code, _ := reply.Data["sessionCode"].(string)
+	split, err := manager.GetSessionByCode(code)
+	if err != nil {
+		t.Fatalf("Expected the new session to exist: %v", err)
+	}
+	if bobClient.sessionID != split.ID {
+		t.Error("Expected Bob's client to move to the new session")
+	}
+
+	for _, client := range []*Client{host, aliceClient} {
+		reply := nextMessage(t, client)
+		if reply.Type != "participants_split" || reply.Data["newSessionCode"] != split.Code {
+			t.Errorf("Expected participants_split for the remaining circle, got %+v", reply)
+		}
+	}
+}
+
+func TestSplitSessionHostOnly(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.HandleMessage(aliceClient, &Message{
+		Type: "split_session",
+		Data: map[string]interface{}{"participantIds": []interface{}{alice.ID}},
+	})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected non-hosts to be refused, got %+v", reply)
+	}
+	if len(sess.Participants) != 2 {
+		t.Error("Expected the session to be untouched")
+	}
+}
+
+func TestRejoinAfterSplit(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	carol, _ := sess.AddParticipant("Carol")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	bobClient := newTestClient(hub, sess.ID, bob.ID)
+	newTestClient(hub, sess.ID, carol.ID)
+
+	mh.HandleMessage(host, &Message{
+		Type: "split_session",
+		Data: map[string]interface{}{"participantIds": []interface{}{bob.ID, carol.ID}, "hostId": carol.ID},
+	})
+	split := nextMessage(t, bobClient)
+	code, _ := split.Data["sessionCode"].(string)
+	token, _ := split.Data["rejoinToken"].(string)
+	if split.Type != "session_split" || token == "" {
+		t.Fatalf("Expected session_split with a rejoin token, got %+v", split)
+	}
+
+	// Bob's browser refreshes straight after the split
+	disconnect(mh, hub, bobClient)
+
+	refreshed := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(refreshed, &Message{Type: "rejoin_session", Data: map[string]interface{}{"sessionCode": code, "rejoinToken": token}})
+	if rejoined := nextMessage(t, refreshed); rejoined.Type != "session_rejoined" || rejoined.Data["userId"] != bob.ID || rejoined.Data["sessionCode"] != code {
+		t.Errorf("Expected Bob to rejoin the circle he was moved to, got %+v", rejoined)
+	}
+}
@@ -0,0 +1,20 @@
+// ABOUTME: Adds sentence starters to writing-phase payloads for participants facing a blank note
+// ABOUTME: Each session gets the set configured for the tenant it was created under
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/session"
+	"github.com/cassiascheffer/uplift/internal/starters"
+)
+
+// SetStarters sets where sentence starters come from (nil = built-in defaults)
+func (mh *MessageHandler) SetStarters(store *starters.Store) {
+	mh.starters = store
+}
+
+// addStarters includes the session's sentence starters in a payload entering writing
+func (mh *MessageHandler) addStarters(sess *session.Session, data map[string]interface{}) {
+	if sess.Phase == session.PhaseWriting {
+		data["sentenceStarters"] = mh.starters.For(sess.Tenant)
+	}
+}
@@ -0,0 +1,31 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+	"github.com/cassiascheffer/uplift/internal/starters"
+)
+
+func TestWritingPayloadIncludesTenantStarters(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	store, _ := starters.NewStore("")
+	store.Set("acme", []string{"Acme thanks you for…"})
+	mh.SetStarters(store)
+
+	sess := manager.CreateSession("Host")
+	sess.SetTenant("acme")
+	sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+
+	sess.TransitionToWriting()
+	mh.broadcastWritingStarted(sess)
+
+	changed := nextMessage(t, host)
+	got, _ := changed.Data["sentenceStarters"].([]interface{})
+	if len(got) != 1 || got[0] != "Acme thanks you for…" {
+		t.Errorf("Expected acme's sentence starters, got %v", changed.Data["sentenceStarters"])
+	}
+}
@@ -0,0 +1,16 @@
+// ABOUTME: Serves the session theme catalog so clients can offer themes when creating a circle
+// ABOUTME: Sessions carry their theme in phase payloads; this is only the list to choose from
+package websocket
+
+import "github.com/cassiascheffer/uplift/internal/session"
+
+// handleListThemes sends the catalog of themes a host may choose at creation
+// Needs no session, so it works before creating or joining one
+func (mh *MessageHandler) handleListThemes(client *Client) {
+	client.SendMessage(&Message{
+		Type: "themes",
+		Data: map[string]interface{}{
+			"themes": session.Themes,
+		},
+	})
+}
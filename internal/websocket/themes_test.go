@@ -0,0 +1,41 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestSessionThemes(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	client := &Client{send: make(chan []byte, 16), hub: hub}
+	mh.HandleMessage(client, &Message{Type: "list_themes"})
+	catalog := nextMessage(t, client)
+	themes, _ := catalog.Data["themes"].([]interface{})
+	if catalog.Type != "themes" || len(themes) != len(session.Themes) {
+		t.Fatalf("Expected the theme catalog, got %+v", catalog)
+	}
+
+	mh.HandleMessage(client, &Message{Type: "create_session", Data: map[string]interface{}{"userName": "Host", "theme": "no-such-theme"}})
+	if reply := nextMessage(t, client); reply.Type != "error" {
+		t.Errorf("Expected an unknown theme to be refused, got %+v", reply)
+	}
+
+	mh.HandleMessage(client, &Message{Type: "create_session", Data: map[string]interface{}{"userName": "Host", "theme": "year-end"}})
+	created := nextMessage(t, client)
+	if created.Type != "session_created" || created.Data["theme"] != "year-end" {
+		t.Fatalf("Expected session_created with the theme, got %+v", created)
+	}
+
+	// Phase broadcasts carry the theme so late-rendering clients can style themselves
+	sess, _ := manager.GetSessionByCode(created.Data["sessionCode"].(string))
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	sess.TransitionToWriting()
+	mh.broadcastWritingStarted(sess)
+	if changed := nextMessage(t, host); changed.Type != "phase_changed" || changed.Data["theme"] != "year-end" {
+		t.Errorf("Expected phase_changed with the theme, got %+v", changed)
+	}
+}
@@ -0,0 +1,24 @@
+// ABOUTME: Lets the host fetch the session timeline to review pacing
+// ABOUTME: Returns joins, transitions, draws and reads with timestamps and offsets
+package websocket
+
+// handleGetTimeline sends the host the session's timeline
+func (mh *MessageHandler) handleGetTimeline(client *Client) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if !sess.IsHostParticipant(client.userID) {
+		mh.sendError(client, "only host can view the timeline")
+		return
+	}
+
+	client.SendMessage(&Message{
+		Type: "timeline",
+		Data: map[string]interface{}{
+			"events": sess.Timeline(),
+		},
+	})
+}
@@ -0,0 +1,61 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestGetTimelineHostOnly(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.HandleMessage(aliceClient, &Message{Type: "get_timeline"})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected non-hosts to be refused, got %+v", reply)
+	}
+
+	mh.HandleMessage(host, &Message{Type: "get_timeline"})
+	reply := nextMessage(t, host)
+	if reply.Type != "timeline" {
+		t.Fatalf("Expected timeline, got %+v", reply)
+	}
+	if events, _ := reply.Data["events"].([]interface{}); len(events) != 2 {
+		t.Errorf("Expected creation and join events, got %v", reply.Data["events"])
+	}
+}
+
+func TestTimelineCountsSubmittedNotes(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	bob, _ := sess.AddParticipant("Bob")
+	sess.TransitionToWriting()
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.HandleMessage(aliceClient, &Message{Type: "submit_notes", Data: map[string]interface{}{
+		"notes": []interface{}{
+			map[string]interface{}{"recipientId": sess.HostID, "content": "Thanks for hosting"},
+			map[string]interface{}{"recipientId": bob.ID, "content": "Thanks for the review"},
+		},
+	}})
+	drain([]*Client{aliceClient})
+
+	timeline := sess.Timeline()
+	last := timeline[len(timeline)-1]
+	if last.Type != session.EventNotesSubmitted || last.Data["count"] != 2 || last.Data["total"] != 2 {
+		t.Fatalf("Expected two submitted notes on the timeline, got %+v", last)
+	}
+	if last.ParticipantID != "" {
+		t.Errorf("Expected the timeline not to say who wrote notes, got %q", last.ParticipantID)
+	}
+}
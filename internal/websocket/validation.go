@@ -1,30 +1,122 @@
 // ABOUTME: Input validation and sanitisation for WebSocket messages
-// ABOUTME: Prevents memory exhaustion and UI breaking from excessive input
+// ABOUTME: Prevents memory exhaustion and UI breaking from excessive input; lengths count characters, not bytes
 package websocket
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/cassiascheffer/uplift/internal/session"
 )
 
 const (
-	maxUserNameLength = 100
-	maxNoteLength     = 2000
-	maxParticipants   = 50
+	maxUserNameLength     = 100
+	maxRoleLength         = 50
+	maxSessionTitleLength = 100
+	maxWelcomeLength      = 1000
+	maxPromptLength       = 200
+	maxCountdownSeconds   = 10
+	minNoteLengthLimit    = 50
+	maxNoteLengthLimit    = 10000
+	maxMinNoteWords       = 100
+	minParticipants       = 2
+	maxParticipants       = 50 // Most any session may allow; hosts may choose fewer
+	maxWritingTimer       = 60 * 60
 )
 
 var (
-	ErrUserNameEmpty    = errors.New("user name cannot be empty")
-	ErrUserNameTooLong  = errors.New("user name too long (max 100 characters)")
-	ErrNoteEmpty        = errors.New("note content cannot be empty")
-	ErrNoteTooLong      = errors.New("note content too long (max 2000 characters)")
-	ErrTooManyParticipants = errors.New("session is full (max 50 participants)")
+	ErrUserNameEmpty       = errors.New("user name cannot be empty")
+	ErrUserNameTooLong     = errors.New("user name too long (max 100 characters)")
+	ErrRoleTooLong         = errors.New("role too long (max 50 characters)")
+	ErrSessionTitleTooLong = errors.New("session title too long (max 100 characters)")
+	ErrWelcomeTooLong      = errors.New("welcome message too long (max 1000 characters)")
+	ErrPromptTooLong       = errors.New("writing prompt too long (max 200 characters)")
+	ErrInvalidAutoStart    = errors.New("auto-start threshold must be at least 2 and within the participant limit")
+	ErrInvalidCountdown    = errors.New("countdown must be between 0 and 10 seconds")
+	ErrNoteEmpty           = errors.New("note content cannot be empty")
+	ErrNoteTooLong         = errors.New("note content too long")
+	ErrNoteTooShort        = errors.New("note content too short")
+	ErrInvalidMinNoteChars = errors.New("minimum note length must be less than the maximum note length")
+	ErrInvalidMinNoteWords = fmt.Errorf("minimum note words must be between 0 and %d", maxMinNoteWords)
+	ErrInvalidNoteLength   = fmt.Errorf("note length limit must be between %d and %d characters", minNoteLengthLimit, maxNoteLengthLimit)
+	ErrInvalidParticipants = fmt.Errorf("participant limit must be between %d and %d", minParticipants, maxParticipants)
+	ErrInvalidWritingTimer = fmt.Errorf("writing timer must be between 0 and %d seconds", maxWritingTimer)
 )
 
+// invisibleFormatting are format characters with no use in names or notes beyond hiding
+// text or reordering how it displays: zero-width spaces, word joiners, byte order marks
+// and bidirectional overrides. Zero-width joiners and non-joiners are kept, since emoji
+// sequences and several scripts depend on them
+var invisibleFormatting = map[rune]bool{
+	'\u200B': true, // Zero-width space
+	'\u2060': true, // Word joiner
+	'\uFEFF': true, // Zero-width no-break space (byte order mark)
+
+	// Bidirectional embeddings and overrides
+	'\u202A': true, '\u202B': true, '\u202C': true, '\u202D': true, '\u202E': true,
+
+	// Bidirectional isolates
+	'\u2066': true, '\u2067': true, '\u2068': true, '\u2069': true,
+}
+
+// cleanText normalises text to NFC, so the same characters always compare and count the
+// same, and removes control and invisible formatting characters before trimming whitespace
+// Multiline text keeps its line breaks and tabs; otherwise they become spaces. Runs of
+// zero-width joiners and non-joiners collapse to one, and none are kept beside whitespace
+func cleanText(text string, multiline bool) string {
+	text = norm.NFC.String(strings.ToValidUTF8(text, ""))
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	cleaned := make([]rune, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\t':
+			if !multiline {
+				r = ' '
+			}
+		case unicode.IsControl(r) || invisibleFormatting[r]:
+			continue
+		}
+
+		if isJoiner(r) && (len(cleaned) == 0 || isJoiner(cleaned[len(cleaned)-1]) || unicode.IsSpace(cleaned[len(cleaned)-1])) {
+			continue
+		}
+		if unicode.IsSpace(r) {
+			cleaned = trimJoiners(cleaned)
+		}
+		cleaned = append(cleaned, r)
+	}
+	return strings.TrimSpace(string(trimJoiners(cleaned)))
+}
+
+// isJoiner reports whether r is a zero-width joiner or non-joiner
+func isJoiner(r rune) bool {
+	return r == '\u200C' || r == '\u200D'
+}
+
+// trimJoiners removes zero-width joiners and non-joiners from the end of text
+func trimJoiners(text []rune) []rune {
+	for len(text) > 0 && isJoiner(text[len(text)-1]) {
+		text = text[:len(text)-1]
+	}
+	return text
+}
+
+// characters counts the characters in text the way limits are stated: by code point,
+// so an accented letter, a CJK character or an emoji each count once
+func characters(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
 // validateUserName validates and sanitises a user name
 func validateUserName(name string) (string, error) {
-	// Trim whitespace
-	name = strings.TrimSpace(name)
+	// Normalise and trim whitespace
+	name = cleanText(name, false)
 
 	// Check if empty
 	if name == "" {
@@ -32,17 +124,88 @@ func validateUserName(name string) (string, error) {
 	}
 
 	// Check length
-	if len(name) > maxUserNameLength {
+	if characters(name) > maxUserNameLength {
 		return "", ErrUserNameTooLong
 	}
 
 	return name, nil
 }
 
-// validateNoteContent validates and sanitises note content
-func validateNoteContent(content string) (string, error) {
-	// Trim whitespace
-	content = strings.TrimSpace(content)
+// validateRole validates and sanitises the label shown beside a participant's name
+// An empty role is allowed and means none
+func validateRole(role string) (string, error) {
+	role = cleanText(role, false)
+
+	if characters(role) > maxRoleLength {
+		return "", ErrRoleTooLong
+	}
+
+	return role, nil
+}
+
+// validateSessionTitle validates and sanitises a session title
+// An empty title is allowed and clears any existing title
+func validateSessionTitle(title string) (string, error) {
+	// Normalise and trim whitespace
+	title = cleanText(title, false)
+
+	// Check length
+	if characters(title) > maxSessionTitleLength {
+		return "", ErrSessionTitleTooLong
+	}
+
+	return title, nil
+}
+
+// validateWelcome validates and sanitises a session welcome message
+// An empty welcome message is allowed
+func validateWelcome(welcome string) (string, error) {
+	// Normalise and trim whitespace
+	welcome = cleanText(welcome, true)
+
+	// Check length
+	if characters(welcome) > maxWelcomeLength {
+		return "", ErrWelcomeTooLong
+	}
+
+	return welcome, nil
+}
+
+// validatePrompt validates and sanitises a writing prompt
+// An empty prompt is allowed and clears any existing prompt
+func validatePrompt(prompt string) (string, error) {
+	// Normalise and trim whitespace
+	prompt = cleanText(prompt, false)
+
+	// Check length
+	if characters(prompt) > maxPromptLength {
+		return "", ErrPromptTooLong
+	}
+
+	return prompt, nil
+}
+
+// cleanCreateRequest validates the name, welcome and prompt of a create_session request and
+// replaces them with their cleaned forms, so moderation checks the text the session will show
+// An empty name is left for newSession to default
+func cleanCreateRequest(req *createSessionRequest) error {
+	var err error
+	if req.UserName != "" {
+		if req.UserName, err = validateUserName(req.UserName); err != nil {
+			return err
+		}
+	}
+	if req.Welcome, err = validateWelcome(req.Welcome); err != nil {
+		return err
+	}
+	req.Prompt, err = validatePrompt(req.Prompt)
+	return err
+}
+
+// validateNoteContent validates and sanitises note content against the session's length limit
+func validateNoteContent(content string, maxLength int) (string, error) {
+	// Normalise and trim whitespace
+	content = cleanText(content, true)
 
 	// Check if empty
 	if content == "" {
@@ -50,17 +213,108 @@ func validateNoteContent(content string) (string, error) {
 	}
 
 	// Check length
-	if len(content) > maxNoteLength {
-		return "", ErrNoteTooLong
+	if characters(content) > maxLength {
+		return "", fmt.Errorf("%w (max %d characters)", ErrNoteTooLong, maxLength)
 	}
 
 	return content, nil
 }
 
-// checkParticipantLimit checks if session has reached max participants
-func checkParticipantLimit(currentCount int) error {
-	if currentCount >= maxParticipants {
-		return ErrTooManyParticipants
+// validateAutoStartAt validates an auto-start participant threshold against the session's participant limit
+// A threshold of 0 disables auto-start
+func validateAutoStartAt(threshold, limit int) (int, error) {
+	if threshold == 0 {
+		return 0, nil
+	}
+
+	if threshold < 2 || threshold > limit {
+		return 0, ErrInvalidAutoStart
+	}
+
+	return threshold, nil
+}
+
+// checkNoteMinimum checks note content meets the session's minimum character and word counts
+// A minimum of 0 disables that check
+func checkNoteMinimum(content string, minChars, minWords int) error {
+	if minChars > 0 && characters(content) < minChars {
+		return fmt.Errorf("%w (min %d characters)", ErrNoteTooShort, minChars)
+	}
+
+	if minWords > 0 && len(strings.Fields(content)) < minWords {
+		return fmt.Errorf("%w (min %d words)", ErrNoteTooShort, minWords)
 	}
+
 	return nil
 }
+
+// validateMinNoteLength validates per-session minimum note character and word counts
+func validateMinNoteLength(chars, words, maxLength int) (int, int, error) {
+	if chars < 0 || chars >= maxLength {
+		return 0, 0, ErrInvalidMinNoteChars
+	}
+
+	if words < 0 || words > maxMinNoteWords {
+		return 0, 0, ErrInvalidMinNoteWords
+	}
+
+	return chars, words, nil
+}
+
+// validateMaxNoteLength validates a per-session note length limit
+// A limit of 0 selects the default
+func validateMaxNoteLength(length int) (int, error) {
+	if length == 0 {
+		return session.DefaultMaxNoteLength, nil
+	}
+
+	if length < minNoteLengthLimit || length > maxNoteLengthLimit {
+		return 0, ErrInvalidNoteLength
+	}
+
+	return length, nil
+}
+
+// validateCountdown validates the number of seconds to count down before phase transitions
+func validateCountdown(seconds int) (int, error) {
+	if seconds < 0 || seconds > maxCountdownSeconds {
+		return 0, ErrInvalidCountdown
+	}
+
+	return seconds, nil
+}
+
+// validateSettings validates the settings a host chose, filling in defaults for unset values
+// maxNoteLength is create_session's older top-level limit, used when the settings don't set one
+func validateSettings(settings session.SessionSettings, maxNoteLength int) (session.SessionSettings, error) {
+	if settings.MaxParticipants != 0 && (settings.MaxParticipants < minParticipants || settings.MaxParticipants > maxParticipants) {
+		return session.SessionSettings{}, ErrInvalidParticipants
+	}
+
+	if settings.MaxNoteLength == 0 {
+		settings.MaxNoteLength = maxNoteLength
+	}
+	length, err := validateMaxNoteLength(settings.MaxNoteLength)
+	if err != nil {
+		return session.SessionSettings{}, err
+	}
+	settings.MaxNoteLength = length
+
+	anonymity, err := session.ParseAnonymityMode(string(settings.Anonymity))
+	if err != nil {
+		return session.SessionSettings{}, err
+	}
+	settings.Anonymity = anonymity
+
+	duplicateNames, err := session.ParseDuplicateNameMode(string(settings.DuplicateNames))
+	if err != nil {
+		return session.SessionSettings{}, err
+	}
+	settings.DuplicateNames = duplicateNames
+
+	if settings.WritingTimer < 0 || settings.WritingTimer > maxWritingTimer {
+		return session.SessionSettings{}, ErrInvalidWritingTimer
+	}
+
+	return settings.WithDefaults(), nil
+}
@@ -0,0 +1,72 @@
+package websocket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLengthLimitsCountCharacters(t *testing.T) {
+	// 100 CJK characters are 300 bytes, and 100 emoji 400
+	for _, name := range []string{strings.Repeat("名", maxUserNameLength), strings.Repeat("🎉", maxUserNameLength)} {
+		if _, err := validateUserName(name); err != nil {
+			t.Errorf("Expected a %d-character name to be allowed, got %v", maxUserNameLength, err)
+		}
+	}
+	if _, err := validateUserName(strings.Repeat("名", maxUserNameLength+1)); !errors.Is(err, ErrUserNameTooLong) {
+		t.Errorf("Expected a name over the limit to be refused, got %v", err)
+	}
+
+	note := strings.Repeat("ありがとう", 20)
+	if _, err := validateNoteContent(note, 100); err != nil {
+		t.Errorf("Expected a 100-character note to fit a 100-character limit, got %v", err)
+	}
+	if _, err := validateNoteContent(note+"!", 100); !errors.Is(err, ErrNoteTooLong) {
+		t.Errorf("Expected a 101-character note to be refused, got %v", err)
+	}
+
+	if err := checkNoteMinimum("ありがとう", 5, 0); err != nil {
+		t.Errorf("Expected five characters to meet a five-character minimum, got %v", err)
+	}
+}
+
+func TestTextIsNormalised(t *testing.T) {
+	// "Zoe\u0308" typed with a combining diaeresis is stored precomposed
+	name, err := validateUserName("Zoë")
+	if err != nil || name != "Zoë" {
+		t.Errorf("Expected the NFC form of the name, got %q err=%v", name, err)
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		multiline bool
+		expected  string
+	}{
+		{"control characters", "Ann\x00\x07a\x1b", false, "Anna"},
+		{"line breaks in a name", "Ann\r\nLee", false, "Ann Lee"},
+		{"line breaks in a note", "Thank you\r\n\tfor everything", true, "Thank you\n\tfor everything"},
+		{"zero-width spaces", "\u200BA\u200Bl\u2060ex\uFEFF", false, "Alex"},
+		{"bidirectional overrides", "Sam\u202Egnp.exe", false, "Samgnp.exe"},
+		{"emoji sequences", "👩\u200D💻 team", false, "👩\u200D💻 team"},
+		{"runs of joiners", "a\u200D\u200D\u200Db", false, "a\u200Db"},
+		{"joiners beside spaces", "\u200Da \u200Db\u200C \u200C", false, "a b"},
+		{"invalid UTF-8", "Ri\xffley", false, "Riley"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := cleanText(test.input, test.multiline); got != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestInvisibleNamesAreEmpty(t *testing.T) {
+	if _, err := validateUserName("\u200B\u200D\u202E "); !errors.Is(err, ErrUserNameEmpty) {
+		t.Errorf("Expected a name of only invisible characters to be refused, got %v", err)
+	}
+	if _, err := validateNoteContent("\u200B\n\u2060", 100); !errors.Is(err, ErrNoteEmpty) {
+		t.Errorf("Expected a note of only invisible characters to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+// ABOUTME: Refuses phase and turn actions sent against an out-of-date view of the session
+// ABOUTME: Clients opt in by echoing the version from the last phase or turn broadcast they saw
+package websocket
+
+import (
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// checkVersion reports whether an action may go ahead; if the client sent a
+// version and the session has moved on since, it gets a version_conflict error
+func (mh *MessageHandler) checkVersion(client *Client, sess *session.Session, req versioned) bool {
+	if req.Version == nil {
+		return true // Older clients don't send a version
+	}
+
+	if err := sess.CheckVersion(*req.Version); err != nil {
+		client.logger().Info("Stale action refused", "sent", *req.Version, "current", sess.Version)
+		mh.sendErrorCode(client, "version_conflict", err.Error())
+		return false
+	}
+	return true
+}
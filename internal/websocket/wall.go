@@ -0,0 +1,104 @@
+// ABOUTME: Host commands to publish a completed circle's shared notes to a public gratitude wall
+// ABOUTME: Only notes whose authors opted in when writing can be published, and never with names
+package websocket
+
+import (
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+	"github.com/cassiascheffer/uplift/internal/wall"
+)
+
+// SetWalls sets where published gratitude walls are kept (nil = publishing disabled)
+func (mh *MessageHandler) SetWalls(store *wall.Store) {
+	mh.walls = store
+}
+
+// handlePublishWall publishes shareable notes from a completed session (host only)
+// noteIds selects which to publish (default all shareable notes); expiresInDays sets how long the wall stays up
+func (mh *MessageHandler) handlePublishWall(client *Client, req *publishWallRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if client.userID != sess.HostID {
+		client.logger().Warn("Non-host tried to publish a wall", "hostID", sess.HostID)
+		mh.sendError(client, "only host can publish a gratitude wall")
+		return
+	}
+
+	if sess.Phase != session.PhaseComplete {
+		mh.sendError(client, "a gratitude wall can only be published after the circle is complete")
+		return
+	}
+
+	shareable := make(map[string]*session.Note)
+	for _, note := range sess.GetShareableNotes() {
+		shareable[note.ID] = note
+	}
+
+	var selected []*session.Note
+	if req.NoteIDs != nil {
+		for _, id := range req.NoteIDs {
+			note, exists := shareable[id]
+			if !exists {
+				mh.sendError(client, "only notes their authors agreed to share can be published")
+				return
+			}
+			selected = append(selected, note)
+		}
+	} else {
+		selected = sess.GetShareableNotes()
+	}
+
+	notes := make([]wall.Note, 0, len(selected))
+	for _, note := range selected {
+		notes = append(notes, wall.Note{Content: note.Content, GIFURL: note.GIFURL})
+	}
+
+	published, err := mh.walls.Publish(sess.ID, sess.Title, notes, time.Duration(req.ExpiresInDays*24)*time.Hour)
+	if err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	client.SendMessage(&Message{
+		Type: "wall_published",
+		Data: map[string]interface{}{
+			"token":     published.Token,
+			"url":       published.URL(),
+			"notes":     len(published.Notes),
+			"expiresAt": published.ExpiresAt,
+		},
+	})
+	sessionLogger(sess).Info("Gratitude wall published", "notes", len(published.Notes))
+}
+
+// handleUnpublishWall takes down a wall the session published (host only)
+func (mh *MessageHandler) handleUnpublishWall(client *Client, req *unpublishWallRequest) {
+	sess, err := mh.sessionManager.GetSessionByID(client.sessionID)
+	if err != nil {
+		mh.sendError(client, "session not found")
+		return
+	}
+
+	if client.userID != sess.HostID {
+		mh.sendError(client, "only host can remove a gratitude wall")
+		return
+	}
+
+	token := req.Token
+	if err := mh.walls.Remove(sess.ID, token); err != nil {
+		mh.sendError(client, err.Error())
+		return
+	}
+
+	client.SendMessage(&Message{
+		Type: "wall_removed",
+		Data: map[string]interface{}{
+			"token": token,
+		},
+	})
+}
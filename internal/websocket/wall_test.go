@@ -0,0 +1,78 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+	"github.com/cassiascheffer/uplift/internal/wall"
+)
+
+func TestPublishWall(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+	walls := wall.NewStore()
+	mh.SetWalls(walls)
+
+	sess := manager.CreateSession("Host")
+	sess.SetMaxNoteLength(500)
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	// Only the note Alice opts in can be published
+	mh.HandleMessage(aliceClient, &Message{Type: "submit_notes", Data: map[string]interface{}{
+		"notes": []interface{}{
+			map[string]interface{}{"recipientId": sess.HostID, "content": "Thanks for hosting us every week", "shareable": true},
+		},
+	}})
+	mh.HandleMessage(host, &Message{Type: "submit_notes", Data: map[string]interface{}{
+		"notes": []interface{}{
+			map[string]interface{}{"recipientId": alice.ID, "content": "Your demo saved the quarter"},
+		},
+	}})
+	for len(host.send) > 0 || len(aliceClient.send) > 0 {
+		select {
+		case <-host.send:
+		case <-aliceClient.send:
+		}
+	}
+	sess.Phase = session.PhaseComplete
+
+	var private string
+	for _, note := range sess.Notes {
+		if !note.Shareable {
+			private = note.ID
+		}
+	}
+
+	mh.HandleMessage(aliceClient, &Message{Type: "publish_wall"})
+	if reply := nextMessage(t, aliceClient); reply.Type != "error" {
+		t.Errorf("Expected a participant to be refused, got %+v", reply)
+	}
+
+	mh.HandleMessage(host, &Message{Type: "publish_wall", Data: map[string]interface{}{"noteIds": []interface{}{private}}})
+	if reply := nextMessage(t, host); reply.Type != "error" {
+		t.Errorf("Expected a note without consent to be refused, got %+v", reply)
+	}
+
+	mh.HandleMessage(host, &Message{Type: "publish_wall", Data: map[string]interface{}{"expiresInDays": float64(3)}})
+	published := nextMessage(t, host)
+	token, _ := published.Data["token"].(string)
+	if published.Type != "wall_published" || published.Data["notes"] != float64(1) {
+		t.Fatalf("Expected one note published, got %+v", published)
+	}
+	if url, _ := published.Data["url"].(string); !strings.HasSuffix(url, token) {
+		t.Errorf("Expected the wall URL to carry its token, got %q", url)
+	}
+	if w, ok := walls.Get(token); !ok || w.Notes[0].Content != "Thanks for hosting us every week" {
+		t.Errorf("Expected the shared note on the wall, got %+v", w)
+	}
+
+	mh.HandleMessage(host, &Message{Type: "unpublish_wall", Data: map[string]interface{}{"token": token}})
+	if reply := nextMessage(t, host); reply.Type != "wall_removed" {
+		t.Errorf("Expected wall_removed, got %+v", reply)
+	}
+}
@@ -0,0 +1,64 @@
+// ABOUTME: Streams the host aggregate writing progress during the writing phase
+// ABOUTME: Updates are throttled server-side and never include note content
+package websocket
+
+import (
+	"time"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+// writingStatsInterval is the most often the host is sent writing stats
+const writingStatsInterval = 2 * time.Second
+
+// scheduleWritingStats sends the host writing stats within writingStatsInterval,
+// coalescing any submissions that arrive in the meantime into one update
+// Runs on the session's actor
+func (mh *MessageHandler) scheduleWritingStats(sess *session.Session) {
+	if mh.writingStatsPending.get(sess.ID) {
+		return
+	}
+	mh.writingStatsPending.set(sess.ID, true)
+
+	time.AfterFunc(writingStatsInterval, func() {
+		mh.hub.ScheduleSession(sess.ID, func() {
+			mh.writingStatsPending.delete(sess.ID)
+			mh.sendWritingStats(sess)
+		})
+	})
+}
+
+// sendWritingStats sends the host notes submitted per participant, average note length
+// and time since the last submission
+func (mh *MessageHandler) sendWritingStats(sess *session.Session) {
+	if sess.Phase != session.PhaseWriting {
+		return
+	}
+
+	stats := sess.GetWritingStats()
+	now := time.Now()
+
+	authors := make([]map[string]interface{}, 0, len(stats.Authors))
+	for _, author := range stats.Authors {
+		entry := map[string]interface{}{
+			"participantId":  author.ParticipantID,
+			"notesSubmitted": author.NotesSubmitted,
+		}
+		if author.LastSubmittedAt != nil {
+			entry["secondsSinceLastSubmission"] = int(now.Sub(*author.LastSubmittedAt).Seconds())
+		}
+		authors = append(authors, entry)
+	}
+
+	data := map[string]interface{}{
+		"authors":       authors,
+		"totalNotes":    stats.TotalNotes,
+		"expectedNotes": stats.ExpectedNotes,
+		"averageLength": stats.AverageLength,
+	}
+	if stats.LastSubmittedAt != nil {
+		data["secondsSinceLastSubmission"] = int(now.Sub(*stats.LastSubmittedAt).Seconds())
+	}
+
+	mh.hub.SendToUser(sess.ID, sess.HostID, &Message{Type: "writing_stats", Data: data})
+}
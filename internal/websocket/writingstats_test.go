@@ -0,0 +1,46 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cassiascheffer/uplift/internal/session"
+)
+
+func TestWritingStatsForHost(t *testing.T) {
+	hub := NewHub(nil)
+	manager := session.NewManager()
+	mh := NewMessageHandler(hub, manager)
+
+	sess := manager.CreateSession("Host")
+	alice, _ := sess.AddParticipant("Alice")
+	sess.TransitionToWriting()
+	sess.AddNote(alice.ID, sess.HostID, "A secret thank you")
+
+	host := newTestClient(hub, sess.ID, sess.HostID)
+	aliceClient := newTestClient(hub, sess.ID, alice.ID)
+
+	mh.sendWritingStats(sess)
+	reply := nextMessage(t, host)
+	if reply.Type != "writing_stats" || reply.Data["totalNotes"] != float64(1) || reply.Data["expectedNotes"] != float64(2) {
+		t.Fatalf("Expected writing_stats for the host, got %+v", reply)
+	}
+	if _, ok := reply.Data["secondsSinceLastSubmission"]; !ok {
+		t.Error("Expected time since last submission")
+	}
+	if raw, _ := encodeMessage(&reply); strings.Contains(string(raw), "secret") {
+		t.Error("Expected no note content in writing stats")
+	}
+	select {
+	case <-aliceClient.send:
+		t.Error("Expected only the host to receive writing stats")
+	default:
+	}
+
+	// Submissions arriving while an update is pending share it
+	mh.scheduleWritingStats(sess)
+	mh.scheduleWritingStats(sess)
+	if !mh.writingStatsPending.get(sess.ID) {
+		t.Error("Expected an update to be pending")
+	}
+}
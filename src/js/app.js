@@ -32,6 +32,8 @@ function uplift() {
     connected: false,
     isConnecting: false,
     reconnectAttempts: 0,
+    totalReconnects: 0,
+    rejoinToken: null,
     maxReconnectDelay: TIMING.MAX_RECONNECT_DELAY,
 
     // ============================================================
@@ -48,6 +50,7 @@ function uplift() {
     myId: null,
     userName: '',
     joinCode: '',
+    joinInvite: '', // Signed token from an invite link, sent with join_session
     selectedAction: null, // 'create' or 'join'
 
     // ============================================================
@@ -103,7 +106,46 @@ function uplift() {
       this.loadTheme();
       this.setupBeforeUnload();
       checkForDevMode(this);
-      this.checkForSessionCodeInURL();
+      if (!this.checkForRejoin()) {
+        this.checkForSessionCodeInURL();
+      }
+    },
+
+    // ============================================================
+    // REJOINING
+    // ============================================================
+    // Reclaim our place after a page refresh using the token saved for this tab
+    checkForRejoin() {
+      const saved = JSON.parse(sessionStorage.getItem('uplift.rejoin') || 'null');
+      if (!saved) {
+        return false;
+      }
+      this.rejoinToken = saved.rejoinToken;
+      this.connectWebSocket(() => this.sendRejoin(saved.sessionCode));
+      return true;
+    },
+
+    sendRejoin(sessionCode) {
+      this.send({
+        type: 'rejoin_session',
+        data: {
+          sessionCode: sessionCode,
+          rejoinToken: this.rejoinToken
+        }
+      });
+    },
+
+    rememberRejoin(data) {
+      this.rejoinToken = data.rejoinToken;
+      sessionStorage.setItem('uplift.rejoin', JSON.stringify({
+        sessionCode: data.sessionCode,
+        rejoinToken: data.rejoinToken
+      }));
+    },
+
+    forgetRejoin() {
+      this.rejoinToken = null;
+      sessionStorage.removeItem('uplift.rejoin');
     },
 
     // ============================================================
@@ -111,6 +153,12 @@ function uplift() {
     // ============================================================
     checkForSessionCodeInURL() {
       const urlParams = new URLSearchParams(window.location.search);
+      const inviteFromURL = urlParams.get('invite');
+      if (inviteFromURL && this.applyInvite(inviteFromURL)) {
+        this.validateSessionCode(this.joinCode);
+        return;
+      }
+
       const codeFromURL = urlParams.get('code');
       if (codeFromURL) {
         this.joinCode = codeFromURL.toUpperCase();
@@ -122,6 +170,28 @@ function uplift() {
       }
     },
 
+    // Fills in the join form from an invite link; the server checks its signature on joining
+    applyInvite(token) {
+      try {
+        const payload = token.split('.')[0].replace(/-/g, '+').replace(/_/g, '/');
+        const bytes = Uint8Array.from(atob(payload), (c) => c.charCodeAt(0));
+        const invite = JSON.parse(new TextDecoder().decode(bytes));
+        this.joinInvite = token;
+        this.joinCode = invite.code;
+        if (invite.name) {
+          this.userName = invite.name;
+        }
+        this.fromDirectLink = true;
+        console.log('Pre-filled join code from invite:', this.joinCode);
+        return true;
+      } catch (err) {
+        console.error('Unreadable invite link:', err);
+        this.showNotification('Invalid invite link', 'error');
+        this.clearSessionCodeFromURL();
+        return false;
+      }
+    },
+
     validateSessionCode(code) {
       // Connect to WebSocket temporarily to validate the code
       this.connectWebSocket(() => {
@@ -137,6 +207,7 @@ function uplift() {
     clearSessionCodeFromURL() {
       const url = new URL(window.location);
       url.searchParams.delete('code');
+      url.searchParams.delete('invite');
       window.history.replaceState({}, '', url);
     },
 
@@ -171,7 +242,9 @@ function uplift() {
     // ============================================================
     connectWebSocket(onConnected) {
       const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-      const wsUrl = `${protocol}//${window.location.host}/ws`;
+      // Message format this frontend was built for; the server translates older versions
+      // Reconnects are reported so diagnostics can show how flaky the connection has been
+      const wsUrl = `${protocol}//${window.location.host}/ws?protocol=1&reconnects=${this.totalReconnects}`;
 
       console.log('Attempting WebSocket connection to:', wsUrl);
       this.isConnecting = true;
@@ -182,8 +255,19 @@ function uplift() {
         this.connected = true;
         this.isConnecting = false;
 
+        // Report which build this is so the server can ask us to refresh after a deploy
+        if (__ASSET_VERSION__) {
+          this.ws.send(JSON.stringify({ type: 'hello', data: { build: __ASSET_VERSION__ } }));
+        }
+
+        // Take our place back rather than joining as someone new
+        if (this.reconnectAttempts > 0 && this.sessionCode && this.rejoinToken) {
+          this.sendRejoin(this.sessionCode);
+        }
+
         // Show reconnected message if this was a reconnection
         if (this.reconnectAttempts > 0) {
+          this.totalReconnects++;
           this.showNotification('Reconnected successfully!');
           this.reconnectAttempts = 0;
         }
@@ -255,6 +339,7 @@ function uplift() {
           this.isHost = true;
           this.participants = message.data.participants;
           this.currentView = 'lobby';
+          this.rememberRejoin(message.data);
           break;
 
         case 'session_joined':
@@ -262,6 +347,24 @@ function uplift() {
           this.myId = message.data.userId;
           this.participants = message.data.participants;
           this.currentView = 'lobby';
+          this.rememberRejoin(message.data);
+          break;
+
+        case 'session_rejoined':
+          this.sessionCode = message.data.sessionCode;
+          this.myId = message.data.userId;
+          this.isHost = message.data.isHost;
+          this.participants = message.data.participants;
+          this.rememberRejoin(message.data);
+          if (message.data.phase === 'JOINING') {
+            this.currentView = 'lobby';
+          } else {
+            this.handlePhaseChange(message.data);
+          }
+          // Notes already submitted before the refresh stay submitted
+          if (message.data.phase === 'WRITING') {
+            this.hasSubmittedNotes = message.data.notesWrittenTo.length >= message.data.totalNotesNeeded;
+          }
           break;
 
         case 'participant_joined':
@@ -304,6 +407,7 @@ function uplift() {
           break;
 
         case 'kicked':
+          this.forgetRejoin();
           this.showNotification(message.data.message, 'error');
           // Close WebSocket and return to home
           if (this.ws) {
@@ -375,7 +479,19 @@ function uplift() {
           this.announceToScreenReader('Session complete! All notes have been read.');
           break;
 
+        case 'client_outdated':
+          // The server waits for a moment when refreshing won't interrupt the circle
+          this.showNotification(message.data.message);
+          break;
+
         case 'error':
+          if (message.data.code === 'rejoin_failed') {
+            this.forgetRejoin();
+          }
+          if (message.data.code === 'invite_invalid' || message.data.code === 'invite_expired') {
+            this.joinInvite = '';
+            this.clearSessionCodeFromURL();
+          }
           this.showNotification(message.data.message, 'error');
           // If error is related to session joining (e.g., "Session not found")
           // clear the join code and URL parameter
@@ -499,7 +615,8 @@ function uplift() {
           type: 'join_session',
           data: {
             sessionCode: this.joinCode.toUpperCase(),
-            userName: this.userName
+            userName: this.userName,
+            invite: this.joinInvite || undefined
           }
         });
         return;
@@ -511,7 +628,8 @@ function uplift() {
           type: 'join_session',
           data: {
             sessionCode: this.joinCode.toUpperCase(),
-            userName: this.userName
+            userName: this.userName,
+            invite: this.joinInvite || undefined
           }
         });
       });
@@ -636,6 +754,7 @@ function uplift() {
       this.receivedNotes = [];
       this.selectedAction = null;
       this.joinCode = '';
+      this.joinInvite = '';
       this.fromDirectLink = false;
 
       // Clear URL parameters
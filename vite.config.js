@@ -7,6 +7,10 @@ export default defineConfig({
   plugins: [
     tailwindcss()
   ],
+  // Reported to the server in hello so it can spot clients running an earlier deploy
+  define: {
+    __ASSET_VERSION__: JSON.stringify(process.env.ASSET_VERSION || ''),
+  },
   root: 'src',
   publicDir: '../static',
   build: {